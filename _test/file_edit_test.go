@@ -1,6 +1,7 @@
 package filesystemserver_test
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
@@ -30,7 +31,7 @@ func TestFileEdits(t *testing.T) {
 			content:  "This is line 1\nThis is line 2\n    This has indentation\nSome text with     multiple   spaces\nLast line",
 			oldText:  "multiple   spaces",
 			newText:  "single space",
-			expected: "This is line 1\nThis is line 2\n    This has indentation\nSome text with single space\nLast line",
+			expected: "This is line 1\nThis is line 2\n    This has indentation\nSome text with     single space\nLast line",
 		},
 		{
 			name:     "full line with indent",
@@ -48,9 +49,9 @@ func TestFileEdits(t *testing.T) {
 		},
 	}
 
-	// Crear handler
-	allowedDirs := []string{"."}
-	handler, err := filesystemserver.NewFilesystemHandler(allowedDirs)
+	// Crear handler sobre un directorio temporal
+	dir := t.TempDir()
+	handler, err := filesystemserver.NewFilesystemHandler([]string{dir})
 	if err != nil {
 		t.Fatalf("Failed to create handler: %v", err)
 	}
@@ -58,11 +59,11 @@ func TestFileEdits(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			// Crear archivo temporal
-			tmpFile, err := os.CreateTemp("", "testfile-*.txt")
+			tmpFile, err := os.CreateTemp(dir, "testfile-*.txt")
 			if err != nil {
 				t.Fatalf("Failed to create temp file: %v", err)
 			}
-			defer os.Remove(tmpFile.Name())
+			tmpFile.Close()
 
 			// Escribir contenido de prueba
 			if err := os.WriteFile(tmpFile.Name(), []byte(tc.content), 0644); err != nil {
@@ -70,20 +71,22 @@ func TestFileEdits(t *testing.T) {
 			}
 
 			// Crear solicitud
-			req := mcp.CallToolRequest{
-				Tool: "edit_file",
-				Arguments: map[string]interface{}{
-					"path":     tmpFile.Name(),
-					"old_text": tc.oldText,
-					"new_text": tc.newText,
-				},
+			req := mcp.CallToolRequest{}
+			req.Params.Name = "edit_file"
+			req.Params.Arguments = map[string]interface{}{
+				"path":     tmpFile.Name(),
+				"old_text": tc.oldText,
+				"new_text": tc.newText,
 			}
 
 			// Ejecutar la edición
-			_, err = handler.HandleEditFile(nil, req)
+			res, err := handler.HandleEditFile(context.Background(), req)
 			if err != nil {
 				t.Fatalf("Edit failed: %v", err)
 			}
+			if res.IsError {
+				t.Fatalf("Edit failed: %v", res.Content)
+			}
 
 			// Leer el resultado
 			result, err := os.ReadFile(tmpFile.Name())
@@ -100,13 +103,13 @@ func TestFileEdits(t *testing.T) {
 func TestMain(m *testing.M) {
 	// Código de configuración previa a las pruebas
 	fmt.Println("Setting up tests...")
-	
+
 	// Ejecutar las pruebas
 	exitCode := m.Run()
-	
+
 	// Código de limpieza posterior a las pruebas
 	fmt.Println("Tests completed")
-	
+
 	// Salir con el código de salida de las pruebas
 	os.Exit(exitCode)
 }
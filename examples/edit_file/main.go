@@ -0,0 +1,64 @@
+// Command edit_file embeds the filesystem handler in a Go program and edits a
+// file through its exported API, without running an MCP server.
+//
+//	go run ./examples/edit_file
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/scopweb/mcp-filesystem-server/filesystemserver"
+)
+
+func main() {
+	dir, err := os.MkdirTemp("", "edit-file-example-")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	handler, err := filesystemserver.NewFilesystemHandler([]string{dir})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+	path := filepath.Join(dir, "greeting.txt")
+	call(ctx, handler.HandleWriteFile, "write_file", map[string]interface{}{
+		"path":    path,
+		"content": "Hello, world!\n",
+	})
+	call(ctx, handler.HandleEditFile, "edit_file", map[string]interface{}{
+		"path":     path,
+		"old_text": "world",
+		"new_text": "gophers",
+	})
+	fmt.Println(call(ctx, handler.HandleReadFile, "read_file", map[string]interface{}{"path": path}))
+}
+
+// call runs one tool and returns the text of its result, stopping the program
+// if the tool reports an error
+func call(ctx context.Context, tool func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), name string, args map[string]interface{}) string {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = args
+	result, err := tool(ctx, request)
+	if err != nil {
+		log.Fatalf("%s: %v", name, err)
+	}
+	text := ""
+	if len(result.Content) > 0 {
+		if content, ok := result.Content[0].(mcp.TextContent); ok {
+			text = content.Text
+		}
+	}
+	if result.IsError {
+		log.Fatalf("%s: %s", name, text)
+	}
+	return text
+}
@@ -0,0 +1,210 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Typed access to tool arguments. A bare type assertion treats a value of the
+// wrong type as if it were absent, so depth: "3" silently became the default;
+// these helpers tell the caller instead ("depth must be a number, got
+// string"). JSON null counts as absent. Type names come from jsonTypeName.
+
+// argTypeError is the error for an argument of the wrong type
+func argTypeError(name, want string, value interface{}) error {
+	article := "a"
+	if strings.IndexByte("aeiou", want[0]) >= 0 {
+		article = "an"
+	}
+	return fmt.Errorf("%s must be %s %s, got %s", name, article, want, jsonTypeName(value))
+}
+
+// getString returns the string argument name, or fallback when it is absent
+func getString(args map[string]interface{}, name, fallback string) (string, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return fallback, nil
+	case string:
+		return v, nil
+	default:
+		return "", argTypeError(name, "string", v)
+	}
+}
+
+// requireString returns the string argument name, which must be present
+func requireString(args map[string]interface{}, name string) (string, error) {
+	if args[name] == nil {
+		return "", fmt.Errorf("%s is required", name)
+	}
+	return getString(args, name, "")
+}
+
+// getBool returns the boolean argument name, or fallback when it is absent
+func getBool(args map[string]interface{}, name string, fallback bool) (bool, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return fallback, nil
+	case bool:
+		return v, nil
+	default:
+		return false, argTypeError(name, "boolean", v)
+	}
+}
+
+// getNumber returns the numeric argument name, or fallback when it is absent
+func getNumber(args map[string]interface{}, name string, fallback float64) (float64, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return fallback, nil
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	default:
+		return 0, argTypeError(name, "number", v)
+	}
+}
+
+// getInt returns the whole-number argument name, or fallback when it is absent
+func getInt(args map[string]interface{}, name string, fallback int) (int, error) {
+	if args[name] == nil {
+		return fallback, nil
+	}
+	v, err := getNumber(args, name, 0)
+	if err != nil {
+		return 0, err
+	}
+	if v != math.Trunc(v) || math.Abs(v) > math.MaxInt32 {
+		return 0, fmt.Errorf("%s must be a whole number, got %v", name, v)
+	}
+	return int(v), nil
+}
+
+// requireInt returns the whole-number argument name, which must be present
+func requireInt(args map[string]interface{}, name string) (int, error) {
+	if args[name] == nil {
+		return 0, fmt.Errorf("%s is required", name)
+	}
+	return getInt(args, name, 0)
+}
+
+// getStringSlice returns the array of strings name, nil when it is absent
+func getStringSlice(args map[string]interface{}, name string) ([]string, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s[%d] must be a string, got %s", name, i, jsonTypeName(item))
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	default:
+		return nil, argTypeError(name, "array", v)
+	}
+}
+
+// getNonEmptyStrings is getStringSlice without the empty strings, for lists
+// of patterns or names where "" means nothing
+func getNonEmptyStrings(args map[string]interface{}, name string) ([]string, error) {
+	items, err := getStringSlice(args, name)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, item := range items {
+		if item != "" {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+// requireStringSlice returns the array of strings name, which must be present
+// and not empty
+func requireStringSlice(args map[string]interface{}, name string) ([]string, error) {
+	values, err := getStringSlice(args, name)
+	if err == nil && len(values) == 0 {
+		err = fmt.Errorf("%s is required", name)
+	}
+	return values, err
+}
+
+// getArray returns the array argument name, whose items may have any type,
+// nil when it is absent
+func getArray(args map[string]interface{}, name string) ([]interface{}, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		return v, nil
+	default:
+		return nil, argTypeError(name, "array", v)
+	}
+}
+
+// getObject returns the object argument name, nil when it is absent
+func getObject(args map[string]interface{}, name string) (map[string]interface{}, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return v, nil
+	default:
+		return nil, argTypeError(name, "object", v)
+	}
+}
+
+// checkArgumentTypes compares each argument with the type its tool declares;
+// arguments the tool doesn't declare and null values are left alone
+func checkArgumentTypes(schema mcp.ToolInputSchema, args map[string]interface{}) error {
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := args[name]
+		property, _ := schema.Properties[name].(map[string]interface{})
+		want, _ := property["type"].(string)
+		if value == nil || want == "" {
+			continue
+		}
+		got := jsonTypeName(value)
+		if got == want || (want == "integer" && got == "number") {
+			if want == "integer" {
+				if _, err := getInt(args, name, 0); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		return argTypeError(name, want, value)
+	}
+	return nil
+}
+
+// withArgumentTypes rejects calls whose arguments don't match the tool's
+// declared types before handler runs, so no handler mistakes a wrong-typed
+// value for an absent one
+func withArgumentTypes(tool mcp.Tool, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := checkArgumentTypes(tool.InputSchema, request.Params.Arguments); err != nil {
+			return badArgError(err).result(), nil
+		}
+		return handler(ctx, request)
+	}
+}
@@ -0,0 +1,221 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArgumentHelpers(t *testing.T) {
+	args := map[string]interface{}{
+		"name":    "demo",
+		"depth":   float64(4),
+		"half":    2.5,
+		"flag":    true,
+		"text":    "3",
+		"quoted":  "true",
+		"list":    []interface{}{"a", "b"},
+		"mixed":   []interface{}{"a", float64(1)},
+		"empty":   []interface{}{},
+		"nothing": nil,
+	}
+
+	s, err := getString(args, "name", "x")
+	assert.NoError(t, err)
+	assert.Equal(t, "demo", s)
+	s, err = getString(args, "nothing", "fallback")
+	assert.NoError(t, err)
+	assert.Equal(t, "fallback", s)
+	_, err = getString(args, "depth", "")
+	assert.EqualError(t, err, "depth must be a string, got number")
+	_, err = requireString(args, "missing")
+	assert.EqualError(t, err, "missing is required")
+
+	n, err := getInt(args, "depth", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, n)
+	n, err = getInt(args, "missing", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	_, err = getInt(args, "text", 3)
+	assert.EqualError(t, err, "text must be a number, got string")
+	_, err = getInt(args, "half", 3)
+	assert.EqualError(t, err, "half must be a whole number, got 2.5")
+	_, err = requireInt(args, "nothing")
+	assert.EqualError(t, err, "nothing is required")
+
+	b, err := getBool(args, "flag", false)
+	assert.NoError(t, err)
+	assert.True(t, b)
+	_, err = getBool(args, "quoted", false)
+	assert.EqualError(t, err, "quoted must be a boolean, got string")
+
+	list, err := getStringSlice(args, "list")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, list)
+	_, err = getStringSlice(args, "mixed")
+	assert.EqualError(t, err, "mixed[1] must be a string, got number")
+	_, err = getStringSlice(args, "name")
+	assert.EqualError(t, err, "name must be an array, got string")
+	_, err = requireStringSlice(args, "empty")
+	assert.EqualError(t, err, "empty is required")
+
+	args["blanks"] = []interface{}{"a", "", "b"}
+	list, err = getNonEmptyStrings(args, "blanks")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, list)
+
+	items, err := getArray(args, "mixed")
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	_, err = getArray(args, "name")
+	assert.EqualError(t, err, "name must be an array, got string")
+
+	args["options"] = map[string]interface{}{"apply": true}
+	object, err := getObject(args, "options")
+	assert.NoError(t, err)
+	assert.Equal(t, true, object["apply"])
+	object, err = getObject(args, "nothing")
+	assert.NoError(t, err)
+	assert.Nil(t, object)
+	_, err = getObject(args, "list")
+	assert.EqualError(t, err, "list must be an object, got array")
+}
+
+func TestCheckArgumentTypes(t *testing.T) {
+	tool := mcp.NewTool("demo",
+		mcp.WithString("path"),
+		mcp.WithNumber("depth"),
+		mcp.WithBoolean("recursive"),
+		mcp.WithArray("patterns"),
+		mcp.WithObject("options"),
+	)
+
+	assert.NoError(t, checkArgumentTypes(tool.InputSchema, map[string]interface{}{
+		"path": "a", "depth": float64(2), "recursive": false, "patterns": []interface{}{}, "options": map[string]interface{}{},
+		"undeclared": "anything", "depth_null": nil,
+	}))
+	assert.NoError(t, checkArgumentTypes(tool.InputSchema, map[string]interface{}{"depth": nil}))
+
+	for want, args := range map[string]map[string]interface{}{
+		"depth must be a number, got string":      {"depth": "3"},
+		"recursive must be a boolean, got string": {"recursive": "true"},
+		"path must be a string, got number":       {"path": float64(1)},
+		"patterns must be an array, got string":   {"patterns": "*.go"},
+		"options must be an object, got array":    {"options": []interface{}{}},
+		// Los argumentos se comprueban por orden alfabético
+		"depth must be a number, got boolean": {"depth": true, "recursive": "yes"},
+	} {
+		assert.EqualError(t, checkArgumentTypes(tool.InputSchema, args), want)
+	}
+}
+
+// wrongTypedValue - Un valor de otro tipo JSON que el declarado
+func wrongTypedValue(declared string) (interface{}, string) {
+	switch declared {
+	case "string":
+		return float64(42), "number"
+	case "boolean":
+		return "true", "string"
+	default:
+		return "3", "string"
+	}
+}
+
+// TestToolsRejectWrongTypedArguments pasa a cada argumento declarado de cada
+// herramienta un valor de otro tipo a través del servidor
+func TestToolsRejectWrongTypedArguments(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFilesystemServer([]string{dir}, WithRuntimeDirectories(true))
+	require.NoError(t, err)
+
+	response := s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	data, err := json.Marshal(response)
+	require.NoError(t, err)
+	var listed struct {
+		Result struct {
+			Tools []mcp.Tool `json:"tools"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(data, &listed))
+	require.NotEmpty(t, listed.Result.Tools)
+
+	checked := 0
+	for _, tool := range listed.Result.Tools {
+		for name, raw := range tool.InputSchema.Properties {
+			property, _ := raw.(map[string]interface{})
+			declared, _ := property["type"].(string)
+			value, got := wrongTypedValue(declared)
+			args := map[string]interface{}{name: value}
+			if name != "path" {
+				args["path"] = filepath.Join(dir, "missing")
+			}
+			message, err := json.Marshal(map[string]interface{}{
+				"jsonrpc": "2.0", "id": 2, "method": "tools/call",
+				"params": map[string]interface{}{"name": tool.Name, "arguments": args},
+			})
+			require.NoError(t, err)
+
+			data, err := json.Marshal(s.HandleMessage(context.Background(), message))
+			require.NoError(t, err)
+			var called struct {
+				Result struct {
+					IsError bool `json:"isError"`
+					Content []struct {
+						Text string `json:"text"`
+					} `json:"content"`
+				} `json:"result"`
+			}
+			require.NoError(t, json.Unmarshal(data, &called), string(data))
+			require.NotEmpty(t, called.Result.Content, "%s %s: %s", tool.Name, name, data)
+
+			article := "a"
+			if declared == "array" || declared == "object" {
+				article = "an"
+			}
+			want := fmt.Sprintf("%s must be %s %s, got %s", name, article, declared, got)
+			assert.True(t, called.Result.IsError, "%s %s", tool.Name, name)
+			assert.Contains(t, called.Result.Content[0].Text, want, tool.Name)
+			checked++
+		}
+	}
+	assert.Greater(t, checked, 300)
+}
+
+func TestTreeAndDeleteArgumentTypes(t *testing.T) {
+	handler, dir := newTestHandler(t)
+
+	text, isError := callText(t, handler.handleTree, "tree", map[string]interface{}{"path": dir, "depth": 1.5})
+	assert.True(t, isError)
+	assert.Equal(t, "[E_BAD_ARG] ❌ Error: depth must be a whole number, got 1.5", text)
+
+	writeFixture(t, dir, map[string]string{"sub/a.txt": "a"})
+	text, isError = callText(t, handler.handleDeleteFile, "delete_file", map[string]interface{}{"path": filepath.Join(dir, "sub"), "recursive": "true"})
+	assert.True(t, isError)
+	assert.Contains(t, text, "recursive must be a boolean, got string")
+	assert.DirExists(t, filepath.Join(dir, "sub"))
+}
+
+// TestNestedArgumentTypes comprueba los campos de los objetos, que el esquema
+// no describe
+func TestNestedArgumentTypes(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{"a.go": "package a\n\nfunc oldName() {}\n"})
+
+	text, isError := callText(t, handler.handleAssistRefactor, "assist_refactor", map[string]interface{}{
+		"path": dir, "operation": "rename", "target": "oldName",
+		"options": map[string]interface{}{"new_name": "newName", "apply": "yes"},
+	})
+	assert.True(t, isError)
+	assert.Equal(t, "[E_BAD_ARG] ❌ Error: options: apply must be a boolean, got string", text)
+	data, err := os.ReadFile(filepath.Join(dir, "a.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "oldName")
+}
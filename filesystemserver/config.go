@@ -0,0 +1,547 @@
+package filesystemserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// HandlerOptions is the persistent server configuration, loaded from a JSON or
+// YAML file with LoadConfig and overridden by the MCP_* environment variables
+// with FromEnvironment. Every field has the name of its file key; unset fields
+// keep the defaults. Pass it to NewServer with WithHandlerOptions(WithOptions(...)).
+type HandlerOptions struct {
+	AllowedDirs         []string          `json:"allowed_dirs,omitempty" yaml:"allowed_dirs,omitempty"`               // added to the directories given on the command line
+	RootLabels          map[string]string `json:"root_labels,omitempty" yaml:"root_labels,omitempty"`                 // allowed directory -> label
+	ReadOnly            bool              `json:"read_only,omitempty" yaml:"read_only,omitempty"`                     // MCP_READ_ONLY
+	AllowTools          []string          `json:"allow_tools,omitempty" yaml:"allow_tools,omitempty"`                 // only these tools are offered
+	DenyTools           []string          `json:"deny_tools,omitempty" yaml:"deny_tools,omitempty"`                   // these tools are never offered
+	DenyPatterns        []string          `json:"deny_patterns,omitempty" yaml:"deny_patterns,omitempty"`             // MCP_DENY_PATTERNS
+	NoDefaultDeny       bool              `json:"no_default_deny,omitempty" yaml:"no_default_deny,omitempty"`         // MCP_NO_DEFAULT_DENY
+	Workspace           string            `json:"workspace,omitempty" yaml:"workspace,omitempty"`                     // MCP_WORKSPACE
+	ImportantFiles      []string          `json:"important_files,omitempty" yaml:"important_files,omitempty"`         // MCP_IMPORTANT_FILES, category:glob
+	AllowRuntimeDirs    bool              `json:"allow_runtime_dirs,omitempty" yaml:"allow_runtime_dirs,omitempty"`   // MCP_ALLOW_RUNTIME_DIRS
+	GrantableRoots      []string          `json:"grantable_roots,omitempty" yaml:"grantable_roots,omitempty"`         // MCP_GRANTABLE_ROOTS
+	MaxHeavyOps         *int              `json:"max_heavy_ops,omitempty" yaml:"max_heavy_ops,omitempty"`             // MCP_MAX_HEAVY_OPS, 0 = unlimited
+	HeavyQueue          *bool             `json:"heavy_queue,omitempty" yaml:"heavy_queue,omitempty"`                 // MCP_HEAVY_QUEUE
+	HeavyQueueTimeout   string            `json:"heavy_queue_timeout,omitempty" yaml:"heavy_queue_timeout,omitempty"` // MCP_HEAVY_QUEUE_TIMEOUT, e.g. "10s"
+	MaxDecompressedSize int64             `json:"max_decompressed_size,omitempty" yaml:"max_decompressed_size,omitempty"`
+	MaxCreateSize       int64             `json:"max_create_size,omitempty" yaml:"max_create_size,omitempty"`
+	InlineResultLimit   int               `json:"inline_result_limit,omitempty" yaml:"inline_result_limit,omitempty"`
+	ResponseBudget      int               `json:"response_budget,omitempty" yaml:"response_budget,omitempty"`           // MCP_RESPONSE_BUDGET
+	LogLevel            string            `json:"log_level,omitempty" yaml:"log_level,omitempty"`                       // MCP_FS_LOG_LEVEL
+	StartupHealthCheck  bool              `json:"startup_health_check,omitempty" yaml:"startup_health_check,omitempty"` // MCP_HEALTH_CHECK
+	GeneratedMarkers    []string          `json:"generated_markers,omitempty" yaml:"generated_markers,omitempty"`       // MCP_GENERATED_MARKERS
+	ToolTimeout         string            `json:"tool_timeout,omitempty" yaml:"tool_timeout,omitempty"`                 // MCP_TOOL_TIMEOUT, "0s" disables
+	HeavyToolTimeout    string            `json:"heavy_tool_timeout,omitempty" yaml:"heavy_tool_timeout,omitempty"`     // MCP_HEAVY_TOOL_TIMEOUT
+	ToolTimeouts        map[string]string `json:"tool_timeouts,omitempty" yaml:"tool_timeouts,omitempty"`               // MCP_TOOL_TIMEOUTS, tool=duration
+	MaxFollowDuration   string            `json:"max_follow_duration,omitempty" yaml:"max_follow_duration,omitempty"`   // MCP_MAX_FOLLOW_DURATION, cap for tail_follow
+	ScaffoldDir         string            `json:"scaffold_dir,omitempty" yaml:"scaffold_dir,omitempty"`                 // MCP_SCAFFOLD_DIR
+}
+
+// redactedPath replaces, in get_config, paths outside the allowed directories
+const redactedPath = "<outside allowed directories>"
+
+// LoadConfig reads a .json, .yaml or .yml configuration file. Unknown keys,
+// values of the wrong type, invalid globs, durations and log levels, and
+// directories that don't exist are all reported, one per line. Relative paths
+// are resolved against the directory holding the file.
+func LoadConfig(path string) (*HandlerOptions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+
+	var raw map[string]interface{}
+	unmarshal := yaml.Unmarshal
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		unmarshal = func(data []byte, v interface{}) error {
+			decoder := json.NewDecoder(bytes.NewReader(data))
+			decoder.DisallowUnknownFields()
+			return decoder.Decode(v)
+		}
+	case ".yaml", ".yml":
+	default:
+		return nil, fmt.Errorf("config %s: unsupported format %q (use .json, .yaml or .yml)", path, ext)
+	}
+	if err := unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+
+	known := jsonFieldNames(&HandlerOptions{})
+	var problems []string
+	for _, key := range sortedConfigKeys(raw) {
+		if !containsString(known, key) {
+			problems = append(problems, fmt.Sprintf("unknown key '%s'%s", key, didYouMean(key, known)))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("config %s:\n  %s", path, strings.Join(problems, "\n  "))
+	}
+
+	options := &HandlerOptions{}
+	if len(raw) > 0 {
+		if err := unmarshal(data, options); err != nil {
+			return nil, fmt.Errorf("config %s: %w", path, err)
+		}
+	}
+	options.resolvePaths(filepath.Dir(path))
+	if err := options.Validate(); err != nil {
+		return nil, fmt.Errorf("config %s:\n  %s", path, strings.ReplaceAll(err.Error(), "\n", "\n  "))
+	}
+	return options, nil
+}
+
+// sortedConfigKeys returns the top-level keys of a decoded file
+func sortedConfigKeys(raw map[string]interface{}) []string {
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// resolvePaths makes relative directories relative to base
+func (o *HandlerOptions) resolvePaths(base string) {
+	resolve := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(base, path)
+	}
+	for i, dir := range o.AllowedDirs {
+		o.AllowedDirs[i] = resolve(dir)
+	}
+	for i, dir := range o.GrantableRoots {
+		o.GrantableRoots[i] = resolve(dir)
+	}
+	if len(o.RootLabels) > 0 {
+		labels := make(map[string]string, len(o.RootLabels))
+		for dir, label := range o.RootLabels {
+			labels[resolve(dir)] = label
+		}
+		o.RootLabels = labels
+	}
+	o.Workspace = resolve(o.Workspace)
+	o.ScaffoldDir = resolve(o.ScaffoldDir)
+}
+
+// Validate applies the options to a scratch handler and reports every value
+// that would make NewServer fail, prefixed with its key
+func (o *HandlerOptions) Validate() error {
+	keyed, problems := o.options()
+	scratch := &FilesystemHandler{}
+	for _, option := range keyed {
+		if err := option.apply(scratch); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", option.key, err))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// WithOptions applies a HandlerOptions; options given after it override it
+func WithOptions(o *HandlerOptions) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		keyed, problems := o.options()
+		if len(problems) > 0 {
+			return errors.New(strings.Join(problems, "\n"))
+		}
+		for _, option := range keyed {
+			if err := option.apply(fs); err != nil {
+				return fmt.Errorf("%s: %w", option.key, err)
+			}
+		}
+		return nil
+	}
+}
+
+// keyedOption is a HandlerOption and the configuration key it comes from
+type keyedOption struct {
+	key   string
+	apply HandlerOption
+}
+
+// options converts the fields that are set to HandlerOptions; values that
+// can't be converted are returned as problems
+func (o *HandlerOptions) options() ([]keyedOption, []string) {
+	var keyed []keyedOption
+	var problems []string
+	add := func(key string, option HandlerOption) {
+		keyed = append(keyed, keyedOption{key, option})
+	}
+
+	if len(o.AllowedDirs) > 0 {
+		add("allowed_dirs", withAllowedDirs(o.AllowedDirs))
+	}
+	if len(o.RootLabels) > 0 {
+		add("root_labels", WithRootLabels(o.RootLabels))
+	}
+	if o.ReadOnly {
+		add("read_only", WithReadOnly(true))
+	}
+	if len(o.AllowTools) > 0 {
+		add("allow_tools", WithToolPolicy(o.AllowTools, nil))
+	}
+	if len(o.DenyTools) > 0 {
+		add("deny_tools", WithToolPolicy(nil, o.DenyTools))
+	}
+	if len(o.DenyPatterns) > 0 {
+		add("deny_patterns", WithDenyPatterns(o.DenyPatterns...))
+	}
+	if o.NoDefaultDeny {
+		add("no_default_deny", WithoutDefaultDenyPatterns())
+	}
+	if o.Workspace != "" {
+		add("workspace", WithWorkspace(o.Workspace))
+	}
+	if len(o.ImportantFiles) > 0 {
+		add("important_files", WithImportantFiles(o.ImportantFiles...))
+	}
+	if o.AllowRuntimeDirs {
+		add("allow_runtime_dirs", WithRuntimeDirectories(true))
+	}
+	if len(o.GrantableRoots) > 0 {
+		add("grantable_roots", WithGrantableRoots(o.GrantableRoots...))
+	}
+	// Each field changes only its part of the limit, so an environment variable
+	// for one of them keeps the others from the file
+	if o.MaxHeavyOps != nil || o.HeavyQueue != nil || o.HeavyQueueTimeout != "" {
+		maxOps, queue, timeout := o.MaxHeavyOps, o.HeavyQueue, time.Duration(0)
+		if o.HeavyQueueTimeout != "" {
+			parsed, err := time.ParseDuration(o.HeavyQueueTimeout)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("heavy_queue_timeout: invalid duration %q (use a number with a unit, e.g. 10s or 2m)", o.HeavyQueueTimeout))
+			}
+			timeout = parsed
+		}
+		add("max_heavy_ops", func(fs *FilesystemHandler) error {
+			limit, queueing, wait := cap(fs.heavySlots), fs.heavyQueue, fs.heavyTimeout
+			if maxOps != nil {
+				limit = *maxOps
+			}
+			if queue != nil {
+				queueing = *queue
+			}
+			if timeout != 0 {
+				wait = timeout
+			}
+			return WithConcurrencyLimit(limit, queueing, wait)(fs)
+		})
+	}
+	if o.MaxDecompressedSize != 0 {
+		add("max_decompressed_size", WithMaxDecompressedSize(o.MaxDecompressedSize))
+	}
+	if o.MaxCreateSize != 0 {
+		add("max_create_size", WithMaxCreateSize(o.MaxCreateSize))
+	}
+	if o.InlineResultLimit != 0 {
+		add("inline_result_limit", WithInlineResultLimit(o.InlineResultLimit))
+	}
+	if o.ResponseBudget != 0 {
+		add("response_budget", WithResponseBudget(o.ResponseBudget))
+	}
+	if o.LogLevel != "" {
+		level, err := parseLogLevel(o.LogLevel)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("log_level: %v", err))
+		}
+		add("log_level", WithLogger(newStderrLogger(level)))
+	}
+	if o.StartupHealthCheck {
+		add("startup_health_check", WithStartupHealthCheck(true))
+	}
+	if len(o.GeneratedMarkers) > 0 {
+		add("generated_markers", WithGeneratedMarkers(o.GeneratedMarkers...))
+	}
+	// As with max_heavy_ops, each field changes only its part; an explicit 0
+	// disables a timeout, which WithToolTimeouts takes as a negative duration
+	if o.ToolTimeout != "" || o.HeavyToolTimeout != "" || len(o.ToolTimeouts) > 0 {
+		parse := func(key, value string) time.Duration {
+			if value == "" {
+				return 0
+			}
+			parsed, err := time.ParseDuration(value)
+			if err != nil || parsed < 0 {
+				problems = append(problems, fmt.Sprintf("%s: invalid duration %q (use a number with a unit, e.g. 10s or 2m, or 0 for no timeout)", key, value))
+				return 0
+			}
+			if parsed == 0 {
+				return -1
+			}
+			return parsed
+		}
+		single := parse("tool_timeout", o.ToolTimeout)
+		heavy := parse("heavy_tool_timeout", o.HeavyToolTimeout)
+		var perTool map[string]time.Duration
+		if len(o.ToolTimeouts) > 0 {
+			perTool = make(map[string]time.Duration, len(o.ToolTimeouts))
+			names := make([]string, 0, len(o.ToolTimeouts))
+			for name := range o.ToolTimeouts {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if timeout := parse("tool_timeouts."+name, o.ToolTimeouts[name]); timeout != 0 {
+					perTool[name] = timeout
+				}
+			}
+		}
+		add("tool_timeout", func(fs *FilesystemHandler) error {
+			s, h, p := fs.toolTimeout, fs.heavyToolTimeout, fs.toolTimeouts
+			if single != 0 {
+				s = single
+			}
+			if heavy != 0 {
+				h = heavy
+			}
+			if perTool != nil {
+				p = perTool
+			}
+			return WithToolTimeouts(s, h, p)(fs)
+		})
+	}
+	if o.MaxFollowDuration != "" {
+		duration, err := time.ParseDuration(o.MaxFollowDuration)
+		if err != nil || duration <= 0 {
+			problems = append(problems, fmt.Sprintf("max_follow_duration: invalid duration %q (use a positive number with a unit, e.g. 30s or 5m)", o.MaxFollowDuration))
+		}
+		add("max_follow_duration", WithMaxFollowDuration(duration))
+	}
+	if o.ScaffoldDir != "" {
+		add("scaffold_dir", WithScaffoldDir(o.ScaffoldDir))
+	}
+	return keyed, problems
+}
+
+// withAllowedDirs adds allowed directories to those given to NewFilesystemHandler
+func withAllowedDirs(dirs []string) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		for _, dir := range dirs {
+			normalized, err := normalizeAllowedDir(dir)
+			if err != nil {
+				return err
+			}
+			if !containsString(fs.allowedDirs, normalized) {
+				fs.allowedDirs = append(fs.allowedDirs, normalized)
+			}
+		}
+		return nil
+	}
+}
+
+// FromEnvironment overrides the options with the MCP_* environment variables
+// that are set; a variable replaces the value from the file, lists included.
+// MCP_GRANTABLE_ROOTS is a path list, the other lists are comma separated.
+func (o *HandlerOptions) FromEnvironment() error {
+	var problems []string
+	envBool := func(name string, field *bool) {
+		if value := os.Getenv(name); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("invalid %s %q: use 1, true, 0 or false", name, value))
+				return
+			}
+			*field = parsed
+		}
+	}
+	envInt := func(name string, bits int, set func(int64)) {
+		if value := os.Getenv(name); value != "" {
+			parsed, err := strconv.ParseInt(value, 10, bits)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("invalid %s %q: %v", name, value, err))
+				return
+			}
+			set(parsed)
+		}
+	}
+	envList := func(name string, split func(string) []string, field *[]string) {
+		if value := os.Getenv(name); value != "" {
+			*field = split(value)
+		}
+	}
+	envDuration := func(name string, field *string) {
+		if value := os.Getenv(name); value != "" {
+			if _, err := time.ParseDuration(value); err != nil {
+				problems = append(problems, fmt.Sprintf("invalid %s %q: %v", name, value, err))
+				return
+			}
+			*field = value
+		}
+	}
+	commas := func(value string) []string { return strings.Split(value, ",") }
+
+	envBool("MCP_READ_ONLY", &o.ReadOnly)
+	envList("MCP_DENY_PATTERNS", commas, &o.DenyPatterns)
+	envBool("MCP_NO_DEFAULT_DENY", &o.NoDefaultDeny)
+	if value := os.Getenv("MCP_WORKSPACE"); value != "" {
+		o.Workspace = value
+	}
+	envList("MCP_IMPORTANT_FILES", commas, &o.ImportantFiles)
+	envBool("MCP_ALLOW_RUNTIME_DIRS", &o.AllowRuntimeDirs)
+	envList("MCP_GRANTABLE_ROOTS", filepath.SplitList, &o.GrantableRoots)
+	envInt("MCP_MAX_HEAVY_OPS", strconv.IntSize, func(v int64) {
+		limit := int(v)
+		o.MaxHeavyOps = &limit
+	})
+	if value := os.Getenv("MCP_HEAVY_QUEUE"); value != "" {
+		queue := true
+		envBool("MCP_HEAVY_QUEUE", &queue)
+		o.HeavyQueue = &queue
+	}
+	envDuration("MCP_HEAVY_QUEUE_TIMEOUT", &o.HeavyQueueTimeout)
+	envInt("MCP_MAX_DECOMPRESSED_SIZE", 64, func(v int64) { o.MaxDecompressedSize = v })
+	envInt("MCP_MAX_CREATE_SIZE", 64, func(v int64) { o.MaxCreateSize = v })
+	envInt("MCP_INLINE_RESULT_LIMIT", strconv.IntSize, func(v int64) { o.InlineResultLimit = int(v) })
+	envInt("MCP_RESPONSE_BUDGET", strconv.IntSize, func(v int64) { o.ResponseBudget = int(v) })
+	if value := os.Getenv("MCP_FS_LOG_LEVEL"); value != "" {
+		if _, err := parseLogLevel(value); err != nil {
+			problems = append(problems, err.Error())
+		} else {
+			o.LogLevel = value
+		}
+	}
+
+	envBool("MCP_HEALTH_CHECK", &o.StartupHealthCheck)
+	envList("MCP_GENERATED_MARKERS", commas, &o.GeneratedMarkers)
+	envDuration("MCP_TOOL_TIMEOUT", &o.ToolTimeout)
+	envDuration("MCP_HEAVY_TOOL_TIMEOUT", &o.HeavyToolTimeout)
+	if value := os.Getenv("MCP_TOOL_TIMEOUTS"); value != "" {
+		timeouts := make(map[string]string)
+		for _, entry := range commas(value) {
+			name, duration, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			if _, err := time.ParseDuration(duration); !ok || name == "" || err != nil {
+				problems = append(problems, fmt.Sprintf("invalid MCP_TOOL_TIMEOUTS entry %q: use tool=duration, e.g. smart_search=30s", entry))
+				continue
+			}
+			timeouts[name] = duration
+		}
+		o.ToolTimeouts = timeouts
+	}
+	envDuration("MCP_MAX_FOLLOW_DURATION", &o.MaxFollowDuration)
+	if value := os.Getenv("MCP_SCAFFOLD_DIR"); value != "" {
+		o.ScaffoldDir = value
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// effectiveConfig describes the configuration the handler runs with, whatever
+// its source, with the defaults filled in
+func (fs *FilesystemHandler) effectiveConfig() *HandlerOptions {
+	config := &HandlerOptions{
+		ReadOnly:            fs.readOnly,
+		AllowTools:          fs.allowTools,
+		DenyTools:           fs.denyTools,
+		DenyPatterns:        fs.denyPatterns,
+		NoDefaultDeny:       fs.noDefaultDeny,
+		AllowRuntimeDirs:    fs.allowRuntimeDirs,
+		MaxDecompressedSize: fs.maxDecompressedSize(),
+		MaxCreateSize:       fs.maxCreateFileSize(),
+		InlineResultLimit:   fs.inlineResultLimit(),
+		ResponseBudget:      fs.responseBudget(),
+		StartupHealthCheck:  fs.startupHealthCheck,
+		GeneratedMarkers:    fs.generatedMarkerList(),
+	}
+
+	fs.mu.RLock()
+	for _, dir := range fs.allowedDirs {
+		config.AllowedDirs = append(config.AllowedDirs, filepath.Clean(dir))
+	}
+	if len(fs.rootLabels) > 0 {
+		config.RootLabels = make(map[string]string, len(fs.rootLabels))
+		for dir, label := range fs.rootLabels {
+			config.RootLabels[filepath.Clean(dir)] = label
+		}
+	}
+	fs.mu.RUnlock()
+
+	if fs.workspace != "" {
+		config.Workspace = filepath.Clean(fs.workspace)
+	}
+	for _, category := range importantCategories {
+		for _, glob := range fs.importantPatterns[category] {
+			config.ImportantFiles = append(config.ImportantFiles, category+":"+glob)
+		}
+	}
+	for _, root := range fs.grantableRoots {
+		config.GrantableRoots = append(config.GrantableRoots, filepath.Clean(root))
+	}
+
+	limit, queue := cap(fs.heavySlots), fs.heavyQueue
+	config.MaxHeavyOps, config.HeavyQueue = &limit, &queue
+	timeout := fs.heavyTimeout
+	if timeout <= 0 {
+		timeout = defaultHeavyQueueTimeout
+	}
+	config.HeavyQueueTimeout = timeout.String()
+
+	// A disabled timeout shows as 0s, the value that disables it in the file
+	timeoutSetting := func(timeout, fallback time.Duration) string {
+		switch {
+		case timeout < 0:
+			return "0s"
+		case timeout == 0:
+			return fallback.String()
+		}
+		return timeout.String()
+	}
+	config.ToolTimeout = timeoutSetting(fs.toolTimeout, defaultToolTimeout)
+	config.HeavyToolTimeout = timeoutSetting(fs.heavyToolTimeout, defaultHeavyToolTimeout)
+	if len(fs.toolTimeouts) > 0 {
+		config.ToolTimeouts = make(map[string]string, len(fs.toolTimeouts))
+		for name, timeout := range fs.toolTimeouts {
+			if timeout != 0 {
+				config.ToolTimeouts[name] = timeoutSetting(timeout, 0)
+			}
+		}
+	}
+
+	config.MaxFollowDuration = fs.maxFollow().String()
+	config.ScaffoldDir = fs.scaffoldDir
+
+	for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError} {
+		if fs.log().Enabled(context.Background(), level) {
+			config.LogLevel = strings.ToLower(level.String())
+			break
+		}
+	}
+	return config
+}
+
+// redactConfig replaces the paths outside the allowed directories, which the
+// client has no business learning, and returns how many were replaced
+func (fs *FilesystemHandler) redactConfig(config *HandlerOptions) int {
+	redacted := 0
+	redact := func(path string) string {
+		if path == "" || fs.isPathInAllowedDirs(path) {
+			return path
+		}
+		redacted++
+		return redactedPath
+	}
+	for i, root := range config.GrantableRoots {
+		config.GrantableRoots[i] = redact(root)
+	}
+	config.Workspace = redact(config.Workspace)
+	config.ScaffoldDir = redact(config.ScaffoldDir)
+	return redacted
+}
@@ -0,0 +1,208 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// tempDirs returns n resolved temporary directories
+func tempDirs(t *testing.T, n int) []string {
+	t.Helper()
+	dirs := make([]string, n)
+	for i := range dirs {
+		dir, err := filepath.EvalSymlinks(t.TempDir())
+		require.NoError(t, err)
+		dirs[i] = dir
+	}
+	return dirs
+}
+
+// writeConfig writes content to a config file with the given name and loads it
+func writeConfig(t *testing.T, name, content string) (*HandlerOptions, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return LoadConfig(path)
+}
+
+func TestLoadConfigRoundTrip(t *testing.T) {
+	dirs := tempDirs(t, 3)
+	workspace := filepath.Join(dirs[0], "src")
+	require.NoError(t, os.Mkdir(workspace, 0755))
+	heavyOps, heavyQueue := 2, false
+	want := &HandlerOptions{
+		AllowedDirs:         dirs[:2],
+		RootLabels:          map[string]string{dirs[0]: "app", dirs[1]: "docs"},
+		ReadOnly:            true,
+		AllowTools:          []string{"read_file", "tree"},
+		DenyTools:           []string{"tree"},
+		DenyPatterns:        []string{"*.sqlite", "secrets/"},
+		NoDefaultDeny:       true,
+		Workspace:           workspace,
+		ImportantFiles:      []string{"build:BUILD.bazel", "docs:*.adoc"},
+		AllowRuntimeDirs:    true,
+		GrantableRoots:      dirs[2:],
+		MaxHeavyOps:         &heavyOps,
+		HeavyQueue:          &heavyQueue,
+		HeavyQueueTimeout:   "10s",
+		MaxDecompressedSize: 1 << 20,
+		MaxCreateSize:       2 << 20,
+		InlineResultLimit:   4096,
+		ResponseBudget:      8192,
+		LogLevel:            "warn",
+		StartupHealthCheck:  true,
+		GeneratedMarkers:    []string{"Generated by protoc", "DO NOT EDIT"},
+		ToolTimeout:         "0s",
+		HeavyToolTimeout:    "45s",
+		ToolTimeouts:        map[string]string{"smart_search": "30s", "checksum": "0s"},
+		MaxFollowDuration:   "2m0s",
+		ScaffoldDir:         workspace,
+	}
+	// Un campo nuevo sin valor aquí no quedaría cubierto
+	fields := reflect.ValueOf(want).Elem()
+	for i := 0; i < fields.NumField(); i++ {
+		assert.False(t, fields.Field(i).IsZero(), "set %s in the round-trip fixture", fields.Type().Field(i).Name)
+	}
+
+	jsonData, err := json.Marshal(want)
+	require.NoError(t, err)
+	yamlData, err := yaml.Marshal(want)
+	require.NoError(t, err)
+
+	for name, data := range map[string][]byte{"server.json": jsonData, "server.yaml": yamlData} {
+		t.Run(name, func(t *testing.T) {
+			loaded, err := writeConfig(t, name, string(data))
+			require.NoError(t, err)
+			assert.Equal(t, want, loaded)
+
+			handler, err := NewFilesystemHandler(nil, WithOptions(loaded))
+			require.NoError(t, err)
+			assert.Equal(t, want, handler.effectiveConfig())
+		})
+	}
+}
+
+func TestLoadConfigRelativePaths(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(base, "projects", "app"), 0755))
+	path := filepath.Join(base, "server.yml")
+	require.NoError(t, os.WriteFile(path, []byte("allowed_dirs: [projects]\nroot_labels: {projects: work}\nworkspace: projects/app\n"), 0644))
+
+	config, err := LoadConfig(path)
+	require.NoError(t, err)
+	projects := filepath.Join(base, "projects")
+	assert.Equal(t, []string{projects}, config.AllowedDirs)
+	assert.Equal(t, map[string]string{projects: "work"}, config.RootLabels)
+	assert.Equal(t, filepath.Join(projects, "app"), config.Workspace)
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []string
+	}{
+		{"unknown key", "c.yaml", "read_onyl: true\ncolor: blue\n", []string{"unknown key 'color'\n", "unknown key 'read_onyl' (did you mean 'read_only'?)"}},
+		{"unknown json key", "c.json", `{"max_create_sise": 1}`, []string{"unknown key 'max_create_sise' (did you mean 'max_create_size'?)"}},
+		{"wrong type", "c.yaml", "max_create_size: big\n", []string{"cannot unmarshal"}},
+		{"bad glob", "c.yaml", "deny_patterns: ['[abc']\n", []string{"deny_patterns: invalid deny pattern \"[abc\""}},
+		{"bad important file", "c.yaml", "important_files: ['tests:*.go']\n", []string{"important_files: invalid important file pattern"}},
+		{"bad duration", "c.yaml", "heavy_queue_timeout: soon\n", []string{"heavy_queue_timeout: invalid duration \"soon\""}},
+		{"bad tool timeout", "c.yaml", "tool_timeouts: {tree: -5s}\n", []string{"tool_timeouts.tree: invalid duration \"-5s\""}},
+		{"bad follow duration", "c.yaml", "max_follow_duration: 0s\n", []string{"max_follow_duration: invalid duration \"0s\""}},
+		{"bad log level", "c.yaml", "log_level: loud\n", []string{"log_level: invalid log level \"loud\""}},
+		{"missing dir", "c.yaml", "allowed_dirs: [/does/not/exist]\n", []string{"allowed_dirs: failed to access directory"}},
+		{"several problems", "c.yaml", "log_level: loud\nheavy_queue_timeout: soon\n", []string{"heavy_queue_timeout:", "log_level:"}},
+		{"format", "c.toml", "read_only = true\n", []string{"unsupported format \".toml\""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := writeConfig(t, tt.file, tt.content)
+			require.Error(t, err)
+			for _, want := range tt.want {
+				assert.Contains(t, err.Error()+"\n", want)
+			}
+		})
+	}
+
+	config, err := writeConfig(t, "empty.yaml", "")
+	require.NoError(t, err)
+	assert.Equal(t, &HandlerOptions{}, config)
+}
+
+func TestConfigEnvironmentOverridesFile(t *testing.T) {
+	dirs := tempDirs(t, 1)
+	config, err := writeConfig(t, "server.yaml", "read_only: true\nmax_create_size: 100\ndeny_patterns: ['*.db']\nmax_heavy_ops: 3\n")
+	require.NoError(t, err)
+
+	t.Setenv("MCP_READ_ONLY", "0")
+	t.Setenv("MCP_MAX_CREATE_SIZE", "200")
+	t.Setenv("MCP_DENY_PATTERNS", "*.log,*.tmp")
+	t.Setenv("MCP_HEAVY_QUEUE", "false")
+	t.Setenv("MCP_TOOL_TIMEOUTS", "smart_search=30s, tree=0")
+	require.NoError(t, config.FromEnvironment())
+	assert.Equal(t, map[string]string{"smart_search": "30s", "tree": "0"}, config.ToolTimeouts)
+	t.Setenv("MCP_MAX_FOLLOW_DURATION", "90s")
+	require.NoError(t, config.FromEnvironment())
+	assert.Equal(t, "90s", config.MaxFollowDuration)
+	assert.False(t, config.ReadOnly)
+	assert.Equal(t, int64(200), config.MaxCreateSize)
+	assert.Equal(t, []string{"*.log", "*.tmp"}, config.DenyPatterns)
+	require.NotNil(t, config.MaxHeavyOps)
+	assert.Equal(t, 3, *config.MaxHeavyOps, "unset variables keep the file value")
+	require.NotNil(t, config.HeavyQueue)
+	assert.False(t, *config.HeavyQueue)
+
+	// El servidor aplica el entorno después de las opciones que recibe
+	fileOnly, err := writeConfig(t, "server.yaml", "max_create_size: 100\nmax_heavy_ops: 3\n")
+	require.NoError(t, err)
+	_, handler, err := NewFilesystemServerWithHandler(dirs, WithOptions(fileOnly))
+	require.NoError(t, err)
+	assert.Equal(t, int64(200), handler.maxCreateFileSize())
+	assert.Equal(t, 3, cap(handler.heavySlots))
+	assert.False(t, handler.heavyQueue)
+
+	t.Setenv("MCP_MAX_CREATE_SIZE", "lots")
+	err = (&HandlerOptions{}).FromEnvironment()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid MCP_MAX_CREATE_SIZE \"lots\"")
+	_, _, err = NewFilesystemServerWithHandler(dirs)
+	assert.Error(t, err)
+}
+
+func TestGetConfig(t *testing.T) {
+	dirs := tempDirs(t, 2)
+	handler, err := NewFilesystemHandler(dirs[:1], WithGrantableRoots(dirs[1]), WithMaxCreateSize(4096))
+	require.NoError(t, err)
+
+	result, err := handler.handleGetConfig(context.Background(), newToolRequest("get_config", nil))
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "⚙️ Effective configuration:")
+	assert.Contains(t, text, "max_create_size: 4096")
+	assert.Contains(t, text, "🔒 1 path(s) outside the allowed directories redacted")
+	assert.NotContains(t, text, dirs[1])
+
+	var config HandlerOptions
+	decodeStructured(t, result, &config)
+	assert.Equal(t, []string{dirs[0]}, config.AllowedDirs)
+	assert.Equal(t, []string{redactedPath}, config.GrantableRoots)
+	assert.Equal(t, defaultDenyPatterns, config.DenyPatterns)
+	assert.Equal(t, int64(defaultMaxDecompressedSize), config.MaxDecompressedSize)
+	assert.Equal(t, "30s", config.HeavyQueueTimeout)
+	assert.Equal(t, "10s", config.ToolTimeout)
+	assert.Equal(t, "2m0s", config.HeavyToolTimeout)
+	assert.Equal(t, "1m0s", config.MaxFollowDuration)
+	assert.Equal(t, "info", config.LogLevel)
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd
+
+package filesystemserver
+
+import "os"
+
+// fileDevice is not implemented on this platform; hard links are skipped.
+func fileDevice(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,18 @@
+//go:build linux || darwin || freebsd
+
+package filesystemserver
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileDevice returns the device ID of the filesystem holding info. The boolean
+// is false when it cannot be determined, in which case hard links are not attempted.
+func fileDevice(info os.FileInfo) (uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Dev), true
+}
@@ -0,0 +1,425 @@
+package filesystemserver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxDiffEditDistance bounds the Myers search; beyond it the differing middle
+// section is reported as a single delete/insert block instead.
+const maxDiffEditDistance = 4000
+
+// diffOpKind identifies a single line-level edit
+type diffOpKind int
+
+const (
+	diffEqual diffOpKind = iota
+	diffDelete
+	diffInsert
+)
+
+// diffOp is one step of an edit script. OldIndex and NewIndex are 0-based
+// positions in the old and new line slices; for inserts OldIndex is the
+// position of the next old line, for deletes NewIndex is the position of the
+// next new line.
+type diffOp struct {
+	Kind     diffOpKind
+	OldIndex int
+	NewIndex int
+}
+
+// diffOptions controls how two line sequences are compared and rendered
+type diffOptions struct {
+	ContextLines     int
+	Width            int // column width for side-by-side output
+	MaxLines         int // output budget; whole hunks beyond it are dropped
+	IgnoreWhitespace bool
+	IgnoreCase       bool
+}
+
+// normalizeDiffLines returns the comparison keys for lines under opts.
+// Whitespace runs collapse to one space and leading/trailing space is dropped.
+func normalizeDiffLines(lines []string, opts diffOptions) []string {
+	keys := make([]string, len(lines))
+	for i, line := range lines {
+		if opts.IgnoreWhitespace {
+			line = strings.Join(strings.Fields(line), " ")
+		}
+		if opts.IgnoreCase {
+			line = strings.ToLower(line)
+		}
+		keys[i] = line
+	}
+	return keys
+}
+
+// computeLineDiff returns the shortest edit script that turns a into b using
+// the Myers O((N+M)D) algorithm. Common prefixes and suffixes are trimmed
+// first so that typical small edits in large files stay cheap.
+func computeLineDiff(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b)-prefix-suffix)
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, diffOp{Kind: diffEqual, OldIndex: i, NewIndex: i})
+	}
+
+	middle := myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	for _, op := range middle {
+		op.OldIndex += prefix
+		op.NewIndex += prefix
+		ops = append(ops, op)
+	}
+
+	for i := 0; i < suffix; i++ {
+		ops = append(ops, diffOp{Kind: diffEqual, OldIndex: len(a) - suffix + i, NewIndex: len(b) - suffix + i})
+	}
+	return ops
+}
+
+// myersDiff runs the Myers forward search and backtracks through the stored
+// frontiers. Each frontier only keeps the diagonals reachable at that depth,
+// so memory is O(D²) rather than O((N+M)·D).
+func myersDiff(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return coarseDiff(n, m)
+	}
+
+	limit := n + m
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		if d > maxDiffEditDistance {
+			return coarseDiff(n, m)
+		}
+
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrackMyers(trace, n, m)
+			}
+		}
+	}
+	return coarseDiff(n, m)
+}
+
+// backtrackMyers rebuilds the edit script from the recorded frontiers
+func backtrackMyers(trace [][]int, n, m int) []diffOp {
+	var reversed []diffOp
+	x, y := n, m
+
+	for d := len(trace) - 1; d > 0; d-- {
+		frontier := trace[d]
+		at := func(k int) int { return frontier[k+d] }
+
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, diffOp{Kind: diffEqual, OldIndex: x - 1, NewIndex: y - 1})
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, diffOp{Kind: diffInsert, OldIndex: x, NewIndex: y - 1})
+		} else {
+			reversed = append(reversed, diffOp{Kind: diffDelete, OldIndex: x - 1, NewIndex: y})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, diffOp{Kind: diffEqual, OldIndex: x - 1, NewIndex: y - 1})
+		x--
+		y--
+	}
+
+	ops := make([]diffOp, len(reversed))
+	for i, op := range reversed {
+		ops[len(reversed)-1-i] = op
+	}
+	return ops
+}
+
+// coarseDiff reports every old line as deleted and every new line as inserted
+func coarseDiff(n, m int) []diffOp {
+	ops := make([]diffOp, 0, n+m)
+	for i := 0; i < n; i++ {
+		ops = append(ops, diffOp{Kind: diffDelete, OldIndex: i, NewIndex: 0})
+	}
+	for j := 0; j < m; j++ {
+		ops = append(ops, diffOp{Kind: diffInsert, OldIndex: n, NewIndex: j})
+	}
+	return ops
+}
+
+// buildDiffHunks groups an edit script into hunks surrounded by up to
+// contextLines unchanged lines, merging changes whose context would overlap.
+func buildDiffHunks(ops []diffOp, oldLines, newLines []string, contextLines int) []DiffHunk {
+	if contextLines < 0 {
+		contextLines = 0
+	}
+
+	var hunks []DiffHunk
+	i := 0
+	for i < len(ops) {
+		if ops[i].Kind == diffEqual {
+			i++
+			continue
+		}
+
+		start := max(0, i-contextLines)
+
+		// Extend while the next change is within 2*contextLines equal lines
+		end := i
+		for {
+			for end < len(ops) && ops[end].Kind != diffEqual {
+				end++
+			}
+			gap := 0
+			for end+gap < len(ops) && ops[end+gap].Kind == diffEqual {
+				gap++
+			}
+			if end+gap < len(ops) && gap <= 2*contextLines {
+				end += gap
+				continue
+			}
+			end = min(len(ops), end+contextLines)
+			break
+		}
+
+		hunk := DiffHunk{
+			OldStart: ops[start].OldIndex + 1,
+			NewStart: ops[start].NewIndex + 1,
+		}
+		for _, op := range ops[start:end] {
+			switch op.Kind {
+			case diffEqual:
+				hunk.OldLines++
+				hunk.NewLines++
+				line := DiffLine{Type: "equal", OldLine: op.OldIndex + 1, NewLine: op.NewIndex + 1, Text: oldLines[op.OldIndex]}
+				if newLines[op.NewIndex] != line.Text {
+					line.NewText = newLines[op.NewIndex]
+				}
+				hunk.Lines = append(hunk.Lines, line)
+			case diffDelete:
+				hunk.OldLines++
+				hunk.Lines = append(hunk.Lines, DiffLine{Type: "delete", OldLine: op.OldIndex + 1, Text: oldLines[op.OldIndex]})
+			case diffInsert:
+				hunk.NewLines++
+				hunk.Lines = append(hunk.Lines, DiffLine{Type: "insert", NewLine: op.NewIndex + 1, Text: newLines[op.NewIndex]})
+			}
+		}
+		// Empty ranges point at the line before the change, as diff(1) does
+		if hunk.OldLines == 0 {
+			hunk.OldStart--
+		}
+		if hunk.NewLines == 0 {
+			hunk.NewStart--
+		}
+
+		hunks = append(hunks, hunk)
+		i = end
+	}
+	return hunks
+}
+
+// renderUnifiedDiff renders hunks in `diff -u` format
+func renderUnifiedDiff(label1, label2 string, hunks []DiffHunk) string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("--- %s\n", label1))
+	out.WriteString(fmt.Sprintf("+++ %s\n", label2))
+
+	for _, hunk := range hunks {
+		out.WriteString(fmt.Sprintf("@@ -%s +%s @@\n",
+			unifiedRange(hunk.OldStart, hunk.OldLines), unifiedRange(hunk.NewStart, hunk.NewLines)))
+		for _, line := range hunk.Lines {
+			switch line.Type {
+			case "equal":
+				out.WriteString(" " + line.Text + "\n")
+			case "delete":
+				out.WriteString("-" + line.Text + "\n")
+			case "insert":
+				out.WriteString("+" + line.Text + "\n")
+			}
+		}
+	}
+	return out.String()
+}
+
+// renderContextDiff renders hunks in `diff -c` format. Runs mixing deletions
+// and insertions are marked with "!" on both sides.
+func renderContextDiff(label1, label2 string, hunks []DiffHunk) string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("*** %s\n", label1))
+	out.WriteString(fmt.Sprintf("--- %s\n", label2))
+
+	for _, hunk := range hunks {
+		markers := contextDiffMarkers(hunk.Lines)
+		hasDeletes, hasInserts := false, false
+		for _, line := range hunk.Lines {
+			hasDeletes = hasDeletes || line.Type == "delete"
+			hasInserts = hasInserts || line.Type == "insert"
+		}
+
+		out.WriteString("***************\n")
+		out.WriteString(fmt.Sprintf("*** %s ****\n", contextRange(hunk.OldStart, hunk.OldLines)))
+		if hasDeletes {
+			for i, line := range hunk.Lines {
+				if line.Type != "insert" {
+					out.WriteString(markers[i] + line.Text + "\n")
+				}
+			}
+		}
+		out.WriteString(fmt.Sprintf("--- %s ----\n", contextRange(hunk.NewStart, hunk.NewLines)))
+		if hasInserts {
+			for i, line := range hunk.Lines {
+				if line.Type != "delete" {
+					out.WriteString(markers[i] + line.Text + "\n")
+				}
+			}
+		}
+	}
+	return out.String()
+}
+
+// renderSideBySideDiff renders hunks as two padded columns. Markers follow
+// sdiff: "|" changed, "<" only on the left, ">" only on the right.
+func renderSideBySideDiff(label1, label2 string, hunks []DiffHunk, width int) string {
+	var out strings.Builder
+	row := func(left, marker, right string) {
+		out.WriteString(strings.TrimRight(fmt.Sprintf("%s %s %s", fitColumn(left, width), marker, fitColumn(right, width)), " ") + "\n")
+	}
+
+	row(label1, " ", label2)
+	out.WriteString(strings.Repeat("=", 2*width+3) + "\n")
+
+	for _, hunk := range hunks {
+		out.WriteString(fmt.Sprintf("@@ -%s +%s @@\n",
+			unifiedRange(hunk.OldStart, hunk.OldLines), unifiedRange(hunk.NewStart, hunk.NewLines)))
+
+		i := 0
+		for i < len(hunk.Lines) {
+			line := hunk.Lines[i]
+			if line.Type == "equal" {
+				right := line.Text
+				if line.NewText != "" {
+					right = line.NewText
+				}
+				row(line.Text, " ", right)
+				i++
+				continue
+			}
+
+			var removed, added []string
+			for i < len(hunk.Lines) && hunk.Lines[i].Type != "equal" {
+				if hunk.Lines[i].Type == "delete" {
+					removed = append(removed, hunk.Lines[i].Text)
+				} else {
+					added = append(added, hunk.Lines[i].Text)
+				}
+				i++
+			}
+			for j := 0; j < max(len(removed), len(added)); j++ {
+				switch {
+				case j < len(removed) && j < len(added):
+					row(removed[j], "|", added[j])
+				case j < len(removed):
+					row(removed[j], "<", "")
+				default:
+					row("", ">", added[j])
+				}
+			}
+		}
+	}
+	return out.String()
+}
+
+// fitColumn pads or truncates s to exactly width runes, expanding tabs
+func fitColumn(s string, width int) string {
+	runes := []rune(strings.ReplaceAll(s, "\t", "    "))
+	if len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	return string(runes) + strings.Repeat(" ", width-len(runes))
+}
+
+// contextDiffMarkers computes the two-character prefix for each hunk line
+func contextDiffMarkers(lines []DiffLine) []string {
+	markers := make([]string, len(lines))
+	i := 0
+	for i < len(lines) {
+		if lines[i].Type == "equal" {
+			markers[i] = "  "
+			i++
+			continue
+		}
+		j := i
+		hasDeletes, hasInserts := false, false
+		for j < len(lines) && lines[j].Type != "equal" {
+			hasDeletes = hasDeletes || lines[j].Type == "delete"
+			hasInserts = hasInserts || lines[j].Type == "insert"
+			j++
+		}
+		for ; i < j; i++ {
+			switch {
+			case hasDeletes && hasInserts:
+				markers[i] = "! "
+			case lines[i].Type == "delete":
+				markers[i] = "- "
+			default:
+				markers[i] = "+ "
+			}
+		}
+	}
+	return markers
+}
+
+// unifiedRange formats a unified diff range ("start" or "start,count")
+func unifiedRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// contextRange formats a context diff range ("start" or "start,end")
+func contextRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d", start)
+	}
+	end := start + count - 1
+	if end == start {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, end)
+}
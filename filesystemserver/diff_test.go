@@ -0,0 +1,108 @@
+package filesystemserver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiffMatchesDiffTool(t *testing.T) {
+	oldLines := strings.Split("a b c d e f g h i j k", " ")
+	newLines := strings.Split("a b X d e f g h i k l", " ")
+
+	diff := diffLines(oldLines, newLines, diffOptions{ContextLines: 3})
+
+	expected := "--- old\n+++ new\n" +
+		"@@ -1,11 +1,11 @@\n a\n b\n-c\n+X\n d\n e\n f\n g\n h\n i\n-j\n k\n+l\n"
+	assert.Equal(t, expected, renderUnifiedDiff("old", "new", diff.Hunks))
+	assert.Equal(t, 9, diff.Unchanged)
+	assert.Equal(t, []string{"c", "j"}, diff.Removed)
+	assert.Equal(t, []string{"X", "l"}, diff.Added)
+}
+
+func TestContextDiffMarksChanges(t *testing.T) {
+	oldLines := []string{"a", "b", "c", "d"}
+	newLines := []string{"a", "B", "c", "d", "e"}
+
+	diff := diffLines(oldLines, newLines, diffOptions{ContextLines: 1})
+
+	expected := "*** old\n--- new\n" +
+		"***************\n*** 1,4 ****\n  a\n! b\n  c\n  d\n" +
+		"--- 1,5 ----\n  a\n! B\n  c\n  d\n+ e\n"
+	assert.Equal(t, expected, renderContextDiff("old", "new", diff.Hunks))
+}
+
+func TestDiffHandlesShuffledAndDuplicateLines(t *testing.T) {
+	shuffled := diffLines([]string{"one", "two", "three"}, []string{"three", "two", "one"}, diffOptions{ContextLines: 3})
+	assert.Less(t, shuffled.Similar, 100.0)
+	assert.NotEmpty(t, shuffled.Hunks)
+
+	duplicates := diffLines([]string{"x", "x", "y"}, []string{"x", "y", "x"}, diffOptions{ContextLines: 3})
+	expected := "--- old\n+++ new\n@@ -1,3 +1,3 @@\n x\n-x\n y\n+x\n"
+	assert.Equal(t, expected, renderUnifiedDiff("old", "new", duplicates.Hunks))
+}
+
+func TestDiffSplitsDistantChangesIntoHunks(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 0; i < 30; i++ {
+		line := strings.Repeat("l", i+1)
+		oldLines = append(oldLines, line)
+		newLines = append(newLines, line)
+	}
+	newLines[2] = "changed-early"
+	newLines[25] = "changed-late"
+
+	diff := diffLines(oldLines, newLines, diffOptions{ContextLines: 2})
+	if assert.Len(t, diff.Hunks, 2) {
+		assert.Equal(t, 1, diff.Hunks[0].OldStart)
+		assert.Equal(t, 5, diff.Hunks[0].OldLines)
+		assert.Equal(t, 24, diff.Hunks[1].OldStart)
+		assert.Equal(t, 5, diff.Hunks[1].OldLines)
+	}
+
+	inserted := diffLines(nil, []string{"a", "b"}, diffOptions{ContextLines: 3})
+	assert.Equal(t, "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n", renderUnifiedDiff("old", "new", inserted.Hunks))
+}
+
+func TestSideBySideDiffMarkers(t *testing.T) {
+	diff := diffLines([]string{"same", "old", "gone"}, []string{"same", "new", "gone", "extra"}, diffOptions{ContextLines: 3})
+
+	expected := "" +
+		"a            b\n" +
+		"=======================\n" +
+		"@@ -1,3 +1,4 @@\n" +
+		"same         same\n" +
+		"old        | new\n" +
+		"gone         gone\n" +
+		"           > extra\n"
+	assert.Equal(t, expected, renderSideBySideDiff("a", "b", diff.Hunks, 10))
+}
+
+func TestDiffIgnoreWhitespaceAndCase(t *testing.T) {
+	oldLines := []string{"func main() {", "\treturn"}
+	newLines := []string{"FUNC  main() {", "    return"}
+
+	strict := diffLines(oldLines, newLines, diffOptions{ContextLines: 3})
+	assert.Len(t, strict.Hunks, 1)
+
+	relaxed := diffLines(oldLines, newLines, diffOptions{ContextLines: 3, IgnoreWhitespace: true, IgnoreCase: true})
+	assert.Empty(t, relaxed.Hunks)
+	assert.Equal(t, 100.0, relaxed.Similar)
+}
+
+func TestTruncateHunks(t *testing.T) {
+	hunks := []DiffHunk{
+		{Lines: make([]DiffLine, 4)},
+		{Lines: make([]DiffLine, 4)},
+		{Lines: make([]DiffLine, 4)},
+	}
+
+	kept, truncated := truncateHunks(hunks, 11)
+	assert.True(t, truncated)
+	assert.Len(t, kept, 2)
+
+	kept, truncated = truncateHunks(hunks, 0)
+	assert.False(t, truncated)
+	assert.Len(t, kept, 3)
+}
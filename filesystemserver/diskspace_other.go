@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd
+
+package filesystemserver
+
+// availableDiskSpace is not implemented on this platform; callers skip the check.
+func availableDiskSpace(dir string) (int64, bool) {
+	return 0, false
+}
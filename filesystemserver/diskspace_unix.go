@@ -0,0 +1,15 @@
+//go:build linux || darwin || freebsd
+
+package filesystemserver
+
+import "syscall"
+
+// availableDiskSpace returns the bytes available to unprivileged users on the
+// filesystem containing dir. The boolean is false when it cannot be determined.
+func availableDiskSpace(dir string) (int64, bool) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, false
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), true
+}
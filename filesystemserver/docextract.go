@@ -0,0 +1,488 @@
+package filesystemserver
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	maxDocumentSize        = 20 << 20 // larger documents are never extracted
+	maxExtractedText       = 2 << 20  // extracted text is cut at this many bytes
+	maxDocumentInflate     = 64 << 20 // decompressed bytes read from one document
+	documentExtractTimeout = 5 * time.Second
+)
+
+// errNoTextLayer is returned for PDFs whose pages are only images
+var errNoTextLayer = errors.New("no text layer")
+
+// isExtractableDocument reports whether extractDocumentText handles path
+func isExtractableDocument(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pdf", ".docx":
+		return true
+	}
+	return false
+}
+
+// extractDocumentText returns the text of a PDF (text layer only) or .docx
+// file, giving up after documentExtractTimeout. Text beyond maxExtractedText
+// is dropped and reported as truncated.
+func extractDocumentText(ctx context.Context, path string) (string, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false, err
+	}
+	if info.Size() > maxDocumentSize {
+		return "", false, fmt.Errorf("larger than the %d MB extraction limit", maxDocumentSize>>20)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, documentExtractTimeout)
+	defer cancel()
+	var text string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pdf":
+		var data []byte
+		if data, err = os.ReadFile(path); err == nil {
+			text, err = extractPDFText(ctx, data)
+		}
+	case ".docx":
+		text, err = extractDocxText(ctx, path)
+	default:
+		err = fmt.Errorf("unsupported document type %q (use .pdf or .docx)", filepath.Ext(path))
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", documentExtractTimeout)
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	if len(text) <= maxExtractedText {
+		return text, false, nil
+	}
+	cut := maxExtractedText
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut], true, nil
+}
+
+// extractDocxText collects the runs of text in word/document.xml, with a
+// newline per paragraph or break and a tab per tab
+func extractDocxText(ctx context.Context, path string) (string, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	var document *zip.File
+	for _, file := range reader.File {
+		if file.Name == "word/document.xml" {
+			document = file
+			break
+		}
+	}
+	if document == nil {
+		return "", errors.New("word/document.xml not found")
+	}
+	rc, err := document.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	decoder := xml.NewDecoder(io.LimitReader(rc, maxDocumentInflate))
+	var b strings.Builder
+	inText := false
+	for tokens := 0; b.Len() <= maxExtractedText; tokens++ {
+		if tokens%1000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+		}
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+// pdfSkippedStream matches the dictionaries of streams that hold no page
+// text: images, embedded fonts, cross-reference, object and metadata streams
+var pdfSkippedStream = regexp.MustCompile(`/Subtype\s*/(Image|XML|Type1C|CIDFontType0C|OpenType)\b|/Length[123]\s*\d|/Type\s*/(XRef|ObjStm|Metadata)\b`)
+
+// pdfFlateFilter matches a FlateDecode filter, alone or as the only array entry
+var pdfFlateFilter = regexp.MustCompile(`/Filter\s*(/FlateDecode|\[\s*/FlateDecode\s*\])`)
+
+// extractPDFText reads the text showing operators of every uncompressed or
+// FlateDecode content stream. Fonts with custom encodings and no ToUnicode
+// support may come out garbled; strings that are mostly unprintable are dropped.
+func extractPDFText(ctx context.Context, data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF-")) {
+		return "", errors.New("not a PDF file")
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return "", errors.New("encrypted PDF")
+	}
+
+	var b strings.Builder
+	inflated := 0
+	for pos := 0; pos < len(data) && b.Len() < maxExtractedText && inflated < maxDocumentInflate; {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		i := bytes.Index(data[pos:], []byte("stream"))
+		if i < 0 {
+			break
+		}
+		start := pos + i
+		pos = start + len("stream")
+		if start >= 3 && string(data[start-3:start]) == "end" {
+			continue
+		}
+
+		// The dictionary sits between "N 0 obj" and "stream"
+		dictStart := bytes.LastIndex(data[:start], []byte("obj"))
+		dict := data[max(dictStart, 0):start]
+		bodyStart := pos
+		if bodyStart < len(data) && data[bodyStart] == '\r' {
+			bodyStart++
+		}
+		if bodyStart < len(data) && data[bodyStart] == '\n' {
+			bodyStart++
+		}
+		end := bytes.Index(data[bodyStart:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		body := data[bodyStart : bodyStart+end]
+		pos = bodyStart + end + len("endstream")
+
+		if pdfSkippedStream.Match(dict) {
+			continue
+		}
+		content := body
+		if bytes.Contains(dict, []byte("/Filter")) {
+			if !pdfFlateFilter.Match(dict) {
+				continue
+			}
+			reader, err := zlib.NewReader(bytes.NewReader(body))
+			if err != nil {
+				continue
+			}
+			// A truncated stream still yields the text inflated so far
+			content, err = io.ReadAll(io.LimitReader(reader, int64(maxDocumentInflate-inflated)))
+			reader.Close()
+			if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && len(content) == 0 {
+				continue
+			}
+		}
+		inflated += len(content)
+		pdfContentText(content, &b)
+	}
+
+	if strings.TrimSpace(b.String()) == "" {
+		return "", errNoTextLayer
+	}
+	return b.String(), nil
+}
+
+// pdfOperand is a string, number or array operand of a content stream operator
+type pdfOperand struct {
+	str   []byte
+	isStr bool
+	num   float64
+	array []pdfOperand
+}
+
+// pdfContentText writes the strings shown between BT and ET, starting a new
+// line on line moves and ET and putting a space on large TJ gaps
+func pdfContentText(content []byte, b *strings.Builder) {
+	var operands []pdfOperand
+	var arrays [][]pdfOperand
+	inText := false
+	lastY := 0.0
+	push := func(op pdfOperand) {
+		if n := len(arrays); n > 0 {
+			arrays[n-1] = append(arrays[n-1], op)
+		} else {
+			operands = append(operands, op)
+		}
+	}
+	newline := func() {
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteByte('\n')
+		}
+	}
+	show := func(op pdfOperand) {
+		if op.isStr {
+			b.WriteString(decodePDFString(op.str))
+		}
+	}
+
+	for i := 0; i < len(content) && b.Len() < maxExtractedText; {
+		c := content[i]
+		switch {
+		case isPDFSpace(c):
+			i++
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			str, next := readPDFLiteral(content, i)
+			push(pdfOperand{str: str, isStr: true})
+			i = next
+		case c == '<' && i+1 < len(content) && content[i+1] == '<', c == '>' && i+1 < len(content) && content[i+1] == '>':
+			i += 2
+		case c == '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				return
+			}
+			push(pdfOperand{str: decodePDFHex(content[i+1 : i+end]), isStr: true})
+			i += end + 1
+		case c == '[':
+			arrays = append(arrays, nil)
+			i++
+		case c == ']':
+			if n := len(arrays); n > 0 {
+				array := arrays[n-1]
+				arrays = arrays[:n-1]
+				push(pdfOperand{array: array})
+			}
+			i++
+		default:
+			start := i
+			for i++; i < len(content) && !isPDFSpace(content[i]) && !bytes.ContainsRune([]byte("()<>[]{}/%"), rune(content[i])); i++ {
+			}
+			if i == start+1 && bytes.ContainsRune([]byte(">{}"), rune(c)) {
+				continue
+			}
+			word := string(content[start:i])
+			if c == '/' {
+				push(pdfOperand{})
+				continue
+			}
+			if num, err := strconv.ParseFloat(word, 64); err == nil {
+				push(pdfOperand{num: num})
+				continue
+			}
+
+			switch word {
+			case "BT":
+				inText = true
+			case "ET":
+				inText = false
+				newline()
+			case "ID":
+				// Inline image: binary data up to EI
+				end := bytes.Index(content[i:], []byte("EI"))
+				if end < 0 {
+					return
+				}
+				i += end + 2
+			}
+			if inText {
+				switch word {
+				case "Tj":
+					if n := len(operands); n > 0 {
+						show(operands[n-1])
+					}
+				case "'", "\"":
+					newline()
+					if n := len(operands); n > 0 {
+						show(operands[n-1])
+					}
+				case "TJ":
+					if n := len(operands); n > 0 {
+						for _, op := range operands[n-1].array {
+							if !op.isStr && op.num < -250 {
+								b.WriteByte(' ')
+							}
+							show(op)
+						}
+					}
+				case "T*":
+					newline()
+				case "Td", "TD":
+					if n := len(operands); n >= 2 && operands[n-1].num != 0 {
+						newline()
+					} else if !strings.HasSuffix(b.String(), " ") {
+						b.WriteByte(' ')
+					}
+				case "Tm":
+					if n := len(operands); n >= 6 {
+						if y := operands[n-1].num; y != lastY {
+							lastY = y
+							newline()
+						}
+					}
+				}
+			}
+			operands = operands[:0]
+		}
+	}
+}
+
+// isPDFSpace reports the PDF whitespace characters
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+// readPDFLiteral decodes the (string) starting at content[start], with its
+// escapes and balanced parentheses, and returns the index after it
+func readPDFLiteral(content []byte, start int) ([]byte, int) {
+	var out []byte
+	depth := 0
+	i := start
+	for ; i < len(content); i++ {
+		c := content[i]
+		switch c {
+		case '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return out, i + 1
+			}
+		case '\\':
+			i++
+			if i >= len(content) {
+				return out, i
+			}
+			switch e := content[i]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r', '\n':
+				// Line continuation
+				if e == '\r' && i+1 < len(content) && content[i+1] == '\n' {
+					i++
+				}
+			default:
+				if e >= '0' && e <= '7' {
+					value := 0
+					for n := 0; n < 3 && i < len(content) && content[i] >= '0' && content[i] <= '7'; n++ {
+						value = value*8 + int(content[i]-'0')
+						i++
+					}
+					i--
+					out = append(out, byte(value))
+				} else {
+					out = append(out, e)
+				}
+			}
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, i
+}
+
+// decodePDFHex decodes a <hex string>; an odd final digit counts as followed by 0
+func decodePDFHex(hex []byte) []byte {
+	var digits []byte
+	for _, c := range hex {
+		if !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, 0, len(digits)/2)
+	for i := 0; i < len(digits); i += 2 {
+		value, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		if err != nil {
+			return nil
+		}
+		out = append(out, byte(value))
+	}
+	return out
+}
+
+// decodePDFString turns a string operand into text: UTF-16BE with a BOM,
+// otherwise one character per byte (PDFDocEncoding is close to Latin-1).
+// Mostly unprintable results, such as glyph ids of embedded fonts, are dropped.
+func decodePDFString(raw []byte) string {
+	var runes []rune
+	if bytes.HasPrefix(raw, []byte{0xFE, 0xFF}) {
+		units := make([]uint16, 0, len(raw)/2)
+		for i := 2; i+1 < len(raw); i += 2 {
+			units = append(units, binary.BigEndian.Uint16(raw[i:]))
+		}
+		runes = utf16.Decode(units)
+	} else {
+		runes = make([]rune, len(raw))
+		for i, c := range raw {
+			runes[i] = rune(c)
+		}
+	}
+
+	printable := 0
+	var b strings.Builder
+	for _, r := range runes {
+		if unicode.IsPrint(r) || r == '\t' || r == '\n' {
+			printable++
+			b.WriteRune(r)
+		}
+	}
+	if len(runes) > 0 && printable*10 < len(runes)*7 {
+		return ""
+	}
+	return b.String()
+}
@@ -0,0 +1,127 @@
+package filesystemserver
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeDocx creates a .docx whose word/document.xml holds the given body XML
+func writeDocx(t *testing.T, path, body string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("word/document.xml")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` + body + `</w:body></w:document>`))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+}
+
+// pdfDocument builds a PDF with one content stream, Flate-compressed if asked,
+// plus an image stream that must be ignored
+func pdfDocument(content string, compress bool) []byte {
+	stream, dict := []byte(content), ""
+	if compress {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		zw.Write(stream)
+		zw.Close()
+		stream, dict = buf.Bytes(), " /Filter /FlateDecode"
+	}
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	fmt.Fprintf(&b, "4 0 obj\n<< /Length %d%s >>\nstream\n", len(stream), dict)
+	b.Write(stream)
+	b.WriteString("\nendstream\nendobj\n")
+	b.WriteString("5 0 obj\n<< /Type /XObject /Subtype /Image /Length 9 >>\nstream\nBT (no) Tj ET\nendstream\nendobj\n")
+	b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+const pdfContent = `BT /F1 12 Tf 72 712 Td (Quarterly \(Q3\) report) Tj
+0 -14 Td [(Re) 20 (venue) -300 (grew)] TJ
+T* <FEFF00E9007400E9> Tj
+ET`
+
+func TestExtractPDFText(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		text, err := extractPDFText(context.Background(), pdfDocument(pdfContent, compress))
+		require.NoError(t, err)
+		assert.Equal(t, "Quarterly (Q3) report\nRevenue grew\nété\n", text, "compressed: %v", compress)
+	}
+
+	_, err := extractPDFText(context.Background(), pdfDocument("0 0 m 10 10 l S", true))
+	assert.ErrorIs(t, err, errNoTextLayer)
+
+	encrypted := bytes.Replace(pdfDocument(pdfContent, false), []byte("/Root 1 0 R"), []byte("/Root 1 0 R /Encrypt 6 0 R"), 1)
+	_, err = extractPDFText(context.Background(), encrypted)
+	assert.EqualError(t, err, "encrypted PDF")
+
+	_, err = extractPDFText(context.Background(), []byte("PK\x03\x04 not a pdf"))
+	assert.EqualError(t, err, "not a PDF file")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = extractPDFText(ctx, pdfDocument(pdfContent, false))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	assert.Equal(t, "", decodePDFString([]byte{0x01, 0x02, 0x03, 'a'}), "glyph ids are dropped")
+	assert.Equal(t, []byte("Hi"), decodePDFHex([]byte("48 69")))
+}
+
+func TestExtractDocumentText(t *testing.T) {
+	dir := t.TempDir()
+	docx := filepath.Join(dir, "memo.docx")
+	writeDocx(t, docx, `<w:p><w:r><w:t>Project</w:t></w:r><w:r><w:t xml:space="preserve"> kickoff</w:t></w:r></w:p>`+
+		`<w:p><w:r><w:t>Owner:</w:t><w:tab/><w:t>Ana</w:t><w:br/><w:t>Budget &amp; scope</w:t></w:r></w:p>`)
+	text, truncated, err := extractDocumentText(context.Background(), docx)
+	require.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Equal(t, "Project kickoff\nOwner:\tAna\nBudget & scope\n", text)
+
+	pdf := filepath.Join(dir, "report.PDF")
+	require.NoError(t, os.WriteFile(pdf, pdfDocument(pdfContent, true), 0644))
+	text, _, err = extractDocumentText(context.Background(), pdf)
+	require.NoError(t, err)
+	assert.Contains(t, text, "Revenue grew")
+
+	// Un .docx sin word/document.xml o que no es un zip falla sin pánico
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	_, _ = zw.Create("xl/workbook.xml")
+	require.NoError(t, zw.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sheet.docx"), buf.Bytes(), 0644))
+	_, _, err = extractDocumentText(context.Background(), filepath.Join(dir, "sheet.docx"))
+	assert.EqualError(t, err, "word/document.xml not found")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fake.docx"), []byte("plain text"), 0644))
+	_, _, err = extractDocumentText(context.Background(), filepath.Join(dir, "fake.docx"))
+	assert.Error(t, err)
+
+	big := filepath.Join(dir, "big.pdf")
+	require.NoError(t, os.WriteFile(big, nil, 0644))
+	require.NoError(t, os.Truncate(big, maxDocumentSize+1))
+	_, _, err = extractDocumentText(context.Background(), big)
+	assert.ErrorContains(t, err, "extraction limit")
+
+	long := filepath.Join(dir, "long.docx")
+	writeDocx(t, long, "<w:p><w:r><w:t>"+strings.Repeat("é", maxExtractedText)+"</w:t></w:r></w:p>")
+	text, truncated, err = extractDocumentText(context.Background(), long)
+	require.NoError(t, err)
+	assert.True(t, truncated)
+	assert.LessOrEqual(t, len(text), maxExtractedText)
+	assert.True(t, strings.HasSuffix(text, "é"), "cut on a character boundary")
+
+	assert.True(t, isExtractableDocument("a/B.Docx"))
+	assert.False(t, isExtractableDocument("notes.doc"))
+}
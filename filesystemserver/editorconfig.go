@@ -0,0 +1,196 @@
+package filesystemserver
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// editorConfigName is the file editorconfig-aware tools look for in every
+// directory from the file up to the root
+const editorConfigName = ".editorconfig"
+
+// editorConfigSection is one [glob] section of a .editorconfig file
+type editorConfigSection struct {
+	pattern *regexp.Regexp
+	props   map[string]string
+}
+
+// editorConfigFile is a parsed .editorconfig; root stops the upward search
+type editorConfigFile struct {
+	dir      string
+	root     bool
+	sections []editorConfigSection
+}
+
+// editorConfigResolver finds the .editorconfig properties that apply to a
+// file. Each directory is read once per resolver, and the search never
+// leaves the allowed directories.
+type editorConfigResolver struct {
+	fs    *FilesystemHandler
+	files map[string]*editorConfigFile // by directory; nil when it has none
+	found bool
+}
+
+func newEditorConfigResolver(fs *FilesystemHandler) *editorConfigResolver {
+	return &editorConfigResolver{fs: fs, files: make(map[string]*editorConfigFile)}
+}
+
+// properties returns the lowercased properties for path. Closer files
+// override farther ones and later sections override earlier ones.
+func (r *editorConfigResolver) properties(path string) map[string]string {
+	var chain []*editorConfigFile
+	for dir := filepath.Dir(path); r.fs.allowedDirFor(dir) != ""; dir = filepath.Dir(dir) {
+		if file := r.load(dir); file != nil {
+			chain = append(chain, file)
+			if file.root {
+				break
+			}
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+
+	props := make(map[string]string)
+	for i := len(chain) - 1; i >= 0; i-- {
+		file := chain[i]
+		rel, err := filepath.Rel(file.dir, path)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, section := range file.sections {
+			if !section.pattern.MatchString(rel) {
+				continue
+			}
+			for key, value := range section.props {
+				props[key] = value
+			}
+		}
+	}
+	return props
+}
+
+func (r *editorConfigResolver) load(dir string) *editorConfigFile {
+	if file, ok := r.files[dir]; ok {
+		return file
+	}
+	file, err := parseEditorConfig(filepath.Join(dir, editorConfigName))
+	if err != nil {
+		file = nil
+	} else {
+		file.dir = dir
+		r.found = true
+	}
+	r.files[dir] = file
+	return file
+}
+
+// parseEditorConfig reads an INI-style .editorconfig. Sections whose glob
+// cannot be translated are ignored, as editors do.
+func parseEditorConfig(path string) (*editorConfigFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	file := &editorConfigFile{}
+	var current *editorConfigSection
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = nil
+			pattern, err := editorConfigGlob(line[1 : len(line)-1])
+			if err == nil {
+				file.sections = append(file.sections, editorConfigSection{pattern: pattern, props: make(map[string]string)})
+				current = &file.sections[len(file.sections)-1]
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.ToLower(strings.TrimSpace(value))
+		if current != nil {
+			current.props[key] = value
+		} else if key == "root" {
+			file.root = value == "true"
+		}
+	}
+	return file, scanner.Err()
+}
+
+// editorConfigGlob translates an editorconfig glob into a regexp matched
+// against slash-separated paths relative to the .editorconfig directory.
+// Globs without a slash match a file name at any depth.
+func editorConfigGlob(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	if strings.Contains(glob, "/") {
+		glob = strings.TrimPrefix(glob, "/")
+	} else {
+		b.WriteString("(?:.*/)?")
+	}
+
+	braces := 0
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			}
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '{':
+			braces++
+			b.WriteString("(?:")
+		case '}':
+			if braces == 0 {
+				b.WriteString(`\}`)
+				continue
+			}
+			braces--
+			b.WriteString(")")
+		case ',':
+			if braces > 0 {
+				b.WriteString("|")
+			} else {
+				b.WriteString(",")
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,33 @@
+package filesystemserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditorConfigGlob(t *testing.T) {
+	cases := []struct {
+		glob    string
+		matches []string
+		misses  []string
+	}{
+		{"*", []string{"a.go", "dir/b.md"}, nil},
+		{"*.{js,ts}", []string{"app.js", "src/app.ts"}, []string{"app.jsx", "app.go"}},
+		{"lib/**.py", []string{"lib/a.py", "lib/x/y/b.py"}, []string{"src/lib/a.py", "a.py"}},
+		{"/docs/*.md", []string{"docs/readme.md"}, []string{"docs/x/readme.md", "a/docs/readme.md"}},
+		{"Makefile", []string{"Makefile", "sub/Makefile"}, []string{"Makefile.am"}},
+		{"[!a]?.txt", []string{"bc.txt"}, []string{"ab.txt", "b.txt"}},
+	}
+	for _, tc := range cases {
+		pattern, err := editorConfigGlob(tc.glob)
+		require.NoError(t, err, tc.glob)
+		for _, path := range tc.matches {
+			assert.True(t, pattern.MatchString(path), "%s should match %s", tc.glob, path)
+		}
+		for _, path := range tc.misses {
+			assert.False(t, pattern.MatchString(path), "%s should not match %s", tc.glob, path)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package filesystemserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Error codes that prefix the text of error results ("[E_NOT_FOUND] ❌ Error:
+// ..."), so clients and agents can branch on the kind of failure without
+// parsing the message. Problems with the caller's input always come back as
+// an IsError result; a Go error from a handler is reserved for bugs in the
+// server or the transport.
+const (
+	codeBadArg       = "E_BAD_ARG"
+	codeAccessDenied = "E_ACCESS_DENIED"
+	codeNotFound     = "E_NOT_FOUND"
+	codeTooLarge     = "E_TOO_LARGE"
+)
+
+// errorCodePrefix matches a text that already starts with an error code
+var errorCodePrefix = regexp.MustCompile(`^\[E_[A-Z_]+\] `)
+
+// errorCodePhrases classifies the message of an error result that carries no
+// code yet. Groups are checked in order, so "access denied - parent directory
+// does not exist" is an access problem; messages matching none stay uncoded,
+// as they aren't about the input (I/O failures, timeouts, a busy server).
+var errorCodePhrases = []struct {
+	code    string
+	phrases []string
+}{
+	{codeAccessDenied, []string{"access denied", "permission denied", "disabled by the server policy", "read-only mode", "is denied", "is an allowed root directory"}},
+	{codeNotFound, []string{"does not exist", "no such file", "not found", "cannot find"}},
+	{codeTooLarge, []string{"too large", "too many", "too long", "exceeds", "larger than"}},
+	{codeBadArg, []string{
+		"required", "must be", "invalid", "unknown", "unsupported", "expected", "provide exactly one", "nothing to do",
+		"cannot be", "is not a", "is not inside", "is a directory", "to a directory", "into itself", "already exists", "looks generated",
+	}},
+}
+
+// errorCodeForText returns the code for an error message, or "" when the
+// message isn't about the caller's input
+func errorCodeForText(text string) string {
+	text = strings.ToLower(text)
+	for _, group := range errorCodePhrases {
+		for _, phrase := range group.phrases {
+			if strings.Contains(text, phrase) {
+				return group.code
+			}
+		}
+	}
+	return ""
+}
+
+// errorCodeOf classifies err, looking at the wrapped os errors first
+func errorCodeOf(err error) string {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return codeNotFound
+	case errors.Is(err, os.ErrPermission):
+		return codeAccessDenied
+	}
+	return errorCodeForText(err.Error())
+}
+
+// Error makes a ToolError usable wherever an error is expected
+func (e *ToolError) Error() string {
+	return e.Message
+}
+
+// result renders the error: the coded message and its hint as text, then the
+// payload as JSON in the last content item like any structured output. An
+// error without a code isn't about the input and is only text.
+func (e *ToolError) result() *mcp.CallToolResult {
+	return e.render("❌ Error: " + e.Message)
+}
+
+// render is result with text as the human-readable message
+func (e *ToolError) render(text string) *mcp.CallToolResult {
+	if e.Code != "" {
+		text = fmt.Sprintf("[%s] %s", e.Code, text)
+	}
+	if e.Hint != "" {
+		text += "\n💡 " + e.Hint
+	}
+	result := &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: text},
+		},
+		IsError: true,
+	}
+	if e.Code == "" {
+		return result
+	}
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return result
+	}
+	uri := "error://" + strings.ToLower(e.Code)
+	if e.Path != "" {
+		uri = pathToResourceURI(e.Path)
+	}
+	result.Content = append(result.Content, mcp.EmbeddedResource{
+		Type: "resource",
+		Resource: mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: "application/json",
+			Text:     string(data),
+		},
+	})
+	return result
+}
+
+// Constructors, one per code, so every handler words the same problem with
+// the same code and hint.
+
+// badArgError is a missing, wrong-typed or contradictory argument
+func badArgError(err error) *ToolError {
+	return &ToolError{Code: codeBadArg, Message: err.Error()}
+}
+
+// directoryNeedsRecursiveError is a directory passed to a delete without recursive
+func directoryNeedsRecursiveError(path string) *ToolError {
+	return &ToolError{
+		Code:    codeBadArg,
+		Message: fmt.Sprintf("%s is a directory", path),
+		Path:    path,
+		Hint:    "pass recursive=true to delete the directory and everything in it",
+	}
+}
+
+// accessDeniedError is a path outside the allowed directories, denied by
+// policy or not permitted by the operating system
+func (fs *FilesystemHandler) accessDeniedError(path string, err error) *ToolError {
+	e := &ToolError{Code: codeAccessDenied, Message: err.Error(), Path: path}
+	if path != "" && strings.Contains(e.Message, "outside allowed directories") {
+		if nearest := fs.nearestAllowedDirectory(path); nearest != "" {
+			e.Hint = fmt.Sprintf("the nearest allowed directory is %s; %s lists them all", nearest, fs.toolName("list_allowed_directories"))
+		}
+	}
+	return e
+}
+
+// notFoundError is a path that doesn't exist
+func (fs *FilesystemHandler) notFoundError(path string, err error) *ToolError {
+	e := &ToolError{Code: codeNotFound, Message: err.Error(), Path: path}
+	if path != "" {
+		if existing := fs.nearestExistingDirectory(path); existing != "" {
+			e.Hint = fmt.Sprintf("the nearest existing directory is %s; %s shows what it contains", existing, fs.toolName("list_directory"))
+		}
+	}
+	return e
+}
+
+// tooLargeError is a file over limit bytes for the operation
+func (fs *FilesystemHandler) tooLargeError(path string, limit int64, err error) *ToolError {
+	return &ToolError{
+		Code:    codeTooLarge,
+		Message: err.Error(),
+		Path:    path,
+		Hint:    fmt.Sprintf("the limit is %d bytes; split the file with %s, or read it through its resource URI with resources/read", limit, fs.toolName("split_file")),
+	}
+}
+
+// toolError classifies err about path with errorCodeOf and builds it with the
+// constructor for its code
+func (fs *FilesystemHandler) toolError(path string, err error) *ToolError {
+	switch errorCodeOf(err) {
+	case codeBadArg:
+		e := badArgError(err)
+		e.Path = path
+		return e
+	case codeAccessDenied:
+		return fs.accessDeniedError(path, err)
+	case codeNotFound:
+		return fs.notFoundError(path, err)
+	case codeTooLarge:
+		if path != "" && strings.Contains(err.Error(), "too large") {
+			return fs.tooLargeError(path, MAX_INLINE_SIZE, err)
+		}
+		return &ToolError{Code: codeTooLarge, Message: err.Error(), Path: path}
+	}
+	return &ToolError{Message: err.Error(), Path: path}
+}
+
+// nearestAllowedDirectory returns the allowed directory sharing the most
+// leading path components with path
+func (fs *FilesystemHandler) nearestAllowedDirectory(path string) string {
+	abs, err := fs.resolvePath(path)
+	if err != nil {
+		abs = filepath.Clean(path)
+	}
+	parts := strings.Split(filepath.ToSlash(abs), "/")
+	nearest, shared := "", -1
+	for _, dir := range fs.allowedDirectories() {
+		dir = filepath.Clean(dir)
+		dirParts := strings.Split(filepath.ToSlash(dir), "/")
+		n := 0
+		for n < len(parts) && n < len(dirParts) && parts[n] == dirParts[n] {
+			n++
+		}
+		if n > shared {
+			nearest, shared = dir, n
+		}
+	}
+	return nearest
+}
+
+// nearestExistingDirectory returns the closest ancestor of path that exists
+// and is inside the allowed directories, or ""
+func (fs *FilesystemHandler) nearestExistingDirectory(path string) string {
+	abs, err := fs.resolvePath(path)
+	if err != nil {
+		return ""
+	}
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			if _, err := fs.checkPath(dir); err != nil {
+				return ""
+			}
+			return dir
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return ""
+		}
+	}
+}
+
+// withToolError codes an error result built without a constructor, so the
+// handlers that format their own "❌ Error: ..." results get the same code,
+// hint and payload; the path comes from the call's arguments
+func (fs *FilesystemHandler) withToolError(result *mcp.CallToolResult, args map[string]interface{}) *mcp.CallToolResult {
+	if result == nil || !result.IsError || len(result.Content) == 0 {
+		return result
+	}
+	text, ok := result.Content[0].(mcp.TextContent)
+	if !ok || errorCodePrefix.MatchString(text.Text) || errorCodeForText(text.Text) == "" {
+		return result
+	}
+	path, _ := args["path"].(string)
+	if path == "" {
+		path, _ = args["source"].(string)
+	}
+	message := strings.TrimPrefix(strings.TrimPrefix(text.Text, "❌ "), "Error: ")
+	coded := fs.toolError(path, errors.New(message)).render(text.Text)
+	// Any other original content stays before the payload, which goes last
+	coded.Content = append(coded.Content[:1:1], append(result.Content[1:], coded.Content[1:]...)...)
+	return coded
+}
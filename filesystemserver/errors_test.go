@@ -0,0 +1,191 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCodeOf(t *testing.T) {
+	_, statErr := os.Stat(filepath.Join(t.TempDir(), "missing"))
+	for err, want := range map[error]string{
+		statErr:                                  codeNotFound,
+		fmt.Errorf("open: %w", os.ErrPermission): codeAccessDenied,
+		errors.New("access denied - path outside allowed directories: /etc"): codeAccessDenied,
+		errors.New("access denied - parent directory does not exist"):        codeAccessDenied,
+		errors.New("file too large to read inline"):                          codeTooLarge,
+		errors.New("path is required"):                                       codeBadArg,
+		errors.New("unknown sort_by 'size'"):                                 codeBadArg,
+		errors.New("disk quota reached"):                                     "",
+	} {
+		assert.Equal(t, want, errorCodeOf(err), err.Error())
+	}
+}
+
+// toolErrorPayload - Decodifica el JSON de un resultado de error
+func toolErrorPayload(t *testing.T, result *mcp.CallToolResult) ToolError {
+	t.Helper()
+	require.True(t, result.IsError)
+	require.GreaterOrEqual(t, len(result.Content), 2, result.Content[0].(mcp.TextContent).Text)
+	embedded, ok := result.Content[len(result.Content)-1].(mcp.EmbeddedResource)
+	require.True(t, ok)
+	resource, ok := embedded.Resource.(mcp.TextResourceContents)
+	require.True(t, ok)
+	require.Equal(t, "application/json", resource.MIMEType)
+
+	// Solo los campos documentados, con sus nombres JSON
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(resource.Text), &fields))
+	for name := range fields {
+		assert.Contains(t, []string{"code", "message", "path", "hint"}, name)
+	}
+	var payload ToolError
+	require.NoError(t, json.Unmarshal([]byte(resource.Text), &payload))
+	return payload
+}
+
+func TestToolErrorPayloads(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	outside := t.TempDir()
+	writeFixture(t, dir, map[string]string{"sub/keep.txt": "x", "big.go": ""})
+	require.NoError(t, os.Truncate(filepath.Join(dir, "big.go"), MAX_INLINE_SIZE+1))
+	call := func(name string, h server.ToolHandlerFunc, args map[string]interface{}) *mcp.CallToolResult {
+		result, err := handler.guardTool(name, h)(context.Background(), newToolRequest(name, args))
+		require.NoError(t, err)
+		return result
+	}
+
+	// E_BAD_ARG
+	result := call("read_file", handler.handleReadFile, map[string]interface{}{})
+	assert.Equal(t, ToolError{Code: codeBadArg, Message: "path is required"}, toolErrorPayload(t, result))
+	assert.Equal(t, "[E_BAD_ARG] ❌ Error: path is required", result.Content[0].(mcp.TextContent).Text)
+
+	// E_BAD_ARG con la pista de recursive
+	sub := filepath.Join(dir, "sub")
+	result = call("delete_file", handler.handleDeleteFile, map[string]interface{}{"path": sub})
+	assert.Equal(t, ToolError{
+		Code: codeBadArg, Message: sub + " is a directory", Path: sub,
+		Hint: "pass recursive=true to delete the directory and everything in it",
+	}, toolErrorPayload(t, result))
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "\n💡 pass recursive=true")
+	assert.DirExists(t, sub)
+
+	// E_ACCESS_DENIED, con el directorio permitido más cercano
+	denied := filepath.Join(outside, "secret.txt")
+	payload := toolErrorPayload(t, call("read_file", handler.handleReadFile, map[string]interface{}{"path": denied}))
+	assert.Equal(t, codeAccessDenied, payload.Code)
+	assert.Equal(t, denied, payload.Path)
+	assert.Contains(t, payload.Message, "access denied - path outside allowed directories")
+	assert.Equal(t, "the nearest allowed directory is "+filepath.Clean(dir)+"; list_allowed_directories lists them all", payload.Hint)
+
+	// E_NOT_FOUND, con el directorio existente más cercano
+	missing := filepath.Join(dir, "sub", "gone", "file.txt")
+	payload = toolErrorPayload(t, call("read_file", handler.handleReadFile, map[string]interface{}{"path": missing}))
+	assert.Equal(t, codeNotFound, payload.Code)
+	assert.Equal(t, missing, payload.Path)
+	assert.Equal(t, "the nearest existing directory is "+sub+"; list_directory shows what it contains", payload.Hint)
+
+	// E_TOO_LARGE, con el límite y la alternativa
+	big := filepath.Join(dir, "big.go")
+	payload = toolErrorPayload(t, call("extract_outline", handler.handleExtractOutline, map[string]interface{}{"path": big}))
+	assert.Equal(t, codeTooLarge, payload.Code)
+	assert.Equal(t, big, payload.Path)
+	assert.Contains(t, payload.Message, "file is too large")
+	assert.Equal(t, fmt.Sprintf("the limit is %d bytes; split the file with split_file, or read it through its resource URI with resources/read", MAX_INLINE_SIZE), payload.Hint)
+
+	// La política de solo lectura también es E_ACCESS_DENIED
+	readOnly, err := NewFilesystemHandler([]string{dir}, WithReadOnly(true))
+	require.NoError(t, err)
+	result, err = readOnly.guardTool("write_file", readOnly.handleWriteFile)(context.Background(), newToolRequest("write_file", map[string]interface{}{"path": filepath.Join(dir, "x.txt"), "content": "x"}))
+	require.NoError(t, err)
+	assert.Equal(t, ToolError{Code: codeAccessDenied, Message: "server is in read-only mode"}, toolErrorPayload(t, result))
+}
+
+func TestWithToolErrorLeavesOtherResults(t *testing.T) {
+	handler, _ := newTestHandler(t)
+
+	// Los códigos explícitos, los resultados correctos y los fallos ajenos a la entrada no cambian
+	for _, result := range []*mcp.CallToolResult{
+		badArgError(errors.New("file does not exist")).result(),
+		{Content: []mcp.Content{mcp.TextContent{Type: "text", Text: "✅ not found anywhere"}}},
+		{Content: []mcp.Content{mcp.TextContent{Type: "text", Text: "❌ Error: operation timed out after 1s"}}, IsError: true},
+	} {
+		content := append([]mcp.Content(nil), result.Content...)
+		assert.Equal(t, content, handler.withToolError(result, nil).Content)
+	}
+	assert.Nil(t, handler.withToolError(nil, nil))
+
+	// El contenido que ya traía el resultado se conserva antes del JSON
+	result := handler.withToolError(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: "Error: Path does not exist"},
+			mcp.TextContent{Type: "text", Text: "details"},
+		},
+		IsError: true,
+	}, map[string]interface{}{"path": "relative.txt"})
+	require.Len(t, result.Content, 3)
+	assert.True(t, strings.HasPrefix(result.Content[0].(mcp.TextContent).Text, "[E_NOT_FOUND] Error: Path does not exist"))
+	assert.Equal(t, "details", result.Content[1].(mcp.TextContent).Text)
+	assert.Equal(t, "Path does not exist", toolErrorPayload(t, result).Message)
+}
+
+// TestNoRawErrorsForBadArguments llama a cada herramienta registrada con una
+// tabla de peticiones mal formadas: ninguna debe acabar en un error JSON-RPC,
+// y todo resultado de error debe llevar su código
+func TestNoRawErrorsForBadArguments(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	s, err := NewFilesystemServer([]string{dir}, WithRuntimeDirectories(true))
+	require.NoError(t, err)
+
+	missing := filepath.Join(dir, "missing.txt")
+	badRequests := map[string]map[string]interface{}{
+		"no arguments":   {},
+		"null arguments": {"path": nil, "source": nil, "destination": nil, "paths": nil, "content": nil},
+		"empty strings":  {"path": "", "source": "", "destination": "", "pattern": "", "content": ""},
+		"missing path":   {"path": missing, "source": missing, "destination": filepath.Join(dir, "copy.txt"), "paths": []interface{}{missing}},
+		"outside path":   {"path": outside, "source": outside, "destination": filepath.Join(outside, "copy.txt"), "paths": []interface{}{outside}},
+		"wrong types":    {"path": float64(1), "paths": "a.txt", "recursive": "yes"},
+	}
+
+	checked := 0
+	for _, tool := range registeredTools(t, s) {
+		for name, args := range badRequests {
+			message, err := json.Marshal(map[string]interface{}{
+				"jsonrpc": "2.0", "id": 2, "method": "tools/call",
+				"params": map[string]interface{}{"name": tool, "arguments": args},
+			})
+			require.NoError(t, err)
+
+			data, err := json.Marshal(s.HandleMessage(context.Background(), message))
+			require.NoError(t, err)
+			var response struct {
+				Error  *json.RawMessage `json:"error"`
+				Result struct {
+					IsError bool `json:"isError"`
+					Content []struct {
+						Text string `json:"text"`
+					} `json:"content"`
+				} `json:"result"`
+			}
+			require.NoError(t, json.Unmarshal(data, &response), string(data))
+			if !assert.Nil(t, response.Error, "%s (%s): %s", tool, name, data) || !response.Result.IsError {
+				continue
+			}
+			require.NotEmpty(t, response.Result.Content, "%s (%s)", tool, name)
+			assert.Regexp(t, errorCodePrefix, response.Result.Content[0].Text, "%s (%s)", tool, name)
+			checked++
+		}
+	}
+	assert.Greater(t, checked, 300)
+}
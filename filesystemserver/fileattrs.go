@@ -0,0 +1,65 @@
+package filesystemserver
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxXattrValueSize caps the bytes of each extended attribute value returned
+	maxXattrValueSize = 1024
+	// maxXattrCount caps the number of extended attributes returned per file
+	maxXattrCount = 100
+)
+
+var errXattrsUnsupported = errors.New("extended attributes are not supported on this platform")
+
+// symlinkInfo reports whether path itself is a symbolic link and, if so,
+// the target it points to as stored in the link.
+func symlinkInfo(path string) (bool, string) {
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return false, ""
+	}
+	target, err := os.Readlink(path)
+	if err != nil {
+		return true, ""
+	}
+	return true, target
+}
+
+// newFileXattr builds the reported form of an extended attribute, keeping
+// at most maxXattrValueSize bytes of its value.
+func newFileXattr(name string, value []byte) FileXattr {
+	xattr := FileXattr{Name: name, Size: len(value), Encoding: "text"}
+	if len(value) > maxXattrValueSize {
+		value = value[:maxXattrValueSize]
+		xattr.Truncated = true
+	}
+	if isPrintableText(value, xattr.Truncated) {
+		xattr.Value = string(value)
+	} else {
+		xattr.Value = base64.StdEncoding.EncodeToString(value)
+		xattr.Encoding = "base64"
+	}
+	return xattr
+}
+
+// isPrintableText reports whether value is UTF-8 without control characters.
+// A truncated value may end in the middle of a rune, which is tolerated.
+func isPrintableText(value []byte, truncated bool) bool {
+	for len(value) > 0 {
+		r, size := utf8.DecodeRune(value)
+		if r == utf8.RuneError && size <= 1 {
+			return truncated && !utf8.FullRune(value)
+		}
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return false
+		}
+		value = value[size:]
+	}
+	return true
+}
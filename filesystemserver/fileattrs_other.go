@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package filesystemserver
+
+import "os"
+
+// platformOwnership is not implemented on this platform.
+func platformOwnership(info os.FileInfo, stats *FileInfo) {}
+
+// listXattrs is not implemented on this platform.
+func listXattrs(path string) ([]FileXattr, error) {
+	return nil, errXattrsUnsupported
+}
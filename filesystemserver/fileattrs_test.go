@@ -0,0 +1,27 @@
+package filesystemserver
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFileXattr(t *testing.T) {
+	xattr := newFileXattr("user.comment", []byte("hola, señor"))
+	assert.Equal(t, FileXattr{Name: "user.comment", Value: "hola, señor", Encoding: "text", Size: 12}, xattr)
+
+	binary := []byte{0x00, 0x01, 0xff}
+	xattr = newFileXattr("security.selinux", binary)
+	assert.Equal(t, "base64", xattr.Encoding)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(binary), xattr.Value)
+
+	// Un valor largo se corta aunque quede una runa a medias
+	long := strings.Repeat("a", maxXattrValueSize-1) + "ñ"
+	xattr = newFileXattr("user.long", []byte(long))
+	assert.True(t, xattr.Truncated)
+	assert.Equal(t, "text", xattr.Encoding)
+	assert.Equal(t, len(long), xattr.Size)
+	assert.Len(t, xattr.Value, maxXattrValueSize)
+}
@@ -0,0 +1,108 @@
+//go:build linux || darwin || freebsd
+
+package filesystemserver
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// ownerNames caches uid/gid to name lookups, which may hit NSS or LDAP.
+// Misses are cached too, as an empty name.
+var ownerNames = struct {
+	sync.Mutex
+	users  map[uint32]string
+	groups map[uint32]string
+}{users: map[uint32]string{}, groups: map[uint32]string{}}
+
+// platformOwnership fills the owner, group and hard link count from the
+// stat data os.Stat already fetched
+func platformOwnership(info os.FileInfo, stats *FileInfo) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	uid, gid := uint32(stat.Uid), uint32(stat.Gid)
+	stats.UID, stats.GID = &uid, &gid
+	stats.Owner, stats.Group = lookupOwnerNames(uid, gid)
+	stats.HardLinks = uint64(stat.Nlink)
+}
+
+func lookupOwnerNames(uid, gid uint32) (string, string) {
+	ownerNames.Lock()
+	defer ownerNames.Unlock()
+
+	owner, ok := ownerNames.users[uid]
+	if !ok {
+		if u, err := user.LookupId(strconv.FormatUint(uint64(uid), 10)); err == nil {
+			owner = u.Username
+		}
+		ownerNames.users[uid] = owner
+	}
+	group, ok := ownerNames.groups[gid]
+	if !ok {
+		if g, err := user.LookupGroupId(strconv.FormatUint(uint64(gid), 10)); err == nil {
+			group = g.Name
+		}
+		ownerNames.groups[gid] = group
+	}
+	return owner, group
+}
+
+// listXattrs returns up to maxXattrCount extended attributes of path
+func listXattrs(path string) ([]FileXattr, error) {
+	names, err := readXattr(func(dest []byte) (int, error) { return unix.Listxattr(path, dest) })
+	if err != nil {
+		if errors.Is(err, unix.ENOTSUP) {
+			return nil, errors.New("extended attributes are not supported by this filesystem")
+		}
+		return nil, err
+	}
+
+	xattrs := []FileXattr{}
+	for _, name := range bytes.Split(names, []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		if len(xattrs) == maxXattrCount {
+			break
+		}
+		value, err := readXattr(func(dest []byte) (int, error) { return unix.Getxattr(path, string(name), dest) })
+		if err != nil {
+			// Removed since it was listed, or not readable by us
+			continue
+		}
+		xattrs = append(xattrs, newFileXattr(string(name), value))
+	}
+	return xattrs, nil
+}
+
+// readXattr calls fn first to size the buffer and then to fill it, retrying
+// when the data grew in between
+func readXattr(fn func(dest []byte) (int, error)) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		size, err := fn(nil)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return nil, nil
+		}
+		buf := make([]byte, size)
+		n, err := fn(buf)
+		if errors.Is(err, unix.ERANGE) && attempt < 3 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
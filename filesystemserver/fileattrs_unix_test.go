@@ -0,0 +1,72 @@
+//go:build linux || darwin || freebsd
+
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestGetFileInfoOwnership(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "data.txt")
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0644))
+	require.NoError(t, os.Link(path, filepath.Join(dir, "data-link.txt")))
+	link := filepath.Join(dir, "current")
+	require.NoError(t, os.Symlink("data.txt", link))
+
+	result, err := handler.handleGetFileInfo(context.Background(), newToolRequest("get_file_info", map[string]interface{}{"path": link}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "IsSymlink: true -> data.txt")
+	assert.Contains(t, text, "Hard links: 2")
+
+	var info FileInfo
+	decodeStructured(t, result, &info)
+	assert.True(t, info.IsSymlink)
+	assert.Equal(t, "data.txt", info.SymlinkTarget)
+	assert.Equal(t, uint64(2), info.HardLinks)
+	require.NotNil(t, info.UID)
+	require.NotNil(t, info.GID)
+	assert.Equal(t, uint32(os.Getuid()), *info.UID)
+	assert.Equal(t, uint32(os.Getgid()), *info.GID)
+	assert.Nil(t, info.Xattrs)
+
+	stats, err := handler.getFileStats(path)
+	require.NoError(t, err)
+	assert.False(t, stats.IsSymlink)
+}
+
+func TestGetFileInfoXattrs(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "tagged.txt")
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0644))
+	if err := unix.Setxattr(path, "user.origin", []byte("https://example.com"), 0); err != nil {
+		t.Skipf("extended attributes not available here: %v", err)
+	}
+	require.NoError(t, unix.Setxattr(path, "user.blob", []byte{0, 1, 2}, 0))
+
+	result, err := handler.handleGetFileInfo(context.Background(), newToolRequest("get_file_info", map[string]interface{}{
+		"path":           path,
+		"include_xattrs": true,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "user.origin = https://example.com")
+
+	var info FileInfo
+	decodeStructured(t, result, &info)
+	assert.Empty(t, info.XattrsError)
+	assert.ElementsMatch(t, []FileXattr{
+		{Name: "user.origin", Value: "https://example.com", Encoding: "text", Size: 19},
+		{Name: "user.blob", Value: "AAEC", Encoding: "base64", Size: 3},
+	}, info.Xattrs)
+}
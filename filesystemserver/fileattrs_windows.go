@@ -0,0 +1,48 @@
+//go:build windows
+
+package filesystemserver
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// windowsFileAttributes maps the reported attribute bits to their names
+var windowsFileAttributes = []struct {
+	bit  uint32
+	name string
+}{
+	{windows.FILE_ATTRIBUTE_READONLY, "readonly"},
+	{windows.FILE_ATTRIBUTE_HIDDEN, "hidden"},
+	{windows.FILE_ATTRIBUTE_SYSTEM, "system"},
+	{windows.FILE_ATTRIBUTE_ARCHIVE, "archive"},
+	{windows.FILE_ATTRIBUTE_TEMPORARY, "temporary"},
+	{windows.FILE_ATTRIBUTE_SPARSE_FILE, "sparse"},
+	{windows.FILE_ATTRIBUTE_REPARSE_POINT, "reparse-point"},
+	{windows.FILE_ATTRIBUTE_COMPRESSED, "compressed"},
+	{windows.FILE_ATTRIBUTE_OFFLINE, "offline"},
+	{windows.FILE_ATTRIBUTE_NOT_CONTENT_INDEXED, "not-content-indexed"},
+	{windows.FILE_ATTRIBUTE_ENCRYPTED, "encrypted"},
+}
+
+// platformOwnership lists the file attributes from the attribute data
+// os.Stat already fetched. Owner and link count need an open handle and
+// are not reported.
+func platformOwnership(info os.FileInfo, stats *FileInfo) {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return
+	}
+	for _, attr := range windowsFileAttributes {
+		if data.FileAttributes&attr.bit != 0 {
+			stats.Attributes = append(stats.Attributes, attr.name)
+		}
+	}
+}
+
+// listXattrs is not implemented on Windows
+func listXattrs(path string) ([]FileXattr, error) {
+	return nil, errXattrsUnsupported
+}
@@ -0,0 +1,32 @@
+package filesystemserver
+
+import (
+	"os"
+	"time"
+)
+
+// fileTimes holds the creation and access times the platform reports. The
+// has* flags are false where the data is not available.
+type fileTimes struct {
+	created     time.Time
+	accessed    time.Time
+	hasCreated  bool
+	hasAccessed bool
+}
+
+// statFileTimes returns the creation and access times of path, which info
+// describes. Times the platform cannot provide are filled with the
+// modification time and listed in estimated.
+func statFileTimes(path string, info os.FileInfo) (created, accessed time.Time, estimated []string) {
+	times := platformFileTimes(path, info)
+	created, accessed = times.created, times.accessed
+	if !times.hasCreated {
+		created = info.ModTime()
+		estimated = append(estimated, "created")
+	}
+	if !times.hasAccessed {
+		accessed = info.ModTime()
+		estimated = append(estimated, "accessed")
+	}
+	return created, accessed, estimated
+}
@@ -0,0 +1,26 @@
+//go:build darwin || freebsd
+
+package filesystemserver
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// platformFileTimes reads the birth and access times from stat(2)
+func platformFileTimes(path string, info os.FileInfo) fileTimes {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileTimes{}
+	}
+	times := fileTimes{
+		accessed:    time.Unix(int64(stat.Atimespec.Sec), int64(stat.Atimespec.Nsec)),
+		hasAccessed: true,
+	}
+	// A birth time of -1 or 0 means the filesystem does not record it
+	if stat.Birthtimespec.Sec > 0 {
+		times.created, times.hasCreated = time.Unix(int64(stat.Birthtimespec.Sec), int64(stat.Birthtimespec.Nsec)), true
+	}
+	return times
+}
@@ -0,0 +1,83 @@
+//go:build linux
+
+package filesystemserver
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// statxSyscall is the statx(2) number for this architecture, or 0 where it is
+// not wired up. The syscall package only defines SYS_STATX on a few ports.
+var statxSyscall = map[string]uintptr{
+	"386":     383,
+	"amd64":   332,
+	"arm":     397,
+	"arm64":   291,
+	"loong64": 291,
+	"ppc64":   383,
+	"ppc64le": 383,
+	"riscv64": 291,
+	"s390x":   379,
+}[runtime.GOARCH]
+
+const (
+	atFDCWD    = -100
+	statxBtime = 0x800
+)
+
+// statxTimestamp mirrors struct statx_timestamp
+type statxTimestamp struct {
+	Sec  int64
+	Nsec uint32
+	_    int32
+}
+
+// statxResult mirrors struct statx (256 bytes); only the times are read
+type statxResult struct {
+	Mask           uint32
+	Blksize        uint32
+	Attributes     uint64
+	Nlink          uint32
+	UID            uint32
+	GID            uint32
+	Mode           uint16
+	_              uint16
+	Ino            uint64
+	Size           uint64
+	Blocks         uint64
+	AttributesMask uint64
+	Atime          statxTimestamp
+	Btime          statxTimestamp
+	Ctime          statxTimestamp
+	Mtime          statxTimestamp
+	_              [128]byte
+}
+
+// platformFileTimes reads the access time from stat(2) and the birth time
+// from statx(2). Birth time is missing on kernels before 4.11, on filesystems
+// that do not record it and where a seccomp profile blocks statx.
+func platformFileTimes(path string, info os.FileInfo) fileTimes {
+	var times fileTimes
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		times.accessed, times.hasAccessed = time.Unix(int64(stat.Atim.Sec), int64(stat.Atim.Nsec)), true
+	}
+	if statxSyscall == 0 {
+		return times
+	}
+
+	name, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return times
+	}
+	var result statxResult
+	dirfd := atFDCWD
+	_, _, errno := syscall.Syscall6(statxSyscall, uintptr(dirfd), uintptr(unsafe.Pointer(name)), 0, statxBtime, uintptr(unsafe.Pointer(&result)), 0)
+	if errno == 0 && result.Mask&statxBtime != 0 {
+		times.created, times.hasCreated = time.Unix(result.Btime.Sec, int64(result.Btime.Nsec)), true
+	}
+	return times
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package filesystemserver
+
+import "os"
+
+// platformFileTimes is not implemented on this platform; callers fall back
+// to the modification time.
+func platformFileTimes(path string, info os.FileInfo) fileTimes {
+	return fileTimes{}
+}
@@ -0,0 +1,50 @@
+//go:build linux || darwin || freebsd || windows
+
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFileStatsRealTimes(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0644))
+
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0644))
+	accessed := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Chtimes(path, accessed, info.ModTime()))
+
+	stats, err := handler.getFileStats(path)
+	require.NoError(t, err)
+	assert.NotContains(t, stats.EstimatedTimes, "accessed")
+	assert.True(t, stats.Accessed.Equal(accessed), "accessed %v", stats.Accessed)
+	if containsString(stats.EstimatedTimes, "created") {
+		// Linux sin statx o sistemas de archivos que no guardan la fecha de creación
+		t.Skip("birth time not recorded here")
+	}
+	assert.NotEqual(t, stats.Modified, stats.Created)
+	assert.True(t, stats.Created.Before(stats.Modified), "created %v, modified %v", stats.Created, stats.Modified)
+
+	result, err := handler.handleGetFileInfo(context.Background(), newToolRequest("get_file_info", map[string]interface{}{"path": path}))
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.NotContains(t, text, "not available on this platform")
+
+	analysis, err := handler.analyzeFile(path)
+	require.NoError(t, err)
+	require.NotNil(t, analysis.Created)
+	require.NotNil(t, analysis.Accessed)
+	assert.True(t, analysis.Created.Equal(stats.Created))
+}
@@ -0,0 +1,24 @@
+//go:build windows
+
+package filesystemserver
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// platformFileTimes reads CreationTime and LastAccessTime from the file
+// attribute data os.Stat already fetched
+func platformFileTimes(path string, info os.FileInfo) fileTimes {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return fileTimes{}
+	}
+	return fileTimes{
+		created:     time.Unix(0, data.CreationTime.Nanoseconds()),
+		accessed:    time.Unix(0, data.LastAccessTime.Nanoseconds()),
+		hasCreated:  true,
+		hasAccessed: true,
+	}
+}
@@ -0,0 +1,97 @@
+package filesystemserver
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// defaultGeneratedMarkers are looked for in the first generatedScanLines lines
+// of a file; any of them marks it as generated
+var defaultGeneratedMarkers = []string{"Code generated", "DO NOT EDIT", "@generated", "<auto-generated"}
+
+const (
+	generatedScanLines = 20       // lines searched for a marker
+	generatedHeadBytes = 16 << 10 // bytes read for them; a minified line may be huge
+	generatedTailBytes = 512      // bytes read for a trailing source map reference
+)
+
+// sourceMapPrefixes start the last line of bundled or compiled output
+var sourceMapPrefixes = []string{"//# sourceMappingURL=", "//@ sourceMappingURL=", "/*# sourceMappingURL="}
+
+// WithGeneratedMarkers replaces the markers that make edit_file and write_file
+// refuse a file as generated (default: defaultGeneratedMarkers). A marker is
+// matched case-sensitively anywhere in the first 20 lines.
+func WithGeneratedMarkers(markers ...string) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		fs.generatedMarkers = nil
+		for _, marker := range markers {
+			if marker = strings.TrimSpace(marker); marker != "" {
+				fs.generatedMarkers = append(fs.generatedMarkers, marker)
+			}
+		}
+		return nil
+	}
+}
+
+// generatedMarkerList returns the configured markers or the defaults
+func (fs *FilesystemHandler) generatedMarkerList() []string {
+	if len(fs.generatedMarkers) > 0 {
+		return fs.generatedMarkers
+	}
+	return defaultGeneratedMarkers
+}
+
+// generatedReason describes why the file at path looks generated, or returns
+// "" when it doesn't: a marker in its first lines, or a source map reference
+// on its last line. Files that can't be read don't look generated.
+func (fs *FilesystemHandler) generatedReason(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	head := make([]byte, generatedHeadBytes)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return ""
+	}
+	head = head[:n]
+	lines := bytes.SplitN(head, []byte("\n"), generatedScanLines+1)
+	if len(lines) > generatedScanLines {
+		lines = lines[:generatedScanLines]
+	}
+	for i, line := range lines {
+		for _, marker := range fs.generatedMarkerList() {
+			if bytes.Contains(line, []byte(marker)) {
+				return fmt.Sprintf("%q on line %d", marker, i+1)
+			}
+		}
+	}
+
+	tail := head
+	if info, err := file.Stat(); err == nil && info.Size() > int64(len(head)) {
+		tail = make([]byte, generatedTailBytes)
+		n, err := file.ReadAt(tail, info.Size()-generatedTailBytes)
+		if err != nil && err != io.EOF {
+			return ""
+		}
+		tail = tail[:n]
+	}
+	tail = bytes.TrimRight(tail, " \t\r\n")
+	last := string(tail[bytes.LastIndexByte(tail, '\n')+1:])
+	for _, prefix := range sourceMapPrefixes {
+		if strings.HasPrefix(last, prefix) {
+			return "source map reference on the last line (bundled output)"
+		}
+	}
+	return ""
+}
+
+// generatedFileError explains why a write to a generated file is refused
+func generatedFileError(path, reason string) error {
+	return fmt.Errorf("%s looks generated (%s): changes would be lost when it is regenerated. Edit its source instead, or pass allow_generated: true", path, reason)
+}
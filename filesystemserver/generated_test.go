@@ -0,0 +1,108 @@
+package filesystemserver
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratedReason(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	bundle := "!function(){" + strings.Repeat("var a=1;", 4096) + "}();\n//# sourceMappingURL=app.min.js.map\n"
+	writeFixture(t, dir, map[string]string{
+		"api.pb.go":      "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage api\n",
+		"schema.ts":      "/**\n * This file is @generated by the schema tool\n */\nexport {}\n",
+		"late.go":        strings.Repeat("\n", generatedScanLines) + "// Code generated below the scanned lines\n",
+		"app.min.js":     bundle,
+		"small.js":       "console.log(1)\n//# sourceMappingURL=small.js.map\n",
+		"handwritten.go": "package main\n\n// The generator says DO NOT EDIT in its output\n",
+		"notes.md":       "sourceMappingURL is described here\n",
+	})
+
+	tests := map[string]string{
+		"api.pb.go":      `"Code generated" on line 1`,
+		"schema.ts":      `"@generated" on line 2`,
+		"late.go":        "",
+		"app.min.js":     "source map reference on the last line (bundled output)",
+		"small.js":       "source map reference on the last line (bundled output)",
+		"handwritten.go": `"DO NOT EDIT" on line 3`,
+		"notes.md":       "",
+		"missing.go":     "",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, handler.generatedReason(filepath.Join(dir, name)), name)
+	}
+
+	custom, err := NewFilesystemHandler([]string{dir}, WithGeneratedMarkers("by the schema tool", " "))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"by the schema tool"}, custom.generatedMarkerList())
+	assert.Equal(t, `"by the schema tool" on line 2`, custom.generatedReason(filepath.Join(dir, "schema.ts")))
+	assert.Empty(t, custom.generatedReason(filepath.Join(dir, "api.pb.go")))
+}
+
+func TestWritersRefuseGeneratedFiles(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "api.pb.go")
+	original := "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage api\n"
+	writeFixture(t, dir, map[string]string{"api.pb.go": original})
+
+	text, isError := callText(t, handler.handleEditFile, "edit_file", map[string]interface{}{
+		"path": path, "old_text": "package api", "new_text": "package v2",
+	})
+	assert.True(t, isError)
+	assert.Contains(t, text, `[E_BAD_ARG] ❌ Error: `+path+` looks generated ("Code generated" on line 1)`)
+	assert.Contains(t, text, "allow_generated: true")
+
+	text, isError = callText(t, handler.handleWriteFile, "write_file", map[string]interface{}{"path": path, "content": "package v2\n"})
+	assert.True(t, isError)
+	assert.Contains(t, text, "looks generated")
+	assertFileContent(t, path, original)
+
+	// allow_generated lo permite, y los archivos nuevos nunca se comprueban
+	result, err := handler.handleEditFile(context.Background(), newToolRequest("edit_file", map[string]interface{}{
+		"path": path, "old_text": "package api", "new_text": "package v2", "allow_generated": true,
+	}))
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assertFileContent(t, path, "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage v2\n")
+
+	_, isError = callText(t, handler.handleWriteFile, "write_file", map[string]interface{}{"path": path, "content": "package v3\n", "allow_generated": true})
+	assert.False(t, isError)
+	assertFileContent(t, path, "package v3\n")
+
+	fresh := filepath.Join(dir, "fresh.pb.go")
+	_, isError = callText(t, handler.handleWriteFile, "write_file", map[string]interface{}{"path": fresh, "content": original})
+	assert.False(t, isError)
+}
+
+func TestGeneratedFilesInAnalysisAndSearch(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{
+		"api.pb.go": "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage api\n\nfunc Handle() {}\n",
+		"main.go":   "package main\n\nfunc Handle() {}\n",
+	})
+
+	text, isError := callText(t, handler.handleAnalyzeFile, "analyze_file", map[string]interface{}{"path": filepath.Join(dir, "api.pb.go")})
+	require.False(t, isError)
+	assert.Contains(t, text, `🏭 Generated: "Code generated" on line 1`)
+	analysis, err := handler.analyzeFile(filepath.Join(dir, "main.go"))
+	require.NoError(t, err)
+	assert.Empty(t, analysis.Generated)
+
+	search := func(exclude bool) string {
+		result, err := handler.handleSmartSearch(context.Background(), newToolRequest("smart_search", map[string]interface{}{
+			"path": dir, "pattern": "func Handle", "include_content": true, "exclude_generated": exclude,
+		}))
+		require.NoError(t, err)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+	assert.Contains(t, search(false), "api.pb.go:4")
+	text = search(true)
+	assert.NotContains(t, text, "api.pb.go")
+	assert.Contains(t, text, "main.go:3")
+}
@@ -22,35 +22,50 @@ func (fs *FilesystemHandler) handleEditFile(ctx context.Context, request mcp.Cal
 			case string:
 				params[param] = v
 			case nil:
-				return nil, fmt.Errorf("parameter %s is null", param)
+				return badArgError(fmt.Errorf("parameter %s is null", param)).result(), nil
 			default:
 				if str, ok := convertToString(v); ok {
 					params[param] = str
 				} else {
-					return nil, fmt.Errorf("parameter %s must be string, got %T: %v", param, v, v)
+					return badArgError(fmt.Errorf("parameter %s must be string, got %T: %v", param, v, v)).result(), nil
 				}
 			}
 		} else {
-			return nil, fmt.Errorf("missing required parameter: %s", param)
+			return badArgError(fmt.Errorf("missing required parameter: %s", param)).result(), nil
 		}
 	}
 
 	path := params["path"]
 	oldText := params["old_text"]
 	newText := params["new_text"]
+	allowGenerated, err := getBool(request.Params.Arguments, "allow_generated", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	dropBOM, err := getBool(request.Params.Arguments, "strip_bom", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
 
 	validPath, err := fs.validatePath(path)
 	if err != nil {
-		return nil, fmt.Errorf("path error: %v", err)
+		return fs.toolError(path, fmt.Errorf("path error: %w", err)).result(), nil
 	}
 
 	if err := fs.validateEditableFile(validPath); err != nil {
-		return nil, fmt.Errorf(err.Error())
+		return fs.toolError(path, err).result(), nil
 	}
+	if !allowGenerated {
+		if reason := fs.generatedReason(validPath); reason != "" {
+			return badArgError(generatedFileError(path, reason)).result(), nil
+		}
+	}
+
+	defer fs.lockPath(validPath)()
 
 	backupPath, err := fs.createBackup(validPath)
 	if err != nil {
-		return nil, fmt.Errorf("could not create backup: %v", err)
+		return fs.toolError(path, fmt.Errorf("could not create backup: %w", err)).result(), nil
 	}
 	defer func() {
 		if backupPath != "" {
@@ -58,19 +73,22 @@ func (fs *FilesystemHandler) handleEditFile(ctx context.Context, request mcp.Cal
 		}
 	}()
 
-	content, err := os.ReadFile(validPath)
+	content, err := fs.readFile(validPath)
 	if err != nil {
-		return nil, fmt.Errorf("error reading file: %v", err)
+		return fs.toolError(path, fmt.Errorf("error reading file: %w", err)).result(), nil
 	}
 
 	analysis := fs.analyzeContent(string(content), oldText)
 	result, err := fs.performIntelligentEdit(string(content), oldText, newText, analysis)
 	if err != nil {
-		return nil, fmt.Errorf(err.Error())
+		return badArgError(err).result(), nil
+	}
+	if dropBOM {
+		result.ModifiedContent = stripBOM(result.ModifiedContent)
 	}
 
-	if err := os.WriteFile(validPath, []byte(result.ModifiedContent), 0644); err != nil {
-		return nil, fmt.Errorf("error writing file: %v", err)
+	if err := fs.writeFileChecked(validPath, []byte(result.ModifiedContent), 0644); err != nil {
+		return fs.toolError(path, fmt.Errorf("error writing file: %w", err)).result(), nil
 	}
 
 	if backupPath != "" {
@@ -85,14 +103,7 @@ func (fs *FilesystemHandler) handleEditFile(ctx context.Context, request mcp.Cal
 				Text: fmt.Sprintf("✅ Successfully edited %s\n📊 Changes: %d replacement(s)\n🎯 Match confidence: %s\n📝 Lines affected: %d",
 					path, result.ReplacementCount, result.MatchConfidence, result.LinesAffected),
 			},
-			mcp.EmbeddedResource{
-				Type: "resource",
-				Resource: mcp.TextResourceContents{
-					URI:      pathToResourceURI(validPath),
-					MIMEType: "text/plain",
-					Text:     fmt.Sprintf("Edited: %s", validPath),
-				},
-			},
+			newFileResource(validPath),
 		},
 	}, nil
 }
@@ -101,42 +112,64 @@ func (fs *FilesystemHandler) handleEditFile(ctx context.Context, request mcp.Cal
 func (fs *FilesystemHandler) handleReadResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
 	uri := request.Params.URI
 
-	if !strings.HasPrefix(uri, "file://") {
-		return nil, fmt.Errorf("unsupported URI scheme: %s", uri)
-	}
-
-	path := strings.TrimPrefix(uri, "file://")
-	validPath, err := fs.validatePath(path)
+	paths, err := resourceURIPaths(uri, windowsPaths)
 	if err != nil {
 		return nil, err
 	}
 
-	fileInfo, err := os.Stat(validPath)
-	if err != nil {
-		return nil, err
+	// Use the first candidate that exists: decoded URI, then the legacy unencoded form
+	var validPath string
+	var fileInfo os.FileInfo
+	var firstErr error
+	decoded := false
+	for i, path := range paths {
+		validPath, err = fs.validatePath(path)
+		if err == nil {
+			fileInfo, err = os.Stat(validPath)
+		}
+		if err == nil {
+			decoded = i == 0
+			break
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if fileInfo == nil {
+		return nil, firstErr
 	}
 
 	if fileInfo.IsDir() {
+		// ?depth=2&glob=*.go&format=json, only on the decoded form: in the
+		// legacy one a ? is part of the name
+		if query := resourceURIQuery(uri); decoded && len(query) > 0 {
+			return fs.readDirectoryResource(uri, validPath, query)
+		}
+
 		entries, err := os.ReadDir(validPath)
 		if err != nil {
 			return nil, err
 		}
 
 		var result strings.Builder
-		result.WriteString(fmt.Sprintf("Directory listing for: %s\n\n", validPath))
+		result.WriteString(fmt.Sprintf("Directory listing for: %s\n\n", safeDisplayName(validPath)))
 
 		for _, entry := range entries {
 			entryPath := filepath.Join(validPath, entry.Name())
+			if fs.isDeniedPath(entryPath) {
+				continue
+			}
 			entryURI := pathToResourceURI(entryPath)
 
 			if entry.IsDir() {
-				result.WriteString(fmt.Sprintf("[DIR]  %s (%s)\n", entry.Name(), entryURI))
+				entryURI = dirResourceURI(entryPath)
+				result.WriteString(fmt.Sprintf("[DIR]  %s (%s)\n", safeDisplayName(entry.Name()), entryURI))
 			} else {
 				info, err := entry.Info()
 				if err == nil {
-					result.WriteString(fmt.Sprintf("[FILE] %s (%s) - %d bytes\n", entry.Name(), entryURI, info.Size()))
+					result.WriteString(fmt.Sprintf("[FILE] %s (%s) - %d bytes\n", safeDisplayName(entry.Name()), entryURI, info.Size()))
 				} else {
-					result.WriteString(fmt.Sprintf("[FILE] %s (%s)\n", entry.Name(), entryURI))
+					result.WriteString(fmt.Sprintf("[FILE] %s (%s)\n", safeDisplayName(entry.Name()), entryURI))
 				}
 			}
 		}
@@ -155,12 +188,12 @@ func (fs *FilesystemHandler) handleReadResource(ctx context.Context, request mcp
 			mcp.TextResourceContents{
 				URI:      uri,
 				MIMEType: "text/plain",
-				Text:     fmt.Sprintf("File is too large to display inline (%d bytes). Use the read_file tool to access specific portions.", fileInfo.Size()),
+				Text:     fmt.Sprintf("File is too large to display inline (%d bytes). Use the %s tool to access specific portions.", fileInfo.Size(), fs.toolName("read_file")),
 			},
 		}, nil
 	}
 
-	content, err := os.ReadFile(validPath)
+	content, err := fs.readFile(validPath)
 	if err != nil {
 		return nil, err
 	}
@@ -189,7 +222,7 @@ func (fs *FilesystemHandler) handleReadResource(ctx context.Context, request mcp
 				mcp.TextResourceContents{
 					URI:      uri,
 					MIMEType: "text/plain",
-					Text:     fmt.Sprintf("Binary file (%s, %d bytes). Use the read_file tool to access specific portions.", mimeType, fileInfo.Size()),
+					Text:     fmt.Sprintf("Binary file (%s, %d bytes). Use the %s tool to access specific portions.", mimeType, fileInfo.Size(), fs.toolName("read_file")),
 				},
 			}, nil
 		}
@@ -197,13 +230,8 @@ func (fs *FilesystemHandler) handleReadResource(ctx context.Context, request mcp
 }
 
 // Placeholder handlers - implementaciones básicas
-func (fs *FilesystemHandler) handleAnalyzeFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{Type: "text", Text: "Feature not implemented yet"},
-		},
-	}, nil
-}
+
+// handleAnalyzeFile - Implementado en handler_analyze.go
 
 // handleAnalyzeProject - Implementado en handler_analyze.go
 
@@ -217,13 +245,7 @@ func (fs *FilesystemHandler) handleAnalyzeDependencies(ctx context.Context, requ
 	}, nil
 }
 
-func (fs *FilesystemHandler) handleCodeQualityCheck(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{Type: "text", Text: "Feature not implemented yet"},
-		},
-	}, nil
-}
+// handleCodeQualityCheck - Implementado en handler_quality.go
 
 func (fs *FilesystemHandler) handlePerformanceAnalysis(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return &mcp.CallToolResult{
@@ -233,26 +255,8 @@ func (fs *FilesystemHandler) handlePerformanceAnalysis(ctx context.Context, requ
 	}, nil
 }
 
-func (fs *FilesystemHandler) handleGenerateReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{Type: "text", Text: "Feature not implemented yet"},
-		},
-	}, nil
-}
+// handleGenerateReport - Implementado en handler_report.go
 
-func (fs *FilesystemHandler) handleSmartSync(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{Type: "text", Text: "Feature not implemented yet"},
-		},
-	}, nil
-}
+// handleSmartSync - Implementado en handler_sync.go
 
-func (fs *FilesystemHandler) handleAssistRefactor(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{Type: "text", Text: "Feature not implemented yet"},
-		},
-	}, nil
-}
+// handleAssistRefactor - Implementado en handler_refactor.go
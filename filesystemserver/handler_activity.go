@@ -0,0 +1,331 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultActivitySince - Ventana de recent_activity si no se indica since ni snapshot
+	defaultActivitySince = "24h"
+	// activityTopFiles - Archivos que destaca recent_activity
+	activityTopFiles = 10
+)
+
+// handleRecentActivity - Qué ha cambiado en un directorio desde una fecha o un
+// snapshot: archivos agrupados por directorio, bytes cambiados, los archivos
+// con más cambios y los directorios nuevos o eliminados
+func (fs *FilesystemHandler) handleRecentActivity(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	since, err := getString(request.Params.Arguments, "since", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	snapshotID, err := getString(request.Params.Arguments, "baseline_snapshot", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	hashVerify, err := getBool(request.Params.Arguments, "hash_verify", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if since != "" && snapshotID != "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: provide since or baseline_snapshot, not both"},
+			},
+			IsError: true,
+		}, nil
+	}
+	if since == "" && snapshotID == "" {
+		since = defaultActivitySince
+	}
+
+	var validPath string
+	if path == "" {
+		validPath, err = fs.workspaceDir()
+	} else {
+		validPath, err = fs.validatePath(path)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	if info, err := os.Stat(validPath); err != nil || !info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: Path must be a directory"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	baseline, err := fs.parseChangeBaseline(validPath, since, snapshotID)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	activity, err := fs.recentActivity(ctx, validPath, baseline, hashVerify)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatRecentActivity(activity)},
+		},
+	}, pathToResourceURI(validPath), activity)
+}
+
+// recentActivity - Resume los cambios de detect_changes bajo el directorio
+// path; el árbol se recorre una sola vez y los directorios nuevos o eliminados
+// salen de las rutas de los archivos cambiados
+func (fs *FilesystemHandler) recentActivity(ctx context.Context, path string, baseline changeBaseline, hashVerify bool) (*RecentActivity, error) {
+	var include, exclude []string
+	if baseline.snapshot != nil {
+		include, exclude = baseline.snapshot.Include, baseline.snapshot.Exclude
+	}
+	report, changes, err := fs.collectChanges(ctx, path, baseline, include, exclude, hashVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	activity := &RecentActivity{
+		Path:               path,
+		Baseline:           report.Baseline,
+		Since:              report.Since,
+		Created:            report.Created,
+		Modified:           report.Modified,
+		Deleted:            report.Deleted,
+		Directories:        []ActivityDirectory{},
+		TopFiles:           []ActivityFile{},
+		NewDirectories:     []string{},
+		DeletedDirectories: []string{},
+		Notes:              report.Notes,
+	}
+
+	groups := make(map[string]*ActivityDirectory)
+	files := make([]ActivityFile, 0, len(changes))
+	listed := 0
+	for _, change := range changes {
+		file := ActivityFile{ChangedFile: change}
+		churn := change.Size
+		if baseline.snapshot != nil {
+			delta := snapshotSizeDelta(path, baseline, change)
+			file.SizeDelta = &delta
+			churn = max(delta, -delta)
+		}
+		activity.ChurnBytes += churn
+		files = append(files, file)
+
+		dir := filepath.ToSlash(filepath.Dir(filepath.FromSlash(change.Path)))
+		group, ok := groups[dir]
+		if !ok {
+			group = &ActivityDirectory{Path: dir, Files: []string{}}
+			groups[dir] = group
+		}
+		switch change.Change {
+		case "created":
+			group.Created++
+		case "modified":
+			group.Modified++
+		case "deleted":
+			group.Deleted++
+		}
+		group.Bytes += churn
+		if listed < maxChangeEntries {
+			group.Files = append(group.Files, filepath.Base(filepath.FromSlash(change.Path)))
+			listed++
+		} else {
+			activity.Truncated = true
+		}
+	}
+	for _, group := range groups {
+		activity.Directories = append(activity.Directories, *group)
+	}
+	sort.Slice(activity.Directories, func(i, j int) bool { return activity.Directories[i].Path < activity.Directories[j].Path })
+
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		if a.SizeDelta != nil && b.SizeDelta != nil {
+			da, db := max(*a.SizeDelta, -*a.SizeDelta), max(*b.SizeDelta, -*b.SizeDelta)
+			if da != db {
+				return da > db
+			}
+		}
+		return a.ModTime.After(b.ModTime)
+	})
+	activity.TopFiles = append(activity.TopFiles, files[:min(len(files), activityTopFiles)]...)
+
+	activity.NewDirectories, activity.DeletedDirectories = changedDirectories(path, baseline, changes)
+	if baseline.snapshot == nil && len(changes) > 0 && !creationTimesKnown(path) {
+		activity.Notes = append(activity.Notes, "New directories cannot be told apart without creation times; pass baseline_snapshot to list them")
+	}
+	return activity, nil
+}
+
+// snapshotSizeDelta - Diferencia de tamaño de un cambio frente al snapshot
+func snapshotSizeDelta(path string, baseline changeBaseline, change ChangedFile) int64 {
+	switch change.Change {
+	case "created":
+		return change.Size
+	case "deleted":
+		return -change.Size
+	}
+	rel, err := filepath.Rel(baseline.snapshot.Source, filepath.Join(path, filepath.FromSlash(change.Path)))
+	if err != nil {
+		return 0
+	}
+	return change.Size - baseline.files[filepath.ToSlash(rel)].Size
+}
+
+// changedDirectories - Directorios nuevos y eliminados, relativos a path. Con
+// snapshot, es nuevo el que contiene archivos creados y ningún archivo del
+// snapshot (un directorio que estaba vacío también cuenta), y eliminado el de
+// archivos eliminados que ya no existe. Sin snapshot, es nuevo el directorio de
+// un archivo cambiado creado después de since, si el sistema guarda esa fecha.
+// Solo se lista el más alto de cada rama.
+func changedDirectories(path string, baseline changeBaseline, changes []ChangedFile) (created, deleted []string) {
+	known := make(map[string]bool)
+	if baseline.snapshot != nil {
+		for _, stored := range baseline.snapshot.Files {
+			original := filepath.Join(baseline.snapshot.Source, filepath.FromSlash(stored.Path))
+			if rel, err := filepath.Rel(path, original); err == nil && pathWithinDir(original, path) {
+				for _, dir := range parentDirs(filepath.ToSlash(rel)) {
+					known[dir] = true
+				}
+			}
+		}
+	}
+
+	newDirs, deletedDirs := make(map[string]bool), make(map[string]bool)
+	checked := make(map[string]bool)
+	for _, change := range changes {
+		if baseline.snapshot != nil && change.Change == "modified" {
+			continue
+		}
+		for _, dir := range parentDirs(change.Path) {
+			if checked[dir] {
+				continue
+			}
+			checked[dir] = true
+			full := filepath.Join(path, filepath.FromSlash(dir))
+			info, err := os.Stat(full)
+			switch {
+			case baseline.snapshot == nil:
+				if err == nil {
+					times := platformFileTimes(full, info)
+					if times.hasCreated && times.created.After(baseline.since) {
+						newDirs[dir] = true
+					}
+				}
+			case change.Change == "created":
+				if err == nil && !known[dir] {
+					newDirs[dir] = true
+				}
+			case change.Change == "deleted":
+				if os.IsNotExist(err) {
+					deletedDirs[dir] = true
+				}
+			}
+		}
+	}
+	return topmostDirs(newDirs), topmostDirs(deletedDirs)
+}
+
+// parentDirs - Directorios que contienen la ruta relativa rel, sin incluir "."
+func parentDirs(rel string) []string {
+	var dirs []string
+	for dir := filepath.ToSlash(filepath.Dir(filepath.FromSlash(rel))); dir != "." && dir != "/" && dir != ""; dir = filepath.ToSlash(filepath.Dir(filepath.FromSlash(dir))) {
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// topmostDirs - Directorios del conjunto cuyo padre no está también en él, ordenados
+func topmostDirs(dirs map[string]bool) []string {
+	top := []string{}
+	for dir := range dirs {
+		parents := parentDirs(dir)
+		if len(parents) > 0 && dirs[parents[0]] {
+			continue
+		}
+		top = append(top, dir)
+	}
+	sort.Strings(top)
+	return top
+}
+
+// creationTimesKnown - Indica si el sistema de archivos de path guarda fechas de creación
+func creationTimesKnown(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && platformFileTimes(path, info).hasCreated
+}
+
+// formatRecentActivity - Resumen legible de recent_activity
+func formatRecentActivity(activity *RecentActivity) string {
+	var b strings.Builder
+	if activity.Baseline == "since" {
+		fmt.Fprintf(&b, "🕒 Activity in %s since %s\n", activity.Path, activity.Since.Format(time.RFC3339))
+	} else {
+		fmt.Fprintf(&b, "📸 Activity in %s since snapshot %s (%s)\n", activity.Path, activity.Baseline, activity.Since.Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "📊 %d created, %d modified, %d deleted, %d bytes of churn\n", activity.Created, activity.Modified, activity.Deleted, activity.ChurnBytes)
+	for _, note := range activity.Notes {
+		fmt.Fprintf(&b, "ℹ️ %s\n", note)
+	}
+	if len(activity.Directories) == 0 {
+		b.WriteString("\n✅ No activity\n")
+		return b.String()
+	}
+
+	if len(activity.NewDirectories) > 0 {
+		fmt.Fprintf(&b, "➕ New directories: %s\n", strings.Join(activity.NewDirectories, ", "))
+	}
+	if len(activity.DeletedDirectories) > 0 {
+		fmt.Fprintf(&b, "➖ Deleted directories: %s\n", strings.Join(activity.DeletedDirectories, ", "))
+	}
+
+	b.WriteString("\n🔥 Most changed:\n")
+	for _, file := range activity.TopFiles {
+		if file.SizeDelta != nil {
+			fmt.Fprintf(&b, "  %s %s (%+d bytes)\n", file.Change, file.Path, *file.SizeDelta)
+		} else {
+			fmt.Fprintf(&b, "  %s %s (%s)\n", file.Change, file.Path, file.ModTime.Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	b.WriteString("\n📁 By directory:\n")
+	for _, dir := range activity.Directories {
+		fmt.Fprintf(&b, "  %s/ (%d created, %d modified, %d deleted, %d bytes): %s\n", dir.Path, dir.Created, dir.Modified, dir.Deleted, dir.Bytes, strings.Join(dir.Files, ", "))
+	}
+	if activity.Truncated {
+		fmt.Fprintf(&b, "... only the first %d files are listed\n", maxChangeEntries)
+	}
+	return b.String()
+}
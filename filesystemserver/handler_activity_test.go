@@ -0,0 +1,139 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecentActivityAgainstSnapshot(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	project := filepath.Join(dir, "project")
+	writeFixture(t, project, map[string]string{
+		"main.go":          "package main",
+		"docs/guide.md":    "short",
+		"old/a.txt":        "aaa",
+		"old/deep/b.txt":   "bbbbbb",
+		"src/keep.go":      "package src",
+		"src/shrink.go":    strings.Repeat("x", 100),
+		"build/output.bin": "artifact",
+	})
+	snapshot, _, err := handler.createSnapshot(context.Background(), project, nil, []string{"build"})
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(project, "docs", "guide.md"), []byte(strings.Repeat("y", 50)), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(project, "src", "shrink.go"), []byte("x"), 0644))
+	require.NoError(t, os.RemoveAll(filepath.Join(project, "old")))
+	writeFixture(t, project, map[string]string{
+		"feature/api/handler.go": "package api",
+		"build/output.bin":       "rebuilt artifact",
+	})
+
+	result, err := handler.handleRecentActivity(context.Background(), newToolRequest("recent_activity", map[string]interface{}{
+		"path": project, "baseline_snapshot": snapshot.ID,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	var activity RecentActivity
+	decodeStructured(t, result, &activity)
+
+	assert.Equal(t, snapshot.ID, activity.Baseline)
+	assert.Equal(t, 1, activity.Created)
+	assert.Equal(t, 2, activity.Modified)
+	assert.Equal(t, 2, activity.Deleted)
+	assert.Equal(t, int64(len("package api")+45+99+3+6), activity.ChurnBytes, "build/ stays excluded with the snapshot's filters")
+	assert.Equal(t, []string{"feature"}, activity.NewDirectories)
+	assert.Equal(t, []string{"old"}, activity.DeletedDirectories)
+
+	var paths []string
+	dirs := make(map[string]ActivityDirectory)
+	for _, group := range activity.Directories {
+		paths = append(paths, group.Path)
+		dirs[group.Path] = group
+	}
+	assert.Equal(t, []string{"docs", "feature/api", "old", "old/deep", "src"}, paths)
+	assert.Equal(t, []string{"shrink.go"}, dirs["src"].Files)
+	assert.Equal(t, 1, dirs["old/deep"].Deleted)
+
+	require.Len(t, activity.TopFiles, 5)
+	assert.Equal(t, "src/shrink.go", activity.TopFiles[0].Path)
+	require.NotNil(t, activity.TopFiles[0].SizeDelta)
+	assert.Equal(t, int64(-99), *activity.TopFiles[0].SizeDelta)
+	assert.Equal(t, "docs/guide.md", activity.TopFiles[1].Path)
+
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "1 created, 2 modified, 2 deleted")
+	assert.Contains(t, text, "➖ Deleted directories: old")
+	assert.Contains(t, text, "modified src/shrink.go (-99 bytes)")
+}
+
+func TestRecentActivitySince(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{"old.txt": "old", "pkg/touched.go": "v1"})
+	past := time.Now().Add(-48 * time.Hour)
+	for _, name := range []string{"old.txt", "pkg/touched.go", "pkg"} {
+		require.NoError(t, os.Chtimes(filepath.Join(dir, filepath.FromSlash(name)), past, past))
+	}
+
+	// Sin path ni since: el espacio de trabajo en las últimas 24 horas
+	result, err := handler.handleRecentActivity(context.Background(), newToolRequest("recent_activity", map[string]interface{}{}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	var activity RecentActivity
+	decodeStructured(t, result, &activity)
+	assert.Equal(t, dir, activity.Path)
+	assert.Equal(t, "since", activity.Baseline)
+	assert.WithinDuration(t, time.Now().Add(-24*time.Hour), activity.Since, time.Minute)
+
+	time.Sleep(20 * time.Millisecond)
+	since := time.Now()
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "touched.go"), []byte("v2"), 0644))
+	time.Sleep(20 * time.Millisecond)
+	writeFixture(t, dir, map[string]string{"notes/today.md": "# today"})
+
+	result, err = handler.handleRecentActivity(context.Background(), newToolRequest("recent_activity", map[string]interface{}{
+		"path": dir, "since": since.Format(time.RFC3339Nano),
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	activity = RecentActivity{}
+	decodeStructured(t, result, &activity)
+	assert.Equal(t, 2, activity.Created+activity.Modified)
+	assert.Equal(t, int64(len("v2")+len("# today")), activity.ChurnBytes)
+	require.Len(t, activity.TopFiles, 2)
+	assert.Equal(t, "notes/today.md", activity.TopFiles[0].Path, "most recently modified first")
+	assert.Nil(t, activity.TopFiles[0].SizeDelta)
+	assert.Empty(t, activity.DeletedDirectories)
+	if creationTimesKnown(dir) {
+		assert.Equal(t, []string{"notes"}, activity.NewDirectories)
+	} else {
+		assert.Empty(t, activity.NewDirectories)
+	}
+
+	result, err = handler.handleRecentActivity(context.Background(), newToolRequest("recent_activity", map[string]interface{}{
+		"path": dir, "since": "1ms",
+	}))
+	require.NoError(t, err)
+	decodeStructured(t, result, &activity)
+	assert.Empty(t, activity.Directories)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "No activity")
+
+	for _, bad := range []map[string]interface{}{
+		{"path": dir, "since": "1h", "baseline_snapshot": "snap_20240101T000000_deadbeef"},
+		{"path": dir, "since": "yesterday"},
+		{"path": filepath.Join(dir, "old.txt")},
+		{"path": dir, "baseline_snapshot": "snap_20240101T000000_deadbeef"},
+	} {
+		result, err := handler.handleRecentActivity(context.Background(), newToolRequest("recent_activity", bad))
+		require.NoError(t, err)
+		assert.True(t, result.IsError, bad)
+	}
+}
@@ -2,37 +2,29 @@ package filesystemserver
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // handleSearchFiles searches for files matching a pattern
 func (fs *FilesystemHandler) handleSearchFiles(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	path, ok := request.Params.Arguments["path"].(string)
-	if !ok {
-		return nil, fmt.Errorf("path must be a string")
-	}
-	pattern, ok := request.Params.Arguments["pattern"].(string)
-	if !ok {
-		return nil, fmt.Errorf("pattern must be a string")
+	path, err := requireString(request.Params.Arguments, "path")
+	if err != nil {
+		return badArgError(err).result(), nil
 	}
-
-	if path == "." || path == "./" {
-		cwd, err := os.Getwd()
-		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error resolving current directory: %v", err)},
-				},
-				IsError: true,
-			}, nil
-		}
-		path = cwd
+	pattern, err := requireString(request.Params.Arguments, "pattern")
+	if err != nil {
+		return badArgError(err).result(), nil
 	}
 
 	validPath, err := fs.validatePath(path)
@@ -64,7 +56,32 @@ func (fs *FilesystemHandler) handleSearchFiles(ctx context.Context, request mcp.
 		}, nil
 	}
 
-	results, err := fs.searchFiles(validPath, pattern)
+	relativeTo, err := getString(request.Params.Arguments, "relative_to", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	display, err := fs.pathDisplay(relativeTo)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	page, err := newResultPage(validPath, request.Params.Arguments, paramsHash("search_files", validPath, pattern))
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	ctx, warnings := withWalkWarnings(ctx)
+	results, err := fs.searchFilesPage(ctx, validPath, pattern, page)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -77,67 +94,59 @@ func (fs *FilesystemHandler) handleSearchFiles(ctx context.Context, request mcp.
 	if len(results) == 0 {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				mcp.TextContent{Type: "text", Text: fmt.Sprintf("No files found matching pattern '%s' in %s", pattern, path)},
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("No files found matching pattern '%s' in %s", pattern, path) + strings.TrimSuffix(warnings.note(display), "\n")},
 			},
 		}, nil
 	}
 
-	var formattedResults strings.Builder
+	formattedResults := fs.newResultBuilder()
 	formattedResults.WriteString(fmt.Sprintf("Found %d results:\n\n", len(results)))
 
+	lastShown := ""
 	for _, result := range results {
 		resourceURI := pathToResourceURI(result)
+		var line string
 		info, err := os.Stat(result)
 		if err == nil {
 			if info.IsDir() {
-				formattedResults.WriteString(fmt.Sprintf("[DIR]  %s (%s)\n", result, resourceURI))
+				line = fmt.Sprintf("[DIR]  %s (%s)\n", safeDisplayName(display(result)), resourceURI)
 			} else {
-				formattedResults.WriteString(fmt.Sprintf("[FILE] %s (%s) - %d bytes\n", result, resourceURI, info.Size()))
+				line = fmt.Sprintf("[FILE] %s (%s) - %d bytes\n", safeDisplayName(display(result)), resourceURI, info.Size())
 			}
 		} else {
-			formattedResults.WriteString(fmt.Sprintf("%s (%s)\n", result, resourceURI))
+			line = fmt.Sprintf("%s (%s)\n", safeDisplayName(display(result)), resourceURI)
+		}
+		if !formattedResults.item(line) {
+			formattedResults.stopPage(page, lastShown)
+			break
 		}
+		lastShown = result
 	}
+	formattedResults.tail(warnings.note(display))
+	formattedResults.tail(page.footer())
+	text, _ := formattedResults.done(validPath, "search_files")
 
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
-			mcp.TextContent{Type: "text", Text: formattedResults.String()},
+			mcp.TextContent{Type: "text", Text: text},
 		},
 	}, nil
 }
 
 // handleTree generates a tree view of directory structure
 func (fs *FilesystemHandler) handleTree(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	path, ok := request.Params.Arguments["path"].(string)
-	if !ok {
-		return nil, fmt.Errorf("path must be a string")
-	}
-
-	if path == "." || path == "./" {
-		cwd, err := os.Getwd()
-		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error resolving current directory: %v", err)},
-				},
-				IsError: true,
-			}, nil
-		}
-		path = cwd
+	path, err := requireString(request.Params.Arguments, "path")
+	if err != nil {
+		return badArgError(err).result(), nil
 	}
 
-	depth := 3
-	if depthParam, ok := request.Params.Arguments["depth"]; ok {
-		if d, ok := depthParam.(float64); ok {
-			depth = int(d)
-		}
+	depth, err := getInt(request.Params.Arguments, "depth", 3)
+	if err != nil {
+		return badArgError(err).result(), nil
 	}
-
-	followSymlinks := false
-	if followParam, ok := request.Params.Arguments["follow_symlinks"]; ok {
-		if f, ok := followParam.(bool); ok {
-			followSymlinks = f
-		}
+	followSymlinks, err := getBool(request.Params.Arguments, "follow_symlinks", false)
+	if err != nil {
+		return badArgError(err).result(), nil
 	}
 
 	validPath, err := fs.validatePath(path)
@@ -169,7 +178,22 @@ func (fs *FilesystemHandler) handleTree(ctx context.Context, request mcp.CallToo
 		}, nil
 	}
 
-	tree, err := fs.buildTree(validPath, depth, 0, followSymlinks)
+	relativeTo, err := getString(request.Params.Arguments, "relative_to", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	display, err := fs.pathDisplay(relativeTo)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	ctx, warnings := withWalkWarnings(ctx)
+	tree, err := fs.buildTree(ctx, validPath, depth, 0, followSymlinks)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -178,6 +202,7 @@ func (fs *FilesystemHandler) handleTree(ctx context.Context, request mcp.CallToo
 			IsError: true,
 		}, nil
 	}
+	displayTreePaths(tree, display)
 
 	jsonData, err := json.MarshalIndent(tree, "", "  ")
 	if err != nil {
@@ -189,40 +214,40 @@ func (fs *FilesystemHandler) handleTree(ctx context.Context, request mcp.CallToo
 		}, nil
 	}
 
+	// Past the response budget only a breadth-first part of the tree is
+	// shown, in the text and the resource alike, and the full JSON is saved
+	header := fmt.Sprintf("Directory tree for %s (max depth: %d):\n\n", safeDisplayName(display(validPath)), depth)
+	result := fs.newResultBuilder()
+	shownData := jsonData
+	if len(header)+len(jsonData) > result.limit {
+		var shown, total int
+		shownData, shown, total = fitTree(tree, result.limit-len(header))
+		result.WriteString(header + string(shownData))
+		result.truncate(shown, total, string(jsonData))
+	} else {
+		result.WriteString(header + string(jsonData))
+	}
+	result.tail(warnings.note(display))
+	text, spill := result.done(validPath, "tree")
+
 	resourceURI := pathToResourceURI(validPath)
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{Type: "text", Text: fmt.Sprintf("Directory tree for %s (max depth: %d):\n\n%s", validPath, depth, string(jsonData))},
-			mcp.EmbeddedResource{
-				Type: "resource",
-				Resource: mcp.TextResourceContents{
-					URI:      resourceURI,
-					MIMEType: "application/json",
-					Text:     string(jsonData),
-				},
-			},
+	content := append([]mcp.Content{mcp.TextContent{Type: "text", Text: text}}, spill...)
+	content = append(content, mcp.EmbeddedResource{
+		Type: "resource",
+		Resource: mcp.TextResourceContents{
+			URI:      resourceURI,
+			MIMEType: "application/json",
+			Text:     string(shownData),
 		},
-	}, nil
+	})
+	return &mcp.CallToolResult{Content: content}, nil
 }
 
 // handleGetFileInfo gets detailed file information
 func (fs *FilesystemHandler) handleGetFileInfo(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	path, ok := request.Params.Arguments["path"].(string)
-	if !ok {
-		return nil, fmt.Errorf("path must be a string")
-	}
-
-	if path == "." || path == "./" {
-		cwd, err := os.Getwd()
-		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error resolving current directory: %v", err)},
-				},
-				IsError: true,
-			}, nil
-		}
-		path = cwd
+	path, err := requireString(request.Params.Arguments, "path")
+	if err != nil {
+		return badArgError(err).result(), nil
 	}
 
 	validPath, err := fs.validatePath(path)
@@ -235,7 +260,11 @@ func (fs *FilesystemHandler) handleGetFileInfo(ctx context.Context, request mcp.
 		}, nil
 	}
 
-	info, err := fs.getFileStats(validPath)
+	includeXattrs, err := getBool(request.Params.Arguments, "include_xattrs", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	info, err := fs.describePath(path, includeXattrs)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -246,59 +275,53 @@ func (fs *FilesystemHandler) handleGetFileInfo(ctx context.Context, request mcp.
 	}
 
 	mimeType := "directory"
+	imageText := ""
 	if info.IsFile {
 		mimeType = detectMimeType(validPath)
 	}
+	if info.Image != nil {
+		imageText = "\nImage: " + formatImageInfo(info.Image)
+	}
 
 	resourceURI := pathToResourceURI(validPath)
 
-	var fileTypeText string
-	if info.IsDirectory {
-		fileTypeText = "Directory"
-	} else {
-		fileTypeText = "File"
-	}
-
-	return &mcp.CallToolResult{
+	return withStructuredContent(&mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
 				Text: fmt.Sprintf(
-					"File information for: %s\n\nSize: %d bytes\nCreated: %s\nModified: %s\nAccessed: %s\nIsDirectory: %v\nIsFile: %v\nPermissions: %s\nMIME Type: %s\nResource URI: %s",
+					"File information for: %s\n\nSize: %d bytes\nCreated: %s%s\nModified: %s\nAccessed: %s%s\nIsDirectory: %v\nIsFile: %v\nPermissions: %s%s\nMIME Type: %s%s\nResource URI: %s",
 					validPath,
 					info.Size,
 					info.Created.Format("2006-01-02 15:04:05"),
+					estimatedTimeNote(info.EstimatedTimes, "created"),
 					info.Modified.Format("2006-01-02 15:04:05"),
 					info.Accessed.Format("2006-01-02 15:04:05"),
+					estimatedTimeNote(info.EstimatedTimes, "accessed"),
 					info.IsDirectory,
 					info.IsFile,
 					info.Permissions,
+					formatFileOwnership(info),
 					mimeType,
+					imageText,
 					resourceURI,
 				),
 			},
-			mcp.EmbeddedResource{
-				Type: "resource",
-				Resource: mcp.TextResourceContents{
-					URI:      resourceURI,
-					MIMEType: "text/plain",
-					Text:     fmt.Sprintf("%s: %s (%s, %d bytes)", fileTypeText, validPath, mimeType, info.Size),
-				},
-			},
+			newFileResource(validPath),
 		},
-	}, nil
+	}, resourceURI, info)
 }
 
 // handleReadMultipleFiles reads multiple files at once
 func (fs *FilesystemHandler) handleReadMultipleFiles(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	pathsParam, ok := request.Params.Arguments["paths"]
 	if !ok {
-		return nil, fmt.Errorf("paths parameter is required")
+		return badArgError(errors.New("paths parameter is required")).result(), nil
 	}
 
 	pathsSlice, ok := pathsParam.([]any)
 	if !ok {
-		return nil, fmt.Errorf("paths must be an array of strings")
+		return badArgError(errors.New("paths must be an array of strings")).result(), nil
 	}
 
 	if len(pathsSlice) == 0 {
@@ -310,33 +333,29 @@ func (fs *FilesystemHandler) handleReadMultipleFiles(ctx context.Context, reques
 		}, nil
 	}
 
-	const maxFiles = 50
-	if len(pathsSlice) > maxFiles {
+	// Globs are expanded first, so the limit counts the files actually read
+	pathArgs, err := fs.expandPathArgs(pathsSlice)
+	if errors.Is(err, errTooManyPaths) {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				mcp.TextContent{Type: "text", Text: fmt.Sprintf("Too many files requested. Maximum is %d files per request.", maxFiles)},
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("Too many files requested. Maximum is %d files per request.", maxMultiplePaths)},
 			},
 			IsError: true,
 		}, nil
 	}
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
 
 	var results []mcp.Content
-	for _, pathInterface := range pathsSlice {
-		path, ok := pathInterface.(string)
-		if !ok {
-			return nil, fmt.Errorf("each path must be a string")
-		}
-
-		if path == "." || path == "./" {
-			cwd, err := os.Getwd()
-			if err != nil {
-				results = append(results, mcp.TextContent{
-					Type: "text",
-					Text: fmt.Sprintf("Error resolving current directory for path '%s': %v", path, err),
-				})
-				continue
-			}
-			path = cwd
+	for _, arg := range pathArgs {
+		path := arg.path
+		if arg.err != nil {
+			results = append(results, mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("Error with path '%s': %v", path, arg.err),
+			})
+			continue
 		}
 
 		validPath, err := fs.validatePath(path)
@@ -361,7 +380,7 @@ func (fs *FilesystemHandler) handleReadMultipleFiles(ctx context.Context, reques
 			resourceURI := pathToResourceURI(validPath)
 			results = append(results, mcp.TextContent{
 				Type: "text",
-				Text: fmt.Sprintf("'%s' is a directory. Use list_directory tool or resource URI: %s", path, resourceURI),
+				Text: fmt.Sprintf("'%s' is a directory. Use %s tool or resource URI: %s", path, fs.toolName("list_directory"), resourceURI),
 			})
 			continue
 		}
@@ -375,7 +394,7 @@ func (fs *FilesystemHandler) handleReadMultipleFiles(ctx context.Context, reques
 			continue
 		}
 
-		content, err := os.ReadFile(validPath)
+		content, err := fs.readFile(validPath)
 		if err != nil {
 			results = append(results, mcp.TextContent{
 				Type: "text",
@@ -393,7 +412,7 @@ func (fs *FilesystemHandler) handleReadMultipleFiles(ctx context.Context, reques
 		if isTextFile(mimeType) {
 			results = append(results, mcp.TextContent{
 				Type: "text",
-				Text: string(content),
+				Text: stripBOM(string(content)),
 			})
 		} else {
 			resourceURI := pathToResourceURI(validPath)
@@ -411,17 +430,21 @@ func (fs *FilesystemHandler) handleReadMultipleFiles(ctx context.Context, reques
 
 // handleListAllowedDirectories lists allowed directories
 func (fs *FilesystemHandler) handleListAllowedDirectories(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	displayDirs := make([]string, len(fs.allowedDirs))
-	for i, dir := range fs.allowedDirs {
+	allowedDirs := fs.allowedDirectories()
+	displayDirs := make([]string, len(allowedDirs))
+	for i, dir := range allowedDirs {
 		displayDirs[i] = strings.TrimSuffix(dir, string(filepath.Separator))
 	}
 
 	var result strings.Builder
 	result.WriteString("Allowed directories:\n\n")
 
-	for _, dir := range displayDirs {
+	for i, dir := range displayDirs {
 		resourceURI := pathToResourceURI(dir)
-		result.WriteString(fmt.Sprintf("%s (%s)\n", dir, resourceURI))
+		result.WriteString(fmt.Sprintf("%s: %s (%s)\n", fs.rootLabel(allowedDirs[i]), dir, resourceURI))
+	}
+	if len(allowedDirs) > 0 {
+		result.WriteString(fmt.Sprintf("\n💡 Paths can be given as label:relative/path, e.g. %s:src/main.go\n", fs.rootLabel(allowedDirs[0])))
 	}
 
 	return &mcp.CallToolResult{
@@ -431,28 +454,125 @@ func (fs *FilesystemHandler) handleListAllowedDirectories(ctx context.Context, r
 	}, nil
 }
 
+// handleAddAllowedDirectory grants access to a directory for the rest of the session
+func (fs *FilesystemHandler) handleAddAllowedDirectory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	dir, err := fs.addAllowedDir(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	fs.notifyRootsChanged()
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: fmt.Sprintf("✅ Allowed directory added: %s (label: %s)", strings.TrimSuffix(dir, string(filepath.Separator)), fs.rootLabel(dir))},
+		},
+	}, nil
+}
+
+// handleRemoveAllowedDirectory revokes a directory added with add_allowed_directory
+func (fs *FilesystemHandler) handleRemoveAllowedDirectory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	dir, err := fs.removeAllowedDir(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	fs.notifyRootsChanged()
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: fmt.Sprintf("✅ Allowed directory removed: %s", strings.TrimSuffix(dir, string(filepath.Separator)))},
+		},
+	}, nil
+}
+
 // Helper functions
 func (fs *FilesystemHandler) searchFiles(rootPath, pattern string) ([]string, error) {
+	return fs.searchFilesPage(context.Background(), rootPath, pattern, nil)
+}
+
+// searchFilesPage - searchFiles limitado a una página; con page nil devuelve todo
+func (fs *FilesystemHandler) searchFilesPage(ctx context.Context, rootPath, pattern string, page *resultPage) ([]string, error) {
 	var results []string
 	pattern = strings.ToLower(pattern)
 
-	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+	err := fs.walkContext(ctx, rootPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
+			fs.logWalkError(ctx, path, err)
+			return nil
+		}
+		if path != rootPath && fs.isDeniedPath(path) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() && path != rootPath && isServerDataDir(info.Name()) {
+			return filepath.SkipDir
+		}
+		if path != rootPath && fs.mcpIgnored(ctx, path, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
 		if _, err := fs.validatePath(path); err != nil {
 			return nil
 		}
+		if skip, skipDir := page.resumeSkip(path, info.IsDir()); skip {
+			if skipDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 
 		if strings.Contains(strings.ToLower(info.Name()), pattern) {
+			if !page.add(path) {
+				return errPageFull
+			}
 			results = append(results, path)
 		}
 		return nil
 	})
-	if err != nil {
+	if err != nil && !errors.Is(err, errPageFull) {
 		return nil, err
 	}
+	sortWalkOrder(rootPath, results)
 	return results, nil
 }
 
@@ -462,18 +582,72 @@ func (fs *FilesystemHandler) getFileStats(path string) (FileInfo, error) {
 		return FileInfo{}, err
 	}
 
-	return FileInfo{
-		Size:        info.Size(),
-		Created:     info.ModTime(),
-		Modified:    info.ModTime(),
-		Accessed:    info.ModTime(),
-		IsDirectory: info.IsDir(),
-		IsFile:      !info.IsDir(),
-		Permissions: fmt.Sprintf("%o", info.Mode().Perm()),
-	}, nil
+	created, accessed, estimated := statFileTimes(path, info)
+	stats := FileInfo{
+		Size:           info.Size(),
+		Created:        created,
+		Modified:       info.ModTime(),
+		Accessed:       accessed,
+		IsDirectory:    info.IsDir(),
+		IsFile:         !info.IsDir(),
+		Permissions:    fmt.Sprintf("%o", info.Mode().Perm()),
+		EstimatedTimes: estimated,
+	}
+	platformOwnership(info, &stats)
+	return stats, nil
+}
+
+// formatFileOwnership - Propietario, enlaces, atributos y xattrs de get_file_info
+func formatFileOwnership(info FileInfo) string {
+	var b strings.Builder
+	if info.UID != nil {
+		fmt.Fprintf(&b, "\nOwner: %s\nGroup: %s", ownerLabel(info.Owner, *info.UID), ownerLabel(info.Group, *info.GID))
+	}
+	if info.HardLinks > 0 {
+		fmt.Fprintf(&b, "\nHard links: %d", info.HardLinks)
+	}
+	fmt.Fprintf(&b, "\nIsSymlink: %v", info.IsSymlink)
+	if info.SymlinkTarget != "" {
+		fmt.Fprintf(&b, " -> %s", info.SymlinkTarget)
+	}
+	if len(info.Attributes) > 0 {
+		fmt.Fprintf(&b, "\nAttributes: %s", strings.Join(info.Attributes, ", "))
+	}
+	if info.XattrsError != "" {
+		fmt.Fprintf(&b, "\nExtended attributes: %s", info.XattrsError)
+	} else if info.Xattrs != nil {
+		fmt.Fprintf(&b, "\nExtended attributes (%d):", len(info.Xattrs))
+		for _, xattr := range info.Xattrs {
+			value := xattr.Value
+			if xattr.Encoding == "base64" {
+				value = "base64:" + value
+			}
+			if xattr.Truncated {
+				value += fmt.Sprintf("... (%d bytes)", xattr.Size)
+			}
+			fmt.Fprintf(&b, "\n  %s = %s", xattr.Name, value)
+		}
+	}
+	return b.String()
+}
+
+// ownerLabel - "nombre (id)", o solo el id si no tiene nombre
+func ownerLabel(name string, id uint32) string {
+	if name == "" {
+		return strconv.FormatUint(uint64(id), 10)
+	}
+	return fmt.Sprintf("%s (%d)", name, id)
 }
 
-func (fs *FilesystemHandler) buildTree(path string, maxDepth int, currentDepth int, followSymlinks bool) (*FileNode, error) {
+// estimatedTimeNote - Aviso junto a una fecha que la plataforma no proporciona
+func estimatedTimeNote(estimated []string, field string) string {
+	if containsString(estimated, field) {
+		return " (not available on this platform, showing modification time)"
+	}
+	return ""
+}
+
+func (fs *FilesystemHandler) buildTree(ctx context.Context, path string, maxDepth int, currentDepth int, followSymlinks bool) (*FileNode, error) {
 	validPath, err := fs.validatePath(path)
 	if err != nil {
 		return nil, err
@@ -481,6 +655,9 @@ func (fs *FilesystemHandler) buildTree(path string, maxDepth int, currentDepth i
 
 	info, err := os.Stat(validPath)
 	if err != nil {
+		if currentDepth > 0 {
+			fs.logWalkError(ctx, validPath, err)
+		}
 		return nil, err
 	}
 
@@ -489,6 +666,9 @@ func (fs *FilesystemHandler) buildTree(path string, maxDepth int, currentDepth i
 		Path:     validPath,
 		Modified: info.ModTime(),
 	}
+	if !utf8.ValidString(node.Name) {
+		node.NameBase64 = base64.StdEncoding.EncodeToString([]byte(node.Name))
+	}
 
 	if info.IsDir() {
 		node.Type = "directory"
@@ -496,11 +676,19 @@ func (fs *FilesystemHandler) buildTree(path string, maxDepth int, currentDepth i
 		if currentDepth < maxDepth {
 			entries, err := os.ReadDir(validPath)
 			if err != nil {
-				return nil, err
+				if currentDepth == 0 {
+					return nil, err
+				}
+				// Un subdirectorio ilegible se muestra, sin hijos, y se avisa
+				fs.logWalkError(ctx, validPath, err)
+				return node, nil
 			}
 
 			for _, entry := range entries {
 				entryPath := filepath.Join(validPath, entry.Name())
+				if fs.mcpIgnored(ctx, entryPath, entry.IsDir()) {
+					continue
+				}
 
 				if entry.Type()&os.ModeSymlink != 0 {
 					if !followSymlinks {
@@ -519,13 +707,17 @@ func (fs *FilesystemHandler) buildTree(path string, maxDepth int, currentDepth i
 					entryPath = linkDest
 				}
 
-				childNode, err := fs.buildTree(entryPath, maxDepth, currentDepth+1, followSymlinks)
+				childNode, err := fs.buildTree(ctx, entryPath, maxDepth, currentDepth+1, followSymlinks)
 				if err != nil {
 					continue
 				}
 
 				node.Children = append(node.Children, childNode)
 			}
+			// Un enlace seguido toma el nombre de su destino: ordenar por el nombre que se muestra
+			sort.SliceStable(node.Children, func(i, j int) bool {
+				return node.Children[i].Name < node.Children[j].Name
+			})
 		}
 	} else {
 		node.Type = "file"
@@ -1,18 +1,307 @@
 package filesystemserver
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
+// handleAnalyzeFile - Análisis detallado de un archivo
+func (fs *FilesystemHandler) handleAnalyzeFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Path error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	analysis, err := fs.analyzeFile(validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: File analysis error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("📄 File Analysis: %s\n\n", path))
+	result.WriteString(fmt.Sprintf("📏 Size: %d bytes | 🕒 Modified: %s | 🔒 %s\n",
+		analysis.Size, analysis.LastModified.Format("2006-01-02 15:04:05"), analysis.Permissions))
+	if analysis.Created != nil || analysis.Accessed != nil {
+		var times []string
+		if analysis.Created != nil {
+			times = append(times, "Created: "+analysis.Created.Format("2006-01-02 15:04:05"))
+		}
+		if analysis.Accessed != nil {
+			times = append(times, "Accessed: "+analysis.Accessed.Format("2006-01-02 15:04:05"))
+		}
+		result.WriteString(fmt.Sprintf("🕒 %s\n", strings.Join(times, " | ")))
+	}
+	result.WriteString(fmt.Sprintf("🏷️ MIME: %s\n", analysis.MimeType))
+	if analysis.Image != nil {
+		result.WriteString(fmt.Sprintf("🖼️ Image: %s\n", formatImageInfo(analysis.Image)))
+	}
+	result.WriteString(fmt.Sprintf("🔐 MD5: %s\n🔐 SHA256: %s\n", analysis.Hash.MD5, analysis.Hash.SHA256))
+	if analysis.Generated != "" {
+		result.WriteString(fmt.Sprintf("🏭 Generated: %s (%s and %s refuse it without allow_generated)\n", analysis.Generated, fs.toolName("edit_file"), fs.toolName("write_file")))
+	}
+
+	if analysis.Skipped != "" {
+		result.WriteString(fmt.Sprintf("\nℹ️ Content analysis skipped: %s\n", analysis.Skipped))
+	} else {
+		result.WriteString(fmt.Sprintf("📝 Lines: %d | Words: %d | Characters: %d\n", analysis.Lines, analysis.Words, analysis.Characters))
+		result.WriteString(fmt.Sprintf("🔤 Encoding: %s | Line endings: %s\n", analysis.Encoding, analysis.LineEndings))
+		if analysis.Language != "" {
+			result.WriteString(fmt.Sprintf("💻 Language: %s (%.0f%% confidence)\n", analysis.Language, analysis.LanguageConfidence*100))
+		}
+		if analysis.Complexity != nil {
+			result.WriteString(fmt.Sprintf("🧮 Complexity: %d | Functions: %d | Classes/Types: %d | Imports: %d\n",
+				analysis.Complexity.CyclomaticComplexity, analysis.Complexity.FunctionCount,
+				analysis.Complexity.ClassCount, analysis.Complexity.ImportCount))
+			result.WriteString(fmt.Sprintf("💬 Comment ratio: %.1f%%\n", analysis.CommentRatio))
+		}
+		if len(analysis.Dependencies) > 0 {
+			result.WriteString(fmt.Sprintf("📦 Dependencies (%d): %s\n", len(analysis.Dependencies), strings.Join(analysis.Dependencies, ", ")))
+		}
+	}
+
+	jsonData, err := json.MarshalIndent(analysis, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: result.String()},
+			mcp.EmbeddedResource{
+				Type: "resource",
+				Resource: mcp.TextResourceContents{
+					URI:      pathToResourceURI(validPath),
+					MIMEType: "application/json",
+					Text:     string(jsonData),
+				},
+			},
+		},
+	}, nil
+}
+
+// analyzeFile - Reúne metadatos, hashes y métricas de contenido de un archivo.
+// Los archivos binarios o demasiado grandes solo obtienen los metadatos.
+func (fs *FilesystemHandler) analyzeFile(path string) (*FileAnalysis, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+
+	sha, md5sum, err := calculateFileHashes(path)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := &FileAnalysis{
+		Path:         path,
+		Size:         info.Size(),
+		MimeType:     detectMimeType(path),
+		LastModified: info.ModTime(),
+		Permissions:  info.Mode().String(),
+		Hash:         FileHashes{MD5: md5sum, SHA256: sha},
+	}
+	// Solo se publican las fechas que la plataforma proporciona de verdad
+	created, accessed, estimated := statFileTimes(path, info)
+	if !containsString(estimated, "created") {
+		analysis.Created = &created
+	}
+	if !containsString(estimated, "accessed") {
+		analysis.Accessed = &accessed
+	}
+
+	if hasImageHeader(analysis.MimeType) {
+		analysis.Image = imageInfo(path)
+	}
+	if !isTextFile(analysis.MimeType) {
+		analysis.Encoding = "binary"
+		analysis.Skipped = "binary file"
+		return analysis, nil
+	}
+	analysis.Generated = fs.generatedReason(path)
+	if info.Size() > MAX_INLINE_SIZE {
+		analysis.Skipped = fmt.Sprintf("file larger than %d bytes", MAX_INLINE_SIZE)
+		return analysis, nil
+	}
+
+	data, err := fs.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	content := string(data)
+
+	analysis.Encoding = detectTextEncoding(data)
+	analysis.LineEndings = detectLineEndings(content)
+	analysis.Lines = countLines(content)
+	analysis.Words = len(strings.Fields(content))
+	analysis.Characters = utf8.RuneCountInString(content)
+
+	// Lenguaje por extensión, nombre, shebang y, si no se reconoce, por contenido
+	language, confidence := languageDetector.Detect(path, content)
+	if language != unknownLanguage {
+		analysis.Language = language.Name
+		analysis.LanguageConfidence = confidence
+	}
+
+	if hasCodeMetrics(language.Key) {
+		complexity := fs.calculateCodeComplexity(content, language.Key)
+		analysis.Complexity = &complexity
+		analysis.Dependencies = fs.extractDependencies(content, language.Key)
+		analysis.CommentRatio = fs.calculateCommentRatio(content, language.Key)
+	}
+
+	return analysis, nil
+}
+
+// hasCodeMetrics - Lenguajes soportados por calculateCodeComplexity y extractDependencies
+func hasCodeMetrics(language string) bool {
+	switch language {
+	case "go", "javascript", "typescript", "python", "rust", "java", "csharp":
+		return true
+	}
+	return false
+}
+
+// utf8BOM - Marca de orden de bytes con la que algunos editores de Windows empiezan los archivos UTF-8
+const utf8BOM = "\ufeff"
+
+// splitBOM - Separa el BOM UTF-8 inicial (si lo hay) del texto
+func splitBOM(content string) (bom, text string) {
+	if strings.HasPrefix(content, utf8BOM) {
+		return utf8BOM, content[len(utf8BOM):]
+	}
+	return "", content
+}
+
+// stripBOM - Texto sin el BOM UTF-8 inicial, tal como debe verlo el modelo
+func stripBOM(content string) string {
+	return strings.TrimPrefix(content, utf8BOM)
+}
+
+// detectTextEncoding - Detecta la codificación a partir del BOM y la validez UTF-8
+func detectTextEncoding(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte(utf8BOM)):
+		return "UTF-8 with BOM"
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return "UTF-16LE"
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return "UTF-16BE"
+	case utf8.Valid(data):
+		return "UTF-8"
+	}
+	return "non-UTF-8"
+}
+
+// detectLineEndings - Devuelve LF, CRLF, CR, mixed o none
+func detectLineEndings(content string) string {
+	crlf := strings.Count(content, "\r\n")
+	lf := strings.Count(content, "\n") - crlf
+	cr := strings.Count(content, "\r") - crlf
+
+	kinds := 0
+	ending := "none"
+	if lf > 0 {
+		kinds++
+		ending = "LF"
+	}
+	if crlf > 0 {
+		kinds++
+		ending = "CRLF"
+	}
+	if cr > 0 {
+		kinds++
+		ending = "CR"
+	}
+	if kinds > 1 {
+		return "mixed"
+	}
+	return ending
+}
+
+// countLines - Cuenta líneas; una última línea sin salto también cuenta
+func countLines(content string) int {
+	if content == "" {
+		return 0
+	}
+	lines := strings.Count(content, "\n")
+	if !strings.HasSuffix(content, "\n") {
+		lines++
+	}
+	return lines
+}
+
 // handleAnalyzeProject - Análisis completo de estructura de proyecto
 func (fs *FilesystemHandler) handleAnalyzeProject(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	path, _ := request.Params.Arguments["path"].(string)
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	output, err := getString(request.Params.Arguments, "output", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	opts := projectAnalysisOptions{IncludeLOC: true, TopN: 10}
+	if opts.IncludeLOC, err = getBool(request.Params.Arguments, "include_loc", opts.IncludeLOC); err != nil {
+		return badArgError(err).result(), nil
+	}
+	top, err := getInt(request.Params.Arguments, "top", 0)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if top > 0 {
+		opts.TopN = top
+	}
+	quick, err := getBool(request.Params.Arguments, "quick", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	var sample sampleOptions
+	if sample.PerDir, err = getInt(request.Params.Arguments, "sample_per_dir", 0); err != nil {
+		return badArgError(err).result(), nil
+	}
+	if sample.Total, err = getInt(request.Params.Arguments, "sample_total", 0); err != nil {
+		return badArgError(err).result(), nil
+	}
+	sample.PerDir, sample.Total = max(sample.PerDir, 0), max(sample.Total, 0)
+
 	if path == "" {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -58,7 +347,13 @@ func (fs *FilesystemHandler) handleAnalyzeProject(ctx context.Context, request m
 		}, nil
 	}
 
-	structure, err := fs.analyzeProjectStructure(validPath)
+	var structure *ProjectStructure
+	ctx, warnings := withWalkWarnings(ctx)
+	if quick {
+		structure, err = fs.analyzeProjectQuick(ctx, validPath, opts, sample)
+	} else {
+		structure, err = fs.analyzeProjectStructure(ctx, validPath, opts)
+	}
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -71,17 +366,59 @@ func (fs *FilesystemHandler) handleAnalyzeProject(ctx context.Context, request m
 		}, nil
 	}
 
+	structure.Warnings = warnings.entries()
+	data, err := json.MarshalIndent(structure, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	summary := func() string { return fs.formatProjectStructure(structure) + warnings.note(nil) }
+	if output == "json" {
+		if spilled := fs.oversizedResult(validPath, "analyze_project", len(data), data, "json", summary); spilled != nil {
+			return spilled, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: string(data)},
+			},
+		}, nil
+	}
+
+	text := summary()
+	if spilled := fs.oversizedResult(validPath, "analyze_project", len(text)+len(data), data, "json", summary); spilled != nil {
+		return spilled, nil
+	}
+	result := fs.newResultBuilder()
+	result.lines(text)
+	text, spill := result.done(validPath, "analyze_project")
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: append([]mcp.Content{
+			mcp.TextContent{Type: "text", Text: text},
+		}, spill...),
+	}, pathToResourceURI(validPath), structure)
+}
+
+// formatProjectStructure - Texto de analyze_project: totales, lenguajes, tipos, tamaños y patrones
+func (fs *FilesystemHandler) formatProjectStructure(structure *ProjectStructure) string {
 	// Formatear resultado con emojis y estructura organizada
 	var result strings.Builder
 	result.WriteString("🏗️ **Project Structure Analysis**\n\n")
 	result.WriteString(fmt.Sprintf("📁 **Root:** %s\n", structure.Root))
-	result.WriteString(fmt.Sprintf("📊 **Total Files:** %d\n", structure.TotalFiles))
-	result.WriteString(fmt.Sprintf("💾 **Total Size:** %.2f MB\n\n", float64(structure.TotalSize)/(1024*1024)))
+	if estimate := structure.Estimate; estimate != nil && !estimate.Exact {
+		result.WriteString(fmt.Sprintf("📊 **Total Files:** ~%d (estimated)\n", structure.TotalFiles))
+		result.WriteString(fmt.Sprintf("💾 **Total Size:** ~%.2f MB (estimated)\n\n", float64(structure.TotalSize)/(1024*1024)))
+	} else {
+		result.WriteString(fmt.Sprintf("📊 **Total Files:** %d\n", structure.TotalFiles))
+		result.WriteString(fmt.Sprintf("💾 **Total Size:** %.2f MB\n\n", float64(structure.TotalSize)/(1024*1024)))
+	}
+	if structure.Estimate != nil {
+		result.WriteString(formatProjectEstimate(structure.Estimate))
+	}
 
 	// Lenguajes detectados
 	if len(structure.Languages) > 0 {
 		result.WriteString("🔧 **Languages Detected:**\n")
-		for lang, count := range structure.Languages {
+		for _, lang := range keysByCount(structure.Languages) {
+			count := structure.Languages[lang]
 			percentage := float64(count) / float64(structure.TotalFiles) * 100
 			result.WriteString(fmt.Sprintf("  • %s: %d files (%.1f%%)\n", lang, count, percentage))
 		}
@@ -91,13 +428,58 @@ func (fs *FilesystemHandler) handleAnalyzeProject(ctx context.Context, request m
 	// Tipos de archivo
 	if len(structure.FileTypes) > 0 {
 		result.WriteString("📄 **File Types:**\n")
-		for ext, count := range structure.FileTypes {
+		for _, ext := range keysByCount(structure.FileTypes) {
+			count := structure.FileTypes[ext]
 			percentage := float64(count) / float64(structure.TotalFiles) * 100
 			result.WriteString(fmt.Sprintf("  • %s: %d files (%.1f%%)\n", ext, count, percentage))
 		}
 		result.WriteString("\n")
 	}
 
+	// Líneas de código por lenguaje
+	if len(structure.LinesOfCode) > 0 {
+		languages := make([]string, 0, len(structure.LinesOfCode))
+		for lang := range structure.LinesOfCode {
+			languages = append(languages, lang)
+		}
+		sort.Slice(languages, func(i, j int) bool {
+			a, b := structure.LinesOfCode[languages[i]], structure.LinesOfCode[languages[j]]
+			if a.Code != b.Code {
+				return a.Code > b.Code
+			}
+			return languages[i] < languages[j]
+		})
+
+		result.WriteString("📏 **Lines of Code:**\n")
+		for _, lang := range languages {
+			loc := structure.LinesOfCode[lang]
+			result.WriteString(fmt.Sprintf("  • %s: %d code, %d comment, %d blank (%d files)\n",
+				lang, loc.Code, loc.Comment, loc.Blank, loc.Files))
+		}
+		result.WriteString("\n")
+	}
+
+	// Archivos y directorios más grandes
+	if len(structure.LargestFiles) > 0 {
+		result.WriteString("📦 **Largest Files:**\n")
+		for _, entry := range structure.LargestFiles {
+			result.WriteString(fmt.Sprintf("  • %s (%d bytes)\n", entry.Path, entry.Size))
+		}
+		result.WriteString("\n")
+	}
+	if len(structure.LargestDirectories) > 0 {
+		result.WriteString("🗂️ **Largest Directories:**\n")
+		for _, entry := range structure.LargestDirectories {
+			result.WriteString(fmt.Sprintf("  • %s (%d bytes)\n", entry.Path, entry.Size))
+		}
+		result.WriteString("\n")
+	}
+
+	// Dependencias declaradas en los manifiestos
+	if len(structure.Manifests) > 0 {
+		result.WriteString(formatManifests(structure.Manifests))
+	}
+
 	// Estructura de directorios
 	if len(structure.Directories) > 0 {
 		result.WriteString("📂 **Directory Structure:**\n")
@@ -124,27 +506,73 @@ func (fs *FilesystemHandler) handleAnalyzeProject(ctx context.Context, request m
 		result.WriteString("\n")
 	}
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{Type: "text", Text: result.String()},
-		},
-	}, nil
+	return result.String()
+}
+
+// formatProjectEstimate - Muestra y advertencias de analyze_project en modo quick
+func formatProjectEstimate(estimate *ProjectEstimate) string {
+	var result strings.Builder
+	if estimate.Exact {
+		result.WriteString(fmt.Sprintf("⚡ **Quick mode:** the sample covered every entry (%d), so the figures are exact\n", estimate.SampledEntries))
+	} else {
+		result.WriteString(fmt.Sprintf("⚡ **Quick mode (estimated):** sampled %d entries in %d directories (at most %d per directory, %d in total); %d directories were only partly read. The top %d levels are listed in full.\n",
+			estimate.SampledEntries, estimate.ListedDirectories, estimate.PerDirectoryLimit, estimate.TotalLimit, estimate.SampledDirectories, estimate.ExactLevels))
+	}
+	for _, caveat := range estimate.Caveats {
+		result.WriteString(fmt.Sprintf("  ⚠️ %s\n", caveat))
+	}
+	result.WriteString("\n")
+	return result.String()
 }
 
+// keysByCount - Claves de mayor a menor cuenta; a igual cuenta, por orden alfabético
+func keysByCount(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// projectAnalysisOptions - Opciones de analyze_project
+type projectAnalysisOptions struct {
+	IncludeLOC bool
+	TopN       int
+}
+
+// maxLOCFileSize - Los archivos mayores no se cuentan en las líneas de código
+const maxLOCFileSize = 10 * 1024 * 1024
+
 // analyzeProjectStructure - Realiza el análisis detallado del proyecto
-func (fs *FilesystemHandler) analyzeProjectStructure(path string) (*ProjectStructure, error) {
+func (fs *FilesystemHandler) analyzeProjectStructure(ctx context.Context, path string, opts projectAnalysisOptions) (*ProjectStructure, error) {
 	structure := &ProjectStructure{
-		Root:        path,
-		Languages:   make(map[string]int),
-		FileTypes:   make(map[string]int),
-		Structure:   make(map[string][]string),
-		Directories: []string{},
+		Root:               path,
+		Languages:          make(map[string]int),
+		FileTypes:          make(map[string]int),
+		Structure:          make(map[string][]string),
+		Directories:        []string{},
+		LargestFiles:       []ReportFileEntry{},
+		LargestDirectories: []ReportFileEntry{},
+	}
+	if opts.IncludeLOC {
+		structure.LinesOfCode = make(map[string]*LanguageLOC)
 	}
+	dirSizes := make(map[string]int64)
 
-	err := filepath.Walk(path, func(currentPath string, info os.FileInfo, err error) error {
+	err := fs.walkContext(ctx, path, func(currentPath string, info os.FileInfo, err error) error {
 		if err != nil {
+			fs.logWalkError(ctx, currentPath, err)
 			return nil // Continuar con otros archivos
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
 		// Validar path
 		if _, err := fs.validatePath(currentPath); err != nil {
@@ -152,7 +580,7 @@ func (fs *FilesystemHandler) analyzeProjectStructure(path string) (*ProjectStruc
 		}
 
 		// Ignorar directorios comunes que no aportan valor
-		if fs.shouldIgnorePath(currentPath) {
+		if fs.shouldIgnoreEntry(ctx, currentPath, info.IsDir()) {
 			if info.IsDir() {
 				return filepath.SkipDir
 			}
@@ -176,9 +604,11 @@ func (fs *FilesystemHandler) analyzeProjectStructure(path string) (*ProjectStruc
 		structure.FileTypes[ext]++
 
 		// Detectar lenguaje
-		language := fs.detectFileLanguage(currentPath, ext)
-		if language != "unknown" {
-			structure.Languages[language]++
+		if language, _ := languageDetector.Detect(currentPath, ""); language != unknownLanguage {
+			structure.Languages[language.Name]++
+			if opts.IncludeLOC && info.Size() <= maxLOCFileSize {
+				fs.countFileLOC(currentPath, language, structure.LinesOfCode)
+			}
 		}
 
 		// Analizar estructura de directorios
@@ -188,117 +618,203 @@ func (fs *FilesystemHandler) analyzeProjectStructure(path string) (*ProjectStruc
 			structure.Structure[relDir] = append(structure.Structure[relDir], info.Name())
 		}
 
+		// Tamaños acumulados: el archivo cuenta en todos sus directorios padre
+		rel, _ := filepath.Rel(path, currentPath)
+		structure.LargestFiles = insertTopEntry(structure.LargestFiles,
+			ReportFileEntry{Path: filepath.ToSlash(rel), Size: info.Size()}, opts.TopN)
+		for d := filepath.Dir(rel); d != "." && d != string(filepath.Separator); d = filepath.Dir(d) {
+			dirSizes[filepath.ToSlash(d)] += info.Size()
+		}
+
 		return nil
 	})
 
+	for dir, size := range dirSizes {
+		structure.LargestDirectories = insertTopEntry(structure.LargestDirectories,
+			ReportFileEntry{Path: dir, Size: size}, opts.TopN)
+	}
+
+	structure.Manifests = fs.parseProjectManifests(path)
+
 	return structure, err
 }
 
-// detectFileLanguage - Detecta el lenguaje de programación de un archivo
-func (fs *FilesystemHandler) detectFileLanguage(filePath, ext string) string {
-	// Mapeo de extensiones a lenguajes
-	languageMap := map[string]string{
-		".go":         "Go",
-		".py":         "Python",
-		".js":         "JavaScript",
-		".ts":         "TypeScript",
-		".jsx":        "React JSX",
-		".tsx":        "React TSX",
-		".java":       "Java",
-		".kt":         "Kotlin",
-		".rs":         "Rust",
-		".cpp":        "C++",
-		".c":          "C",
-		".cs":         "C#",
-		".php":        "PHP",
-		".rb":         "Ruby",
-		".swift":      "Swift",
-		".dart":       "Dart",
-		".scala":      "Scala",
-		".html":       "HTML",
-		".css":        "CSS",
-		".scss":       "SASS",
-		".less":       "LESS",
-		".vue":        "Vue",
-		".sql":        "SQL",
-		".sh":         "Shell",
-		".ps1":        "PowerShell",
-		".bat":        "Batch",
-		".dockerfile": "Docker",
-		".yaml":       "YAML",
-		".yml":        "YAML",
-		".json":       "JSON",
-		".xml":        "XML",
-		".toml":       "TOML",
-		".ini":        "INI",
-		".md":         "Markdown",
-		".tex":        "LaTeX",
-		".r":          "R",
-		".m":          "MATLAB",
-		".jl":         "Julia",
-		".elm":        "Elm",
-		".ex":         "Elixir",
-		".exs":        "Elixir",
-		".erl":        "Erlang",
-		".hrl":        "Erlang",
-		".clj":        "Clojure",
-		".fs":         "F#",
-		".ml":         "OCaml",
-		".hs":         "Haskell",
-		".lua":        "Lua",
-		".pl":         "Perl",
-		".vim":        "Vimscript",
-	}
-
-	if lang, exists := languageMap[ext]; exists {
-		return lang
-	}
-
-	// Detectar por nombre de archivo
-	filename := strings.ToLower(filepath.Base(filePath))
-	switch filename {
-	case "dockerfile":
-		return "Docker"
-	case "makefile":
-		return "Makefile"
-	case "rakefile":
-		return "Ruby"
-	case "gemfile":
-		return "Ruby"
-	case "package.json":
-		return "Node.js"
-	case "composer.json":
-		return "PHP"
-	case "pom.xml":
-		return "Java"
-	case "cargo.toml":
-		return "Rust"
-	case "go.mod":
-		return "Go"
-	case "requirements.txt":
-		return "Python"
-	case "pipfile":
-		return "Python"
-	}
-
-	return "unknown"
+// analyzeProjectQuick - analyze_project acotado: lista completos los primeros
+// niveles y extrapola el resto a partir de una muestra (ver sampledWalk). No
+// cuenta líneas de código y los archivos más grandes son los vistos en la muestra
+func (fs *FilesystemHandler) analyzeProjectQuick(ctx context.Context, path string, opts projectAnalysisOptions, sample sampleOptions) (*ProjectStructure, error) {
+	sample = sample.withDefaults()
+	structure := &ProjectStructure{
+		Root:               path,
+		Languages:          make(map[string]int),
+		FileTypes:          make(map[string]int),
+		Structure:          make(map[string][]string),
+		Directories:        []string{},
+		LargestFiles:       []ReportFileEntry{},
+		LargestDirectories: []ReportFileEntry{},
+	}
+	var files, size float64
+	languages := make(map[string]float64)
+	fileTypes := make(map[string]float64)
+	dirSizes := make(map[string]float64)
+
+	stats, err := fs.sampledWalk(ctx, path, sample, func(currentPath string, info os.FileInfo, depth int, weight float64) error {
+		if info.IsDir() {
+			// Solo los niveles completos: la estructura que se muestra es exacta
+			if depth <= sample.ExactLevels {
+				structure.Directories = append(structure.Directories, currentPath)
+			}
+			return nil
+		}
+
+		files += weight
+		size += weight * float64(info.Size())
+		ext := strings.ToLower(filepath.Ext(currentPath))
+		if ext == "" {
+			ext = "no-extension"
+		}
+		fileTypes[ext] += weight
+		if language, _ := languageDetector.Detect(currentPath, ""); language != unknownLanguage {
+			languages[language.Name] += weight
+		}
+
+		rel, _ := filepath.Rel(path, currentPath)
+		if depth <= sample.ExactLevels {
+			if relDir := strings.TrimPrefix(filepath.Dir(currentPath), path); relDir != "" {
+				structure.Structure[relDir] = append(structure.Structure[relDir], info.Name())
+			}
+		}
+		structure.LargestFiles = insertTopEntry(structure.LargestFiles,
+			ReportFileEntry{Path: filepath.ToSlash(rel), Size: info.Size()}, opts.TopN)
+		for d := filepath.Dir(rel); d != "." && d != string(filepath.Separator); d = filepath.Dir(d) {
+			dirSizes[filepath.ToSlash(d)] += weight * float64(info.Size())
+		}
+		return nil
+	})
+
+	structure.TotalFiles = int(math.Round(files))
+	structure.TotalSize = int64(math.Round(size))
+	for language, count := range languages {
+		structure.Languages[language] = max(int(math.Round(count)), 1)
+	}
+	for ext, count := range fileTypes {
+		structure.FileTypes[ext] = max(int(math.Round(count)), 1)
+	}
+	for dir, dirSize := range dirSizes {
+		structure.LargestDirectories = insertTopEntry(structure.LargestDirectories,
+			ReportFileEntry{Path: dir, Size: int64(math.Round(dirSize))}, opts.TopN)
+	}
+	structure.Manifests = fs.parseProjectManifests(path)
+	structure.Estimate = projectEstimate(stats, sample)
+	return structure, err
+}
+
+// projectEstimate - Tamaño de la muestra de analyze_project en modo quick y sus advertencias
+func projectEstimate(stats sampleStats, sample sampleOptions) *ProjectEstimate {
+	estimate := &ProjectEstimate{
+		Exact:              stats.exact(),
+		SampledEntries:     stats.Visited,
+		ListedDirectories:  stats.Listed,
+		SampledDirectories: stats.SampledDirectories,
+		Unexplored:         int(math.Round(stats.Unexplored)),
+		PerDirectoryLimit:  sample.PerDir,
+		TotalLimit:         sample.Total,
+		ExactLevels:        sample.ExactLevels,
+	}
+	if !estimate.Exact {
+		estimate.Caveats = append(estimate.Caveats,
+			fmt.Sprintf("File counts, sizes, languages and file types below level %d are extrapolated from the sample; treat them as approximate, especially for small categories", sample.ExactLevels),
+			"Largest files are the largest seen in the sample; bigger ones may exist elsewhere")
+	}
+	if estimate.Unexplored > 0 {
+		estimate.Caveats = append(estimate.Caveats,
+			fmt.Sprintf("About %d directories ran out of budget and were never read; their files are missing from the totals, so they are likely low. Raise sample_total for a closer estimate", estimate.Unexplored))
+	}
+	estimate.Caveats = append(estimate.Caveats, "Lines of code are not counted in quick mode")
+	return estimate
+}
+
+// insertTopEntry - Mantiene los n mayores ordenados por tamaño y después por ruta
+func insertTopEntry(entries []ReportFileEntry, entry ReportFileEntry, n int) []ReportFileEntry {
+	if n <= 0 {
+		return entries
+	}
+	pos := sort.Search(len(entries), func(i int) bool {
+		if entries[i].Size != entry.Size {
+			return entries[i].Size < entry.Size
+		}
+		return entries[i].Path > entry.Path
+	})
+	if pos >= n {
+		return entries
+	}
+	entries = append(entries, ReportFileEntry{})
+	copy(entries[pos+1:], entries[pos:])
+	entries[pos] = entry
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// countFileLOC - Cuenta líneas de código, comentario y en blanco leyendo el archivo en streaming
+func (fs *FilesystemHandler) countFileLOC(path string, language Language, counts map[string]*LanguageLOC) {
+	if !isTextFile(detectMimeType(path)) {
+		return
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	loc := &LanguageLOC{Files: 1}
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			trimmed := strings.TrimSpace(line)
+			switch {
+			case trimmed == "":
+				loc.Blank++
+			case isCommentLine(trimmed, language.Key):
+				loc.Comment++
+			default:
+				loc.Code++
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if total, ok := counts[language.Name]; ok {
+		total.Files += loc.Files
+		total.Code += loc.Code
+		total.Comment += loc.Comment
+		total.Blank += loc.Blank
+	} else {
+		counts[language.Name] = loc
+	}
+}
+
+// serverDataDirs - Directorios que el propio servidor crea (planes, snapshots, informes);
+// se excluyen de búsquedas, análisis y detección de duplicados
+var serverDataDirs = []string{planDirName, snapshotDirName, reportDirName}
+
+// isServerDataDir - Indica si name es uno de serverDataDirs
+func isServerDataDir(name string) bool {
+	return containsString(serverDataDirs, name)
 }
 
 // shouldIgnorePath - Determina si un path debe ser ignorado
 func (fs *FilesystemHandler) shouldIgnorePath(path string) bool {
-	ignorePaths := []string{
-		".git", ".svn", ".hg",
-		"node_modules", "vendor", "target",
-		".vscode", ".idea", ".vs",
-		"bin", "obj", "build", "dist",
-		".cache", ".tmp", "temp",
-		"__pycache__", ".pytest_cache",
-		"coverage", ".nyc_output",
-		"logs", "log",
+	if isServerDataDir(filepath.Base(path)) || fs.isDeniedPath(path) {
+		return true
 	}
 
 	pathBase := filepath.Base(path)
-	for _, ignore := range ignorePaths {
+	for _, ignore := range builtinIgnoreNames {
 		if pathBase == ignore {
 			return true
 		}
@@ -0,0 +1,220 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeFile(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "main.go")
+	source := "package main\r\n\r\nimport \"fmt\"\r\n\r\n// main prints a greeting\r\nfunc main() {\r\n\tif true {\r\n\t\tfmt.Println(\"hi\")\r\n\t}\r\n}\r\n"
+	require.NoError(t, os.WriteFile(path, []byte(source), 0644))
+
+	analysis, err := handler.analyzeFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, analysis.Skipped)
+	assert.Equal(t, "Go", analysis.Language)
+	assert.Equal(t, extensionConfidence, analysis.LanguageConfidence)
+	assert.Equal(t, "CRLF", analysis.LineEndings)
+	assert.Equal(t, "UTF-8", analysis.Encoding)
+	assert.Equal(t, 10, analysis.Lines)
+	assert.Len(t, analysis.Hash.SHA256, 64)
+	assert.Len(t, analysis.Hash.MD5, 32)
+	require.NotNil(t, analysis.Complexity)
+	assert.Equal(t, 1, analysis.Complexity.FunctionCount)
+	assert.Equal(t, []string{"fmt"}, analysis.Dependencies)
+	assert.Greater(t, analysis.CommentRatio, 0.0)
+}
+
+func TestAnalyzeFileBinarySkipsContent(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "blob.bin")
+	require.NoError(t, os.WriteFile(path, []byte{0x00, 0x01, 0x02, 0x03, 0xff}, 0644))
+
+	analysis, err := handler.analyzeFile(path)
+	require.NoError(t, err)
+	assert.NotEmpty(t, analysis.Skipped)
+	assert.Equal(t, int64(5), analysis.Size)
+	assert.NotEmpty(t, analysis.Hash.SHA256)
+	assert.Zero(t, analysis.Lines)
+}
+
+func TestDetectLineEndings(t *testing.T) {
+	assert.Equal(t, "LF", detectLineEndings("a\nb\n"))
+	assert.Equal(t, "CRLF", detectLineEndings("a\r\nb\r\n"))
+	assert.Equal(t, "CR", detectLineEndings("a\rb"))
+	assert.Equal(t, "mixed", detectLineEndings("a\r\nb\n"))
+	assert.Equal(t, "none", detectLineEndings("abc"))
+}
+
+func TestAnalyzeProjectLinesOfCode(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg", "big"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\n// entry point\nfunc main() {}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "util.go"), []byte("package pkg\n\n\nvar X = 1"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "big", "data.py"), []byte("# data\nx = 1\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "big", "blob.txt"), make([]byte, 4096), 0644))
+
+	ctx := context.Background()
+	structure, err := handler.analyzeProjectStructure(ctx, dir, projectAnalysisOptions{IncludeLOC: true, TopN: 2})
+	require.NoError(t, err)
+
+	goLOC := structure.LinesOfCode["Go"]
+	require.NotNil(t, goLOC)
+	assert.Equal(t, LanguageLOC{Files: 2, Code: 4, Comment: 1, Blank: 3}, *goLOC)
+	assert.Equal(t, LanguageLOC{Files: 1, Code: 1, Comment: 1, Blank: 0}, *structure.LinesOfCode["Python"])
+
+	require.Len(t, structure.LargestFiles, 2)
+	assert.Equal(t, "pkg/big/blob.txt", structure.LargestFiles[0].Path)
+	require.Len(t, structure.LargestDirectories, 2)
+	assert.Equal(t, "pkg", structure.LargestDirectories[0].Path)
+	assert.Equal(t, "pkg/big", structure.LargestDirectories[1].Path)
+	assert.Greater(t, structure.LargestDirectories[0].Size, structure.LargestDirectories[1].Size)
+
+	structure, err = handler.analyzeProjectStructure(ctx, dir, projectAnalysisOptions{TopN: 10})
+	require.NoError(t, err)
+	assert.Nil(t, structure.LinesOfCode)
+	assert.Len(t, structure.LargestFiles, 4)
+
+	result, err := handler.handleAnalyzeProject(ctx, newToolRequest("analyze_project", map[string]interface{}{
+		"path":   dir,
+		"output": "json",
+	}))
+	require.NoError(t, err)
+	var decoded ProjectStructure
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &decoded))
+	assert.Equal(t, 4, decoded.TotalFiles)
+	assert.Equal(t, 4, decoded.LinesOfCode["Go"].Code)
+}
+
+func TestAnalyzeProjectDeterministic(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{
+		"main.go":        "package main\n",
+		"pkg/util.go":    "package pkg\n",
+		"app.py":         "x = 1\n",
+		"tools/build.py": "y = 2\n",
+		"README.md":      "# readme\n",
+		"notes.txt":      "notes\n",
+		"web/index.js":   "let a = 1\n",
+		"web/style.css":  "a {}\n",
+	})
+
+	run := func(output string) string {
+		result, err := handler.handleAnalyzeProject(context.Background(), newToolRequest("analyze_project", map[string]interface{}{
+			"path": dir, "output": output,
+		}))
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+	for _, output := range []string{"text", "json"} {
+		first := run(output)
+		for i := 0; i < 5; i++ {
+			require.Equal(t, first, run(output), "%s output changed between runs", output)
+		}
+	}
+
+	// A igual cuenta, orden alfabético
+	text := run("text")
+	goAt, pythonAt := strings.Index(text, "• Go: 2 files"), strings.Index(text, "• Python: 2 files")
+	require.True(t, goAt >= 0 && pythonAt >= 0, text)
+	assert.Less(t, goAt, pythonAt)
+	goExt, pyExt, cssExt := strings.Index(text, "• .go: 2"), strings.Index(text, "• .py: 2"), strings.Index(text, "• .css: 1")
+	assert.Less(t, goExt, pyExt)
+	assert.Less(t, pyExt, cssExt)
+}
+
+func TestAnalyzeProjectQuick(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	files := map[string]string{"README.md": "# monorepo\n"}
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 100; j++ {
+			files[fmt.Sprintf("pkg/sub%02d/file%03d.go", i, j)] = "package sub\n"
+		}
+	}
+	writeFixture(t, dir, files)
+	ctx := context.Background()
+
+	// Cabe en el presupuesto: coincide con el análisis completo
+	full, err := handler.analyzeProjectStructure(ctx, dir, projectAnalysisOptions{TopN: 10})
+	require.NoError(t, err)
+	quick, err := handler.analyzeProjectQuick(ctx, dir, projectAnalysisOptions{TopN: 10}, sampleOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, quick.Estimate)
+	assert.True(t, quick.Estimate.Exact)
+	assert.Equal(t, full.TotalFiles, quick.TotalFiles)
+	assert.Equal(t, full.TotalSize, quick.TotalSize)
+	assert.Equal(t, full.Languages, quick.Languages)
+
+	// 10 de cada 100 archivos por subdirectorio, extrapolados
+	quick, err = handler.analyzeProjectQuick(ctx, dir, projectAnalysisOptions{TopN: 10}, sampleOptions{PerDir: 10, Total: 30})
+	require.NoError(t, err)
+	assert.False(t, quick.Estimate.Exact)
+	assert.Equal(t, 2001, quick.TotalFiles)
+	assert.Equal(t, full.TotalSize, quick.TotalSize)
+	assert.Equal(t, 2000, quick.Languages["Go"])
+	assert.Equal(t, 20, quick.Estimate.SampledDirectories)
+	assert.Len(t, quick.Directories, 21, "the top two levels are listed in full")
+	assert.Less(t, quick.Estimate.SampledEntries, 100)
+	assert.Nil(t, quick.LinesOfCode)
+
+	result, err := handler.handleAnalyzeProject(ctx, newToolRequest("analyze_project", map[string]interface{}{
+		"path": dir, "quick": true, "sample_per_dir": float64(10),
+	}))
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "**Total Files:** ~2001 (estimated)")
+	assert.Contains(t, text, "Quick mode (estimated)")
+	assert.Contains(t, text, "Lines of code are not counted")
+}
+
+func TestSampledWalkBudget(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	files := map[string]string{}
+	for i := 0; i < 10; i++ {
+		files[fmt.Sprintf("pkg/deep/n%d/x.go", i)] = "package n\n"
+	}
+	writeFixture(t, dir, files)
+
+	var seen []string
+	stats, err := handler.sampledWalk(context.Background(), dir, sampleOptions{Total: 3}, func(path string, info os.FileInfo, depth int, weight float64) error {
+		rel, _ := filepath.Rel(dir, path)
+		seen = append(seen, filepath.ToSlash(rel))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pkg", "pkg/deep", "pkg/deep/n0"}, seen)
+	assert.Equal(t, float64(10), stats.Unexplored)
+	assert.False(t, stats.exact())
+
+	assert.Equal(t, []int{0, 2, 5, 7}, evenlySpaced([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, 4))
+}
+
+func TestBuildTreeSortsFollowedLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{"a.txt": "a", "m.txt": "m", "sub/z.txt": "z"})
+	require.NoError(t, os.Symlink(filepath.Join(dir, "sub", "z.txt"), filepath.Join(dir, "b-link")))
+
+	tree, err := handler.buildTree(context.Background(), dir, 1, 0, true)
+	require.NoError(t, err)
+	var names []string
+	for _, child := range tree.Children {
+		names = append(names, child.Name)
+	}
+	assert.Equal(t, []string{"a.txt", "m.txt", "sub", "z.txt"}, names)
+}
@@ -0,0 +1,403 @@
+package filesystemserver
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// archiveFormats - Formatos que admite create_archive
+var archiveFormats = []string{"zip", "tar.gz"}
+
+// archiveWriter - Escribe entradas en un zip o un tar.gz sin cargar los archivos en memoria
+type archiveWriter interface {
+	add(name string, info os.FileInfo, path string) error
+	Close() error
+}
+
+// handleCreateArchive - Empaqueta un archivo o directorio en un zip o tar.gz
+func (fs *FilesystemHandler) handleCreateArchive(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	sourcePath, err := getString(request.Params.Arguments, "source_path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	outputPath, err := getString(request.Params.Arguments, "output_path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	format, err := getString(request.Params.Arguments, "format", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	respectIgnoreDirs, err := getBool(request.Params.Arguments, "respect_ignore_dirs", true)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if sourcePath == "" || outputPath == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: source_path and output_path are required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if format == "" {
+		format = archiveFormatFor(outputPath)
+	}
+	if !containsString(archiveFormats, format) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: unknown format '%s' (use %s)", format, strings.Join(archiveFormats, " or "))},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validSource, err := fs.validatePath(sourcePath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validOutput, err := fs.validateNewPath(outputPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Invalid output: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Un archivo dentro del árbol que se empaqueta se incluiría a sí mismo
+	if validOutput == validSource || pathWithinDir(validOutput, validSource) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: output_path must be outside the tree being archived"},
+			},
+			IsError: true,
+		}, nil
+	}
+	if info, err := os.Stat(validOutput); err == nil && info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: output_path is a directory"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	include, err := getNonEmptyStrings(request.Params.Arguments, "include")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	exclude, err := getNonEmptyStrings(request.Params.Arguments, "exclude")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	result, err := fs.createArchive(ctx, validSource, validOutput, format, include, exclude, respectIgnoreDirs)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Archive failed: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatArchiveResult(result)},
+		},
+	}, pathToResourceURI(validOutput), result)
+}
+
+// archiveFormatFor - Formato deducido de la extensión de salida; zip si no se reconoce
+func archiveFormatFor(path string) string {
+	lower := strings.ToLower(path)
+	if strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz") {
+		return "tar.gz"
+	}
+	return "zip"
+}
+
+// createArchive - Recorre source y escribe el archivo en un temporal que se
+// renombra a output solo cuando está completo
+func (fs *FilesystemHandler) createArchive(ctx context.Context, source, output, format string, include, exclude []string, respectIgnoreDirs bool) (*ArchiveResult, error) {
+	info, err := os.Lstat(source)
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.mkdirAllChecked(filepath.Dir(output), 0755); err != nil {
+		return nil, err
+	}
+
+	tempPath := output + ".tmp"
+	defer fs.trackTemp(tempPath)()
+	file, err := fs.openFileChecked(tempPath, os.O_WRONLY|os.O_CREATE, 0644)
+	if err == nil {
+		if err = file.Truncate(0); err != nil {
+			file.Close()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ArchiveResult{Path: output, Source: source, Format: format}
+	hasher := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(file, hasher)}
+
+	var archive archiveWriter
+	if format == "zip" {
+		archive = newZipArchive(counter)
+	} else {
+		archive, err = newTarGzArchive(counter)
+		if err != nil {
+			file.Close()
+			os.Remove(tempPath)
+			return nil, err
+		}
+	}
+
+	progress := progressFrom(ctx)
+	// Las entradas cuelgan del nombre del origen, que es lo que aparece al extraer
+	base := filepath.Base(source)
+	add := func(path string, info os.FileInfo) error {
+		name := base
+		if path != source {
+			rel, _ := filepath.Rel(source, path)
+			name = filepath.ToSlash(filepath.Join(base, rel))
+		}
+		if err := archive.add(name, info, path); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		result.Entries++
+		switch {
+		case info.IsDir():
+			result.Directories++
+		case info.Mode()&os.ModeSymlink != 0:
+			result.Symlinks++
+		default:
+			result.Files++
+			result.UncompressedSize += info.Size()
+			progress.read(path, info.Size())
+		}
+		return nil
+	}
+
+	if info.IsDir() {
+		err = fs.walkContext(ctx, source, func(currentPath string, info os.FileInfo, err error) error {
+			if err != nil {
+				fs.logWalkError(ctx, currentPath, err)
+				return nil
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if currentPath == source {
+				return add(currentPath, info)
+			}
+			if info.IsDir() {
+				name := info.Name()
+				if isServerDataDir(name) || fs.isDeniedPath(currentPath) || matchesAnyPattern(source, currentPath, exclude) {
+					return filepath.SkipDir
+				}
+				if respectIgnoreDirs && fs.shouldIgnoreEntry(ctx, currentPath, info.IsDir()) {
+					return filepath.SkipDir
+				}
+				return add(currentPath, info)
+			}
+			if fs.isDeniedPath(currentPath) || matchesAnyPattern(source, currentPath, exclude) {
+				return nil
+			}
+			if len(include) > 0 && !matchesAnyPattern(source, currentPath, include) {
+				return nil
+			}
+			switch {
+			case info.Mode()&os.ModeSymlink != 0:
+				// Se guarda el enlace, no lo que apunta
+			case info.Mode().IsRegular():
+				if _, err := fs.validatePath(currentPath); err != nil {
+					return nil
+				}
+			default:
+				return nil
+			}
+			return add(currentPath, info)
+		})
+	} else {
+		err = add(source, info)
+	}
+
+	if closeErr := archive.Close(); err == nil {
+		err = closeErr
+	}
+	if syncErr := file.Sync(); err == nil {
+		err = syncErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = fs.renameChecked(tempPath, output)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return nil, err
+	}
+	fs.stats.addWritten(int(counter.n))
+
+	result.CompressedSize = counter.n
+	result.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+	return result, nil
+}
+
+// countingWriter - Cuenta los bytes que pasan hacia w
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// zipArchive - archiveWriter para zip, con deflate en los archivos
+type zipArchive struct {
+	zw *zip.Writer
+}
+
+func newZipArchive(w io.Writer) *zipArchive {
+	return &zipArchive{zw: zip.NewWriter(w)}
+}
+
+func (a *zipArchive) add(name string, info os.FileInfo, path string) error {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	} else {
+		header.Method = zip.Deflate
+	}
+
+	entry, err := a.zw.CreateHeader(header)
+	if err != nil || info.IsDir() {
+		return err
+	}
+	// En zip el destino de un enlace simbólico es el contenido de la entrada
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(entry, target)
+		return err
+	}
+	return copyFileTo(entry, path)
+}
+
+func (a *zipArchive) Close() error {
+	return a.zw.Close()
+}
+
+// tarGzArchive - archiveWriter para tar comprimido con gzip
+type tarGzArchive struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func newTarGzArchive(w io.Writer) (*tarGzArchive, error) {
+	gz, err := gzip.NewWriterLevel(w, gzip.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	return &tarGzArchive{gz: gz, tw: tar.NewWriter(gz)}, nil
+}
+
+func (a *tarGzArchive) add(name string, info os.FileInfo, path string) error {
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		link = target
+	}
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	// Sin nombres de usuario: dependen de la máquina y no aportan al restaurar
+	header.Uname, header.Gname = "", ""
+	header.Format = tar.FormatPAX
+
+	if err := a.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	return copyFileTo(a.tw, path)
+}
+
+func (a *tarGzArchive) Close() error {
+	if err := a.tw.Close(); err != nil {
+		a.gz.Close()
+		return err
+	}
+	return a.gz.Close()
+}
+
+// copyFileTo - Copia el contenido de path a w sin seguir un enlace final
+func copyFileTo(w io.Writer, path string) error {
+	file, err := os.OpenFile(path, os.O_RDONLY|openNoFollow, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// formatArchiveResult - Resumen legible de create_archive
+func formatArchiveResult(result *ArchiveResult) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📦 Archive created: %s (%s)\n", result.Path, result.Format))
+	b.WriteString(fmt.Sprintf("📁 Source: %s\n", result.Source))
+	b.WriteString(fmt.Sprintf("📊 %d entries: %d files, %d directories, %d symlinks\n", result.Entries, result.Files, result.Directories, result.Symlinks))
+	ratio := 0.0
+	if result.UncompressedSize > 0 {
+		ratio = float64(result.CompressedSize) / float64(result.UncompressedSize) * 100
+	}
+	b.WriteString(fmt.Sprintf("💾 Uncompressed: %d bytes, compressed: %d bytes (%.1f%%)\n", result.UncompressedSize, result.CompressedSize, ratio))
+	b.WriteString(fmt.Sprintf("🔒 SHA256: %s\n", result.SHA256))
+	return b.String()
+}
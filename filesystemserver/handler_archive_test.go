@@ -0,0 +1,160 @@
+package filesystemserver
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeArchiveTree(t *testing.T, dir string) string {
+	t.Helper()
+	src := filepath.Join(dir, "app")
+	files := map[string]string{
+		"main.go":               "package main\n",
+		"docs/readme.md":        "# docs\n",
+		"run.sh":                "#!/bin/sh\necho hi\n",
+		"node_modules/x/i.js":   "module.exports = 1\n",
+		".git/HEAD":             "ref: refs/heads/main\n",
+		"debug.log":             "noise\n",
+		".mcp-snapshots/s/file": "snapshot\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	require.NoError(t, os.Chmod(filepath.Join(src, "run.sh"), 0755))
+	mtime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(filepath.Join(src, "main.go"), mtime, mtime))
+	require.NoError(t, os.Symlink("main.go", filepath.Join(src, "link.go")))
+	return src
+}
+
+func TestCreateArchiveZip(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	src := writeArchiveTree(t, dir)
+	output := filepath.Join(dir, "out", "app.zip")
+	require.NoError(t, os.MkdirAll(filepath.Dir(output), 0755))
+
+	result, err := handler.createArchive(context.Background(), src, output, "zip", nil, []string{"*.log"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Files)
+	assert.Equal(t, 1, result.Symlinks)
+	assert.Equal(t, 2, result.Directories)
+	assert.Equal(t, 6, result.Entries)
+
+	hashes, err := calculateChecksums(output, []string{"sha256"})
+	require.NoError(t, err)
+	assert.Equal(t, hashes["sha256"], result.SHA256)
+	info, err := os.Stat(output)
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), result.CompressedSize)
+
+	reader, err := zip.OpenReader(output)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	entries := make(map[string]*zip.File)
+	var names []string
+	for _, f := range reader.File {
+		entries[f.Name] = f
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"app/", "app/docs/", "app/docs/readme.md", "app/link.go", "app/main.go", "app/run.sh"}, names)
+
+	assert.Equal(t, os.FileMode(0755), entries["app/run.sh"].Mode().Perm())
+	assert.True(t, entries["app/main.go"].Modified.Equal(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)))
+
+	link := entries["app/link.go"]
+	assert.NotZero(t, link.Mode()&os.ModeSymlink)
+	rc, err := link.Open()
+	require.NoError(t, err)
+	target, _ := io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, "main.go", string(target))
+}
+
+func TestCreateArchiveTarGz(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	src := writeArchiveTree(t, dir)
+	output := filepath.Join(dir, "app.tar.gz")
+
+	result, err := handler.createArchive(context.Background(), src, output, "tar.gz", []string{"*.go", "*.sh"}, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, "tar.gz", result.Format)
+
+	file, err := os.Open(output)
+	require.NoError(t, err)
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+
+	headers := make(map[string]*tar.Header)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		headers[header.Name] = header
+	}
+
+	// Sin respect_ignore_dirs se incluyen node_modules y .git, pero nunca .mcp-snapshots
+	assert.Contains(t, headers, "app/node_modules/")
+	assert.Contains(t, headers, "app/.git/")
+	assert.NotContains(t, headers, "app/.mcp-snapshots/")
+	assert.NotContains(t, headers, "app/docs/readme.md")
+	assert.NotContains(t, headers, "app/.git/HEAD")
+
+	assert.Equal(t, byte(tar.TypeSymlink), headers["app/link.go"].Typeflag)
+	assert.Equal(t, "main.go", headers["app/link.go"].Linkname)
+	assert.Equal(t, int64(0755), headers["app/run.sh"].Mode&0777)
+	assert.True(t, headers["app/main.go"].ModTime.Equal(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)))
+}
+
+func TestHandleCreateArchive(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	src := writeArchiveTree(t, dir)
+
+	result, err := handler.handleCreateArchive(context.Background(), newToolRequest("create_archive", map[string]interface{}{
+		"source_path": src,
+		"output_path": filepath.Join(src, "self.zip"),
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "outside the tree")
+
+	result, err = handler.handleCreateArchive(context.Background(), newToolRequest("create_archive", map[string]interface{}{
+		"source_path": src,
+		"output_path": filepath.Join(dir, "app.rar"),
+		"format":      "rar",
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	result, err = handler.handleCreateArchive(context.Background(), newToolRequest("create_archive", map[string]interface{}{
+		"source_path": filepath.Join(src, "main.go"),
+		"output_path": filepath.Join(dir, "main.tgz"),
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "📦 Archive created")
+	assert.Contains(t, text, "(tar.gz)")
+	assert.Contains(t, text, "1 entries: 1 files")
+	assert.Contains(t, text, "SHA256: ")
+	assert.Empty(t, tempFilesIn(t, dir))
+}
@@ -10,6 +10,16 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
+// Estado de cada operación de batch_operations
+const (
+	batchDone       = "done"
+	batchChecked    = "checked" // dry_run: se ejecutaría
+	batchFailed     = "failed"
+	batchCancelled  = "cancelled" // interrumpida al cancelar la llamada
+	batchNotRun     = "not run"
+	batchRolledBack = "rolled back"
+)
+
 // handleBatchEdit - Operaciones en lote para múltiples archivos
 func (fs *FilesystemHandler) handleBatchEdit(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	operationsParam, ok := request.Params.Arguments["operations"].([]interface{})
@@ -44,71 +54,175 @@ func (fs *FilesystemHandler) handleBatchEdit(ctx context.Context, request mcp.Ca
 		}, nil
 	}
 
+	dryRun, err := getBool(request.Params.Arguments, "dry_run", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	transactional, err := getBool(request.Params.Arguments, "transactional", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	// Se valida el lote entero antes de ejecutar nada, para corregirlo de una vez
+	operations := make([]batchOperation, len(operationsParam))
+	opTypes := make([]string, len(operationsParam))
+	var problems []string
+	for i, raw := range operationsParam {
+		opType, op, opProblems := decodeBatchOperation(raw)
+		label := fmt.Sprintf("operation %d", i+1)
+		if opType != "" {
+			label += fmt.Sprintf(" (%s)", opType)
+		}
+		for _, problem := range opProblems {
+			problems = append(problems, fmt.Sprintf("  - %s: %s", label, problem))
+		}
+		operations[i], opTypes[i] = op, opType
+	}
+	if len(problems) > 0 {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: invalid batch, nothing was executed:\n%s", strings.Join(problems, "\n"))},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	progress := progressFrom(ctx)
 	results := []string{}
 	errors := []string{}
-
-	for i, op := range operationsParam {
-		opMap, ok := op.(map[string]interface{})
-		if !ok {
-			errors = append(errors, fmt.Sprintf("Operation %d: invalid format", i+1))
+	batch := BatchResult{DryRun: dryRun, Transactional: transactional && !dryRun, Operations: []BatchOperationResult{}}
+	var touched []string
+	var undos []*batchUndo
+	stop := false
+
+	for i, op := range operations {
+		opResult := BatchOperationResult{Index: i + 1, Type: opTypes[i]}
+		// Tras cancelar, al apagar el servidor o tras un fallo en modo
+		// transaccional no se empiezan más operaciones
+		if err := ctx.Err(); err != nil || stop {
+			reason := "an earlier operation failed"
+			if err != nil {
+				reason = err.Error()
+			}
+			errors = append(errors, fmt.Sprintf("Operation %d: not run: %s", i+1, reason))
+			opResult.Status, opResult.Error = batchNotRun, "not run: "+reason
+			batch.Operations = append(batch.Operations, opResult)
 			continue
 		}
-
-		result, err := fs.processBatchOperation(opMap, i+1)
+		progress.begin(fmt.Sprintf("operation %d/%d: %s", i+1, len(operations), op.task()))
+
+		var result string
+		var err error
+		switch {
+		case dryRun:
+			result, err = op.preview(fs, i+1)
+		case transactional:
+			var undo *batchUndo
+			result, undo, err = fs.runBatchUndoable(ctx, op, i+1)
+			if undo != nil {
+				undos = append(undos, undo)
+			}
+		default:
+			result, err = op.run(ctx, fs, i+1)
+		}
 		if err != nil {
 			errors = append(errors, fmt.Sprintf("Operation %d: %v", i+1, err))
+			opResult.Status, opResult.Error = batchFailed, err.Error()
+			if ctx.Err() != nil {
+				opResult.Status = batchCancelled
+			}
+			stop = transactional
 		} else {
 			results = append(results, result)
+			opResult.Success, opResult.Status = true, batchDone
+			if dryRun {
+				opResult.Status = batchChecked
+			}
+			opResult.Message = strings.TrimSpace(result)
+			// Recurso del archivo o directorio resultante, para abrirlo desde el cliente
+			if path := op.touched(); !dryRun && path != "" {
+				if validPath, err := fs.validatePath(path); err == nil {
+					touched = append(touched, validPath)
+					opResult.Resource = fileResourceURI(validPath)
+				}
+			}
+		}
+		batch.Operations = append(batch.Operations, opResult)
+	}
+	batch.Cancelled = ctx.Err() != nil
+
+	// Un lote transaccional se aplica entero o no se aplica
+	rollbackFailed := false
+	if len(errors) > 0 && len(undos) > 0 {
+		touched = nil
+		for j := len(undos) - 1; j >= 0; j-- {
+			opResult := &batch.Operations[undos[j].index-1]
+			if err := undos[j].undo(fs); err != nil {
+				errors = append(errors, fmt.Sprintf("Operation %d: rollback failed: %v", undos[j].index, err))
+				if opResult.Error != "" {
+					opResult.Error += "; "
+				}
+				opResult.Error += "rollback failed: " + err.Error()
+				rollbackFailed = true
+				continue
+			}
+			if opResult.Status == batchDone {
+				opResult.Status, opResult.Success, opResult.Resource = batchRolledBack, false, ""
+			}
+		}
+	} else {
+		for _, undo := range undos {
+			undo.commit()
 		}
 	}
 
-	response := fmt.Sprintf("🔄 Batch Operations Completed\n✅ Successful: %d\n❌ Failed: %d\n\nResults:\n%s",
-		len(results), len(errors), strings.Join(results, "\n"))
+	for _, opResult := range batch.Operations {
+		switch opResult.Status {
+		case batchDone, batchChecked:
+			batch.Successful++
+		case batchFailed, batchCancelled:
+			batch.Failed++
+		case batchNotRun:
+			batch.NotRun++
+		case batchRolledBack:
+			batch.RolledBack++
+		}
+	}
+
+	title := "🔄 Batch Operations Completed"
+	if batch.Cancelled {
+		title = "⏹️ Batch Operations Cancelled"
+	}
+	response := fmt.Sprintf("%s\n✅ Successful: %d\n❌ Failed: %d", title, batch.Successful, batch.Failed)
+	if batch.NotRun > 0 {
+		response += fmt.Sprintf("\n⏸️ Not run: %d", batch.NotRun)
+	}
+	if batch.RolledBack > 0 {
+		response += fmt.Sprintf("\n↩️ Rolled back: %d", batch.RolledBack)
+		if !rollbackFailed {
+			response += " (nothing was changed)"
+		}
+	}
+	response += fmt.Sprintf("\n\nResults:\n%s", strings.Join(results, "\n"))
+	if dryRun {
+		response = fmt.Sprintf("🧪 Batch Dry Run (nothing was changed)\n✅ Would succeed: %d\n❌ Would fail: %d\n\nPlan:\n%s",
+			len(results), len(errors), strings.Join(results, "\n"))
+	}
 
 	if len(errors) > 0 {
 		response += fmt.Sprintf("\n\nErrors:\n%s", strings.Join(errors, "\n"))
 	}
 
-	return &mcp.CallToolResult{
+	return withStructuredContent(withFileResources(&mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{Type: "text", Text: response},
 		},
-	}, nil
-}
-
-// processBatchOperation - Procesa una operación individual del lote
-func (fs *FilesystemHandler) processBatchOperation(operation map[string]interface{}, opNum int) (string, error) {
-	opType, ok := operation["type"].(string)
-	if !ok {
-		return "", fmt.Errorf("missing or invalid 'type' field")
-	}
-
-	switch strings.ToLower(opType) {
-	case "rename", "move":
-		return fs.processBatchMove(operation, opNum)
-	case "copy":
-		return fs.processBatchCopy(operation, opNum)
-	case "delete":
-		return fs.processBatchDelete(operation, opNum)
-	case "create_dir", "mkdir":
-		return fs.processBatchCreateDir(operation, opNum)
-	case "write":
-		return fs.processBatchWrite(operation, opNum)
-	default:
-		return "", fmt.Errorf("unsupported operation type: %s", opType)
-	}
+	}, touched), "batch://operations", batch)
 }
 
 // processBatchMove - Procesa operación de mover/renombrar
-func (fs *FilesystemHandler) processBatchMove(operation map[string]interface{}, opNum int) (string, error) {
-	from, ok := operation["from"].(string)
-	if !ok {
-		return "", fmt.Errorf("missing 'from' field")
-	}
-	to, ok := operation["to"].(string)
-	if !ok {
-		return "", fmt.Errorf("missing 'to' field")
-	}
+func (fs *FilesystemHandler) processBatchMove(ctx context.Context, operation *batchTransferOp, opNum int) (string, error) {
+	from, to := operation.From, operation.To
 
 	validFrom, err := fs.validatePath(from)
 	if err != nil {
@@ -122,27 +236,21 @@ func (fs *FilesystemHandler) processBatchMove(operation map[string]interface{},
 
 	// Crear directorio padre si no existe
 	parentDir := filepath.Dir(validTo)
-	if err := os.MkdirAll(parentDir, 0755); err != nil {
+	if err := fs.mkdirAllChecked(parentDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create parent directory: %v", err)
 	}
 
-	if err := os.Rename(validFrom, validTo); err != nil {
-		return "", fmt.Errorf("move failed: %v", err)
+	moved, err := fs.moveChecked(ctx, validFrom, validTo, operation.Verify)
+	if err != nil {
+		return "", fmt.Errorf("move failed: %w", err)
 	}
 
-	return fmt.Sprintf("  %d. ✅ Moved: %s → %s", opNum, from, to), nil
+	return fmt.Sprintf("  %d. ✅ Moved: %s → %s%s", opNum, from, to, moved.batchNote()), nil
 }
 
 // processBatchCopy - Procesa operación de copiar
-func (fs *FilesystemHandler) processBatchCopy(operation map[string]interface{}, opNum int) (string, error) {
-	from, ok := operation["from"].(string)
-	if !ok {
-		return "", fmt.Errorf("missing 'from' field")
-	}
-	to, ok := operation["to"].(string)
-	if !ok {
-		return "", fmt.Errorf("missing 'to' field")
-	}
+func (fs *FilesystemHandler) processBatchCopy(ctx context.Context, operation *batchTransferOp, opNum int) (string, error) {
+	from, to := operation.From, operation.To
 
 	validFrom, err := fs.validatePath(from)
 	if err != nil {
@@ -156,23 +264,21 @@ func (fs *FilesystemHandler) processBatchCopy(operation map[string]interface{},
 
 	// Crear directorio padre si no existe
 	parentDir := filepath.Dir(validTo)
-	if err := os.MkdirAll(parentDir, 0755); err != nil {
+	if err := fs.mkdirAllChecked(parentDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create parent directory: %v", err)
 	}
 
-	if err := copyFile(validFrom, validTo); err != nil {
-		return "", fmt.Errorf("copy failed: %v", err)
+	copied, err := fs.copyTreeChecked(ctx, validFrom, validTo, operation.Verify)
+	if err != nil {
+		return "", fmt.Errorf("copy failed: %w", err)
 	}
 
-	return fmt.Sprintf("  %d. ✅ Copied: %s → %s", opNum, from, to), nil
+	return fmt.Sprintf("  %d. ✅ Copied: %s → %s%s", opNum, from, to, copied.batchNote()), nil
 }
 
 // processBatchDelete - Procesa operación de eliminar
-func (fs *FilesystemHandler) processBatchDelete(operation map[string]interface{}, opNum int) (string, error) {
-	path, ok := operation["path"].(string)
-	if !ok {
-		return "", fmt.Errorf("missing 'path' field")
-	}
+func (fs *FilesystemHandler) processBatchDelete(ctx context.Context, operation *batchDeleteOp, opNum int) (string, error) {
+	path := operation.Path
 
 	validPath, err := fs.validatePath(path)
 	if err != nil {
@@ -186,18 +292,16 @@ func (fs *FilesystemHandler) processBatchDelete(operation map[string]interface{}
 		return "", fmt.Errorf("stat failed: %v", err)
 	}
 
-	recursive, _ := operation["recursive"].(bool)
-
 	if info.IsDir() {
-		if !recursive {
+		if !operation.Recursive {
 			return "", fmt.Errorf("directory deletion requires recursive=true")
 		}
-		if err := os.RemoveAll(validPath); err != nil {
-			return "", fmt.Errorf("delete directory failed: %v", err)
+		if err := fs.removeTreeChecked(ctx, validPath); err != nil {
+			return "", fmt.Errorf("delete directory failed: %w", err)
 		}
 		return fmt.Sprintf("  %d. ✅ Deleted directory: %s", opNum, path), nil
 	} else {
-		if err := os.Remove(validPath); err != nil {
+		if err := fs.removeChecked(validPath, false); err != nil {
 			return "", fmt.Errorf("delete file failed: %v", err)
 		}
 		return fmt.Sprintf("  %d. ✅ Deleted file: %s", opNum, path), nil
@@ -205,18 +309,15 @@ func (fs *FilesystemHandler) processBatchDelete(operation map[string]interface{}
 }
 
 // processBatchCreateDir - Procesa operación de crear directorio
-func (fs *FilesystemHandler) processBatchCreateDir(operation map[string]interface{}, opNum int) (string, error) {
-	path, ok := operation["path"].(string)
-	if !ok {
-		return "", fmt.Errorf("missing 'path' field")
-	}
+func (fs *FilesystemHandler) processBatchCreateDir(operation *batchCreateDirOp, opNum int) (string, error) {
+	path := operation.Path
 
 	validPath, err := fs.validatePath(path)
 	if err != nil {
 		return "", fmt.Errorf("invalid path: %v", err)
 	}
 
-	if err := os.MkdirAll(validPath, 0755); err != nil {
+	if err := fs.mkdirAllChecked(validPath, 0755); err != nil {
 		return "", fmt.Errorf("create directory failed: %v", err)
 	}
 
@@ -224,15 +325,8 @@ func (fs *FilesystemHandler) processBatchCreateDir(operation map[string]interfac
 }
 
 // processBatchWrite - Procesa operación de escribir archivo
-func (fs *FilesystemHandler) processBatchWrite(operation map[string]interface{}, opNum int) (string, error) {
-	path, ok := operation["path"].(string)
-	if !ok {
-		return "", fmt.Errorf("missing 'path' field")
-	}
-	content, ok := operation["content"].(string)
-	if !ok {
-		return "", fmt.Errorf("missing 'content' field")
-	}
+func (fs *FilesystemHandler) processBatchWrite(operation *batchWriteOp, opNum int) (string, error) {
+	path, content := operation.Path, *operation.Content
 
 	validPath, err := fs.validatePath(path)
 	if err != nil {
@@ -241,11 +335,11 @@ func (fs *FilesystemHandler) processBatchWrite(operation map[string]interface{},
 
 	// Crear directorio padre si no existe
 	parentDir := filepath.Dir(validPath)
-	if err := os.MkdirAll(parentDir, 0755); err != nil {
+	if err := fs.mkdirAllChecked(parentDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create parent directory: %v", err)
 	}
 
-	if err := os.WriteFile(validPath, []byte(content), 0644); err != nil {
+	if err := fs.writeFileChecked(validPath, []byte(content), 0644); err != nil {
 		return "", fmt.Errorf("write failed: %v", err)
 	}
 
@@ -0,0 +1,351 @@
+package filesystemserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// batchOperation - Operación de batch_operations ya decodificada y validada
+type batchOperation interface {
+	// missing - Campos obligatorios ausentes
+	missing() []string
+	// run - Ejecuta la operación; el trabajo recursivo se detiene al cancelar ctx
+	run(ctx context.Context, fs *FilesystemHandler, opNum int) (string, error)
+	// preview - Comprueba las rutas y describe lo que haría run, sin tocar nada
+	preview(fs *FilesystemHandler, opNum int) (string, error)
+	// touched - Ruta que run crea o modifica; vacía si solo borra
+	touched() string
+	// task - Lo que hace la operación, para las notificaciones de progreso
+	task() string
+}
+
+// batchTransferOp - rename, move y copy
+type batchTransferOp struct {
+	Type   string `json:"type"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Verify bool   `json:"verify"` // SHA-256 de cada archivo, como en copy_file y move_file
+}
+
+// batchDeleteOp - delete
+type batchDeleteOp struct {
+	Type      string `json:"type"`
+	Path      string `json:"path"`
+	Recursive bool   `json:"recursive"`
+}
+
+// batchCreateDirOp - create_dir y mkdir
+type batchCreateDirOp struct {
+	Type string `json:"type"`
+	Path string `json:"path"`
+}
+
+// batchWriteOp - write; content es puntero para distinguir "" de ausente
+type batchWriteOp struct {
+	Type    string  `json:"type"`
+	Path    string  `json:"path"`
+	Content *string `json:"content"`
+}
+
+// batchOperationTypes - Tipo de operación -> estructura que la describe
+var batchOperationTypes = map[string]func() batchOperation{
+	"rename":     func() batchOperation { return &batchTransferOp{} },
+	"move":       func() batchOperation { return &batchTransferOp{} },
+	"copy":       func() batchOperation { return &batchTransferOp{} },
+	"delete":     func() batchOperation { return &batchDeleteOp{} },
+	"create_dir": func() batchOperation { return &batchCreateDirOp{} },
+	"mkdir":      func() batchOperation { return &batchCreateDirOp{} },
+	"write":      func() batchOperation { return &batchWriteOp{} },
+}
+
+// fieldAliases - Nombres habituales que se sugieren como otro campo o tipo
+var fieldAliases = map[string]string{
+	"src": "from", "source": "from", "dst": "to", "dest": "to", "destination": "to", "target": "to",
+	"file": "path", "filename": "path", "dir": "path", "directory": "path",
+	"text": "content", "data": "content", "body": "content",
+	"op": "type", "kind": "type", "action": "type",
+	"remove": "delete", "rm": "delete", "mv": "move", "cp": "copy", "mkdirs": "mkdir",
+}
+
+func (op *batchTransferOp) missing() []string {
+	return missingFields("from", op.From, "to", op.To)
+}
+
+func (op *batchDeleteOp) missing() []string { return missingFields("path", op.Path) }
+
+func (op *batchCreateDirOp) missing() []string { return missingFields("path", op.Path) }
+
+func (op *batchWriteOp) missing() []string {
+	fields := missingFields("path", op.Path)
+	if op.Content == nil {
+		fields = append(fields, "content")
+	}
+	return fields
+}
+
+func (op *batchTransferOp) run(ctx context.Context, fs *FilesystemHandler, opNum int) (string, error) {
+	if strings.EqualFold(op.Type, "copy") {
+		return fs.processBatchCopy(ctx, op, opNum)
+	}
+	return fs.processBatchMove(ctx, op, opNum)
+}
+
+func (op *batchDeleteOp) run(ctx context.Context, fs *FilesystemHandler, opNum int) (string, error) {
+	return fs.processBatchDelete(ctx, op, opNum)
+}
+
+func (op *batchCreateDirOp) run(ctx context.Context, fs *FilesystemHandler, opNum int) (string, error) {
+	return fs.processBatchCreateDir(op, opNum)
+}
+
+func (op *batchWriteOp) run(ctx context.Context, fs *FilesystemHandler, opNum int) (string, error) {
+	return fs.processBatchWrite(op, opNum)
+}
+
+func (op *batchTransferOp) touched() string { return op.To }
+
+func (op *batchDeleteOp) touched() string { return "" }
+
+func (op *batchCreateDirOp) touched() string { return op.Path }
+
+func (op *batchWriteOp) touched() string { return op.Path }
+
+func (op *batchTransferOp) task() string {
+	if strings.EqualFold(op.Type, "copy") {
+		return fmt.Sprintf("copying %s", op.From)
+	}
+	return fmt.Sprintf("moving %s", op.From)
+}
+
+func (op *batchDeleteOp) task() string { return fmt.Sprintf("deleting %s", op.Path) }
+
+func (op *batchCreateDirOp) task() string { return fmt.Sprintf("creating %s", op.Path) }
+
+func (op *batchWriteOp) task() string { return fmt.Sprintf("writing %s", op.Path) }
+
+func (op *batchTransferOp) preview(fs *FilesystemHandler, opNum int) (string, error) {
+	validFrom, err := fs.validatePath(op.From)
+	if err != nil {
+		return "", fmt.Errorf("invalid source path: %v", err)
+	}
+	if _, err := os.Lstat(validFrom); err != nil {
+		return "", fmt.Errorf("invalid source path: %v", err)
+	}
+	// Los directorios padre del destino se crean al ejecutar
+	if _, err := fs.validateNewPath(op.To); err != nil {
+		return "", fmt.Errorf("invalid destination path: %v", err)
+	}
+	verb := "move"
+	if strings.EqualFold(op.Type, "copy") {
+		verb = "copy"
+	}
+	return fmt.Sprintf("  %d. 🔍 Would %s: %s → %s", opNum, verb, op.From, op.To), nil
+}
+
+func (op *batchDeleteOp) preview(fs *FilesystemHandler, opNum int) (string, error) {
+	validPath, err := fs.validatePath(op.Path)
+	if err != nil {
+		return "", fmt.Errorf("invalid path: %v", err)
+	}
+	info, err := os.Stat(validPath)
+	if os.IsNotExist(err) {
+		return fmt.Sprintf("  %d. ⚠️  Already deleted: %s", opNum, op.Path), nil
+	} else if err != nil {
+		return "", fmt.Errorf("stat failed: %v", err)
+	}
+	if info.IsDir() {
+		if !op.Recursive {
+			return "", fmt.Errorf("directory deletion requires recursive=true")
+		}
+		return fmt.Sprintf("  %d. 🔍 Would delete directory: %s", opNum, op.Path), nil
+	}
+	return fmt.Sprintf("  %d. 🔍 Would delete file: %s", opNum, op.Path), nil
+}
+
+func (op *batchCreateDirOp) preview(fs *FilesystemHandler, opNum int) (string, error) {
+	if _, err := fs.validateNewPath(op.Path); err != nil {
+		return "", fmt.Errorf("invalid path: %v", err)
+	}
+	return fmt.Sprintf("  %d. 🔍 Would create directory: %s", opNum, op.Path), nil
+}
+
+func (op *batchWriteOp) preview(fs *FilesystemHandler, opNum int) (string, error) {
+	if _, err := fs.validateNewPath(op.Path); err != nil {
+		return "", fmt.Errorf("invalid path: %v", err)
+	}
+	return fmt.Sprintf("  %d. 🔍 Would write: %s (%d bytes)", opNum, op.Path, len(*op.Content)), nil
+}
+
+// processBatchOperation - Decodifica, valida y ejecuta una operación individual del lote
+func (fs *FilesystemHandler) processBatchOperation(ctx context.Context, operation map[string]interface{}, opNum int) (string, error) {
+	_, op, problems := decodeBatchOperation(operation)
+	if len(problems) > 0 {
+		return "", errors.New(strings.Join(problems, "; "))
+	}
+	return op.run(ctx, fs, opNum)
+}
+
+// decodeBatchOperation - Convierte un objeto del lote en su estructura, rechazando
+// campos desconocidos, tipos incorrectos y campos obligatorios ausentes.
+// Devuelve el tipo de operación (vacío si no se pudo leer) y todos los problemas.
+func decodeBatchOperation(raw interface{}) (string, batchOperation, []string) {
+	fields, ok := raw.(map[string]interface{})
+	if !ok {
+		return "", nil, []string{fmt.Sprintf("expected an object, got %s", jsonTypeName(raw))}
+	}
+
+	opType, _ := fields["type"].(string)
+	newOp, known := batchOperationTypes[strings.ToLower(opType)]
+	if !known {
+		types := make([]string, 0, len(batchOperationTypes))
+		for name := range batchOperationTypes {
+			types = append(types, name)
+		}
+		sort.Strings(types)
+		switch value, present := fields["type"]; {
+		case !present:
+			return "", nil, []string{"missing field 'type'" + didYouMeanKey(fields, "type") + " (one of: " + strings.Join(types, ", ") + ")"}
+		case opType == "":
+			return "", nil, []string{fmt.Sprintf("field 'type' must be a string, got %s", jsonTypeName(value))}
+		default:
+			return opType, nil, []string{fmt.Sprintf("unsupported operation type '%s'%s", opType, didYouMean(strings.ToLower(opType), types))}
+		}
+	}
+
+	op := newOp()
+	names := jsonFieldNames(op)
+	var problems []string
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !containsString(names, key) {
+			problems = append(problems, fmt.Sprintf("unknown field '%s'%s", key, didYouMean(key, names)))
+		}
+	}
+	if len(problems) > 0 {
+		return opType, nil, problems
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return opType, nil, []string{err.Error()}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(op); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return opType, nil, []string{fmt.Sprintf("field '%s' must be %s, got %s", typeErr.Field, jsonKindName(typeErr.Type), typeErr.Value)}
+		}
+		return opType, nil, []string{err.Error()}
+	}
+
+	for _, field := range op.missing() {
+		problems = append(problems, fmt.Sprintf("missing field '%s'", field))
+	}
+	if len(problems) > 0 {
+		return opType, nil, problems
+	}
+	return opType, op, nil
+}
+
+// missingFields - Nombres de los pares (nombre, valor) con valor vacío
+func missingFields(pairs ...string) []string {
+	var missing []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			missing = append(missing, pairs[i])
+		}
+	}
+	return missing
+}
+
+// jsonFieldNames - Nombres JSON de los campos de la estructura a la que apunta v
+func jsonFieldNames(v interface{}) []string {
+	t := reflect.TypeOf(v).Elem()
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		names = append(names, name)
+	}
+	return names
+}
+
+// didYouMean - " (did you mean 'x'?)" con el candidato más parecido, o vacío
+func didYouMean(name string, candidates []string) string {
+	lower := strings.ToLower(name)
+	if alias, ok := fieldAliases[lower]; ok && containsString(candidates, alias) {
+		return fmt.Sprintf(" (did you mean '%s'?)", alias)
+	}
+	best, bestDistance := "", 0
+	for _, candidate := range candidates {
+		distance := levenshteinDistance(lower, candidate)
+		if distance <= 2 && distance < len(candidate) && (best == "" || distance < bestDistance) {
+			best, bestDistance = candidate, distance
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean '%s'?)", best)
+}
+
+// didYouMeanKey - Para un campo ausente, sugiere la clave desconocida que parece serlo
+func didYouMeanKey(fields map[string]interface{}, field string) string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if didYouMean(key, []string{field}) != "" {
+			return fmt.Sprintf(" ('%s' given)", key)
+		}
+	}
+	return ""
+}
+
+// jsonTypeName - Nombre JSON del tipo de un valor decodificado
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// jsonKindName - Nombre JSON de un tipo de Go, para los errores de tipo
+func jsonKindName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	default:
+		return "a " + t.Kind().String()
+	}
+}
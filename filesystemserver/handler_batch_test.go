@@ -0,0 +1,398 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeBatchOperation(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      interface{}
+		opType   string
+		problems []string
+	}{
+		{
+			name:     "not an object",
+			raw:      "copy a b",
+			problems: []string{"expected an object, got string"},
+		},
+		{
+			name:     "missing type",
+			raw:      map[string]interface{}{"path": "a"},
+			problems: []string{"missing field 'type' (one of: copy, create_dir, delete, mkdir, move, rename, write)"},
+		},
+		{
+			name:     "type under an alias",
+			raw:      map[string]interface{}{"op": "copy", "from": "a", "to": "b"},
+			problems: []string{"missing field 'type' ('op' given) (one of: copy, create_dir, delete, mkdir, move, rename, write)"},
+		},
+		{
+			name:     "type is not a string",
+			raw:      map[string]interface{}{"type": 3.0},
+			problems: []string{"field 'type' must be a string, got number"},
+		},
+		{
+			name:     "unsupported type with typo",
+			raw:      map[string]interface{}{"type": "cpoy", "from": "a", "to": "b"},
+			opType:   "cpoy",
+			problems: []string{"unsupported operation type 'cpoy' (did you mean 'copy'?)"},
+		},
+		{
+			name:     "unsupported type alias",
+			raw:      map[string]interface{}{"type": "rm", "path": "a"},
+			opType:   "rm",
+			problems: []string{"unsupported operation type 'rm' (did you mean 'delete'?)"},
+		},
+		{
+			name:     "unknown field with alias",
+			raw:      map[string]interface{}{"type": "copy", "src": "a", "to": "b"},
+			opType:   "copy",
+			problems: []string{"unknown field 'src' (did you mean 'from'?)"},
+		},
+		{
+			name:     "unknown field with typo",
+			raw:      map[string]interface{}{"type": "delete", "path": "a", "recursve": true},
+			opType:   "delete",
+			problems: []string{"unknown field 'recursve' (did you mean 'recursive'?)"},
+		},
+		{
+			name:   "every unknown field is reported",
+			raw:    map[string]interface{}{"type": "move", "source": "a", "dest": "b", "force": true},
+			opType: "move",
+			problems: []string{
+				"unknown field 'dest' (did you mean 'to'?)",
+				"unknown field 'force'",
+				"unknown field 'source' (did you mean 'from'?)",
+			},
+		},
+		{
+			name:     "wrong field type",
+			raw:      map[string]interface{}{"type": "delete", "path": "a", "recursive": "yes"},
+			opType:   "delete",
+			problems: []string{"field 'recursive' must be a boolean, got string"},
+		},
+		{
+			name:     "missing content",
+			raw:      map[string]interface{}{"type": "write", "path": "a"},
+			opType:   "write",
+			problems: []string{"missing field 'content'"},
+		},
+		{
+			name:     "missing both ends",
+			raw:      map[string]interface{}{"type": "rename"},
+			opType:   "rename",
+			problems: []string{"missing field 'from'", "missing field 'to'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opType, op, problems := decodeBatchOperation(tt.raw)
+			assert.Equal(t, tt.opType, opType)
+			assert.Nil(t, op)
+			assert.Equal(t, tt.problems, problems)
+		})
+	}
+
+	opType, op, problems := decodeBatchOperation(map[string]interface{}{"type": "Write", "path": "a", "content": ""})
+	assert.Empty(t, problems)
+	assert.Equal(t, "Write", opType)
+	require.IsType(t, &batchWriteOp{}, op)
+	assert.Equal(t, "", *op.(*batchWriteOp).Content, "empty content is allowed")
+}
+
+func TestBatchOperationsValidateBeforeRunning(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	created := filepath.Join(dir, "created")
+
+	result, err := handler.handleBatchEdit(context.Background(), newToolRequest("batch_operations", map[string]interface{}{
+		"operations": []interface{}{
+			map[string]interface{}{"type": "mkdir", "path": created},
+			map[string]interface{}{"type": "copy", "src": "a.txt", "to": "b.txt"},
+			"delete everything",
+		},
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "nothing was executed")
+	assert.Contains(t, text, "operation 2 (copy): unknown field 'src' (did you mean 'from'?)")
+	assert.Contains(t, text, "operation 3: expected an object, got string")
+	assert.NoDirExists(t, created, "the valid operation must not run")
+}
+
+func TestBatchOperationsDryRun(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	source := filepath.Join(dir, "source.txt")
+	require.NoError(t, os.WriteFile(source, []byte("data"), 0644))
+	subdir := filepath.Join(dir, "sub")
+	require.NoError(t, os.Mkdir(subdir, 0755))
+
+	result, err := handler.handleBatchEdit(context.Background(), newToolRequest("batch_operations", map[string]interface{}{
+		"dry_run": true,
+		"operations": []interface{}{
+			map[string]interface{}{"type": "copy", "from": source, "to": filepath.Join(dir, "new", "copy.txt")},
+			map[string]interface{}{"type": "write", "path": filepath.Join(dir, "written.txt"), "content": "hello"},
+			map[string]interface{}{"type": "delete", "path": subdir},
+			map[string]interface{}{"type": "move", "from": filepath.Join(dir, "missing.txt"), "to": filepath.Join(dir, "x.txt")},
+		},
+	}))
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "🧪 Batch Dry Run")
+
+	var batch BatchResult
+	decodeStructured(t, result, &batch)
+	assert.True(t, batch.DryRun)
+	assert.Equal(t, 2, batch.Successful)
+	assert.Equal(t, 2, batch.Failed)
+	assert.Contains(t, batch.Operations[0].Message, "Would copy")
+	assert.Contains(t, batch.Operations[2].Error, "recursive=true")
+	assert.Contains(t, batch.Operations[3].Error, "invalid source path")
+
+	assert.NoDirExists(t, filepath.Join(dir, "new"))
+	assert.NoFileExists(t, filepath.Join(dir, "written.txt"))
+	assert.DirExists(t, subdir)
+	assert.FileExists(t, source)
+}
+
+func TestBatchOperationsFileResources(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{"old.txt": "old", "gone.txt": "bye"})
+
+	result, err := handler.handleBatchEdit(context.Background(), newToolRequest("batch_operations", map[string]interface{}{
+		"operations": []interface{}{
+			map[string]interface{}{"type": "write", "path": filepath.Join(dir, "new.txt"), "content": "hello"},
+			map[string]interface{}{"type": "move", "from": filepath.Join(dir, "old.txt"), "to": filepath.Join(dir, "moved.txt")},
+			map[string]interface{}{"type": "delete", "path": filepath.Join(dir, "gone.txt")},
+			map[string]interface{}{"type": "mkdir", "path": filepath.Join(dir, "made")},
+		},
+	}))
+	require.NoError(t, err)
+
+	want := []string{
+		pathToResourceURI(filepath.Join(dir, "new.txt")),
+		pathToResourceURI(filepath.Join(dir, "moved.txt")),
+		dirResourceURI(filepath.Join(dir, "made")),
+	}
+	assert.Equal(t, want, fileResourceURIs(result))
+
+	var batch BatchResult
+	decodeStructured(t, result, &batch)
+	require.Len(t, batch.Operations, 4)
+	assert.Equal(t, want[0], batch.Operations[0].Resource)
+	assert.Equal(t, want[1], batch.Operations[1].Resource)
+	assert.Empty(t, batch.Operations[2].Resource, "deletes leave nothing to open")
+	assert.Equal(t, want[2], batch.Operations[3].Resource)
+}
+
+// runCancelledBatch - Ejecuta batch_operations con un token de progreso y
+// cancela la llamada cuando la operación en curso lleva cancelAt archivos
+func runCancelledBatch(t *testing.T, handler *FilesystemHandler, args map[string]interface{}, cancelAt string) (BatchResult, []string) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var messages []string
+	handler.progressNotify = func(ctx context.Context, params map[string]any) error {
+		message, _ := params["message"].(string)
+		messages = append(messages, message)
+		if strings.HasSuffix(message, cancelAt) {
+			cancel()
+		}
+		return nil
+	}
+	request := newToolRequest("batch_operations", args)
+	request.Params.Meta = progressRequest("batch").Params.Meta
+	ctx = handler.withProgress(ctx, request)
+	// Un reloj que avanza un segundo por consulta: se notifica cada paso
+	clock := time.Now()
+	progressFrom(ctx).now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	result, err := handler.handleBatchEdit(ctx, request)
+	require.NoError(t, err)
+	var batch BatchResult
+	decodeStructured(t, result, &batch)
+	return batch, messages
+}
+
+// batchStatuses - Estado de cada operación, en orden
+func batchStatuses(batch BatchResult) []string {
+	var statuses []string
+	for _, op := range batch.Operations {
+		statuses = append(statuses, op.Status)
+	}
+	return statuses
+}
+
+// writeBatchTree - n archivos pequeños repartidos en subdirectorios de dir
+func writeBatchTree(t *testing.T, dir string, n int) {
+	t.Helper()
+	files := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		files[fmt.Sprintf("d%02d/file%04d.txt", i%10, i)] = fmt.Sprintf("content %d\n", i)
+	}
+	writeFixture(t, dir, files)
+}
+
+func TestBatchOperationsCancelMidCopy(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	source := filepath.Join(dir, "source")
+	writeBatchTree(t, source, 1000)
+	target := filepath.Join(dir, "target")
+
+	batch, messages := runCancelledBatch(t, handler, map[string]interface{}{
+		"operations": []interface{}{
+			map[string]interface{}{"type": "copy", "from": source, "to": target},
+			map[string]interface{}{"type": "write", "path": filepath.Join(dir, "after.txt"), "content": "x"},
+			map[string]interface{}{"type": "mkdir", "path": filepath.Join(dir, "made")},
+		},
+	}, "(500 files done)")
+
+	assert.True(t, batch.Cancelled)
+	assert.Equal(t, []string{batchCancelled, batchNotRun, batchNotRun}, batchStatuses(batch))
+	assert.Equal(t, 1, batch.Failed)
+	assert.Equal(t, 2, batch.NotRun)
+	assert.Contains(t, batch.Operations[0].Error, "context canceled")
+	assert.Equal(t, "not run: context canceled", batch.Operations[1].Error)
+	assert.Contains(t, messages, fmt.Sprintf("operation 1/3: copying %s (250 files done)", source))
+	assert.NotContains(t, messages, fmt.Sprintf("operation 1/3: copying %s (501 files done)", source), "no file is copied after the cancellation")
+
+	// La copia a medias se retira y las demás operaciones no se ejecutan
+	assert.NoDirExists(t, target)
+	assert.NoFileExists(t, filepath.Join(dir, "after.txt"))
+	assert.NoDirExists(t, filepath.Join(dir, "made"))
+	entries, err := os.ReadDir(source)
+	require.NoError(t, err)
+	assert.Len(t, entries, 10)
+}
+
+func TestBatchOperationsCancelMidDelete(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	doomed := filepath.Join(dir, "doomed")
+	writeBatchTree(t, doomed, 1000)
+
+	batch, messages := runCancelledBatch(t, handler, map[string]interface{}{
+		"operations": []interface{}{
+			map[string]interface{}{"type": "delete", "path": doomed, "recursive": true},
+			map[string]interface{}{"type": "delete", "path": filepath.Join(dir, "other")},
+		},
+	}, "(100 files done)")
+
+	assert.Equal(t, []string{batchCancelled, batchNotRun}, batchStatuses(batch))
+	assert.Contains(t, messages, fmt.Sprintf("operation 1/2: deleting %s (100 files done)", doomed))
+	// Se detiene entre archivos: lo borrado queda borrado y el resto sigue ahí
+	remaining := 0
+	require.NoError(t, filepath.Walk(doomed, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			remaining++
+		}
+		return err
+	}))
+	assert.Equal(t, 900, remaining)
+}
+
+func TestBatchOperationsTransactionalRollbackOnCancel(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{"existing.txt": "original", "gone.txt": "keep me", "old.txt": "old", "tree/a.txt": "a"})
+	source := filepath.Join(dir, "source")
+	writeBatchTree(t, source, 1000)
+
+	batch, _ := runCancelledBatch(t, handler, map[string]interface{}{
+		"transactional": true,
+		"operations": []interface{}{
+			map[string]interface{}{"type": "write", "path": filepath.Join(dir, "new.txt"), "content": "new"},
+			map[string]interface{}{"type": "write", "path": filepath.Join(dir, "existing.txt"), "content": "overwritten"},
+			map[string]interface{}{"type": "delete", "path": filepath.Join(dir, "gone.txt")},
+			map[string]interface{}{"type": "delete", "path": filepath.Join(dir, "tree"), "recursive": true},
+			map[string]interface{}{"type": "move", "from": filepath.Join(dir, "old.txt"), "to": filepath.Join(dir, "moved.txt")},
+			map[string]interface{}{"type": "copy", "from": source, "to": filepath.Join(dir, "target")},
+			map[string]interface{}{"type": "mkdir", "path": filepath.Join(dir, "made")},
+		},
+	}, "(500 files done)")
+
+	assert.True(t, batch.Transactional)
+	assert.True(t, batch.Cancelled)
+	assert.Equal(t, []string{
+		batchRolledBack, batchRolledBack, batchRolledBack, batchRolledBack, batchRolledBack, batchCancelled, batchNotRun,
+	}, batchStatuses(batch))
+	assert.Equal(t, 5, batch.RolledBack)
+	assert.Equal(t, 0, batch.Successful)
+
+	// Todo vuelve a como estaba, sin copias de seguridad ni papeleras a la vista
+	assert.NoFileExists(t, filepath.Join(dir, "new.txt"))
+	assertFileContent(t, filepath.Join(dir, "existing.txt"), "original")
+	assertFileContent(t, filepath.Join(dir, "gone.txt"), "keep me")
+	assertFileContent(t, filepath.Join(dir, "tree", "a.txt"), "a")
+	assertFileContent(t, filepath.Join(dir, "old.txt"), "old")
+	assert.NoFileExists(t, filepath.Join(dir, "moved.txt"))
+	assert.NoDirExists(t, filepath.Join(dir, "target"))
+	assert.NoDirExists(t, filepath.Join(dir, "made"))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{"existing.txt", "gone.txt", "old.txt", "tree", "source"}, names)
+}
+
+func TestBatchOperationsTransactional(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{"keep.txt": "keep", "gone.txt": "bye"})
+	operations := func(moveFrom string) []interface{} {
+		return []interface{}{
+			map[string]interface{}{"type": "write", "path": filepath.Join(dir, "keep.txt"), "content": "changed"},
+			map[string]interface{}{"type": "delete", "path": filepath.Join(dir, "gone.txt")},
+			map[string]interface{}{"type": "move", "from": moveFrom, "to": filepath.Join(dir, "moved.txt")},
+			map[string]interface{}{"type": "mkdir", "path": filepath.Join(dir, "made")},
+		}
+	}
+
+	// Un fallo deshace lo hecho y no ejecuta lo que queda
+	result, err := handler.handleBatchEdit(context.Background(), newToolRequest("batch_operations", map[string]interface{}{
+		"transactional": true, "operations": operations(filepath.Join(dir, "missing.txt")),
+	}))
+	require.NoError(t, err)
+	var batch BatchResult
+	decodeStructured(t, result, &batch)
+	assert.Equal(t, []string{batchRolledBack, batchRolledBack, batchFailed, batchNotRun}, batchStatuses(batch))
+	assert.Equal(t, "not run: an earlier operation failed", batch.Operations[3].Error)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "↩️ Rolled back: 2 (nothing was changed)")
+	assertFileContent(t, filepath.Join(dir, "keep.txt"), "keep")
+	assertFileContent(t, filepath.Join(dir, "gone.txt"), "bye")
+	assert.NoDirExists(t, filepath.Join(dir, "made"))
+
+	// Si todo sale bien se aplica y no deja nada guardado
+	writeFixture(t, dir, map[string]string{"source.txt": "moved"})
+	result, err = handler.handleBatchEdit(context.Background(), newToolRequest("batch_operations", map[string]interface{}{
+		"transactional": true, "operations": operations(filepath.Join(dir, "source.txt")),
+	}))
+	require.NoError(t, err)
+	batch = BatchResult{}
+	decodeStructured(t, result, &batch)
+	assert.Equal(t, []string{batchDone, batchDone, batchDone, batchDone}, batchStatuses(batch))
+	assertFileContent(t, filepath.Join(dir, "keep.txt"), "changed")
+	assertFileContent(t, filepath.Join(dir, "moved.txt"), "moved")
+	assert.NoFileExists(t, filepath.Join(dir, "gone.txt"))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{"keep.txt", "moved.txt", "made"}, names)
+}
@@ -0,0 +1,142 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Con transactional, batch_operations guarda antes de cada operación lo
+// necesario para deshacerla: el archivo que va a sobrescribir, el primer
+// directorio que va a crear o, en un delete, aparta el original en lugar de
+// borrarlo. Si una operación falla o se cancela la llamada, se deshacen en
+// orden inverso; si todas terminan, se descarta lo guardado.
+
+// batchUndo - Cómo deshacer una operación de un lote transaccional
+type batchUndo struct {
+	index   int    // 1-based, como BatchOperationResult.Index
+	target  string // ruta que la operación creó, modificó o borró
+	from    string // move: origen al que vuelve target
+	created string // primera ruta que no existía antes; se borra entera
+	backup  string // copia de target antes de sobrescribirlo
+	trash   string // delete: directorio temporal con el original apartado
+}
+
+// runBatchUndoable - Ejecuta op preparando su batchUndo. Si op falla también
+// se devuelve, para quitar lo que haya dejado a medias
+func (fs *FilesystemHandler) runBatchUndoable(ctx context.Context, op batchOperation, opNum int) (string, *batchUndo, error) {
+	if del, ok := op.(*batchDeleteOp); ok {
+		return fs.deleteUndoable(ctx, del, opNum)
+	}
+	target, err := fs.validatePath(op.touched())
+	if err != nil {
+		// run informa del mismo error sin haber tocado nada
+		out, err := op.run(ctx, fs, opNum)
+		return out, nil, err
+	}
+
+	undo := &batchUndo{index: opNum, target: target, created: firstMissingPath(target)}
+	if info, err := os.Lstat(target); err == nil && info.Mode().IsRegular() {
+		if undo.backup, err = setAsideCopy(target); err != nil {
+			return "", nil, fmt.Errorf("backup before overwriting failed: %v", err)
+		}
+	}
+	out, err := op.run(ctx, fs, opNum)
+	if move, ok := op.(*batchTransferOp); ok && err == nil && !strings.EqualFold(move.Type, "copy") {
+		undo.from, _ = fs.validatePath(move.From)
+	}
+	return out, undo, err
+}
+
+// deleteUndoable - delete que aparta el original en un directorio temporal a
+// su lado, en el mismo volumen, en lugar de borrarlo
+func (fs *FilesystemHandler) deleteUndoable(ctx context.Context, op *batchDeleteOp, opNum int) (string, *batchUndo, error) {
+	validPath, err := fs.validatePath(op.Path)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid path: %v", err)
+	}
+	info, err := os.Lstat(validPath)
+	if err != nil || (info.IsDir() && !op.Recursive) {
+		// Ya borrado o no se puede borrar: run da el mensaje sin tocar nada
+		out, err := fs.processBatchDelete(ctx, op, opNum)
+		return out, nil, err
+	}
+
+	trash, err := os.MkdirTemp(filepath.Dir(validPath), ".batch-trash-")
+	if err != nil {
+		return "", nil, fmt.Errorf("delete failed: %v", err)
+	}
+	if err := fs.renameChecked(validPath, filepath.Join(trash, filepath.Base(validPath))); err != nil {
+		os.Remove(trash)
+		return "", nil, fmt.Errorf("delete failed: %v", err)
+	}
+	kind := "file"
+	if info.IsDir() {
+		kind = "directory"
+	}
+	return fmt.Sprintf("  %d. ✅ Deleted %s: %s", opNum, kind, op.Path), &batchUndo{index: opNum, target: validPath, trash: trash}, nil
+}
+
+// undo - Devuelve target a como estaba antes de la operación
+func (u *batchUndo) undo(fs *FilesystemHandler) error {
+	if u.trash != "" {
+		if err := os.Rename(filepath.Join(u.trash, filepath.Base(u.target)), u.target); err != nil {
+			return err
+		}
+		return os.Remove(u.trash)
+	}
+	if u.from != "" {
+		// El rollback no se interrumpe aunque la llamada se haya cancelado
+		if _, err := fs.moveChecked(context.Background(), u.target, u.from, false); err != nil {
+			return err
+		}
+	}
+	if u.backup != "" {
+		return os.Rename(u.backup, u.target)
+	}
+	if u.created != "" {
+		return os.RemoveAll(u.created)
+	}
+	return nil
+}
+
+// commit - Descarta lo guardado para deshacer la operación
+func (u *batchUndo) commit() {
+	if u.backup != "" {
+		os.Remove(u.backup)
+	}
+	if u.trash != "" {
+		os.RemoveAll(u.trash)
+	}
+}
+
+// firstMissingPath - La ruta más alta entre path y sus padres que no existe,
+// o "" si path ya existe
+func firstMissingPath(path string) string {
+	missing := ""
+	for p := path; ; p = filepath.Dir(p) {
+		if _, err := os.Lstat(p); err == nil {
+			return missing
+		}
+		missing = p
+		if filepath.Dir(p) == p {
+			return missing
+		}
+	}
+}
+
+// setAsideCopy - Copia path a un archivo oculto a su lado y devuelve su ruta
+func setAsideCopy(path string) (string, error) {
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".batch-*")
+	if err != nil {
+		return "", err
+	}
+	temp.Close()
+	if err := copyFile(path, temp.Name()); err != nil {
+		os.Remove(temp.Name())
+		return "", err
+	}
+	return temp.Name(), nil
+}
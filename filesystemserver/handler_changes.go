@@ -0,0 +1,376 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxChangeEntries - Cambios que se listan; los contadores siempre son completos
+const maxChangeEntries = 1000
+
+// changeBaseline - Referencia con la que detect_changes compara el árbol actual
+type changeBaseline struct {
+	since    time.Time
+	snapshot *Snapshot
+	files    map[string]SnapshotFile // por ruta relativa a snapshot.Source
+}
+
+// handleDetectChanges - Archivos creados, modificados y eliminados desde una fecha o un snapshot
+func (fs *FilesystemHandler) handleDetectChanges(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	since, err := getString(request.Params.Arguments, "since", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	snapshotID, err := getString(request.Params.Arguments, "baseline_snapshot", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	hashVerify, err := getBool(request.Params.Arguments, "hash_verify", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	include, err := getNonEmptyStrings(request.Params.Arguments, "include")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	exclude, err := getNonEmptyStrings(request.Params.Arguments, "exclude")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+	if (since == "") == (snapshotID == "") {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: provide exactly one of since or baseline_snapshot"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	baseline, err := fs.parseChangeBaseline(validPath, since, snapshotID)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	// Sin filtros propios se usan los del snapshot, para no dar por eliminado lo que nunca guardó
+	if baseline.snapshot != nil && len(include) == 0 && len(exclude) == 0 {
+		include, exclude = baseline.snapshot.Include, baseline.snapshot.Exclude
+	}
+
+	report, err := fs.detectChanges(ctx, validPath, baseline, include, exclude, hashVerify)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatChangeReport(report)},
+		},
+	}, pathToResourceURI(validPath), report)
+}
+
+// parseChangeBaseline - Referencia de since (una fecha o una duración hacia
+// atrás) o del snapshot snapshotID, que debe contener path
+func (fs *FilesystemHandler) parseChangeBaseline(path, since, snapshotID string) (changeBaseline, error) {
+	var baseline changeBaseline
+	if since != "" {
+		now := time.Now()
+		bound, err := parseLogBound("since", since, now)
+		if err != nil {
+			return baseline, err
+		}
+		bound.resolve(now)
+		baseline.since = bound.at
+		return baseline, nil
+	}
+
+	_, snapshot, err := fs.findSnapshot(snapshotID)
+	if err != nil {
+		return baseline, err
+	}
+	if !pathWithinDir(path, snapshot.Source) {
+		return baseline, fmt.Errorf("%s is not inside the snapshot source %s", path, snapshot.Source)
+	}
+	baseline.snapshot = snapshot
+	baseline.since = snapshot.Created
+	baseline.files = make(map[string]SnapshotFile, len(snapshot.Files))
+	for _, file := range snapshot.Files {
+		baseline.files[file.Path] = file
+	}
+	return baseline, nil
+}
+
+// detectChanges - Recorre path y clasifica cada archivo frente a la referencia
+func (fs *FilesystemHandler) detectChanges(ctx context.Context, path string, baseline changeBaseline, include, exclude []string, hashVerify bool) (*ChangeReport, error) {
+	report, changes, err := fs.collectChanges(ctx, path, baseline, include, exclude, hashVerify)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) > maxChangeEntries {
+		changes = changes[:maxChangeEntries]
+		report.Truncated = true
+	}
+	report.Files = append(report.Files, changes...)
+	return report, nil
+}
+
+// collectChanges - Un único recorrido de path: el informe con los contadores
+// completos y todos los cambios ordenados por ruta, sin recortar
+func (fs *FilesystemHandler) collectChanges(ctx context.Context, path string, baseline changeBaseline, include, exclude []string, hashVerify bool) (*ChangeReport, []ChangedFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report := &ChangeReport{
+		Path:         path,
+		Since:        baseline.since,
+		HashVerified: hashVerify && baseline.snapshot != nil,
+		Files:        []ChangedFile{},
+	}
+	sources := []string{path}
+	if info.IsDir() {
+		// Los filtros se aplican respecto al origen del snapshot, como al crearlo
+		root := path
+		if baseline.snapshot != nil && baseline.snapshot.SourceDir {
+			root = baseline.snapshot.Source
+		}
+		if sources, err = fs.snapshotSourcesUnder(ctx, root, path, include, exclude); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var changes []ChangedFile
+	seen := make(map[string]bool)
+	if baseline.snapshot == nil {
+		report.Baseline = "since"
+		report.Notes = append(report.Notes, "Deleted files cannot be detected without a baseline snapshot")
+		creationKnown := true
+		for _, file := range sources {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, err
+			}
+			fileInfo, err := os.Stat(file)
+			if err != nil {
+				continue
+			}
+			times := platformFileTimes(file, fileInfo)
+			creationKnown = creationKnown && times.hasCreated
+			change := ChangedFile{Path: changeDisplayPath(path, file, info.IsDir()), Size: fileInfo.Size(), ModTime: fileInfo.ModTime()}
+			switch {
+			case times.hasCreated && times.created.After(baseline.since):
+				change.Change, change.Reason = "created", "birth time"
+			case fileInfo.ModTime().After(baseline.since):
+				change.Change, change.Reason = "modified", "mtime"
+			default:
+				continue
+			}
+			changes = append(changes, change)
+		}
+		if !creationKnown {
+			report.Notes = append(report.Notes, "Creation times are not available here: new files are reported as modified")
+		}
+		if hashVerify {
+			report.Notes = append(report.Notes, "hash_verify needs a baseline_snapshot; mtime was used")
+		}
+	} else {
+		snapshot := baseline.snapshot
+		report.Baseline = snapshot.ID
+		for _, file := range sources {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, err
+			}
+			fileInfo, err := os.Stat(file)
+			if err != nil {
+				continue
+			}
+			rel := filepath.Base(file)
+			if snapshot.SourceDir {
+				rel, _ = filepath.Rel(snapshot.Source, file)
+			}
+			rel = filepath.ToSlash(rel)
+			seen[rel] = true
+			change := ChangedFile{Path: changeDisplayPath(path, file, info.IsDir()), Size: fileInfo.Size(), ModTime: fileInfo.ModTime()}
+
+			stored, ok := baseline.files[rel]
+			switch {
+			case !ok:
+				change.Change, change.Reason = "created", "not in snapshot"
+			case stored.Size != fileInfo.Size():
+				change.Change, change.Reason = "modified", "size"
+			case hashVerify:
+				hashes, err := calculateChecksums(file, []string{"sha256"})
+				if err != nil {
+					continue
+				}
+				fs.stats.addRead(int(fileInfo.Size()))
+				if hashes["sha256"] == stored.SHA256 {
+					continue
+				}
+				change.Change, change.Reason = "modified", "hash"
+			default:
+				reference := stored.ModTime
+				if reference.IsZero() {
+					reference = snapshot.Created
+				}
+				if !fileInfo.ModTime().After(reference) {
+					continue
+				}
+				change.Change, change.Reason = "modified", "mtime"
+			}
+			changes = append(changes, change)
+		}
+
+		for _, stored := range snapshot.Files {
+			if seen[stored.Path] {
+				continue
+			}
+			original := snapshot.Source
+			if snapshot.SourceDir {
+				original = filepath.Join(snapshot.Source, filepath.FromSlash(stored.Path))
+			}
+			if !pathWithinDir(original, path) {
+				continue
+			}
+			if _, err := os.Lstat(original); err == nil {
+				// Sigue ahí, pero ahora lo filtran las reglas o no es legible
+				continue
+			}
+			changes = append(changes, ChangedFile{
+				Path:    changeDisplayPath(path, original, info.IsDir()),
+				Change:  "deleted",
+				Reason:  "missing",
+				Size:    stored.Size,
+				ModTime: stored.ModTime,
+			})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	for _, change := range changes {
+		switch change.Change {
+		case "created":
+			report.Created++
+		case "modified":
+			report.Modified++
+		case "deleted":
+			report.Deleted++
+		}
+		report.ChangedBytes += change.Size
+	}
+	return report, changes, nil
+}
+
+// snapshotSourcesUnder - snapshotSources de root limitado a los archivos bajo path
+func (fs *FilesystemHandler) snapshotSourcesUnder(ctx context.Context, root, path string, include, exclude []string) ([]string, error) {
+	if root == path {
+		return fs.snapshotSources(ctx, root, include, exclude)
+	}
+	// Las reglas se evalúan relativas a root, pero solo se recorre path
+	var sources []string
+	all, err := fs.snapshotSources(ctx, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range all {
+		if matchesAnyPattern(root, file, exclude) || (len(include) > 0 && !matchesAnyPattern(root, file, include)) {
+			continue
+		}
+		if excludedAncestor(root, file, exclude) {
+			continue
+		}
+		sources = append(sources, file)
+	}
+	return sources, nil
+}
+
+// excludedAncestor - Indica si algún directorio entre root y path coincide con exclude
+func excludedAncestor(root, path string, exclude []string) bool {
+	for dir := filepath.Dir(path); dir != root && pathWithinDir(dir, root); dir = filepath.Dir(dir) {
+		if matchesAnyPattern(root, dir, exclude) {
+			return true
+		}
+	}
+	return false
+}
+
+// changeDisplayPath - Ruta relativa a path, o el nombre cuando path es un archivo
+func changeDisplayPath(path, file string, dir bool) string {
+	if !dir {
+		return filepath.Base(file)
+	}
+	rel, err := filepath.Rel(path, file)
+	if err != nil {
+		return file
+	}
+	return filepath.ToSlash(rel)
+}
+
+// formatChangeReport - Resumen legible de detect_changes
+func formatChangeReport(report *ChangeReport) string {
+	var b strings.Builder
+	if report.Baseline == "since" {
+		fmt.Fprintf(&b, "🕒 Changes in %s since %s\n", report.Path, report.Since.Format(time.RFC3339))
+	} else {
+		fmt.Fprintf(&b, "📸 Changes in %s since snapshot %s (%s)\n", report.Path, report.Baseline, report.Since.Format(time.RFC3339))
+	}
+	if report.HashVerified {
+		b.WriteString("🔐 Same-size files verified by SHA-256\n")
+	}
+	fmt.Fprintf(&b, "📊 %d created, %d modified, %d deleted, %d bytes changed\n", report.Created, report.Modified, report.Deleted, report.ChangedBytes)
+	for _, note := range report.Notes {
+		fmt.Fprintf(&b, "ℹ️ %s\n", note)
+	}
+	if len(report.Files) == 0 {
+		b.WriteString("\n✅ No changes\n")
+		return b.String()
+	}
+
+	b.WriteString("\n")
+	icons := map[string]string{"created": "➕", "modified": "✏️", "deleted": "➖"}
+	for _, change := range report.Files {
+		fmt.Fprintf(&b, "%s %s (%d bytes, %s)\n", icons[change.Change], change.Path, change.Size, change.Reason)
+	}
+	if report.Truncated {
+		fmt.Fprintf(&b, "... only the first %d changes are listed\n", maxChangeEntries)
+	}
+	return b.String()
+}
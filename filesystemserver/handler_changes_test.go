@@ -0,0 +1,137 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func changesByPath(report ChangeReport) map[string]string {
+	changes := make(map[string]string)
+	for _, file := range report.Files {
+		changes[file.Path] = file.Change + ":" + file.Reason
+	}
+	return changes
+}
+
+func TestDetectChangesAgainstSnapshot(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	project := filepath.Join(dir, "project")
+	for name, content := range map[string]string{
+		"keep.txt":       "unchanged",
+		"grow.txt":       "short",
+		"same.txt":       "aaaa",
+		"gone.txt":       "bye",
+		"sub/inner.txt":  "inner",
+		"build/out.bin":  "artifact",
+		".git/HEAD":      "ref: main",
+		"sub/remove.txt": "soon gone",
+	} {
+		path := filepath.Join(project, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	snapshot, _, err := handler.createSnapshot(context.Background(), project, nil, []string{"build"})
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(project, "grow.txt"), []byte("much longer now"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(project, "new.txt"), []byte("fresh"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(project, "gone.txt")))
+	require.NoError(t, os.Remove(filepath.Join(project, "sub", "remove.txt")))
+	require.NoError(t, os.WriteFile(filepath.Join(project, "build", "out.bin"), []byte("rebuilt artifact"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".git", "HEAD"), []byte("ref: other"), 0644))
+	// Mismo tamaño y la fecha original: solo el hash lo delata
+	same := filepath.Join(project, "same.txt")
+	info, err := os.Stat(same)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(same, []byte("bbbb"), 0644))
+	require.NoError(t, os.Chtimes(same, info.ModTime(), info.ModTime()))
+
+	detect := func(args map[string]interface{}) ChangeReport {
+		result, err := handler.handleDetectChanges(context.Background(), newToolRequest("detect_changes", args))
+		require.NoError(t, err)
+		var report ChangeReport
+		decodeStructured(t, result, &report)
+		return report
+	}
+
+	report := detect(map[string]interface{}{"path": project, "baseline_snapshot": snapshot.ID})
+	assert.Equal(t, map[string]string{
+		"grow.txt":       "modified:size",
+		"new.txt":        "created:not in snapshot",
+		"gone.txt":       "deleted:missing",
+		"sub/remove.txt": "deleted:missing",
+	}, changesByPath(report), "build/ stays excluded with the snapshot's filters")
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Modified)
+	assert.Equal(t, 2, report.Deleted)
+	assert.Equal(t, int64(len("much longer now")+len("fresh")+len("bye")+len("soon gone")), report.ChangedBytes)
+	assert.False(t, report.HashVerified)
+
+	report = detect(map[string]interface{}{"path": project, "baseline_snapshot": snapshot.ID, "hash_verify": true})
+	assert.True(t, report.HashVerified)
+	assert.Equal(t, "modified:hash", changesByPath(report)["same.txt"])
+	assert.Equal(t, 2, report.Modified)
+
+	// Un subdirectorio del origen solo informa de lo suyo
+	report = detect(map[string]interface{}{"path": filepath.Join(project, "sub"), "baseline_snapshot": snapshot.ID})
+	assert.Equal(t, map[string]string{"remove.txt": "deleted:missing"}, changesByPath(report))
+
+	result, err := handler.handleDetectChanges(context.Background(), newToolRequest("detect_changes", map[string]interface{}{
+		"path": project, "baseline_snapshot": snapshot.ID,
+	}))
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "1 created, 1 modified, 2 deleted")
+	assert.Contains(t, text, "➖ gone.txt")
+}
+
+func TestDetectChangesSince(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	old := filepath.Join(dir, "old.txt")
+	touched := filepath.Join(dir, "touched.txt")
+	require.NoError(t, os.WriteFile(old, []byte("old"), 0644))
+	require.NoError(t, os.WriteFile(touched, []byte("v1"), 0644))
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(old, past, past))
+
+	time.Sleep(20 * time.Millisecond)
+	since := time.Now()
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, os.WriteFile(touched, []byte("v2"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new"), 0644))
+
+	report, err := handler.detectChanges(context.Background(), dir, changeBaseline{since: since}, nil, nil, false)
+	require.NoError(t, err)
+	changes := changesByPath(*report)
+	assert.Equal(t, "modified:mtime", changes["touched.txt"])
+	assert.Contains(t, []string{"created:birth time", "modified:mtime"}, changes["new.txt"])
+	assert.NotContains(t, changes, "old.txt")
+	assert.Zero(t, report.Deleted)
+	assert.NotEmpty(t, report.Notes)
+
+	result, err := handler.handleDetectChanges(context.Background(), newToolRequest("detect_changes", map[string]interface{}{
+		"path": dir, "since": since.Format(time.RFC3339Nano),
+	}))
+	require.NoError(t, err)
+	var viaTool ChangeReport
+	decodeStructured(t, result, &viaTool)
+	assert.Equal(t, changes, changesByPath(viaTool))
+
+	for _, bad := range []map[string]interface{}{
+		{"path": dir},
+		{"path": dir, "since": "1h", "baseline_snapshot": "snap_20240101T000000_deadbeef"},
+		{"path": dir, "since": "yesterday"},
+		{"path": dir, "baseline_snapshot": "snap_20240101T000000_deadbeef"},
+	} {
+		result, err := handler.handleDetectChanges(context.Background(), newToolRequest("detect_changes", bad))
+		require.NoError(t, err)
+		assert.True(t, result.IsError, bad)
+	}
+}
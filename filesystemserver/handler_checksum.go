@@ -0,0 +1,467 @@
+package filesystemserver
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// checksumAlgorithms - Algoritmos soportados y su constructor
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+	"xxhash": func() hash.Hash { return newXXHash64() },
+}
+
+// handleChecksum - Calcula hashes de un archivo o de los archivos de un directorio
+func (fs *FilesystemHandler) handleChecksum(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	recursive, err := getBool(request.Params.Arguments, "recursive", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	output, err := getString(request.Params.Arguments, "output", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	algorithms, err := getNonEmptyStrings(request.Params.Arguments, "algorithms")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	for i, name := range algorithms {
+		algorithms[i] = strings.ToLower(name)
+	}
+	if len(algorithms) == 0 {
+		algorithms = []string{"sha256"}
+	}
+	for _, name := range algorithms {
+		if _, ok := checksumAlgorithms[name]; !ok {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: unsupported algorithm '%s' (use md5, sha1, sha256, sha512 or xxhash)", name)},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	if output == "" {
+		output = "text"
+	}
+	if output != "text" && output != "json" && output != "sfv" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: unsupported output '%s' (use 'text', 'json' or 'sfv')", output)},
+			},
+			IsError: true,
+		}, nil
+	}
+	if output == "sfv" && len(algorithms) > 1 {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: sfv output supports a single algorithm"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	excludePatterns, err := getNonEmptyStrings(request.Params.Arguments, "exclude_patterns")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	manifest, err := fs.computeChecksums(ctx, validPath, algorithms, recursive, excludePatterns)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error computing checksums: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var text string
+	report, reportFormat := []byte(nil), output
+	switch output {
+	case "json":
+		data, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		text, report = string(data), data
+	case "sfv":
+		text = manifest.sfv(algorithms[0])
+		report = []byte(text)
+	default:
+		// Para el texto, el informe guarda el manifiesto completo en JSON
+		data, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		report, reportFormat = data, "json"
+
+		var result strings.Builder
+		result.WriteString(fmt.Sprintf("🔐 Checksums for %s (%d files):\n\n", path, len(manifest.Files)))
+		for _, file := range manifest.Files {
+			result.WriteString(fmt.Sprintf("📄 %s (%d bytes)\n", file.Path, file.Size))
+			for _, name := range algorithms {
+				result.WriteString(fmt.Sprintf("   %s: %s\n", name, file.Hashes[name]))
+			}
+		}
+		if manifest.ManifestHash != "" {
+			result.WriteString(fmt.Sprintf("\n📋 Manifest SHA256 (%s): %s\n", algorithms[0], manifest.ManifestHash))
+		}
+		text = result.String()
+	}
+
+	summary := func() string {
+		summary := fmt.Sprintf("🔐 Checksums for %s (%d files, %s)\n", path, len(manifest.Files), strings.Join(algorithms, ", "))
+		if manifest.ManifestHash != "" {
+			summary += fmt.Sprintf("📋 Manifest SHA256 (%s): %s\n", algorithms[0], manifest.ManifestHash)
+		}
+		return summary
+	}
+	if spilled := fs.oversizedResult(validPath, "checksum", len(text), report, reportFormat, summary); spilled != nil {
+		return spilled, nil
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: text},
+		},
+	}, nil
+}
+
+// computeChecksums - Calcula los hashes pedidos para path. En directorios las
+// rutas son relativas y el manifiesto agregado es el SHA256 de la salida sfv
+// del primer algoritmo
+func (fs *FilesystemHandler) computeChecksums(ctx context.Context, path string, algorithms []string, recursive bool, excludePatterns []string) (*ChecksumManifest, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &ChecksumManifest{Root: path, Algorithms: algorithms}
+
+	if !info.IsDir() {
+		hashes, err := calculateChecksums(path, algorithms)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Files = []FileChecksum{{Path: filepath.Base(path), Size: info.Size(), Hashes: hashes}}
+		return manifest, nil
+	}
+
+	err = fs.walkContext(ctx, path, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			fs.logWalkError(ctx, currentPath, err)
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if currentPath == path {
+			return nil
+		}
+		if fs.shouldIgnoreEntry(ctx, currentPath, info.IsDir()) || matchesAnyPattern(path, currentPath, excludePatterns) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			if !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if _, err := fs.validatePath(currentPath); err != nil {
+			return nil
+		}
+
+		hashes, err := calculateChecksums(currentPath, algorithms)
+		if err != nil {
+			return nil // Continuar con otros archivos
+		}
+		rel, _ := filepath.Rel(path, currentPath)
+		manifest.Files = append(manifest.Files, FileChecksum{
+			Path:   filepath.ToSlash(rel),
+			Size:   info.Size(),
+			Hashes: hashes,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+	sum := sha256.Sum256([]byte(manifest.sfv(algorithms[0])))
+	manifest.ManifestHash = hex.EncodeToString(sum[:])
+	return manifest, nil
+}
+
+// sfv - Genera el manifiesto "<hash>  <ruta>" compatible con sha256sum -c
+func (m *ChecksumManifest) sfv(algorithm string) string {
+	var b strings.Builder
+	for _, file := range m.Files {
+		b.WriteString(fmt.Sprintf("%s  %s\n", file.Hashes[algorithm], file.Path))
+	}
+	return b.String()
+}
+
+// calculateChecksums - Calcula varios hashes de un archivo en una sola lectura
+func calculateChecksums(filePath string, algorithms []string) (map[string]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hashers := make(map[string]hash.Hash, len(algorithms))
+	writers := make([]io.Writer, 0, len(algorithms))
+	for _, name := range algorithms {
+		h := checksumAlgorithms[name]()
+		hashers[name] = h
+		writers = append(writers, h)
+	}
+
+	if _, err := io.Copy(io.MultiWriter(writers...), file); err != nil {
+		return nil, err
+	}
+
+	hashes := make(map[string]string, len(hashers))
+	for name, h := range hashers {
+		hashes[name] = hex.EncodeToString(h.Sum(nil))
+	}
+	return hashes, nil
+}
+
+// checksumAlgorithmForLength - Deduce el algoritmo por la longitud del hash hex
+func checksumAlgorithmForLength(n int) string {
+	switch n {
+	case 16:
+		return "xxhash"
+	case 32:
+		return "md5"
+	case 40:
+		return "sha1"
+	case 64:
+		return "sha256"
+	case 128:
+		return "sha512"
+	}
+	return ""
+}
+
+// handleVerifyChecksums - Verifica los archivos listados en un manifiesto sfv
+func (fs *FilesystemHandler) handleVerifyChecksums(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	manifestPath, err := getString(request.Params.Arguments, "manifest", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	baseDir, err := getString(request.Params.Arguments, "base_dir", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	algorithm, err := getString(request.Params.Arguments, "algorithm", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if manifestPath == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: manifest is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	algorithm = strings.ToLower(algorithm)
+	if _, ok := checksumAlgorithms[algorithm]; algorithm != "" && !ok {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: unsupported algorithm '%s' (use md5, sha1, sha256, sha512 or xxhash)", algorithm)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validManifest, err := fs.validatePath(manifestPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error with manifest: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if baseDir == "" {
+		baseDir = filepath.Dir(validManifest)
+	}
+	validBase, err := fs.validatePath(baseDir)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error with base_dir: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	results, err := fs.verifyChecksums(ctx, validManifest, validBase, algorithm)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error verifying checksums: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	counts := make(map[string]int)
+	var result strings.Builder
+	for _, r := range results {
+		counts[r.Status]++
+		if r.Note != "" {
+			result.WriteString(fmt.Sprintf("%s: %s (%s)\n", r.Path, r.Status, r.Note))
+		} else {
+			result.WriteString(fmt.Sprintf("%s: %s\n", r.Path, r.Status))
+		}
+	}
+
+	summary := fmt.Sprintf("🔐 Verified %d entries: ✅ %d OK | ❌ %d FAILED | ❓ %d MISSING\n\n",
+		len(results), counts["OK"], counts["FAILED"], counts["MISSING"])
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: summary + result.String()},
+		},
+		IsError: counts["FAILED"] > 0 || counts["MISSING"] > 0,
+	}, nil
+}
+
+// verifyChecksums - Lee un manifiesto "<hash>  <ruta>" y comprueba cada entrada
+func (fs *FilesystemHandler) verifyChecksums(ctx context.Context, manifestPath, baseDir, algorithm string) ([]ChecksumVerification, error) {
+	file, err := os.Open(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var results []ChecksumVerification
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Formato de sha256sum: "<hash>  <ruta>" o "<hash> *<ruta>" (modo binario)
+		sep := strings.Index(line, " ")
+		if sep <= 0 || sep+2 > len(line) {
+			results = append(results, ChecksumVerification{Path: line, Status: "FAILED", Note: "malformed line"})
+			continue
+		}
+		expected := strings.ToLower(line[:sep])
+		rel := line[sep+2:]
+
+		entry := ChecksumVerification{Path: rel, Expected: expected}
+		name := algorithm
+		if name == "" {
+			name = checksumAlgorithmForLength(len(expected))
+		}
+		if name == "" {
+			entry.Status, entry.Note = "FAILED", "unknown hash length"
+			results = append(results, entry)
+			continue
+		}
+
+		target := rel
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(baseDir, filepath.FromSlash(rel))
+		}
+		if !fs.isPathInAllowedDirs(target) {
+			entry.Status, entry.Note = "FAILED", "access denied - path outside allowed directories"
+			results = append(results, entry)
+			continue
+		}
+		if _, err := os.Lstat(target); os.IsNotExist(err) {
+			entry.Status = "MISSING"
+			results = append(results, entry)
+			continue
+		}
+		validTarget, err := fs.validatePath(target)
+		if err != nil {
+			entry.Status, entry.Note = "FAILED", err.Error()
+			results = append(results, entry)
+			continue
+		}
+
+		hashes, err := calculateChecksums(validTarget, []string{name})
+		if err != nil {
+			entry.Status, entry.Note = "FAILED", err.Error()
+			results = append(results, entry)
+			continue
+		}
+		entry.Actual = hashes[name]
+		if entry.Actual == expected {
+			entry.Status = "OK"
+		} else {
+			entry.Status = "FAILED"
+		}
+		results = append(results, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,96 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXXHash64(t *testing.T) {
+	tests := map[string]string{
+		"":    "ef46db3751d8e999",
+		"a":   "d24ec4f1a98c6e5b",
+		"abc": "44bc2cf5ad770999",
+		"Nobody inspects the spammish repetition": "fbcea83c8a378bf1",
+	}
+	for input, expected := range tests {
+		h := newXXHash64()
+		h.Write([]byte(input))
+		assert.Equal(t, expected, hex.EncodeToString(h.Sum(nil)), "xxhash(%q)", input)
+
+		// Escribir byte a byte debe dar el mismo resultado
+		h.Reset()
+		for i := 0; i < len(input); i++ {
+			h.Write([]byte{input[i]})
+		}
+		assert.Equal(t, expected, hex.EncodeToString(h.Sum(nil)), "streamed xxhash(%q)", input)
+	}
+}
+
+func TestCalculateChecksums(t *testing.T) {
+	_, dir := newTestHandler(t)
+	path := filepath.Join(dir, "data.txt")
+	require.NoError(t, os.WriteFile(path, []byte("abc"), 0644))
+
+	hashes, err := calculateChecksums(path, []string{"md5", "sha1", "sha256", "sha512", "xxhash"})
+	require.NoError(t, err)
+	assert.Equal(t, "900150983cd24fb0d6963f7d28e17f72", hashes["md5"])
+	assert.Equal(t, "a9993e364706816aba3e25717850c26c9cd0d89d", hashes["sha1"])
+	assert.Equal(t, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", hashes["sha256"])
+	assert.Len(t, hashes["sha512"], 128)
+	assert.Equal(t, "44bc2cf5ad770999", hashes["xxhash"])
+}
+
+func TestChecksumManifestRoundTrip(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	tree := filepath.Join(dir, "tree")
+	files := map[string]string{
+		"a.txt":         "alpha\n",
+		"sub/b.txt":     "beta\n",
+		"sub/skip.log":  "ignored\n",
+		".git/HEAD":     "ref\n",
+		"gone/away.txt": "soon missing\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(tree, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	result, err := handler.handleChecksum(context.Background(), newToolRequest("checksum", map[string]interface{}{
+		"path":             tree,
+		"recursive":        true,
+		"output":           "sfv",
+		"exclude_patterns": []interface{}{"*.log"},
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	sfv := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, sfv, "  a.txt\n")
+	assert.Contains(t, sfv, "  sub/b.txt\n")
+	assert.NotContains(t, sfv, "skip.log")
+	assert.NotContains(t, sfv, "HEAD")
+
+	manifest := filepath.Join(tree, "SHA256SUMS")
+	require.NoError(t, os.WriteFile(manifest, []byte(sfv), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tree, "a.txt"), []byte("changed\n"), 0644))
+	require.NoError(t, os.RemoveAll(filepath.Join(tree, "gone")))
+
+	results, err := handler.verifyChecksums(context.Background(), manifest, tree, "")
+	require.NoError(t, err)
+	statuses := make(map[string]string)
+	for _, r := range results {
+		statuses[r.Path] = r.Status
+	}
+	assert.Equal(t, map[string]string{
+		"a.txt":         "FAILED",
+		"gone/away.txt": "MISSING",
+		"sub/b.txt":     "OK",
+	}, statuses)
+}
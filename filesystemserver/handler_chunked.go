@@ -2,20 +2,38 @@ package filesystemserver
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // handleChunkedWrite - Escribe archivo en fragmentos de 1MB
 func (fs *FilesystemHandler) handleChunkedWrite(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	path, _ := request.Params.Arguments["path"].(string)
-	content, _ := request.Params.Arguments["content"].(string)
-	chunkIndex, _ := request.Params.Arguments["chunk_index"].(float64)
-	totalChunks, _ := request.Params.Arguments["total_chunks"].(float64)
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	content, err := getString(request.Params.Arguments, "content", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	chunkIndex, err := getNumber(request.Params.Arguments, "chunk_index", 0)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	totalChunks, err := getNumber(request.Params.Arguments, "total_chunks", 0)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
 
 	if path == "" || content == "" {
 		return &mcp.CallToolResult{
@@ -36,10 +54,14 @@ func (fs *FilesystemHandler) handleChunkedWrite(ctx context.Context, request mcp
 		}, nil
 	}
 
-	// Primer chunk - crear/truncar archivo
+	// Los fragmentos se acumulan en un temporal que solo sustituye al destino
+	// con el último, así una escritura abandonada nunca deja el archivo a medias
+	tempPath := chunkSessionPath(validPath)
+
+	// Primer chunk - crear/truncar el temporal
 	if chunkIndex == 0 {
 		parentDir := filepath.Dir(validPath)
-		if err := os.MkdirAll(parentDir, 0755); err != nil {
+		if err := fs.mkdirAllChecked(parentDir, 0755); err != nil {
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
 					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error creating directory: %v", err)},
@@ -52,9 +74,20 @@ func (fs *FilesystemHandler) handleChunkedWrite(ctx context.Context, request mcp
 	// Escribir chunk
 	var file *os.File
 	if chunkIndex == 0 {
-		file, err = os.Create(validPath)
+		file, err = fs.openFileChecked(tempPath, os.O_WRONLY|os.O_CREATE, 0644)
+		if err == nil {
+			if err = file.Truncate(0); err != nil {
+				file.Close()
+			}
+		}
+		if err == nil {
+			fs.startChunkSession(validPath, tempPath)
+		}
 	} else {
-		file, err = os.OpenFile(validPath, os.O_WRONLY|os.O_APPEND, 0644)
+		file, err = fs.openFileChecked(tempPath, os.O_WRONLY|os.O_APPEND, 0644)
+		if os.IsNotExist(err) {
+			err = fmt.Errorf("no chunked write in progress for %s, start again with chunk_index 0", path)
+		}
 	}
 	if err != nil {
 		return &mcp.CallToolResult{
@@ -64,9 +97,12 @@ func (fs *FilesystemHandler) handleChunkedWrite(ctx context.Context, request mcp
 			IsError: true,
 		}, nil
 	}
-	defer file.Close()
 
-	_, err = file.WriteString(content)
+	n, err := file.WriteString(content)
+	fs.stats.addWritten(n)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -77,8 +113,22 @@ func (fs *FilesystemHandler) handleChunkedWrite(ctx context.Context, request mcp
 	}
 
 	completed := int(chunkIndex) >= int(totalChunks)-1
-	
-	info, _ := os.Stat(validPath)
+
+	sizePath := tempPath
+	if completed {
+		if err := fs.renameChecked(tempPath, validPath); err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error finalizing file: %v", err)},
+				},
+				IsError: true,
+			}, nil
+		}
+		fs.endChunkSession(validPath)
+		sizePath = validPath
+	}
+
+	info, _ := os.Stat(sizePath)
 	size := int64(0)
 	if info != nil {
 		size = info.Size()
@@ -89,7 +139,7 @@ func (fs *FilesystemHandler) handleChunkedWrite(ctx context.Context, request mcp
 		status = "✅ Completed"
 	}
 
-	return &mcp.CallToolResult{
+	result := &mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
@@ -97,13 +147,29 @@ func (fs *FilesystemHandler) handleChunkedWrite(ctx context.Context, request mcp
 					status, path, int(chunkIndex)+1, int(totalChunks), size),
 			},
 		},
-	}, nil
+	}
+	// El archivo solo existe cuando llega el último fragmento
+	if completed {
+		withFileResources(result, []string{validPath})
+	}
+	return result, nil
+}
+
+// chunkSessionPath - Temporal en el que chunked_write acumula los fragmentos de validPath
+func chunkSessionPath(validPath string) string {
+	return validPath + ".chunked.tmp"
 }
 
 // handleSplitFile - Divide archivo en múltiples fragmentos
 func (fs *FilesystemHandler) handleSplitFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	path, _ := request.Params.Arguments["path"].(string)
-	chunkSizeParam, _ := request.Params.Arguments["chunk_size"].(float64)
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	chunkSizeParam, err := getNumber(request.Params.Arguments, "chunk_size", 0)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
 
 	if path == "" {
 		return &mcp.CallToolResult{
@@ -148,6 +214,16 @@ func (fs *FilesystemHandler) handleSplitFile(ctx context.Context, request mcp.Ca
 		}, nil
 	}
 
+	// Comprobar espacio libre antes de empezar
+	if available, ok := availableDiskSpace(filepath.Dir(validPath)); ok && available < info.Size() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: not enough free space to split %s (need %d bytes, %d available)", path, info.Size(), available)},
+			},
+			IsError: true,
+		}, nil
+	}
+
 	sourceFile, err := os.Open(validPath)
 	if err != nil {
 		return &mcp.CallToolResult{
@@ -162,36 +238,56 @@ func (fs *FilesystemHandler) handleSplitFile(ctx context.Context, request mcp.Ca
 	totalChunks := (info.Size() + chunkSize - 1) / chunkSize
 	var chunkFiles []string
 
+	// Ante cualquier fallo, eliminar los fragmentos ya creados
+	fail := func(msg string) (*mcp.CallToolResult, error) {
+		removed := removeChunkFiles(chunkFiles)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("%s\nCleaned up %d partial chunk(s)", msg, removed)},
+			},
+			IsError: true,
+		}, nil
+	}
+
 	for i := int64(0); i < totalChunks; i++ {
+		if err := ctx.Err(); err != nil {
+			return fail(fmt.Sprintf("❌ Split aborted: %v", err))
+		}
+
+		// Un fragmento existente puede ser un enlace simbólico fuera de los
+		// directorios permitidos: se valida y se abre sin seguirlo
 		chunkName := fmt.Sprintf("%s.part%03d", validPath, i)
-		chunkFile, err := os.Create(chunkName)
+		if _, err := fs.validatePath(chunkName); err != nil {
+			return fail(fmt.Sprintf("❌ Error creating chunk: %v", err))
+		}
+		chunkFile, err := fs.openFileChecked(chunkName, os.O_WRONLY|os.O_CREATE, 0644)
+		if err == nil {
+			if err = chunkFile.Truncate(0); err != nil {
+				chunkFile.Close()
+			}
+		}
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error creating chunk: %v", err)},
-				},
-				IsError: true,
-			}, nil
+			return fail(fmt.Sprintf("❌ Error creating chunk: %v", err))
 		}
+		chunkFiles = append(chunkFiles, chunkName)
 
 		written, err := io.CopyN(chunkFile, sourceFile, chunkSize)
-		chunkFile.Close()
+		closeErr := chunkFile.Close()
 
 		if err != nil && err != io.EOF {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error writing chunk: %v", err)},
-				},
-				IsError: true,
-			}, nil
+			return fail(fmt.Sprintf("❌ Error writing chunk: %v", err))
+		}
+		if closeErr != nil {
+			return fail(fmt.Sprintf("❌ Error writing chunk: %v", closeErr))
 		}
 
-		if written > 0 {
-			chunkFiles = append(chunkFiles, chunkName)
+		if written == 0 {
+			os.Remove(chunkName)
+			chunkFiles = chunkFiles[:len(chunkFiles)-1]
 		}
 	}
 
-	return &mcp.CallToolResult{
+	return withFileResources(&mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
@@ -199,18 +295,127 @@ func (fs *FilesystemHandler) handleSplitFile(ctx context.Context, request mcp.Ca
 					path, info.Size(), len(chunkFiles), chunkSize),
 			},
 		},
+	}, chunkFiles), nil
+}
+
+// handleSplitCleanup - Elimina los fragmentos .partNNN generados por split_file
+func (fs *FilesystemHandler) handleSplitCleanup(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	chunkFiles, err := findChunkFiles(validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error listing chunks: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if len(chunkFiles) == 0 {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("✅ No chunk files found for %s", path)},
+			},
+		}, nil
+	}
+
+	removed := removeChunkFiles(chunkFiles)
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("🧹 Split cleanup: %s\nRemoved: %d/%d chunk files", path, removed, len(chunkFiles)),
+			},
+		},
 	}, nil
 }
 
+// chunkSuffixPattern - Sufijo usado por split_file para los fragmentos
+var chunkSuffixPattern = regexp.MustCompile(`^\.part\d{3,}$`)
+
+// findChunkFiles - Devuelve los fragmentos .partNNN que pertenecen a un archivo origen
+func findChunkFiles(sourcePath string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(sourcePath))
+	if err != nil {
+		return nil, err
+	}
+
+	base := filepath.Base(sourcePath)
+	var chunkFiles []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, base) {
+			continue
+		}
+		if chunkSuffixPattern.MatchString(name[len(base):]) {
+			chunkFiles = append(chunkFiles, filepath.Join(filepath.Dir(sourcePath), name))
+		}
+	}
+	sort.Strings(chunkFiles)
+	return chunkFiles, nil
+}
+
+// removeChunkFiles - Elimina los fragmentos indicados y devuelve cuántos se borraron
+func removeChunkFiles(chunkFiles []string) int {
+	removed := 0
+	for _, chunk := range chunkFiles {
+		if err := os.Remove(chunk); err == nil || os.IsNotExist(err) {
+			removed++
+		}
+	}
+	return removed
+}
+
 // handleJoinFiles - Une múltiples fragmentos en un archivo
 func (fs *FilesystemHandler) handleJoinFiles(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	targetPath, _ := request.Params.Arguments["target_path"].(string)
-	sourceFilesParam, _ := request.Params.Arguments["source_files"].([]interface{})
+	targetPath, err := getString(request.Params.Arguments, "target_path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	sourceFilesParam, err := getStringSlice(request.Params.Arguments, "source_files")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	sourceGlob, err := getString(request.Params.Arguments, "source_glob", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
 
-	if targetPath == "" || len(sourceFilesParam) == 0 {
+	if targetPath == "" || (len(sourceFilesParam) == 0 && sourceGlob == "") {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				mcp.TextContent{Type: "text", Text: "❌ Error: target_path and source_files are required"},
+				mcp.TextContent{Type: "text", Text: "❌ Error: target_path and source_files or source_glob are required"},
+			},
+			IsError: true,
+		}, nil
+	}
+	if len(sourceFilesParam) > 0 && sourceGlob != "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: use either source_files or source_glob, not both"},
 			},
 			IsError: true,
 		}, nil
@@ -228,24 +433,39 @@ func (fs *FilesystemHandler) handleJoinFiles(ctx context.Context, request mcp.Ca
 
 	// Convertir source files
 	var sourceFiles []string
-	for _, sf := range sourceFilesParam {
-		if str, ok := sf.(string); ok {
-			validPath, err := fs.validatePath(str)
-			if err != nil {
-				return &mcp.CallToolResult{
-					Content: []mcp.Content{
-						mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error with source file %s: %v", str, err)},
-					},
-					IsError: true,
-				}, nil
-			}
-			sourceFiles = append(sourceFiles, validPath)
+	for _, str := range sourceFilesParam {
+		validPath, err := fs.validatePath(str)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error with source file %s: %v", str, err)},
+				},
+				IsError: true,
+			}, nil
+		}
+		sourceFiles = append(sourceFiles, validPath)
+	}
+
+	// Con un glob, los fragmentos salen en orden natural y sin huecos
+	var sequence string
+	if sourceGlob != "" {
+		sourceFiles, err = fs.expandChunkGlob(sourceGlob, validTargetPath)
+		if err == nil {
+			sequence, err = checkChunkSequence(sourceFiles)
+		}
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error with source_glob %s: %v", sourceGlob, err)},
+				},
+				IsError: true,
+			}, nil
 		}
 	}
 
 	// Crear directorio padre si no existe
 	parentDir := filepath.Dir(validTargetPath)
-	if err := os.MkdirAll(parentDir, 0755); err != nil {
+	if err := fs.mkdirAllChecked(parentDir, 0755); err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error creating directory: %v", err)},
@@ -254,7 +474,12 @@ func (fs *FilesystemHandler) handleJoinFiles(ctx context.Context, request mcp.Ca
 		}, nil
 	}
 
-	targetFile, err := os.Create(validTargetPath)
+	targetFile, err := fs.openFileChecked(validTargetPath, os.O_WRONLY|os.O_CREATE, 0644)
+	if err == nil {
+		if err = targetFile.Truncate(0); err != nil {
+			targetFile.Close()
+		}
+	}
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -290,24 +515,141 @@ func (fs *FilesystemHandler) handleJoinFiles(ctx context.Context, request mcp.Ca
 		}
 
 		totalSize += written
+		fs.stats.addWritten(int(written))
 	}
 
-	return &mcp.CallToolResult{
+	text := fmt.Sprintf("✅ Join completed: %s\nSources: %d files\nTotal size: %d bytes",
+		targetPath, len(sourceFiles), totalSize)
+	if sequence != "" {
+		text += fmt.Sprintf("\nSequence: %s (%s → %s)", sequence, filepath.Base(sourceFiles[0]), filepath.Base(sourceFiles[len(sourceFiles)-1]))
+	}
+	return withFileResources(&mcp.CallToolResult{
 		Content: []mcp.Content{
-			mcp.TextContent{
-				Type: "text",
-				Text: fmt.Sprintf("✅ Join completed: %s\nSources: %d files\nTotal size: %d bytes",
-					targetPath, len(sourceFiles), totalSize),
-			},
+			mcp.TextContent{Type: "text", Text: text},
 		},
-	}, nil
+	}, []string{validTargetPath}), nil
+}
+
+// expandChunkGlob - Archivos que casan con pattern (sintaxis de filepath.Match)
+// dentro de los directorios permitidos, en orden natural; se omiten los
+// denegados, los directorios y el propio destino
+func (fs *FilesystemHandler) expandChunkGlob(pattern, target string) ([]string, error) {
+	resolved, err := fs.resolvePath(pattern)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob: %v", err)
+	}
+
+	var files []string
+	for _, match := range matches {
+		validPath, err := fs.validatePath(match)
+		if err != nil || fs.isDeniedPath(validPath) || validPath == target {
+			continue
+		}
+		if info, err := os.Stat(validPath); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, validPath)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files match")
+	}
+	sort.SliceStable(files, func(i, j int) bool { return naturalLess(files[i], files[j]) })
+	return files, nil
+}
+
+// chunkNumberPattern - Número final del nombre de un fragmento (part7, part007, 12)
+var chunkNumberPattern = regexp.MustCompile(`(\d+)$`)
+
+// checkChunkSequence - Comprueba que los fragmentos, ya ordenados, tengan un
+// sufijo numérico consecutivo sin huecos ni repeticiones, y describe el rango
+func checkChunkSequence(files []string) (string, error) {
+	numbers := make([]int, len(files))
+	for i, file := range files {
+		match := chunkNumberPattern.FindString(filepath.Base(file))
+		n, err := strconv.Atoi(match)
+		if match == "" || err != nil {
+			return "", fmt.Errorf("%s has no numeric suffix to order it by", filepath.Base(file))
+		}
+		numbers[i] = n
+	}
+
+	var missing []string
+	for i := 1; i < len(numbers); i++ {
+		if numbers[i] == numbers[i-1] {
+			return "", fmt.Errorf("%s and %s have the same part number %d", filepath.Base(files[i-1]), filepath.Base(files[i]), numbers[i])
+		}
+		for n := numbers[i-1] + 1; n < numbers[i]; n++ {
+			if len(missing) == maxReportedChunkGaps {
+				missing = append(missing, "...")
+				break
+			}
+			missing = append(missing, strconv.Itoa(n))
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("sequence has gaps, missing part(s) %s", strings.Join(missing, ", "))
+	}
+	return fmt.Sprintf("parts %d-%d", numbers[0], numbers[len(numbers)-1]), nil
+}
+
+// maxReportedChunkGaps - Números de fragmento que faltan citados en el error
+const maxReportedChunkGaps = 20
+
+// naturalLess - Orden natural: las secuencias de dígitos se comparan por su
+// valor, así que part2 va antes que part10
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := leadingDigits(a), leadingDigits(b)
+		if da != "" && db != "" {
+			na, nb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+// leadingDigits - Dígitos ASCII al principio de s
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
 }
 
 // handleWriteFileSafe - Escritura con backup automático
 func (fs *FilesystemHandler) handleWriteFileSafe(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	path, _ := request.Params.Arguments["path"].(string)
-	content, _ := request.Params.Arguments["content"].(string)
-	createBackup, _ := request.Params.Arguments["create_backup"].(bool)
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	content, err := getString(request.Params.Arguments, "content", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	createBackup, err := getBool(request.Params.Arguments, "create_backup", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	expectedSHA256, err := getString(request.Params.Arguments, "expected_sha256", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
 
 	if path == "" || content == "" {
 		return &mcp.CallToolResult{
@@ -328,26 +670,12 @@ func (fs *FilesystemHandler) handleWriteFileSafe(ctx context.Context, request mc
 		}, nil
 	}
 
-	var backupPath string
-	
-	// Crear backup si el archivo existe y se solicita
-	if createBackup {
-		if _, err := os.Stat(validPath); err == nil {
-			backupPath, err = fs.createBackup(validPath)
-			if err != nil {
-				return &mcp.CallToolResult{
-					Content: []mcp.Content{
-						mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error creating backup: %v", err)},
-					},
-					IsError: true,
-				}, nil
-			}
-		}
-	}
+	// Un solo escritor por archivo: el temporal y el backup tienen nombre fijo
+	defer fs.lockPath(validPath)()
 
 	// Crear directorio padre si no existe
 	parentDir := filepath.Dir(validPath)
-	if err := os.MkdirAll(parentDir, 0755); err != nil {
+	if err := fs.mkdirAllChecked(parentDir, 0755); err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error creating directory: %v", err)},
@@ -356,10 +684,12 @@ func (fs *FilesystemHandler) handleWriteFileSafe(ctx context.Context, request mc
 		}, nil
 	}
 
-	// Escribir archivo temporal primero
+	// Escribir archivo temporal primero, calculando el hash al vuelo
 	tempPath := validPath + ".tmp"
-	err = os.WriteFile(tempPath, []byte(content), 0644)
+	defer fs.trackTemp(tempPath)()
+	actualSHA256, err := writeTempFileWithHash(tempPath, content)
 	if err != nil {
+		os.Remove(tempPath)
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error writing temp file: %v", err)},
@@ -367,9 +697,39 @@ func (fs *FilesystemHandler) handleWriteFileSafe(ctx context.Context, request mc
 			IsError: true,
 		}, nil
 	}
+	fs.stats.addWritten(len(content))
+
+	// Verificar el hash antes de tocar el archivo original
+	if expectedSHA256 != "" && !strings.EqualFold(strings.TrimSpace(expectedSHA256), actualSHA256) {
+		os.Remove(tempPath)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: SHA256 mismatch for %s (expected %s, got %s). Original file left untouched.", path, expectedSHA256, actualSHA256)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var backupPath string
+
+	// Crear backup si el archivo existe y se solicita
+	if createBackup {
+		if _, err := os.Stat(validPath); err == nil {
+			backupPath, err = fs.createBackup(validPath)
+			if err != nil {
+				os.Remove(tempPath)
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{
+						mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error creating backup: %v", err)},
+					},
+					IsError: true,
+				}, nil
+			}
+		}
+	}
 
 	// Mover archivo temporal al destino final (operación atómica)
-	err = os.Rename(tempPath, validPath)
+	err = fs.renameChecked(tempPath, validPath)
 	if err != nil {
 		os.Remove(tempPath) // Limpiar archivo temporal
 		return &mcp.CallToolResult{
@@ -386,7 +746,10 @@ func (fs *FilesystemHandler) handleWriteFileSafe(ctx context.Context, request mc
 		size = info.Size()
 	}
 
-	result := fmt.Sprintf("✅ Safe write completed: %s\nSize: %d bytes", path, size)
+	result := fmt.Sprintf("✅ Safe write completed: %s\nSize: %d bytes\nSHA256: %s", path, size, actualSHA256)
+	if expectedSHA256 != "" {
+		result += "\nHash verified: yes"
+	}
 	if backupPath != "" {
 		result += fmt.Sprintf("\nBackup: %s", backupPath)
 	}
@@ -397,3 +760,45 @@ func (fs *FilesystemHandler) handleWriteFileSafe(ctx context.Context, request mc
 		},
 	}, nil
 }
+
+// writeTempFileWithHash - Escribe el contenido en fragmentos de MAX_CHUNK_SIZE,
+// sincroniza a disco y devuelve el SHA256 en hexadecimal
+func writeTempFileWithHash(tempPath, content string) (string, error) {
+	file, err := os.OpenFile(tempPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|openNoFollow, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	hasher := sha256.New()
+	writer := io.MultiWriter(file, hasher)
+
+	// Evitar una copia []byte completa del contenido para archivos grandes
+	for offset := 0; offset < len(content); offset += MAX_CHUNK_SIZE {
+		end := min(offset+MAX_CHUNK_SIZE, len(content))
+		if _, err := io.WriteString(writer, content[offset:end]); err != nil {
+			file.Close()
+			return "", err
+		}
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// writeFileAtomic - Escribe en un temporal junto al destino y lo renombra encima,
+// conservando los permisos del archivo original si existe. El renombrado
+// vuelve a comprobar la ruta, así que un symlink colado tras validarla falla
+func (fs *FilesystemHandler) writeFileAtomic(path, content string) error {
+	perm := os.FileMode(0644)
+	if info, err := os.Lstat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	return fs.replaceFileChecked(path, content, perm)
+}
@@ -0,0 +1,229 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newToolRequest builds a CallToolRequest for the given tool and arguments
+func newToolRequest(name string, args map[string]interface{}) mcp.CallToolRequest {
+	req := mcp.CallToolRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	return req
+}
+
+// newTestHandler creates a handler rooted at a fresh temporary directory
+func newTestHandler(t *testing.T) (*FilesystemHandler, string) {
+	t.Helper()
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+	return handler, dir
+}
+
+func TestSplitFileAbortRemovesChunks(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	source := filepath.Join(dir, "big.bin")
+	require.NoError(t, os.WriteFile(source, make([]byte, 4096), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := handler.handleSplitFile(ctx, newToolRequest("split_file", map[string]interface{}{
+		"path":       source,
+		"chunk_size": float64(1024),
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	chunks, err := findChunkFiles(source)
+	require.NoError(t, err)
+	assert.Empty(t, chunks)
+}
+
+func TestSplitCleanupRemovesOnlyChunks(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	source := filepath.Join(dir, "data.txt")
+	require.NoError(t, os.WriteFile(source, []byte("0123456789"), 0644))
+
+	result, err := handler.handleSplitFile(context.Background(), newToolRequest("split_file", map[string]interface{}{
+		"path":       source,
+		"chunk_size": float64(4),
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	chunks, err := findChunkFiles(source)
+	require.NoError(t, err)
+	assert.Len(t, chunks, 3)
+
+	unrelated := filepath.Join(dir, "data.txt.partial")
+	require.NoError(t, os.WriteFile(unrelated, []byte("keep"), 0644))
+
+	result, err = handler.handleSplitCleanup(context.Background(), newToolRequest("split_cleanup", map[string]interface{}{
+		"path": source,
+	}))
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+
+	chunks, err = findChunkFiles(source)
+	require.NoError(t, err)
+	assert.Empty(t, chunks)
+	assert.FileExists(t, unrelated)
+	assert.FileExists(t, source)
+}
+
+func TestWriteFileSafeHashMismatchKeepsOriginal(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	target := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(target, []byte("original"), 0644))
+
+	result, err := handler.handleWriteFileSafe(context.Background(), newToolRequest("write_file_safe", map[string]interface{}{
+		"path":            target,
+		"content":         "replacement",
+		"expected_sha256": "0000000000000000000000000000000000000000000000000000000000000000",
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(content))
+	assert.NoFileExists(t, target+".tmp")
+}
+
+func TestWriteFileSafeReportsAndVerifiesHash(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	target := filepath.Join(dir, "out.txt")
+	// sha256("hello")
+	const helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	result, err := handler.handleWriteFileSafe(context.Background(), newToolRequest("write_file_safe", map[string]interface{}{
+		"path":            target,
+		"content":         "hello",
+		"expected_sha256": strings.ToUpper(helloSHA256),
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, helloSHA256)
+
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+}
+
+func TestNaturalLess(t *testing.T) {
+	names := []string{"part10", "part2", "part1", "part002x", "part02", "Part3", "part"}
+	sort.Slice(names, func(i, j int) bool { return naturalLess(names[i], names[j]) })
+	assert.Equal(t, []string{"Part3", "part", "part1", "part2", "part02", "part002x", "part10"}, names)
+}
+
+func TestJoinFilesGlob(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	var want strings.Builder
+	for i := 0; i < 12; i++ {
+		part := fmt.Sprintf("[%d]", i)
+		want.WriteString(part)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("backup.tar.part%d", i)), []byte(part), 0644))
+	}
+	target := filepath.Join(dir, "backup.tar")
+
+	text, isError := callText(t, handler.handleJoinFiles, "join_files", map[string]interface{}{
+		"target_path": target, "source_glob": filepath.Join(dir, "backup.tar.part*"),
+	})
+	require.False(t, isError, text)
+	assert.Contains(t, text, "Sources: 12 files")
+	assert.Contains(t, text, "Sequence: parts 0-11 (backup.tar.part0 → backup.tar.part11)")
+	assertFileContent(t, target, want.String())
+
+	// split_file numera con tres dígitos; el glob también los une
+	source := filepath.Join(dir, "data.txt")
+	require.NoError(t, os.WriteFile(source, []byte("0123456789"), 0644))
+	result, err := handler.handleSplitFile(context.Background(), newToolRequest("split_file", map[string]interface{}{
+		"path": source, "chunk_size": float64(3),
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	text, isError = callText(t, handler.handleJoinFiles, "join_files", map[string]interface{}{
+		"target_path": filepath.Join(dir, "joined.txt"), "source_glob": filepath.Join(dir, "data.txt.part*"),
+	})
+	require.False(t, isError, text)
+	assertFileContent(t, filepath.Join(dir, "joined.txt"), "0123456789")
+}
+
+func TestJoinFilesGlobRejects(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{
+		"gap/a.part1": "1", "gap/a.part2": "2", "gap/a.part5": "5",
+		"dup/a.part1": "1", "dup/a.part01": "1",
+		"nonum/a.part1": "1", "nonum/a.partx": "x",
+	})
+	target := filepath.Join(dir, "out.bin")
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want string
+	}{
+		{"gap", map[string]interface{}{"source_glob": filepath.Join(dir, "gap", "a.part*")}, "missing part(s) 3, 4"},
+		{"duplicate number", map[string]interface{}{"source_glob": filepath.Join(dir, "dup", "a.part*")}, "have the same part number 1"},
+		{"no number", map[string]interface{}{"source_glob": filepath.Join(dir, "nonum", "a.part*")}, "a.partx has no numeric suffix"},
+		{"no match", map[string]interface{}{"source_glob": filepath.Join(dir, "none.*")}, "no files match"},
+		{"both", map[string]interface{}{"source_glob": filepath.Join(dir, "gap", "*"), "source_files": []interface{}{filepath.Join(dir, "gap", "a.part1")}}, "not both"},
+		{"neither", map[string]interface{}{}, "source_files or source_glob are required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args["target_path"] = target
+			text, isError := callText(t, handler.handleJoinFiles, "join_files", tt.args)
+			assert.True(t, isError)
+			assert.Contains(t, text, tt.want)
+			assert.NoFileExists(t, target)
+		})
+	}
+}
+
+func TestChunkedOperationsFileResources(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	target := filepath.Join(dir, "out.txt")
+
+	// chunked_write solo adjunta el archivo al terminar
+	write := func(index int) *mcp.CallToolResult {
+		result, err := handler.handleChunkedWrite(context.Background(), newToolRequest("chunked_write", map[string]interface{}{
+			"path": target, "content": "0123456789ab", "chunk_index": float64(index), "total_chunks": float64(2),
+		}))
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result
+	}
+	assert.Empty(t, fileResourceURIs(write(0)))
+	assert.Equal(t, []string{pathToResourceURI(target)}, fileResourceURIs(write(1)))
+
+	result, err := handler.handleSplitFile(context.Background(), newToolRequest("split_file", map[string]interface{}{
+		"path": target, "chunk_size": float64(2),
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	uris := fileResourceURIs(result)
+	require.Len(t, uris, maxFileResources)
+	assert.Equal(t, pathToResourceURI(target+".part000"), uris[0])
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "and 2 more file(s)")
+
+	joined := filepath.Join(dir, "joined.txt")
+	result, err = handler.handleJoinFiles(context.Background(), newToolRequest("join_files", map[string]interface{}{
+		"target_path": joined, "source_glob": target + ".part*",
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	assert.Equal(t, []string{pathToResourceURI(joined)}, fileResourceURIs(result))
+}
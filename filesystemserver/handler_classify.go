@@ -0,0 +1,372 @@
+package filesystemserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// classifySampleSize - Bytes de cabecera que se leen de los archivos con extensión conocida
+	classifySampleSize = 512
+	// maxClassifyMismatches - Discrepancias que se detallan en la respuesta
+	maxClassifyMismatches = 500
+)
+
+// fileFamilies - Familias de classify_files, en el orden en que se muestran
+var fileFamilies = []string{"text", "code", "image", "audio", "video", "archive", "binary"}
+
+// extensionFamilies - Familia esperada por extensión. Las extensiones que no
+// aparecen se consideran ambiguas y se detectan por contenido.
+var extensionFamilies = map[string]string{}
+
+func init() {
+	for family, exts := range map[string][]string{
+		"text": {"txt", "md", "markdown", "rst", "adoc", "csv", "tsv", "json", "jsonl", "yaml", "yml", "toml",
+			"xml", "html", "htm", "css", "scss", "sass", "less", "ini", "cfg", "conf", "log", "properties", "tex"},
+		"code": {"go", "py", "js", "mjs", "cjs", "ts", "jsx", "tsx", "java", "c", "h", "cpp", "hpp", "cc", "cs",
+			"rb", "php", "rs", "swift", "kt", "kts", "scala", "sh", "bash", "zsh", "fish", "ps1", "bat", "cmd",
+			"lua", "pl", "pm", "r", "sql", "vue", "svelte", "dart", "ex", "exs", "erl", "hs", "clj", "groovy"},
+		"image":   {"png", "jpg", "jpeg", "gif", "bmp", "webp", "ico", "tif", "tiff", "svg", "heic", "avif"},
+		"audio":   {"mp3", "wav", "flac", "ogg", "oga", "m4a", "aac", "opus", "wma", "mid", "midi"},
+		"video":   {"mp4", "mkv", "mov", "avi", "webm", "wmv", "flv", "m4v", "mpg", "mpeg"},
+		"archive": {"zip", "tar", "gz", "tgz", "bz2", "xz", "7z", "rar", "zst", "jar", "war", "apk", "whl"},
+		"binary": {"exe", "dll", "so", "dylib", "o", "a", "lib", "class", "wasm", "pyc", "pdf", "doc", "docx",
+			"xls", "xlsx", "ppt", "pptx", "odt", "ods", "odp", "sqlite", "db", "ttf", "otf", "woff", "woff2"},
+	} {
+		for _, ext := range exts {
+			extensionFamilies["."+ext] = family
+		}
+	}
+}
+
+// executableExtensions - Extensiones en las que un ejecutable no es sospechoso
+var executableExtensions = []string{".exe", ".dll", ".so", ".dylib", ".o", ".sys", ".com", ".scr", ".efi", ".node", ".msi"}
+
+// fileSignature - Número mágico que se busca en la cabecera de los archivos
+type fileSignature struct {
+	magic      string
+	mimeType   string
+	family     string
+	executable bool
+}
+
+// fileSignatures - Firmas que delatan un contenido distinto al que sugiere la extensión
+var fileSignatures = []fileSignature{
+	{"\x7fELF", "application/x-elf", "binary", true},
+	{"\xfe\xed\xfa\xce", "application/x-mach-binary", "binary", true},
+	{"\xfe\xed\xfa\xcf", "application/x-mach-binary", "binary", true},
+	{"\xce\xfa\xed\xfe", "application/x-mach-binary", "binary", true},
+	{"\xcf\xfa\xed\xfe", "application/x-mach-binary", "binary", true},
+	{"PK\x03\x04", "application/zip", "archive", false},
+	{"\x1f\x8b", "application/gzip", "archive", false},
+	{"7z\xbc\xaf\x27\x1c", "application/x-7z-compressed", "archive", false},
+	{"Rar!\x1a\x07", "application/vnd.rar", "archive", false},
+	{"\xfd7zXZ\x00", "application/x-xz", "archive", false},
+	{"BZh", "application/x-bzip2", "archive", false},
+	{"\x28\xb5\x2f\xfd", "application/zstd", "archive", false},
+	{"\x89PNG\r\n\x1a\n", "image/png", "image", false},
+	{"\xff\xd8\xff", "image/jpeg", "image", false},
+	{"GIF8", "image/gif", "image", false},
+	{"%PDF-", "application/pdf", "binary", false},
+}
+
+// handleClassifyFiles - Agrupa los archivos por familia MIME y señala extensiones engañosas
+func (fs *FilesystemHandler) handleClassifyFiles(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	mismatchesOnly, err := getBool(request.Params.Arguments, "mismatches_only", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	fullDetection, err := getBool(request.Params.Arguments, "full_detection", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	result, err := fs.classifyFiles(ctx, validPath, fullDetection)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	if mismatchesOnly {
+		result.MismatchesOnly = true
+		result.Buckets = nil
+	}
+
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatFileClassification(result)},
+		},
+	}, pathToResourceURI(validPath), result)
+}
+
+// classifyFiles - Recorre root clasificando cada archivo regular. Con extensión
+// conocida solo se comprueba la firma de la cabecera; el resto (o todos con
+// fullDetection) pasa por detectMimeType.
+func (fs *FilesystemHandler) classifyFiles(ctx context.Context, root string, fullDetection bool) (*FileClassification, error) {
+	result := &FileClassification{Root: root, Mismatches: []FileTypeMismatch{}}
+	buckets := make(map[string]*FileBucket)
+	progress := progressFrom(ctx)
+
+	err := fs.walkContext(ctx, root, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			fs.logWalkError(ctx, currentPath, err)
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if currentPath != root && (containsString(cleanupVCSDirs, info.Name()) || isServerDataDir(info.Name()) || fs.isDeniedPath(currentPath)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() || fs.isDeniedPath(currentPath) {
+			return nil
+		}
+
+		ext := strings.ToLower(filepath.Ext(currentPath))
+		expected, known := extensionFamilies[ext]
+		var detected fileSignature
+		var sniffed bool
+		if known && !fullDetection {
+			sample, err := readFileSample(currentPath, classifySampleSize)
+			if err != nil {
+				fs.logWalkError(ctx, currentPath, err)
+				return nil
+			}
+			fs.stats.addRead(len(sample))
+			progress.read(currentPath, int64(len(sample)))
+			detected, sniffed = matchFileSignature(sample, expected)
+		} else {
+			mimeType := detectMimeType(currentPath)
+			detected = fileSignature{mimeType: mimeType, family: mimeFamily(mimeType), executable: isExecutableMIME(mimeType)}
+			// mimetype no reconoce los scripts con shebang, que son código
+			if detected.family == "text" {
+				if head, err := readFileSample(currentPath, 2); err == nil && string(head) == "#!" {
+					detected.family = "code"
+				}
+			}
+			sniffed = true
+			result.Detected++
+		}
+
+		// Si el contenido encaja con la extensión manda la extensión (un .docx es binary, no archive)
+		family := expected
+		switch {
+		case !known:
+			family = detected.family
+		case sniffed && (!compatibleFamilies(expected, detected.family) || detected.executable && !containsString(executableExtensions, ext)):
+			family = detected.family
+			result.addMismatch(root, currentPath, ext, expected, detected)
+		}
+
+		bucket, ok := buckets[family]
+		if !ok {
+			bucket = &FileBucket{Family: family}
+			buckets[family] = bucket
+		}
+		bucket.Files++
+		bucket.Size += info.Size()
+		result.TotalFiles++
+		result.TotalSize += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, family := range fileFamilies {
+		if bucket, ok := buckets[family]; ok {
+			result.Buckets = append(result.Buckets, *bucket)
+		}
+	}
+	sort.SliceStable(result.Mismatches, func(i, j int) bool {
+		if result.Mismatches[i].Executable != result.Mismatches[j].Executable {
+			return result.Mismatches[i].Executable
+		}
+		return result.Mismatches[i].Path < result.Mismatches[j].Path
+	})
+	return result, nil
+}
+
+// addMismatch - Anota una discrepancia, guardando solo las primeras maxClassifyMismatches
+func (c *FileClassification) addMismatch(root, path, ext, expected string, detected fileSignature) {
+	c.TotalMismatches++
+	if len(c.Mismatches) >= maxClassifyMismatches {
+		return
+	}
+	rel, _ := filepath.Rel(root, path)
+	if rel == "." {
+		rel = filepath.Base(path)
+	}
+	c.Mismatches = append(c.Mismatches, FileTypeMismatch{
+		Path:       filepath.ToSlash(rel),
+		Extension:  ext,
+		Expected:   expected,
+		Detected:   detected.family,
+		MimeType:   detected.mimeType,
+		Executable: detected.executable,
+	})
+}
+
+// readFileSample - Primeros n bytes de path (menos si el archivo es más corto)
+func readFileSample(path string, n int) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sample := make([]byte, n)
+	read, err := io.ReadFull(file, sample)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return sample[:read], nil
+}
+
+// matchFileSignature - Firma que contradice o confirma la cabecera de un archivo.
+// Los ejecutables PE se reconocen por la cabecera "PE" a la que apunta la de MZ;
+// un texto o código con bytes nulos (y sin BOM UTF-16) se considera binario.
+func matchFileSignature(sample []byte, expected string) (fileSignature, bool) {
+	for _, sig := range fileSignatures {
+		if bytes.HasPrefix(sample, []byte(sig.magic)) {
+			return sig, true
+		}
+	}
+	if len(sample) >= 64 && bytes.HasPrefix(sample, []byte("MZ")) {
+		offset := int(binary.LittleEndian.Uint32(sample[60:64]))
+		if offset+4 <= len(sample) && bytes.Equal(sample[offset:offset+4], []byte("PE\x00\x00")) {
+			return fileSignature{mimeType: "application/vnd.microsoft.portable-executable", family: "binary", executable: true}, true
+		}
+	}
+	if (expected == "text" || expected == "code") && bytes.IndexByte(sample, 0) >= 0 &&
+		!bytes.HasPrefix(sample, []byte("\xff\xfe")) && !bytes.HasPrefix(sample, []byte("\xfe\xff")) {
+		return fileSignature{mimeType: "application/octet-stream", family: "binary"}, true
+	}
+	return fileSignature{}, false
+}
+
+// compatibleFamilies - Si un contenido de la familia detected es normal para
+// una extensión de la familia expected (un .docx o un .jar son zip, un .svg es texto)
+func compatibleFamilies(expected, detected string) bool {
+	switch {
+	case expected == detected:
+		return true
+	case expected == "binary" && detected == "archive":
+		return true
+	case expected == "image" && detected == "text":
+		return true
+	case (expected == "text" || expected == "code") && (detected == "text" || detected == "code"):
+		return true
+	}
+	return false
+}
+
+// mimeFamily - Familia de classify_files para un tipo MIME detectado
+func mimeFamily(mimeType string) string {
+	base, _, _ := strings.Cut(mimeType, ";")
+	switch {
+	case strings.HasPrefix(base, "image/"):
+		return "image"
+	case strings.HasPrefix(base, "audio/"):
+		return "audio"
+	case strings.HasPrefix(base, "video/"):
+		return "video"
+	case isArchiveMIME(base):
+		return "archive"
+	case strings.Contains(base, "script") || strings.HasPrefix(base, "text/x-"):
+		return "code"
+	case isTextFile(base):
+		return "text"
+	}
+	return "binary"
+}
+
+// isArchiveMIME - Tipos MIME de formatos comprimidos o empaquetados
+func isArchiveMIME(mimeType string) bool {
+	switch mimeType {
+	case "application/zip", "application/gzip", "application/x-gzip", "application/x-tar",
+		"application/x-7z-compressed", "application/vnd.rar", "application/x-rar-compressed",
+		"application/x-xz", "application/x-bzip2", "application/zstd", "application/java-archive":
+		return true
+	}
+	return false
+}
+
+// isExecutableMIME - Tipos MIME de binarios ejecutables o bibliotecas
+func isExecutableMIME(mimeType string) bool {
+	switch mimeType {
+	case "application/x-executable", "application/x-elf", "application/x-sharedlib", "application/x-object",
+		"application/x-mach-binary", "application/vnd.microsoft.portable-executable":
+		return true
+	}
+	return false
+}
+
+// formatFileClassification - Resumen legible de classify_files
+func formatFileClassification(result *FileClassification) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🗂️ File classification for %s\n", result.Root)
+	fmt.Fprintf(&b, "📊 %d files, %d bytes (%d detected by content)\n", result.TotalFiles, result.TotalSize, result.Detected)
+
+	if !result.MismatchesOnly && len(result.Buckets) > 0 {
+		b.WriteString("\n")
+		for _, bucket := range result.Buckets {
+			fmt.Fprintf(&b, "  %-8s %6d files %12d bytes\n", bucket.Family, bucket.Files, bucket.Size)
+		}
+	}
+
+	if result.TotalMismatches == 0 {
+		b.WriteString("\n✅ No extension/content mismatches found\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "\n⚠️ %d extension/content mismatch(es):\n", result.TotalMismatches)
+	for _, m := range result.Mismatches {
+		marker := "  -"
+		if m.Executable {
+			marker = "  🚨"
+		}
+		fmt.Fprintf(&b, "%s %s: %s extension (%s) but content is %s (%s)\n", marker, m.Path, m.Extension, m.Expected, m.Detected, m.MimeType)
+	}
+	if result.TotalMismatches > len(result.Mismatches) {
+		fmt.Fprintf(&b, "  ... %d more\n", result.TotalMismatches-len(result.Mismatches))
+	}
+	return b.String()
+}
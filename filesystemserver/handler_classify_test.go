@@ -0,0 +1,93 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyFiles(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	elf := "\x7fELF\x02\x01\x01" + strings.Repeat("\x00", 57)
+	files := map[string]string{
+		"main.go":              "package main\n",
+		"notes.txt":            "hello\n",
+		"uploads/invoice.txt":  elf,
+		"uploads/photo.png":    "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 24),
+		"uploads/backup.zip":   "not really a zip\n",
+		"docs/report.docx":     "PK\x03\x04" + strings.Repeat("\x00", 26),
+		"bin/run":              "#!/bin/sh\necho hi\n",
+		".git/objects/pack.go": elf,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	result, err := handler.handleClassifyFiles(context.Background(), newToolRequest("classify_files", map[string]interface{}{"path": dir}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "1 extension/content mismatch(es)")
+	assert.Contains(t, text, "🚨 uploads/invoice.txt: .txt extension (text) but content is binary (application/x-elf)")
+
+	var report FileClassification
+	decodeStructured(t, result, &report)
+	assert.Equal(t, 7, report.TotalFiles)
+	assert.Equal(t, 1, report.Detected)
+	families := map[string]int{}
+	for _, bucket := range report.Buckets {
+		families[bucket.Family] = bucket.Files
+	}
+	// Sin firma reconocible en la cabecera, el .zip cuenta por su extensión
+	assert.Equal(t, map[string]int{"text": 1, "code": 2, "image": 1, "archive": 1, "binary": 2}, families)
+	require.Len(t, report.Mismatches, 1)
+	assert.Equal(t, FileTypeMismatch{Path: "uploads/invoice.txt", Extension: ".txt", Expected: "text", Detected: "binary", MimeType: "application/x-elf", Executable: true}, report.Mismatches[0])
+
+	result, err = handler.handleClassifyFiles(context.Background(), newToolRequest("classify_files", map[string]interface{}{
+		"path":            filepath.Join(dir, "uploads"),
+		"mismatches_only": true,
+		"full_detection":  true,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	var filtered FileClassification
+	decodeStructured(t, result, &filtered)
+	assert.Nil(t, filtered.Buckets)
+	assert.Equal(t, 3, filtered.Detected)
+	require.Len(t, filtered.Mismatches, 2)
+	// Los ejecutables van primero
+	assert.Equal(t, "invoice.txt", filtered.Mismatches[0].Path)
+	assert.Equal(t, "backup.zip", filtered.Mismatches[1].Path)
+	assert.Equal(t, "text", filtered.Mismatches[1].Detected)
+	assert.NotContains(t, result.Content[0].(mcp.TextContent).Text, "files ")
+}
+
+func TestMatchFileSignature(t *testing.T) {
+	pe := make([]byte, 256)
+	copy(pe, "MZ")
+	pe[60] = 0x80
+	copy(pe[0x80:], "PE\x00\x00")
+	sig, ok := matchFileSignature(pe, "text")
+	require.True(t, ok)
+	assert.True(t, sig.executable)
+	assert.Equal(t, "application/vnd.microsoft.portable-executable", sig.mimeType)
+
+	// "MZ" al principio de un texto no basta
+	_, ok = matchFileSignature([]byte("MZ is a band\n"+strings.Repeat(" ", 64)), "text")
+	assert.False(t, ok)
+
+	// UTF-16 con BOM no es binario
+	_, ok = matchFileSignature([]byte("\xff\xfeh\x00i\x00"), "text")
+	assert.False(t, ok)
+	sig, ok = matchFileSignature([]byte("h\x00i\x00"), "code")
+	require.True(t, ok)
+	assert.Equal(t, "binary", sig.family)
+}
@@ -0,0 +1,362 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// cleanupCategories - Categorías de cleanup que se activan por defecto
+var cleanupCategories = []string{"editor_backups", "server_artifacts", "pycache", "ds_store", "empty_dirs"}
+
+// cleanupVCSDirs - Directorios en los que cleanup nunca entra
+var cleanupVCSDirs = []string{".git", ".svn", ".hg"}
+
+// cleanupItem - Archivo o directorio seleccionado para borrar
+type cleanupItem struct {
+	Path     string
+	Rel      string
+	Size     int64
+	IsDir    bool
+	Category string
+}
+
+// handleCleanup - Elimina basura común bajo un directorio; por defecto solo simula
+func (fs *FilesystemHandler) handleCleanup(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	output, err := getString(request.Params.Arguments, "output", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	dryRun, err := getBool(request.Params.Arguments, "dry_run", true)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	requested, err := getStringSlice(request.Params.Arguments, "categories")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	enabled := make(map[string]bool)
+	if len(requested) > 0 {
+		for _, name := range requested {
+			if !containsString(cleanupCategories, name) {
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{
+						mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: unknown category '%s' (use %s)", name, strings.Join(cleanupCategories, ", "))},
+					},
+					IsError: true,
+				}, nil
+			}
+			enabled[name] = true
+		}
+	} else {
+		for _, name := range cleanupCategories {
+			enabled[name] = true
+		}
+	}
+
+	extraPatterns, err := getNonEmptyStrings(request.Params.Arguments, "extra_patterns")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	info, err := os.Stat(validPath)
+	if err != nil || !info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: Path must be a directory"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	result, items, err := fs.cleanupDirectory(ctx, validPath, enabled, extraPatterns, dryRun)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Cleanup error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: string(data)},
+			},
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatCleanupResult(validPath, result, items)},
+		},
+	}, nil
+}
+
+// cleanupDirectory - Selecciona la basura de root y, sin dryRun, la elimina
+func (fs *FilesystemHandler) cleanupDirectory(ctx context.Context, root string, enabled map[string]bool, extraPatterns []string, dryRun bool) (*CleanupResult, []cleanupItem, error) {
+	result := &CleanupResult{
+		FilesRemoved: []string{},
+		DryRun:       dryRun,
+		Categories:   make(map[string]int),
+	}
+
+	var items []cleanupItem
+	selected := make(map[string]bool)
+	var dirs []string
+
+	err := fs.walkContext(ctx, root, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			fs.logWalkError(ctx, currentPath, err)
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if currentPath == root {
+			return nil
+		}
+		if _, err := fs.validatePath(currentPath); err != nil {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if fs.mcpIgnored(ctx, currentPath, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, currentPath)
+		name := info.Name()
+
+		if info.IsDir() {
+			if containsString(cleanupVCSDirs, name) {
+				return filepath.SkipDir
+			}
+			if name == "__pycache__" && enabled["pycache"] {
+				size, _ := directorySize(currentPath)
+				items = append(items, cleanupItem{Path: currentPath, Rel: filepath.ToSlash(rel), Size: size, IsDir: true, Category: "pycache"})
+				selected[currentPath] = true
+				return filepath.SkipDir
+			}
+			dirs = append(dirs, currentPath)
+			return nil
+		}
+
+		// Los enlaces simbólicos y archivos especiales nunca se tocan
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		result.TotalFiles++
+		result.TotalSize += info.Size()
+
+		category := cleanupCategory(currentPath, name, enabled)
+		if category == "" && len(extraPatterns) > 0 && matchesAnyPattern(root, currentPath, extraPatterns) {
+			category = "extra_patterns"
+		}
+		if category != "" {
+			items = append(items, cleanupItem{Path: currentPath, Rel: filepath.ToSlash(rel), Size: info.Size(), Category: category})
+			selected[currentPath] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Directorios que quedarían vacíos, de los más profundos a los más altos
+	if enabled["empty_dirs"] {
+		sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) > len(dirs[j]) })
+		for _, dir := range dirs {
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				continue
+			}
+			empty := true
+			for _, entry := range entries {
+				if !selected[filepath.Join(dir, entry.Name())] {
+					empty = false
+					break
+				}
+			}
+			if empty {
+				rel, _ := filepath.Rel(root, dir)
+				items = append(items, cleanupItem{Path: dir, Rel: filepath.ToSlash(rel), IsDir: true, Category: "empty_dirs"})
+				selected[dir] = true
+			}
+		}
+	}
+
+	var done []cleanupItem
+	for _, item := range items {
+		if !dryRun {
+			if err := fs.removeCleanupItem(item); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", item.Rel, err))
+				continue
+			}
+		}
+		done = append(done, item)
+		result.FilesRemoved = append(result.FilesRemoved, item.Rel)
+		result.SizeFreed += item.Size
+		result.Categories[item.Category]++
+	}
+
+	return result, done, nil
+}
+
+// cleanupCategory - Categoría de un archivo o "" si no es basura
+func cleanupCategory(path, name string, enabled map[string]bool) string {
+	switch {
+	case enabled["editor_backups"] && (strings.HasSuffix(name, "~") || strings.HasSuffix(name, ".swp") || strings.HasSuffix(name, ".swo")):
+		return "editor_backups"
+	case enabled["ds_store"] && name == ".DS_Store":
+		return "ds_store"
+	case enabled["server_artifacts"] && isOrphanedServerArtifact(path):
+		return "server_artifacts"
+	}
+	return ""
+}
+
+// isOrphanedServerArtifact - Temporales .tmp de escrituras interrumpidas, y
+// .backup / .partNNN cuyo original sigue existiendo (nunca la única copia)
+func isOrphanedServerArtifact(path string) bool {
+	var original string
+	switch {
+	case strings.HasSuffix(path, ".tmp"):
+		return true
+	case strings.HasSuffix(path, ".backup"):
+		original = strings.TrimSuffix(path, ".backup")
+	default:
+		ext := filepath.Ext(path)
+		if !chunkSuffixPattern.MatchString(ext) {
+			return false
+		}
+		original = strings.TrimSuffix(path, ext)
+	}
+
+	info, err := os.Stat(original)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// removeCleanupItem - Revalida la ruta justo antes de borrar
+func (fs *FilesystemHandler) removeCleanupItem(item cleanupItem) error {
+	if _, err := fs.validatePath(item.Path); err != nil {
+		return err
+	}
+	info, err := os.Lstat(item.Path)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("became a symlink, skipped")
+	}
+
+	if item.Category == "pycache" {
+		return os.RemoveAll(item.Path)
+	}
+	// Con directorios os.Remove falla si ya no están vacíos
+	return os.Remove(item.Path)
+}
+
+// directorySize - Suma de los tamaños de los archivos de un directorio
+func directorySize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// formatCleanupResult - Resumen legible por categoría con la lista de elementos
+func formatCleanupResult(root string, result *CleanupResult, items []cleanupItem) string {
+	var b strings.Builder
+	if result.DryRun {
+		b.WriteString(fmt.Sprintf("🧹 Cleanup (dry run): %s\n", root))
+	} else {
+		b.WriteString(fmt.Sprintf("🧹 Cleanup: %s\n", root))
+	}
+	b.WriteString(fmt.Sprintf("📊 Scanned %d files (%d bytes)\n", result.TotalFiles, result.TotalSize))
+
+	verb := "Removed"
+	if result.DryRun {
+		verb = "Would remove"
+	}
+	b.WriteString(fmt.Sprintf("🗑️ %s %d items, freeing %d bytes\n", verb, len(result.FilesRemoved), result.SizeFreed))
+
+	if len(items) == 0 && len(result.Errors) == 0 {
+		b.WriteString("✅ Nothing to clean up\n")
+		return b.String()
+	}
+
+	categories := make([]string, 0, len(result.Categories))
+	for category := range result.Categories {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		b.WriteString(fmt.Sprintf("  %s: %d\n", category, result.Categories[category]))
+	}
+
+	b.WriteString("\n")
+	for _, item := range items {
+		suffix := ""
+		if item.IsDir {
+			suffix = "/"
+		}
+		b.WriteString(fmt.Sprintf("  • %s%s (%d bytes) [%s]\n", item.Rel, suffix, item.Size, item.Category))
+	}
+
+	if len(result.Errors) > 0 {
+		b.WriteString(fmt.Sprintf("\n⚠️ %d errors:\n", len(result.Errors)))
+		for _, e := range result.Errors {
+			b.WriteString(fmt.Sprintf("  • %s\n", e))
+		}
+	}
+	if result.DryRun {
+		b.WriteString("\n💡 Run with dry_run=false to delete these items (deletion is permanent)\n")
+	}
+	return b.String()
+}
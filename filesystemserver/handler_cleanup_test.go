@@ -0,0 +1,103 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCleanupTree(t *testing.T, dir string) {
+	t.Helper()
+	files := map[string]string{
+		"main.go":                 "package main\n",
+		"main.go~":                "old",
+		".main.go.swp":            "swap",
+		"config.json":             "{}",
+		"config.json.backup":      "{}",
+		"gone.txt.backup":         "only copy",
+		"data.bin.tmp":            "partial",
+		"video.mp4":               "video",
+		"video.mp4.part001":       "vid",
+		"orphan.iso.part001":      "only copy",
+		"pkg/__pycache__/mod.pyc": "bytecode",
+		"pkg/mod.py":              "x = 1\n",
+		"assets/.DS_Store":        "mac",
+		"logs/app.log":            "log line",
+		".git/objects/abc.tmp":    "git internals",
+		"nested/empty/.DS_Store":  "mac",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "really", "empty"), 0755))
+}
+
+func TestCleanupDryRunAndDelete(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeCleanupTree(t, dir)
+
+	all := map[string]bool{"editor_backups": true, "server_artifacts": true, "pycache": true, "ds_store": true, "empty_dirs": true}
+	result, _, err := handler.cleanupDirectory(context.Background(), dir, all, []string{"*.log"}, true)
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+
+	removed := append([]string(nil), result.FilesRemoved...)
+	sort.Strings(removed)
+	assert.Equal(t, []string{
+		".main.go.swp", "assets", "assets/.DS_Store", "config.json.backup", "data.bin.tmp",
+		"logs", "logs/app.log", "main.go~", "nested", "nested/empty", "nested/empty/.DS_Store",
+		"pkg/__pycache__", "really", "really/empty", "video.mp4.part001",
+	}, removed)
+	assert.Equal(t, map[string]int{
+		"editor_backups": 2, "server_artifacts": 3, "pycache": 1, "ds_store": 2, "extra_patterns": 1, "empty_dirs": 6,
+	}, result.Categories)
+
+	// Simulación: nada se ha borrado
+	_, err = os.Stat(filepath.Join(dir, "main.go~"))
+	require.NoError(t, err)
+
+	result, _, err = handler.cleanupDirectory(context.Background(), dir, all, []string{"*.log"}, false)
+	require.NoError(t, err)
+	assert.Empty(t, result.Errors)
+
+	for _, gone := range []string{"main.go~", "pkg/__pycache__", "nested", "really", "assets", "data.bin.tmp"} {
+		_, err := os.Stat(filepath.Join(dir, gone))
+		assert.True(t, os.IsNotExist(err), gone)
+	}
+	// Nunca se borra la única copia ni el contenido de .git
+	for _, kept := range []string{"main.go", "gone.txt.backup", "orphan.iso.part001", "pkg/mod.py", ".git/objects/abc.tmp", "video.mp4"} {
+		_, err := os.Stat(filepath.Join(dir, kept))
+		assert.NoError(t, err, kept)
+	}
+}
+
+func TestHandleCleanupCategories(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeCleanupTree(t, dir)
+
+	result, err := handler.handleCleanup(context.Background(), newToolRequest("cleanup", map[string]interface{}{
+		"path":       dir,
+		"categories": []interface{}{"ds_store"},
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "Cleanup (dry run)")
+	assert.Contains(t, text, "Would remove 2 items")
+	assert.NotContains(t, text, "main.go~")
+
+	result, err = handler.handleCleanup(context.Background(), newToolRequest("cleanup", map[string]interface{}{
+		"path":       dir,
+		"categories": []interface{}{"everything"},
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
@@ -0,0 +1,276 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// codeStatsSorts - Columnas por las que se puede ordenar code_stats; todas
+// de mayor a menor salvo directory
+var codeStatsSorts = []string{"loc", "files", "code_files", "comment_ratio", "avg_complexity", "max_complexity", "directory"}
+
+// handleCodeStats - Métricas de código por directorio de primer nivel: dónde
+// se concentran las líneas y la complejidad
+func (fs *FilesystemHandler) handleCodeStats(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	sortBy, err := getString(request.Params.Arguments, "sort_by", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+	if sortBy == "" {
+		sortBy = "loc"
+	}
+	if !containsString(codeStatsSorts, sortBy) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: unknown sort_by '%s' (use %s)", sortBy, strings.Join(codeStatsSorts, ", "))},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	if info, err := os.Stat(validPath); err != nil || !info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: Path must be a directory"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// El mismo recorrido que generate_report comparte entre sus secciones
+	walk, err := fs.walkProject(ctx, validPath)
+	if err == nil {
+		var report *CodeStatsReport
+		report, err = fs.codeStats(ctx, walk, sortBy)
+		if err == nil {
+			return withStructuredContent(&mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: formatCodeStats(report)},
+				},
+			}, pathToResourceURI(validPath), report)
+		}
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+		},
+		IsError: true,
+	}, nil
+}
+
+// codeStatsAccumulator - Totales de un directorio mientras se recorren sus archivos
+type codeStatsAccumulator struct {
+	stats        DirectoryCodeStats
+	commentLines int
+	complexity   int
+	languageLOC  map[string]int
+}
+
+// add - Suma un archivo de código de lines líneas
+func (a *codeStatsAccumulator) add(rel, language string, lines, commentLines, complexity int) {
+	a.stats.CodeFiles++
+	a.stats.LOC += lines
+	a.commentLines += commentLines
+	a.complexity += complexity
+	if complexity > a.stats.MaxComplexity {
+		a.stats.MaxComplexity = complexity
+		a.stats.MaxComplexityFile = rel
+	}
+	a.languageLOC[language] += lines
+}
+
+// result - Estadísticas finales: ratios, media y lenguaje dominante por líneas
+func (a *codeStatsAccumulator) result() DirectoryCodeStats {
+	stats := a.stats
+	if stats.LOC > 0 {
+		stats.CommentRatio = math.Round(float64(a.commentLines)/float64(stats.LOC)*1000) / 10
+	}
+	if stats.CodeFiles > 0 {
+		stats.AvgComplexity = math.Round(float64(a.complexity)/float64(stats.CodeFiles)*10) / 10
+	}
+	for language, lines := range a.languageLOC {
+		best := a.languageLOC[stats.Language]
+		if stats.Language == "" || lines > best || (lines == best && language < stats.Language) {
+			stats.Language = language
+		}
+	}
+	return stats
+}
+
+// codeStats - Agrupa los archivos del recorrido por directorio de primer nivel
+// ("." para los de la raíz) y mide los de código: líneas, comentarios y complejidad
+func (fs *FilesystemHandler) codeStats(ctx context.Context, walk *projectWalk, sortBy string) (*CodeStatsReport, error) {
+	newAccumulator := func(directory string) *codeStatsAccumulator {
+		return &codeStatsAccumulator{stats: DirectoryCodeStats{Directory: directory}, languageLOC: make(map[string]int)}
+	}
+	total := newAccumulator("total")
+	byDir := make(map[string]*codeStatsAccumulator)
+
+	for _, file := range walk.Files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		directory := "."
+		if top, _, nested := strings.Cut(file.Rel, "/"); nested {
+			directory = top
+		}
+		acc := byDir[directory]
+		if acc == nil {
+			acc = newAccumulator(directory)
+			byDir[directory] = acc
+		}
+		acc.stats.Files++
+		total.stats.Files++
+
+		language := file.Language.Key
+		if !hasCodeMetrics(language) || file.Size > MAX_INLINE_SIZE {
+			continue
+		}
+		data, err := fs.readFile(file.Path)
+		progressFrom(ctx).read(file.Path, int64(len(data)))
+		if err != nil {
+			continue
+		}
+		content := string(data)
+		lines := strings.Count(content, "\n")
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			lines++
+		}
+		// calculateCommentRatio cuenta sobre todas las líneas del split
+		commentLines := int(math.Round(fs.calculateCommentRatio(content, language) * float64(len(strings.Split(content, "\n"))) / 100))
+		complexity := fs.calculateComplexity(content, language)
+		acc.add(file.Rel, file.Language.Name, lines, commentLines, complexity)
+		total.add(file.Rel, file.Language.Name, lines, commentLines, complexity)
+	}
+
+	report := &CodeStatsReport{Path: walk.Root, SortBy: sortBy, Directories: []DirectoryCodeStats{}, Total: total.result()}
+	for _, acc := range byDir {
+		report.Directories = append(report.Directories, acc.result())
+	}
+	sortCodeStats(report.Directories, sortBy)
+	return report, nil
+}
+
+// sortCodeStats - Ordena por la columna pedida, con el nombre como desempate
+func sortCodeStats(dirs []DirectoryCodeStats, sortBy string) {
+	key := func(s DirectoryCodeStats) float64 {
+		switch sortBy {
+		case "files":
+			return float64(s.Files)
+		case "code_files":
+			return float64(s.CodeFiles)
+		case "comment_ratio":
+			return s.CommentRatio
+		case "avg_complexity":
+			return s.AvgComplexity
+		case "max_complexity":
+			return float64(s.MaxComplexity)
+		case "directory":
+			return 0
+		}
+		return float64(s.LOC)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if a, b := key(dirs[i]), key(dirs[j]); a != b {
+			return a > b
+		}
+		return dirs[i].Directory < dirs[j].Directory
+	})
+}
+
+// codeStatsRow - Celdas de una fila de la tabla de code_stats
+func codeStatsRow(s DirectoryCodeStats) []string {
+	language := s.Language
+	if language == "" {
+		language = "-"
+	}
+	return []string{
+		s.Directory,
+		fmt.Sprint(s.Files),
+		fmt.Sprint(s.CodeFiles),
+		fmt.Sprint(s.LOC),
+		fmt.Sprintf("%.1f%%", s.CommentRatio),
+		fmt.Sprintf("%.1f", s.AvgComplexity),
+		fmt.Sprint(s.MaxComplexity),
+		language,
+	}
+}
+
+// codeStatsHeaders - Cabeceras de la tabla de code_stats, en el orden de codeStatsRow
+var codeStatsHeaders = []string{"Directory", "Files", "Code files", "LOC", "Comments", "Avg complexity", "Max complexity", "Language"}
+
+// formatCodeStats - Tabla alineada de code_stats con la fila de totales al final
+func formatCodeStats(report *CodeStatsReport) string {
+	rows := [][]string{codeStatsHeaders}
+	for _, dir := range report.Directories {
+		rows = append(rows, codeStatsRow(dir))
+	}
+	rows = append(rows, codeStatsRow(report.Total))
+
+	widths := make([]int, len(codeStatsHeaders))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 Code stats for %s (%d directories, sorted by %s)\n\n", report.Path, len(report.Directories), report.SortBy)
+	for r, row := range rows {
+		if r == len(rows)-1 {
+			b.WriteString(strings.Repeat("─", sumInts(widths)+2*(len(widths)-1)) + "\n")
+		}
+		// Texto a la izquierda, números a la derecha
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			pad := strings.Repeat(" ", widths[i]-len([]rune(cell)))
+			if i == 0 || i == len(row)-1 {
+				cells[i] = cell + pad
+			} else {
+				cells[i] = pad + cell
+			}
+		}
+		b.WriteString(strings.TrimRight(strings.Join(cells, "  "), " ") + "\n")
+	}
+	if report.Total.MaxComplexityFile != "" {
+		fmt.Fprintf(&b, "\n🔥 Most complex file: %s (%d)\n", report.Total.MaxComplexityFile, report.Total.MaxComplexity)
+	}
+	return b.String()
+}
+
+// sumInts - Suma de values
+func sumInts(values []int) int {
+	total := 0
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
@@ -0,0 +1,108 @@
+package filesystemserver
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeStats(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{
+		"main.go":           "package main\n\n// main arranca\nfunc main() {\n\tif true {\n\t}\n}\n",
+		"README.md":         "# demo\n",
+		"api/handler.go":    "package api\n\nfunc Handle(a, b bool) {\n\tif a {\n\t}\n\tif b {\n\t}\n\tfor {\n\t}\n}\n",
+		"api/routes.go":     "package api\n\n// Routes\n// lista\nvar Routes = 1\n",
+		"scripts/build.py":  "# build\n# script\nimport os\nif os.name:\n    pass\n",
+		"docs/guide.md":     "guide\n",
+		"node_modules/x.js": "if (a) {}\n",
+	})
+
+	result, err := handler.handleCodeStats(context.Background(), newToolRequest("code_stats", map[string]interface{}{"path": dir}))
+	require.NoError(t, err)
+	var report CodeStatsReport
+	decodeStructured(t, result, &report)
+
+	var names []string
+	for _, stats := range report.Directories {
+		names = append(names, stats.Directory)
+	}
+	assert.Equal(t, []string{"api", ".", "scripts", "docs"}, names)
+
+	api := report.Directories[0]
+	assert.Equal(t, DirectoryCodeStats{
+		Directory:         "api",
+		Files:             2,
+		CodeFiles:         2,
+		LOC:               15,
+		CommentRatio:      13.3,
+		AvgComplexity:     2.5,
+		MaxComplexity:     4,
+		MaxComplexityFile: "api/handler.go",
+		Language:          "Go",
+	}, api)
+	assert.Equal(t, "Python", report.Directories[2].Language)
+	assert.Equal(t, DirectoryCodeStats{Directory: "docs", Files: 1}, report.Directories[3])
+
+	assert.Equal(t, 6, report.Total.Files)
+	assert.Equal(t, 4, report.Total.CodeFiles)
+	assert.Equal(t, 27, report.Total.LOC)
+	assert.Equal(t, "Go", report.Total.Language)
+
+	text := result.Content[0].(mcp.TextContent).Text
+	lines := strings.Split(text, "\n")
+	assert.Equal(t, "Directory  Files  Code files  LOC  Comments  Avg complexity  Max complexity  Language", lines[2])
+	assert.Equal(t, "api            2           2   15     13.3%             2.5               4  Go", lines[3])
+	assert.True(t, strings.HasPrefix(lines[7], "───"))
+	assert.True(t, strings.HasPrefix(lines[8], "total"))
+	assert.Contains(t, text, "🔥 Most complex file: api/handler.go (4)")
+}
+
+func TestCodeStatsSortBy(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{
+		"b/one.go": "package b\n// c\n",
+		"a/one.go": "package a\n",
+		"a/two.go": "package a\n",
+	})
+
+	for sortBy, want := range map[string][]string{
+		"directory":     {"a", "b"},
+		"files":         {"a", "b"},
+		"comment_ratio": {"b", "a"},
+	} {
+		result, err := handler.handleCodeStats(context.Background(), newToolRequest("code_stats", map[string]interface{}{"path": dir, "sort_by": sortBy}))
+		require.NoError(t, err)
+		var report CodeStatsReport
+		decodeStructured(t, result, &report)
+		var names []string
+		for _, stats := range report.Directories {
+			names = append(names, stats.Directory)
+		}
+		assert.Equal(t, want, names, sortBy)
+		assert.Equal(t, sortBy, report.SortBy)
+	}
+
+	text, isError := callText(t, handler.handleCodeStats, "code_stats", map[string]interface{}{"path": dir, "sort_by": "size"})
+	assert.True(t, isError)
+	assert.Contains(t, text, "unknown sort_by 'size'")
+}
+
+func TestGenerateReportCodeStatsSection(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{"pkg/a.go": "package pkg\n"})
+
+	report, err := handler.buildProjectReport(context.Background(), dir, "demo", []string{"overview", "code_stats"})
+	require.NoError(t, err)
+	require.NotNil(t, report.CodeStats)
+	assert.Equal(t, "demo", report.CodeStats.Path)
+	assert.Equal(t, 1, report.CodeStats.Total.LOC)
+	assert.Nil(t, report.Quality)
+
+	assert.Contains(t, renderReportMarkdown(report), "## Code stats\n\n| Directory | Files | Code files | LOC |")
+	assert.Contains(t, formatReportSummary(report, "json"), "📏 1 lines of code in 1 directories")
+}
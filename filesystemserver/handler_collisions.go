@@ -0,0 +1,295 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// windowsReservedNames - Nombres de dispositivo que Windows no permite, con o sin extensión
+var windowsReservedNames = []string{
+	"CON", "PRN", "AUX", "NUL",
+	"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+	"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9",
+}
+
+// windowsInvalidChars - Caracteres que Windows no admite en un nombre
+const windowsInvalidChars = `<>:"/\|?*`
+
+// handleFindNameCollisions - Busca nombres que solo difieren en mayúsculas dentro
+// de un mismo directorio y, opcionalmente, nombres que Windows no admite
+func (fs *FilesystemHandler) handleFindNameCollisions(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	checkWindows, err := getBool(request.Params.Arguments, "check_windows_compat", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	if info, err := os.Stat(validPath); err != nil || !info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: Path must be a directory"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	report, err := fs.findNameCollisions(ctx, validPath, checkWindows)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatNameCollisions(report)},
+		},
+	}, pathToResourceURI(validPath), report)
+}
+
+// findNameCollisions - Recorre root agrupando los nombres de cada directorio por
+// su forma sin mayúsculas; los grupos de más de un nombre son colisiones
+func (fs *FilesystemHandler) findNameCollisions(ctx context.Context, root string, checkWindows bool) (*NameCollisionReport, error) {
+	report := &NameCollisionReport{Root: root, WindowsChecked: checkWindows, Collisions: []NameCollision{}, InvalidNames: []InvalidName{}}
+	children := make(map[string][]string)
+
+	err := fs.walkContext(ctx, root, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			fs.logWalkError(ctx, currentPath, err)
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if currentPath == root {
+			return nil
+		}
+		if fs.isDeniedPath(currentPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		report.Scanned++
+		parent := filepath.Dir(currentPath)
+		children[parent] = append(children[parent], info.Name())
+		if info.IsDir() && (containsString(cleanupVCSDirs, info.Name()) || isServerDataDir(info.Name())) {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := make([]string, 0, len(children))
+	for dir := range children {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		rel, _ := filepath.Rel(root, dir)
+		rel = filepath.ToSlash(rel)
+		names := children[dir]
+		sort.Strings(names)
+
+		taken := make(map[string]bool, len(names))
+		groups := make(map[string][]string)
+		var keys []string
+		for _, name := range names {
+			key := foldName(name)
+			if !taken[key] {
+				keys = append(keys, key)
+			}
+			taken[key] = true
+			groups[key] = append(groups[key], name)
+		}
+
+		for _, key := range keys {
+			group := groups[key]
+			if len(group) < 2 {
+				continue
+			}
+			collision := NameCollision{Directory: rel, Names: group}
+			// Se conserva el primero; los demás reciben un nombre libre
+			for _, name := range group[1:] {
+				collision.Renames = append(collision.Renames, SuggestedRename{From: name, To: uniqueName(name, taken)})
+			}
+			report.Collisions = append(report.Collisions, collision)
+		}
+
+		if checkWindows {
+			for _, name := range names {
+				if problems := windowsNameProblems(name); len(problems) > 0 {
+					report.InvalidNames = append(report.InvalidNames, InvalidName{
+						Directory: rel,
+						Name:      name,
+						Problems:  problems,
+						Suggested: uniqueName(windowsSafeName(name), taken),
+					})
+				}
+			}
+		}
+	}
+	return report, nil
+}
+
+// foldName - Forma canónica de name sin mayúsculas: cada letra se sustituye por
+// la menor de su órbita de unicode.SimpleFold, como compara strings.EqualFold
+func foldName(name string) string {
+	return strings.Map(func(r rune) rune {
+		folded := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			if f < folded {
+				folded = f
+			}
+		}
+		return folded
+	}, name)
+}
+
+// uniqueName - name, o name-2, name-3... antes de la extensión, el primero que
+// no coincide sin mayúsculas con ningún nombre de taken; lo añade a taken
+func uniqueName(name string, taken map[string]bool) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if base == "" {
+		base, ext = name, ""
+	}
+	candidate := name
+	for n := 2; taken[foldName(candidate)]; n++ {
+		candidate = fmt.Sprintf("%s-%d%s", base, n, ext)
+	}
+	taken[foldName(candidate)] = true
+	return candidate
+}
+
+// windowsNameProblems - Motivos por los que Windows no admite name
+func windowsNameProblems(name string) []string {
+	var problems []string
+	stem, _, _ := strings.Cut(name, ".")
+	if containsString(windowsReservedNames, strings.ToUpper(strings.TrimRight(stem, " "))) {
+		problems = append(problems, fmt.Sprintf("reserved device name %s", strings.ToUpper(strings.TrimRight(stem, " "))))
+	}
+	var invalid []string
+	for _, r := range name {
+		if r < 0x20 || strings.ContainsRune(windowsInvalidChars, r) {
+			if char := fmt.Sprintf("%q", r); !containsString(invalid, char) {
+				invalid = append(invalid, char)
+			}
+		}
+	}
+	if len(invalid) > 0 {
+		problems = append(problems, "invalid characters "+strings.Join(invalid, " "))
+	}
+	if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+		problems = append(problems, "ends with a dot or space")
+	}
+	return problems
+}
+
+// windowsSafeName - name con los caracteres no válidos como "_", sin puntos ni
+// espacios finales y con "_" tras un nombre de dispositivo reservado
+func windowsSafeName(name string) string {
+	safe := strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(windowsInvalidChars, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	safe = strings.TrimRight(safe, ". ")
+	if safe == "" {
+		safe = "_"
+	}
+	stem, rest, hasExt := strings.Cut(safe, ".")
+	if containsString(windowsReservedNames, strings.ToUpper(strings.TrimRight(stem, " "))) {
+		safe = stem + "_"
+		if hasExt {
+			safe += "." + rest
+		}
+	}
+	return safe
+}
+
+// formatNameCollisions - Texto de find_name_collisions agrupado por directorio
+func formatNameCollisions(report *NameCollisionReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🔠 Name check for %s: %d entries scanned\n", report.Root, report.Scanned)
+	if len(report.Collisions) == 0 && len(report.InvalidNames) == 0 {
+		if report.WindowsChecked {
+			b.WriteString("✅ No case-insensitive collisions or names invalid on Windows\n")
+		} else {
+			b.WriteString("✅ No case-insensitive collisions\n")
+		}
+		return b.String()
+	}
+	fmt.Fprintf(&b, "⚠️ %d case-insensitive collision(s)", len(report.Collisions))
+	if report.WindowsChecked {
+		fmt.Fprintf(&b, ", %d name(s) invalid on Windows", len(report.InvalidNames))
+	}
+	b.WriteString("\n")
+
+	byDir := make(map[string][]string)
+	var dirs []string
+	add := func(dir, line string) {
+		if _, ok := byDir[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		byDir[dir] = append(byDir[dir], line)
+	}
+	for _, collision := range report.Collisions {
+		quoted := make([]string, len(collision.Names))
+		for i, name := range collision.Names {
+			quoted[i] = safeDisplayName(name)
+		}
+		var renames []string
+		for _, rename := range collision.Renames {
+			renames = append(renames, fmt.Sprintf("%s → %s", safeDisplayName(rename.From), safeDisplayName(rename.To)))
+		}
+		add(collision.Directory, fmt.Sprintf("  🔀 %s (suggested: %s)", strings.Join(quoted, ", "), strings.Join(renames, ", ")))
+	}
+	for _, invalid := range report.InvalidNames {
+		add(invalid.Directory, fmt.Sprintf("  🚫 %s: %s (suggested: %s)", safeDisplayName(invalid.Name), strings.Join(invalid.Problems, "; "), safeDisplayName(invalid.Suggested)))
+	}
+
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		label := dir + "/"
+		if dir == "." {
+			label = "./"
+		}
+		fmt.Fprintf(&b, "\n📁 %s\n%s\n", safeDisplayName(label), strings.Join(byDir[dir], "\n"))
+	}
+	return b.String()
+}
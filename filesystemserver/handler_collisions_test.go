@@ -0,0 +1,94 @@
+package filesystemserver
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindNameCollisions(t *testing.T) {
+	if caseInsensitivePaths {
+		t.Skip("names differing only in case can't coexist on this filesystem")
+	}
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{
+		"README.md":         "a",
+		"Readme.md":         "b",
+		"readme-2.md":       "taken suggestion",
+		"docs/Guide.md":     "c",
+		"Docs/guide.md":     "d",
+		"src/main.go":       "e",
+		"src/Main.go":       "f",
+		"src/MAIN.GO":       "g",
+		"src/util.go":       "h",
+		"secrets/.env":      "i",
+		"secrets/.ENV":      "j",
+		".git/HEAD":         "k",
+		".git/head":         "l",
+		".mcp-reports/a.md": "m",
+		".mcp-reports/A.md": "n",
+	})
+	handler.denyPatterns = append(handler.denyPatterns, "secrets")
+
+	result, err := handler.handleFindNameCollisions(context.Background(), newToolRequest("find_name_collisions", map[string]interface{}{"path": dir}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	var report NameCollisionReport
+	decodeStructured(t, result, &report)
+
+	assert.Equal(t, []NameCollision{
+		{Directory: ".", Names: []string{"Docs", "docs"}, Renames: []SuggestedRename{{From: "docs", To: "docs-2"}}},
+		{Directory: ".", Names: []string{"README.md", "Readme.md"}, Renames: []SuggestedRename{{From: "Readme.md", To: "Readme-3.md"}}},
+		{Directory: "src", Names: []string{"MAIN.GO", "Main.go", "main.go"}, Renames: []SuggestedRename{
+			{From: "Main.go", To: "Main-2.go"}, {From: "main.go", To: "main-3.go"},
+		}},
+	}, report.Collisions)
+	assert.Empty(t, report.InvalidNames)
+	assert.False(t, report.WindowsChecked)
+
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "⚠️ 3 case-insensitive collision(s)\n")
+	assert.Contains(t, text, "📁 ./\n  🔀 Docs, docs (suggested: docs → docs-2)\n")
+	assert.Contains(t, text, "📁 src/\n  🔀 MAIN.GO, Main.go, main.go (suggested: Main.go → Main-2.go, main.go → main-3.go)")
+}
+
+func TestFindNameCollisionsWindowsNames(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("these names can't be created on Windows")
+	}
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{
+		"con.txt":          "a",
+		"con_.txt":         "taken suggestion",
+		"notes.":           "b",
+		"a:b?.md":          "c",
+		"lib/LPT1":         "d",
+		"lib/console.log":  "not reserved",
+		"lib/com10.txt":    "not reserved",
+		"lib/ok name.txt ": "trailing space",
+	})
+
+	result, err := handler.handleFindNameCollisions(context.Background(), newToolRequest("find_name_collisions", map[string]interface{}{
+		"path": dir, "check_windows_compat": true,
+	}))
+	require.NoError(t, err)
+	var report NameCollisionReport
+	decodeStructured(t, result, &report)
+
+	assert.Equal(t, []InvalidName{
+		{Directory: ".", Name: "a:b?.md", Problems: []string{`invalid characters ':' '?'`}, Suggested: "a_b_.md"},
+		{Directory: ".", Name: "con.txt", Problems: []string{"reserved device name CON"}, Suggested: "con_-2.txt"},
+		{Directory: ".", Name: "notes.", Problems: []string{"ends with a dot or space"}, Suggested: "notes"},
+		{Directory: "lib", Name: "LPT1", Problems: []string{"reserved device name LPT1"}, Suggested: "LPT1_"},
+		{Directory: "lib", Name: "ok name.txt ", Problems: []string{"ends with a dot or space"}, Suggested: "ok name.txt"},
+	}, report.InvalidNames)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "🚫 con.txt: reserved device name CON (suggested: con_-2.txt)")
+
+	text, isError := callText(t, handler.handleFindNameCollisions, "find_name_collisions", map[string]interface{}{"path": dir})
+	assert.False(t, isError)
+	assert.Contains(t, text, "✅ No case-insensitive collisions\n")
+}
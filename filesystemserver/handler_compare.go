@@ -3,8 +3,11 @@ package filesystemserver
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -12,14 +15,45 @@ import (
 
 // handleCompareFiles - Comparación avanzada de archivos
 func (fs *FilesystemHandler) handleCompareFiles(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	file1, _ := request.Params.Arguments["file1"].(string)
-	file2, _ := request.Params.Arguments["file2"].(string)
-	format, _ := request.Params.Arguments["format"].(string)
+	file1, err := getString(request.Params.Arguments, "file1", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	file2, err := getString(request.Params.Arguments, "file2", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	file2Content, err := getString(request.Params.Arguments, "file2_content", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	hasInlineContent := request.Params.Arguments["file2_content"] != nil
+	format, err := getString(request.Params.Arguments, "format", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	outputPath, err := getString(request.Params.Arguments, "output_path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	opts, err := parseDiffOptions(request.Params.Arguments)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if file1 == "" || (file2 == "" && !hasInlineContent) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: file1 and either file2 or file2_content are required"},
+			},
+			IsError: true,
+		}, nil
+	}
 
-	if file1 == "" || file2 == "" {
+	if file2 != "" && hasInlineContent {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				mcp.TextContent{Type: "text", Text: "❌ Error: both file1 and file2 are required"},
+				mcp.TextContent{Type: "text", Text: "❌ Error: provide either file2 or file2_content, not both"},
 			},
 			IsError: true,
 		}, nil
@@ -28,6 +62,14 @@ func (fs *FilesystemHandler) handleCompareFiles(ctx context.Context, request mcp
 	if format == "" {
 		format = "unified"
 	}
+	if format != "unified" && format != "context" && format != "side-by-side" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: unsupported format '%s' (use 'unified', 'context' or 'side-by-side')", format)},
+			},
+			IsError: true,
+		}, nil
+	}
 
 	validPath1, err := fs.validatePath(file1)
 	if err != nil {
@@ -39,6 +81,29 @@ func (fs *FilesystemHandler) handleCompareFiles(ctx context.Context, request mcp
 		}, nil
 	}
 
+	// Archivo de parche opcional
+	var patch *patchOutput
+	if outputPath != "" {
+		validOutput, err := fs.validatePath(outputPath)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error with output_path: %v", err)},
+				},
+				IsError: true,
+			}, nil
+		}
+		inline, err := getBool(request.Params.Arguments, "inline", false)
+		if err != nil {
+			return badArgError(err).result(), nil
+		}
+		patch = &patchOutput{Path: validOutput, Inline: inline}
+	}
+
+	if hasInlineContent {
+		return fs.handleCompareWithContent(file1, validPath1, file2Content, format, opts, patch)
+	}
+
 	validPath2, err := fs.validatePath(file2)
 	if err != nil {
 		return &mcp.CallToolResult{
@@ -50,7 +115,8 @@ func (fs *FilesystemHandler) handleCompareFiles(ctx context.Context, request mcp
 	}
 
 	// Verificar que ambos archivos existen
-	if _, err := os.Stat(validPath1); os.IsNotExist(err) {
+	info1, err := os.Stat(validPath1)
+	if os.IsNotExist(err) {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: file1 does not exist: %s", file1)},
@@ -59,7 +125,8 @@ func (fs *FilesystemHandler) handleCompareFiles(ctx context.Context, request mcp
 		}, nil
 	}
 
-	if _, err := os.Stat(validPath2); os.IsNotExist(err) {
+	info2, err := os.Stat(validPath2)
+	if os.IsNotExist(err) {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: file2 does not exist: %s", file2)},
@@ -68,7 +135,41 @@ func (fs *FilesystemHandler) handleCompareFiles(ctx context.Context, request mcp
 		}, nil
 	}
 
-	diff, err := fs.compareFiles(validPath1, validPath2, format)
+	// Modo directorio: ambos argumentos son directorios
+	if info1 != nil && info2 != nil && (info1.IsDir() || info2.IsDir()) {
+		if !info1.IsDir() || !info2.IsDir() {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: "❌ Error: cannot compare a file with a directory"},
+				},
+				IsError: true,
+			}, nil
+		}
+		if patch != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: "❌ Error: output_path is only supported when comparing files"},
+				},
+				IsError: true,
+			}, nil
+		}
+		useHash, err := getBool(request.Params.Arguments, "hash", false)
+		if err != nil {
+			return badArgError(err).result(), nil
+		}
+		detectRenames, err := getBool(request.Params.Arguments, "detect_renames", true)
+		if err != nil {
+			return badArgError(err).result(), nil
+		}
+		dirOpts := dirCompareOptions{UseHash: useHash, DetectRenames: detectRenames}
+		recursiveDiff, err := getBool(request.Params.Arguments, "recursive_diff", false)
+		if err != nil {
+			return badArgError(err).result(), nil
+		}
+		return fs.handleCompareDirectories(ctx, validPath1, validPath2, dirOpts, recursiveDiff, opts)
+	}
+
+	diff, err := fs.compareFiles(validPath1, validPath2, opts)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -78,118 +179,718 @@ func (fs *FilesystemHandler) handleCompareFiles(ctx context.Context, request mcp
 		}, nil
 	}
 
-	// Si los archivos son idénticos
-	if diff.Similar == 100.0 {
+	if patch != nil {
+		if err := patch.write(fs, file1, file2, diff, validPath1, validPath2); err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error writing patch: %v", err)},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	return withStructuredContent(fs.formatFileDiffResult(validPath1, file1, file2, diff, format, opts, patch), pathToResourceURI(validPath1), diff)
+}
+
+// handleCompareWithContent - Compara un archivo con contenido proporcionado en línea
+func (fs *FilesystemHandler) handleCompareWithContent(file1, validPath1, content, format string, opts diffOptions, patch *patchOutput) (*mcp.CallToolResult, error) {
+	if len(content) > MAX_INLINE_SIZE {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: file2_content is too large (%d bytes, max %d)", len(content), MAX_INLINE_SIZE)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	diff, err := fs.diffFileAgainstContent(validPath1, content, opts)
+	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				mcp.TextContent{Type: "text", Text: "✅ Files are identical"},
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Comparison error: %v", err)},
 			},
+			IsError: true,
 		}, nil
 	}
 
+	// El parche se etiqueta con file1 en ambos lados para poder aplicarlo sobre él
+	if patch != nil {
+		if err := patch.write(fs, file1, file1, diff, validPath1); err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error writing patch: %v", err)},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	label2 := fmt.Sprintf("file2_content (inline, %d bytes)", len(content))
+	return fs.formatFileDiffResult(validPath1, file1, label2, diff, format, opts, patch), nil
+}
+
+// patchOutput - Destino del diff unificado cuando se usa output_path
+type patchOutput struct {
+	Path   string
+	Inline bool // incluir también el diff en la respuesta
+	Size   int64
+	Hunks  int
+}
+
+// write - Escribe el diff unificado completo (sin truncar) en Path
+func (p *patchOutput) write(fs *FilesystemHandler, label1, label2 string, diff *FileDiff, sources ...string) error {
+	for _, source := range sources {
+		if p.Path == source {
+			return fmt.Errorf("output_path must not be one of the compared files")
+		}
+	}
+	if len(diff.Hunks) == 0 && diff.Similar < 100.0 {
+		return fmt.Errorf("cannot write a patch: %s", diff.HashOnly)
+	}
+	if info, err := os.Stat(p.Path); err == nil && info.IsDir() {
+		return fmt.Errorf("output_path is a directory: %s", p.Path)
+	}
+	content := ""
+	if len(diff.Hunks) > 0 {
+		content = renderUnifiedDiff(label1, label2, diff.Hunks)
+	}
+	if err := fs.writeFileChecked(p.Path, []byte(content), 0644); err != nil {
+		return err
+	}
+	p.Size = int64(len(content))
+	p.Hunks = len(diff.Hunks)
+	return nil
+}
+
+// summary - Línea de resumen del parche escrito
+func (p *patchOutput) summary() string {
+	return fmt.Sprintf("💾 Patch written: %s (%d bytes, %d hunks)\n🔗 Resource: %s\n",
+		p.Path, p.Size, p.Hunks, pathToResourceURI(p.Path))
+}
+
+// diffFileAgainstContent - Calcula el diff entre un archivo de texto existente y
+// un contenido nuevo, sin escribirlo a disco
+func (fs *FilesystemHandler) diffFileAgainstContent(path, content string, opts diffOptions) (*FileDiff, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size() > MAX_INLINE_SIZE {
+		return nil, fmt.Errorf("file is too large to diff (%d bytes, max %d)", info.Size(), MAX_INLINE_SIZE)
+	}
+	if !isTextFile(detectMimeType(path)) {
+		return nil, fmt.Errorf("file is not a text file: %s", path)
+	}
+
+	lines1, err := readFileLines(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file1: %v", err)
+	}
+
+	diff := diffLines(lines1, splitContentLines(content), opts)
+	diff.File1 = path
+	diff.File2 = "inline content"
+	return diff, nil
+}
+
+// formatFileDiffResult - Genera la respuesta de texto para un FileDiff; el diff
+// completo que no cabe en el presupuesto va a un informe junto a validPath
+func (fs *FilesystemHandler) formatFileDiffResult(validPath, file1, file2 string, diff *FileDiff, format string, opts diffOptions, patch *patchOutput) *mcp.CallToolResult {
+	// Si los archivos son idénticos
+	if diff.Similar == 100.0 && len(diff.Hunks) == 0 && len(diff.Added) == 0 && len(diff.Removed) == 0 {
+		text := "✅ Files are identical"
+		if len(diff.SHA256) > 0 {
+			text += fmt.Sprintf("\n🔐 SHA256: %s", diff.SHA256[0])
+		}
+		if patch != nil {
+			text += "\n" + patch.summary()
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: text},
+			},
+		}
+	}
+
 	var result strings.Builder
-	result.WriteString(fmt.Sprintf("🔍 File Comparison Results:\n\n"))
+	result.WriteString("🔍 File Comparison Results:\n\n")
 	result.WriteString(fmt.Sprintf("📁 File 1: %s\n", file1))
 	result.WriteString(fmt.Sprintf("📁 File 2: %s\n", file2))
-	result.WriteString(fmt.Sprintf("📊 Similarity: %.1f%%\n\n", diff.Similar))
+	result.WriteString(fmt.Sprintf("📊 Similarity: %.1f%%\n", diff.Similar))
+	result.WriteString(fmt.Sprintf("➕ Added: %d | ➖ Removed: %d | 📝 Modified: %d | 📈 Unchanged: %d\n",
+		len(diff.Added), len(diff.Removed), len(diff.Modified), diff.Unchanged))
+
+	if len(diff.Hunks) == 0 {
+		// Comparación por hash: solo hay resumen
+		if diff.HashOnly != "" {
+			result.WriteString(fmt.Sprintf("\nℹ️ Files differ (%s)\n", diff.HashOnly))
+			result.WriteString(fmt.Sprintf("🔐 SHA256: %s | %s\n", diff.SHA256[0], diff.SHA256[1]))
+			result.WriteString(fmt.Sprintf("🔐 MD5: %s | %s\n", diff.MD5[0], diff.MD5[1]))
+		} else {
+			for _, line := range diff.Added {
+				result.WriteString(fmt.Sprintf("\n%s\n", line))
+			}
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: result.String()},
+			},
+		}
+	}
+
+	if patch != nil {
+		result.WriteString(patch.summary())
+		if !patch.Inline {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: result.String()},
+				},
+			}
+		}
+	}
+
+	hunks, truncated := truncateHunks(diff.Hunks, opts.MaxLines)
+	result.WriteString(fmt.Sprintf("🧩 Hunks: %d\n\n", len(diff.Hunks)))
+	out := fs.newResultBuilder()
+	out.WriteString(result.String())
+	out.lines(renderDiff(format, file1, file2, hunks, opts))
+	if truncated {
+		out.tail(fmt.Sprintf("\n⚠️ Diff truncated after %d hunks (%d more omitted, max_lines: %d)\n",
+			len(hunks), len(diff.Hunks)-len(hunks), opts.MaxLines))
+	}
+	text, spill := out.done(validPath, "compare_files")
+
+	return &mcp.CallToolResult{
+		Content: append([]mcp.Content{
+			mcp.TextContent{Type: "text", Text: text},
+		}, spill...),
+	}
+}
+
+// parseDiffOptions - Lee las opciones de diff comunes desde los argumentos
+func parseDiffOptions(args map[string]interface{}) (diffOptions, error) {
+	opts := diffOptions{
+		ContextLines: 3,
+		Width:        60,
+		MaxLines:     2000,
+	}
+	contextLines, err := getInt(args, "context_lines", opts.ContextLines)
+	if err != nil {
+		return opts, err
+	}
+	width, err := getInt(args, "width", opts.Width)
+	if err != nil {
+		return opts, err
+	}
+	maxLines, err := getInt(args, "max_lines", opts.MaxLines)
+	if err != nil {
+		return opts, err
+	}
+	if contextLines >= 0 {
+		opts.ContextLines = contextLines
+	}
+	if width >= 10 {
+		opts.Width = width
+	}
+	if maxLines > 0 {
+		opts.MaxLines = maxLines
+	}
+	if opts.IgnoreWhitespace, err = getBool(args, "ignore_whitespace", false); err != nil {
+		return opts, err
+	}
+	opts.IgnoreCase, err = getBool(args, "ignore_case", false)
+	return opts, err
+}
+
+// truncateHunks - Conserva hunks completos mientras quepan en maxLines
+func truncateHunks(hunks []DiffHunk, maxLines int) ([]DiffHunk, bool) {
+	if maxLines <= 0 {
+		return hunks, false
+	}
+	used := 0
+	for i, hunk := range hunks {
+		used += len(hunk.Lines) + 1
+		if used > maxLines {
+			return hunks[:i], true
+		}
+	}
+	return hunks, false
+}
+
+// renderDiff - Genera la salida del diff en el formato solicitado
+func renderDiff(format, label1, label2 string, hunks []DiffHunk, opts diffOptions) string {
+	switch format {
+	case "context":
+		return renderContextDiff(label1, label2, hunks)
+	case "side-by-side":
+		return renderSideBySideDiff(label1, label2, hunks, opts.Width)
+	default:
+		return renderUnifiedDiff(label1, label2, hunks)
+	}
+}
+
+// handleCompareDirectories - Compara dos árboles de directorios
+func (fs *FilesystemHandler) handleCompareDirectories(ctx context.Context, dirA, dirB string, dirOpts dirCompareOptions, recursiveDiff bool, opts diffOptions) (*mcp.CallToolResult, error) {
+	dirDiff, err := fs.compareDirectories(ctx, dirA, dirB, dirOpts)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Comparison error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	skippedDiffs := 0
+	if recursiveDiff {
+		skippedDiffs = fs.attachDirectoryFileDiffs(dirDiff, opts)
+	}
+
+	result := fs.newResultBuilder()
+	result.WriteString("🔍 Directory Comparison Results:\n\n")
+	result.WriteString(fmt.Sprintf("📁 A: %s\n", dirA))
+	result.WriteString(fmt.Sprintf("📁 B: %s\n", dirB))
+	result.WriteString(fmt.Sprintf("✅ Identical: %d | ⬅️ Only in A: %d | ➡️ Only in B: %d | 🔀 Renamed: %d | 📝 Different: %d\n",
+		dirDiff.Identical, len(dirDiff.OnlyInA), len(dirDiff.OnlyInB), len(dirDiff.Renamed), len(dirDiff.Different)))
+	if !dirOpts.UseHash {
+		result.WriteString("ℹ️ Differences decided by size and modification time (use hash: true for content certainty)\n")
+	}
+	summary := result.text.String()
+	result.WriteString("\n")
 
-	if len(diff.Added) > 0 {
-		result.WriteString(fmt.Sprintf("➕ Added lines (%d):\n", len(diff.Added)))
-		for _, line := range diff.Added {
-			result.WriteString(fmt.Sprintf("  + %s\n", line))
+	if len(dirDiff.OnlyInA) > 0 {
+		result.WriteString(fmt.Sprintf("⬅️ Only in A (%d):\n", len(dirDiff.OnlyInA)))
+		for _, path := range dirDiff.OnlyInA {
+			result.item(fmt.Sprintf("  %s\n", path))
 		}
 		result.WriteString("\n")
 	}
 
-	if len(diff.Removed) > 0 {
-		result.WriteString(fmt.Sprintf("➖ Removed lines (%d):\n", len(diff.Removed)))
-		for _, line := range diff.Removed {
-			result.WriteString(fmt.Sprintf("  - %s\n", line))
+	if len(dirDiff.OnlyInB) > 0 {
+		result.WriteString(fmt.Sprintf("➡️ Only in B (%d):\n", len(dirDiff.OnlyInB)))
+		for _, path := range dirDiff.OnlyInB {
+			result.item(fmt.Sprintf("  %s\n", path))
 		}
 		result.WriteString("\n")
 	}
 
-	if len(diff.Modified) > 0 {
-		result.WriteString(fmt.Sprintf("📝 Modified lines (%d):\n", len(diff.Modified)))
-		for _, line := range diff.Modified {
-			result.WriteString(fmt.Sprintf("  ~ %s\n", line))
+	if len(dirDiff.Renamed) > 0 {
+		result.WriteString(fmt.Sprintf("🔀 Renamed (%d):\n", len(dirDiff.Renamed)))
+		for _, rename := range dirDiff.Renamed {
+			result.item(fmt.Sprintf("  %s → %s\n", rename.From, rename.To))
 		}
 		result.WriteString("\n")
 	}
 
-	result.WriteString(fmt.Sprintf("📈 Unchanged lines: %d\n", diff.Unchanged))
+	if len(dirDiff.Different) > 0 {
+		result.WriteString(fmt.Sprintf("📝 Different (%d):\n", len(dirDiff.Different)))
+		for _, entry := range dirDiff.Different {
+			result.item(fmt.Sprintf("  %s (%s: %d → %d bytes)\n", entry.Path, entry.Reason, entry.SizeA, entry.SizeB))
+		}
+		result.WriteString("\n")
+	}
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{Type: "text", Text: result.String()},
+	for _, entry := range dirDiff.Different {
+		if fileDiff, ok := dirDiff.Diffs[entry.Path]; ok {
+			result.item(fileDiff + "\n")
+		}
+	}
+	if skippedDiffs > 0 {
+		result.tail(fmt.Sprintf("⚠️ %d file diff(s) omitted (binary, too large or over the diff budget)\n", skippedDiffs))
+	}
+
+	jsonData, err := json.MarshalIndent(dirDiff, "", "  ")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error generating JSON: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	// Demasiado grande: solo los totales; las listas y los diffs quedan en el informe
+	if spilled := fs.oversizedResult(dirA, "compare_files", result.size()+len(jsonData), jsonData, "json", func() string { return summary }); spilled != nil {
+		return spilled, nil
+	}
+	text, spill := result.done(dirA, "compare_files")
+
+	content := append([]mcp.Content{mcp.TextContent{Type: "text", Text: text}}, spill...)
+	content = append(content, mcp.EmbeddedResource{
+		Type: "resource",
+		Resource: mcp.TextResourceContents{
+			URI:      pathToResourceURI(dirA),
+			MIMEType: "application/json",
+			Text:     string(jsonData),
 		},
-	}, nil
+	})
+	return &mcp.CallToolResult{Content: content}, nil
 }
 
-// compareFiles - Realiza la comparación entre dos archivos
-func (fs *FilesystemHandler) compareFiles(path1, path2, format string) (*FileDiff, error) {
-	// Verificar si son archivos de texto
-	mimeType1 := detectMimeType(path1)
-	mimeType2 := detectMimeType(path2)
+// dirCompareOptions - Opciones de compareDirectories
+type dirCompareOptions struct {
+	UseHash       bool     // decidir por contenido en lugar de tamaño y fecha
+	DetectRenames bool     // emparejar archivos que solo están en un lado por tamaño y hash
+	Exclude       []string // patrones glob que se omiten en ambos árboles
+}
 
-	if !isTextFile(mimeType1) || !isTextFile(mimeType2) {
-		return fs.compareBinaryFiles(path1, path2)
+// compareDirectories - Recorre ambos árboles y clasifica las diferencias
+func (fs *FilesystemHandler) compareDirectories(ctx context.Context, dirA, dirB string, dirOpts dirCompareOptions) (*DirectoryDiff, error) {
+	entriesA, err := fs.collectTreeEntries(ctx, dirA, dirOpts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	entriesB, err := fs.collectTreeEntries(ctx, dirB, dirOpts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	useHash := dirOpts.UseHash
+	dirDiff := &DirectoryDiff{
+		DirA:      dirA,
+		DirB:      dirB,
+		OnlyInA:   []string{},
+		OnlyInB:   []string{},
+		Renamed:   []DirectoryRename{},
+		Different: []DirectoryDiffEntry{},
+		HashUsed:  useHash,
+	}
+
+	// Los directorios que contienen un extremo de un renombrado no se
+	// informan enteros: se listan sus demás entradas una a una
+	renamedA, renamedB := map[string]bool{}, map[string]bool{}
+	if dirOpts.DetectRenames {
+		renames, err := detectRenames(ctx, dirA, dirB, entriesA, entriesB)
+		if err != nil {
+			return nil, err
+		}
+		for _, rename := range renames {
+			markRenamed(renamedA, rename.From)
+			markRenamed(renamedB, rename.To)
+		}
+		dirDiff.Renamed = append(dirDiff.Renamed, renames...)
 	}
 
-	return fs.compareTextFiles(path1, path2, format)
+	for _, rel := range sortedKeys(entriesA) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		infoA := entriesA[rel]
+		infoB, inB := entriesB[rel]
+		if !inB {
+			// Solo informar la raíz de un subárbol ausente
+			if !renamedA[rel] && !hasAncestorIn(rel, entriesA, entriesB, renamedA) {
+				dirDiff.OnlyInA = append(dirDiff.OnlyInA, displayRelPath(rel, infoA))
+			}
+			continue
+		}
+		if infoA.IsDir() != infoB.IsDir() {
+			dirDiff.Different = append(dirDiff.Different, DirectoryDiffEntry{Path: rel, Reason: "type", SizeA: infoA.Size(), SizeB: infoB.Size()})
+			continue
+		}
+		if infoA.IsDir() {
+			continue
+		}
+
+		reason := ""
+		switch {
+		case infoA.Size() != infoB.Size():
+			reason = "size"
+		case useHash:
+			hashA, errA := calculateFileSHA256(filepath.Join(dirA, rel))
+			hashB, errB := calculateFileSHA256(filepath.Join(dirB, rel))
+			if errA != nil || errB != nil || hashA != hashB {
+				reason = "content"
+			}
+		case !infoA.ModTime().Equal(infoB.ModTime()):
+			reason = "mtime"
+		}
+
+		if reason == "" {
+			dirDiff.Identical++
+		} else {
+			dirDiff.Different = append(dirDiff.Different, DirectoryDiffEntry{Path: rel, Reason: reason, SizeA: infoA.Size(), SizeB: infoB.Size()})
+		}
+	}
+
+	for _, rel := range sortedKeys(entriesB) {
+		if _, inA := entriesA[rel]; !inA && !renamedB[rel] && !hasAncestorIn(rel, entriesB, entriesA, renamedB) {
+			dirDiff.OnlyInB = append(dirDiff.OnlyInB, displayRelPath(rel, entriesB[rel]))
+		}
+	}
+
+	return dirDiff, nil
 }
 
-// compareTextFiles - Compara archivos de texto línea por línea
-func (fs *FilesystemHandler) compareTextFiles(path1, path2, format string) (*FileDiff, error) {
-	lines1, err := readFileLines(path1)
+// attachDirectoryFileDiffs - Añade diffs unificados de archivos de texto pequeños
+// que difieren, hasta agotar el presupuesto. Devuelve cuántos se omitieron.
+func (fs *FilesystemHandler) attachDirectoryFileDiffs(dirDiff *DirectoryDiff, opts diffOptions) int {
+	const maxFileSize = 256 * 1024
+	const diffBudget = 64 * 1024
+
+	dirDiff.Diffs = make(map[string]string)
+	used, skipped := 0, 0
+
+	for _, entry := range dirDiff.Different {
+		if entry.Reason == "type" {
+			continue
+		}
+		pathA := filepath.Join(dirDiff.DirA, entry.Path)
+		pathB := filepath.Join(dirDiff.DirB, entry.Path)
+		if entry.SizeA > maxFileSize || entry.SizeB > maxFileSize ||
+			!isTextFile(detectMimeType(pathA)) || !isTextFile(detectMimeType(pathB)) {
+			skipped++
+			continue
+		}
+
+		fileDiff, err := fs.compareTextFiles(pathA, pathB, opts)
+		if err != nil || len(fileDiff.Hunks) == 0 {
+			continue
+		}
+
+		rendered := renderUnifiedDiff("a/"+filepath.ToSlash(entry.Path), "b/"+filepath.ToSlash(entry.Path), fileDiff.Hunks)
+		if used+len(rendered) > diffBudget {
+			skipped++
+			continue
+		}
+		used += len(rendered)
+		dirDiff.Diffs[entry.Path] = rendered
+	}
+	return skipped
+}
+
+// detectRenames - Empareja los archivos que solo están en A con los que solo
+// están en B: primero por tamaño y, solo entre los del mismo tamaño, por SHA-256.
+// Entre varios candidatos idénticos prefiere el del mismo nombre.
+func detectRenames(ctx context.Context, dirA, dirB string, entriesA, entriesB map[string]os.FileInfo) ([]DirectoryRename, error) {
+	// Los archivos vacíos son todos iguales: emparejarlos no diría nada
+	bySize := make(map[int64][]string)
+	for _, rel := range sortedKeys(entriesA) {
+		info := entriesA[rel]
+		if _, inB := entriesB[rel]; !inB && info.Mode().IsRegular() && info.Size() > 0 {
+			bySize[info.Size()] = append(bySize[info.Size()], rel)
+		}
+	}
+
+	hashes := make(map[string]string)
+	hashOf := func(path string) string {
+		if sum, ok := hashes[path]; ok {
+			return sum
+		}
+		sum, err := calculateFileSHA256(path)
+		if err != nil {
+			sum = ""
+		}
+		hashes[path] = sum
+		return sum
+	}
+
+	renames := []DirectoryRename{}
+	matched := make(map[string]bool)
+	for _, rel := range sortedKeys(entriesB) {
+		info := entriesB[rel]
+		if _, inA := entriesA[rel]; inA || !info.Mode().IsRegular() {
+			continue
+		}
+		candidates := bySize[info.Size()]
+		if len(candidates) == 0 {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		sumB := hashOf(filepath.Join(dirB, rel))
+		if sumB == "" {
+			continue
+		}
+		from := ""
+		for _, candidate := range candidates {
+			if matched[candidate] || hashOf(filepath.Join(dirA, candidate)) != sumB {
+				continue
+			}
+			if from == "" || (filepath.Base(candidate) == filepath.Base(rel) && filepath.Base(from) != filepath.Base(rel)) {
+				from = candidate
+			}
+		}
+		if from != "" {
+			matched[from] = true
+			renames = append(renames, DirectoryRename{From: from, To: rel, Size: info.Size()})
+		}
+	}
+	return renames, nil
+}
+
+// markRenamed - Marca un extremo de un renombrado y sus directorios padre
+func markRenamed(renamed map[string]bool, rel string) {
+	renamed[rel] = true
+	for parent := filepath.Dir(rel); parent != "." && parent != string(filepath.Separator); parent = filepath.Dir(parent) {
+		renamed[parent] = true
+	}
+}
+
+// collectTreeEntries - Recorre un árbol y devuelve sus entradas por ruta relativa,
+// sin las que coinciden con exclude. Un árbol que no existe está vacío.
+func (fs *FilesystemHandler) collectTreeEntries(ctx context.Context, root string, exclude []string) (map[string]os.FileInfo, error) {
+	entries := make(map[string]os.FileInfo)
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return entries, nil
+	}
+
+	err := fs.walkContext(ctx, root, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			fs.logWalkError(ctx, currentPath, err)
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if currentPath == root {
+			return nil
+		}
+
+		if _, err := fs.validatePath(currentPath); err != nil {
+			return nil
+		}
+
+		if fs.shouldIgnoreEntry(ctx, currentPath, info.IsDir()) || matchesAnyPattern(root, currentPath, exclude) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, currentPath)
+		if err != nil {
+			return nil
+		}
+		entries[rel] = info
+		return nil
+	})
+
+	return entries, err
+}
+
+// hasAncestorIn - Indica si algún directorio padre de rel existe solo en own y
+// se informa entero, es decir, no contiene un extremo de un renombrado
+func hasAncestorIn(rel string, own, other map[string]os.FileInfo, renamed map[string]bool) bool {
+	for parent := filepath.Dir(rel); parent != "." && parent != string(filepath.Separator); parent = filepath.Dir(parent) {
+		if _, inOwn := own[parent]; inOwn && !renamed[parent] {
+			if _, inOther := other[parent]; !inOther {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// displayRelPath - Añade un separador final a los directorios
+func displayRelPath(rel string, info os.FileInfo) string {
+	if info.IsDir() {
+		return rel + string(filepath.Separator)
+	}
+	return rel
+}
+
+// sortedKeys - Devuelve las claves de un mapa de entradas ordenadas
+func sortedKeys(entries map[string]os.FileInfo) []string {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// compareFiles - Realiza la comparación entre dos archivos
+func (fs *FilesystemHandler) compareFiles(path1, path2 string, opts diffOptions) (*FileDiff, error) {
+	info1, err := os.Stat(path1)
 	if err != nil {
 		return nil, fmt.Errorf("error reading file1: %v", err)
 	}
-
-	lines2, err := readFileLines(path2)
+	info2, err := os.Stat(path2)
 	if err != nil {
 		return nil, fmt.Errorf("error reading file2: %v", err)
 	}
 
-	diff := &FileDiff{
-		File1: path1,
-		File2: path2,
+	// Mismo tamaño: si el hash coincide no hace falta diff de líneas
+	var hashed *FileDiff
+	if info1.Size() == info2.Size() {
+		hashed, err = fs.compareFileHashes(path1, path2)
+		if err != nil || hashed.Similar == 100.0 {
+			return hashed, err
+		}
 	}
 
-	// Crear mapas para comparación rápida
-	lines1Map := make(map[string]bool)
-	lines2Map := make(map[string]bool)
+	// Binarios o archivos demasiado grandes para cargarlos en memoria: solo hash
+	reason := ""
+	if !isTextFile(detectMimeType(path1)) || !isTextFile(detectMimeType(path2)) {
+		reason = "binary files are compared by hash only"
+	} else if info1.Size() > MAX_INLINE_SIZE || info2.Size() > MAX_INLINE_SIZE {
+		reason = fmt.Sprintf("files larger than %d bytes are compared by hash only", MAX_INLINE_SIZE)
+	}
 
-	for _, line := range lines1 {
-		lines1Map[line] = true
+	if reason != "" {
+		if hashed == nil {
+			if hashed, err = fs.compareFileHashes(path1, path2); err != nil {
+				return nil, err
+			}
+		}
+		if hashed.Similar < 100.0 {
+			hashed.HashOnly = reason
+		}
+		return hashed, nil
 	}
 
-	for _, line := range lines2 {
-		lines2Map[line] = true
+	return fs.compareTextFiles(path1, path2, opts)
+}
+
+// compareTextFiles - Compara archivos de texto con un diff ordenado (Myers)
+func (fs *FilesystemHandler) compareTextFiles(path1, path2 string, opts diffOptions) (*FileDiff, error) {
+	lines1, err := readFileLines(path1)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file1: %v", err)
 	}
 
-	// Encontrar líneas agregadas (en file2 pero no en file1)
-	for _, line := range lines2 {
-		if !lines1Map[line] {
-			diff.Added = append(diff.Added, line)
-		}
+	lines2, err := readFileLines(path2)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file2: %v", err)
 	}
 
-	// Encontrar líneas eliminadas (en file1 pero no en file2)
-	for _, line := range lines1 {
-		if !lines2Map[line] {
-			diff.Removed = append(diff.Removed, line)
-		}
+	diff := diffLines(lines1, lines2, opts)
+	diff.File1 = path1
+	diff.File2 = path2
+	return diff, nil
+}
+
+// diffLines - Calcula el diff entre dos listas de líneas y sus métricas resumen
+func diffLines(lines1, lines2 []string, opts diffOptions) *FileDiff {
+	diff := &FileDiff{}
+
+	// Normalizar solo para comparar; la salida muestra las líneas originales
+	keys1, keys2 := lines1, lines2
+	if opts.IgnoreWhitespace || opts.IgnoreCase {
+		keys1 = normalizeDiffLines(lines1, opts)
+		keys2 = normalizeDiffLines(lines2, opts)
 	}
+	ops := computeLineDiff(keys1, keys2)
 
-	// Contar líneas sin cambios
-	for _, line := range lines1 {
-		if lines2Map[line] {
+	for _, op := range ops {
+		switch op.Kind {
+		case diffEqual:
 			diff.Unchanged++
+		case diffDelete:
+			diff.Removed = append(diff.Removed, lines1[op.OldIndex])
+		case diffInsert:
+			diff.Added = append(diff.Added, lines2[op.NewIndex])
 		}
 	}
 
-	// Calcular similitud
+	// Similitud basada en la longitud real de la LCS
 	totalLines := len(lines1) + len(lines2)
 	if totalLines > 0 {
 		diff.Similar = float64(diff.Unchanged*2) / float64(totalLines) * 100
@@ -197,42 +898,46 @@ func (fs *FilesystemHandler) compareTextFiles(path1, path2, format string) (*Fil
 		diff.Similar = 100.0
 	}
 
+	diff.Hunks = buildDiffHunks(ops, lines1, lines2, opts.ContextLines)
+
 	// Para líneas modificadas, intentar encontrar líneas similares
-	diff.Modified = fs.findModifiedLines(diff.Removed, diff.Added)
+	diff.Modified = findModifiedLinesInHunks(diff.Hunks)
 
-	return diff, nil
+	return diff
 }
 
-// compareBinaryFiles - Compara archivos binarios por hash
-func (fs *FilesystemHandler) compareBinaryFiles(path1, path2 string) (*FileDiff, error) {
-	hash1, err := calculateFileMD5(path1)
+// compareFileHashes - Compara dos archivos por SHA256 (y MD5 por compatibilidad)
+func (fs *FilesystemHandler) compareFileHashes(path1, path2 string) (*FileDiff, error) {
+	shaA, md5A, err := calculateFileHashes(path1)
 	if err != nil {
 		return nil, fmt.Errorf("error calculating hash for file1: %v", err)
 	}
 
-	hash2, err := calculateFileMD5(path2)
+	shaB, md5B, err := calculateFileHashes(path2)
 	if err != nil {
 		return nil, fmt.Errorf("error calculating hash for file2: %v", err)
 	}
 
 	diff := &FileDiff{
-		File1: path1,
-		File2: path2,
+		File1:  path1,
+		File2:  path2,
+		SHA256: []string{shaA, shaB},
+		MD5:    []string{md5A, md5B},
 	}
 
-	if hash1 == hash2 {
+	if shaA == shaB {
 		diff.Similar = 100.0
 		diff.Unchanged = 1
 	} else {
 		diff.Similar = 0.0
-		diff.Added = []string{"Binary files differ"}
+		diff.Added = []string{"Files differ"}
 	}
 
 	return diff, nil
 }
 
 // findModifiedLines - Intenta encontrar líneas que fueron modificadas
-func (fs *FilesystemHandler) findModifiedLines(removed, added []string) []string {
+func findModifiedLines(removed, added []string) []string {
 	var modified []string
 	const similarityThreshold = 0.6
 
@@ -262,6 +967,45 @@ func (fs *FilesystemHandler) findModifiedLines(removed, added []string) []string
 	return modified
 }
 
+// findModifiedLinesInHunks - Empareja líneas eliminadas y agregadas dentro de
+// cada bloque de cambios contiguo, en lugar de entre todo el archivo
+func findModifiedLinesInHunks(hunks []DiffHunk) []string {
+	var modified []string
+	for _, hunk := range hunks {
+		var removed, added []string
+		flush := func() {
+			if len(removed) > 0 && len(added) > 0 {
+				modified = append(modified, findModifiedLines(removed, added)...)
+			}
+			removed, added = nil, nil
+		}
+		for _, line := range hunk.Lines {
+			switch line.Type {
+			case "delete":
+				removed = append(removed, line.Text)
+			case "insert":
+				added = append(added, line.Text)
+			default:
+				flush()
+			}
+		}
+		flush()
+	}
+	return modified
+}
+
+// splitContentLines - Divide contenido en líneas igual que readFileLines
+func splitContentLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
 // readFileLines - Lee un archivo y devuelve sus líneas
 func readFileLines(path string) ([]string, error) {
 	file, err := os.Open(path)
@@ -272,8 +1016,9 @@ func readFileLines(path string) ([]string, error) {
 
 	var lines []string
 	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), MAX_INLINE_SIZE)
 	for scanner.Scan() {
-		lines = append(lines, strings.TrimSpace(scanner.Text()))
+		lines = append(lines, strings.TrimSuffix(scanner.Text(), "\r"))
 	}
 
 	return lines, scanner.Err()
@@ -0,0 +1,192 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareDirectories(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	dirA := filepath.Join(dir, "a")
+	dirB := filepath.Join(dir, "b")
+
+	files := map[string]string{
+		"a/same.txt":        "same\n",
+		"b/same.txt":        "same\n",
+		"a/changed.txt":     "one\ntwo\n",
+		"b/changed.txt":     "one\nTWO\n",
+		"a/only_a.txt":      "a\n",
+		"a/gone/nested.txt": "x\n",
+		"b/only_b.txt":      "b\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	dirDiff, err := handler.compareDirectories(context.Background(), dirA, dirB, dirCompareOptions{UseHash: true, DetectRenames: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"gone" + string(filepath.Separator), "only_a.txt"}, dirDiff.OnlyInA)
+	assert.Equal(t, []string{"only_b.txt"}, dirDiff.OnlyInB)
+	assert.Equal(t, 1, dirDiff.Identical)
+	if assert.Len(t, dirDiff.Different, 1) {
+		assert.Equal(t, "changed.txt", dirDiff.Different[0].Path)
+		assert.Equal(t, "content", dirDiff.Different[0].Reason)
+	}
+
+	skipped := handler.attachDirectoryFileDiffs(dirDiff, diffOptions{ContextLines: 3})
+	assert.Equal(t, 0, skipped)
+	assert.Contains(t, dirDiff.Diffs["changed.txt"], "-two\n+TWO\n")
+}
+
+func TestCompareDirectoriesDetectsRenames(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	dirA := filepath.Join(dir, "a")
+	dirB := filepath.Join(dir, "b")
+	writeFixture(t, dir, map[string]string{
+		"a/docs/notes.txt": "moved content",
+		"a/docs/keep.txt":  "stays\n",
+		"b/archive/n.txt":  "moved content",
+		"b/fresh.txt":      "fresh content",
+		"b/docs/keep.txt":  "stays\n",
+	})
+
+	dirDiff, err := handler.compareDirectories(context.Background(), dirA, dirB, dirCompareOptions{DetectRenames: true})
+	require.NoError(t, err)
+	assert.Equal(t, []DirectoryRename{{From: filepath.Join("docs", "notes.txt"), To: filepath.Join("archive", "n.txt"), Size: 13}}, dirDiff.Renamed)
+	assert.Empty(t, dirDiff.OnlyInA)
+	assert.Equal(t, []string{"fresh.txt"}, dirDiff.OnlyInB, "same size, different content is not a rename")
+
+	dirDiff, err = handler.compareDirectories(context.Background(), dirA, dirB, dirCompareOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, dirDiff.Renamed)
+	assert.Equal(t, []string{filepath.Join("docs", "notes.txt")}, dirDiff.OnlyInA)
+	assert.Equal(t, []string{"archive" + string(filepath.Separator), "fresh.txt"}, dirDiff.OnlyInB)
+
+	result, err := handler.handleCompareFiles(context.Background(), newToolRequest("compare_files", map[string]interface{}{
+		"file1": dirA, "file2": dirB,
+	}))
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "🔀 Renamed: 1")
+	assert.Contains(t, text, "  "+filepath.Join("docs", "notes.txt")+" → "+filepath.Join("archive", "n.txt")+"\n")
+
+	result, err = handler.handleCompareFiles(context.Background(), newToolRequest("compare_files", map[string]interface{}{
+		"file1": dirA, "file2": dirB, "detect_renames": false,
+	}))
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "🔀 Renamed: 0")
+}
+
+func TestCompareFileWithInlineContent(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "config.txt")
+	require.NoError(t, os.WriteFile(path, []byte("alpha\nbeta\r\ngamma\n"), 0644))
+
+	result, err := handler.handleCompareFiles(context.Background(), newToolRequest("compare_files", map[string]interface{}{
+		"file1":         path,
+		"file2_content": "alpha\nBETA\ngamma\n",
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "file2_content (inline, 17 bytes)")
+	assert.Contains(t, text, "-beta\n+BETA\n")
+
+	result, err = handler.handleCompareFiles(context.Background(), newToolRequest("compare_files", map[string]interface{}{
+		"file1":         path,
+		"file2_content": "alpha\nbeta\ngamma",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "✅ Files are identical", result.Content[0].(mcp.TextContent).Text)
+
+	result, err = handler.handleCompareFiles(context.Background(), newToolRequest("compare_files", map[string]interface{}{
+		"file1":         path,
+		"file2":         path,
+		"file2_content": "x",
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestCompareFilesWritesPatch(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path1 := filepath.Join(dir, "old.txt")
+	path2 := filepath.Join(dir, "new.txt")
+	patchPath := filepath.Join(dir, "change.diff")
+	require.NoError(t, os.WriteFile(path1, []byte("one\ntwo\nthree\n"), 0644))
+	require.NoError(t, os.WriteFile(path2, []byte("one\n2\nthree\n"), 0644))
+
+	result, err := handler.handleCompareFiles(context.Background(), newToolRequest("compare_files", map[string]interface{}{
+		"file1":       path1,
+		"file2":       path2,
+		"output_path": patchPath,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	patch, err := os.ReadFile(patchPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(patch), "-two\n+2\n")
+
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, fmt.Sprintf("(%d bytes, 1 hunks)", len(patch)))
+	assert.Contains(t, text, pathToResourceURI(patchPath))
+	assert.NotContains(t, text, "-two")
+
+	result, err = handler.handleCompareFiles(context.Background(), newToolRequest("compare_files", map[string]interface{}{
+		"file1":       path1,
+		"file2":       path2,
+		"output_path": path1,
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	content, err := os.ReadFile(path1)
+	require.NoError(t, err)
+	assert.Equal(t, "one\ntwo\nthree\n", string(content))
+}
+
+func TestCompareFilesByHash(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	bin1 := filepath.Join(dir, "a.bin")
+	bin2 := filepath.Join(dir, "b.bin")
+	bin3 := filepath.Join(dir, "c.bin")
+	require.NoError(t, os.WriteFile(bin1, []byte{0x00, 0x01, 0x02, 0xff}, 0644))
+	require.NoError(t, os.WriteFile(bin2, []byte{0x00, 0x01, 0x02, 0xff}, 0644))
+	require.NoError(t, os.WriteFile(bin3, []byte{0x00, 0x01, 0x02, 0xfe, 0x00}, 0644))
+
+	diff, err := handler.compareFiles(bin1, bin2, diffOptions{ContextLines: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, diff.Similar)
+	assert.Equal(t, diff.SHA256[0], diff.SHA256[1])
+
+	diff, err = handler.compareFiles(bin1, bin3, diffOptions{ContextLines: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, diff.Similar)
+	assert.NotEmpty(t, diff.HashOnly)
+	assert.Len(t, diff.SHA256[0], 64)
+	assert.Len(t, diff.MD5[0], 32)
+	assert.Empty(t, diff.Hunks)
+
+	big1 := filepath.Join(dir, "big1.txt")
+	big2 := filepath.Join(dir, "big2.txt")
+	line := strings.Repeat("x", 1023) + "\n"
+	content := strings.Repeat(line, MAX_INLINE_SIZE/len(line)+1)
+	require.NoError(t, os.WriteFile(big1, []byte(content), 0644))
+	require.NoError(t, os.WriteFile(big2, []byte(content+"tail\n"), 0644))
+
+	diff, err = handler.compareFiles(big1, big2, diffOptions{ContextLines: 3})
+	require.NoError(t, err)
+	assert.Contains(t, diff.HashOnly, "compared by hash only")
+	assert.Empty(t, diff.Hunks)
+}
@@ -0,0 +1,432 @@
+package filesystemserver
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultMaxDecompressedSize - Tamaño máximo que decompress_file escribe por defecto (1GB)
+const defaultMaxDecompressedSize = 1 << 30
+
+// compressionMagic - Firmas de los formatos comprimidos que se reconocen
+var compressionMagic = []struct {
+	format string
+	magic  []byte
+}{
+	{"gzip", []byte{0x1f, 0x8b}},
+	{"zstd", []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{"bzip2", []byte("BZh")},
+	{"xz", []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+}
+
+// errDecompressLimit - La salida descomprimida supera el límite configurado
+var errDecompressLimit = errors.New("decompressed size exceeds the limit")
+
+// WithMaxDecompressedSize caps how many bytes decompress_file writes, so a
+// small archive cannot fill the disk (a decompression bomb). 0 or less keeps
+// the 1GB default.
+func WithMaxDecompressedSize(limit int64) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		fs.maxDecompressed = limit
+		return nil
+	}
+}
+
+// maxDecompressedSize - Límite efectivo de decompress_file
+func (fs *FilesystemHandler) maxDecompressedSize() int64 {
+	if fs.maxDecompressed > 0 {
+		return fs.maxDecompressed
+	}
+	return defaultMaxDecompressedSize
+}
+
+// handleCompressFile - Comprime un archivo con gzip, en streaming
+func (fs *FilesystemHandler) handleCompressFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	outputPath, err := getString(request.Params.Arguments, "output_path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	format, err := getString(request.Params.Arguments, "format", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	overwrite, err := getBool(request.Params.Arguments, "overwrite", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	keepOriginal, err := getBool(request.Params.Arguments, "keep_original", true)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	level, err := getInt(request.Params.Arguments, "level", gzip.DefaultCompression)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+	switch format {
+	case "", "gzip":
+	default:
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: unknown format '%s' (use gzip)", format)},
+			},
+			IsError: true,
+		}, nil
+	}
+	if level != gzip.DefaultCompression && (level < gzip.BestSpeed || level > gzip.BestCompression) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: level must be between 1 and 9"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, validOutput, errResult := fs.validateCompressPaths(path, outputPath, path+".gz", overwrite)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	before, after, err := fs.streamToFile(validPath, validOutput, func(dst io.Writer, src io.Reader) (int64, error) {
+		gz, err := gzip.NewWriterLevel(dst, level)
+		if err != nil {
+			return 0, err
+		}
+		gz.Name = strings.TrimSuffix(filepath.Base(validOutput), ".gz")
+		if info, err := os.Stat(validPath); err == nil {
+			gz.ModTime = info.ModTime()
+		}
+		n, err := io.Copy(gz, src)
+		if closeErr := gz.Close(); err == nil {
+			err = closeErr
+		}
+		return n, err
+	})
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Compression failed: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	removed, err := fs.removeOriginal(validPath, keepOriginal)
+	text := fmt.Sprintf("🗜️ Compressed (gzip): %s → %s\n📊 %d bytes → %d bytes (%s)\n", validPath, validOutput, before, after, compressionRatio(before, after))
+	text += originalNote(removed, err)
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: text},
+		},
+	}, nil
+}
+
+// handleDecompressFile - Descomprime un archivo gzip con límite de tamaño de salida
+func (fs *FilesystemHandler) handleDecompressFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	outputPath, err := getString(request.Params.Arguments, "output_path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	overwrite, err := getBool(request.Params.Arguments, "overwrite", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	keepOriginal, err := getBool(request.Params.Arguments, "keep_original", true)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	maxOutput, err := getNumber(request.Params.Arguments, "max_output_size", 0)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	limit := fs.maxDecompressedSize()
+	if maxOutput > 0 && int64(maxOutput) < limit {
+		limit = int64(maxOutput)
+	}
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Sin extensión conocida hace falta output_path; el formato se detecta igualmente por la firma
+	defaultOutput := ""
+	for _, ext := range []string{".gz", ".gzip"} {
+		if strings.HasSuffix(strings.ToLower(path), ext) {
+			defaultOutput = path[:len(path)-len(ext)]
+		}
+	}
+	if outputPath == "" && defaultOutput == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: output_path is required when path has no .gz extension"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, validOutput, errResult := fs.validateCompressPaths(path, outputPath, defaultOutput, overwrite)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	format, err := detectCompressionFile(validPath)
+	if err == nil && format != "gzip" {
+		if format == "" {
+			err = fmt.Errorf("%s is not compressed (no known signature)", path)
+		} else {
+			err = fmt.Errorf("%s is %s, which this server cannot decompress (only gzip)", path, format)
+		}
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	before, after, err := fs.streamToFile(validPath, validOutput, func(dst io.Writer, src io.Reader) (int64, error) {
+		gz, err := gzip.NewReader(src)
+		if err != nil {
+			return 0, err
+		}
+		defer gz.Close()
+		n, err := io.Copy(dst, io.LimitReader(gz, limit+1))
+		if err == nil && n > limit {
+			err = fmt.Errorf("%w of %d bytes", errDecompressLimit, limit)
+		}
+		return n, err
+	})
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Decompression failed: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	removed, err := fs.removeOriginal(validPath, keepOriginal)
+	text := fmt.Sprintf("📂 Decompressed (gzip): %s → %s\n📊 %d bytes → %d bytes (%s)\n", validPath, validOutput, before, after, compressionRatio(after, before))
+	text += originalNote(removed, err)
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: text},
+		},
+	}, nil
+}
+
+// validateCompressPaths - Valida origen y destino; devuelve el resultado de error listo para el cliente
+func (fs *FilesystemHandler) validateCompressPaths(path, outputPath, defaultOutput string, overwrite bool) (string, string, *mcp.CallToolResult) {
+	errorResult := func(text string) *mcp.CallToolResult {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: text},
+			},
+			IsError: true,
+		}
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return "", "", errorResult(fmt.Sprintf("❌ Error: %v", err))
+	}
+	if info, err := os.Stat(validPath); err != nil || !info.Mode().IsRegular() {
+		return "", "", errorResult("❌ Error: path must be a regular file")
+	}
+
+	if outputPath == "" {
+		outputPath = defaultOutput
+	}
+	validOutput, err := fs.validateNewPath(outputPath)
+	if err != nil {
+		return "", "", errorResult(fmt.Sprintf("❌ Error: Invalid output: %v", err))
+	}
+	if validOutput == validPath {
+		return "", "", errorResult("❌ Error: output_path must differ from path")
+	}
+	if _, err := os.Lstat(validOutput); err == nil && !overwrite {
+		return "", "", errorResult(fmt.Sprintf("❌ Error: %s already exists (set overwrite=true to replace it)", validOutput))
+	}
+	return validPath, validOutput, nil
+}
+
+// streamToFile - Pasa src por transform hacia un temporal junto a dst y lo renombra
+// al terminar; devuelve los tamaños de origen y destino
+func (fs *FilesystemHandler) streamToFile(src, dst string, transform func(io.Writer, io.Reader) (int64, error)) (int64, int64, error) {
+	in, err := os.OpenFile(src, os.O_RDONLY|openNoFollow, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := fs.mkdirAllChecked(filepath.Dir(dst), 0755); err != nil {
+		return 0, 0, err
+	}
+	tempPath := dst + ".tmp"
+	defer fs.trackTemp(tempPath)()
+	out, err := fs.openFileChecked(tempPath, os.O_WRONLY|os.O_CREATE, info.Mode().Perm())
+	if err == nil {
+		if err = out.Truncate(0); err != nil {
+			out.Close()
+		}
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	counter := &countingWriter{w: out}
+	_, err = transform(counter, bufio.NewReader(in))
+	if syncErr := out.Sync(); err == nil {
+		err = syncErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = fs.renameChecked(tempPath, dst)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return 0, 0, err
+	}
+	fs.stats.addWritten(int(counter.n))
+	return info.Size(), counter.n, nil
+}
+
+// removeOriginal - Borra el origen si no se pidió conservarlo
+func (fs *FilesystemHandler) removeOriginal(validPath string, keep bool) (bool, error) {
+	if keep {
+		return false, nil
+	}
+	if err := fs.recheckPath(validPath); err != nil {
+		return false, err
+	}
+	if err := os.Remove(validPath); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// originalNote - Línea final sobre el archivo original
+func originalNote(removed bool, err error) string {
+	switch {
+	case err != nil:
+		return fmt.Sprintf("⚠️ Original kept: %v\n", err)
+	case removed:
+		return "🗑️ Original removed\n"
+	}
+	return "📄 Original kept\n"
+}
+
+// compressionRatio - Tamaño comprimido como porcentaje del original
+func compressionRatio(original, compressed int64) string {
+	if original == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.1f%% of original", float64(compressed)/float64(original)*100)
+}
+
+// detectCompression - Formato comprimido según la firma inicial, o "" si no se reconoce
+func detectCompression(header []byte) string {
+	for _, m := range compressionMagic {
+		if bytes.HasPrefix(header, m.magic) {
+			return m.format
+		}
+	}
+	return ""
+}
+
+// detectCompressionFile - detectCompression sobre los primeros bytes de un archivo
+func detectCompressionFile(path string) (string, error) {
+	file, err := os.OpenFile(path, os.O_RDONLY|openNoFollow, 0)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	header := make([]byte, 8)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	return detectCompression(header[:n]), nil
+}
+
+// openDecompressed - Abre path para lectura en streaming, descomprimiendo gzip
+// si la firma lo indica; devuelve también el formato detectado ("" si es texto plano)
+func openDecompressed(path string) (io.ReadCloser, string, error) {
+	file, err := os.OpenFile(path, os.O_RDONLY|openNoFollow, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	reader := bufio.NewReader(file)
+	header, _ := reader.Peek(8)
+
+	format := detectCompression(header)
+	switch format {
+	case "":
+		return readCloser{Reader: reader, close: file.Close}, "", nil
+	case "gzip":
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			file.Close()
+			return nil, "", err
+		}
+		return readCloser{Reader: gz, close: func() error {
+			gz.Close()
+			return file.Close()
+		}}, format, nil
+	}
+	file.Close()
+	return nil, "", fmt.Errorf("%s is %s, which this server cannot decompress (only gzip)", path, format)
+}
+
+// readCloser - io.Reader con una función de cierre propia
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (r readCloser) Close() error {
+	return r.close()
+}
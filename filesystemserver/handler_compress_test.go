@@ -0,0 +1,131 @@
+package filesystemserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressDecompressRoundTrip(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	source := filepath.Join(dir, "app.log")
+	content := strings.Repeat("2024-03-01 INFO request served\n", 500)
+	require.NoError(t, os.WriteFile(source, []byte(content), 0640))
+
+	result, err := handler.handleCompressFile(context.Background(), newToolRequest("compress_file", map[string]interface{}{
+		"path":          source,
+		"keep_original": false,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "Compressed (gzip)")
+	assert.Contains(t, text, "of original")
+	assert.Contains(t, text, "Original removed")
+
+	_, err = os.Stat(source)
+	assert.True(t, os.IsNotExist(err))
+	info, err := os.Stat(source + ".gz")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	// Sin extensión: la firma decide y output_path es obligatorio
+	require.NoError(t, os.Rename(source+".gz", filepath.Join(dir, "dump")))
+	result, err = handler.handleDecompressFile(context.Background(), newToolRequest("decompress_file", map[string]interface{}{
+		"path": filepath.Join(dir, "dump"),
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	result, err = handler.handleDecompressFile(context.Background(), newToolRequest("decompress_file", map[string]interface{}{
+		"path":        filepath.Join(dir, "dump"),
+		"output_path": source,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Original kept")
+
+	data, err := os.ReadFile(source)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+
+	// El destino existente solo se sustituye con overwrite
+	result, err = handler.handleCompressFile(context.Background(), newToolRequest("compress_file", map[string]interface{}{
+		"path":        source,
+		"output_path": filepath.Join(dir, "dump"),
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "overwrite=true")
+
+	// Solo se comprime con gzip; zstd solo se reconoce al descomprimir
+	result, err = handler.handleCompressFile(context.Background(), newToolRequest("compress_file", map[string]interface{}{
+		"path":   source,
+		"format": "zstd",
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "unknown format 'zstd' (use gzip)")
+}
+
+func TestDecompressCapsOutputSize(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	require.NoError(t, WithMaxDecompressedSize(1024)(handler))
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write(make([]byte, 64*1024))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	bomb := filepath.Join(dir, "bomb.gz")
+	require.NoError(t, os.WriteFile(bomb, buf.Bytes(), 0644))
+
+	result, err := handler.handleDecompressFile(context.Background(), newToolRequest("decompress_file", map[string]interface{}{
+		"path": bomb,
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "exceeds the limit of 1024 bytes")
+
+	_, err = os.Stat(filepath.Join(dir, "bomb"))
+	assert.True(t, os.IsNotExist(err))
+	assert.Empty(t, tempFilesIn(t, dir))
+}
+
+func TestDetectCompressionAndOpenDecompressed(t *testing.T) {
+	assert.Equal(t, "gzip", detectCompression([]byte{0x1f, 0x8b, 0x08}))
+	assert.Equal(t, "zstd", detectCompression([]byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}))
+	assert.Equal(t, "", detectCompression([]byte("plain text")))
+
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("hello\n"))
+	gz.Close()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a"), buf.Bytes(), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b"), []byte("hello\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c"), []byte{0x28, 0xb5, 0x2f, 0xfd, 1, 2}, 0644))
+
+	for name, wantFormat := range map[string]string{"a": "gzip", "b": ""} {
+		reader, format, err := openDecompressed(filepath.Join(dir, name))
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		require.NoError(t, err)
+		assert.Equal(t, wantFormat, format)
+		assert.Equal(t, "hello\n", string(data))
+	}
+
+	_, _, err := openDecompressed(filepath.Join(dir, "c"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "zstd")
+}
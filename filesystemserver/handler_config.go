@@ -0,0 +1,36 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"gopkg.in/yaml.v3"
+)
+
+// handleGetConfig - Muestra la configuración efectiva (archivo, entorno y
+// opciones combinados) con los valores por defecto y sin las rutas ajenas
+func (fs *FilesystemHandler) handleGetConfig(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	config := fs.effectiveConfig()
+	redacted := fs.redactConfig(config)
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	text := "⚙️ Effective configuration:\n\n" + string(data)
+	if redacted > 0 {
+		text += fmt.Sprintf("\n🔒 %d path(s) outside the allowed directories redacted\n", redacted)
+	}
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: text},
+		},
+	}, "config://effective", config)
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
-	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,38 +12,214 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
+// HandlerOption configures optional FilesystemHandler behaviour
+type HandlerOption func(*FilesystemHandler) error
+
+// WithRuntimeDirectories enables add_allowed_directory / remove_allowed_directory
+// for any existing directory
+func WithRuntimeDirectories(enabled bool) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		fs.allowRuntimeDirs = enabled
+		return nil
+	}
+}
+
+// WithGrantableRoots enables add_allowed_directory for directories under roots
+func WithGrantableRoots(roots ...string) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		for _, root := range roots {
+			normalized, err := normalizeAllowedDir(root)
+			if err != nil {
+				return err
+			}
+			fs.grantableRoots = append(fs.grantableRoots, normalized)
+		}
+		return nil
+	}
+}
+
+// WithWorkspace sets the directory relative paths are resolved against. It
+// must be inside an allowed directory; by default the first allowed directory
+// is used.
+func WithWorkspace(dir string) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		normalized, err := normalizeAllowedDir(dir)
+		if err != nil {
+			return err
+		}
+		fs.workspace = strings.TrimSuffix(normalized, string(filepath.Separator))
+		return nil
+	}
+}
+
 // NewFilesystemHandler creates a new filesystem handler
-func NewFilesystemHandler(allowedDirs []string) (*FilesystemHandler, error) {
+func NewFilesystemHandler(allowedDirs []string, opts ...HandlerOption) (*FilesystemHandler, error) {
 	normalized := make([]string, 0, len(allowedDirs))
 	for _, dir := range allowedDirs {
-		abs, err := filepath.Abs(dir)
+		dir, err := normalizeAllowedDir(dir)
 		if err != nil {
-			return nil, fmt.Errorf("failed to resolve path %s: %w", dir, err)
+			return nil, err
 		}
+		normalized = append(normalized, dir)
+	}
 
-		info, err := os.Stat(abs)
-		if err != nil {
-			return nil, fmt.Errorf("failed to access directory %s: %w", abs, err)
+	fs := &FilesystemHandler{
+		allowedDirs: normalized,
+		runtimeDirs: make(map[string]bool),
+		logger:      newStderrLogger(slog.LevelInfo),
+		stats:       newServerStats(),
+		heavySlots:  make(chan struct{}, defaultHeavyLimit),
+		heavyQueue:  true,
+	}
+	for _, opt := range opts {
+		if err := opt(fs); err != nil {
+			return nil, err
 		}
-		if !info.IsDir() {
-			return nil, fmt.Errorf("path is not a directory: %s", abs)
+	}
+	if !fs.noDefaultDeny {
+		fs.denyPatterns = append(append([]string(nil), defaultDenyPatterns...), fs.denyPatterns...)
+	}
+	if err := fs.assignRootLabels(); err != nil {
+		return nil, err
+	}
+	if fs.workspace != "" && !fs.isPathInAllowedDirs(fs.workspace) {
+		return nil, fmt.Errorf("workspace %s is outside allowed directories", fs.workspace)
+	}
+	if fs.startupHealthCheck {
+		fs.logHealthProblems()
+	}
+	return fs, nil
+}
+
+// normalizeAllowedDir resolves dir to an absolute directory path ending in a separator
+func normalizeAllowedDir(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path %s: %w", dir, err)
+	}
+
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", fmt.Errorf("failed to access directory %s: %w", abs, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("path is not a directory: %s", abs)
+	}
+
+	return filepath.Clean(abs) + string(filepath.Separator), nil
+}
+
+// allowedDirectories returns the current allowed directories. The slice is
+// replaced on every change, so callers may keep iterating it safely.
+func (fs *FilesystemHandler) allowedDirectories() []string {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.allowedDirs
+}
+
+// runtimeDirsEnabled reports whether directories can be granted at runtime
+func (fs *FilesystemHandler) runtimeDirsEnabled() bool {
+	return fs.allowRuntimeDirs || len(fs.grantableRoots) > 0
+}
+
+// addAllowedDir grants access to dir if runtime changes are enabled and dir is
+// under a grantable root (when roots are configured)
+func (fs *FilesystemHandler) addAllowedDir(dir string) (string, error) {
+	if !fs.runtimeDirsEnabled() {
+		return "", fmt.Errorf("adding allowed directories at runtime is disabled")
+	}
+	dir, err := fs.resolvePath(dir)
+	if err != nil {
+		return "", err
+	}
+	normalized, err := normalizeAllowedDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if len(fs.grantableRoots) > 0 {
+		grantable := false
+		for _, root := range fs.grantableRoots {
+			if pathWithinDir(normalized, root) {
+				grantable = true
+				break
+			}
 		}
+		if !grantable {
+			return "", fmt.Errorf("%s is not under a grantable root", strings.TrimSuffix(normalized, string(filepath.Separator)))
+		}
+	}
 
-		normalized = append(normalized, filepath.Clean(abs)+string(filepath.Separator))
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, allowed := range fs.allowedDirs {
+		if pathsEqual(comparablePath(allowed), comparablePath(normalized)) {
+			return allowed, nil
+		}
 	}
-	return &FilesystemHandler{
-		allowedDirs: normalized,
-	}, nil
+	dirs := make([]string, len(fs.allowedDirs), len(fs.allowedDirs)+1)
+	copy(dirs, fs.allowedDirs)
+	fs.allowedDirs = append(dirs, normalized)
+	if fs.runtimeDirs == nil {
+		fs.runtimeDirs = make(map[string]bool)
+	}
+	fs.runtimeDirs[normalized] = true
+	if fs.rootLabels == nil {
+		fs.rootLabels = make(map[string]string)
+	}
+	fs.rootLabels[normalized] = fs.nextRootLabel()
+	return normalized, nil
+}
+
+// removeAllowedDir revokes a directory previously granted with addAllowedDir.
+// Operations that already validated their paths are not interrupted.
+func (fs *FilesystemHandler) removeAllowedDir(dir string) (string, error) {
+	if !fs.runtimeDirsEnabled() {
+		return "", fmt.Errorf("removing allowed directories at runtime is disabled")
+	}
+	abs, err := fs.resolvePath(dir)
+	if err != nil {
+		return "", err
+	}
+	normalized := filepath.Clean(abs) + string(filepath.Separator)
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if !fs.runtimeDirs[normalized] {
+		return "", fmt.Errorf("%s was not added at runtime and cannot be removed", strings.TrimSuffix(normalized, string(filepath.Separator)))
+	}
+	dirs := make([]string, 0, len(fs.allowedDirs))
+	for _, allowed := range fs.allowedDirs {
+		if allowed != normalized {
+			dirs = append(dirs, allowed)
+		}
+	}
+	fs.allowedDirs = dirs
+	delete(fs.runtimeDirs, normalized)
+	delete(fs.rootLabels, normalized)
+	return normalized, nil
 }
 
-// validatePath checks if a path is within allowed directories
+// validatePath checks if a path is within allowed directories, logging rejections
 func (fs *FilesystemHandler) validatePath(requestedPath string) (string, error) {
-	abs, err := filepath.Abs(requestedPath)
+	validPath, err := fs.checkPath(requestedPath)
+	if err != nil {
+		fs.log().Warn("path rejected", "path", requestedPath, "error", err)
+	}
+	return validPath, err
+}
+
+// checkPath resolves requestedPath and verifies it is within allowed directories
+func (fs *FilesystemHandler) checkPath(requestedPath string) (string, error) {
+	abs, err := fs.resolvePath(requestedPath)
 	if err != nil {
-		return "", fmt.Errorf("invalid path: %w", err)
+		return "", err
 	}
 
 	if !fs.isPathInAllowedDirs(abs) {
+		if !filepath.IsAbs(requestedPath) {
+			return "", fmt.Errorf("access denied - path outside allowed directories: %s (%s)", abs, fs.resolutionOrder())
+		}
 		return "", fmt.Errorf("access denied - path outside allowed directories: %s", abs)
 	}
 
@@ -55,22 +231,78 @@ func (fs *FilesystemHandler) validatePath(requestedPath string) (string, error)
 		parent := filepath.Dir(abs)
 		realParent, err := filepath.EvalSymlinks(parent)
 		if err != nil {
+			if !filepath.IsAbs(requestedPath) {
+				return "", fmt.Errorf("parent directory does not exist: %s (%s)", parent, fs.resolutionOrder())
+			}
 			return "", fmt.Errorf("parent directory does not exist: %s", parent)
 		}
 
 		if !fs.isPathInAllowedDirs(realParent) {
 			return "", fmt.Errorf("access denied - parent directory outside allowed directories")
 		}
+		if err := fs.checkDenyPatterns(abs, filepath.Join(realParent, filepath.Base(abs))); err != nil {
+			return "", err
+		}
 		return abs, nil
 	}
 
 	if !fs.isPathInAllowedDirs(realPath) {
 		return "", fmt.Errorf("access denied - symlink target outside allowed directories")
 	}
+	if err := fs.checkDenyPatterns(abs, realPath); err != nil {
+		return "", err
+	}
 
 	return realPath, nil
 }
 
+// workspaceDir returns the directory relative paths are resolved against
+func (fs *FilesystemHandler) workspaceDir() (string, error) {
+	if fs.workspace != "" && fs.isPathInAllowedDirs(fs.workspace) {
+		return fs.workspace, nil
+	}
+	dirs := fs.allowedDirectories()
+	if len(dirs) == 0 {
+		return "", fmt.Errorf("no allowed directories to resolve relative paths against")
+	}
+	return strings.TrimSuffix(dirs[0], string(filepath.Separator)), nil
+}
+
+// resolvePath makes requestedPath absolute without consulting the process
+// working directory: label:rel/path is joined to the labelled root, "~"
+// expands to the user's home directory and other relative paths are joined to
+// the workspace
+func (fs *FilesystemHandler) resolvePath(requestedPath string) (string, error) {
+	if labeled, ok := fs.resolveLabeledPath(requestedPath); ok {
+		return labeled, nil
+	}
+	if requestedPath == "~" || strings.HasPrefix(requestedPath, "~/") || strings.HasPrefix(requestedPath, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("cannot expand ~: %w", err)
+		}
+		return filepath.Join(home, requestedPath[1:]), nil
+	}
+	if filepath.IsAbs(requestedPath) {
+		return filepath.Clean(requestedPath), nil
+	}
+
+	workspace, err := fs.workspaceDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve relative path %s: %w", requestedPath, err)
+	}
+	return filepath.Join(workspace, requestedPath), nil
+}
+
+// resolutionOrder describes how relative paths are resolved, for error messages
+func (fs *FilesystemHandler) resolutionOrder() string {
+	workspace, err := fs.workspaceDir()
+	if err != nil {
+		workspace = "unavailable"
+	}
+	return fmt.Sprintf("relative paths resolve against the workspace %s and ~ against the home directory; use an absolute path inside an allowed directory", workspace)
+}
+
 // isPathInAllowedDirs checks if a path is within any allowed directory
 func (fs *FilesystemHandler) isPathInAllowedDirs(path string) bool {
 	absPath, err := filepath.Abs(path)
@@ -78,40 +310,67 @@ func (fs *FilesystemHandler) isPathInAllowedDirs(path string) bool {
 		return false
 	}
 
-	if !strings.HasSuffix(absPath, string(filepath.Separator)) {
-		if info, err := os.Stat(absPath); err == nil && !info.IsDir() {
-			absPath = filepath.Dir(absPath) + string(filepath.Separator)
-		} else {
-			absPath = absPath + string(filepath.Separator)
+	for _, dir := range fs.allowedDirectories() {
+		if pathWithinDir(absPath, dir) {
+			return true
 		}
 	}
+	return false
+}
 
-	for _, dir := range fs.allowedDirs {
-		if strings.HasPrefix(absPath, dir) {
-			return true
+// comparablePath cleans path for containment checks: on Windows it drops the
+// \\?\ extended-length prefix (\\?\UNC\server\share becomes \\server\share)
+// and it never ends in a separator unless it is a root
+func comparablePath(path string) string {
+	if filepath.Separator == '\\' {
+		switch {
+		case strings.HasPrefix(path, `\\?\UNC\`):
+			path = `\\` + path[len(`\\?\UNC\`):]
+		case strings.HasPrefix(path, `\\?\`):
+			path = path[len(`\\?\`):]
 		}
 	}
-	return false
+	return filepath.Clean(path)
 }
 
-// handleReadFile reads file contents
-func (fs *FilesystemHandler) handleReadFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	path, ok := request.Params.Arguments["path"].(string)
-	if !ok {
-		return nil, fmt.Errorf("path must be a string")
+// pathRelToDir returns path relative to dir ("." for dir itself) when
+// path is dir or lies below it. Components are compared whole, so /allowed/dir
+// never contains /allowed/dir-evil, and case-insensitively where the platform
+// filesystems are.
+func pathRelToDir(path, dir string) (string, bool) {
+	p := comparablePath(path)
+	d := comparablePath(dir)
+	if pathsEqual(p, d) {
+		return ".", true
+	}
+	if !strings.HasSuffix(d, string(filepath.Separator)) {
+		d += string(filepath.Separator)
 	}
+	if len(p) <= len(d) || !pathsEqual(p[:len(d)], d) {
+		return "", false
+	}
+	return p[len(d):], true
+}
 
-	if path == "." || path == "./" {
-		cwd, err := os.Getwd()
-		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error resolving current directory: %v", err)},
-				},
-				IsError: true,
-			}, nil
-		}
-		path = cwd
+// pathWithinDir reports whether path is dir or lies below it
+func pathWithinDir(path, dir string) bool {
+	_, ok := pathRelToDir(path, dir)
+	return ok
+}
+
+// pathsEqual compares two cleaned paths, ignoring case where the platform does
+func pathsEqual(a, b string) bool {
+	if caseInsensitivePaths {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// handleReadFile reads file contents
+func (fs *FilesystemHandler) handleReadFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := requireString(request.Params.Arguments, "path")
+	if err != nil {
+		return badArgError(err).result(), nil
 	}
 
 	validPath, err := fs.validatePath(path)
@@ -135,18 +394,11 @@ func (fs *FilesystemHandler) handleReadFile(ctx context.Context, request mcp.Cal
 	}
 
 	if info.IsDir() {
-		resourceURI := pathToResourceURI(validPath)
+		resourceURI := dirResourceURI(validPath)
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{Type: "text", Text: fmt.Sprintf("This is a directory. Use the resource URI to browse its contents: %s", resourceURI)},
-				mcp.EmbeddedResource{
-					Type: "resource",
-					Resource: mcp.TextResourceContents{
-						URI:      resourceURI,
-						MIMEType: "text/plain",
-						Text:     fmt.Sprintf("Directory: %s", validPath),
-					},
-				},
+				newFileResource(validPath),
 			},
 		}, nil
 	}
@@ -156,19 +408,12 @@ func (fs *FilesystemHandler) handleReadFile(ctx context.Context, request mcp.Cal
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{Type: "text", Text: fmt.Sprintf("File is too large to display inline (%d bytes). Access it via resource URI: %s", info.Size(), resourceURI)},
-				mcp.EmbeddedResource{
-					Type: "resource",
-					Resource: mcp.TextResourceContents{
-						URI:      resourceURI,
-						MIMEType: "text/plain",
-						Text:     fmt.Sprintf("Large file: %s (%d bytes)", validPath, info.Size()),
-					},
-				},
+				newFileResource(validPath),
 			},
 		}, nil
 	}
 
-	content, err := os.ReadFile(validPath)
+	content, err := fs.readFile(validPath)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -182,10 +427,10 @@ func (fs *FilesystemHandler) handleReadFile(ctx context.Context, request mcp.Cal
 	if isTextFile(mimeType) {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				mcp.TextContent{Type: "text", Text: string(content)},
+				mcp.TextContent{Type: "text", Text: stripBOM(string(content))},
 			},
 		}, nil
-	} else if isImageFile(mimeType) {
+	} else if isImageFile(validPath, mimeType) {
 		if info.Size() <= MAX_BASE64_SIZE {
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
@@ -204,40 +449,24 @@ func (fs *FilesystemHandler) handleReadFile(ctx context.Context, request mcp.Cal
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{Type: "text", Text: fmt.Sprintf("Binary file: %s (%s, %d bytes). Access it via resource URI: %s", validPath, mimeType, info.Size(), resourceURI)},
-			mcp.EmbeddedResource{
-				Type: "resource",
-				Resource: mcp.TextResourceContents{
-					URI:      resourceURI,
-					MIMEType: "text/plain",
-					Text:     fmt.Sprintf("Binary file: %s (%s, %d bytes)", validPath, mimeType, info.Size()),
-				},
-			},
+			newFileResource(validPath),
 		},
 	}, nil
 }
 
 // handleWriteFile writes content to a file
 func (fs *FilesystemHandler) handleWriteFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	path, ok := request.Params.Arguments["path"].(string)
-	if !ok {
-		return nil, fmt.Errorf("path must be a string")
+	path, err := requireString(request.Params.Arguments, "path")
+	if err != nil {
+		return badArgError(err).result(), nil
 	}
-	content, ok := request.Params.Arguments["content"].(string)
-	if !ok {
-		return nil, fmt.Errorf("content must be a string")
+	content, err := requireString(request.Params.Arguments, "content")
+	if err != nil {
+		return badArgError(err).result(), nil
 	}
-
-	if path == "." || path == "./" {
-		cwd, err := os.Getwd()
-		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error resolving current directory: %v", err)},
-				},
-				IsError: true,
-			}, nil
-		}
-		path = cwd
+	allowGenerated, err := getBool(request.Params.Arguments, "allow_generated", false)
+	if err != nil {
+		return badArgError(err).result(), nil
 	}
 
 	validPath, err := fs.validatePath(path)
@@ -250,6 +479,8 @@ func (fs *FilesystemHandler) handleWriteFile(ctx context.Context, request mcp.Ca
 		}, nil
 	}
 
+	defer fs.lockPath(validPath)()
+
 	if info, err := os.Stat(validPath); err == nil && info.IsDir() {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -257,10 +488,19 @@ func (fs *FilesystemHandler) handleWriteFile(ctx context.Context, request mcp.Ca
 			},
 			IsError: true,
 		}, nil
+	} else if err == nil && !allowGenerated {
+		if reason := fs.generatedReason(validPath); reason != "" {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error: %v", generatedFileError(path, reason))},
+				},
+				IsError: true,
+			}, nil
+		}
 	}
 
 	parentDir := filepath.Dir(validPath)
-	if err := os.MkdirAll(parentDir, 0755); err != nil {
+	if err := fs.mkdirAllChecked(parentDir, 0755); err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error creating parent directories: %v", err)},
@@ -269,7 +509,7 @@ func (fs *FilesystemHandler) handleWriteFile(ctx context.Context, request mcp.Ca
 		}, nil
 	}
 
-	if err := os.WriteFile(validPath, []byte(content), 0644); err != nil {
+	if err := fs.writeFileChecked(validPath, []byte(content), 0644); err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error writing file: %v", err)},
@@ -287,40 +527,19 @@ func (fs *FilesystemHandler) handleWriteFile(ctx context.Context, request mcp.Ca
 		}, nil
 	}
 
-	resourceURI := pathToResourceURI(validPath)
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{Type: "text", Text: fmt.Sprintf("Successfully wrote %d bytes to %s", info.Size(), path)},
-			mcp.EmbeddedResource{
-				Type: "resource",
-				Resource: mcp.TextResourceContents{
-					URI:      resourceURI,
-					MIMEType: "text/plain",
-					Text:     fmt.Sprintf("File: %s (%d bytes)", validPath, info.Size()),
-				},
-			},
+			newFileResource(validPath),
 		},
 	}, nil
 }
 
 // handleListDirectory lists directory contents
 func (fs *FilesystemHandler) handleListDirectory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	path, ok := request.Params.Arguments["path"].(string)
-	if !ok {
-		return nil, fmt.Errorf("path must be a string")
-	}
-
-	if path == "." || path == "./" {
-		cwd, err := os.Getwd()
-		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error resolving current directory: %v", err)},
-				},
-				IsError: true,
-			}, nil
-		}
-		path = cwd
+	path, err := requireString(request.Params.Arguments, "path")
+	if err != nil {
+		return badArgError(err).result(), nil
 	}
 
 	validPath, err := fs.validatePath(path)
@@ -362,59 +581,81 @@ func (fs *FilesystemHandler) handleListDirectory(ctx context.Context, request mc
 		}, nil
 	}
 
-	var result strings.Builder
-	result.WriteString(fmt.Sprintf("Directory listing for: %s\n\n", validPath))
+	relativeTo, err := getString(request.Params.Arguments, "relative_to", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	display, err := fs.pathDisplay(relativeTo)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	page, err := newResultPage(validPath, request.Params.Arguments, paramsHash("list_directory", validPath))
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
 
+	result := fs.newResultBuilder()
+	result.WriteString(fmt.Sprintf("Directory listing for: %s\n\n", safeDisplayName(display(validPath))))
+
+	lastShown := ""
 	for _, entry := range entries {
 		entryPath := filepath.Join(validPath, entry.Name())
+		if fs.isDeniedPath(entryPath) {
+			continue
+		}
+		if skip, _ := page.resumeSkip(entryPath, false); skip {
+			continue
+		}
+		if !page.add(entryPath) {
+			break
+		}
 		resourceURI := pathToResourceURI(entryPath)
 
+		var line string
 		if entry.IsDir() {
-			result.WriteString(fmt.Sprintf("[DIR]  %s (%s)\n", entry.Name(), resourceURI))
+			resourceURI = dirResourceURI(entryPath)
+			line = fmt.Sprintf("[DIR]  %s (%s)\n", safeDisplayName(entry.Name()), resourceURI)
 		} else {
 			info, err := entry.Info()
 			if err == nil {
-				result.WriteString(fmt.Sprintf("[FILE] %s (%s) - %d bytes\n", entry.Name(), resourceURI, info.Size()))
+				line = fmt.Sprintf("[FILE] %s (%s) - %d bytes\n", safeDisplayName(entry.Name()), resourceURI, info.Size())
 			} else {
-				result.WriteString(fmt.Sprintf("[FILE] %s (%s)\n", entry.Name(), resourceURI))
+				line = fmt.Sprintf("[FILE] %s (%s)\n", safeDisplayName(entry.Name()), resourceURI)
 			}
 		}
+		if !result.item(line) {
+			result.stopPage(page, lastShown)
+			break
+		}
+		lastShown = entryPath
 	}
+	result.tail(page.footer())
+	text, _ := result.done(validPath, "list_directory")
 
-	resourceURI := pathToResourceURI(validPath)
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
-			mcp.TextContent{Type: "text", Text: result.String()},
-			mcp.EmbeddedResource{
-				Type: "resource",
-				Resource: mcp.TextResourceContents{
-					URI:      resourceURI,
-					MIMEType: "text/plain",
-					Text:     fmt.Sprintf("Directory: %s", validPath),
-				},
-			},
+			mcp.TextContent{Type: "text", Text: text},
+			newFileResource(validPath),
 		},
 	}, nil
 }
 
 // handleCreateDirectory creates a new directory
 func (fs *FilesystemHandler) handleCreateDirectory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	path, ok := request.Params.Arguments["path"].(string)
-	if !ok {
-		return nil, fmt.Errorf("path must be a string")
-	}
-
-	if path == "." || path == "./" {
-		cwd, err := os.Getwd()
-		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error resolving current directory: %v", err)},
-				},
-				IsError: true,
-			}, nil
-		}
-		path = cwd
+	path, err := requireString(request.Params.Arguments, "path")
+	if err != nil {
+		return badArgError(err).result(), nil
 	}
 
 	validPath, err := fs.validatePath(path)
@@ -429,18 +670,10 @@ func (fs *FilesystemHandler) handleCreateDirectory(ctx context.Context, request
 
 	if info, err := os.Stat(validPath); err == nil {
 		if info.IsDir() {
-			resourceURI := pathToResourceURI(validPath)
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
 					mcp.TextContent{Type: "text", Text: fmt.Sprintf("Directory already exists: %s", path)},
-					mcp.EmbeddedResource{
-						Type: "resource",
-						Resource: mcp.TextResourceContents{
-							URI:      resourceURI,
-							MIMEType: "text/plain",
-							Text:     fmt.Sprintf("Directory: %s", validPath),
-						},
-					},
+					newFileResource(validPath),
 				},
 			}, nil
 		}
@@ -452,7 +685,7 @@ func (fs *FilesystemHandler) handleCreateDirectory(ctx context.Context, request
 		}, nil
 	}
 
-	if err := os.MkdirAll(validPath, 0755); err != nil {
+	if err := fs.mkdirAllChecked(validPath, 0755); err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error creating directory: %v", err)},
@@ -461,40 +694,19 @@ func (fs *FilesystemHandler) handleCreateDirectory(ctx context.Context, request
 		}, nil
 	}
 
-	resourceURI := pathToResourceURI(validPath)
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{Type: "text", Text: fmt.Sprintf("Successfully created directory %s", path)},
-			mcp.EmbeddedResource{
-				Type: "resource",
-				Resource: mcp.TextResourceContents{
-					URI:      resourceURI,
-					MIMEType: "text/plain",
-					Text:     fmt.Sprintf("Directory: %s", validPath),
-				},
-			},
+			newFileResource(validPath),
 		},
 	}, nil
 }
 
 // handleDeleteFile deletes a file or directory
 func (fs *FilesystemHandler) handleDeleteFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	path, ok := request.Params.Arguments["path"].(string)
-	if !ok {
-		return nil, fmt.Errorf("path must be a string")
-	}
-
-	if path == "." || path == "./" {
-		cwd, err := os.Getwd()
-		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error resolving current directory: %v", err)},
-				},
-				IsError: true,
-			}, nil
-		}
-		path = cwd
+	path, err := requireString(request.Params.Arguments, "path")
+	if err != nil {
+		return badArgError(err).result(), nil
 	}
 
 	validPath, err := fs.validatePath(path)
@@ -524,24 +736,22 @@ func (fs *FilesystemHandler) handleDeleteFile(ctx context.Context, request mcp.C
 		}, nil
 	}
 
-	recursive := false
-	if recursiveParam, ok := request.Params.Arguments["recursive"]; ok {
-		if r, ok := recursiveParam.(bool); ok {
-			recursive = r
-		}
+	recursive, err := getBool(request.Params.Arguments, "recursive", false)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
 	}
 
 	if info.IsDir() {
 		if !recursive {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error: %s is a directory. Use recursive=true to delete directories.", path)},
-				},
-				IsError: true,
-			}, nil
+			return directoryNeedsRecursiveError(path).result(), nil
 		}
 
-		if err := os.RemoveAll(validPath); err != nil {
+		if err := fs.removeChecked(validPath, true); err != nil {
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
 					mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error deleting directory: %v", err)},
@@ -557,7 +767,7 @@ func (fs *FilesystemHandler) handleDeleteFile(ctx context.Context, request mcp.C
 		}, nil
 	}
 
-	if err := os.Remove(validPath); err != nil {
+	if err := fs.removeChecked(validPath, false); err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error deleting file: %v", err)},
@@ -575,26 +785,6 @@ func (fs *FilesystemHandler) handleDeleteFile(ctx context.Context, request mcp.C
 
 // copyFile copies a single file
 func copyFile(src, dst string) error {
-	sourceFile, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer sourceFile.Close()
-
-	destFile, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer destFile.Close()
-
-	if _, err := io.Copy(destFile, sourceFile); err != nil {
-		return err
-	}
-
-	sourceInfo, err := os.Stat(src)
-	if err != nil {
-		return err
-	}
-
-	return os.Chmod(dst, sourceInfo.Mode())
+	_, err := copyFileHash(src, dst, nil)
+	return err
 }
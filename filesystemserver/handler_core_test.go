@@ -0,0 +1,307 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuntimeAllowedDirectories(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	extra, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(extra, "hello.txt"), []byte("hello"), 0644))
+	ctx := context.Background()
+
+	handler, err := NewFilesystemHandler([]string{base}, WithRuntimeDirectories(true))
+	require.NoError(t, err)
+
+	readHello := func() *mcp.CallToolResult {
+		result, err := handler.handleReadFile(ctx, newToolRequest("read_file", map[string]interface{}{
+			"path": filepath.Join(extra, "hello.txt"),
+		}))
+		require.NoError(t, err)
+		return result
+	}
+	assert.True(t, readHello().IsError)
+
+	result, err := handler.handleAddAllowedDirectory(ctx, newToolRequest("add_allowed_directory", map[string]interface{}{"path": extra}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+	result = readHello()
+	require.False(t, result.IsError)
+	assert.Equal(t, "hello", result.Content[0].(mcp.TextContent).Text)
+
+	result, err = handler.handleListAllowedDirectories(ctx, newToolRequest("list_allowed_directories", nil))
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, extra)
+
+	// Los directorios de arranque no se pueden revocar
+	result, err = handler.handleRemoveAllowedDirectory(ctx, newToolRequest("remove_allowed_directory", map[string]interface{}{"path": base}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	result, err = handler.handleRemoveAllowedDirectory(ctx, newToolRequest("remove_allowed_directory", map[string]interface{}{"path": extra}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	assert.True(t, readHello().IsError)
+
+	result, err = handler.handleListAllowedDirectories(ctx, newToolRequest("list_allowed_directories", nil))
+	require.NoError(t, err)
+	assert.NotContains(t, result.Content[0].(mcp.TextContent).Text, extra)
+}
+
+func TestRuntimeAllowedDirectoriesGuards(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	roots, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	project := filepath.Join(roots, "project")
+	require.NoError(t, os.Mkdir(project, 0755))
+
+	// Desactivado por defecto
+	handler, err := NewFilesystemHandler([]string{base})
+	require.NoError(t, err)
+	_, err = handler.addAllowedDir(project)
+	assert.Error(t, err)
+
+	handler, err = NewFilesystemHandler([]string{base}, WithGrantableRoots(roots))
+	require.NoError(t, err)
+	_, err = handler.addAllowedDir(project)
+	require.NoError(t, err)
+	_, err = handler.addAllowedDir(t.TempDir())
+	assert.ErrorContains(t, err, "not under a grantable root")
+	_, err = handler.addAllowedDir(filepath.Join(roots, "missing"))
+	assert.Error(t, err)
+
+	_, err = NewFilesystemHandler([]string{base}, WithGrantableRoots(filepath.Join(roots, "missing")))
+	assert.Error(t, err)
+}
+
+func TestRuntimeAllowedDirectoriesConcurrent(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	handler.allowRuntimeDirs = true
+	var dirs []string
+	for i := 0; i < 8; i++ {
+		sub := filepath.Join(dir, "sub", string(rune('a'+i)))
+		require.NoError(t, os.MkdirAll(sub, 0755))
+		dirs = append(dirs, sub)
+	}
+
+	var wg sync.WaitGroup
+	for _, sub := range dirs {
+		wg.Add(2)
+		go func(sub string) {
+			defer wg.Done()
+			_, err := handler.addAllowedDir(sub)
+			assert.NoError(t, err)
+			handler.removeAllowedDir(sub)
+		}(sub)
+		go func() {
+			defer wg.Done()
+			_, err := handler.validatePath(filepath.Join(dir, "file.txt"))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, handler.allowedDirectories(), 1)
+}
+
+func TestValidatePathSiblingPrefix(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	allowed := filepath.Join(base, "dir")
+	evil := filepath.Join(base, "dir-evil")
+	require.NoError(t, os.MkdirAll(allowed, 0755))
+	require.NoError(t, os.MkdirAll(evil, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(evil, "secret.txt"), []byte("x"), 0644))
+
+	// Tanto con separador final como sin él
+	for _, root := range []string{allowed, allowed + string(filepath.Separator)} {
+		handler, err := NewFilesystemHandler([]string{root})
+		require.NoError(t, err)
+
+		_, err = handler.validatePath(filepath.Join(evil, "secret.txt"))
+		assert.ErrorContains(t, err, "outside allowed directories")
+		_, err = handler.validatePath(filepath.Join(evil, "new.txt"))
+		assert.ErrorContains(t, err, "outside allowed directories")
+		_, err = handler.validatePath(evil)
+		assert.ErrorContains(t, err, "outside allowed directories")
+		_, err = handler.validatePath(allowed + "/../dir-evil/secret.txt")
+		assert.ErrorContains(t, err, "outside allowed directories")
+
+		_, err = handler.validatePath(allowed)
+		assert.NoError(t, err)
+		_, err = handler.validatePath(filepath.Join(allowed, "new.txt"))
+		assert.NoError(t, err)
+	}
+
+	// Un enlace dentro del directorio permitido que apunta al hermano tampoco pasa
+	handler, err := NewFilesystemHandler([]string{allowed})
+	require.NoError(t, err)
+	require.NoError(t, os.Symlink(evil, filepath.Join(allowed, "link")))
+	_, err = handler.validatePath(filepath.Join(allowed, "link", "secret.txt"))
+	assert.ErrorContains(t, err, "symlink target outside allowed directories")
+}
+
+func TestPathRelToDir(t *testing.T) {
+	sep := string(filepath.Separator)
+	root := filepath.Join(sep+"srv", "data")
+	cases := []struct {
+		path string
+		rel  string
+		ok   bool
+	}{
+		{root, ".", true},
+		{root + sep, ".", true},
+		{filepath.Join(root, "a", "b.txt"), filepath.Join("a", "b.txt"), true},
+		{root + "-evil", "", false},
+		{filepath.Join(root+"-evil", "b.txt"), "", false},
+		{filepath.Join(root, "..", "other"), "", false},
+		{filepath.Dir(root), "", false},
+	}
+	for _, c := range cases {
+		rel, ok := pathRelToDir(c.path, root)
+		assert.Equal(t, c.ok, ok, c.path)
+		assert.Equal(t, c.rel, rel, c.path)
+	}
+	assert.True(t, pathWithinDir(filepath.Join(sep, "etc"), sep))
+
+	upper := strings.ToUpper(filepath.Join(root, "file.txt"))
+	assert.Equal(t, caseInsensitivePaths, pathWithinDir(upper, root))
+}
+
+func TestValidatePathMixedCase(t *testing.T) {
+	if runtime.GOOS != "darwin" && runtime.GOOS != "windows" {
+		t.Skip("case-insensitive filesystems only")
+	}
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	allowed := filepath.Join(base, "Project")
+	require.NoError(t, os.MkdirAll(allowed, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(allowed, "main.go"), []byte("package main\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(base, "project-evil"), 0755))
+
+	handler, err := NewFilesystemHandler([]string{allowed})
+	require.NoError(t, err)
+
+	_, err = handler.validatePath(filepath.Join(base, "PROJECT", "MAIN.GO"))
+	assert.NoError(t, err)
+	_, err = handler.validatePath(filepath.Join(base, "project", "new.txt"))
+	assert.NoError(t, err)
+	_, err = handler.validatePath(filepath.Join(base, "PROJECT-EVIL"))
+	assert.ErrorContains(t, err, "outside allowed directories")
+}
+
+func TestPathRelToDirWindowsForms(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skip("Windows path forms only")
+	}
+	assert.True(t, pathWithinDir(`c:\Data\file.txt`, `C:\data\`))
+	assert.True(t, pathWithinDir(`\\?\C:\data\file.txt`, `C:\data`))
+	assert.True(t, pathWithinDir(`\\server\share\dir\file.txt`, `\\server\share\dir`))
+	assert.True(t, pathWithinDir(`\\?\UNC\server\share\dir\file.txt`, `\\SERVER\share\dir`))
+	assert.False(t, pathWithinDir(`\\server\share\dir-evil\file.txt`, `\\server\share\dir`))
+	assert.False(t, pathWithinDir(`D:\data\file.txt`, `C:\data`))
+	assert.False(t, pathWithinDir(`C:\data-evil\file.txt`, `C:\data`))
+}
+
+func TestRelativePathResolution(t *testing.T) {
+	first, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	second, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(first, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(first, "src", "main.go"), []byte("package main\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(second, "notes.txt"), []byte("notes\n"), 0644))
+
+	// Por defecto se resuelve contra el primer directorio permitido, no contra el CWD
+	handler, err := NewFilesystemHandler([]string{first, second})
+	require.NoError(t, err)
+	for input, want := range map[string]string{
+		".":                                     first,
+		"./":                                    first,
+		"./src/main.go":                         filepath.Join(first, "src", "main.go"),
+		"src/new.go":                            filepath.Join(first, "src", "new.go"),
+		"src/../src/":                           filepath.Join(first, "src"),
+		second + "/../" + filepath.Base(second): second,
+	} {
+		got, err := handler.validatePath(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	_, err = handler.validatePath("../outside.txt")
+	assert.ErrorContains(t, err, "relative paths resolve against the workspace "+first)
+	_, err = handler.validatePath("missing/dir/file.txt")
+	assert.ErrorContains(t, err, "parent directory does not exist")
+	assert.ErrorContains(t, err, "relative paths resolve against the workspace")
+
+	result, err := handler.handleReadFile(context.Background(), newToolRequest("read_file", map[string]interface{}{"path": "src/main.go"}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	assert.Equal(t, "package main\n", result.Content[0].(mcp.TextContent).Text)
+
+	handler, err = NewFilesystemHandler([]string{first, second}, WithWorkspace(second))
+	require.NoError(t, err)
+	got, err := handler.validatePath("notes.txt")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(second, "notes.txt"), got)
+
+	_, err = NewFilesystemHandler([]string{first}, WithWorkspace(second))
+	assert.ErrorContains(t, err, "outside allowed directories")
+}
+
+func TestHomeExpansion(t *testing.T) {
+	home, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".profile"), []byte("x"), 0644))
+
+	handler, err := NewFilesystemHandler([]string{home})
+	require.NoError(t, err)
+	got, err := handler.validatePath("~/.profile")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".profile"), got)
+	got, err = handler.validatePath("~")
+	require.NoError(t, err)
+	assert.Equal(t, home, got)
+
+	// Si el home no está permitido, ~ no abre ninguna puerta
+	other, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	handler, err = NewFilesystemHandler([]string{other})
+	require.NoError(t, err)
+	_, err = handler.validatePath("~/.profile")
+	assert.ErrorContains(t, err, "outside allowed directories")
+}
+
+func TestReadFileStripsBOM(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "bom.txt")
+	require.NoError(t, os.WriteFile(path, []byte("\ufeffname,value\n"), 0644))
+
+	result, err := handler.handleReadFile(context.Background(), newToolRequest("read_file", map[string]interface{}{"path": path}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	assert.Equal(t, "name,value\n", result.Content[0].(mcp.TextContent).Text)
+
+	result, err = handler.handleReadMultipleFiles(context.Background(), newToolRequest("read_multiple_files", map[string]interface{}{"paths": []interface{}{path}}))
+	require.NoError(t, err)
+	assert.Equal(t, "name,value\n", result.Content[1].(mcp.TextContent).Text)
+
+	analysis, err := handler.analyzeFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "UTF-8 with BOM", analysis.Encoding)
+}
@@ -0,0 +1,437 @@
+package filesystemserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultCSVLimit - Filas que devuelve csv_query si no se indica limit
+	defaultCSVLimit = 50
+	// maxCSVLimit - Máximo de filas por llamada
+	maxCSVLimit = 1000
+	// maxCSVMalformed - Filas mal formadas que se detallan en la respuesta
+	maxCSVMalformed = 20
+)
+
+// csvDelimiters - Separadores que se detectan automáticamente, por orden de preferencia
+var csvDelimiters = []rune{',', '\t', ';'}
+
+// csvOperators - Operadores de where; en la misma posición gana el primero de la
+// lista, por eso los de dos caracteres van antes que sus prefijos
+var csvOperators = []string{" contains ", "==", "!=", ">=", "<=", "=", ">", "<"}
+
+// csvCondition - Filtro "columna operador valor" de csv_query
+type csvCondition struct {
+	column int
+	op     string
+	value  string
+}
+
+// handleCSVQuery - Vista previa y consulta simple de CSV/TSV en streaming
+func (fs *FilesystemHandler) handleCSVQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	delimiter, err := getString(request.Params.Arguments, "delimiter", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	where, err := getString(request.Params.Arguments, "where", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	limit, err := getInt(request.Params.Arguments, "limit", 0)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if limit <= 0 {
+		limit = defaultCSVLimit
+	}
+	limit = min(limit, maxCSVLimit)
+	offset, err := getInt(request.Params.Arguments, "offset", 0)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	offset = max(offset, 0)
+	countRows, err := getBool(request.Params.Arguments, "count_rows", true)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	columns, err := getArray(request.Params.Arguments, "columns")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var comma rune
+	switch delimiter {
+	case "", "auto":
+	case "tab", "\\t", "\t":
+		comma = '\t'
+	default:
+		if len([]rune(delimiter)) != 1 {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: "❌ Error: delimiter must be a single character, 'tab' or 'auto'"},
+				},
+				IsError: true,
+			}, nil
+		}
+		comma = []rune(delimiter)[0]
+	}
+
+	result, err := fs.queryCSV(ctx, validPath, comma, columns, where, offset, limit, countRows)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatCSVQuery(result)},
+		},
+	}, pathToResourceURI(validPath), result)
+}
+
+// queryCSV - Lee path fila a fila aplicando columnas, filtro y paginación.
+// comma == 0 detecta el separador a partir de la primera línea.
+func (fs *FilesystemHandler) queryCSV(ctx context.Context, path string, comma rune, columns []interface{}, where string, offset, limit int, countRows bool) (*CSVQueryResult, error) {
+	file, _, err := openDecompressed(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	buffered := bufio.NewReaderSize(file, 64*1024)
+	if comma == 0 {
+		comma = detectCSVDelimiter(buffered)
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1 // las filas con otro número de campos se informan, no abortan
+	reader.ReuseRecord = false
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %v", err)
+	}
+	if len(header) > 0 {
+		header[0] = stripBOM(header[0])
+	}
+
+	selected, err := csvColumnIndexes(header, columns)
+	if err != nil {
+		return nil, err
+	}
+	var condition *csvCondition
+	if strings.TrimSpace(where) != "" {
+		if condition, err = parseCSVWhere(where, header); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &CSVQueryResult{
+		Path:      path,
+		Delimiter: csvDelimiterName(comma),
+		Header:    header,
+		Offset:    offset,
+		Rows:      []map[string]string{},
+	}
+	for _, index := range selected {
+		result.Columns = append(result.Columns, header[index])
+	}
+
+	progress := progressFrom(ctx)
+	matched := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			result.addMalformed(parseErr.StartLine, parseErr.Err.Error())
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.TotalRows++
+		line, _ := reader.FieldPos(0)
+		progress.entry(fmt.Sprintf("%s:%d", path, line))
+
+		if len(record) != len(header) {
+			result.addMalformed(line, fmt.Sprintf("%d fields, header has %d", len(record), len(header)))
+			continue
+		}
+		if condition != nil && !condition.matches(record) {
+			continue
+		}
+		matched++
+		if matched > offset && len(result.Rows) < limit {
+			row := make(map[string]string, len(selected))
+			for _, index := range selected {
+				row[header[index]] = record[index]
+			}
+			result.Rows = append(result.Rows, row)
+			result.Lines = append(result.Lines, line)
+		}
+		if !countRows && len(result.Rows) >= limit {
+			result.Partial = true
+			break
+		}
+	}
+
+	if result.Partial {
+		result.TotalRows = 0
+	} else {
+		result.MatchedRows = matched
+	}
+	return result, nil
+}
+
+// addMalformed - Anota una fila mal formada, hasta maxCSVMalformed con detalle
+func (r *CSVQueryResult) addMalformed(line int, message string) {
+	r.MalformedRows++
+	if len(r.Malformed) < maxCSVMalformed {
+		r.Malformed = append(r.Malformed, CSVMalformedRow{Line: line, Error: message})
+	}
+}
+
+// detectCSVDelimiter - Separador más frecuente fuera de comillas en la primera línea
+func detectCSVDelimiter(reader *bufio.Reader) rune {
+	peek, _ := reader.Peek(64 * 1024)
+	if end := strings.IndexByte(string(peek), '\n'); end >= 0 {
+		peek = peek[:end]
+	}
+
+	best, bestCount := csvDelimiters[0], 0
+	for _, candidate := range csvDelimiters {
+		count, quoted := 0, false
+		for _, r := range string(peek) {
+			switch {
+			case r == '"':
+				quoted = !quoted
+			case r == candidate && !quoted:
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = candidate, count
+		}
+	}
+	return best
+}
+
+// csvDelimiterName - Nombre legible del separador
+func csvDelimiterName(comma rune) string {
+	if comma == '\t' {
+		return "tab"
+	}
+	return string(comma)
+}
+
+// csvColumnIndexes - Índices de las columnas pedidas, por nombre o posición (desde 0);
+// sin columnas se devuelven todas
+func csvColumnIndexes(header []string, columns []interface{}) ([]int, error) {
+	if len(columns) == 0 {
+		all := make([]int, len(header))
+		for i := range header {
+			all[i] = i
+		}
+		return all, nil
+	}
+
+	var indexes []int
+	for _, column := range columns {
+		index, err := csvColumnIndex(header, column)
+		if err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+// csvColumnIndex - Resuelve una columna por nombre o posición (desde 0)
+func csvColumnIndex(header []string, column interface{}) (int, error) {
+	switch v := column.(type) {
+	case float64:
+		if int(v) < 0 || int(v) >= len(header) || v != float64(int(v)) {
+			return 0, fmt.Errorf("column index %v out of range (0-%d)", v, len(header)-1)
+		}
+		return int(v), nil
+	case string:
+		for i, name := range header {
+			if name == v {
+				return i, nil
+			}
+		}
+		for i, name := range header {
+			if strings.EqualFold(name, v) {
+				return i, nil
+			}
+		}
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 && n < len(header) {
+			return n, nil
+		}
+		return 0, fmt.Errorf("unknown column '%s' (columns: %s)", v, strings.Join(header, ", "))
+	}
+	return 0, fmt.Errorf("invalid column %v", column)
+}
+
+// parseCSVWhere - Interpreta "columna operador valor"; el valor puede ir entre comillas.
+// Manda el primer operador del texto, así el valor puede contener otros.
+func parseCSVWhere(where string, header []string) (*csvCondition, error) {
+	position, op := -1, ""
+	for _, candidate := range csvOperators {
+		if i := strings.Index(where, candidate); i > 0 && (position < 0 || i < position) {
+			position, op = i, candidate
+		}
+	}
+	if position > 0 {
+		name := strings.TrimSpace(where[:position])
+		value := strings.TrimSpace(where[position+len(op):])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		column, err := csvColumnIndex(header, name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid where: %v", err)
+		}
+		op = strings.TrimSpace(op)
+		if op == "==" {
+			op = "="
+		}
+		return &csvCondition{column: column, op: op, value: value}, nil
+	}
+	return nil, fmt.Errorf("invalid where '%s': use 'column op value' with op one of =, !=, >, >=, <, <=, contains", where)
+}
+
+// matches - Compara numéricamente si ambos lados son números; si no, como texto
+func (c *csvCondition) matches(record []string) bool {
+	field := strings.TrimSpace(record[c.column])
+	if c.op == "contains" {
+		return strings.Contains(strings.ToLower(field), strings.ToLower(c.value))
+	}
+
+	var cmp int
+	a, errA := strconv.ParseFloat(field, 64)
+	b, errB := strconv.ParseFloat(c.value, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case a < b:
+			cmp = -1
+		case a > b:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(field, c.value)
+	}
+
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// formatCSVQuery - Cabecera, tabla Markdown de las filas y filas mal formadas
+func formatCSVQuery(result *CSVQueryResult) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📊 %s\n", result.Path))
+	b.WriteString(fmt.Sprintf("Delimiter: %s | Columns (%d): %s\n", result.Delimiter, len(result.Header), strings.Join(result.Header, ", ")))
+	switch {
+	case result.Partial:
+		b.WriteString(fmt.Sprintf("Rows: %d shown from offset %d (total not counted)\n", len(result.Rows), result.Offset))
+	default:
+		b.WriteString(fmt.Sprintf("Rows: %d total, %d matching, %d shown from offset %d\n", result.TotalRows, result.MatchedRows, len(result.Rows), result.Offset))
+	}
+	b.WriteString("\n")
+
+	if len(result.Rows) == 0 {
+		b.WriteString("No rows to show.\n")
+	} else {
+		escape := func(s string) string {
+			s = strings.ReplaceAll(s, "|", "\\|")
+			return strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(s)
+		}
+		b.WriteString("| line |")
+		for _, column := range result.Columns {
+			b.WriteString(" " + escape(column) + " |")
+		}
+		b.WriteString("\n|---|")
+		for range result.Columns {
+			b.WriteString("---|")
+		}
+		b.WriteString("\n")
+		for i, row := range result.Rows {
+			b.WriteString(fmt.Sprintf("| %d |", result.Lines[i]))
+			for _, column := range result.Columns {
+				b.WriteString(" " + escape(row[column]) + " |")
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if result.MalformedRows > 0 {
+		b.WriteString(fmt.Sprintf("\n⚠️ %d malformed rows skipped:\n", result.MalformedRows))
+		for _, row := range result.Malformed {
+			b.WriteString(fmt.Sprintf("  • line %d: %s\n", row.Line, row.Error))
+		}
+	}
+	if !result.Partial && result.Offset+len(result.Rows) < result.MatchedRows {
+		b.WriteString(fmt.Sprintf("\n💡 More rows available, call again with offset=%d\n", result.Offset+len(result.Rows)))
+	}
+	return b.String()
+}
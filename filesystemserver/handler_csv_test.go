@@ -0,0 +1,121 @@
+package filesystemserver
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const csvSample = "\ufeffid,name,status,amount\n" +
+	"1,alpha,ok,10\n" +
+	"2,beta,failed,250\n" +
+	"3,\"gamma, inc\",ok,99.5\n" +
+	"4,delta,failed\n" +
+	"5,epsilon,failed,30\n" +
+	"6,\"zeta \"bad\" quote\",ok,1\n" +
+	"7,eta,ok,1000\n"
+
+func TestQueryCSV(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "data.csv")
+	require.NoError(t, os.WriteFile(path, []byte(csvSample), 0644))
+
+	result, err := handler.queryCSV(context.Background(), path, 0, nil, "", 0, 3, true)
+	require.NoError(t, err)
+	assert.Equal(t, ",", result.Delimiter)
+	assert.Equal(t, []string{"id", "name", "status", "amount"}, result.Header)
+	assert.Equal(t, 6, result.TotalRows)
+	assert.Equal(t, 5, result.MatchedRows)
+	require.Len(t, result.Rows, 3)
+	assert.Equal(t, "gamma, inc", result.Rows[2]["name"])
+	assert.Equal(t, []int{2, 3, 4}, result.Lines)
+
+	// Filas mal formadas: campos de menos y comillas sin escapar, con su línea
+	assert.Equal(t, 2, result.MalformedRows)
+	assert.Equal(t, 5, result.Malformed[0].Line)
+	assert.Contains(t, result.Malformed[0].Error, "3 fields")
+	assert.Equal(t, 7, result.Malformed[1].Line)
+
+	// Filtro numérico, columnas por nombre e índice y paginación
+	result, err = handler.queryCSV(context.Background(), path, 0, []interface{}{"name", float64(3)}, "amount >= 30", 1, 10, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"name", "amount"}, result.Columns)
+	assert.Equal(t, 4, result.MatchedRows)
+	assert.Equal(t, []map[string]string{
+		{"name": "gamma, inc", "amount": "99.5"},
+		{"name": "epsilon", "amount": "30"},
+		{"name": "eta", "amount": "1000"},
+	}, result.Rows)
+
+	result, err = handler.queryCSV(context.Background(), path, 0, nil, "status = failed", 0, 1, false)
+	require.NoError(t, err)
+	assert.True(t, result.Partial)
+	assert.Zero(t, result.TotalRows)
+	assert.Equal(t, "beta", result.Rows[0]["name"])
+
+	_, err = handler.queryCSV(context.Background(), path, 0, []interface{}{"missing"}, "", 0, 10, true)
+	assert.ErrorContains(t, err, "unknown column 'missing'")
+	_, err = handler.queryCSV(context.Background(), path, 0, nil, "amount ~ 3", 0, 10, true)
+	assert.ErrorContains(t, err, "invalid where")
+}
+
+func TestQueryCSVDelimitersAndGzip(t *testing.T) {
+	handler, dir := newTestHandler(t)
+
+	tsv := filepath.Join(dir, "data.tsv")
+	require.NoError(t, os.WriteFile(tsv, []byte("a\tb\n1\t2\n"), 0644))
+	result, err := handler.queryCSV(context.Background(), tsv, 0, nil, "", 0, 10, true)
+	require.NoError(t, err)
+	assert.Equal(t, "tab", result.Delimiter)
+	assert.Equal(t, "2", result.Rows[0]["b"])
+
+	semi := filepath.Join(dir, "data.gz")
+	file, err := os.Create(semi)
+	require.NoError(t, err)
+	gz := gzip.NewWriter(file)
+	gz.Write([]byte("city;note\nMadrid;\"a, b\"\n"))
+	require.NoError(t, gz.Close())
+	require.NoError(t, file.Close())
+	result, err = handler.queryCSV(context.Background(), semi, 0, nil, "city contains mad", 0, 10, true)
+	require.NoError(t, err)
+	assert.Equal(t, ";", result.Delimiter)
+	assert.Equal(t, 1, result.MatchedRows)
+	assert.Equal(t, "a, b", result.Rows[0]["note"])
+}
+
+func TestHandleCSVQuery(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "data.csv")
+	require.NoError(t, os.WriteFile(path, []byte(csvSample), 0644))
+
+	result, err := handler.handleCSVQuery(context.Background(), newToolRequest("csv_query", map[string]interface{}{
+		"path":    path,
+		"columns": []interface{}{"id", "name"},
+		"limit":   float64(2),
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "Rows: 6 total, 5 matching, 2 shown from offset 0")
+	assert.Contains(t, text, "| line | id | name |\n|---|---|---|\n| 2 | 1 | alpha |")
+	assert.Contains(t, text, "line 5: 3 fields, header has 4")
+	assert.Contains(t, text, "call again with offset=2")
+
+	var decoded CSVQueryResult
+	decodeStructured(t, result, &decoded)
+	assert.Equal(t, []string{"id", "name"}, decoded.Columns)
+	assert.Len(t, decoded.Rows, 2)
+
+	result, err = handler.handleCSVQuery(context.Background(), newToolRequest("csv_query", map[string]interface{}{
+		"path":      path,
+		"delimiter": "::",
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
@@ -0,0 +1,478 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Límites de find_duplicate_code
+const (
+	defaultDuplicateCodeWindow  = 8
+	minDuplicateCodeWindow      = 3
+	defaultDuplicateCodeResults = 10
+	maxDuplicateCodeResults     = 100
+	maxDuplicateCodeFileSize    = 1024 * 1024 // los archivos mayores suelen ser generados o minificados
+	duplicateCodeBuckets        = 1 << 20     // contadores de un byte por cubeta de hash
+	maxDuplicateCodeWindows     = 500000      // ventanas candidatas que se guardan con su posición
+	duplicateCodeSnippetLines   = 12
+	maxLicenseHeaderLines       = 60
+)
+
+// commentLinePrefixes - Inicios de línea de comentario con los que se reconoce
+// la cabecera de licencia cuando no se indica skip_prefix_lines
+var commentLinePrefixes = []string{"//", "/*", "*", "#", "--", "<!--", "-->", `"""`, "'''", ";;", "%"}
+
+// codeFile - Archivo analizado por find_duplicate_code: la línea original de
+// cada línea normalizada que cuenta y el hash de cada ventana
+type codeFile struct {
+	path     string
+	rel      string
+	language string
+	lines    []int32
+	windows  []uint64
+}
+
+// codeWindowRef - Ventana index del archivo file
+type codeWindowRef struct {
+	file  int32
+	index int32
+}
+
+// duplicateCodeOptions - Parámetros de find_duplicate_code
+type duplicateCodeOptions struct {
+	Window           int
+	SkipPrefixLines  int // -1: la cabecera de comentarios de cada archivo
+	MaxResults       int
+	Languages        []string
+	ExcludePatterns  []string
+	IncludeGenerated bool
+}
+
+// handleFindDuplicateCode - Bloques de código repetidos entre archivos (o dentro
+// de uno), buscados con ventanas deslizantes de líneas normalizadas
+func (fs *FilesystemHandler) handleFindDuplicateCode(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	output, err := getString(request.Params.Arguments, "output", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	opts := duplicateCodeOptions{
+		Window:          defaultDuplicateCodeWindow,
+		SkipPrefixLines: -1,
+		MaxResults:      defaultDuplicateCodeResults,
+	}
+	window, err := getInt(request.Params.Arguments, "window", opts.Window)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if window < minDuplicateCodeWindow {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: window must be at least %d lines", minDuplicateCodeWindow)},
+			},
+			IsError: true,
+		}, nil
+	}
+	opts.Window = window
+	skip, err := getInt(request.Params.Arguments, "skip_prefix_lines", -1)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if skip >= 0 {
+		opts.SkipPrefixLines = skip
+	}
+	maxResults, err := getInt(request.Params.Arguments, "max_results", 0)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if maxResults > 0 {
+		opts.MaxResults = minInt(maxResults, maxDuplicateCodeResults)
+	}
+	if opts.IncludeGenerated, err = getBool(request.Params.Arguments, "include_generated", false); err != nil {
+		return badArgError(err).result(), nil
+	}
+	languages, err := getNonEmptyStrings(request.Params.Arguments, "languages")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	for _, language := range languages {
+		opts.Languages = append(opts.Languages, strings.ToLower(language))
+	}
+	if opts.ExcludePatterns, err = getNonEmptyStrings(request.Params.Arguments, "exclude_patterns"); err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	if info, err := os.Stat(validPath); err != nil || !info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: Path must be a directory"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	ctx, warnings := withWalkWarnings(ctx)
+	report, err := fs.findDuplicateCode(ctx, validPath, opts)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Duplicate code detection error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	report.Warnings = warnings.entries()
+
+	if output == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: string(data)},
+			},
+		}, nil
+	}
+
+	summary := fmt.Sprintf("📊 Scanned %d files (%d significant lines, window of %d lines)\n", report.FilesScanned, report.LinesScanned, report.Window)
+	if report.Truncated {
+		summary += fmt.Sprintf("⚠️ Stopped tracking candidates at %d windows; narrow the path or exclude_patterns for a complete scan\n", maxDuplicateCodeWindows)
+	}
+	if len(report.Blocks) == 0 {
+		return withStructuredContent(&mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "✅ No duplicate code blocks found\n" + summary + warnings.note(nil)},
+			},
+		}, pathToResourceURI(validPath), report)
+	}
+
+	// Cada bloque es un elemento; el resumen se muestra siempre
+	result := fs.newResultBuilder()
+	result.WriteString(fmt.Sprintf("🔍 Found %d duplicate code block(s), showing the %d largest:\n\n", report.TotalBlocks, len(report.Blocks)))
+	for i, block := range report.Blocks {
+		var entry strings.Builder
+		entry.WriteString(fmt.Sprintf("%d. 📋 %d lines × %d occurrences\n", i+1, block.Lines, len(block.Locations)))
+		for _, location := range block.Locations {
+			entry.WriteString(fmt.Sprintf("   📄 %s:%d-%d\n", location.File, location.StartLine, location.EndLine))
+		}
+		for _, line := range strings.Split(block.Snippet, "\n") {
+			entry.WriteString("   │ " + line + "\n")
+		}
+		entry.WriteString("\n")
+		result.item(entry.String())
+	}
+	result.tail(summary)
+	result.tail(warnings.note(nil))
+
+	text, spill := result.done(validPath, "find_duplicate_code")
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: append([]mcp.Content{
+			mcp.TextContent{Type: "text", Text: text},
+		}, spill...),
+	}, pathToResourceURI(validPath), report)
+}
+
+// findDuplicateCode - Recorre root guardando los hashes de ventana de cada
+// archivo de código y agrupa las ventanas repetidas en bloques máximos
+func (fs *FilesystemHandler) findDuplicateCode(ctx context.Context, root string, opts duplicateCodeOptions) (*DuplicateCodeReport, error) {
+	report := &DuplicateCodeReport{Path: root, Window: opts.Window, Blocks: []DuplicateCodeBlock{}}
+
+	// Primera pasada: hashes de ventana por archivo y un contador saturado por
+	// cubeta. Solo las ventanas de cubetas vistas dos veces se guardan después
+	// con su posición, lo que limita la memoria al número de candidatas.
+	buckets := make([]uint8, duplicateCodeBuckets)
+	var files []*codeFile
+	err := fs.walkContext(ctx, root, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			fs.logWalkError(ctx, currentPath, err)
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if currentPath != root && (fs.shouldIgnoreEntry(ctx, currentPath, info.IsDir()) || matchesAnyPattern(root, currentPath, opts.ExcludePatterns)) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() || info.Size() == 0 || info.Size() > maxDuplicateCodeFileSize {
+			return nil
+		}
+		language, _ := languageDetector.Detect(currentPath, "")
+		if language == unknownLanguage || !isTextFile(detectMimeType(currentPath)) {
+			return nil
+		}
+		if len(opts.Languages) > 0 && !containsString(opts.Languages, language.Key) && !containsString(opts.Languages, strings.ToLower(language.Name)) {
+			return nil
+		}
+		if _, err := fs.validatePath(currentPath); err != nil {
+			return nil
+		}
+		if !opts.IncludeGenerated && fs.generatedReason(currentPath) != "" {
+			return nil
+		}
+		data, err := fs.readFile(currentPath)
+		if err != nil {
+			fs.logWalkError(ctx, currentPath, err)
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, currentPath)
+		file := hashCodeFile(string(data), opts)
+		file.path, file.rel, file.language = currentPath, filepath.ToSlash(rel), language.Key
+		report.FilesScanned++
+		report.LinesScanned += len(file.lines)
+		if len(file.windows) == 0 {
+			return nil
+		}
+		for _, hash := range file.windows {
+			if bucket := hash % duplicateCodeBuckets; buckets[bucket] < 2 {
+				buckets[bucket]++
+			}
+		}
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Segunda pasada: posiciones de las ventanas candidatas
+	candidates := make(map[uint64][]codeWindowRef)
+	stored := 0
+collect:
+	for f, file := range files {
+		for i, hash := range file.windows {
+			if buckets[hash%duplicateCodeBuckets] < 2 {
+				continue
+			}
+			if stored >= maxDuplicateCodeWindows {
+				report.Truncated = true
+				break collect
+			}
+			candidates[hash] = append(candidates[hash], codeWindowRef{file: int32(f), index: int32(i)})
+			stored++
+		}
+	}
+
+	// Grupos de ventanas repetidas, sin solapes dentro de un mismo archivo
+	groups := make(map[uint64][]codeWindowRef)
+	for hash, refs := range candidates {
+		var kept []codeWindowRef
+		for _, ref := range refs {
+			if n := len(kept); n > 0 && kept[n-1].file == ref.file && int(ref.index-kept[n-1].index) < opts.Window {
+				continue
+			}
+			kept = append(kept, ref)
+		}
+		if len(kept) > 1 {
+			groups[hash] = kept
+		}
+	}
+
+	var blocks []DuplicateCodeBlock
+	for _, refs := range groups {
+		if !startsDuplicateBlock(files, groups, refs) {
+			continue
+		}
+		blocks = append(blocks, extendDuplicateBlock(files, refs, opts.Window))
+	}
+
+	sort.Slice(blocks, func(i, j int) bool {
+		a, b := blocks[i], blocks[j]
+		if a.Lines != b.Lines {
+			return a.Lines > b.Lines
+		}
+		if len(a.Locations) != len(b.Locations) {
+			return len(a.Locations) > len(b.Locations)
+		}
+		if a.Locations[0].File != b.Locations[0].File {
+			return a.Locations[0].File < b.Locations[0].File
+		}
+		return a.Locations[0].StartLine < b.Locations[0].StartLine
+	})
+	report.TotalBlocks = len(blocks)
+	if len(blocks) > opts.MaxResults {
+		blocks = blocks[:opts.MaxResults]
+	}
+	for i := range blocks {
+		location := blocks[i].Locations[0]
+		blocks[i].Snippet = codeSnippet(filepath.Join(root, filepath.FromSlash(location.File)), location.StartLine, location.EndLine)
+	}
+	report.Blocks = append(report.Blocks, blocks...)
+	return report, nil
+}
+
+// hashCodeFile - Normaliza las líneas de content (sin espacios sobrantes, sin
+// líneas vacías ni de solo llaves) y calcula el hash de cada ventana
+func hashCodeFile(content string, opts duplicateCodeOptions) *codeFile {
+	lines := strings.Split(content, "\n")
+	skip := opts.SkipPrefixLines
+	if skip < 0 {
+		skip = licenseHeaderLines(lines)
+	}
+
+	file := &codeFile{}
+	var lineHashes []uint64
+	for i, line := range lines {
+		if i < skip {
+			continue
+		}
+		normalized := strings.Join(strings.Fields(line), " ")
+		if isTrivialCodeLine(normalized) {
+			continue
+		}
+		h := fnv.New64a()
+		h.Write([]byte(normalized))
+		lineHashes = append(lineHashes, h.Sum64())
+		file.lines = append(file.lines, int32(i+1))
+	}
+
+	for start := 0; start+opts.Window <= len(lineHashes); start++ {
+		h := fnv.New64a()
+		var buf [8]byte
+		for _, lineHash := range lineHashes[start : start+opts.Window] {
+			for b := range buf {
+				buf[b] = byte(lineHash >> (8 * b))
+			}
+			h.Write(buf[:])
+		}
+		file.windows = append(file.windows, h.Sum64())
+	}
+	return file
+}
+
+// isTrivialCodeLine - Línea normalizada vacía o de solo llaves, paréntesis y separadores
+func isTrivialCodeLine(line string) bool {
+	return strings.Trim(line, "{}()[];, ") == ""
+}
+
+// licenseHeaderLines - Líneas del bloque de comentarios inicial, donde suelen
+// ir las licencias; 0 si el archivo no empieza por un comentario
+func licenseHeaderLines(lines []string) int {
+	header := 0
+	for i, line := range lines {
+		if i >= maxLicenseHeaderLines {
+			return 0
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		comment := false
+		for _, prefix := range commentLinePrefixes {
+			if strings.HasPrefix(trimmed, prefix) {
+				comment = true
+				break
+			}
+		}
+		if !comment {
+			return header
+		}
+		header = i + 1
+	}
+	return header
+}
+
+// startsDuplicateBlock - Un grupo empieza un bloque salvo que las ventanas
+// anteriores de todas sus posiciones formen un grupo con las mismas posiciones
+func startsDuplicateBlock(files []*codeFile, groups map[uint64][]codeWindowRef, refs []codeWindowRef) bool {
+	var previous uint64
+	for i, ref := range refs {
+		if ref.index == 0 {
+			return true
+		}
+		hash := files[ref.file].windows[ref.index-1]
+		if i > 0 && hash != previous {
+			return true
+		}
+		previous = hash
+	}
+	return len(groups[previous]) != len(refs)
+}
+
+// extendDuplicateBlock - Alarga el bloque mientras la ventana siguiente
+// coincida en todas las posiciones y ninguna alcance a la siguiente del mismo archivo
+func extendDuplicateBlock(files []*codeFile, refs []codeWindowRef, window int) DuplicateCodeBlock {
+	extra := 0
+extend:
+	for {
+		next := extra + 1
+		var hash uint64
+		for i, ref := range refs {
+			windows := files[ref.file].windows
+			if int(ref.index)+next >= len(windows) {
+				break extend
+			}
+			if i > 0 && windows[int(ref.index)+next] != hash {
+				break extend
+			}
+			hash = windows[int(ref.index)+next]
+			if i+1 < len(refs) && refs[i+1].file == ref.file && int(ref.index)+next+window > int(refs[i+1].index) {
+				break extend
+			}
+		}
+		extra = next
+	}
+
+	block := DuplicateCodeBlock{Lines: window + extra}
+	for _, ref := range refs {
+		file := files[ref.file]
+		start, end := file.lines[ref.index], file.lines[int(ref.index)+extra+window-1]
+		block.Locations = append(block.Locations, DuplicateCodeLocation{
+			File:      file.rel,
+			Language:  file.language,
+			StartLine: int(start),
+			EndLine:   int(end),
+			Length:    int(end - start + 1),
+		})
+	}
+	return block
+}
+
+// codeSnippet - Primeras líneas originales de un bloque
+func codeSnippet(path string, start, end int) string {
+	lines, err := readFileLines(path)
+	if err != nil || start > len(lines) {
+		return ""
+	}
+	end = minInt(end, len(lines))
+	snippet := lines[start-1 : minInt(end, start-1+duplicateCodeSnippetLines)]
+	text := strings.Join(snippet, "\n")
+	if end-start+1 > duplicateCodeSnippetLines {
+		text += fmt.Sprintf("\n… %d more line(s)", end-start+1-duplicateCodeSnippetLines)
+	}
+	return text
+}
@@ -0,0 +1,127 @@
+package filesystemserver
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dupCodeLicense = "// Copyright 2026 Example Authors\n// Licensed under the MIT license.\n// See LICENSE for details.\n// SPDX-License-Identifier: MIT\n\n"
+
+const dupCodeFunction = `func process(items []string) int {
+	total := 0
+	for _, item := range items {
+		if item == "" {
+			continue
+		}
+		total += len(item)
+		log.Println(item)
+	}
+	fmt.Println("total", total)
+	return total
+}
+`
+
+// runDuplicateCode - Llama a find_duplicate_code y decodifica el informe
+func runDuplicateCode(t *testing.T, handler *FilesystemHandler, args map[string]interface{}) (*mcp.CallToolResult, DuplicateCodeReport) {
+	t.Helper()
+	result, err := handler.handleFindDuplicateCode(context.Background(), newToolRequest("find_duplicate_code", args))
+	require.NoError(t, err)
+	var report DuplicateCodeReport
+	decodeStructured(t, result, &report)
+	return result, report
+}
+
+func TestFindDuplicateCode(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	// b.go tiene el mismo código con otra indentación y espacios de más
+	reindented := strings.ReplaceAll(strings.ReplaceAll(dupCodeFunction, "\t", "    "), "total := 0", "total  :=   0")
+	writeFixture(t, dir, map[string]string{
+		"a.go":                dupCodeLicense + "package a\n\nimport \"fmt\"\n\n" + dupCodeFunction + "\nfunc other() {}\n",
+		"pkg/b.go":            dupCodeLicense + "package b\n\n" + reindented + "\nfunc unrelated() int { return 1 }\n",
+		"notes.dat":           dupCodeFunction + dupCodeFunction,
+		"node_modules/dep.js": dupCodeFunction,
+	})
+
+	result, report := runDuplicateCode(t, handler, map[string]interface{}{"path": dir})
+	assert.Equal(t, 2, report.FilesScanned)
+	assert.Equal(t, 1, report.TotalBlocks)
+	require.Len(t, report.Blocks, 1)
+	block := report.Blocks[0]
+	assert.Equal(t, 9, block.Lines) // las llaves de cierre no cuentan
+	assert.Equal(t, []DuplicateCodeLocation{
+		{File: "a.go", Language: "go", StartLine: 10, EndLine: 20, Length: 11},
+		{File: "pkg/b.go", Language: "go", StartLine: 8, EndLine: 18, Length: 11},
+	}, block.Locations)
+	assert.True(t, strings.HasPrefix(block.Snippet, "func process(items []string) int {\n\ttotal := 0\n"))
+
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "1. 📋 9 lines × 2 occurrences\n   📄 a.go:10-20\n   📄 pkg/b.go:8-18\n   │ func process")
+	assert.Contains(t, text, "📊 Scanned 2 files")
+}
+
+func TestFindDuplicateCodeLicenseHeader(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{
+		"a.py": "# Copyright 2026 Example Authors\n# Licensed under the MIT license.\n# See LICENSE for details.\n\nimport os\nprint(os.getcwd())\n",
+		"b.py": "# Copyright 2026 Example Authors\n# Licensed under the MIT license.\n# See LICENSE for details.\n\nimport sys\nprint(sys.argv)\n",
+	})
+
+	// Por defecto la cabecera de comentarios se salta
+	_, report := runDuplicateCode(t, handler, map[string]interface{}{"path": dir, "window": float64(3)})
+	assert.Empty(t, report.Blocks)
+	assert.Equal(t, 4, report.LinesScanned)
+
+	_, report = runDuplicateCode(t, handler, map[string]interface{}{"path": dir, "window": float64(3), "skip_prefix_lines": float64(0)})
+	require.Len(t, report.Blocks, 1)
+	assert.Equal(t, 1, report.Blocks[0].Locations[0].StartLine)
+	assert.Equal(t, 3, report.Blocks[0].Locations[0].EndLine)
+}
+
+func TestFindDuplicateCodeWithinFile(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	chunk := "alpha()\nbeta()\ngamma()\ndelta()\n"
+	writeFixture(t, dir, map[string]string{"repeat.js": chunk + chunk})
+
+	_, report := runDuplicateCode(t, handler, map[string]interface{}{"path": dir, "window": float64(3)})
+	require.Len(t, report.Blocks, 1)
+	assert.Equal(t, 4, report.Blocks[0].Lines)
+	assert.Equal(t, []DuplicateCodeLocation{
+		{File: "repeat.js", Language: "javascript", StartLine: 1, EndLine: 4, Length: 4},
+		{File: "repeat.js", Language: "javascript", StartLine: 5, EndLine: 8, Length: 4},
+	}, report.Blocks[0].Locations)
+
+	// Las ventanas de una misma línea repetida no se solapan
+	writeFixture(t, dir, map[string]string{"repeat.js": strings.Repeat("count++\n", 9)})
+	_, report = runDuplicateCode(t, handler, map[string]interface{}{"path": dir, "window": float64(3)})
+	for _, block := range report.Blocks {
+		for i := 1; i < len(block.Locations); i++ {
+			assert.Greater(t, block.Locations[i].StartLine, block.Locations[i-1].EndLine)
+		}
+	}
+}
+
+func TestFindDuplicateCodeArguments(t *testing.T) {
+	handler, dir := newTestHandler(t)
+
+	text, isError := callText(t, handler.handleFindDuplicateCode, "find_duplicate_code", map[string]interface{}{"path": dir, "window": float64(2)})
+	assert.True(t, isError)
+	assert.Contains(t, text, "window must be at least 3 lines")
+
+	text, isError = callText(t, handler.handleFindDuplicateCode, "find_duplicate_code", map[string]interface{}{"path": dir})
+	assert.False(t, isError)
+	assert.Contains(t, text, "✅ No duplicate code blocks found")
+}
+
+func TestIsTrivialCodeLine(t *testing.T) {
+	for _, line := range []string{"", "}", "});", "]", "},", "{ }"} {
+		assert.True(t, isTrivialCodeLine(line), line)
+	}
+	for _, line := range []string{"return", "} else {", "x := []int{}"} {
+		assert.False(t, isTrivialCodeLine(line), line)
+	}
+}
@@ -0,0 +1,671 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Estados de un paso y de un plan en ejecución
+const (
+	stepDone       = "done"
+	stepFailed     = "failed"
+	stepSkipped    = "skipped"
+	stepRolledBack = "rolled_back"
+
+	planPaused      = "paused"
+	planAwaitingAck = "awaiting_ack"
+	planFailed      = "failed"
+	planCompleted   = "completed"
+	planRolledBack  = "rolled_back"
+)
+
+// planPlaceholderFiles - Marcadores que plan_task usa cuando no conoce los archivos
+var planPlaceholderFiles = []string{"*", "new files"}
+
+// planExecOptions - Límites de una llamada a execute_plan / resume_plan
+type planExecOptions struct {
+	PauseAfter      int
+	AcknowledgeRisk bool
+}
+
+// handleExecutePlan - Ejecuta un plan persistido o en línea paso a paso
+func (fs *FilesystemHandler) handleExecutePlan(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := getString(request.Params.Arguments, "id", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	workspace, err := getString(request.Params.Arguments, "workspace", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	inline, err := getObject(request.Params.Arguments, "plan")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	hasInline := inline != nil
+
+	if id == "" && !hasInline {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: id or plan is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var plan *TaskPlan
+	if hasInline {
+		plan, err = fs.inlinePlan(inline, workspace)
+	} else {
+		plan, err = fs.loadExecutablePlan(id, workspace)
+		if err == nil && planStarted(plan) {
+			err = fmt.Errorf("plan '%s' has already been started; use %s to continue it", plan.ID, fs.toolName("resume_plan"))
+		}
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	opts, err := planExecArgs(request)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	return fs.runPlan(ctx, plan, opts)
+}
+
+// handleResumePlan - Continúa un plan desde el último paso completado
+func (fs *FilesystemHandler) handleResumePlan(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := getString(request.Params.Arguments, "id", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	workspace, err := getString(request.Params.Arguments, "workspace", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if id == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: id is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	plan, err := fs.loadExecutablePlan(id, workspace)
+	if err == nil && (plan.Status == planCompleted || plan.Status == planRolledBack) {
+		err = fmt.Errorf("plan '%s' is %s; nothing to resume", plan.ID, plan.Status)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	opts, err := planExecArgs(request)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	return fs.runPlan(ctx, plan, opts)
+}
+
+// handleRollbackPlan - Deshace los pasos completados usando los cambios y backups registrados
+func (fs *FilesystemHandler) handleRollbackPlan(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := getString(request.Params.Arguments, "id", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	workspace, err := getString(request.Params.Arguments, "workspace", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if id == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: id is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	plan, err := fs.loadExecutablePlan(id, workspace)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	errs := fs.rollbackPlan(plan)
+	if _, err := fs.savePlan(plan); err != nil {
+		errs = append(errs, fmt.Sprintf("saving plan: %v", err))
+	}
+
+	text := formatPlanExecution(plan, "")
+	if len(errs) > 0 {
+		text += fmt.Sprintf("\n⚠️ %d rollback errors:\n", len(errs))
+		for _, e := range errs {
+			text += fmt.Sprintf("  • %s\n", e)
+		}
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: text},
+		},
+		IsError: len(errs) > 0,
+	}, nil
+}
+
+// planExecArgs - Lee pause_after_step y acknowledge_risk de la petición
+func planExecArgs(request mcp.CallToolRequest) (planExecOptions, error) {
+	var opts planExecOptions
+	pauseAfter, err := getInt(request.Params.Arguments, "pause_after_step", 0)
+	if err != nil {
+		return opts, err
+	}
+	opts.PauseAfter = max(pauseAfter, 0)
+	opts.AcknowledgeRisk, err = getBool(request.Params.Arguments, "acknowledge_risk", false)
+	return opts, err
+}
+
+// loadExecutablePlan - Carga un plan persistido del workspace validado
+func (fs *FilesystemHandler) loadExecutablePlan(id, workspace string) (*TaskPlan, error) {
+	validWorkspace, err := fs.resolvePlanWorkspace(workspace)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace: %v", err)
+	}
+	return fs.loadPlan(validWorkspace, id)
+}
+
+// inlinePlan - Convierte el argumento plan en un TaskPlan listo para persistir
+func (fs *FilesystemHandler) inlinePlan(raw map[string]interface{}, workspace string) (*TaskPlan, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var plan TaskPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("invalid plan: %v", err)
+	}
+	if len(plan.Steps) == 0 {
+		return nil, fmt.Errorf("plan has no steps")
+	}
+
+	if workspace == "" {
+		workspace = plan.Workspace
+	}
+	validWorkspace, err := fs.resolvePlanWorkspace(workspace)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace: %v", err)
+	}
+	plan.Workspace = validWorkspace
+
+	if plan.ID == "" {
+		plan.ID = generateTaskID()
+	} else if !planIDPattern.MatchString(plan.ID) {
+		return nil, fmt.Errorf("invalid plan id '%s'", plan.ID)
+	}
+	if plan.Created.IsZero() {
+		plan.Created = time.Now()
+	}
+	for i := range plan.Steps {
+		if plan.Steps[i].ID == 0 {
+			plan.Steps[i].ID = i + 1
+		}
+	}
+	plan.EstimatedOps = len(plan.Steps)
+	return &plan, nil
+}
+
+// planStarted - Indica si algún paso ya tiene estado registrado
+func planStarted(plan *TaskPlan) bool {
+	for _, step := range plan.Steps {
+		if step.Status != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// runPlan - Ejecuta el plan y devuelve el resumen como resultado de la herramienta
+func (fs *FilesystemHandler) runPlan(ctx context.Context, plan *TaskPlan, opts planExecOptions) (*mcp.CallToolResult, error) {
+	note, err := fs.executePlan(ctx, plan, opts)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatPlanExecution(plan, note)},
+		},
+		IsError: plan.Status == planFailed,
+	}, nil
+}
+
+// executePlan - Ejecuta los pasos pendientes en orden, guardando el estado tras cada uno.
+// Se detiene en el primer fallo, en un paso de alto riesgo sin confirmar o tras pause_after_step.
+func (fs *FilesystemHandler) executePlan(ctx context.Context, plan *TaskPlan, opts planExecOptions) (string, error) {
+	note := ""
+	plan.Status = planCompleted
+
+	// Re-measure: the files may have changed since the plan was created
+	fs.applyPlanRisk(ctx, plan)
+
+	for i := range plan.Steps {
+		step := &plan.Steps[i]
+		if step.Status == stepDone || step.Status == stepSkipped {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		if step.Risk == "high" && !opts.AcknowledgeRisk {
+			plan.Status = planAwaitingAck
+			note = fmt.Sprintf("Step %d is high risk; review it and call %s with acknowledge_risk=true", step.ID, fs.toolName("resume_plan"))
+			if plan.Risk != nil && len(plan.Risk.Reasons) > 0 && plan.Risk.Level == "high" {
+				note += fmt.Sprintf(" (%s)", strings.Join(plan.Risk.Reasons, "; "))
+			}
+			break
+		}
+
+		step.Output, step.Changes, step.Error = nil, nil, ""
+		err := fs.executeStep(ctx, plan, step)
+		now := time.Now()
+		step.Completed = &now
+		if err != nil {
+			step.Status = stepFailed
+			step.Error = err.Error()
+			plan.Status = planFailed
+			note = fmt.Sprintf("Step %d failed; fix the cause and call %s, or %s to undo", step.ID, fs.toolName("resume_plan"), fs.toolName("rollback_plan"))
+			break
+		}
+		if step.Status == "" {
+			step.Status = stepDone
+		}
+
+		if _, err := fs.savePlan(plan); err != nil {
+			return "", fmt.Errorf("saving plan after step %d: %v", step.ID, err)
+		}
+
+		if opts.PauseAfter > 0 && step.ID >= opts.PauseAfter && i < len(plan.Steps)-1 {
+			plan.Status = planPaused
+			note = fmt.Sprintf("Paused after step %d; call %s to continue", step.ID, fs.toolName("resume_plan"))
+			break
+		}
+	}
+
+	if _, err := fs.savePlan(plan); err != nil {
+		return "", fmt.Errorf("saving plan: %v", err)
+	}
+	return note, nil
+}
+
+// executeStep - Traduce un paso a las operaciones de batch_operations
+func (fs *FilesystemHandler) executeStep(ctx context.Context, plan *TaskPlan, step *TaskStep) error {
+	stepType := strings.ToLower(step.Type)
+
+	switch stepType {
+	case "backup", "validate", "copy", "move", "delete", "create", "modify":
+	default:
+		step.Status = stepSkipped
+		step.Output = append(step.Output, fmt.Sprintf("'%s' is a manual step; no automatic action", step.Type))
+		return nil
+	}
+
+	for _, file := range step.Files {
+		if containsString(planPlaceholderFiles, file) {
+			return fmt.Errorf("step lists placeholder files (%q); replace them with concrete paths", file)
+		}
+	}
+	if len(step.Files) == 0 {
+		if stepType == "backup" || stepType == "validate" {
+			step.Output = append(step.Output, "No files listed")
+			return nil
+		}
+		if step.NeedsInput != "" {
+			return fmt.Errorf("step needs input: %s", step.NeedsInput)
+		}
+		return fmt.Errorf("step has no files")
+	}
+
+	files := make([]string, len(step.Files))
+	for i, file := range step.Files {
+		files[i] = planStepPath(plan, file)
+	}
+
+	switch stepType {
+	case "backup":
+		for _, file := range files {
+			backup, err := fs.ensurePlanBackup(ctx, plan, file)
+			if err != nil {
+				return err
+			}
+			step.Output = append(step.Output, fmt.Sprintf("Backed up %s → %s", file, backup))
+		}
+
+	case "validate":
+		for _, file := range files {
+			if err := fs.validatePlanFile(file); err != nil {
+				return err
+			}
+			step.Output = append(step.Output, fmt.Sprintf("Valid: %s", file))
+		}
+
+	case "copy", "move":
+		if step.Destination == "" {
+			return fmt.Errorf("%s step requires a destination", stepType)
+		}
+		destination := planStepPath(plan, step.Destination)
+		toDir := len(files) > 1 || strings.HasSuffix(step.Destination, "/")
+		if info, err := os.Stat(destination); err == nil && info.IsDir() {
+			toDir = true
+		} else if toDir {
+			if _, err := fs.processBatchCreateDir(&batchCreateDirOp{Path: destination}, 0); err != nil {
+				return err
+			}
+			step.Changes = append(step.Changes, PlanChange{Action: "created", Path: destination})
+		}
+
+		for i, file := range files {
+			target := destination
+			if toDir {
+				target = filepath.Join(destination, filepath.Base(file))
+			}
+			if _, err := os.Lstat(target); err == nil {
+				return fmt.Errorf("destination already exists: %s", target)
+			}
+
+			operation := map[string]interface{}{"type": stepType, "from": file, "to": target}
+			out, err := fs.processBatchOperation(ctx, operation, i+1)
+			if err != nil {
+				return err
+			}
+			step.Output = append(step.Output, strings.TrimSpace(out))
+			if stepType == "copy" {
+				step.Changes = append(step.Changes, PlanChange{Action: "created", Path: target})
+			} else {
+				step.Changes = append(step.Changes, PlanChange{Action: "moved", Path: target, Source: file})
+			}
+		}
+
+	case "delete":
+		for i, file := range files {
+			if _, err := os.Lstat(file); os.IsNotExist(err) {
+				step.Output = append(step.Output, fmt.Sprintf("Already deleted: %s", file))
+				continue
+			}
+			backup, err := fs.ensurePlanBackup(ctx, plan, file)
+			if err != nil {
+				return err
+			}
+			out, err := fs.processBatchOperation(ctx, map[string]interface{}{"type": "delete", "path": file, "recursive": true}, i+1)
+			if err != nil {
+				return err
+			}
+			step.Output = append(step.Output, strings.TrimSpace(out))
+			step.Changes = append(step.Changes, PlanChange{Action: "deleted", Path: file, Source: backup})
+		}
+
+	case "create":
+		for i, file := range files {
+			if _, err := os.Lstat(file); err == nil {
+				return fmt.Errorf("already exists: %s", file)
+			}
+			operation := map[string]interface{}{"type": "write", "path": file, "content": step.Content}
+			if strings.HasSuffix(step.Files[i], "/") {
+				operation = map[string]interface{}{"type": "mkdir", "path": file}
+			}
+			out, err := fs.processBatchOperation(ctx, operation, i+1)
+			if err != nil {
+				return err
+			}
+			step.Output = append(step.Output, strings.TrimSpace(out))
+			step.Changes = append(step.Changes, PlanChange{Action: "created", Path: file})
+		}
+
+	case "modify":
+		if step.OldText == "" {
+			return fmt.Errorf("modify step requires old_text")
+		}
+		for _, file := range files {
+			validPath, err := fs.validatePath(file)
+			if err != nil {
+				return err
+			}
+			content, err := fs.readFile(validPath)
+			if err != nil {
+				return err
+			}
+			edit, err := fs.performIntelligentEdit(string(content), step.OldText, step.NewText, nil)
+			if err != nil {
+				return fmt.Errorf("%s: %v", file, err)
+			}
+			if edit.ReplacementCount == 0 {
+				return fmt.Errorf("%s: old_text not found", file)
+			}
+
+			backup, err := fs.ensurePlanBackup(ctx, plan, validPath)
+			if err != nil {
+				return err
+			}
+			if err := fs.writeFileAtomic(validPath, edit.ModifiedContent); err != nil {
+				return err
+			}
+			step.Output = append(step.Output, fmt.Sprintf("Modified %s (%d replacements)", file, edit.ReplacementCount))
+			step.Changes = append(step.Changes, PlanChange{Action: "modified", Path: validPath, Source: backup})
+		}
+	}
+
+	return nil
+}
+
+// planStepPath - Las rutas relativas de un paso se resuelven contra el workspace
+func planStepPath(plan *TaskPlan, path string) string {
+	path = strings.TrimSuffix(path, "/")
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(plan.Workspace, path)
+}
+
+// ensurePlanBackup - Copia el archivo o directorio a <workspace>/.mcp-plans/<id>/backups
+// una sola vez por plan, de modo que el rollback restaure el estado original
+func (fs *FilesystemHandler) ensurePlanBackup(ctx context.Context, plan *TaskPlan, path string) (string, error) {
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return "", err
+	}
+	if backup, ok := plan.Backups[validPath]; ok {
+		if _, err := os.Lstat(backup); err == nil {
+			return backup, nil
+		}
+	}
+
+	backupDir := filepath.Join(plan.Workspace, planDirName, plan.ID, "backups")
+	if err := fs.mkdirAllChecked(backupDir, 0755); err != nil {
+		return "", err
+	}
+	backup, err := fs.validatePath(filepath.Join(backupDir, fmt.Sprintf("%03d-%s", len(plan.Backups)+1, filepath.Base(validPath))))
+	if err != nil {
+		return "", err
+	}
+	if _, err := fs.copyTreeChecked(ctx, validPath, backup, false); err != nil {
+		return "", fmt.Errorf("backup of %s failed: %v", path, err)
+	}
+
+	if plan.Backups == nil {
+		plan.Backups = make(map[string]string)
+	}
+	plan.Backups[validPath] = backup
+	return backup, nil
+}
+
+// validatePlanFile - Comprueba que el archivo existe y, para Go y JSON, que es sintácticamente válido
+func (fs *FilesystemHandler) validatePlanFile(path string) error {
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(validPath)
+	if err != nil {
+		return fmt.Errorf("%s does not exist", path)
+	}
+	if info.IsDir() {
+		return nil
+	}
+
+	switch strings.ToLower(filepath.Ext(validPath)) {
+	case ".go":
+		if _, err := parser.ParseFile(token.NewFileSet(), validPath, nil, parser.AllErrors); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	case ".json":
+		data, err := fs.readFile(validPath)
+		if err != nil {
+			return err
+		}
+		if !json.Valid(data) {
+			return fmt.Errorf("%s: invalid JSON", path)
+		}
+	}
+	return nil
+}
+
+// rollbackPlan - Deshace en orden inverso los cambios de los pasos completados
+func (fs *FilesystemHandler) rollbackPlan(plan *TaskPlan) []string {
+	var errs []string
+
+	for i := len(plan.Steps) - 1; i >= 0; i-- {
+		step := &plan.Steps[i]
+		if step.Status != stepDone && step.Status != stepFailed {
+			continue
+		}
+
+		failed := false
+		for j := len(step.Changes) - 1; j >= 0; j-- {
+			if err := fs.undoPlanChange(step.Changes[j]); err != nil {
+				errs = append(errs, fmt.Sprintf("step %d: %s %s: %v", step.ID, step.Changes[j].Action, step.Changes[j].Path, err))
+				failed = true
+			}
+		}
+		if !failed {
+			step.Status = stepRolledBack
+		}
+	}
+
+	if len(errs) == 0 {
+		plan.Status = planRolledBack
+	}
+	return errs
+}
+
+// undoPlanChange - Revierte un único cambio registrado
+func (fs *FilesystemHandler) undoPlanChange(change PlanChange) error {
+	path, err := fs.validatePath(change.Path)
+	if err != nil {
+		return err
+	}
+
+	var source string
+	switch change.Action {
+	case "created":
+	case "moved":
+		if source, err = fs.validatePath(change.Source); err != nil {
+			return err
+		}
+	case "modified", "deleted":
+		if change.Source == "" {
+			return fmt.Errorf("no backup recorded")
+		}
+		if source, err = fs.validatePath(change.Source); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown change")
+	}
+	return fs.revertPlanChange(change.Action, path, source)
+}
+
+// revertPlanChange - Deshace un cambio sobre rutas ya validadas; cada operación
+// vuelve a comprobarlas justo antes de tocar el disco. El rollback no se corta
+// a medias, por eso la copia no depende del contexto de la llamada
+func (fs *FilesystemHandler) revertPlanChange(action, path, source string) error {
+	switch action {
+	case "created":
+		return fs.removeChecked(path, true)
+	case "moved":
+		return fs.renameChecked(path, source)
+	default:
+		if err := fs.removeChecked(path, true); err != nil {
+			return err
+		}
+		_, err := fs.copyTreeChecked(context.Background(), source, path, false)
+		return err
+	}
+}
+
+// formatPlanExecution - Estado de cada paso tras ejecutar, reanudar o deshacer
+func formatPlanExecution(plan *TaskPlan, note string) string {
+	var b strings.Builder
+
+	status := plan.Status
+	if status == "" {
+		status = "pending"
+	}
+	b.WriteString(fmt.Sprintf("▶️ Plan %s: %s\n", plan.ID, plan.Description))
+	b.WriteString(fmt.Sprintf("📊 Status: %s\n\n", status))
+
+	for _, step := range plan.Steps {
+		emoji := "⏳"
+		switch step.Status {
+		case stepDone:
+			emoji = "✅"
+		case stepFailed:
+			emoji = "❌"
+		case stepSkipped:
+			emoji = "⏭️"
+		case stepRolledBack:
+			emoji = "↩️"
+		}
+		b.WriteString(fmt.Sprintf("%s %d. %s - %s [%s risk]\n", emoji, step.ID, strings.ToUpper(step.Type), step.Description, step.Risk))
+		for _, line := range step.Output {
+			b.WriteString(fmt.Sprintf("   %s\n", line))
+		}
+		if step.Error != "" {
+			b.WriteString(fmt.Sprintf("   ❌ %s\n", step.Error))
+		}
+	}
+
+	if note != "" {
+		b.WriteString(fmt.Sprintf("\n💡 %s\n", note))
+	}
+	return b.String()
+}
@@ -0,0 +1,127 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func executablePlan() map[string]interface{} {
+	return map[string]interface{}{
+		"description": "move config and tweak main",
+		"steps": []interface{}{
+			map[string]interface{}{"type": "backup", "files": []interface{}{"main.go"}, "risk": "low"},
+			map[string]interface{}{"type": "modify", "files": []interface{}{"main.go"}, "old_text": "old()", "new_text": "renamed()", "risk": "medium"},
+			map[string]interface{}{"type": "copy", "files": []interface{}{"config.json"}, "destination": "conf/", "risk": "low"},
+			map[string]interface{}{"type": "analyze", "files": []interface{}{"*"}, "risk": "low"},
+			map[string]interface{}{"type": "delete", "files": []interface{}{"config.json"}, "risk": "high"},
+			map[string]interface{}{"type": "create", "files": []interface{}{"docs/NOTES.md"}, "content": "notes\n", "risk": "low"},
+			map[string]interface{}{"type": "validate", "files": []interface{}{"main.go", "conf/config.json"}, "risk": "low"},
+		},
+	}
+}
+
+func TestExecuteResumeAndRollbackPlan(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	mainSrc := "package main\n\nfunc old() {}\n\nfunc main() { old() }\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(mainSrc), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"a": 1}`), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docs"), 0755))
+	ctx := context.Background()
+
+	result, err := handler.handleExecutePlan(ctx, newToolRequest("execute_plan", map[string]interface{}{
+		"plan":             executablePlan(),
+		"workspace":        dir,
+		"pause_after_step": float64(2),
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Paused after step 2")
+
+	plans, err := handler.listPlans(dir)
+	require.NoError(t, err)
+	require.Len(t, plans, 1)
+	plan := plans[0]
+	assert.Equal(t, planPaused, plan.Status)
+	assert.Equal(t, stepDone, plan.Steps[1].Status)
+	assert.Empty(t, plan.Steps[2].Status)
+
+	data, err := os.ReadFile(filepath.Join(dir, "main.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "renamed()")
+
+	// El paso de alto riesgo requiere confirmación
+	result, err = handler.handleResumePlan(ctx, newToolRequest("resume_plan", map[string]interface{}{
+		"id": plan.ID, "workspace": dir,
+	}))
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "acknowledge_risk=true")
+	_, err = os.Stat(filepath.Join(dir, "config.json"))
+	require.NoError(t, err)
+
+	result, err = handler.handleResumePlan(ctx, newToolRequest("resume_plan", map[string]interface{}{
+		"id": plan.ID, "workspace": dir, "acknowledge_risk": true,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+	plan, err = handler.loadPlan(dir, plan.ID)
+	require.NoError(t, err)
+	assert.Equal(t, planCompleted, plan.Status)
+	assert.Equal(t, stepSkipped, plan.Steps[3].Status)
+	_, err = os.Stat(filepath.Join(dir, "config.json"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "docs", "NOTES.md"))
+	require.NoError(t, err)
+
+	// Un plan iniciado no se vuelve a ejecutar desde el principio
+	result, err = handler.handleExecutePlan(ctx, newToolRequest("execute_plan", map[string]interface{}{
+		"id": plan.ID, "workspace": dir,
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	result, err = handler.handleRollbackPlan(ctx, newToolRequest("rollback_plan", map[string]interface{}{
+		"id": plan.ID, "workspace": dir,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+	data, err = os.ReadFile(filepath.Join(dir, "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, mainSrc, string(data))
+	data, err = os.ReadFile(filepath.Join(dir, "config.json"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"a": 1}`, string(data))
+	for _, gone := range []string{"conf", "docs/NOTES.md"} {
+		_, err = os.Stat(filepath.Join(dir, gone))
+		assert.True(t, os.IsNotExist(err), gone)
+	}
+}
+
+func TestExecutePlanStopsAtFailure(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644))
+
+	plan := map[string]interface{}{
+		"description": "copy and create",
+		"steps": []interface{}{
+			map[string]interface{}{"type": "create", "files": []interface{}{"new files"}, "risk": "low"},
+			map[string]interface{}{"type": "copy", "files": []interface{}{"a.txt"}, "destination": "b.txt", "risk": "low"},
+		},
+	}
+	result, err := handler.handleExecutePlan(context.Background(), newToolRequest("execute_plan", map[string]interface{}{
+		"plan": plan, "workspace": dir,
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "placeholder files")
+
+	_, err = os.Stat(filepath.Join(dir, "b.txt"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,135 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleExtractText - Devuelve el texto de un PDF (solo la capa de texto) o de un .docx
+func (fs *FilesystemHandler) handleExtractText(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	if info, err := os.Stat(validPath); err != nil || info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: Path must be a file"},
+			},
+			IsError: true,
+		}, nil
+	}
+	if !isExtractableDocument(validPath) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: unsupported document type %q (use .pdf or .docx)", filepath.Ext(validPath))},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	text, truncated, err := extractDocumentText(ctx, validPath)
+	progressFrom(ctx).read(validPath, int64(len(text)))
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: extraction failed: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	header := fmt.Sprintf("📄 Text of %s (%d characters)\n", safeDisplayName(validPath), len([]rune(text)))
+	if truncated {
+		header += fmt.Sprintf("✂️ Truncated at %d bytes\n", maxExtractedText)
+	}
+	summary := func() string {
+		return header + fmt.Sprintf("Preview:\n%s", strings.Join(firstLines(text, 20), "\n"))
+	}
+	if spilled := fs.oversizedResult(validPath, "extract_text", len(header)+len(text), []byte(text), "text", summary); spilled != nil {
+		return spilled, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: header + "\n" + text},
+		},
+	}, nil
+}
+
+// firstLines - Las primeras n líneas de text
+func firstLines(text string, n int) []string {
+	lines := strings.SplitN(text, "\n", n+1)
+	return lines[:min(len(lines), n)]
+}
+
+// documentSearch - Extracción de documentos durante una búsqueda con
+// extract_documents; nil deja los documentos fuera como hasta ahora
+type documentSearch struct {
+	ctx     context.Context
+	skipped []skippedDocument
+}
+
+// skippedDocument - Documento cuya extracción falló
+type skippedDocument struct {
+	path string
+	err  error
+}
+
+// newDocumentSearch - documentSearch activa si enabled
+func newDocumentSearch(ctx context.Context, enabled bool) *documentSearch {
+	if !enabled {
+		return nil
+	}
+	return &documentSearch{ctx: ctx}
+}
+
+// text - Texto extraído de path; ok es false si path no es un documento o la
+// extracción falló, que queda anotada como omitido sin detener la búsqueda
+func (d *documentSearch) text(path string) (string, bool) {
+	if d == nil || !isExtractableDocument(path) {
+		return "", false
+	}
+	text, _, err := extractDocumentText(d.ctx, path)
+	if err != nil {
+		d.skipped = append(d.skipped, skippedDocument{path: path, err: err})
+		return "", false
+	}
+	progressFrom(d.ctx).read(path, int64(len(text)))
+	return text, true
+}
+
+// writeSkipped - Lista los documentos que no se pudieron extraer
+func (d *documentSearch) writeSkipped(b io.Writer, display func(string) string) {
+	if d == nil || len(d.skipped) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n⚠️ Documents not searched (%d):\n", len(d.skipped))
+	for _, doc := range d.skipped {
+		fmt.Fprintf(b, "  📄 %s: skipped (extraction failed: %v)\n", safeDisplayName(display(doc.path)), doc.err)
+	}
+}
@@ -0,0 +1,75 @@
+package filesystemserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeDocuments creates a docx, a PDF, an encrypted PDF and a text file that
+// all mention "revenue"
+func writeDocuments(t *testing.T, dir string) {
+	t.Helper()
+	writeDocx(t, filepath.Join(dir, "memo.docx"), `<w:p><w:r><w:t>Intro</w:t></w:r></w:p><w:p><w:r><w:t>Revenue targets for Q3</w:t></w:r></w:p>`)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "report.pdf"), pdfDocument(pdfContent, true), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "locked.pdf"), append(pdfDocument(pdfContent, false), "/Encrypt"...), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("revenue notes\n"), 0644))
+}
+
+func TestExtractText(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeDocuments(t, dir)
+
+	text, isError := callText(t, handler.handleExtractText, "extract_text", map[string]interface{}{"path": filepath.Join(dir, "memo.docx")})
+	require.False(t, isError, text)
+	assert.Contains(t, text, "memo.docx (29 characters)\n\nIntro\nRevenue targets for Q3\n")
+
+	text, isError = callText(t, handler.handleExtractText, "extract_text", map[string]interface{}{"path": filepath.Join(dir, "report.pdf")})
+	require.False(t, isError, text)
+	assert.Contains(t, text, "Quarterly (Q3) report\nRevenue grew\n")
+
+	text, isError = callText(t, handler.handleExtractText, "extract_text", map[string]interface{}{"path": filepath.Join(dir, "locked.pdf")})
+	assert.True(t, isError)
+	assert.Equal(t, "❌ Error: extraction failed: encrypted PDF", text)
+
+	text, isError = callText(t, handler.handleExtractText, "extract_text", map[string]interface{}{"path": filepath.Join(dir, "notes.txt")})
+	assert.True(t, isError)
+	assert.Contains(t, text, "unsupported document type")
+}
+
+func TestSearchExtractDocuments(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeDocuments(t, dir)
+
+	args := map[string]interface{}{"path": dir, "pattern": "(?i)revenue", "include_content": true}
+	text, isError := callText(t, handler.handleSmartSearch, "smart_search", args)
+	require.False(t, isError)
+	assert.Contains(t, text, "Content matches (1)")
+	assert.NotContains(t, text, "memo.docx")
+
+	args["extract_documents"] = true
+	args["include_file_content"] = true
+	text, isError = callText(t, handler.handleSmartSearch, "smart_search", args)
+	require.False(t, isError)
+	assert.Contains(t, text, "Content matches (3)")
+	assert.Contains(t, text, "memo.docx:2 - Revenue targets for Q3")
+	assert.Contains(t, text, "report.pdf:2 - Revenue grew")
+	assert.Contains(t, text, "▶    2│ Revenue grew")
+	assert.Contains(t, text, "⚠️ Documents not searched (1):\n")
+	assert.Contains(t, text, "locked.pdf: skipped (extraction failed: encrypted PDF)")
+
+	text, isError = callText(t, handler.handleAdvancedTextSearch, "advanced_text_search", map[string]interface{}{
+		"path": dir, "pattern": "grew", "extract_documents": true,
+	})
+	require.False(t, isError)
+	assert.Contains(t, text, "Found 1 matches")
+	assert.Contains(t, text, "report.pdf:2\n   Revenue grew\n")
+	assert.Contains(t, text, "locked.pdf: skipped (extraction failed: encrypted PDF)")
+
+	text, _ = callText(t, handler.handleAdvancedTextSearch, "advanced_text_search", map[string]interface{}{"path": dir, "pattern": "grew"})
+	assert.Contains(t, text, "No matches found")
+	assert.NotContains(t, text, "skipped")
+}
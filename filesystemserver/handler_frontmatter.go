@@ -0,0 +1,421 @@
+package filesystemserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"gopkg.in/yaml.v3"
+)
+
+// maxFrontmatterFileSize - Tamaño máximo de los documentos que se leen o modifican
+const maxFrontmatterFileSize = 10 * 1024 * 1024
+
+// atxHeadingPattern - Encabezado Markdown "# Título" (hasta tres espacios delante,
+// almohadillas de cierre opcionales)
+var atxHeadingPattern = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+
+// markdownDocument - Documento partido en front-matter y cuerpo; concatenar
+// bom, open, yaml, close y body devuelve exactamente el archivo original
+type markdownDocument struct {
+	bom     string
+	open    string // "---" con su salto de línea
+	yaml    string
+	close   string // "---" o "..." con su salto de línea, si lo tiene
+	body    string
+	newline string
+	present bool
+}
+
+// handleGetFrontmatter - Front-matter YAML, primer H1 y esquema de encabezados de un Markdown
+func (fs *FilesystemHandler) handleGetFrontmatter(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	doc, _, err := readMarkdownDocument(validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	fs.stats.addRead(len(doc.String()))
+
+	info := &FrontmatterInfo{Path: validPath, Present: doc.present, Keys: []string{}, Headings: []MarkdownHeading{}}
+	if doc.present {
+		root, err := doc.mapping()
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+				},
+				IsError: true,
+			}, nil
+		}
+		if err := root.Decode(&info.Frontmatter); err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: invalid front-matter: %v", err)},
+				},
+				IsError: true,
+			}, nil
+		}
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			info.Keys = append(info.Keys, root.Content[i].Value)
+		}
+	}
+	info.Headings = markdownHeadings(doc.body, doc.bodyStartLine())
+	for _, heading := range info.Headings {
+		if heading.Level == 1 {
+			info.Title = heading.Text
+			break
+		}
+	}
+
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatFrontmatterInfo(info)},
+		},
+	}, pathToResourceURI(validPath), info)
+}
+
+// handleSetFrontmatter - Cambia o borra claves del front-matter sin tocar el cuerpo
+func (fs *FilesystemHandler) handleSetFrontmatter(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	set, err := getObject(request.Params.Arguments, "set")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	deleteKeys, err := getArray(request.Params.Arguments, "delete")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if path == "" || (len(set) == 0 && len(deleteKeys) == 0) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path and at least one of set or delete are required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var keys []string
+	for _, key := range deleteKeys {
+		name, ok := key.(string)
+		if !ok || name == "" {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: invalid key to delete: %v", key)},
+				},
+				IsError: true,
+			}, nil
+		}
+		keys = append(keys, name)
+	}
+
+	doc, perm, err := readMarkdownDocument(validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	original := doc.String()
+	if err := doc.update(set, keys); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	content := doc.String()
+	if content == original {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("ℹ️ Front-matter of %s already up to date, nothing written", path)},
+			},
+		}, nil
+	}
+
+	if err := fs.replaceFileChecked(validPath, content, perm); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error writing file: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	setKeys := make([]string, 0, len(set))
+	for key := range set {
+		setKeys = append(setKeys, key)
+	}
+	sort.Strings(setKeys)
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("✅ Front-matter updated: %s\n", path))
+	if len(setKeys) > 0 {
+		b.WriteString(fmt.Sprintf("Set: %s\n", strings.Join(setKeys, ", ")))
+	}
+	if len(keys) > 0 {
+		b.WriteString(fmt.Sprintf("Deleted: %s\n", strings.Join(keys, ", ")))
+	}
+	if !doc.present {
+		b.WriteString("Front-matter block removed (no keys left)\n")
+	}
+	b.WriteString("Body unchanged")
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: b.String()},
+		},
+	}, nil
+}
+
+// readMarkdownDocument - Lee el archivo y lo parte en front-matter y cuerpo
+func readMarkdownDocument(path string) (*markdownDocument, os.FileMode, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	if info.IsDir() {
+		return nil, 0, fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size() > maxFrontmatterFileSize {
+		return nil, 0, fmt.Errorf("%s is %d bytes, the limit is %d", path, info.Size(), maxFrontmatterFileSize)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	return splitFrontmatter(string(data)), info.Mode().Perm(), nil
+}
+
+// splitFrontmatter - Separa el bloque inicial "---" ... "---" (o "..."). Sin línea
+// de cierre no hay front-matter y todo el archivo es cuerpo.
+func splitFrontmatter(content string) *markdownDocument {
+	doc := &markdownDocument{newline: "\n"}
+	doc.bom, content = splitBOM(content)
+	if i := strings.IndexByte(content, '\n'); i > 0 && content[i-1] == '\r' {
+		doc.newline = "\r\n"
+	}
+
+	open, rest, ok := cutLine(content)
+	if !ok || strings.TrimRight(open, " \t\r\n") != "---" {
+		doc.body = content
+		return doc
+	}
+	offset := 0
+	for offset < len(rest) {
+		line, _, _ := cutLine(rest[offset:])
+		if marker := strings.TrimRight(line, " \t\r\n"); marker == "---" || marker == "..." {
+			doc.open, doc.yaml, doc.close = open, rest[:offset], line
+			doc.body = rest[offset+len(line):]
+			doc.present = true
+			return doc
+		}
+		offset += len(line)
+	}
+	doc.body = content
+	return doc
+}
+
+// cutLine - Primera línea de s con su salto incluido y el resto; ok es false si s está vacío
+func cutLine(s string) (line, rest string, ok bool) {
+	if s == "" {
+		return "", "", false
+	}
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i+1], s[i+1:], true
+	}
+	return s, "", true
+}
+
+// String - Documento completo
+func (d *markdownDocument) String() string {
+	return d.bom + d.open + d.yaml + d.close + d.body
+}
+
+// bodyStartLine - Número de línea (desde 1) en el que empieza el cuerpo
+func (d *markdownDocument) bodyStartLine() int {
+	return 1 + strings.Count(d.open+d.yaml+d.close, "\n")
+}
+
+// mapping - Front-matter como nodo YAML de tipo mapa; un bloque vacío es un mapa vacío
+func (d *markdownDocument) mapping() (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(d.yaml), &doc); err != nil {
+		return nil, fmt.Errorf("invalid front-matter: %v", err)
+	}
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("front-matter is not a YAML mapping")
+	}
+	return root, nil
+}
+
+// update - Aplica set y delete manteniendo el orden de las claves existentes; las
+// nuevas se añaden al final en orden alfabético. Solo se reescribe el bloque YAML.
+func (d *markdownDocument) update(set map[string]interface{}, deleteKeys []string) error {
+	root, err := d.mapping()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var value yaml.Node
+		if err := value.Encode(set[name]); err != nil {
+			return fmt.Errorf("key %s: %v", name, err)
+		}
+		if i := mappingIndex(root, name); i >= 0 {
+			// Se conservan los comentarios del valor anterior
+			old := root.Content[i+1]
+			value.HeadComment, value.LineComment, value.FootComment = old.HeadComment, old.LineComment, old.FootComment
+			root.Content[i+1] = &value
+			continue
+		}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, &value)
+	}
+	for _, name := range deleteKeys {
+		if i := mappingIndex(root, name); i >= 0 {
+			root.Content = append(root.Content[:i], root.Content[i+2:]...)
+		}
+	}
+
+	if len(root.Content) == 0 {
+		d.open, d.yaml, d.close, d.present = "", "", "", false
+		return nil
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	d.yaml = strings.ReplaceAll(buf.String(), "\n", d.newline)
+	if !d.present {
+		d.open, d.close, d.present = "---"+d.newline, "---"+d.newline, true
+	}
+	return nil
+}
+
+// mappingIndex - Posición de la clave name en el mapa, o -1
+func mappingIndex(root *yaml.Node, name string) int {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// markdownHeadings - Encabezados ATX del cuerpo fuera de bloques de código
+func markdownHeadings(body string, firstLine int) []MarkdownHeading {
+	headings := []MarkdownHeading{}
+	fence := ""
+	for i, line := range strings.Split(body, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimLeft(line, " ")
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			for j := 3; j < len(trimmed) && trimmed[j] == fence[0]; j++ {
+				fence += fence[:1]
+			}
+			continue
+		}
+		if m := atxHeadingPattern.FindStringSubmatch(line); m != nil {
+			headings = append(headings, MarkdownHeading{Level: len(m[1]), Text: strings.TrimSpace(m[2]), Line: firstLine + i})
+		}
+	}
+	return headings
+}
+
+// formatFrontmatterInfo - Claves, título y esquema del documento
+func formatFrontmatterInfo(info *FrontmatterInfo) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📝 %s\n", info.Path))
+	if !info.Present {
+		b.WriteString("No front-matter block\n")
+	} else {
+		b.WriteString(fmt.Sprintf("Front-matter (%d keys):\n", len(info.Keys)))
+		for _, key := range info.Keys {
+			value, _ := yaml.Marshal(info.Frontmatter[key])
+			text := strings.TrimSpace(string(value))
+			if strings.Contains(text, "\n") {
+				text = "\n    " + strings.ReplaceAll(text, "\n", "\n    ")
+			}
+			b.WriteString(fmt.Sprintf("  %s: %s\n", key, text))
+		}
+	}
+	if info.Title != "" {
+		b.WriteString(fmt.Sprintf("Title: %s\n", info.Title))
+	}
+	if len(info.Headings) > 0 {
+		b.WriteString("\nOutline:\n")
+		for _, heading := range info.Headings {
+			b.WriteString(fmt.Sprintf("%s- %s (line %d)\n", strings.Repeat("  ", heading.Level-1), heading.Text, heading.Line))
+		}
+	}
+	return b.String()
+}
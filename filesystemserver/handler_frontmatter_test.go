@@ -0,0 +1,156 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const markdownSample = "---\n" +
+	"title: Hello\n" +
+	"draft: true # pending review\n" +
+	"tags: [go, mcp]\n" +
+	"---\n" +
+	"# Hello world\n" +
+	"\n" +
+	"Intro.\n" +
+	"\n" +
+	"```sh\n" +
+	"# not a heading\n" +
+	"```\n" +
+	"\n" +
+	"## Install ##\n" +
+	"### Linux\n"
+
+func TestGetFrontmatter(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "post.md")
+	require.NoError(t, os.WriteFile(path, []byte(markdownSample), 0644))
+
+	result, err := handler.handleGetFrontmatter(context.Background(), newToolRequest("get_frontmatter", map[string]interface{}{"path": path}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "Front-matter (3 keys)")
+	assert.Contains(t, text, "Title: Hello world")
+	assert.Contains(t, text, "  - Install (line 14)")
+
+	var info FrontmatterInfo
+	decodeStructured(t, result, &info)
+	assert.True(t, info.Present)
+	assert.Equal(t, []string{"title", "draft", "tags"}, info.Keys)
+	assert.Equal(t, true, info.Frontmatter["draft"])
+	assert.Equal(t, []interface{}{"go", "mcp"}, info.Frontmatter["tags"])
+	assert.Equal(t, []MarkdownHeading{
+		{Level: 1, Text: "Hello world", Line: 6},
+		{Level: 2, Text: "Install", Line: 14},
+		{Level: 3, Text: "Linux", Line: 15},
+	}, info.Headings)
+
+	// Sin front-matter, o con un bloque sin cerrar, todo es cuerpo
+	for _, content := range []string{"# Plain\n", "---\ntitle: open\n# Plain\n"} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		result, err = handler.handleGetFrontmatter(context.Background(), newToolRequest("get_frontmatter", map[string]interface{}{"path": path}))
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "No front-matter block")
+		decodeStructured(t, result, &info)
+		assert.False(t, info.Present)
+		assert.Equal(t, "Plain", info.Title)
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte("---\n- a\n- b\n---\nbody"), 0644))
+	result, err = handler.handleGetFrontmatter(context.Background(), newToolRequest("get_frontmatter", map[string]interface{}{"path": path}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "not a YAML mapping")
+}
+
+func TestSetFrontmatter(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "post.md")
+	require.NoError(t, os.WriteFile(path, []byte(markdownSample), 0640))
+
+	result, err := handler.handleSetFrontmatter(context.Background(), newToolRequest("set_frontmatter", map[string]interface{}{
+		"path":   path,
+		"set":    map[string]interface{}{"draft": false, "date": "2024-03-01", "author": "ana"},
+		"delete": []interface{}{"tags"},
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	body := markdownSample[strings.Index(markdownSample, "# Hello"):]
+	assert.Equal(t, "---\ntitle: Hello\ndraft: false # pending review\nauthor: ana\ndate: \"2024-03-01\"\n---\n"+body, string(data))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	assert.Empty(t, tempFilesIn(t, dir))
+
+	// Repetir la llamada no escribe nada
+	result, err = handler.handleSetFrontmatter(context.Background(), newToolRequest("set_frontmatter", map[string]interface{}{
+		"path": path,
+		"set":  map[string]interface{}{"draft": false},
+	}))
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "already up to date")
+
+	// Sin claves restantes el bloque desaparece
+	result, err = handler.handleSetFrontmatter(context.Background(), newToolRequest("set_frontmatter", map[string]interface{}{
+		"path":   path,
+		"delete": []interface{}{"title", "draft", "author", "date"},
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(data))
+
+	result, err = handler.handleSetFrontmatter(context.Background(), newToolRequest("set_frontmatter", map[string]interface{}{"path": path}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestSetFrontmatterPreservesBody(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "win.md")
+
+	cases := []struct {
+		name, before, after string
+	}{
+		{
+			name:   "crlf without final newline",
+			before: "---\r\ntitle: Old\r\n---\r\n# Doc\r\n\r\ntext  \r\nlast line",
+			after:  "---\r\ntitle: New\r\n---\r\n# Doc\r\n\r\ntext  \r\nlast line",
+		},
+		{
+			name:   "new block keeps crlf",
+			before: "\ufeff# Doc\r\nno newline",
+			after:  "\ufeff---\r\ntitle: New\r\n---\r\n# Doc\r\nno newline",
+		},
+		{
+			name:   "closing marker at end of file",
+			before: "---\ntitle: Old\n---",
+			after:  "---\ntitle: New\n---",
+		},
+	}
+	for _, tc := range cases {
+		require.NoError(t, os.WriteFile(path, []byte(tc.before), 0644))
+		result, err := handler.handleSetFrontmatter(context.Background(), newToolRequest("set_frontmatter", map[string]interface{}{
+			"path": path,
+			"set":  map[string]interface{}{"title": "New"},
+		}))
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, tc.after, string(data), tc.name)
+	}
+}
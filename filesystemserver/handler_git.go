@@ -0,0 +1,501 @@
+package filesystemserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultGitMaxFiles - Rutas por lista que devuelve git_info si no se indica max_files
+const defaultGitMaxFiles = 200
+
+// gitModeGitlink - Modo de las entradas del índice que son submódulos
+const gitModeGitlink = 0160000
+
+// gitIndexEntry - Lo que git_info necesita de cada entrada de .git/index
+type gitIndexEntry struct {
+	MtimeSec  uint32
+	MtimeNsec uint32
+	Mode      uint32
+	Size      uint32
+	SHA       [20]byte
+	SkipWork  bool // skip-worktree: el archivo no tiene por qué existir
+}
+
+// gitIndex - Entradas del índice en stage 0, por ruta relativa con "/"
+type gitIndex map[string]gitIndexEntry
+
+// handleGitInfo - Rama, commit y archivos cambiados leyendo .git directamente (solo lectura)
+func (fs *FilesystemHandler) handleGitInfo(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	maxFiles, err := getInt(request.Params.Arguments, "max_files", 0)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if maxFiles <= 0 {
+		maxFiles = defaultGitMaxFiles
+	}
+
+	// Sin path se usa el workspace, contra el que se resuelven las rutas relativas
+	if path == "" {
+		path = "."
+	}
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	info, err := fs.gitInfo(ctx, validPath, maxFiles)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatGitInfo(info)},
+		},
+	}, dirResourceURI(info.WorkTree), info)
+}
+
+// gitInfo - Estado del repositorio que contiene scope, limitado a lo que hay bajo scope
+func (fs *FilesystemHandler) gitInfo(ctx context.Context, scope string, maxFiles int) (*GitInfo, error) {
+	gitDir, workTree := fs.findGitRepo(scope)
+	if gitDir == "" {
+		return nil, fmt.Errorf("%s is not inside a git repository within the allowed directories", scope)
+	}
+
+	info := &GitInfo{
+		WorkTree:  workTree,
+		Scope:     scope,
+		Modified:  []string{},
+		Deleted:   []string{},
+		Untracked: []string{},
+	}
+	info.Branch, info.Commit = readGitHead(gitDir)
+
+	index, err := readGitIndex(filepath.Join(gitDir, "index"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading git index: %v", err)
+	}
+
+	appendLimited := func(list *[]string, rel string) {
+		if len(*list) >= maxFiles {
+			info.Truncated = true
+			return
+		}
+		*list = append(*list, rel)
+	}
+
+	// Archivos registrados: borrados o con contenido distinto del blob del índice
+	names := make([]string, 0, len(index))
+	for name := range index {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	progress := progressFrom(ctx)
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		abs := filepath.Join(workTree, filepath.FromSlash(name))
+		if !pathWithinDir(abs, scope) || fs.isDeniedPath(abs) {
+			continue
+		}
+		progress.entry(abs)
+		switch index.fileStatus(name, abs) {
+		case "modified":
+			appendLimited(&info.Modified, name)
+		case "deleted":
+			appendLimited(&info.Deleted, name)
+		}
+	}
+
+	// Archivos sin registrar, respetando el .gitignore raíz y info/exclude
+	ignore := readGitIgnore(workTree, gitDir)
+	err = fs.walkContext(ctx, scope, func(currentPath string, fi os.FileInfo, err error) error {
+		if err != nil {
+			fs.logWalkError(ctx, currentPath, err)
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(workTree, currentPath)
+		rel = filepath.ToSlash(rel)
+		if fi.IsDir() {
+			if currentPath == scope {
+				return nil
+			}
+			if fi.Name() == ".git" || isServerDataDir(fi.Name()) || fs.isDeniedPath(currentPath) || gitIgnored(ignore, rel, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, tracked := index[rel]; tracked || fs.isDeniedPath(currentPath) || gitIgnored(ignore, rel, false) {
+			return nil
+		}
+		appendLimited(&info.Untracked, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// findGitRepo - Busca hacia arriba desde path el .git más cercano, sin salir de
+// los directorios permitidos; devuelve el directorio git y el árbol de trabajo
+func (fs *FilesystemHandler) findGitRepo(path string) (string, string) {
+	dir := path
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	for fs.isPathInAllowedDirs(dir) {
+		if gitDir := gitDirAt(dir); gitDir != "" {
+			return gitDir, dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return "", ""
+}
+
+// gitDirAt - Directorio git de dir: dir/.git, o el indicado por "gitdir:" si
+// .git es un archivo (worktrees y submódulos)
+func gitDirAt(dir string) string {
+	candidate := filepath.Join(dir, ".git")
+	info, err := os.Stat(candidate)
+	if err != nil {
+		return ""
+	}
+	if info.IsDir() {
+		return candidate
+	}
+	data, err := os.ReadFile(candidate)
+	if err != nil {
+		return ""
+	}
+	target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return ""
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(dir, target)
+	}
+	if info, err := os.Stat(target); err != nil || !info.IsDir() {
+		return ""
+	}
+	return filepath.Clean(target)
+}
+
+// readGitHead - Rama actual ("" si HEAD está desacoplado) y commit al que apunta
+// ("" en una rama sin commits)
+func readGitHead(gitDir string) (string, string) {
+	data, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "", ""
+	}
+	head := strings.TrimSpace(string(data))
+	ref, ok := strings.CutPrefix(head, "ref: ")
+	if !ok {
+		return "", head
+	}
+	return strings.TrimPrefix(ref, "refs/heads/"), resolveGitRef(gitDir, ref)
+}
+
+// resolveGitRef - Commit de una referencia, en refs/ o en packed-refs. Los
+// worktrees guardan las referencias compartidas en el directorio de commondir.
+func resolveGitRef(gitDir, ref string) string {
+	dirs := []string{gitDir}
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		common := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(gitDir, common)
+		}
+		dirs = append(dirs, common)
+	}
+
+	for _, dir := range dirs {
+		if data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(ref))); err == nil {
+			value := strings.TrimSpace(string(data))
+			// Una referencia simbólica apunta a otra
+			if target, ok := strings.CutPrefix(value, "ref: "); ok && target != ref {
+				return resolveGitRef(gitDir, target)
+			}
+			return value
+		}
+		file, err := os.Open(filepath.Join(dir, "packed-refs"))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if sha, name, ok := strings.Cut(line, " "); ok && name == ref && !strings.HasPrefix(line, "#") {
+				file.Close()
+				return sha
+			}
+		}
+		file.Close()
+	}
+	return ""
+}
+
+// readGitIndex - Lee las entradas en stage 0 de un índice de git en versión 2, 3 o 4
+func readGitIndex(path string) (gitIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 || string(data[:4]) != "DIRC" {
+		return nil, errors.New("not a git index (missing DIRC signature)")
+	}
+	version := binary.BigEndian.Uint32(data[4:8])
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("unsupported git index version %d", version)
+	}
+	count := binary.BigEndian.Uint32(data[8:12])
+
+	const fixed = 62 // stat data, sha and flags
+	index := make(gitIndex, count)
+	offset := 12
+	previous := ""
+	for i := uint32(0); i < count; i++ {
+		start := offset
+		if offset+fixed > len(data) {
+			return nil, errors.New("truncated git index")
+		}
+		entry := gitIndexEntry{
+			MtimeSec:  binary.BigEndian.Uint32(data[offset+8:]),
+			MtimeNsec: binary.BigEndian.Uint32(data[offset+12:]),
+			Mode:      binary.BigEndian.Uint32(data[offset+24:]),
+			Size:      binary.BigEndian.Uint32(data[offset+36:]),
+		}
+		copy(entry.SHA[:], data[offset+40:offset+60])
+		flags := binary.BigEndian.Uint16(data[offset+60:])
+		offset += fixed
+		if flags&0x4000 != 0 && version >= 3 {
+			if offset+2 > len(data) {
+				return nil, errors.New("truncated git index")
+			}
+			entry.SkipWork = binary.BigEndian.Uint16(data[offset:])&0x4000 != 0
+			offset += 2
+		}
+
+		var name string
+		if version == 4 {
+			// Prefijo comprimido: bytes a quitar del nombre anterior y el resto del nombre
+			strip, n := gitVarint(data[offset:])
+			if n == 0 || strip > len(previous) {
+				return nil, errors.New("corrupt git index path")
+			}
+			offset += n
+			end := bytes.IndexByte(data[offset:], 0)
+			if end < 0 {
+				return nil, errors.New("truncated git index")
+			}
+			name = previous[:len(previous)-strip] + string(data[offset:offset+end])
+			offset += end + 1
+		} else {
+			end := bytes.IndexByte(data[offset:], 0)
+			if end < 0 {
+				return nil, errors.New("truncated git index")
+			}
+			name = string(data[offset : offset+end])
+			// Relleno con NUL hasta un múltiplo de 8 desde el inicio de la entrada
+			offset = start + (offset+end-start+8)/8*8
+		}
+		previous = name
+
+		if stage := (flags >> 12) & 3; stage != 0 {
+			// Conflicto sin resolver: cuenta como modificado
+			entry.SHA = [20]byte{}
+		}
+		index[name] = entry
+	}
+	return index, nil
+}
+
+// gitVarint - Entero de longitud variable del índice v4; devuelve el valor y los bytes leídos
+func gitVarint(data []byte) (int, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+	value := int(data[0] & 0x7f)
+	n := 1
+	for data[n-1]&0x80 != 0 {
+		if n >= len(data) || n > 8 {
+			return 0, 0
+		}
+		value = ((value + 1) << 7) | int(data[n]&0x7f)
+		n++
+	}
+	return value, n
+}
+
+// fileStatus - "modified", "deleted" o "" comparando el archivo con su entrada del
+// índice: primero tamaño y mtime, y si difieren, el hash del blob
+func (index gitIndex) fileStatus(name, abs string) string {
+	entry, ok := index[name]
+	if !ok || entry.Mode == gitModeGitlink || entry.SkipWork {
+		return ""
+	}
+	info, err := os.Lstat(abs)
+	if err != nil {
+		return "deleted"
+	}
+	if info.IsDir() {
+		return "modified"
+	}
+	mtime := info.ModTime()
+	if uint32(info.Size()) == entry.Size && uint32(mtime.Unix()) == entry.MtimeSec && uint32(mtime.Nanosecond()) == entry.MtimeNsec {
+		return ""
+	}
+	sha, err := gitBlobHash(abs, info)
+	if err != nil || sha != entry.SHA {
+		return "modified"
+	}
+	return ""
+}
+
+// gitBlobHash - SHA-1 del objeto blob que git guardaría para el archivo
+func gitBlobHash(path string, info os.FileInfo) ([20]byte, error) {
+	var sum [20]byte
+	hasher := sha1.New()
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return sum, err
+		}
+		fmt.Fprintf(hasher, "blob %d\x00%s", len(target), target)
+	} else {
+		file, err := os.OpenFile(path, os.O_RDONLY|openNoFollow, 0)
+		if err != nil {
+			return sum, err
+		}
+		defer file.Close()
+		fmt.Fprintf(hasher, "blob %d\x00", info.Size())
+		if _, err := io.Copy(hasher, file); err != nil {
+			return sum, err
+		}
+	}
+	copy(sum[:], hasher.Sum(nil))
+	return sum, nil
+}
+
+// readGitIgnore - Patrones del .gitignore raíz y de info/exclude. Es una
+// aproximación: no lee .gitignore anidados ni aplica negaciones (!)
+func readGitIgnore(workTree, gitDir string) []string {
+	var patterns []string
+	for _, path := range []string{filepath.Join(workTree, ".gitignore"), filepath.Join(gitDir, "info", "exclude")} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+				continue
+			}
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns
+}
+
+// gitIgnored - Si rel (relativa al árbol de trabajo, con "/") coincide con algún patrón
+func gitIgnored(patterns []string, rel string, isDir bool) bool {
+	name := rel[strings.LastIndex(rel, "/")+1:]
+	for _, pattern := range patterns {
+		dirOnly := strings.HasSuffix(pattern, "/")
+		pattern = strings.TrimSuffix(pattern, "/")
+		if dirOnly && !isDir {
+			continue
+		}
+		// Con "/" el patrón se ancla a la raíz; sin él vale en cualquier nivel
+		if strings.Contains(pattern, "/") {
+			if matched, _ := filepath.Match(strings.TrimPrefix(pattern, "/"), rel); matched {
+				return true
+			}
+			continue
+		}
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// formatGitInfo - Resumen legible de git_info
+func formatGitInfo(info *GitInfo) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🌿 Git repository: %s\n", info.WorkTree))
+	if info.Branch != "" {
+		b.WriteString(fmt.Sprintf("Branch: %s\n", info.Branch))
+	} else {
+		b.WriteString("Branch: (detached HEAD)\n")
+	}
+	if info.Commit != "" {
+		b.WriteString(fmt.Sprintf("Commit: %s\n", info.Commit))
+	} else {
+		b.WriteString("Commit: (no commits yet)\n")
+	}
+	if info.Scope != info.WorkTree {
+		b.WriteString(fmt.Sprintf("Scope: %s\n", info.Scope))
+	}
+
+	sections := []struct {
+		title string
+		files []string
+	}{
+		{"✏️ Modified", info.Modified},
+		{"🗑️ Deleted", info.Deleted},
+		{"❓ Untracked", info.Untracked},
+	}
+	clean := true
+	for _, section := range sections {
+		if len(section.files) == 0 {
+			continue
+		}
+		clean = false
+		b.WriteString(fmt.Sprintf("\n%s (%d):\n", section.title, len(section.files)))
+		for _, file := range section.files {
+			b.WriteString(fmt.Sprintf("  • %s\n", file))
+		}
+	}
+	if clean {
+		b.WriteString("\n✅ Working tree clean\n")
+	}
+	if info.Truncated {
+		b.WriteString("\n⚠️ Lists truncated, raise max_files to see more\n")
+	}
+	b.WriteString("\nℹ️ Best-effort: read from .git without running git. Staged changes are not distinguished, and only the top-level .gitignore and info/exclude are applied.\n")
+	return b.String()
+}
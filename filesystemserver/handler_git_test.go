@@ -0,0 +1,138 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "init.defaultBranch=main"}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "HOME="+dir)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+func newGitRepo(t *testing.T) (*FilesystemHandler, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	handler, dir := newTestHandler(t)
+	files := map[string]string{
+		"main.go":        "package main\n",
+		"pkg/util.go":    "package pkg\n",
+		"pkg/old.go":     "package pkg\n\n// old\n",
+		"docs/readme.md": "# docs\n",
+		".gitignore":     "*.log\nbuild/\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "initial")
+	return handler, dir
+}
+
+func TestGitInfoStatus(t *testing.T) {
+	handler, dir := newGitRepo(t)
+	head := runGit(t, dir, "rev-parse", "HEAD")
+
+	info, err := handler.gitInfo(context.Background(), dir, 100)
+	require.NoError(t, err)
+	assert.Equal(t, "main", info.Branch)
+	assert.Equal(t, head, info.Commit)
+	assert.Empty(t, info.Modified)
+	assert.Empty(t, info.Deleted)
+	assert.Empty(t, info.Untracked)
+
+	// Solo cambia el mtime: el hash del blob decide que no hay cambios
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "main.go"), later, later))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "util.go"), []byte("package pkg\n\nfunc X() {}\n"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(dir, "pkg", "old.go")))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "new.go"), []byte("package pkg\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "debug.log"), []byte("ignored"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "build"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "build", "out.bin"), []byte("ignored"), 0644))
+
+	// Índices v3 y v4 (nombres con prefijo comprimido) y referencias empaquetadas
+	runGit(t, dir, "pack-refs", "--all")
+	for _, version := range []string{"2", "3", "4"} {
+		runGit(t, dir, "update-index", "--index-version", version)
+		info, err = handler.gitInfo(context.Background(), dir, 100)
+		require.NoError(t, err, "index v%s", version)
+		assert.Equal(t, head, info.Commit)
+		assert.Equal(t, []string{"pkg/util.go"}, info.Modified, "index v%s", version)
+		assert.Equal(t, []string{"pkg/old.go"}, info.Deleted, "index v%s", version)
+		assert.Equal(t, []string{"pkg/new.go"}, info.Untracked, "index v%s", version)
+	}
+
+	// Limitado a un subdirectorio
+	info, err = handler.gitInfo(context.Background(), filepath.Join(dir, "docs"), 100)
+	require.NoError(t, err)
+	assert.Equal(t, dir, info.WorkTree)
+	assert.Empty(t, info.Modified)
+	assert.Empty(t, info.Untracked)
+}
+
+func TestHandleGitInfo(t *testing.T) {
+	handler, dir := newGitRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644))
+	runGit(t, dir, "checkout", "-q", "--detach")
+
+	result, err := handler.handleGitInfo(context.Background(), newToolRequest("git_info", map[string]interface{}{}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "(detached HEAD)")
+	assert.Contains(t, text, "✏️ Modified (1):\n  • main.go")
+	assert.Contains(t, text, "Best-effort")
+
+	var info GitInfo
+	decodeStructured(t, result, &info)
+	assert.Equal(t, []string{"main.go"}, info.Modified)
+
+	other, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	plain, err := NewFilesystemHandler([]string{other})
+	require.NoError(t, err)
+	result, err = plain.handleGitInfo(context.Background(), newToolRequest("git_info", map[string]interface{}{"path": other}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "not inside a git repository")
+}
+
+func TestPlanRiskUsesGitIndex(t *testing.T) {
+	handler, dir := newGitRepo(t)
+	plan := &TaskPlan{Workspace: dir, Steps: []TaskStep{
+		{ID: 1, Type: "delete", Files: []string{"pkg"}, Risk: "medium"},
+	}}
+
+	// Un mtime posterior al índice ya no basta para considerarlo modificado
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "pkg", "util.go"), later, later))
+	handler.applyPlanRisk(context.Background(), plan)
+	assert.True(t, plan.Risk.GitRepo)
+	assert.False(t, plan.Risk.GitDirty)
+	assert.Equal(t, "medium", plan.Risk.Level)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "util.go"), []byte("package pkg // changed\n"), 0644))
+	handler.applyPlanRisk(context.Background(), plan)
+	assert.True(t, plan.Risk.GitDirty)
+	assert.Equal(t, "high", plan.Risk.Level)
+}
@@ -0,0 +1,219 @@
+package filesystemserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// lowFreeSpace - Por debajo de este espacio libre una raíz se considera degradada
+const lowFreeSpace = 100 << 20
+
+// Estados de health_check, de mejor a peor
+const (
+	healthOK       = "ok"
+	healthDegraded = "degraded"
+	healthFailed   = "failed"
+)
+
+// WithStartupHealthCheck runs health_check when the handler is created and
+// logs a warning for every allowed directory that is not healthy
+func WithStartupHealthCheck(enabled bool) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		fs.startupHealthCheck = enabled
+		return nil
+	}
+}
+
+// handleHealthCheck - Comprueba cada directorio permitido: existencia, lectura,
+// escritura, enlaces simbólicos, espacio libre y sensibilidad a mayúsculas
+func (fs *FilesystemHandler) handleHealthCheck(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	report := fs.healthCheck()
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatHealthReport(report)},
+		},
+	}, "health://allowed-directories", report)
+}
+
+// healthCheck - Revisa todas las raíces; el estado global es el peor de ellas
+func (fs *FilesystemHandler) healthCheck() *HealthReport {
+	report := &HealthReport{Status: healthOK, ReadOnlyMode: fs.readOnly, Roots: []RootHealth{}}
+	for _, dir := range fs.allowedDirectories() {
+		root := fs.checkRoot(dir)
+		if healthWorse(root.Status, report.Status) {
+			report.Status = root.Status
+		}
+		report.Roots = append(report.Roots, root)
+	}
+	return report
+}
+
+// checkRoot - Comprobaciones de una raíz. La escritura se prueba creando y
+// borrando un archivo, salvo en modo solo lectura.
+func (fs *FilesystemHandler) checkRoot(dir string) RootHealth {
+	label := fs.rootLabel(dir)
+	dir = filepath.Clean(dir)
+	root := RootHealth{Path: dir, Label: label, Status: healthOK, Problems: []string{}}
+	problem := func(status, format string, args ...interface{}) {
+		root.Problems = append(root.Problems, fmt.Sprintf(format, args...))
+		if healthWorse(status, root.Status) {
+			root.Status = status
+		}
+	}
+
+	info, err := os.Stat(dir)
+	switch {
+	case err != nil:
+		problem(healthFailed, "not accessible: %v", err)
+		return root
+	case !info.IsDir():
+		problem(healthFailed, "not a directory")
+		return root
+	}
+	root.Exists = true
+
+	if resolved, err := filepath.EvalSymlinks(dir); err != nil {
+		problem(healthDegraded, "symlinks could not be resolved: %v", err)
+	} else if resolved != dir {
+		root.ResolvedPath = resolved
+	}
+
+	// Leer al menos una entrada, o llegar al final de un directorio vacío
+	var sample string
+	if dirFile, err := os.Open(dir); err != nil {
+		problem(healthFailed, "not readable: %v", err)
+	} else {
+		names, err := dirFile.Readdirnames(32)
+		dirFile.Close()
+		if err != nil && !errors.Is(err, io.EOF) {
+			problem(healthFailed, "not readable: %v", err)
+		} else {
+			root.Readable = true
+			for _, name := range names {
+				if strings.ToUpper(name) != strings.ToLower(name) {
+					sample = name
+					break
+				}
+			}
+		}
+	}
+
+	if !fs.readOnly {
+		root.WriteChecked = true
+		probe, err := os.CreateTemp(dir, ".mcp-health-*")
+		if err != nil {
+			problem(healthDegraded, "not writable: %v", err)
+		} else {
+			untrack := fs.trackTemp(probe.Name())
+			probe.Close()
+			root.Writable = true
+			sample = filepath.Base(probe.Name())
+			root.CaseSensitive = caseSensitivity(dir, sample)
+			if err := os.Remove(probe.Name()); err != nil {
+				problem(healthDegraded, "probe file %s could not be removed: %v", probe.Name(), err)
+			}
+			untrack()
+		}
+	}
+	if root.CaseSensitive == nil && sample != "" {
+		root.CaseSensitive = caseSensitivity(dir, sample)
+	}
+
+	if available, ok := availableDiskSpace(dir); ok {
+		root.FreeBytes = &available
+		if available < lowFreeSpace {
+			problem(healthDegraded, "low free space: %d bytes", available)
+		}
+	}
+	return root
+}
+
+// caseSensitivity - Busca name con las mayúsculas invertidas: si no existe, o es
+// otro archivo, el sistema distingue mayúsculas. nil si no se pudo saber.
+func caseSensitivity(dir, name string) *bool {
+	swapped := strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, name)
+	original, err := os.Stat(filepath.Join(dir, name))
+	if err != nil {
+		return nil
+	}
+	sensitive := true
+	if other, err := os.Stat(filepath.Join(dir, swapped)); err == nil {
+		sensitive = !os.SameFile(original, other)
+	} else if !os.IsNotExist(err) {
+		return nil
+	}
+	return &sensitive
+}
+
+// healthWorse - Indica si el estado a es peor que b
+func healthWorse(a, b string) bool {
+	rank := map[string]int{healthOK: 0, healthDegraded: 1, healthFailed: 2}
+	return rank[a] > rank[b]
+}
+
+// logHealthProblems - Avisa en el log de cada raíz que no está sana
+func (fs *FilesystemHandler) logHealthProblems() {
+	for _, root := range fs.healthCheck().Roots {
+		if root.Status != healthOK {
+			fs.log().Warn("allowed directory is not healthy", "path", root.Path, "status", root.Status, "problems", strings.Join(root.Problems, "; "))
+		}
+	}
+}
+
+// formatHealthReport - Estado global y una tabla con una fila por raíz
+func formatHealthReport(report *HealthReport) string {
+	icons := map[string]string{healthOK: "✅", healthDegraded: "⚠️", healthFailed: "❌"}
+	yesNo := func(ok bool) string {
+		if ok {
+			return "yes"
+		}
+		return "no"
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🩺 Health check: %s %s (%d allowed directories)\n", icons[report.Status], report.Status, len(report.Roots)))
+	if report.ReadOnlyMode {
+		b.WriteString("🔒 Read-only mode: write access not probed\n")
+	}
+	b.WriteString("\n| Status | Root | Readable | Writable | Free | Case sensitive | Resolves to |\n|---|---|---|---|---|---|---|\n")
+	for _, root := range report.Roots {
+		writable := yesNo(root.Writable)
+		if !root.WriteChecked {
+			writable = "not checked"
+		}
+		free := "unknown"
+		if root.FreeBytes != nil {
+			free = fmt.Sprintf("%.0f MB", float64(*root.FreeBytes)/(1<<20))
+		}
+		caseSensitive := "unknown"
+		if root.CaseSensitive != nil {
+			caseSensitive = yesNo(*root.CaseSensitive)
+		}
+		resolved := "-"
+		if root.ResolvedPath != "" {
+			resolved = root.ResolvedPath
+		}
+		b.WriteString(fmt.Sprintf("| %s %s | %s:%s | %s | %s | %s | %s | %s |\n",
+			icons[root.Status], root.Status, root.Label, root.Path, yesNo(root.Readable), writable, free, caseSensitive, resolved))
+	}
+
+	for _, root := range report.Roots {
+		for _, problem := range root.Problems {
+			b.WriteString(fmt.Sprintf("\n%s %s: %s", icons[root.Status], root.Path, problem))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
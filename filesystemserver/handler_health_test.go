@@ -0,0 +1,80 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runHealthCheck calls health_check and returns its text and report
+func runHealthCheck(t *testing.T, handler *FilesystemHandler) (string, HealthReport) {
+	t.Helper()
+	result, err := handler.handleHealthCheck(context.Background(), newToolRequest("health_check", nil))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	var report HealthReport
+	decodeStructured(t, result, &report)
+	return result.Content[0].(mcp.TextContent).Text, report
+}
+
+func TestHealthCheckHealthyRoots(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# app\n"), 0644))
+
+	text, report := runHealthCheck(t, handler)
+	require.Len(t, report.Roots, 1)
+	root := report.Roots[0]
+	assert.Equal(t, dir, root.Path)
+	assert.Equal(t, "root1", root.Label)
+	assert.True(t, root.Exists)
+	assert.True(t, root.Readable)
+	assert.True(t, root.WriteChecked)
+	assert.True(t, root.Writable)
+	assert.NotNil(t, root.CaseSensitive)
+	if root.FreeBytes != nil && *root.FreeBytes >= lowFreeSpace {
+		assert.Equal(t, healthOK, report.Status)
+		assert.Contains(t, text, "🩺 Health check: ✅ ok (1 allowed directories)")
+	}
+	assert.Contains(t, text, "| Status | Root |")
+	assert.Contains(t, text, "root1:"+dir)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "the probe file is removed")
+	assert.Empty(t, tempFilesIn(t, dir))
+}
+
+func TestHealthCheckReadOnlyModeWritesNothing(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	require.NoError(t, WithReadOnly(true)(handler))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Notes.txt"), []byte("x"), 0644))
+
+	text, report := runHealthCheck(t, handler)
+	root := report.Roots[0]
+	assert.False(t, root.WriteChecked)
+	assert.False(t, root.Writable)
+	assert.NotNil(t, root.CaseSensitive, "decided from an existing entry")
+	assert.Contains(t, text, "🔒 Read-only mode: write access not probed")
+	assert.Contains(t, text, "| not checked |")
+}
+
+func TestHealthCheckMissingRoot(t *testing.T) {
+	dirs := tempDirs(t, 2)
+	handler, err := NewFilesystemHandler(dirs)
+	require.NoError(t, err)
+	other := dirs[1]
+	require.NoError(t, os.Remove(other))
+
+	text, report := runHealthCheck(t, handler)
+	assert.Equal(t, healthFailed, report.Status)
+	require.Len(t, report.Roots, 2)
+	assert.False(t, report.Roots[1].Exists)
+	assert.Equal(t, healthFailed, report.Roots[1].Status)
+	assert.Contains(t, report.Roots[1].Problems[0], "not accessible")
+	assert.Contains(t, text, "❌ "+other+": not accessible")
+}
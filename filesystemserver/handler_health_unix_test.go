@@ -0,0 +1,54 @@
+//go:build linux || darwin || freebsd
+
+package filesystemserver
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthCheckReadOnlyDirectory(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	require.NoError(t, os.Chmod(dir, 0555))
+	t.Cleanup(func() { os.Chmod(dir, 0755) })
+	if probe, err := os.CreateTemp(dir, "probe"); err == nil {
+		probe.Close()
+		os.Remove(probe.Name())
+		t.Skip("permissions are not enforced for this user")
+	}
+
+	text, report := runHealthCheck(t, handler)
+	assert.Equal(t, healthDegraded, report.Status)
+	root := report.Roots[0]
+	assert.True(t, root.Readable)
+	assert.True(t, root.WriteChecked)
+	assert.False(t, root.Writable)
+	assert.Contains(t, root.Problems[0], "not writable")
+	assert.Contains(t, text, "🩺 Health check: ⚠️ degraded")
+}
+
+func TestStartupHealthCheckLogsWarnings(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Chmod(dir, 0555))
+	t.Cleanup(func() { os.Chmod(dir, 0755) })
+	if probe, err := os.CreateTemp(dir, "probe"); err == nil {
+		probe.Close()
+		os.Remove(probe.Name())
+		t.Skip("permissions are not enforced for this user")
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	_, err := NewFilesystemHandler([]string{dir}, WithLogger(logger), WithStartupHealthCheck(true))
+	require.NoError(t, err)
+	records := logRecords(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "allowed directory is not healthy", records[0]["msg"])
+	assert.Equal(t, healthDegraded, records[0]["status"])
+	assert.Contains(t, records[0]["problems"], "not writable")
+}
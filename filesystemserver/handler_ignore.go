@@ -0,0 +1,349 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ignoreFileName - Archivo de reglas de exclusión en la raíz de cada directorio permitido
+const ignoreFileName = ".mcpignore"
+
+// ignoreAwareTools - Herramientas que recorren árboles aplicando .mcpignore;
+// anuncian el argumento no_ignore
+var ignoreAwareTools = []string{
+	"smart_search", "search_files", "tree", "analyze_project", "find_duplicates",
+	"smart_sync", "generate_report", "scan", "cleanup", "checksum", "compare_files",
+	"extract_outline", "code_quality_check", "assist_refactor", "create_archive",
+	"find_duplicate_code", "code_stats",
+}
+
+// builtinIgnoreNames - Directorios y archivos que los recorridos de análisis
+// omiten por defecto; .mcpignore puede volver a incluirlos con !
+var builtinIgnoreNames = []string{
+	".git", ".svn", ".hg",
+	"node_modules", "vendor", "target",
+	".vscode", ".idea", ".vs",
+	"bin", "obj", "build", "dist",
+	".cache", ".tmp", "temp",
+	"__pycache__", ".pytest_cache",
+	"coverage", ".nyc_output",
+	"logs", "log",
+}
+
+// ignoreRule - Una línea de .mcpignore, con la sintaxis de .gitignore
+type ignoreRule struct {
+	text     string
+	line     int
+	segments []string // patrón dividido por "/"
+	negate   bool     // !patrón vuelve a incluir
+	dirOnly  bool     // patrón/ solo vale para directorios
+	anchored bool     // con "/" se compara con la ruta desde la raíz; sin él, con el nombre
+}
+
+// parseIgnoreRules - Reglas de un archivo con la sintaxis de .gitignore:
+// comentarios con #, ! para negar, / final para directorios, / inicial o
+// intermedia para anclar a la raíz y ** para cualquier número de directorios
+func parseIgnoreRules(data string) []ignoreRule {
+	var rules []ignoreRule
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{text: line, line: i + 1}
+		pattern := line
+		if strings.HasPrefix(pattern, "!") {
+			rule.negate, pattern = true, pattern[1:]
+		} else if strings.HasPrefix(pattern, `\!`) || strings.HasPrefix(pattern, `\#`) {
+			pattern = pattern[1:]
+		}
+		if strings.HasSuffix(pattern, "/") {
+			rule.dirOnly, pattern = true, strings.TrimRight(pattern, "/")
+		}
+		rule.anchored = strings.Contains(pattern, "/")
+		pattern = strings.TrimPrefix(pattern, "/")
+		if pattern == "" {
+			continue
+		}
+		rule.segments = strings.Split(pattern, "/")
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// matches - Si la regla describe rel (relativa a la raíz, con "/")
+func (r ignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	parts := strings.Split(rel, "/")
+	if !r.anchored {
+		matched, _ := path.Match(r.segments[0], parts[len(parts)-1])
+		return matched
+	}
+	return matchIgnoreSegments(r.segments, parts)
+}
+
+// matchIgnoreSegments - Compara un patrón por segmentos; ** vale por cero o más
+func matchIgnoreSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchIgnoreSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(pattern[0], parts[0]); !matched {
+		return false
+	}
+	return matchIgnoreSegments(pattern[1:], parts[1:])
+}
+
+// ignoreVerdict - La última regla que describe rel decide; nil si ninguna lo hace
+func ignoreVerdict(rules []ignoreRule, rel string, isDir bool) *ignoreRule {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].matches(rel, isDir) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// ignoreCache - Reglas de .mcpignore por directorio permitido; se vuelven a
+// leer en cuanto cambia la fecha o el tamaño del archivo
+type ignoreCache struct {
+	mu      sync.Mutex
+	entries map[string]ignoreCacheEntry
+}
+
+// ignoreCacheEntry - Reglas leídas y la versión del archivo de la que salieron
+type ignoreCacheEntry struct {
+	modTime time.Time
+	size    int64
+	rules   []ignoreRule
+}
+
+// rules - Reglas vigentes del .mcpignore de root; nil si no existe
+func (c *ignoreCache) rules(root string) ([]ignoreRule, time.Time, error) {
+	file := filepath.Join(root, ignoreFileName)
+	info, err := os.Stat(file)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		delete(c.entries, root)
+		if os.IsNotExist(err) {
+			return nil, time.Time{}, nil
+		}
+		return nil, time.Time{}, err
+	}
+	if entry, ok := c.entries[root]; ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+		return entry.rules, entry.modTime, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	entry := ignoreCacheEntry{modTime: info.ModTime(), size: info.Size(), rules: parseIgnoreRules(string(data))}
+	if c.entries == nil {
+		c.entries = make(map[string]ignoreCacheEntry)
+	}
+	c.entries[root] = entry
+	return entry.rules, entry.modTime, nil
+}
+
+// noIgnoreKey - Marca en el contexto de una llamada con no_ignore: true
+type noIgnoreKey struct{}
+
+// withIgnoreArgs - Contexto de la llamada, marcado si pidió no_ignore
+func withIgnoreArgs(ctx context.Context, name string, args map[string]interface{}) context.Context {
+	if noIgnore, _ := args["no_ignore"].(bool); noIgnore && containsString(ignoreAwareTools, name) {
+		return context.WithValue(ctx, noIgnoreKey{}, true)
+	}
+	return ctx
+}
+
+// ignoreDisabled - Si la llamada pidió no aplicar las reglas de exclusión
+func ignoreDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(noIgnoreKey{}).(bool)
+	return disabled
+}
+
+// ignoreRoot - Directorio permitido más interno que contiene path, y path relativa a él
+func (fs *FilesystemHandler) ignoreRoot(path string) (root, rel string, ok bool) {
+	for _, dir := range fs.allowedDirectories() {
+		dir = strings.TrimSuffix(dir, string(filepath.Separator))
+		if r, within := pathRelToDir(path, dir); within && len(dir) > len(root) {
+			root, rel, ok = dir, filepath.ToSlash(r), true
+		}
+	}
+	return root, rel, ok
+}
+
+// ignoreFileRule - Regla de .mcpignore que decide sobre path; nil si ninguna
+func (fs *FilesystemHandler) ignoreFileRule(path string, isDir bool) *ignoreRule {
+	root, rel, ok := fs.ignoreRoot(path)
+	if !ok || rel == "." {
+		return nil
+	}
+	rules, _, err := fs.ignores.rules(root)
+	if err != nil {
+		fs.log().Warn("cannot read ignore file", "root", root, "error", err)
+		return nil
+	}
+	return ignoreVerdict(rules, rel, isDir)
+}
+
+// mcpIgnored - Si .mcpignore excluye path, para los recorridos sin lista fija.
+// Los recorridos no entran en los directorios excluidos, así que basta con
+// mirar cada entrada.
+func (fs *FilesystemHandler) mcpIgnored(ctx context.Context, path string, isDir bool) bool {
+	if ignoreDisabled(ctx) {
+		return false
+	}
+	rule := fs.ignoreFileRule(path, isDir)
+	return rule != nil && !rule.negate
+}
+
+// shouldIgnoreEntry - shouldIgnorePath con .mcpignore por encima de la lista
+// fija: sus reglas excluyen más o, con !, vuelven a incluir. Las rutas
+// denegadas y los datos del servidor se excluyen siempre, también con no_ignore.
+func (fs *FilesystemHandler) shouldIgnoreEntry(ctx context.Context, path string, isDir bool) bool {
+	if isServerDataDir(filepath.Base(path)) || fs.isDeniedPath(path) {
+		return true
+	}
+	if ignoreDisabled(ctx) {
+		return false
+	}
+	if rule := fs.ignoreFileRule(path, isDir); rule != nil {
+		return !rule.negate
+	}
+	return fs.shouldIgnorePath(path)
+}
+
+// handleGetIgnoreRules - Reglas de exclusión que aplican los recorridos: la
+// lista fija y el .mcpignore de cada directorio permitido; con path, explica
+// si esa ruta se excluye y por qué
+func (fs *FilesystemHandler) handleGetIgnoreRules(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	report := IgnoreRules{Defaults: builtinIgnoreNames, Roots: []IgnoreRootRules{}}
+	for _, dir := range fs.allowedDirectories() {
+		dir = strings.TrimSuffix(dir, string(filepath.Separator))
+		root := IgnoreRootRules{Root: dir, File: filepath.Join(dir, ignoreFileName), Rules: []string{}}
+		rules, modTime, err := fs.ignores.rules(dir)
+		switch {
+		case err != nil:
+			root.Error = err.Error()
+		case !modTime.IsZero():
+			root.Exists, root.Modified = true, &modTime
+		}
+		for _, rule := range rules {
+			root.Rules = append(root.Rules, rule.text)
+		}
+		report.Roots = append(report.Roots, root)
+	}
+
+	if path != "" {
+		validPath, err := fs.validatePath(path)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+				},
+				IsError: true,
+			}, nil
+		}
+		report.Check = fs.explainIgnore(ctx, validPath)
+	}
+
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatIgnoreRules(&report)},
+		},
+	}, "ignore://rules", report)
+}
+
+// explainIgnore - Si path o alguno de sus directorios se excluye, y la regla
+func (fs *FilesystemHandler) explainIgnore(ctx context.Context, path string) *IgnoreCheck {
+	check := &IgnoreCheck{Path: path}
+	root, rel, ok := fs.ignoreRoot(path)
+	if !ok || rel == "." {
+		return check
+	}
+	// Desde el directorio más externo: uno excluido ya oculta todo lo de dentro
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		current := filepath.Join(root, filepath.FromSlash(strings.Join(parts[:i+1], "/")))
+		isDir := i < len(parts)-1
+		if !isDir {
+			if info, err := os.Stat(current); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+		if !fs.shouldIgnoreEntry(ctx, current, isDir) {
+			continue
+		}
+		check.Ignored, check.By = true, current
+		switch rule := fs.ignoreFileRule(current, isDir); {
+		case isServerDataDir(filepath.Base(current)) || fs.isDeniedPath(current):
+			check.Rule = "denied or server data"
+		case rule != nil:
+			check.Rule = fmt.Sprintf("%s:%d: %s", ignoreFileName, rule.line, rule.text)
+		default:
+			check.Rule = "built-in default; searches and tree still include it"
+		}
+		break
+	}
+	return check
+}
+
+// formatIgnoreRules - Resumen legible de get_ignore_rules
+func formatIgnoreRules(report *IgnoreRules) string {
+	var b strings.Builder
+	b.WriteString("🙈 Ignore rules (built-in defaults < .mcpignore < per-call exclude_patterns)\n")
+	fmt.Fprintf(&b, "📦 Built-in: %s and hidden files\n", strings.Join(report.Defaults, ", "))
+	for _, root := range report.Roots {
+		switch {
+		case root.Error != "":
+			fmt.Fprintf(&b, "\n❌ %s: %s\n", root.File, root.Error)
+		case !root.Exists:
+			fmt.Fprintf(&b, "\n📁 %s: no %s\n", root.Root, ignoreFileName)
+		default:
+			fmt.Fprintf(&b, "\n📄 %s (%d rules, modified %s)\n", root.File, len(root.Rules), root.Modified.Format("2006-01-02 15:04:05"))
+			for _, rule := range root.Rules {
+				fmt.Fprintf(&b, "  %s\n", rule)
+			}
+		}
+	}
+	if check := report.Check; check != nil {
+		if check.Ignored {
+			fmt.Fprintf(&b, "\n🚫 %s is ignored", check.Path)
+			if check.By != check.Path {
+				fmt.Fprintf(&b, " because %s is", check.By)
+			}
+			fmt.Fprintf(&b, " (%s)\n", check.Rule)
+		} else {
+			fmt.Fprintf(&b, "\n✅ %s is not ignored\n", check.Path)
+		}
+	}
+	return b.String()
+}
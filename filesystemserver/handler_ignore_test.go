@@ -0,0 +1,116 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnoreRulesMatch(t *testing.T) {
+	rules := parseIgnoreRules("# comment\n*.log\n!keep.log\nbuild/\n/root-only.txt\ndocs/**/draft.md\n\\#literal\n")
+	tests := []struct {
+		rel     string
+		isDir   bool
+		ignored bool
+	}{
+		{"app.log", false, true},
+		{"sub/deep/app.log", false, true},
+		{"sub/keep.log", false, false},
+		{"build", true, true},
+		{"sub/build", true, true},
+		{"build", false, false},
+		{"root-only.txt", false, true},
+		{"sub/root-only.txt", false, false},
+		{"docs/draft.md", false, true},
+		{"docs/a/b/draft.md", false, true},
+		{"other/draft.md", false, false},
+		{"#literal", false, true},
+		{"main.go", false, false},
+	}
+	for _, tt := range tests {
+		rule := ignoreVerdict(rules, tt.rel, tt.isDir)
+		assert.Equal(t, tt.ignored, rule != nil && !rule.negate, tt.rel)
+	}
+	assert.Equal(t, 3, ignoreVerdict(rules, "keep.log", false).line)
+}
+
+func TestMcpIgnoreHidesFromSmartSearch(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{
+		"src/main.go":        "needle in code",
+		"fixtures/big.json":  "needle in fixture",
+		"fixtures/keep.json": "needle kept",
+	})
+	search := func(args map[string]interface{}) string {
+		args["path"] = dir
+		args["pattern"] = "needle"
+		args["include_content"] = true
+		text, isError := callText(t, handler.handleSmartSearch, "smart_search", args)
+		require.False(t, isError, text)
+		return text
+	}
+
+	text := search(map[string]interface{}{})
+	assert.Contains(t, text, "big.json")
+
+	// Sin reiniciar: la siguiente búsqueda ya aplica el archivo nuevo
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ignoreFileName), []byte("fixtures/\n"), 0644))
+	text = search(map[string]interface{}{})
+	assert.NotContains(t, text, "big.json")
+	assert.NotContains(t, text, "keep.json")
+	assert.Contains(t, text, "main.go")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ignoreFileName), []byte("fixtures/*\n!fixtures/keep.json\n"), 0644))
+	text = search(map[string]interface{}{})
+	assert.NotContains(t, text, "big.json")
+	assert.Contains(t, text, "keep.json")
+
+	// no_ignore llega por el contexto que prepara guardTool
+	guarded := handler.guardTool("smart_search", handler.handleSmartSearch)
+	result, err := guarded(context.Background(), newToolRequest("smart_search", map[string]interface{}{
+		"path": dir, "pattern": "needle", "include_content": true, "no_ignore": true,
+	}))
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "big.json")
+
+	require.NoError(t, os.Remove(filepath.Join(dir, ignoreFileName)))
+	assert.Contains(t, search(map[string]interface{}{}), "big.json")
+}
+
+func TestMcpIgnoreOverridesBuiltinDefaults(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{"build/gen.go": "package build", "notes/todo.md": "todo"})
+	ctx := context.Background()
+	build, notes := filepath.Join(dir, "build"), filepath.Join(dir, "notes")
+
+	assert.True(t, handler.shouldIgnoreEntry(ctx, build, true), "built-in default")
+	assert.False(t, handler.shouldIgnoreEntry(ctx, notes, true))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ignoreFileName), []byte("!build/\nnotes/\n"), 0644))
+	assert.False(t, handler.shouldIgnoreEntry(ctx, build, true), ".mcpignore re-includes a default")
+	assert.True(t, handler.shouldIgnoreEntry(ctx, notes, true))
+
+	noIgnore := withIgnoreArgs(ctx, "analyze_project", map[string]interface{}{"no_ignore": true})
+	assert.False(t, handler.shouldIgnoreEntry(noIgnore, notes, true))
+	assert.True(t, handler.shouldIgnoreEntry(noIgnore, filepath.Join(dir, planDirName), true), "server data stays hidden")
+
+	var report IgnoreRules
+	result, err := handler.handleGetIgnoreRules(ctx, newToolRequest("get_ignore_rules", map[string]interface{}{
+		"path": filepath.Join(notes, "todo.md"),
+	}))
+	require.NoError(t, err)
+	decodeStructured(t, result, &report)
+	require.Len(t, report.Roots, 1)
+	assert.True(t, report.Roots[0].Exists)
+	assert.Equal(t, []string{"!build/", "notes/"}, report.Roots[0].Rules)
+	require.NotNil(t, report.Check)
+	assert.True(t, report.Check.Ignored)
+	assert.Equal(t, notes, report.Check.By)
+	assert.Equal(t, ".mcpignore:2: notes/", report.Check.Rule)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "because "+notes+" is")
+}
@@ -0,0 +1,195 @@
+package filesystemserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// heavyTools - Herramientas que recorren árboles de directorios; comparten un
+// límite de ejecuciones simultáneas. Las operaciones sobre rutas sueltas nunca esperan por ellas.
+var heavyTools = []string{
+	"search_files", "smart_search", "tree", "find_duplicates", "analyze_project",
+	"extract_outline", "checksum", "verify_checksums", "compare_files",
+	"code_quality_check", "performance_analysis", "generate_report", "scan",
+	"smart_sync", "assist_refactor", "plan_task", "cleanup", "create_snapshot",
+	"create_archive", "git_info", "classify_files", "normalize_file",
+	"detect_changes", "workspace_context", "find_name_collisions", "fix_permissions",
+	"recent_activity", "find_duplicate_code", "code_stats",
+}
+
+// streamingTools - Herramientas que no recorren árboles pero procesan archivos
+// enteros de cualquier tamaño; tienen el mismo timeout que las pesadas
+var streamingTools = []string{
+	"read_multiple_files", "copy_file", "move_file", "delete_file", "analyze_file",
+	"extract_text", "csv_query", "log_query", "tail_follow", "analyze_lines", "batch_operations",
+	"execute_plan", "resume_plan", "rollback_plan", "split_file", "join_files",
+	"compress_file", "decompress_file", "restore_snapshot", "create_file_of_size",
+	"render_tree", "truncate_file",
+}
+
+const (
+	// defaultHeavyLimit - Operaciones pesadas simultáneas por defecto
+	defaultHeavyLimit = 4
+	// defaultHeavyQueueTimeout - Espera máxima por un hueco cuando se encola
+	defaultHeavyQueueTimeout = 30 * time.Second
+	// defaultToolTimeout - Tiempo máximo de una operación sobre rutas sueltas
+	defaultToolTimeout = 10 * time.Second
+	// defaultHeavyToolTimeout - Tiempo máximo de heavyTools y streamingTools
+	defaultHeavyToolTimeout = 120 * time.Second
+)
+
+// errToolTimeout - Causa de la cancelación de una llamada que agotó su timeout
+var errToolTimeout = errors.New("tool call timed out")
+
+// WithConcurrencyLimit caps the directory-walking tools running at once. When
+// queue is true excess calls wait up to timeout for a slot; otherwise they fail
+// immediately with a "server busy" error. A limit of 0 or less disables the cap.
+func WithConcurrencyLimit(limit int, queue bool, timeout time.Duration) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		fs.heavySlots = nil
+		if limit > 0 {
+			fs.heavySlots = make(chan struct{}, limit)
+		}
+		fs.heavyQueue = queue
+		fs.heavyTimeout = timeout
+		return nil
+	}
+}
+
+// acquireHeavySlot - Reserva un hueco para una operación pesada; devuelve la
+// función que lo libera, o un error si el servidor está ocupado
+func (fs *FilesystemHandler) acquireHeavySlot(ctx context.Context, name string) (func(), error) {
+	if fs.heavySlots == nil || !containsString(heavyTools, name) {
+		return func() {}, nil
+	}
+	release := func() { <-fs.heavySlots }
+
+	select {
+	case fs.heavySlots <- struct{}{}:
+		return release, nil
+	default:
+	}
+	busy := fmt.Errorf("server busy: all %d heavy operation slots in use, retry later", cap(fs.heavySlots))
+	if !fs.heavyQueue {
+		return nil, busy
+	}
+
+	timeout := fs.heavyTimeout
+	if timeout <= 0 {
+		timeout = defaultHeavyQueueTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case fs.heavySlots <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%w (waited %s)", busy, timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// WithToolTimeouts sets how long a tool call runs before its context is
+// cancelled: single for tools on single paths, heavy for directory walkers and
+// tools that stream whole files, perTool for individual tools. A zero duration
+// keeps the default (10s and 120s, or the category for perTool); a negative
+// one disables the timeout.
+func WithToolTimeouts(single, heavy time.Duration, perTool map[string]time.Duration) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		fs.toolTimeout = single
+		fs.heavyToolTimeout = heavy
+		fs.toolTimeouts = perTool
+		return nil
+	}
+}
+
+// acceptsTimeoutArg - Herramientas que anuncian el argumento timeout_ms
+func acceptsTimeoutArg(name string) bool {
+	return containsString(heavyTools, name) || containsString(streamingTools, name)
+}
+
+// toolTimeoutFor - Timeout configurado para name; 0 si no tiene
+func (fs *FilesystemHandler) toolTimeoutFor(name string) time.Duration {
+	if timeout := fs.toolTimeouts[name]; timeout != 0 {
+		return max(timeout, 0)
+	}
+	timeout, fallback := fs.toolTimeout, defaultToolTimeout
+	if acceptsTimeoutArg(name) {
+		timeout, fallback = fs.heavyToolTimeout, defaultHeavyToolTimeout
+	}
+	if timeout == 0 {
+		return fallback
+	}
+	return max(timeout, 0)
+}
+
+// callTimeout - Timeout de una llamada: timeout_ms puede acortarlo, nunca
+// alargarlo más allá del configurado
+func (fs *FilesystemHandler) callTimeout(name string, args map[string]interface{}) time.Duration {
+	timeout := fs.toolTimeoutFor(name)
+	if ms, ok := args["timeout_ms"].(float64); ok && ms > 0 && acceptsTimeoutArg(name) {
+		if requested := time.Duration(ms * float64(time.Millisecond)); timeout == 0 || requested < timeout {
+			timeout = requested
+		}
+	}
+	return timeout
+}
+
+// callActivity - Entradas recorridas por una llamada con timeout, para informar
+// de lo que llegó a hacer si se corta
+type callActivity struct {
+	timeout time.Duration
+	entries atomic.Int64
+}
+
+type callActivityKey struct{}
+
+// withCallTimeout - ctx cancelado con errToolTimeout tras timeout; 0 no limita
+func withCallTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	ctx = context.WithValue(ctx, callActivityKey{}, &callActivity{timeout: timeout})
+	return context.WithTimeoutCause(ctx, timeout, errToolTimeout)
+}
+
+// activityFrom - callActivity de la llamada en curso, o nil
+func activityFrom(ctx context.Context) *callActivity {
+	activity, _ := ctx.Value(callActivityKey{}).(*callActivity)
+	return activity
+}
+
+// callTimedOut - Si la llamada de ctx agotó su timeout, devuelve cuál era
+func callTimedOut(ctx context.Context) (time.Duration, bool) {
+	activity := activityFrom(ctx)
+	if activity == nil || !errors.Is(context.Cause(ctx), errToolTimeout) {
+		return 0, false
+	}
+	return activity.timeout, true
+}
+
+// timedOutNote - Cabecera de los resultados parciales de una llamada cortada
+func timedOutNote(timeout time.Duration) string {
+	return fmt.Sprintf("⏱️ Operation timed out after %s; partial results:\n\n", timeout)
+}
+
+// timedOutResult - Error de una llamada que agotó su timeout sin devolver
+// resultados parciales; indica cuánto llegó a recorrer
+func timedOutResult(ctx context.Context, timeout time.Duration) *mcp.CallToolResult {
+	partial := "no partial results"
+	if entries := activityFrom(ctx).entries.Load(); entries > 0 {
+		partial = fmt.Sprintf("partial results: %d entries scanned before stopping", entries)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: operation timed out after %s; %s", timeout, partial)},
+		},
+		IsError: true,
+	}
+}
@@ -0,0 +1,186 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowWalk is a fake heavy handler that records how many copies run at once
+type slowWalk struct {
+	running atomic.Int32
+	peak    atomic.Int32
+	delay   time.Duration
+}
+
+func (w *slowWalk) handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	n := w.running.Add(1)
+	defer w.running.Add(-1)
+	for {
+		peak := w.peak.Load()
+		if n <= peak || w.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(w.delay)
+	return &mcp.CallToolResult{Content: []mcp.Content{mcp.TextContent{Type: "text", Text: "done"}}}, nil
+}
+
+// runConcurrently calls tool n times at once and counts the busy errors
+func runConcurrently(t *testing.T, tool func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), n int) int {
+	t.Helper()
+	var busy atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := tool(context.Background(), newToolRequest("find_duplicates", map[string]interface{}{}))
+			require.NoError(t, err)
+			if result.IsError {
+				assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "server busy")
+				busy.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	return int(busy.Load())
+}
+
+func TestConcurrencyLimitRejects(t *testing.T) {
+	handler, _ := newTestHandler(t)
+	require.NoError(t, WithConcurrencyLimit(2, false, 0)(handler))
+
+	walk := &slowWalk{delay: 200 * time.Millisecond}
+	busy := runConcurrently(t, handler.guardTool("find_duplicates", walk.handle), 6)
+	assert.Equal(t, int32(2), walk.peak.Load())
+	assert.Equal(t, 4, busy)
+}
+
+func TestConcurrencyLimitQueues(t *testing.T) {
+	handler, _ := newTestHandler(t)
+	require.NoError(t, WithConcurrencyLimit(2, true, 5*time.Second)(handler))
+
+	walk := &slowWalk{delay: 50 * time.Millisecond}
+	busy := runConcurrently(t, handler.guardTool("find_duplicates", walk.handle), 6)
+	assert.Equal(t, int32(2), walk.peak.Load())
+	assert.Zero(t, busy)
+
+	// Con un plazo corto la cola expira
+	require.NoError(t, WithConcurrencyLimit(1, true, 20*time.Millisecond)(handler))
+	walk = &slowWalk{delay: 300 * time.Millisecond}
+	busy = runConcurrently(t, handler.guardTool("find_duplicates", walk.handle), 3)
+	assert.Equal(t, int32(1), walk.peak.Load())
+	assert.Equal(t, 2, busy)
+}
+
+func TestLightToolsNotBlockedByHeavy(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	require.NoError(t, WithConcurrencyLimit(1, true, 5*time.Second)(handler))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0644))
+
+	walk := &slowWalk{delay: 500 * time.Millisecond}
+	heavy := handler.guardTool("smart_search", walk.handle)
+	go heavy(context.Background(), newToolRequest("smart_search", map[string]interface{}{}))
+	require.Eventually(t, func() bool { return walk.running.Load() == 1 }, time.Second, time.Millisecond)
+
+	read := handler.guardTool("read_file", handler.handleReadFile)
+	start := time.Now()
+	result, err := read(context.Background(), newToolRequest("read_file", map[string]interface{}{"path": filepath.Join(dir, "a.txt")}))
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Less(t, time.Since(start), 250*time.Millisecond)
+
+	// Cancelar el contexto deja de esperar en la cola
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err = heavy(ctx, newToolRequest("smart_search", map[string]interface{}{}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "context canceled")
+}
+
+func TestToolTimeoutSettings(t *testing.T) {
+	handler, _ := newTestHandler(t)
+	assert.Equal(t, defaultToolTimeout, handler.toolTimeoutFor("read_file"))
+	assert.Equal(t, defaultHeavyToolTimeout, handler.toolTimeoutFor("smart_search"))
+	assert.Equal(t, defaultHeavyToolTimeout, handler.toolTimeoutFor("csv_query"), "streaming tools get the walker timeout")
+
+	require.NoError(t, WithToolTimeouts(-1, time.Minute, map[string]time.Duration{"checksum": 5 * time.Minute, "tree": -1})(handler))
+	assert.Zero(t, handler.toolTimeoutFor("read_file"))
+	assert.Equal(t, time.Minute, handler.toolTimeoutFor("smart_search"))
+	assert.Equal(t, 5*time.Minute, handler.toolTimeoutFor("checksum"))
+	assert.Zero(t, handler.toolTimeoutFor("tree"))
+
+	// timeout_ms acorta el límite, nunca lo alarga, y solo en herramientas largas
+	assert.Equal(t, 250*time.Millisecond, handler.callTimeout("smart_search", map[string]interface{}{"timeout_ms": float64(250)}))
+	assert.Equal(t, time.Minute, handler.callTimeout("smart_search", map[string]interface{}{"timeout_ms": float64(600000)}))
+	assert.Equal(t, 2*time.Second, handler.callTimeout("tree", map[string]interface{}{"timeout_ms": float64(2000)}))
+	assert.Zero(t, handler.callTimeout("read_file", map[string]interface{}{"timeout_ms": float64(2000)}))
+}
+
+func TestToolTimeoutStopsHandler(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{"a.txt": "a", "b/c.txt": "c"})
+	require.NoError(t, WithToolTimeouts(30*time.Millisecond, 0, nil)(handler))
+
+	var stopped atomic.Bool
+	tool := handler.guardTool("get_file_info", func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		err := handler.walkContext(ctx, dir, func(string, os.FileInfo, error) error { return nil })
+		require.NoError(t, err)
+		<-ctx.Done()
+		stopped.Store(true)
+		return nil, ctx.Err()
+	})
+	start := time.Now()
+	result, err := tool(context.Background(), newToolRequest("get_file_info", nil))
+	require.NoError(t, err)
+	assert.True(t, stopped.Load())
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.True(t, result.IsError)
+	assert.Equal(t, "❌ Error: operation timed out after 30ms; partial results: 4 entries scanned before stopping", result.Content[0].(mcp.TextContent).Text)
+
+	// Un resultado correcto no se toca aunque llegue tarde
+	late := handler.guardTool("get_file_info", func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		<-ctx.Done()
+		return &mcp.CallToolResult{Content: []mcp.Content{mcp.TextContent{Type: "text", Text: "done anyway"}}}, nil
+	})
+	result, err = late(context.Background(), newToolRequest("get_file_info", nil))
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+}
+
+func TestSmartSearchPartialResultsOnTimeout(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{"a-match.txt": "", "b.txt": "", "c-match.txt": ""})
+
+	// El reloj avanza en cada paso para que cada entrada notifique; al llegar a
+	// b.txt la llamada agota su timeout
+	ctx, cancel := context.WithCancelCause(context.WithValue(context.Background(), callActivityKey{}, &callActivity{timeout: time.Second}))
+	defer cancel(nil)
+	clock := time.Now()
+	reporter := &progressReporter{token: "t", now: func() time.Time { clock = clock.Add(time.Second); return clock }}
+	reporter.notify = func(_ context.Context, params map[string]any) error {
+		if strings.HasSuffix(params["message"].(string), "b.txt") {
+			cancel(errToolTimeout)
+		}
+		return nil
+	}
+	ctx = context.WithValue(ctx, progressKey{}, reporter)
+
+	text, _, err := handler.performSmartSearch(ctx, dir, "match", regexp.MustCompile("match"), false, nil, false, nil, func(p string) string { return p }, nil, searchContentOptions{})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(text, "⏱️ Operation timed out after 1s; partial results:\n\n"), text)
+	assert.Contains(t, text, "a-match.txt")
+	assert.NotContains(t, text, "c-match.txt")
+}
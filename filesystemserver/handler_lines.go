@@ -0,0 +1,342 @@
+package filesystemserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// maxLineKeyLength - Bytes de cada línea que se comparan al buscar duplicados;
+	// las más largas se distinguen además por su longitud real
+	maxLineKeyLength = 1024
+	// maxTrackedLines - Líneas distintas que se recuerdan para detectar duplicados
+	maxTrackedLines = 50000
+	// maxDuplicateLineNumbers - Números de línea que se listan por duplicado
+	maxDuplicateLineNumbers = 20
+	// maxBlankRuns - Rachas de líneas en blanco que se detallan
+	maxBlankRuns = 100
+	// defaultLinesTop, maxLinesTop - Líneas más largas que se devuelven
+	defaultLinesTop = 5
+	maxLinesTop     = 100
+	// defaultMaxDuplicates, maxMaxDuplicates - Grupos de duplicados que se devuelven
+	defaultMaxDuplicates = 50
+	maxMaxDuplicates     = 500
+	// lineTextPreview - Caracteres de una línea que se muestran en el informe
+	lineTextPreview = 120
+)
+
+// lineAnalysisOptions - Opciones de analyze_lines
+type lineAnalysisOptions struct {
+	trim          bool
+	ignoreCase    bool
+	maxBlankLines int
+	top           int
+	maxDuplicates int
+}
+
+// duplicateTracker - Apariciones de una línea normalizada
+type duplicateTracker struct {
+	text  string
+	count int
+	lines []int
+}
+
+// handleAnalyzeLines - Líneas duplicadas, rachas de líneas en blanco y líneas más largas de un texto
+func (fs *FilesystemHandler) handleAnalyzeLines(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	output, err := getString(request.Params.Arguments, "output", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	opts := lineAnalysisOptions{
+		trim:          true,
+		maxBlankLines: 1,
+		top:           defaultLinesTop,
+		maxDuplicates: defaultMaxDuplicates,
+	}
+	if opts.trim, err = getBool(request.Params.Arguments, "trim_whitespace", opts.trim); err != nil {
+		return badArgError(err).result(), nil
+	}
+	if opts.ignoreCase, err = getBool(request.Params.Arguments, "ignore_case", false); err != nil {
+		return badArgError(err).result(), nil
+	}
+	maxBlankLines, err := getInt(request.Params.Arguments, "max_blank_lines", opts.maxBlankLines)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	top, err := getInt(request.Params.Arguments, "top", opts.top)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	maxDuplicates, err := getInt(request.Params.Arguments, "max_duplicates", opts.maxDuplicates)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if maxBlankLines >= 0 {
+		opts.maxBlankLines = maxBlankLines
+	}
+	if top >= 0 {
+		opts.top = min(top, maxLinesTop)
+	}
+	if maxDuplicates >= 0 {
+		opts.maxDuplicates = min(maxDuplicates, maxMaxDuplicates)
+	}
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	result, err := fs.analyzeLines(ctx, validPath, opts)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: string(data)},
+			},
+		}, nil
+	}
+
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatLineAnalysis(result)},
+		},
+	}, pathToResourceURI(validPath), result)
+}
+
+// analyzeLines - Recorre path línea a línea acumulando duplicados, rachas en
+// blanco, líneas más largas y longitudes
+func (fs *FilesystemHandler) analyzeLines(ctx context.Context, path string, opts lineAnalysisOptions) (*LineAnalysis, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	if mimeType := detectMimeType(path); !isTextFile(mimeType) {
+		return nil, fmt.Errorf("not a text file (%s)", mimeType)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	result := &LineAnalysis{
+		Path:         path,
+		Duplicates:   []DuplicateLine{},
+		BlankRuns:    []BlankRun{},
+		LongestLines: []LongLine{},
+	}
+	seen := make(map[string]*duplicateTracker)
+	var stats lineLengthStats
+	var bytesRead int64
+	blankStart, blankRun := 0, 0
+	progress := progressFrom(ctx)
+
+	endBlankRun := func() {
+		if blankRun > opts.maxBlankLines {
+			result.TotalBlankRuns++
+			if len(result.BlankRuns) < maxBlankRuns {
+				result.BlankRuns = append(result.BlankRuns, BlankRun{StartLine: blankStart, Length: blankRun})
+			}
+		}
+		blankRun = 0
+	}
+
+	reader := bufio.NewReader(file)
+	for lineNo := 1; ; lineNo++ {
+		if lineNo%10000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		raw, size, err := readLogLine(reader, maxLineKeyLength)
+		if err != nil {
+			break
+		}
+		bytesRead += int64(size) + 1
+		line := stripBOM(strings.TrimSuffix(string(raw), "\r"))
+		if lineNo == 1 {
+			size -= len(raw) - len(line)
+		}
+		result.Lines++
+		stats.add(size)
+		result.LongestLines = insertLongLine(result.LongestLines, LongLine{Line: lineNo, Length: size, Preview: truncateLine(line)}, opts.top)
+
+		if strings.TrimSpace(line) == "" {
+			result.BlankLines++
+			if blankRun == 0 {
+				blankStart = lineNo
+			}
+			blankRun++
+			continue
+		}
+		endBlankRun()
+
+		if opts.trim {
+			line = strings.TrimSpace(line)
+		}
+		key := line
+		if opts.ignoreCase {
+			key = strings.ToLower(key)
+		}
+		if size > len(raw) {
+			key += "\x00" + strconv.Itoa(size)
+		}
+		tracker, ok := seen[key]
+		if !ok {
+			if len(seen) >= maxTrackedLines {
+				result.Truncated = true
+				continue
+			}
+			tracker = &duplicateTracker{text: truncateLine(line)}
+			seen[key] = tracker
+		}
+		tracker.count++
+		if len(tracker.lines) < maxDuplicateLineNumbers {
+			tracker.lines = append(tracker.lines, lineNo)
+		}
+	}
+	endBlankRun()
+	fs.stats.addRead(int(bytesRead))
+	progress.read(path, bytesRead)
+
+	result.UniqueLines = len(seen)
+	result.MaxLineLength = stats.max
+	result.AvgLineLength = stats.avg()
+
+	var duplicates []DuplicateLine
+	for _, tracker := range seen {
+		if tracker.count > 1 {
+			duplicates = append(duplicates, DuplicateLine{Text: tracker.text, Count: tracker.count, Lines: tracker.lines})
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].Count != duplicates[j].Count {
+			return duplicates[i].Count > duplicates[j].Count
+		}
+		return duplicates[i].Lines[0] < duplicates[j].Lines[0]
+	})
+	result.DuplicateGroups = len(duplicates)
+	if len(duplicates) > opts.maxDuplicates {
+		duplicates = duplicates[:opts.maxDuplicates]
+	}
+	result.Duplicates = append(result.Duplicates, duplicates...)
+	return result, nil
+}
+
+// insertLongLine - Mantiene las n líneas más largas, por longitud descendente
+func insertLongLine(lines []LongLine, line LongLine, n int) []LongLine {
+	if n <= 0 {
+		return lines
+	}
+	pos := sort.Search(len(lines), func(i int) bool { return lines[i].Length < line.Length })
+	if pos >= n {
+		return lines
+	}
+	lines = append(lines, LongLine{})
+	copy(lines[pos+1:], lines[pos:])
+	lines[pos] = line
+	if len(lines) > n {
+		lines = lines[:n]
+	}
+	return lines
+}
+
+// truncateLine - Vista previa de una línea limitada a lineTextPreview caracteres
+func truncateLine(line string) string {
+	if runes := []rune(line); len(runes) > lineTextPreview {
+		return string(runes[:lineTextPreview]) + "…"
+	}
+	return line
+}
+
+// formatLineAnalysis - Resumen legible de analyze_lines
+func formatLineAnalysis(result *LineAnalysis) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📄 Line analysis for %s\n", result.Path)
+	fmt.Fprintf(&b, "📊 %d lines (%d blank, %d distinct), max length %d, average %.1f\n",
+		result.Lines, result.BlankLines, result.UniqueLines, result.MaxLineLength, result.AvgLineLength)
+	if result.Truncated {
+		fmt.Fprintf(&b, "⚠️ Only the first %d distinct lines were tracked for duplicates\n", maxTrackedLines)
+	}
+
+	if result.DuplicateGroups == 0 {
+		b.WriteString("\n✅ No duplicate lines\n")
+	} else {
+		fmt.Fprintf(&b, "\n🔁 %d duplicated line(s):\n", result.DuplicateGroups)
+		for _, dup := range result.Duplicates {
+			lines := make([]string, len(dup.Lines))
+			for i, n := range dup.Lines {
+				lines[i] = strconv.Itoa(n)
+			}
+			more := ""
+			if dup.Count > len(dup.Lines) {
+				more = ", ..."
+			}
+			fmt.Fprintf(&b, "  %dx %q (lines %s%s)\n", dup.Count, dup.Text, strings.Join(lines, ", "), more)
+		}
+		if result.DuplicateGroups > len(result.Duplicates) {
+			fmt.Fprintf(&b, "  ... %d more\n", result.DuplicateGroups-len(result.Duplicates))
+		}
+	}
+
+	if result.TotalBlankRuns > 0 {
+		fmt.Fprintf(&b, "\n⬜ %d run(s) of consecutive blank lines:\n", result.TotalBlankRuns)
+		for _, run := range result.BlankRuns {
+			fmt.Fprintf(&b, "  lines %d-%d (%d blank)\n", run.StartLine, run.StartLine+run.Length-1, run.Length)
+		}
+		if result.TotalBlankRuns > len(result.BlankRuns) {
+			fmt.Fprintf(&b, "  ... %d more\n", result.TotalBlankRuns-len(result.BlankRuns))
+		}
+	}
+
+	if len(result.LongestLines) > 0 {
+		b.WriteString("\n📏 Longest lines:\n")
+		for _, line := range result.LongestLines {
+			fmt.Fprintf(&b, "  %d: %d bytes  %s\n", line.Line, line.Length, line.Preview)
+		}
+	}
+	return b.String()
+}
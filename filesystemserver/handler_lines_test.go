@@ -0,0 +1,84 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeLines(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	long := strings.Repeat("x", 2000)
+	path := filepath.Join(dir, "notes.txt")
+	content := "\ufeffalpha\n  beta\n\n\n\nALPHA\nbeta  \r\n" + long + "\n\nbeta\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	result, err := handler.handleAnalyzeLines(context.Background(), newToolRequest("analyze_lines", map[string]interface{}{
+		"path": path,
+	}))
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "10 lines (4 blank, 4 distinct), max length 2000")
+	assert.Contains(t, text, `3x "beta" (lines 2, 7, 10)`)
+	assert.Contains(t, text, "lines 3-5 (3 blank)")
+
+	var report LineAnalysis
+	decodeStructured(t, result, &report)
+	assert.Equal(t, 10, report.Lines)
+	assert.Equal(t, 2000, report.MaxLineLength)
+	assert.InDelta(t, 2026.0/10, report.AvgLineLength, 0.001)
+	assert.Equal(t, []DuplicateLine{{Text: "beta", Count: 3, Lines: []int{2, 7, 10}}}, report.Duplicates)
+	assert.Equal(t, []BlankRun{{StartLine: 3, Length: 3}}, report.BlankRuns)
+	require.Len(t, report.LongestLines, 5)
+	assert.Equal(t, LongLine{Line: 8, Length: 2000, Preview: strings.Repeat("x", lineTextPreview) + "…"}, report.LongestLines[0])
+	assert.Equal(t, []int{2, 7}, []int{report.LongestLines[1].Line, report.LongestLines[2].Line})
+	assert.Equal(t, LongLine{Line: 1, Length: 5, Preview: "alpha"}, report.LongestLines[3])
+
+	// Sin recortar, ignorando mayúsculas y con todas las rachas en blanco, en JSON
+	result, err = handler.handleAnalyzeLines(context.Background(), newToolRequest("analyze_lines", map[string]interface{}{
+		"path":            path,
+		"trim_whitespace": false,
+		"ignore_case":     true,
+		"max_blank_lines": float64(0),
+		"top":             float64(1),
+		"output":          "json",
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	var raw LineAnalysis
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &raw))
+	assert.Equal(t, []DuplicateLine{{Text: "alpha", Count: 2, Lines: []int{1, 6}}}, raw.Duplicates)
+	assert.Equal(t, 2, raw.TotalBlankRuns)
+	assert.Equal(t, []BlankRun{{StartLine: 3, Length: 3}, {StartLine: 9, Length: 1}}, raw.BlankRuns)
+	require.Len(t, raw.LongestLines, 1)
+	assert.Equal(t, 8, raw.LongestLines[0].Line)
+
+	binary := filepath.Join(dir, "logo.png")
+	require.NoError(t, os.WriteFile(binary, []byte("\x89PNG\r\n\x1a\n\x00\x00"), 0644))
+	for _, bad := range []map[string]interface{}{
+		{},
+		{"path": dir},
+		{"path": binary},
+		{"path": filepath.Join(dir, "missing.txt")},
+	} {
+		result, err = handler.handleAnalyzeLines(context.Background(), newToolRequest("analyze_lines", bad))
+		require.NoError(t, err)
+		assert.True(t, result.IsError, bad)
+	}
+}
+
+func TestInsertLongLine(t *testing.T) {
+	var lines []LongLine
+	for i, length := range []int{3, 9, 1, 9, 5} {
+		lines = insertLongLine(lines, LongLine{Line: i + 1, Length: length}, 3)
+	}
+	assert.Equal(t, []LongLine{{Line: 2, Length: 9}, {Line: 4, Length: 9}, {Line: 5, Length: 5}}, lines)
+	assert.Empty(t, insertLongLine(nil, LongLine{Line: 1, Length: 1}, 0))
+}
@@ -0,0 +1,526 @@
+package filesystemserver
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultLogLimit - Líneas que devuelve log_query en modo lines si no se indica limit
+	defaultLogLimit = 100
+	// maxLogLimit - Máximo de líneas por llamada
+	maxLogLimit = 1000
+	// defaultLogTop - Grupos que devuelve el modo summary si no se indica top
+	defaultLogTop = 20
+	// maxLogTop - Máximo de grupos por llamada
+	maxLogTop = 200
+	// maxLogLineLength - Bytes que se conservan de cada línea; el resto se descarta al leer
+	maxLogLineLength = 4096
+	// maxLogGroups - Mensajes distintos que se agrupan; los demás cuentan en OtherLines
+	maxLogGroups = 10000
+	// maxLogMessageLength - Longitud máxima de un mensaje normalizado
+	maxLogMessageLength = 200
+)
+
+// logTimestampFormat - Prefijo de fecha reconocido en las líneas de log
+type logTimestampFormat struct {
+	name    string
+	pattern *regexp.Regexp
+	parse   func(value string, year int) (time.Time, error)
+}
+
+// logTimestampFormats - Formatos que se prueban hasta que uno encaja; el primero
+// que aparece en el fichero se usa para el resto de líneas
+var logTimestampFormats = []logTimestampFormat{
+	{
+		name:    "iso8601",
+		pattern: regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?)\]?`),
+		parse: func(value string, _ int) (time.Time, error) {
+			value = strings.Replace(value[:10]+"T"+value[11:], ",", ".", 1)
+			return parseLogTime(value, "2006-01-02T15:04:05Z07:00", "2006-01-02T15:04:05Z0700", "2006-01-02T15:04:05")
+		},
+	},
+	{
+		name:    "go-log",
+		pattern: regexp.MustCompile(`^\[?(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)?)\]?`),
+		parse: func(value string, _ int) (time.Time, error) {
+			return parseLogTime(value, "2006/01/02 15:04:05")
+		},
+	},
+	{
+		name:    "syslog",
+		pattern: regexp.MustCompile(`^\[?([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2})\]?`),
+		parse: func(value string, year int) (time.Time, error) {
+			// syslog no lleva año: se toma el de la última modificación del fichero
+			return parseLogTime(fmt.Sprintf("%d %s", year, value), "2006 Jan _2 15:04:05")
+		},
+	},
+}
+
+// logBoundLayouts - Formatos aceptados en since/until además de HH:MM y duraciones
+var logBoundLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006/01/02 15:04:05",
+	"2006-01-02",
+}
+
+// logClockPattern - since/until con solo la hora, referida al día de la primera línea fechada
+var logClockPattern = regexp.MustCompile(`^(\d{1,2}):(\d{2})(?::(\d{2}))?$`)
+
+// logNormalizers - Sustituciones que convierten una línea en su mensaje agrupable
+var logNormalizers = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), "<uuid>"},
+	{regexp.MustCompile(`\b0x[0-9a-fA-F]+\b`), "<hex>"},
+	{regexp.MustCompile(`\b[0-9a-fA-F]{16,}\b`), "<hex>"},
+	{regexp.MustCompile(`"[^"]*"`), `"<str>"`},
+	{regexp.MustCompile(`\b\d+(?:[.:]\d+)*`), "<n>"},
+	{regexp.MustCompile(`\s+`), " "},
+}
+
+// logBound - Límite since/until; daily indica que solo hay hora y falta fijar el día
+type logBound struct {
+	at    time.Time
+	clock time.Duration
+	set   bool
+	daily bool
+}
+
+// handleLogQuery - Filtra un log por fecha y patrón, o lo resume agrupando mensajes
+func (fs *FilesystemHandler) handleLogQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	since, err := getString(request.Params.Arguments, "since", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	until, err := getString(request.Params.Arguments, "until", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	pattern, err := getString(request.Params.Arguments, "pattern", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	ignoreCase, err := getBool(request.Params.Arguments, "ignore_case", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	tail, err := getBool(request.Params.Arguments, "tail", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	mode, err := getString(request.Params.Arguments, "mode", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if mode == "" {
+		mode = "lines"
+	}
+
+	limit, err := getInt(request.Params.Arguments, "limit", 0)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if limit <= 0 {
+		limit = defaultLogLimit
+	}
+	limit = min(limit, maxLogLimit)
+	top, err := getInt(request.Params.Arguments, "top", 0)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if top <= 0 {
+		top = defaultLogTop
+	}
+	top = min(top, maxLogTop)
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+	if mode != "lines" && mode != "summary" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: unknown mode '%s' (use lines or summary)", mode)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var filter *regexp.Regexp
+	if pattern != "" {
+		if ignoreCase {
+			pattern = "(?i)" + pattern
+		}
+		if filter, err = compileUserPattern(pattern); err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: invalid pattern: %v", err)},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	now := time.Now()
+	sinceBound, err := parseLogBound("since", since, now)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	untilBound, err := parseLogBound("until", until, now)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	result, err := fs.queryLog(ctx, validPath, sinceBound, untilBound, filter, mode, limit, top, tail)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatLogQuery(result)},
+		},
+	}, pathToResourceURI(validPath), result)
+}
+
+// queryLog - Recorre el log línea a línea sin cargarlo entero. Las líneas sin fecha
+// (trazas, continuaciones) heredan la de la última línea fechada.
+func (fs *FilesystemHandler) queryLog(ctx context.Context, path string, since, until logBound, filter *regexp.Regexp, mode string, limit, top int, tail bool) (*LogQueryResult, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	file, compression, err := openDecompressed(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	result := &LogQueryResult{
+		Path:            path,
+		Compression:     compression,
+		Mode:            mode,
+		TimestampFormat: "none",
+		Lines:           []LogLine{},
+	}
+	if filter != nil {
+		result.Pattern = filter.String()
+	}
+
+	var (
+		format  *logTimestampFormat
+		current time.Time
+		dated   bool
+		next    int
+		groups  = map[string]*LogGroup{}
+	)
+	year := info.ModTime().Year()
+	reader := bufio.NewReaderSize(file, 64*1024)
+	progress := progressFrom(ctx)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		raw, size, err := readLogLine(reader, maxLogLineLength)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.LinesRead++
+		progress.read(path, int64(size))
+		line := string(raw)
+
+		// El formato se fija con la primera línea que encaja en alguno
+		prefix := ""
+		if format == nil {
+			for i := range logTimestampFormats {
+				if logTimestampFormats[i].pattern.MatchString(line) {
+					format = &logTimestampFormats[i]
+					result.TimestampFormat = format.name
+					break
+				}
+			}
+		}
+		if format != nil {
+			if m := format.pattern.FindStringSubmatch(line); m != nil {
+				if t, err := format.parse(m[1], year); err == nil {
+					current, prefix = t, m[0]
+					if !dated {
+						dated = true
+						since.resolve(t)
+						until.resolve(t)
+					}
+				}
+			}
+		}
+
+		if since.set || until.set {
+			if !dated || (since.set && current.Before(since.at)) || (until.set && current.After(until.at)) {
+				continue
+			}
+		}
+		if filter != nil && !filter.MatchString(line) {
+			continue
+		}
+		result.MatchedLines++
+
+		timestamp := ""
+		if dated {
+			timestamp = current.Format(time.RFC3339)
+		}
+		if size > len(raw) {
+			line += "…"
+		}
+
+		if mode == "summary" {
+			message := normalizeLogMessage(strings.TrimPrefix(line, prefix))
+			group := groups[message]
+			if group == nil {
+				if len(groups) >= maxLogGroups {
+					result.OtherLines++
+					continue
+				}
+				group = &LogGroup{Message: message, FirstLine: result.LinesRead, First: timestamp, Example: line}
+				groups[message] = group
+			}
+			group.Count++
+			group.LastLine = result.LinesRead
+			group.Last = timestamp
+			continue
+		}
+
+		entry := LogLine{Line: result.LinesRead, Timestamp: timestamp, Text: line}
+		switch {
+		case len(result.Lines) < limit:
+			result.Lines = append(result.Lines, entry)
+		case tail:
+			// Buffer circular con las últimas limit coincidencias
+			result.Lines[next] = entry
+			next = (next + 1) % limit
+		}
+	}
+	result.Lines = append(result.Lines[next:], result.Lines[:next]...)
+
+	if since.set {
+		result.Since = since.at.Format(time.RFC3339)
+	}
+	if until.set {
+		result.Until = until.at.Format(time.RFC3339)
+	}
+	if (since.set || until.set) && !dated {
+		result.Note = "no timestamps recognised, so since/until matched nothing"
+	}
+
+	if mode == "summary" {
+		result.DistinctMessages = len(groups)
+		for _, group := range groups {
+			result.Groups = append(result.Groups, *group)
+		}
+		sort.Slice(result.Groups, func(i, j int) bool {
+			if result.Groups[i].Count != result.Groups[j].Count {
+				return result.Groups[i].Count > result.Groups[j].Count
+			}
+			return result.Groups[i].FirstLine < result.Groups[j].FirstLine
+		})
+		if len(result.Groups) > top {
+			result.Groups = result.Groups[:top]
+		}
+	} else {
+		result.Truncated = result.MatchedLines > len(result.Lines)
+	}
+	return result, nil
+}
+
+// readLogLine - Lee una línea sin el salto final conservando como mucho max bytes;
+// size es la longitud real para detectar las líneas recortadas
+func readLogLine(reader *bufio.Reader, max int) ([]byte, int, error) {
+	var line []byte
+	size := 0
+	for {
+		chunk, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			if err == io.EOF && size > 0 {
+				return line, size, nil
+			}
+			return nil, 0, err
+		}
+		size += len(chunk)
+		if room := max - len(line); room > 0 {
+			line = append(line, chunk[:min(room, len(chunk))]...)
+		}
+		if !isPrefix {
+			return line, size, nil
+		}
+	}
+}
+
+// parseLogTime - Prueba los layouts en la zona local, salvo que el valor lleve la suya
+func parseLogTime(value string, layouts ...string) (time.Time, error) {
+	var err error
+	for _, layout := range layouts {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// parseLogBound - Interpreta since/until: fecha completa, solo hora o duración hacia atrás
+func parseLogBound(name, value string, now time.Time) (logBound, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return logBound{}, nil
+	}
+	if m := logClockPattern.FindStringSubmatch(value); m != nil {
+		var hour, minute, second int
+		fmt.Sscanf(m[1]+" "+m[2], "%d %d", &hour, &minute)
+		if m[3] != "" {
+			fmt.Sscanf(m[3], "%d", &second)
+		}
+		if hour < 24 && minute < 60 && second < 60 {
+			clock := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second
+			return logBound{clock: clock, set: true, daily: true}, nil
+		}
+	}
+	if d, err := time.ParseDuration(strings.TrimPrefix(value, "-")); err == nil {
+		return logBound{at: now.Add(-d), set: true}, nil
+	}
+	if t, err := parseLogTime(value, logBoundLayouts...); err == nil {
+		return logBound{at: t, set: true}, nil
+	}
+	return logBound{}, fmt.Errorf("invalid %s '%s': use RFC3339, 'YYYY-MM-DD HH:MM[:SS]', 'HH:MM[:SS]' or a duration like 15m", name, value)
+}
+
+// resolve - Fija el día de un límite con solo la hora usando la primera línea fechada
+func (b *logBound) resolve(first time.Time) {
+	if b.daily {
+		year, month, day := first.Date()
+		b.at = time.Date(year, month, day, 0, 0, 0, 0, first.Location()).Add(b.clock)
+		b.daily = false
+	}
+}
+
+// normalizeLogMessage - Sustituye números, ids y cadenas para agrupar líneas equivalentes
+func normalizeLogMessage(message string) string {
+	message = strings.TrimLeft(message, " \t-:|")
+	for _, n := range logNormalizers {
+		message = n.pattern.ReplaceAllString(message, n.replacement)
+	}
+	message = strings.TrimSpace(message)
+	if len(message) > maxLogMessageLength {
+		message = message[:maxLogMessageLength] + "…"
+	}
+	return message
+}
+
+// formatLogQuery - Resumen, líneas coincidentes o grupos de mensajes
+func formatLogQuery(result *LogQueryResult) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📜 %s", result.Path))
+	if result.Compression != "" {
+		b.WriteString(fmt.Sprintf(" (%s)", result.Compression))
+	}
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("Timestamp format: %s | Lines read: %d | Matching: %d\n", result.TimestampFormat, result.LinesRead, result.MatchedLines))
+	if result.Since != "" || result.Until != "" {
+		from, to := result.Since, result.Until
+		if from == "" {
+			from = "start"
+		}
+		if to == "" {
+			to = "end"
+		}
+		b.WriteString(fmt.Sprintf("Range: %s → %s\n", from, to))
+	}
+	if result.Pattern != "" {
+		b.WriteString(fmt.Sprintf("Pattern: %s\n", result.Pattern))
+	}
+	if result.Note != "" {
+		b.WriteString(fmt.Sprintf("⚠️ %s\n", result.Note))
+	}
+	b.WriteString("\n")
+
+	if result.Mode == "summary" {
+		if len(result.Groups) == 0 {
+			b.WriteString("No matching lines.\n")
+			return b.String()
+		}
+		b.WriteString(fmt.Sprintf("📊 Top %d of %d distinct messages:\n", len(result.Groups), result.DistinctMessages))
+		for _, group := range result.Groups {
+			b.WriteString(fmt.Sprintf("%7d × %s\n", group.Count, group.Message))
+			span := fmt.Sprintf("lines %d-%d", group.FirstLine, group.LastLine)
+			if group.First != "" {
+				span += fmt.Sprintf(", %s → %s", group.First, group.Last)
+			}
+			b.WriteString(fmt.Sprintf("          %s\n", span))
+		}
+		if result.OtherLines > 0 {
+			b.WriteString(fmt.Sprintf("\n%d more lines not grouped (over %d distinct messages)\n", result.OtherLines, maxLogGroups))
+		}
+		return b.String()
+	}
+
+	if len(result.Lines) == 0 {
+		b.WriteString("No matching lines.\n")
+		return b.String()
+	}
+	for _, line := range result.Lines {
+		b.WriteString(fmt.Sprintf("%7d │ %s\n", line.Line, line.Text))
+	}
+	if result.Truncated {
+		b.WriteString(fmt.Sprintf("\n… %d of %d matching lines shown; narrow since/until or pattern, or raise limit\n", len(result.Lines), result.MatchedLines))
+	}
+	return b.String()
+}
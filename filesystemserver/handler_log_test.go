@@ -0,0 +1,183 @@
+package filesystemserver
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const logSample = "2024-03-01T13:59:58Z INFO starting\n" +
+	"2024-03-01T14:02:10Z ERROR request 17 failed for user \"bob\"\n" +
+	"2024-03-01T14:03:00Z INFO request 18 served in 12ms\n" +
+	"2024-03-01T14:03:05Z ERROR request 19 failed for user \"alice\"\n" +
+	"panic: boom\n" +
+	"2024-03-01 14:05:30,250 ERROR request 20 failed for user \"carol\"\n" +
+	"2024-03-01T14:06:00Z WARN disk at 91%\n" +
+	"2024-03-01T14:10:00Z ERROR upstream 0xdeadbeef timed out\n"
+
+func TestQueryLogLines(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte(logSample), 0644))
+
+	since, err := parseLogBound("since", "14:03", time.Now())
+	require.NoError(t, err)
+	result, err := handler.queryLog(context.Background(), path, since, logBound{}, regexp.MustCompile("ERROR"), "lines", 2, 20, false)
+	require.NoError(t, err)
+	assert.Equal(t, "iso8601", result.TimestampFormat)
+	assert.Equal(t, 8, result.LinesRead)
+	assert.Equal(t, 3, result.MatchedLines)
+	assert.True(t, result.Truncated)
+	require.Len(t, result.Lines, 2)
+	assert.Equal(t, 4, result.Lines[0].Line)
+	assert.Equal(t, 6, result.Lines[1].Line)
+	assert.Equal(t, "2024-03-01T14:03:00Z", result.Since)
+
+	// tail devuelve las últimas coincidencias en orden
+	result, err = handler.queryLog(context.Background(), path, since, logBound{}, regexp.MustCompile("ERROR"), "lines", 2, 20, true)
+	require.NoError(t, err)
+	assert.Equal(t, 6, result.Lines[0].Line)
+	assert.Equal(t, 8, result.Lines[1].Line)
+
+	// Las líneas sin fecha heredan la anterior y caen dentro del rango
+	until, err := parseLogBound("until", "2024-03-01T14:04:00Z", time.Now())
+	require.NoError(t, err)
+	result, err = handler.queryLog(context.Background(), path, since, until, nil, "lines", 10, 20, false)
+	require.NoError(t, err)
+	require.Len(t, result.Lines, 3)
+	assert.Equal(t, "panic: boom", result.Lines[2].Text)
+	assert.Equal(t, "2024-03-01T14:03:05Z", result.Lines[2].Timestamp)
+}
+
+func TestQueryLogSummary(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte(logSample), 0644))
+
+	result, err := handler.queryLog(context.Background(), path, logBound{}, logBound{}, regexp.MustCompile("ERROR"), "summary", 100, 20, false)
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.MatchedLines)
+	assert.Equal(t, 2, result.DistinctMessages)
+	require.Len(t, result.Groups, 2)
+	assert.Equal(t, `ERROR request <n> failed for user "<str>"`, result.Groups[0].Message)
+	assert.Equal(t, 3, result.Groups[0].Count)
+	assert.Equal(t, 2, result.Groups[0].FirstLine)
+	assert.Equal(t, 6, result.Groups[0].LastLine)
+	assert.Equal(t, "ERROR upstream <hex> timed out", result.Groups[1].Message)
+	assert.Equal(t, "INFO request <n> served in <n>ms", normalizeLogMessage(" INFO request 18 served in 12ms"))
+
+	result, err = handler.queryLog(context.Background(), path, logBound{}, logBound{}, nil, "summary", 100, 1, false)
+	require.NoError(t, err)
+	assert.Len(t, result.Groups, 1)
+	assert.Equal(t, 6, result.DistinctMessages)
+}
+
+func TestQueryLogFormatsAndGzip(t *testing.T) {
+	handler, dir := newTestHandler(t)
+
+	goLog := filepath.Join(dir, "go.log.gz")
+	file, err := os.Create(goLog)
+	require.NoError(t, err)
+	gz := gzip.NewWriter(file)
+	gz.Write([]byte("2024/03/01 14:00:00 listening\n2024/03/01 14:30:00.123456 shutting down\n"))
+	require.NoError(t, gz.Close())
+	require.NoError(t, file.Close())
+
+	since, err := parseLogBound("since", "2024-03-01 14:15", time.Now())
+	require.NoError(t, err)
+	result, err := handler.queryLog(context.Background(), goLog, since, logBound{}, nil, "lines", 10, 20, false)
+	require.NoError(t, err)
+	assert.Equal(t, "gzip", result.Compression)
+	assert.Equal(t, "go-log", result.TimestampFormat)
+	require.Len(t, result.Lines, 1)
+	assert.Equal(t, "2024/03/01 14:30:00.123456 shutting down", result.Lines[0].Text)
+
+	syslog := filepath.Join(dir, "syslog")
+	require.NoError(t, os.WriteFile(syslog, []byte("Mar  1 09:00:01 host sshd[1]: accepted\nMar  1 09:30:00 host cron[2]: job\n"), 0644))
+	since, err = parseLogBound("since", "09:15", time.Now())
+	require.NoError(t, err)
+	result, err = handler.queryLog(context.Background(), syslog, since, logBound{}, nil, "lines", 10, 20, false)
+	require.NoError(t, err)
+	assert.Equal(t, "syslog", result.TimestampFormat)
+	require.Len(t, result.Lines, 1)
+	assert.Equal(t, 2, result.Lines[0].Line)
+
+	// Sin fechas reconocibles since no deja pasar nada, y las líneas largas se recortan
+	plain := filepath.Join(dir, "plain.log")
+	require.NoError(t, os.WriteFile(plain, []byte(strings.Repeat("x", maxLogLineLength+10)+"\nshort"), 0644))
+	result, err = handler.queryLog(context.Background(), plain, since, logBound{}, nil, "lines", 10, 20, false)
+	require.NoError(t, err)
+	assert.Equal(t, "none", result.TimestampFormat)
+	assert.Zero(t, result.MatchedLines)
+	assert.NotEmpty(t, result.Note)
+
+	result, err = handler.queryLog(context.Background(), plain, logBound{}, logBound{}, nil, "lines", 10, 20, false)
+	require.NoError(t, err)
+	require.Len(t, result.Lines, 2)
+	assert.Equal(t, strings.Repeat("x", maxLogLineLength)+"…", result.Lines[0].Text)
+	assert.Equal(t, "short", result.Lines[1].Text)
+}
+
+func TestParseLogBound(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	bound, err := parseLogBound("since", "15m", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-15*time.Minute), bound.at)
+
+	bound, err = parseLogBound("since", "2024-03-01T10:00:00+02:00", now)
+	require.NoError(t, err)
+	assert.True(t, bound.at.Equal(time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)))
+
+	bound, err = parseLogBound("since", "", now)
+	require.NoError(t, err)
+	assert.False(t, bound.set)
+
+	_, err = parseLogBound("until", "yesterday", now)
+	assert.ErrorContains(t, err, "invalid until 'yesterday'")
+	_, err = parseLogBound("until", "25:00", now)
+	assert.Error(t, err)
+}
+
+func TestHandleLogQuery(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte(logSample), 0644))
+
+	result, err := handler.handleLogQuery(context.Background(), newToolRequest("log_query", map[string]interface{}{
+		"path":        path,
+		"pattern":     "error",
+		"ignore_case": true,
+		"mode":        "summary",
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "Timestamp format: iso8601 | Lines read: 8 | Matching: 4")
+	assert.Contains(t, text, `      3 × ERROR request <n> failed for user "<str>"`)
+
+	var decoded LogQueryResult
+	decodeStructured(t, result, &decoded)
+	assert.Equal(t, "summary", decoded.Mode)
+	assert.Len(t, decoded.Groups, 2)
+
+	for _, args := range []map[string]interface{}{
+		{"path": path, "mode": "grouped"},
+		{"path": path, "pattern": "("},
+		{"path": path, "since": "soon"},
+		{"pattern": "ERROR"},
+	} {
+		result, err = handler.handleLogQuery(context.Background(), newToolRequest("log_query", args))
+		require.NoError(t, err)
+		assert.True(t, result.IsError, args)
+	}
+}
@@ -0,0 +1,437 @@
+package filesystemserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// manifestParsers - Manifiestos reconocidos en la raíz del proyecto, en orden de salida
+var manifestParsers = []struct {
+	file      string
+	ecosystem string
+	parse     func(content string, manifest *DependencyManifest) error
+}{
+	{"go.mod", "go", parseGoMod},
+	{"package.json", "npm", parsePackageJSON},
+	{"requirements.txt", "python", parseRequirementsTxt},
+	{"pyproject.toml", "python", parsePyproject},
+	{"Cargo.toml", "cargo", parseCargoToml},
+}
+
+// parseProjectManifests - Lee los manifiestos presentes; un archivo mal formado
+// se informa en Error sin interrumpir el análisis
+func (fs *FilesystemHandler) parseProjectManifests(root string) []DependencyManifest {
+	var manifests []DependencyManifest
+
+	for _, parser := range manifestParsers {
+		path := filepath.Join(root, parser.file)
+		if _, err := fs.validatePath(path); err != nil {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		manifest := DependencyManifest{File: parser.file, Ecosystem: parser.ecosystem, Dependencies: []string{}}
+		if info.Size() > MAX_INLINE_SIZE {
+			manifest.Error = fmt.Sprintf("could not parse %s: file too large", parser.file)
+			manifests = append(manifests, manifest)
+			continue
+		}
+
+		data, err := fs.readFile(path)
+		if err == nil {
+			err = parser.parse(string(data), &manifest)
+		}
+		if err != nil {
+			manifest = DependencyManifest{
+				File:         parser.file,
+				Ecosystem:    parser.ecosystem,
+				Dependencies: []string{},
+				Error:        fmt.Sprintf("could not parse %s: %v", parser.file, err),
+			}
+		}
+		sort.Strings(manifest.Dependencies)
+		sort.Strings(manifest.DevDependencies)
+		manifests = append(manifests, manifest)
+	}
+
+	return manifests
+}
+
+// parseGoMod - Módulo, versión de Go y requires directos (sin // indirect)
+func parseGoMod(content string, manifest *DependencyManifest) error {
+	inRequire := false
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		if inRequire {
+			if line == ")" {
+				inRequire = false
+				continue
+			}
+			addGoRequire(line, manifest)
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "module":
+			if len(fields) < 2 {
+				return fmt.Errorf("line %d: module directive without path", i+1)
+			}
+			manifest.Name = strings.Trim(fields[1], `"`)
+		case "go":
+			if len(fields) < 2 {
+				return fmt.Errorf("line %d: go directive without version", i+1)
+			}
+			manifest.Version = fields[1]
+		case "require":
+			if len(fields) > 1 && fields[1] == "(" {
+				inRequire = true
+			} else {
+				addGoRequire(strings.TrimSpace(strings.TrimPrefix(line, "require")), manifest)
+			}
+		}
+	}
+
+	if inRequire {
+		return fmt.Errorf("unterminated require block")
+	}
+	if manifest.Name == "" {
+		return fmt.Errorf("missing module directive")
+	}
+	return nil
+}
+
+// addGoRequire - Añade "path version" salvo las dependencias indirectas
+func addGoRequire(line string, manifest *DependencyManifest) {
+	if strings.Contains(line, "// indirect") {
+		return
+	}
+	if fields := strings.Fields(line); len(fields) >= 2 {
+		manifest.Dependencies = append(manifest.Dependencies, fields[0])
+	}
+}
+
+// parsePackageJSON - Nombre, scripts y dependencias de un package.json
+func parsePackageJSON(content string, manifest *DependencyManifest) error {
+	var pkg struct {
+		Name            string            `json:"name"`
+		Version         string            `json:"version"`
+		Scripts         map[string]string `json:"scripts"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal([]byte(content), &pkg); err != nil {
+		return err
+	}
+
+	manifest.Name = pkg.Name
+	manifest.Version = pkg.Version
+	for name := range pkg.Scripts {
+		manifest.Scripts = append(manifest.Scripts, name)
+	}
+	sort.Strings(manifest.Scripts)
+	for name := range pkg.Dependencies {
+		manifest.Dependencies = append(manifest.Dependencies, name)
+	}
+	for name := range pkg.DevDependencies {
+		manifest.DevDependencies = append(manifest.DevDependencies, name)
+	}
+	return nil
+}
+
+// parseRequirementsTxt - Nombres de paquete de un requirements.txt
+func parseRequirementsTxt(content string, manifest *DependencyManifest) error {
+	for _, line := range strings.Split(content, "\n") {
+		if idx := strings.Index(line, " #"); idx >= 0 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+		// Comentarios, opciones (-r, -e, --index-url) y URLs no son nombres de paquete
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") || strings.Contains(line, "://") {
+			continue
+		}
+		if name := pythonRequirementName(line); name != "" {
+			manifest.Dependencies = append(manifest.Dependencies, name)
+		}
+	}
+	return nil
+}
+
+// pythonRequirementName - "requests[socks]>=2.0; python_version>'3'" -> "requests"
+func pythonRequirementName(spec string) string {
+	end := strings.IndexAny(spec, "=<>!~[;@ (")
+	if end >= 0 {
+		spec = spec[:end]
+	}
+	return strings.TrimSpace(spec)
+}
+
+// parsePyproject - PEP 621 ([project]) o Poetry ([tool.poetry])
+func parsePyproject(content string, manifest *DependencyManifest) error {
+	doc, err := parseSimpleTOML(content)
+	if err != nil {
+		return err
+	}
+
+	if project, ok := doc["project"]; ok {
+		manifest.Name = tomlString(project["name"])
+		manifest.Version = tomlString(project["version"])
+		for _, spec := range tomlStringArray(project["dependencies"]) {
+			if name := pythonRequirementName(spec); name != "" {
+				manifest.Dependencies = append(manifest.Dependencies, name)
+			}
+		}
+		for _, spec := range tomlStringArray(doc["project.optional-dependencies"]["dev"]) {
+			if name := pythonRequirementName(spec); name != "" {
+				manifest.DevDependencies = append(manifest.DevDependencies, name)
+			}
+		}
+	}
+
+	if poetry, ok := doc["tool.poetry"]; ok {
+		if manifest.Name == "" {
+			manifest.Name = tomlString(poetry["name"])
+			manifest.Version = tomlString(poetry["version"])
+		}
+		for name := range doc["tool.poetry.dependencies"] {
+			if name != "python" {
+				manifest.Dependencies = append(manifest.Dependencies, name)
+			}
+		}
+		for _, section := range []string{"tool.poetry.dev-dependencies", "tool.poetry.group.dev.dependencies"} {
+			for name := range doc[section] {
+				manifest.DevDependencies = append(manifest.DevDependencies, name)
+			}
+		}
+	}
+	return nil
+}
+
+// parseCargoToml - Paquete y dependencias de un Cargo.toml
+func parseCargoToml(content string, manifest *DependencyManifest) error {
+	doc, err := parseSimpleTOML(content)
+	if err != nil {
+		return err
+	}
+
+	manifest.Name = tomlString(doc["package"]["name"])
+	manifest.Version = tomlString(doc["package"]["version"])
+	for name := range doc["dependencies"] {
+		manifest.Dependencies = append(manifest.Dependencies, name)
+	}
+	for name := range doc["dev-dependencies"] {
+		manifest.DevDependencies = append(manifest.DevDependencies, name)
+	}
+	// [dependencies.serde] es otra forma de declarar una dependencia
+	for section := range doc {
+		if name, ok := strings.CutPrefix(section, "dependencies."); ok {
+			manifest.Dependencies = appendUnique(manifest.Dependencies, name)
+		} else if name, ok := strings.CutPrefix(section, "dev-dependencies."); ok {
+			manifest.DevDependencies = appendUnique(manifest.DevDependencies, name)
+		}
+	}
+	return nil
+}
+
+// appendUnique - Añade value si no está ya en list
+func appendUnique(list []string, value string) []string {
+	if containsString(list, value) {
+		return list
+	}
+	return append(list, value)
+}
+
+// parseSimpleTOML - Subconjunto de TOML suficiente para manifiestos: tablas,
+// claves simples y arrays en varias líneas; los valores quedan sin interpretar
+func parseSimpleTOML(content string) (map[string]map[string]string, error) {
+	doc := map[string]map[string]string{"": {}}
+	section := ""
+
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(stripTOMLComment(lines[i]))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: malformed table header", i+1)
+			}
+			section = strings.TrimSpace(strings.Trim(line, "[]"))
+			if _, ok := doc[section]; !ok {
+				doc[section] = map[string]string{}
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", i+1)
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"'`)
+		value = strings.TrimSpace(value)
+
+		// Arrays y tablas en línea pueden continuar en las líneas siguientes
+		start := i
+		for tomlOpenBrackets(value) > 0 {
+			i++
+			if i >= len(lines) {
+				return nil, fmt.Errorf("line %d: unterminated value for %s", start+1, key)
+			}
+			value += " " + strings.TrimSpace(stripTOMLComment(lines[i]))
+		}
+		doc[section][key] = value
+	}
+
+	return doc, nil
+}
+
+// stripTOMLComment - Quita un comentario # que no esté dentro de una cadena
+func stripTOMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// tomlOpenBrackets - Corchetes y llaves abiertos fuera de cadenas
+func tomlOpenBrackets(value string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+// tomlString - Valor de una cadena TOML, o "" si no lo es
+func tomlString(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if len(raw) >= 2 && raw[0] == '\'' && raw[len(raw)-1] == '\'' {
+		return raw[1 : len(raw)-1]
+	}
+	if s, err := strconv.Unquote(raw); err == nil && strings.HasPrefix(raw, `"`) {
+		return s
+	}
+	return ""
+}
+
+// tomlStringArray - Cadenas de un array TOML de una dimensión
+func tomlStringArray(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	if !strings.HasPrefix(raw, "[") || !strings.HasSuffix(raw, "]") {
+		return nil
+	}
+
+	var items []string
+	inner := raw[1 : len(raw)-1]
+	for len(inner) > 0 {
+		inner = strings.TrimLeft(inner, " \t,")
+		if inner == "" {
+			break
+		}
+		quote := inner[0]
+		if quote != '"' && quote != '\'' {
+			break
+		}
+		end := 1
+		for end < len(inner) && inner[end] != quote {
+			if inner[end] == '\\' && quote == '"' {
+				end++
+			}
+			end++
+		}
+		if end >= len(inner) {
+			break
+		}
+		items = append(items, tomlString(inner[:end+1]))
+		inner = inner[end+1:]
+	}
+	return items
+}
+
+// formatManifests - Sección de dependencias para la salida de analyze_project
+func formatManifests(manifests []DependencyManifest) string {
+	const maxNames = 15
+	names := func(list []string) string {
+		if len(list) <= maxNames {
+			return strings.Join(list, ", ")
+		}
+		return fmt.Sprintf("%s ... and %d more", strings.Join(list[:maxNames], ", "), len(list)-maxNames)
+	}
+
+	var b strings.Builder
+	b.WriteString("📦 **Dependencies:**\n")
+	for _, m := range manifests {
+		if m.Error != "" {
+			b.WriteString(fmt.Sprintf("  • %s: ⚠️ %s\n", m.File, m.Error))
+			continue
+		}
+
+		header := m.File
+		if m.Name != "" {
+			header += " — " + m.Name
+		}
+		if m.Version != "" {
+			if m.Ecosystem == "go" {
+				header += fmt.Sprintf(" (go %s)", m.Version)
+			} else {
+				header += fmt.Sprintf(" (%s)", m.Version)
+			}
+		}
+		b.WriteString(fmt.Sprintf("  • %s\n", header))
+		b.WriteString(fmt.Sprintf("    %d direct dependencies", len(m.Dependencies)))
+		if len(m.Dependencies) > 0 {
+			b.WriteString(": " + names(m.Dependencies))
+		}
+		b.WriteString("\n")
+		if len(m.DevDependencies) > 0 {
+			b.WriteString(fmt.Sprintf("    %d dev dependencies: %s\n", len(m.DevDependencies), names(m.DevDependencies)))
+		}
+		if len(m.Scripts) > 0 {
+			b.WriteString(fmt.Sprintf("    scripts: %s\n", names(m.Scripts)))
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
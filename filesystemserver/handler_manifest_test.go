@@ -0,0 +1,120 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProjectManifests(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	files := map[string]string{
+		"go.mod": `module example.com/app
+
+go 1.23
+
+require github.com/single/dep v1.0.0
+
+require (
+	github.com/a/b v1.2.3
+	github.com/c/d v0.1.0 // indirect
+)
+`,
+		"package.json": `{
+  "name": "web",
+  "version": "1.0.0",
+  "scripts": {"test": "jest", "build": "tsc"},
+  "dependencies": {"react": "^18.0.0", "axios": "^1.0.0"},
+  "devDependencies": {"jest": "^29.0.0"}
+}`,
+		"requirements.txt": "# pinned\nrequests[socks]>=2.0 ; python_version > '3'\n-r dev.txt\nflask==2.3.0  # web\n\n",
+		"pyproject.toml": `[project]
+name = "tool"
+version = "0.2.0"
+dependencies = [
+    "httpx>=0.24",  # client
+    'rich',
+]
+
+[project.optional-dependencies]
+dev = ["pytest"]
+`,
+		"Cargo.toml": `[package]
+name = "crate"
+version = "0.1.0"
+
+[dependencies]
+serde = { version = "1", features = ["derive"] }
+tokio = "1"
+
+[dependencies.regex]
+version = "1"
+
+[dev-dependencies]
+criterion = "0.5"
+`,
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	manifests := handler.parseProjectManifests(dir)
+	require.Len(t, manifests, 5)
+	byFile := make(map[string]DependencyManifest)
+	for _, m := range manifests {
+		assert.Empty(t, m.Error, m.File)
+		byFile[m.File] = m
+	}
+
+	assert.Equal(t, "example.com/app", byFile["go.mod"].Name)
+	assert.Equal(t, "1.23", byFile["go.mod"].Version)
+	assert.Equal(t, []string{"github.com/a/b", "github.com/single/dep"}, byFile["go.mod"].Dependencies)
+
+	assert.Equal(t, "web", byFile["package.json"].Name)
+	assert.Equal(t, []string{"build", "test"}, byFile["package.json"].Scripts)
+	assert.Equal(t, []string{"axios", "react"}, byFile["package.json"].Dependencies)
+	assert.Equal(t, []string{"jest"}, byFile["package.json"].DevDependencies)
+
+	assert.Equal(t, []string{"flask", "requests"}, byFile["requirements.txt"].Dependencies)
+
+	assert.Equal(t, "tool", byFile["pyproject.toml"].Name)
+	assert.Equal(t, []string{"httpx", "rich"}, byFile["pyproject.toml"].Dependencies)
+	assert.Equal(t, []string{"pytest"}, byFile["pyproject.toml"].DevDependencies)
+
+	assert.Equal(t, "crate", byFile["Cargo.toml"].Name)
+	assert.Equal(t, []string{"regex", "serde", "tokio"}, byFile["Cargo.toml"].Dependencies)
+	assert.Equal(t, []string{"criterion"}, byFile["Cargo.toml"].DevDependencies)
+}
+
+func TestParseProjectManifestsMalformed(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"name": "broken",`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Cargo.toml"), []byte("[package]\nname = \"x\"\ndeps = [\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644))
+
+	manifests := handler.parseProjectManifests(dir)
+	require.Len(t, manifests, 2)
+	for _, m := range manifests {
+		assert.Contains(t, m.Error, "could not parse "+m.File)
+	}
+
+	// Un manifiesto roto no hace fallar el análisis completo
+	result, err := handler.handleAnalyzeProject(context.Background(), newToolRequest("analyze_project", map[string]interface{}{
+		"path": dir,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "could not parse package.json")
+
+	result, err = handler.handleAnalyzeProject(context.Background(), newToolRequest("analyze_project", map[string]interface{}{
+		"path":   dir,
+		"output": "json",
+	}))
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, `"manifests": [`)
+}
@@ -0,0 +1,182 @@
+package filesystemserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxMultiplePaths - Rutas por llamada de read_multiple_files y
+// get_multiple_file_info, contadas después de expandir los globs
+const maxMultiplePaths = 50
+
+// errTooManyPaths - La lista supera maxMultiplePaths una vez expandida
+var errTooManyPaths = errors.New("too many paths")
+
+// pathArg - Una ruta de una lista de rutas; glob es el patrón del que salió
+// y err el motivo por el que no se puede usar
+type pathArg struct {
+	path string
+	glob string
+	err  error
+}
+
+// expandPathArgs - Expande los globs (*, ? y [...], con la sintaxis de
+// filepath.Match, sin **) de una lista de rutas. Las coincidencias fuera de
+// los directorios permitidos o denegadas se omiten; un glob sin coincidencias
+// queda como error de esa entrada. El error es para la lista entera.
+func (fs *FilesystemHandler) expandPathArgs(raw []interface{}) ([]pathArg, error) {
+	var args []pathArg
+	for _, item := range raw {
+		path, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("each path must be a string")
+		}
+		if !strings.ContainsAny(path, "*?[") {
+			args = append(args, pathArg{path: path})
+			continue
+		}
+
+		pattern, err := fs.resolvePath(path)
+		if err != nil {
+			args = append(args, pathArg{path: path, glob: path, err: err})
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			args = append(args, pathArg{path: path, glob: path, err: fmt.Errorf("invalid glob: %v", err)})
+			continue
+		}
+		found := 0
+		for _, match := range matches {
+			if _, err := fs.validatePath(match); err != nil || fs.isDeniedPath(match) {
+				continue
+			}
+			args = append(args, pathArg{path: match, glob: path})
+			found++
+		}
+		if found == 0 {
+			args = append(args, pathArg{path: path, glob: path, err: errors.New("no paths match")})
+		}
+	}
+	if len(args) > maxMultiplePaths {
+		return nil, fmt.Errorf("%w: %d after expanding globs, the maximum is %d", errTooManyPaths, len(args), maxMultiplePaths)
+	}
+	return args, nil
+}
+
+// handleGetMultipleFileInfo - get_file_info para varias rutas en una llamada,
+// con un resumen de tamaño total y la modificación más reciente
+func (fs *FilesystemHandler) handleGetMultipleFileInfo(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	pathsParam, err := getArray(request.Params.Arguments, "paths")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	includeXattrs, err := getBool(request.Params.Arguments, "include_xattrs", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if len(pathsParam) == 0 {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: paths must be a non-empty array"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	args, err := fs.expandPathArgs(pathsParam)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	report := MultipleFileInfo{Files: make([]PathFileInfo, 0, len(args))}
+	summary := &report.Summary
+	for _, arg := range args {
+		entry := PathFileInfo{Path: arg.path, Glob: arg.glob}
+		err := arg.err
+		if err == nil {
+			var info FileInfo
+			if info, err = fs.describePath(arg.path, includeXattrs); err == nil {
+				entry.FileInfo = &info
+			}
+		}
+		if err != nil {
+			entry.Error = err.Error()
+			summary.Errors++
+			report.Files = append(report.Files, entry)
+			continue
+		}
+
+		if entry.IsDirectory {
+			summary.Directories++
+		} else {
+			summary.Files++
+			summary.TotalSize += entry.Size
+		}
+		if summary.NewestMTime == nil || entry.Modified.After(*summary.NewestMTime) {
+			modified := entry.Modified
+			summary.Newest, summary.NewestMTime = entry.Path, &modified
+		}
+		report.Files = append(report.Files, entry)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📋 File info for %d paths: %d files, %d directories, %d errors\n", len(report.Files), summary.Files, summary.Directories, summary.Errors)
+	fmt.Fprintf(&b, "💾 Total size: %d bytes\n", summary.TotalSize)
+	if summary.NewestMTime != nil {
+		fmt.Fprintf(&b, "🕒 Newest: %s (%s)\n", safeDisplayName(summary.Newest), summary.NewestMTime.Format("2006-01-02 15:04:05"))
+	}
+	b.WriteString("\n")
+	for _, entry := range report.Files {
+		switch {
+		case entry.Error != "":
+			fmt.Fprintf(&b, "❌ %s: %s\n", safeDisplayName(entry.Path), entry.Error)
+		case entry.IsDirectory:
+			fmt.Fprintf(&b, "📁 %s  modified %s  %s\n", safeDisplayName(entry.Path), entry.Modified.Format("2006-01-02 15:04:05"), entry.Permissions)
+		default:
+			fmt.Fprintf(&b, "📄 %s  %d bytes  modified %s  %s\n", safeDisplayName(entry.Path), entry.Size, entry.Modified.Format("2006-01-02 15:04:05"), entry.Permissions)
+		}
+	}
+
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: b.String()},
+		},
+	}, "fileinfo://multiple", report)
+}
+
+// describePath - FileInfo de get_file_info para una ruta tal como se pidió:
+// el enlace simbólico se mira sin resolver, el resto sobre la ruta resuelta
+func (fs *FilesystemHandler) describePath(path string, includeXattrs bool) (FileInfo, error) {
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	info, err := fs.getFileStats(validPath)
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	if lexicalPath, err := fs.resolvePath(path); err == nil {
+		info.IsSymlink, info.SymlinkTarget = symlinkInfo(lexicalPath)
+	}
+	if includeXattrs {
+		if info.Xattrs, err = listXattrs(validPath); err != nil {
+			info.XattrsError = err.Error()
+		}
+	}
+	if info.IsFile && hasImageHeader(detectMimeType(validPath)) {
+		info.Image = imageInfo(validPath)
+	}
+	return info, nil
+}
@@ -0,0 +1,71 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMultipleFileInfo(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{"src/a.go": "package a\n", "src/b.go": "package b // longer\n", "notes.txt": "hi"})
+	newest := time.Now().Add(time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "src/b.go"), newest, newest))
+
+	result, err := handler.handleGetMultipleFileInfo(context.Background(), newToolRequest("get_multiple_file_info", map[string]interface{}{
+		"paths": []interface{}{filepath.Join(dir, "src/*.go"), filepath.Join(dir, "notes.txt"), filepath.Join(dir, "src"), filepath.Join(dir, "missing.txt"), filepath.Join(dir, "*.md")},
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "📋 File info for 6 paths: 3 files, 1 directories, 2 errors\n💾 Total size: 32 bytes\n")
+	assert.Contains(t, text, fmt.Sprintf("🕒 Newest: %s (%s)", filepath.Join(dir, "src/b.go"), newest.Format("2006-01-02 15:04:05")))
+	assert.Contains(t, text, "❌ "+filepath.Join(dir, "*.md")+": no paths match")
+
+	var report MultipleFileInfo
+	decodeStructured(t, result, &report)
+	require.Len(t, report.Files, 6)
+	assert.Equal(t, filepath.Join(dir, "src/a.go"), report.Files[0].Path)
+	assert.Equal(t, filepath.Join(dir, "src/*.go"), report.Files[0].Glob)
+	assert.Equal(t, int64(10), report.Files[0].Size)
+	assert.True(t, report.Files[2].IsFile)
+	assert.True(t, report.Files[3].IsDirectory)
+	assert.Nil(t, report.Files[4].FileInfo)
+	assert.Contains(t, report.Files[4].Error, "no such file")
+	assert.Equal(t, MultipleFileInfoSummary{Files: 3, Directories: 1, Errors: 2, TotalSize: 32, Newest: filepath.Join(dir, "src/b.go"), NewestMTime: report.Summary.NewestMTime}, report.Summary)
+	assert.True(t, newest.Equal(*report.Summary.NewestMTime))
+
+	many := make([]interface{}, maxMultiplePaths+1)
+	for i := range many {
+		many[i] = filepath.Join(dir, "notes.txt")
+	}
+	text, isError := callText(t, handler.handleGetMultipleFileInfo, "get_multiple_file_info", map[string]interface{}{"paths": many})
+	assert.True(t, isError)
+	assert.Equal(t, "❌ Error: too many paths: 51 after expanding globs, the maximum is 50", text)
+}
+
+func TestReadMultipleFilesGlob(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{"a.txt": "alpha", "b.txt": "beta", "c.md": "gamma"})
+
+	result, err := handler.handleReadMultipleFiles(context.Background(), newToolRequest("read_multiple_files", map[string]interface{}{
+		"paths": []interface{}{filepath.Join(dir, "*.txt"), filepath.Join(dir, "[")},
+	}))
+	require.NoError(t, err)
+	var texts []string
+	for _, content := range result.Content {
+		texts = append(texts, content.(mcp.TextContent).Text)
+	}
+	assert.Equal(t, []string{
+		"--- File: " + filepath.Join(dir, "a.txt") + " ---", "alpha",
+		"--- File: " + filepath.Join(dir, "b.txt") + " ---", "beta",
+		"Error with path '" + filepath.Join(dir, "[") + "': invalid glob: syntax error in pattern",
+	}, texts)
+}
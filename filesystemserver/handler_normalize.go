@@ -0,0 +1,464 @@
+package filesystemserver
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultTabWidth - Ancho de tabulador si no se indica tab_width ni lo fija .editorconfig
+	defaultTabWidth = 4
+	// maxNormalizeReported - Archivos modificados que se detallan en la respuesta
+	maxNormalizeReported = 500
+	// maxNormalizeErrors - Errores por archivo que se detallan en la respuesta
+	maxNormalizeErrors = 20
+)
+
+// normalizeOptions - Transformaciones de normalize_file para un archivo
+type normalizeOptions struct {
+	lineEnding   string // "\n", "\r\n" o "" para conservar los existentes
+	trimTrailing bool
+	finalNewline bool
+	indent       string // "spaces", "tabs" o "" para no tocar la sangría
+	tabWidth     int
+}
+
+// active - Si hay alguna transformación que aplicar
+func (o normalizeOptions) active() bool {
+	return o.lineEnding != "" || o.trimTrailing || o.finalNewline || o.indent != ""
+}
+
+// withEditorConfig - Completa con las propiedades de .editorconfig las opciones
+// que la llamada no fijó de forma explícita
+func (o normalizeOptions) withEditorConfig(props map[string]string, explicit map[string]bool) normalizeOptions {
+	if !explicit["line_endings"] {
+		switch props["end_of_line"] {
+		case "lf":
+			o.lineEnding = "\n"
+		case "crlf":
+			o.lineEnding = "\r\n"
+		}
+	}
+	if !explicit["trim_trailing_whitespace"] {
+		if value, ok := props["trim_trailing_whitespace"]; ok {
+			o.trimTrailing = value == "true"
+		}
+	}
+	if !explicit["ensure_final_newline"] {
+		if value, ok := props["insert_final_newline"]; ok {
+			o.finalNewline = value == "true"
+		}
+	}
+	if !explicit["indent"] {
+		switch props["indent_style"] {
+		case "space":
+			o.indent = "spaces"
+		case "tab":
+			o.indent = "tabs"
+		}
+	}
+	if !explicit["tab_width"] {
+		for _, key := range []string{"tab_width", "indent_size"} {
+			if width, err := strconv.Atoi(props[key]); err == nil && width > 0 {
+				o.tabWidth = width
+				break
+			}
+		}
+	}
+	return o
+}
+
+// handleNormalizeFile - Normaliza finales de línea, espacios finales, salto de
+// línea final y sangría de un archivo o de un árbol
+func (fs *FilesystemHandler) handleNormalizeFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	path, err := getString(args, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	dryRun, err := getBool(args, "dry_run", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	useEditorConfig, err := getBool(args, "use_editorconfig", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	include, err := getNonEmptyStrings(args, "include")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	exclude, err := getNonEmptyStrings(args, "exclude")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	opts := normalizeOptions{tabWidth: defaultTabWidth}
+	explicit := make(map[string]bool)
+	lineEndings, err := getString(args, "line_endings", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if lineEndings != "" {
+		switch strings.ToLower(lineEndings) {
+		case "lf":
+			opts.lineEnding = "\n"
+		case "crlf":
+			opts.lineEnding = "\r\n"
+		default:
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: "❌ Error: line_endings must be 'lf' or 'crlf'"},
+				},
+				IsError: true,
+			}, nil
+		}
+		explicit["line_endings"] = true
+	}
+	if args["trim_trailing_whitespace"] != nil {
+		if opts.trimTrailing, err = getBool(args, "trim_trailing_whitespace", false); err != nil {
+			return badArgError(err).result(), nil
+		}
+		explicit["trim_trailing_whitespace"] = true
+	}
+	if args["ensure_final_newline"] != nil {
+		if opts.finalNewline, err = getBool(args, "ensure_final_newline", false); err != nil {
+			return badArgError(err).result(), nil
+		}
+		explicit["ensure_final_newline"] = true
+	}
+	tabsToSpaces, err := getBool(args, "tabs_to_spaces", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	spacesToTabs, err := getBool(args, "spaces_to_tabs", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if tabsToSpaces && spacesToTabs {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: tabs_to_spaces and spaces_to_tabs are mutually exclusive"},
+			},
+			IsError: true,
+		}, nil
+	}
+	if args["tabs_to_spaces"] != nil || args["spaces_to_tabs"] != nil {
+		explicit["indent"] = true
+	}
+	if tabsToSpaces {
+		opts.indent = "spaces"
+	} else if spacesToTabs {
+		opts.indent = "tabs"
+	}
+	width, err := getInt(args, "tab_width", 0)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if width > 0 {
+		opts.tabWidth = width
+		explicit["tab_width"] = true
+	}
+
+	if !opts.active() && !useEditorConfig {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: nothing to do; set line_endings, trim_trailing_whitespace, ensure_final_newline, tabs_to_spaces, spaces_to_tabs or use_editorconfig"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var editorConfig *editorConfigResolver
+	if useEditorConfig {
+		editorConfig = newEditorConfigResolver(fs)
+	}
+	result, err := fs.normalizeFiles(ctx, validPath, opts, explicit, editorConfig, include, exclude, dryRun)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatNormalizeResult(result)},
+		},
+	}, pathToResourceURI(validPath), result)
+}
+
+// normalizeFiles - Aplica las opciones a root (archivo o directorio). Los
+// binarios se saltan; con editorConfig cada archivo parte de sus propiedades.
+func (fs *FilesystemHandler) normalizeFiles(ctx context.Context, root string, opts normalizeOptions, explicit map[string]bool, editorConfig *editorConfigResolver, include, exclude []string, dryRun bool) (*NormalizeResult, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	result := &NormalizeResult{Path: root, DryRun: dryRun, Files: []NormalizedFile{}}
+
+	normalize := func(path string) error {
+		if !isTextFile(detectMimeType(path)) {
+			result.SkippedBinary++
+			return nil
+		}
+		fileOpts := opts
+		if editorConfig != nil {
+			fileOpts = opts.withEditorConfig(editorConfig.properties(path), explicit)
+		}
+		result.FilesScanned++
+		if !fileOpts.active() {
+			return nil
+		}
+		change, err := fs.normalizeFile(path, fileOpts, dryRun)
+		if err != nil {
+			return err
+		}
+		if !change.changed() {
+			return nil
+		}
+		result.FilesChanged++
+		if len(result.Files) < maxNormalizeReported {
+			rel, _ := filepath.Rel(root, path)
+			if rel == "." {
+				rel = filepath.Base(path)
+			}
+			change.Path = filepath.ToSlash(rel)
+			result.Files = append(result.Files, change)
+		}
+		return nil
+	}
+
+	if !info.IsDir() {
+		if !isTextFile(detectMimeType(root)) {
+			return nil, fmt.Errorf("not a text file: %s", root)
+		}
+		if err := normalize(root); err != nil {
+			return nil, err
+		}
+	} else {
+		err = fs.walkContext(ctx, root, func(currentPath string, info os.FileInfo, err error) error {
+			if err != nil {
+				fs.logWalkError(ctx, currentPath, err)
+				return nil
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if info.IsDir() {
+				if currentPath != root && (containsString(cleanupVCSDirs, info.Name()) || isServerDataDir(info.Name()) ||
+					fs.isDeniedPath(currentPath) || matchesAnyPattern(root, currentPath, exclude)) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() || fs.isDeniedPath(currentPath) || matchesAnyPattern(root, currentPath, exclude) {
+				return nil
+			}
+			if len(include) > 0 && !matchesAnyPattern(root, currentPath, include) {
+				return nil
+			}
+			if err := normalize(currentPath); err != nil {
+				if len(result.Errors) < maxNormalizeErrors {
+					result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", currentPath, err))
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if editorConfig != nil {
+		result.EditorConfig = editorConfig.found
+	}
+	return result, nil
+}
+
+// normalizeFile - Cuenta los cambios de un archivo y, si los hay y no es un
+// dry run, lo reescribe de forma atómica. Los archivos sin cambios no se tocan.
+func (fs *FilesystemHandler) normalizeFile(path string, opts normalizeOptions, dryRun bool) (NormalizedFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return NormalizedFile{}, err
+	}
+	counter := &countingWriter{w: io.Discard}
+	change, err := normalizeText(counter, file, opts)
+	file.Close()
+	fs.stats.addRead(int(counter.n))
+	if err != nil || dryRun || !change.changed() {
+		return change, err
+	}
+
+	_, _, err = fs.streamToFile(path, path, func(w io.Writer, r io.Reader) (int64, error) {
+		_, err := normalizeText(w, r, opts)
+		return 0, err
+	})
+	return change, err
+}
+
+// normalizeText - Copia r en w línea a línea aplicando opts y cuenta los cambios
+func normalizeText(w io.Writer, r io.Reader, opts normalizeOptions) (NormalizedFile, error) {
+	var change NormalizedFile
+	reader := bufio.NewReader(r)
+	writer := bufio.NewWriter(w)
+	firstEnding := ""
+
+	for {
+		line, readErr := reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return change, readErr
+		}
+		if line != "" {
+			body, ending := line, ""
+			if strings.HasSuffix(body, "\n") {
+				body, ending = body[:len(body)-1], "\n"
+				if strings.HasSuffix(body, "\r") {
+					body, ending = body[:len(body)-1], "\r\n"
+				}
+			}
+
+			if opts.indent != "" {
+				if converted := convertIndent(body, opts.indent, opts.tabWidth); converted != body {
+					body = converted
+					change.Indentation++
+				}
+			}
+			if opts.trimTrailing {
+				if trimmed := strings.TrimRight(body, " \t"); trimmed != body {
+					body = trimmed
+					change.TrailingWhitespace++
+				}
+			}
+
+			switch {
+			case ending != "":
+				if firstEnding == "" {
+					firstEnding = ending
+				}
+				if opts.lineEnding != "" && ending != opts.lineEnding {
+					ending = opts.lineEnding
+					change.LineEndings++
+				}
+			case opts.finalNewline && body != "":
+				// Última línea sin terminar: el final pedido, el del archivo o LF
+				ending = opts.lineEnding
+				if ending == "" {
+					ending = firstEnding
+				}
+				if ending == "" {
+					ending = "\n"
+				}
+				change.FinalNewline = true
+			}
+
+			if _, err := writer.WriteString(body + ending); err != nil {
+				return change, err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+	}
+	return change, writer.Flush()
+}
+
+// convertIndent - Rehace la sangría inicial de line con espacios o con
+// tabuladores (más los espacios que no completen una parada de width columnas)
+func convertIndent(line, style string, width int) string {
+	n, column := 0, 0
+	for ; n < len(line); n++ {
+		if line[n] == '\t' {
+			column += width - column%width
+		} else if line[n] == ' ' {
+			column++
+		} else {
+			break
+		}
+	}
+	if n == 0 {
+		return line
+	}
+	if style == "spaces" {
+		return strings.Repeat(" ", column) + line[n:]
+	}
+	return strings.Repeat("\t", column/width) + strings.Repeat(" ", column%width) + line[n:]
+}
+
+// changed - Si normalizar el archivo lo modifica
+func (f NormalizedFile) changed() bool {
+	return f.LineEndings > 0 || f.TrailingWhitespace > 0 || f.Indentation > 0 || f.FinalNewline
+}
+
+// formatNormalizeResult - Resumen legible de normalize_file
+func formatNormalizeResult(result *NormalizeResult) string {
+	var b strings.Builder
+	verb := "Normalized"
+	if result.DryRun {
+		verb = "Would normalize"
+	}
+	fmt.Fprintf(&b, "🧹 %s %d of %d text file(s) in %s", verb, result.FilesChanged, result.FilesScanned, result.Path)
+	if result.SkippedBinary > 0 {
+		fmt.Fprintf(&b, " (%d binary skipped)", result.SkippedBinary)
+	}
+	b.WriteString("\n")
+	if result.EditorConfig {
+		b.WriteString("📐 Settings from .editorconfig applied\n")
+	}
+
+	for _, file := range result.Files {
+		var parts []string
+		if file.LineEndings > 0 {
+			parts = append(parts, fmt.Sprintf("%d line ending(s)", file.LineEndings))
+		}
+		if file.TrailingWhitespace > 0 {
+			parts = append(parts, fmt.Sprintf("%d trailing whitespace", file.TrailingWhitespace))
+		}
+		if file.Indentation > 0 {
+			parts = append(parts, fmt.Sprintf("%d indentation", file.Indentation))
+		}
+		if file.FinalNewline {
+			parts = append(parts, "final newline")
+		}
+		fmt.Fprintf(&b, "  📝 %s: %s\n", file.Path, strings.Join(parts, ", "))
+	}
+	if result.FilesChanged > len(result.Files) {
+		fmt.Fprintf(&b, "  ... %d more\n", result.FilesChanged-len(result.Files))
+	}
+	for _, e := range result.Errors {
+		fmt.Fprintf(&b, "  ❌ %s\n", e)
+	}
+	if result.DryRun && result.FilesChanged > 0 {
+		b.WriteString("\n💡 Dry run: nothing was written\n")
+	}
+	return b.String()
+}
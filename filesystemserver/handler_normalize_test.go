@@ -0,0 +1,185 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeText(t *testing.T) {
+	cases := []struct {
+		name  string
+		opts  normalizeOptions
+		input string
+		want  string
+		check NormalizedFile
+	}{
+		{
+			name:  "crlf to lf with final newline",
+			opts:  normalizeOptions{lineEnding: "\n", finalNewline: true},
+			input: "a\r\nb\nc\r\nlast",
+			want:  "a\nb\nc\nlast\n",
+			check: NormalizedFile{LineEndings: 2, FinalNewline: true},
+		},
+		{
+			name:  "final newline keeps the file ending",
+			opts:  normalizeOptions{finalNewline: true},
+			input: "a\r\nlast",
+			want:  "a\r\nlast\r\n",
+			check: NormalizedFile{FinalNewline: true},
+		},
+		{
+			name:  "trailing whitespace",
+			opts:  normalizeOptions{trimTrailing: true},
+			input: "a  \r\nb\t\n   \n",
+			want:  "a\r\nb\n\n",
+			check: NormalizedFile{TrailingWhitespace: 3},
+		},
+		{
+			name:  "tabs to spaces only in indentation",
+			opts:  normalizeOptions{indent: "spaces", tabWidth: 4},
+			input: "\tx\t= 1\n  \ty\n",
+			want:  "    x\t= 1\n    y\n",
+			check: NormalizedFile{Indentation: 2},
+		},
+		{
+			name:  "spaces to tabs keeps the remainder",
+			opts:  normalizeOptions{indent: "tabs", tabWidth: 4},
+			input: "        x\n      y\n\tz\n",
+			want:  "\t\tx\n\t  y\n\tz\n",
+			check: NormalizedFile{Indentation: 2},
+		},
+	}
+	for _, tc := range cases {
+		var out strings.Builder
+		change, err := normalizeText(&out, strings.NewReader(tc.input), tc.opts)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.want, out.String(), tc.name)
+		assert.Equal(t, tc.check, change, tc.name)
+	}
+}
+
+func TestNormalizeFile(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	files := map[string]string{
+		"src/main.go":       "package main  \r\n\r\nfunc main() {}",
+		"src/clean.go":      "package clean\n",
+		"docs/readme.md":    "# Title \r\n",
+		"assets/logo.png":   "\x89PNG\r\n\x1a\n\x00\x00  \r\n",
+		".git/config":       "[core] \r\n",
+		"vendor/lib/lib.go": "package lib \r\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	cleanInfo, err := os.Stat(filepath.Join(dir, "src", "clean.go"))
+	require.NoError(t, err)
+
+	args := map[string]interface{}{
+		"path":                     dir,
+		"line_endings":             "lf",
+		"trim_trailing_whitespace": true,
+		"ensure_final_newline":     true,
+		"exclude":                  []interface{}{"vendor"},
+		"dry_run":                  true,
+	}
+	result, err := handler.handleNormalizeFile(context.Background(), newToolRequest("normalize_file", args))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Would normalize 2 of 3 text file(s)")
+	data, err := os.ReadFile(filepath.Join(dir, "src", "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, files["src/main.go"], string(data))
+
+	var report NormalizeResult
+	decodeStructured(t, result, &report)
+	assert.Equal(t, 1, report.SkippedBinary)
+	assert.Equal(t, []NormalizedFile{
+		{Path: "docs/readme.md", LineEndings: 1, TrailingWhitespace: 1},
+		{Path: "src/main.go", LineEndings: 2, TrailingWhitespace: 1, FinalNewline: true},
+	}, report.Files)
+
+	args["dry_run"] = false
+	result, err = handler.handleNormalizeFile(context.Background(), newToolRequest("normalize_file", args))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+	for name, want := range map[string]string{
+		"src/main.go":       "package main\n\nfunc main() {}\n",
+		"docs/readme.md":    "# Title\n",
+		"assets/logo.png":   files["assets/logo.png"],
+		".git/config":       files[".git/config"],
+		"vendor/lib/lib.go": files["vendor/lib/lib.go"],
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		require.NoError(t, err)
+		assert.Equal(t, want, string(data), name)
+	}
+	info, err := os.Stat(filepath.Join(dir, "src", "clean.go"))
+	require.NoError(t, err)
+	assert.Equal(t, cleanInfo.ModTime(), info.ModTime(), "unchanged files are not rewritten")
+	assert.Empty(t, tempFilesIn(t, filepath.Join(dir, "src")))
+
+	// Una segunda pasada no encuentra nada que cambiar
+	result, err = handler.handleNormalizeFile(context.Background(), newToolRequest("normalize_file", args))
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Normalized 0 of 3")
+
+	for _, bad := range []map[string]interface{}{
+		{"path": dir},
+		{"path": dir, "line_endings": "cr"},
+		{"path": dir, "tabs_to_spaces": true, "spaces_to_tabs": true},
+		{"path": filepath.Join(dir, "assets", "logo.png"), "trim_trailing_whitespace": true},
+	} {
+		result, err = handler.handleNormalizeFile(context.Background(), newToolRequest("normalize_file", bad))
+		require.NoError(t, err)
+		assert.True(t, result.IsError, bad)
+	}
+}
+
+func TestNormalizeFileEditorConfig(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	editorConfig := "root = true\n\n[*]\nend_of_line = lf\ninsert_final_newline = true\n\n" +
+		"[*.{py,md}]\nindent_style = space\nindent_size = 2\ntrim_trailing_whitespace = true\n\n[Makefile]\nindent_style = tab\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".editorconfig"), []byte(editorConfig), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "app.py"), []byte("def f():\r\n\treturn 1  \r\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Makefile"), []byte("all:\n    go build  "), 0644))
+
+	result, err := handler.handleNormalizeFile(context.Background(), newToolRequest("normalize_file", map[string]interface{}{
+		"path":             dir,
+		"use_editorconfig": true,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Settings from .editorconfig applied")
+
+	data, err := os.ReadFile(filepath.Join(dir, "pkg", "app.py"))
+	require.NoError(t, err)
+	assert.Equal(t, "def f():\n  return 1\n", string(data))
+	// Sin trim_trailing_whitespace para Makefile; tab_width por defecto 4
+	data, err = os.ReadFile(filepath.Join(dir, "Makefile"))
+	require.NoError(t, err)
+	assert.Equal(t, "all:\n\tgo build  \n", string(data))
+
+	// Las opciones explícitas ganan a .editorconfig
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "app.py"), []byte("x = 1\r\n"), 0644))
+	result, err = handler.handleNormalizeFile(context.Background(), newToolRequest("normalize_file", map[string]interface{}{
+		"path":             filepath.Join(dir, "pkg", "app.py"),
+		"use_editorconfig": true,
+		"line_endings":     "crlf",
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	data, err = os.ReadFile(filepath.Join(dir, "pkg", "app.py"))
+	require.NoError(t, err)
+	assert.Equal(t, "x = 1\r\n", string(data))
+}
@@ -0,0 +1,521 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// outlineLanguages - Lenguajes soportados por extract_outline
+var outlineLanguages = map[string]bool{"go": true, "javascript": true, "typescript": true, "python": true}
+
+// outlinePattern - Definición de nivel superior reconocida por expresión regular
+type outlinePattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// scriptOutlinePatterns - Patrones JS/TS de nivel superior; las funciones se
+// reconocen con functionStartPatterns
+var scriptOutlinePatterns = []outlinePattern{
+	{"class", regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(\w+)`)},
+	{"interface", regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?interface\s+(\w+)`)},
+	{"type", regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?type\s+(\w+)\s*(?:<[^>]*>)?\s*=`)},
+	{"enum", regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?(?:const\s+)?enum\s+(\w+)`)},
+	{"const", regexp.MustCompile(`^(?:export\s+)?(?:const|let|var)\s+(\w+)`)},
+}
+
+// scriptMethodPattern - Método dentro del cuerpo de una clase JS/TS
+var scriptMethodPattern = regexp.MustCompile(`^\s+(?:(?:public|private|protected|static|async|readonly|override|abstract|get|set)\s+)*\*?#?(\w+)\s*(?:<[^>]*>)?\s*\(`)
+
+// pythonOutlinePatterns - Definiciones de nivel superior en Python
+var pythonOutlinePatterns = []outlinePattern{
+	{"class", regexp.MustCompile(`^class\s+(\w+)`)},
+	{"function", regexp.MustCompile(`^(?:async\s+)?def\s+(\w+)`)},
+	{"const", regexp.MustCompile(`^([A-Z][A-Z0-9_]*)\s*(?::[^=]+)?=`)},
+}
+
+// pythonMethodPattern - Método de una clase Python
+var pythonMethodPattern = regexp.MustCompile(`^\s+(?:async\s+)?def\s+(\w+)`)
+
+// handleExtractOutline - Símbolos de nivel superior con sus rangos de líneas
+func (fs *FilesystemHandler) handleExtractOutline(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	output, err := getString(request.Params.Arguments, "output", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	outlines, err := fs.extractOutlines(ctx, validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(outlines, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: string(data)},
+			},
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatOutlines(validPath, outlines)},
+		},
+	}, nil
+}
+
+// extractOutlines - Outline de un archivo o de los archivos soportados de un directorio
+func (fs *FilesystemHandler) extractOutlines(ctx context.Context, root string) ([]FileOutline, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		if info.Size() > MAX_INLINE_SIZE {
+			return nil, fmt.Errorf("file is too large (%d bytes, max %d)", info.Size(), MAX_INLINE_SIZE)
+		}
+		data, err := fs.readFile(root)
+		if err != nil {
+			return nil, err
+		}
+		detected, _ := languageDetector.Detect(root, string(data))
+		language := detected.Key
+		if !outlineLanguages[language] {
+			return nil, fmt.Errorf("unsupported language '%s' (supported: Go, JavaScript, TypeScript, Python)", language)
+		}
+		return []FileOutline{extractFileOutline(root, string(data), language)}, nil
+	}
+
+	outlines := []FileOutline{}
+	err = fs.walkContext(ctx, root, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			fs.logWalkError(ctx, currentPath, err)
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if currentPath != root && fs.shouldIgnoreEntry(ctx, currentPath, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() || info.Size() > MAX_INLINE_SIZE {
+			return nil
+		}
+		detected, _ := languageDetector.Detect(currentPath, "")
+		language := detected.Key
+		if !outlineLanguages[language] {
+			return nil
+		}
+		if _, err := fs.validatePath(currentPath); err != nil {
+			return nil
+		}
+		data, err := fs.readFile(currentPath)
+		if err != nil {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, currentPath)
+		outlines = append(outlines, extractFileOutline(filepath.ToSlash(rel), string(data), language))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(outlines, func(i, j int) bool { return outlines[i].File < outlines[j].File })
+	return outlines, nil
+}
+
+// extractFileOutline - Go se analiza con go/parser; el resto por expresiones e indentación
+func extractFileOutline(name, content, language string) FileOutline {
+	outline := FileOutline{
+		File:     name,
+		Language: language,
+		Lines:    len(strings.Split(strings.TrimSuffix(content, "\n"), "\n")),
+		Symbols:  []OutlineSymbol{},
+	}
+
+	switch language {
+	case "go":
+		symbols, err := goOutline(content)
+		if err != nil {
+			outline.Error = err.Error()
+		}
+		outline.Symbols = append(outline.Symbols, symbols...)
+	case "python":
+		outline.Symbols = append(outline.Symbols, pythonOutline(content)...)
+	default:
+		outline.Symbols = append(outline.Symbols, scriptOutline(content, language)...)
+	}
+	return outline
+}
+
+// goOutline - Declaraciones de nivel superior de un archivo Go
+func goOutline(content string) ([]OutlineSymbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.SkipObjectResolution)
+	if file == nil {
+		return nil, err
+	}
+
+	text := func(from, to token.Pos) string {
+		start, end := fset.Position(from).Offset, fset.Position(to).Offset
+		if start < 0 || end > len(content) || start > end {
+			return ""
+		}
+		return strings.Join(strings.Fields(content[start:end]), " ")
+	}
+	symbol := func(name, kind string, node ast.Node, signature string) OutlineSymbol {
+		start, end := fset.Position(node.Pos()).Line, fset.Position(node.End()).Line
+		return OutlineSymbol{Name: name, Kind: kind, Signature: signature, StartLine: start, EndLine: end, Lines: end - start + 1}
+	}
+
+	var symbols []OutlineSymbol
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			s := symbol(d.Name.Name, "function", d, text(d.Pos(), d.Type.End()))
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				s.Kind = "method"
+				s.Receiver = text(d.Recv.List[0].Type.Pos(), d.Recv.List[0].Type.End())
+			}
+			symbols = append(symbols, s)
+
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch sp := spec.(type) {
+				case *ast.TypeSpec:
+					kind := "type"
+					signature := "type " + text(sp.Pos(), sp.End())
+					switch sp.Type.(type) {
+					case *ast.StructType:
+						kind = "struct"
+						signature = "type " + text(sp.Pos(), sp.Type.Pos()) + " struct"
+					case *ast.InterfaceType:
+						kind = "interface"
+						signature = "type " + text(sp.Pos(), sp.Type.Pos()) + " interface"
+					}
+					var node ast.Node = sp
+					if !d.Lparen.IsValid() {
+						node = d
+					}
+					symbols = append(symbols, symbol(sp.Name.Name, kind, node, signature))
+
+				case *ast.ValueSpec:
+					kind := strings.ToLower(d.Tok.String())
+					var node ast.Node = sp
+					if !d.Lparen.IsValid() {
+						node = d
+					}
+					signature := kind + " " + truncateSignature(text(sp.Pos(), sp.End()))
+					for _, ident := range sp.Names {
+						if ident.Name != "_" {
+							symbols = append(symbols, symbol(ident.Name, kind, node, signature))
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if err != nil {
+		return symbols, fmt.Errorf("parse error (partial outline): %v", err)
+	}
+	return symbols, nil
+}
+
+// scriptOutline - Funciones, clases (con métodos), interfaces, tipos y constantes JS/TS
+func scriptOutline(content, language string) []OutlineSymbol {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	functionRe := functionStartPatterns[language]
+
+	var symbols []OutlineSymbol
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+
+		kind, name := "", ""
+		if match := functionRe.FindStringSubmatch(line); match != nil {
+			kind, name = "function", firstGroup(match)
+		} else {
+			for _, pattern := range scriptOutlinePatterns {
+				if match := pattern.re.FindStringSubmatch(line); match != nil {
+					kind, name = pattern.kind, match[1]
+					break
+				}
+			}
+		}
+		if kind == "" {
+			continue
+		}
+
+		end := bracketBlockEnd(lines, i)
+		s := OutlineSymbol{
+			Name:      name,
+			Kind:      kind,
+			Signature: truncateSignature(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), "{"))),
+			StartLine: i + 1,
+			EndLine:   end + 1,
+			Lines:     end - i + 1,
+		}
+		if kind == "class" {
+			s.Children = scriptClassMethods(lines, i, end)
+		}
+		symbols = append(symbols, s)
+		i = end
+	}
+	return symbols
+}
+
+// scriptClassMethods - Métodos del primer nivel del cuerpo de una clase
+func scriptClassMethods(lines []string, start, end int) []OutlineSymbol {
+	var methods []OutlineSymbol
+	memberIndent := -1
+	for i := start + 1; i < end; i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(getIndentation(line))
+		if memberIndent < 0 {
+			memberIndent = indent
+		}
+		if indent != memberIndent {
+			continue
+		}
+		match := scriptMethodPattern.FindStringSubmatch(line)
+		if match == nil || isControlKeyword(match[1]) {
+			continue
+		}
+		methodEnd := bracketBlockEnd(lines, i)
+		methods = append(methods, OutlineSymbol{
+			Name:      match[1],
+			Kind:      "method",
+			Signature: truncateSignature(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), "{"))),
+			StartLine: i + 1,
+			EndLine:   methodEnd + 1,
+			Lines:     methodEnd - i + 1,
+		})
+		i = methodEnd
+	}
+	return methods
+}
+
+// pythonOutline - Clases (con métodos), funciones y constantes de módulo
+func pythonOutline(content string) []OutlineSymbol {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+
+	var symbols []OutlineSymbol
+	for i := 0; i < len(lines); i++ {
+		for _, pattern := range pythonOutlinePatterns {
+			match := pattern.re.FindStringSubmatch(lines[i])
+			if match == nil {
+				continue
+			}
+
+			end := i
+			signature := strings.TrimSpace(lines[i])
+			if pattern.kind == "const" {
+				end = bracketBlockEnd(lines, i)
+			} else {
+				signature = pythonSignature(lines, i)
+				end = pythonBlockEnd(lines, i)
+			}
+
+			s := OutlineSymbol{
+				Name:      match[1],
+				Kind:      pattern.kind,
+				Signature: truncateSignature(signature),
+				StartLine: i + 1,
+				EndLine:   end + 1,
+				Lines:     end - i + 1,
+			}
+			if pattern.kind == "class" {
+				s.Children = pythonClassMethods(lines, i, end)
+			}
+			symbols = append(symbols, s)
+			i = end
+			break
+		}
+	}
+	return symbols
+}
+
+// pythonClassMethods - Métodos directos de una clase Python
+func pythonClassMethods(lines []string, start, end int) []OutlineSymbol {
+	var methods []OutlineSymbol
+	memberIndent := -1
+	for i := start + 1; i <= end; i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(getIndentation(line))
+		if memberIndent < 0 {
+			memberIndent = indent
+		}
+		if indent != memberIndent {
+			continue
+		}
+		match := pythonMethodPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		methodEnd := pythonBlockEnd(lines, i)
+		methods = append(methods, OutlineSymbol{
+			Name:      match[1],
+			Kind:      "method",
+			Signature: truncateSignature(pythonSignature(lines, i)),
+			StartLine: i + 1,
+			EndLine:   methodEnd + 1,
+			Lines:     methodEnd - i + 1,
+		})
+		i = methodEnd
+	}
+	return methods
+}
+
+// pythonSignature - Une las líneas de una definición hasta los dos puntos finales
+func pythonSignature(lines []string, start int) string {
+	var parts []string
+	for i := start; i < len(lines) && i < start+10; i++ {
+		parts = append(parts, strings.TrimSpace(lines[i]))
+		if strings.HasSuffix(strings.TrimSpace(lines[i]), ":") {
+			break
+		}
+	}
+	return strings.TrimSuffix(strings.Join(parts, " "), ":")
+}
+
+// bracketBlockEnd - Línea donde se equilibran (), [] y {} abiertos desde start
+func bracketBlockEnd(lines []string, start int) int {
+	depth := 0
+	for i := start; i < len(lines); i++ {
+		for _, ch := range lines[i] {
+			switch ch {
+			case '{', '(', '[':
+				depth++
+			case '}', ')', ']':
+				depth--
+			}
+		}
+		if depth <= 0 {
+			return i
+		}
+	}
+	return len(lines) - 1
+}
+
+// firstGroup - Primer grupo de captura no vacío
+func firstGroup(match []string) string {
+	for _, group := range match[1:] {
+		if group != "" {
+			return group
+		}
+	}
+	return ""
+}
+
+// isControlKeyword - Palabras que el patrón de métodos confundiría con nombres
+func isControlKeyword(name string) bool {
+	switch name {
+	case "if", "for", "while", "switch", "catch", "return", "function":
+		return true
+	}
+	return false
+}
+
+// truncateSignature - Limita la firma a una longitud legible
+func truncateSignature(signature string) string {
+	if runes := []rune(signature); len(runes) > 160 {
+		return string(runes[:157]) + "..."
+	}
+	return signature
+}
+
+// formatOutlines - Outline indentado, con un bloque por archivo en directorios
+func formatOutlines(root string, outlines []FileOutline) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📑 Outline: %s\n", root))
+	if len(outlines) == 0 {
+		b.WriteString("ℹ️ No Go, JavaScript, TypeScript or Python files found\n")
+		return b.String()
+	}
+
+	total := 0
+	for _, outline := range outlines {
+		total += len(outline.Symbols)
+	}
+	if len(outlines) > 1 {
+		b.WriteString(fmt.Sprintf("📁 %d files, %d top-level symbols\n", len(outlines), total))
+	}
+
+	var writeSymbol func(s OutlineSymbol, indent string)
+	writeSymbol = func(s OutlineSymbol, indent string) {
+		b.WriteString(fmt.Sprintf("%s• %s  [L%d-%d, %d lines]\n", indent, s.Signature, s.StartLine, s.EndLine, s.Lines))
+		for _, child := range s.Children {
+			writeSymbol(child, indent+"    ")
+		}
+	}
+
+	for _, outline := range outlines {
+		b.WriteString(fmt.Sprintf("\n📄 %s (%s, %d lines, %d symbols)\n", outline.File, outline.Language, outline.Lines, len(outline.Symbols)))
+		if outline.Error != "" {
+			b.WriteString(fmt.Sprintf("  ⚠️ %s\n", outline.Error))
+		}
+		for _, s := range outline.Symbols {
+			writeSymbol(s, "  ")
+		}
+	}
+	return b.String()
+}
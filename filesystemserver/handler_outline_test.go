@@ -0,0 +1,168 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func outlineSummary(symbols []OutlineSymbol) []string {
+	var out []string
+	for _, s := range symbols {
+		out = append(out, s.Kind+" "+s.Name)
+		for _, child := range s.Children {
+			out = append(out, "  "+child.Kind+" "+child.Name)
+		}
+	}
+	return out
+}
+
+func TestGoOutline(t *testing.T) {
+	source := `package shop
+
+import "fmt"
+
+const MaxItems = 10
+
+var (
+	ErrEmpty = fmt.Errorf("empty")
+	debug    bool
+)
+
+// Cart holds items
+type Cart struct {
+	Items []int
+}
+
+type Totaler interface {
+	Total() int
+}
+
+type ID = string
+
+func New() *Cart {
+	return &Cart{}
+}
+
+func (c *Cart) Total() int {
+	sum := 0
+	for _, item := range c.Items {
+		sum += item
+	}
+	return sum
+}
+`
+	symbols, err := goOutline(source)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"const MaxItems", "var ErrEmpty", "var debug", "struct Cart", "interface Totaler",
+		"type ID", "function New", "method Total",
+	}, outlineSummary(symbols))
+
+	total := symbols[len(symbols)-1]
+	assert.Equal(t, "*Cart", total.Receiver)
+	assert.Equal(t, "func (c *Cart) Total() int", total.Signature)
+	assert.Equal(t, 27, total.StartLine)
+	assert.Equal(t, 33, total.EndLine)
+	assert.Equal(t, 7, total.Lines)
+
+	assert.Equal(t, "type Cart struct", symbols[3].Signature)
+	assert.Equal(t, 13, symbols[3].StartLine)
+	assert.Equal(t, 15, symbols[3].EndLine)
+}
+
+func TestScriptAndPythonOutline(t *testing.T) {
+	ts := `import { x } from "y";
+
+export interface Options {
+  name: string;
+}
+
+export type Handler = (o: Options) => void;
+
+export class Service {
+  private cache = new Map();
+
+  constructor(private opts: Options) {}
+
+  async load(id: string): Promise<void> {
+    if (id) {
+      return;
+    }
+  }
+}
+
+export const DEFAULTS = {
+  name: "x",
+};
+
+export function start(opts: Options) {
+  return new Service(opts);
+}
+
+const handler = (o) => {
+  console.log(o);
+};
+`
+	symbols := scriptOutline(ts, "typescript")
+	assert.Equal(t, []string{
+		"interface Options", "type Handler", "class Service", "  method constructor", "  method load",
+		"const DEFAULTS", "function start", "function handler",
+	}, outlineSummary(symbols))
+	assert.Equal(t, 9, symbols[2].StartLine)
+	assert.Equal(t, 19, symbols[2].EndLine)
+	assert.Equal(t, "async load(id: string): Promise<void>", symbols[2].Children[1].Signature)
+
+	py := `import os
+
+TIMEOUT = 30
+
+class Client:
+    """A client."""
+
+    def __init__(self, url):
+        self.url = url
+
+    async def fetch(self,
+                    path):
+        return path
+
+def main():
+    Client("x")
+`
+	symbols = pythonOutline(py)
+	assert.Equal(t, []string{"const TIMEOUT", "class Client", "  method __init__", "  method fetch", "function main"}, outlineSummary(symbols))
+	assert.Equal(t, "async def fetch(self, path)", symbols[1].Children[1].Signature)
+	assert.Equal(t, 5, symbols[1].StartLine)
+	assert.Equal(t, 13, symbols[1].EndLine)
+}
+
+func TestHandleExtractOutline(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "web"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "web", "app.js"), []byte("function render() {\n}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("just some notes\n"), 0644))
+
+	result, err := handler.handleExtractOutline(context.Background(), newToolRequest("extract_outline", map[string]interface{}{
+		"path": dir,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "📄 main.go (go, 3 lines, 1 symbols)")
+	assert.Contains(t, text, "• func main()  [L3-3, 1 lines]")
+	assert.Contains(t, text, "📄 web/app.js")
+	assert.NotContains(t, text, "notes.txt")
+
+	result, err = handler.handleExtractOutline(context.Background(), newToolRequest("extract_outline", map[string]interface{}{
+		"path": filepath.Join(dir, "notes.txt"),
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
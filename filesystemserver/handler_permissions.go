@@ -0,0 +1,276 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	defaultFixDirMode  os.FileMode = 0755
+	defaultFixFileMode os.FileMode = 0644
+)
+
+// permissionFix - Modos objetivo de fix_permissions
+type permissionFix struct {
+	dirMode, fileMode os.FileMode
+	executableGlobs   []string
+	recursive, dryRun bool
+}
+
+// handleFixPermissions - Normaliza los permisos de un árbol: directorios a
+// dir_mode, archivos a file_mode y los de executable_globs con +x; solo se
+// cambian las entradas que lo necesitan
+func (fs *FilesystemHandler) handleFixPermissions(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	confirm, err := getString(request.Params.Arguments, "confirm", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	fix := permissionFix{}
+	if fix.recursive, err = getBool(request.Params.Arguments, "recursive", false); err != nil {
+		return badArgError(err).result(), nil
+	}
+	if fix.dryRun, err = getBool(request.Params.Arguments, "dry_run", false); err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+	if runtime.GOOS == "windows" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %s is not supported on Windows, where only the read-only attribute can be changed", fs.toolName("fix_permissions"))},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if fix.dirMode, err = parseModeArg(request.Params.Arguments, "dir_mode", defaultFixDirMode); err == nil {
+		fix.fileMode, err = parseModeArg(request.Params.Arguments, "file_mode", defaultFixFileMode)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	if fix.executableGlobs, err = getNonEmptyStrings(request.Params.Arguments, "executable_globs"); err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	if _, err := os.Stat(validPath); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Sobre un directorio permitido completo hace falta el token de una simulación previa
+	token := ""
+	if fs.isAllowedRoot(validPath) {
+		token = paramsHash("fix_permissions", validPath, fix.dirMode.String(), fix.fileMode.String(), strings.Join(fix.executableGlobs, "\x00"), fmt.Sprint(fix.recursive))
+		if !fix.dryRun && confirm != token {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %s is an allowed root directory; run with dry_run: true to review the changes and get the confirm token for these parameters", validPath)},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	report, err := fs.fixPermissions(ctx, validPath, fix)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	if fix.dryRun {
+		report.ConfirmToken = token
+	}
+
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatPermissionFixReport(report)},
+		},
+	}, pathToResourceURI(validPath), report)
+}
+
+// fixPermissions - Recorre root (o solo root y sus entradas directas sin
+// recursive) y aplica los chmod necesarios; los enlaces simbólicos, los
+// archivos especiales y las rutas denegadas o fuera de los directorios
+// permitidos se saltan
+func (fs *FilesystemHandler) fixPermissions(ctx context.Context, root string, fix permissionFix) (*PermissionFixReport, error) {
+	report := &PermissionFixReport{Root: root, DryRun: fix.dryRun, Changes: []PermissionChange{}}
+	groups := make(map[PermissionChange]int)
+
+	err := fs.walkContext(ctx, root, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			fs.logWalkError(ctx, currentPath, err)
+			return nil
+		}
+		if currentPath != root && fs.isDeniedPath(currentPath) {
+			report.Skipped++
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, err := fs.validatePath(currentPath); err != nil {
+			report.Skipped++
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() && currentPath != root && isServerDataDir(info.Name()) {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			report.Skipped++
+			return nil
+		}
+		report.Scanned++
+
+		kind, target := "file", fix.fileMode
+		switch {
+		case info.IsDir():
+			kind, target = "directory", fix.dirMode
+		case matchesAnyPattern(root, currentPath, fix.executableGlobs):
+			// +x donde haya permiso de lectura: 644 pasa a 755, 640 a 750
+			kind, target = "executable", fix.fileMode|(fix.fileMode&0444)>>2
+		}
+
+		if current := info.Mode().Perm(); current != target {
+			ok := true
+			if !fix.dryRun {
+				special := info.Mode() & (os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+				if err := fs.chmodChecked(currentPath, target|special); err != nil {
+					report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", currentPath, err))
+					ok = false
+				}
+			}
+			if ok {
+				groups[PermissionChange{Kind: kind, From: fmt.Sprintf("%04o", current), To: fmt.Sprintf("%04o", target)}]++
+				report.Changed++
+			}
+		}
+
+		if info.IsDir() && currentPath != root && !fix.recursive {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for change, count := range groups {
+		change.Count = count
+		report.Changes = append(report.Changes, change)
+	}
+	sort.Slice(report.Changes, func(i, j int) bool {
+		a, b := report.Changes[i], report.Changes[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.From < b.From
+	})
+	return report, nil
+}
+
+// parseModeArg - Modo octal de un argumento ("755", "0755" o el número 755)
+func parseModeArg(args map[string]interface{}, name string, fallback os.FileMode) (os.FileMode, error) {
+	var text string
+	switch v := args[name].(type) {
+	case nil:
+		return fallback, nil
+	case string:
+		text = v
+	case float64:
+		text = strconv.Itoa(int(v))
+	default:
+		return 0, fmt.Errorf("%s must be an octal mode such as \"755\"", name)
+	}
+	mode, err := strconv.ParseUint(text, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("%s must be an octal mode between 000 and 777, got %q", name, text)
+	}
+	return os.FileMode(mode), nil
+}
+
+// isAllowedRoot - Indica si path es uno de los directorios permitidos
+func (fs *FilesystemHandler) isAllowedRoot(path string) bool {
+	for _, dir := range fs.allowedDirectories() {
+		if filepath.Clean(dir) == filepath.Clean(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatPermissionFixReport - Resumen por tipo de cambio
+func formatPermissionFixReport(report *PermissionFixReport) string {
+	var b strings.Builder
+	verb := "Changed"
+	if report.DryRun {
+		b.WriteString(fmt.Sprintf("🔐 Fix permissions (dry run): %s\n", report.Root))
+		verb = "Would change"
+	} else {
+		b.WriteString(fmt.Sprintf("🔐 Fix permissions: %s\n", report.Root))
+	}
+	b.WriteString(fmt.Sprintf("📊 Scanned %d entries, skipped %d\n", report.Scanned, report.Skipped))
+
+	if report.Changed == 0 && len(report.Errors) == 0 {
+		b.WriteString("✅ Permissions already as requested\n")
+	} else {
+		b.WriteString(fmt.Sprintf("🔧 %s %d entries:\n", verb, report.Changed))
+		for _, change := range report.Changes {
+			b.WriteString(fmt.Sprintf("  %s %s → %s: %d\n", change.Kind, change.From, change.To, change.Count))
+		}
+	}
+
+	if len(report.Errors) > 0 {
+		b.WriteString(fmt.Sprintf("\n❌ Errors (%d):\n", len(report.Errors)))
+		for _, e := range report.Errors {
+			b.WriteString(fmt.Sprintf("  %s\n", e))
+		}
+	}
+	if report.ConfirmToken != "" {
+		b.WriteString(fmt.Sprintf("\n🔑 %s is an allowed root: to apply these changes, call again without dry_run and with confirm: %q\n", report.Root, report.ConfirmToken))
+	}
+	return b.String()
+}
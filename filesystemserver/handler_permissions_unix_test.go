@@ -0,0 +1,112 @@
+//go:build linux || darwin || freebsd
+
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePermissionTree creates tree/ with 0777 files, a script, a directory
+// missing its execute bits and a symlink
+func writePermissionTree(t *testing.T, dir string) string {
+	t.Helper()
+	tree := filepath.Join(dir, "tree")
+	writeFixture(t, tree, map[string]string{"a.txt": "a", "run.sh": "#!/bin/sh\n", "sub/b.txt": "b", "sub/deep/c.txt": "c"})
+	for name, mode := range map[string]os.FileMode{"a.txt": 0777, "run.sh": 0644, "sub/b.txt": 0777, "sub/deep/c.txt": 0600, "sub/deep": 0700, "sub": 0644} {
+		require.NoError(t, os.Chmod(filepath.Join(tree, name), mode))
+	}
+	t.Cleanup(func() { os.Chmod(filepath.Join(tree, "sub"), 0755) })
+	require.NoError(t, os.Symlink(filepath.Join(tree, "a.txt"), filepath.Join(tree, "link")))
+	return tree
+}
+
+func modeOf(t *testing.T, path string) os.FileMode {
+	t.Helper()
+	return mustLstat(t, path).Mode().Perm()
+}
+
+func mustLstat(t *testing.T, path string) os.FileInfo {
+	t.Helper()
+	info, err := os.Lstat(path)
+	require.NoError(t, err)
+	return info
+}
+
+func TestFixPermissions(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	tree := writePermissionTree(t, dir)
+	args := map[string]interface{}{"path": tree, "recursive": true, "executable_globs": []interface{}{"*.sh"}, "dry_run": true}
+
+	text, isError := callText(t, handler.handleFixPermissions, "fix_permissions", args)
+	require.False(t, isError, text)
+	assert.Contains(t, text, "🔐 Fix permissions (dry run)")
+	assert.Contains(t, text, "📊 Scanned 7 entries, skipped 1\n")
+	assert.Contains(t, text, "🔧 Would change 6 entries:\n  directory 0644 → 0755: 1\n  directory 0700 → 0755: 1\n  executable 0644 → 0755: 1\n  file 0600 → 0644: 1\n  file 0777 → 0644: 2\n")
+	assert.NotContains(t, text, "confirm")
+	assert.Equal(t, os.FileMode(0777), modeOf(t, filepath.Join(tree, "a.txt")), "dry run changes nothing")
+
+	delete(args, "dry_run")
+	result, err := handler.handleFixPermissions(context.Background(), newToolRequest("fix_permissions", args))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	var report PermissionFixReport
+	decodeStructured(t, result, &report)
+	assert.Equal(t, 6, report.Changed)
+	assert.Equal(t, PermissionChange{Kind: "file", From: "0777", To: "0644", Count: 2}, report.Changes[4])
+	for name, want := range map[string]os.FileMode{"a.txt": 0644, "run.sh": 0755, "sub": 0755, "sub/b.txt": 0644, "sub/deep": 0755, "sub/deep/c.txt": 0644} {
+		assert.Equal(t, want, modeOf(t, filepath.Join(tree, name)), name)
+	}
+	assert.Equal(t, os.ModeSymlink, mustLstat(t, filepath.Join(tree, "link")).Mode()&os.ModeSymlink)
+
+	text, _ = callText(t, handler.handleFixPermissions, "fix_permissions", args)
+	assert.Contains(t, text, "✅ Permissions already as requested")
+}
+
+func TestFixPermissionsNonRecursiveAndModes(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	tree := writePermissionTree(t, dir)
+
+	text, isError := callText(t, handler.handleFixPermissions, "fix_permissions", map[string]interface{}{"path": tree, "file_mode": "0640", "dir_mode": float64(750)})
+	require.False(t, isError, text)
+	assert.Equal(t, os.FileMode(0640), modeOf(t, filepath.Join(tree, "a.txt")))
+	assert.Equal(t, os.FileMode(0750), modeOf(t, filepath.Join(tree, "sub")))
+	assert.Equal(t, os.FileMode(0777), modeOf(t, filepath.Join(tree, "sub/b.txt")), "not recursive")
+
+	text, isError = callText(t, handler.handleFixPermissions, "fix_permissions", map[string]interface{}{"path": tree, "file_mode": "rw-r--r--"})
+	assert.True(t, isError)
+	assert.Contains(t, text, "file_mode must be an octal mode")
+}
+
+func TestFixPermissionsAllowedRootNeedsConfirm(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	tree := writePermissionTree(t, dir)
+	args := map[string]interface{}{"path": dir, "recursive": true}
+
+	text, isError := callText(t, handler.handleFixPermissions, "fix_permissions", args)
+	assert.True(t, isError)
+	assert.Contains(t, text, "is an allowed root directory; run with dry_run: true")
+	assert.Equal(t, os.FileMode(0777), modeOf(t, filepath.Join(tree, "a.txt")))
+
+	args["dry_run"] = true
+	text, isError = callText(t, handler.handleFixPermissions, "fix_permissions", args)
+	require.False(t, isError)
+	token := regexp.MustCompile(`confirm: "([0-9a-f]+)"`).FindStringSubmatch(text)
+	require.NotNil(t, token, text)
+
+	// El token solo vale para los mismos parámetros
+	args["dry_run"], args["confirm"], args["file_mode"] = false, token[1], "600"
+	_, isError = callText(t, handler.handleFixPermissions, "fix_permissions", args)
+	assert.True(t, isError)
+
+	delete(args, "file_mode")
+	text, isError = callText(t, handler.handleFixPermissions, "fix_permissions", args)
+	require.False(t, isError, text)
+	assert.Equal(t, os.FileMode(0644), modeOf(t, filepath.Join(tree, "a.txt")))
+}
@@ -2,10 +2,16 @@ package filesystemserver
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -20,8 +26,39 @@ type TaskPlan struct {
 	EstimatedOps int        `json:"estimated_ops"`
 	RiskLevel   string      `json:"risk_level"`
 	Dependencies []string   `json:"dependencies"`
+	Created     time.Time   `json:"created"`
+	Updated     time.Time   `json:"updated"`
+	Risk        *RiskMetrics      `json:"risk,omitempty"`
+	Status      string            `json:"status,omitempty"`
+	Backups     map[string]string `json:"backups,omitempty"`
 }
 
+// RiskMetrics holds the measured facts behind a plan's risk level
+type RiskMetrics struct {
+	Level            string   `json:"level"`
+	FilesAffected    int      `json:"files_affected"` // files removed or moved by delete/move steps
+	BytesAffected    int64    `json:"bytes_affected"`
+	GitRepo          bool     `json:"git_repo"`
+	GitBranch        string   `json:"git_branch,omitempty"`
+	GitDirty         bool     `json:"git_dirty"` // targets modified after the last index update
+	BackupCovered    bool     `json:"backup_covered"`
+	OutsideWorkspace []string `json:"outside_workspace,omitempty"`
+	Reasons          []string `json:"reasons,omitempty"`
+	Incomplete       bool     `json:"incomplete,omitempty"` // the walk was cut short; counts are lower bounds
+}
+
+// Thresholds above which destructive steps are high risk regardless of safety nets
+const (
+	riskFileThreshold  = 50
+	riskBytesThreshold = 100 * 1024 * 1024
+)
+
+// planDirName is the directory, relative to the workspace, where plans are persisted
+const planDirName = ".mcp-plans"
+
+// planIDPattern matches the IDs produced by generateTaskID
+var planIDPattern = regexp.MustCompile(`^task_[0-9]{8}T[0-9]{6}_[0-9a-f]{8}$`)
+
 // TaskStep represents a single step in the plan
 type TaskStep struct {
 	ID          int      `json:"id"`
@@ -31,13 +68,39 @@ type TaskStep struct {
 	Command     string   `json:"command,omitempty"`
 	Risk        string   `json:"risk"`
 	Rollback    string   `json:"rollback"`
+	NeedsInput  string   `json:"needs_input,omitempty"`
+	Destination string   `json:"destination,omitempty"`
+	Content     string   `json:"content,omitempty"`
+	OldText     string   `json:"old_text,omitempty"`
+	NewText     string   `json:"new_text,omitempty"`
+	Status      string       `json:"status,omitempty"`
+	Output      []string     `json:"output,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	Changes     []PlanChange `json:"changes,omitempty"`
+	Completed   *time.Time   `json:"completed,omitempty"`
+}
+
+// PlanChange records a filesystem change made by a step so it can be rolled back
+type PlanChange struct {
+	Action string `json:"action"` // "created", "moved", "modified" or "deleted"
+	Path   string `json:"path"`
+	Source string `json:"source,omitempty"` // original path for moves, backup for modify/delete
 }
 
 // handlePlanTask creates step-by-step execution plan for complex operations
 func (fs *FilesystemHandler) handlePlanTask(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	description, _ := request.Params.Arguments["description"].(string)
-	workspace, _ := request.Params.Arguments["workspace"].(string)
-	targetFilesParam, _ := request.Params.Arguments["target_files"].([]interface{})
+	description, err := getString(request.Params.Arguments, "description", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	workspace, err := getString(request.Params.Arguments, "workspace", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	targetFiles, err := getStringSlice(request.Params.Arguments, "target_files")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
 
 	if description == "" {
 		return &mcp.CallToolResult{
@@ -48,25 +111,11 @@ func (fs *FilesystemHandler) handlePlanTask(ctx context.Context, request mcp.Cal
 		}, nil
 	}
 
-	// Convert target files
-	targetFiles := []string{}
-	for _, file := range targetFilesParam {
-		if str, ok := file.(string); ok {
-			targetFiles = append(targetFiles, str)
-		}
+	if targetFiles == nil {
+		targetFiles = []string{}
 	}
 
-	// Use current directory if no workspace specified
-	if workspace == "" {
-		cwd, err := os.Getwd()
-		if err != nil {
-			workspace = "."
-		} else {
-			workspace = cwd
-		}
-	}
-
-	validWorkspace, err := fs.validatePath(workspace)
+	validWorkspace, err := fs.resolvePlanWorkspace(workspace)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -76,7 +125,7 @@ func (fs *FilesystemHandler) handlePlanTask(ctx context.Context, request mcp.Cal
 		}, nil
 	}
 
-	plan, err := fs.createTaskPlan(description, validWorkspace, targetFiles)
+	plan, err := fs.createTaskPlan(ctx, description, validWorkspace, targetFiles)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -87,6 +136,13 @@ func (fs *FilesystemHandler) handlePlanTask(ctx context.Context, request mcp.Cal
 	}
 
 	result := fs.formatTaskPlan(plan)
+	if fs.readOnly {
+		result += "\nℹ️ Plan not saved: server is in read-only mode\n"
+	} else if planPath, err := fs.savePlan(plan); err != nil {
+		result += fmt.Sprintf("\n⚠️ Plan not saved: %v\n", err)
+	} else {
+		result += fmt.Sprintf("\n💾 Saved to %s (retrieve with %s)\n", planPath, fs.toolName("get_plan"))
+	}
 
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
@@ -96,138 +152,67 @@ func (fs *FilesystemHandler) handlePlanTask(ctx context.Context, request mcp.Cal
 }
 
 // createTaskPlan analyzes the task and creates execution plan
-func (fs *FilesystemHandler) createTaskPlan(description, workspace string, targetFiles []string) (*TaskPlan, error) {
+func (fs *FilesystemHandler) createTaskPlan(ctx context.Context, description, workspace string, targetFiles []string) (*TaskPlan, error) {
+	now := time.Now()
 	plan := &TaskPlan{
 		ID:          generateTaskID(),
 		Description: description,
 		Workspace:   workspace,
 		Steps:       []TaskStep{},
 		Dependencies: []string{},
+		Created:     now,
+		Updated:     now,
 	}
 
 	// Analyze workspace context
-	context, err := fs.analyzeWorkspaceContext(workspace)
+	context, err := fs.analyzeWorkspaceContext(ctx, workspace)
 	if err != nil {
 		return nil, err
 	}
 
 	// Generate steps based on task description
-	steps := fs.generateStepsFromDescription(description, workspace, targetFiles, context)
+	steps := fs.generateStepsFromDescription(ctx, description, workspace, targetFiles, context)
 	plan.Steps = steps
 
 	// Calculate complexity and risk
 	plan.Complexity = fs.calculateComplexityLevel(steps)
 	plan.EstimatedOps = len(steps)
-	plan.RiskLevel = fs.calculateRiskLevel(steps)
+	fs.applyPlanRisk(ctx, plan)
 	plan.Dependencies = fs.extractTaskDependencies(steps, context)
 
 	return plan, nil
 }
 
-// analyzeWorkspaceContext gathers project information
-func (fs *FilesystemHandler) analyzeWorkspaceContext(workspace string) (map[string]interface{}, error) {
+// analyzeWorkspaceContext gathers project information, reusing the cached
+// workspace_context result when there is one
+func (fs *FilesystemHandler) analyzeWorkspaceContext(ctx context.Context, workspace string) (map[string]interface{}, error) {
 	context := make(map[string]interface{})
 
-	// Detect project type
-	projectType := fs.detectProjectType(workspace)
-	context["project_type"] = projectType
-
-	// Find important files
-	importantFiles := fs.findImportantFiles(workspace)
-	context["important_files"] = importantFiles
-
-	// Get directory structure overview
-	structure, _ := fs.getDirectoryOverview(workspace)
-	context["structure"] = structure
+	workspaceContext, err := fs.workspaceContext(ctx, workspace, false)
+	if err != nil {
+		return nil, err
+	}
+	context["project_type"] = workspaceContext.ProjectType
+	context["important_files"] = workspaceContext.ImportantFiles
+	context["important_groups"] = workspaceContext.ImportantGroups
+	context["structure"] = workspaceContext.overview()
 
 	return context, nil
 }
 
-// detectProjectType identifies the type of project
-func (fs *FilesystemHandler) detectProjectType(workspace string) string {
-	patterns := map[string][]string{
-		"go":         {"go.mod", "go.sum", "main.go"},
-		"node":       {"package.json", "node_modules"},
-		"python":     {"requirements.txt", "setup.py", "pyproject.toml"},
-		"rust":       {"Cargo.toml", "Cargo.lock"},
-		"java":       {"pom.xml", "build.gradle", "src/main/java"},
-		"dotnet":     {"*.csproj", "*.sln", "Program.cs"},
-		"web":        {"index.html", "src", "public"},
-		"docker":     {"Dockerfile", "docker-compose.yml"},
-	}
-
-	for projectType, files := range patterns {
-		for _, file := range files {
-			if filepath.Ext(file) == "" {
-				// Directory or exact file
-				if _, err := os.Stat(filepath.Join(workspace, file)); err == nil {
-					return projectType
-				}
-			} else {
-				// Pattern matching
-				matches, _ := filepath.Glob(filepath.Join(workspace, file))
-				if len(matches) > 0 {
-					return projectType
-				}
-			}
-		}
-	}
-
-	return "unknown"
-}
-
-// findImportantFiles locates key configuration and source files
-func (fs *FilesystemHandler) findImportantFiles(workspace string) []string {
-	important := []string{}
-	
-	importantPatterns := []string{
-		"*.go", "*.js", "*.ts", "*.py", "*.rs", "*.java", "*.cs",
-		"package.json", "go.mod", "Cargo.toml", "requirements.txt",
-		"Dockerfile", "docker-compose.yml", "Makefile",
-		"README.md", "LICENSE", ".gitignore",
-	}
-
-	err := filepath.Walk(workspace, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() {
-			return nil
-		}
-
-		if _, err := fs.validatePath(path); err != nil {
-			return nil
-		}
-
-		relPath, _ := filepath.Rel(workspace, path)
-		filename := info.Name()
-
-		for _, pattern := range importantPatterns {
-			matched, _ := filepath.Match(pattern, filename)
-			if matched {
-				important = append(important, relPath)
-				break
-			}
-		}
-
-		// Limit to avoid huge lists
-		if len(important) >= 50 {
-			return filepath.SkipDir
-		}
-
-		return nil
-	})
-
-	if err == nil && len(important) > 20 {
-		important = important[:20]
-	}
-
-	return important
+// findImportantFiles locates key configuration and source files, best ranked
+// first (see rankImportantFiles)
+func (fs *FilesystemHandler) findImportantFiles(ctx context.Context, workspace string) []string {
+	return flattenImportantFiles(fs.rankImportantFiles(ctx, workspace))
 }
 
 // getDirectoryOverview provides high-level structure info
-func (fs *FilesystemHandler) getDirectoryOverview(workspace string) (map[string]int, error) {
+func (fs *FilesystemHandler) getDirectoryOverview(ctx context.Context, workspace string) (map[string]int, error) {
 	overview := make(map[string]int)
 
-	err := filepath.Walk(workspace, func(path string, info os.FileInfo, err error) error {
+	err := fs.walkContext(ctx, workspace, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
+			fs.logWalkError(ctx, path, err)
 			return nil
 		}
 
@@ -236,6 +221,9 @@ func (fs *FilesystemHandler) getDirectoryOverview(workspace string) (map[string]
 		}
 
 		if info.IsDir() {
+			if path != workspace && containsString(planSkipDirs, info.Name()) {
+				return filepath.SkipDir
+			}
 			overview["directories"]++
 		} else {
 			overview["files"]++
@@ -251,149 +239,333 @@ func (fs *FilesystemHandler) getDirectoryOverview(workspace string) (map[string]
 	return overview, err
 }
 
-// generateStepsFromDescription creates steps based on task description
-func (fs *FilesystemHandler) generateStepsFromDescription(description, workspace string, targetFiles []string, context map[string]interface{}) []TaskStep {
+// planSkipDirs are never searched when resolving files for a plan
+var planSkipDirs = []string{".git", ".svn", ".hg", "node_modules", "vendor", planDirName, snapshotDirName, reportDirName}
+
+// descriptionFilePattern finds file names mentioned in a task description
+var descriptionFilePattern = regexp.MustCompile("(?:^|[\\s\"'`(])((?:[\\w.-]+/)*[\\w-]{2,}\\.[A-Za-z][A-Za-z0-9]{0,5})\\b")
+
+// generateStepsFromDescription creates steps based on task description, attaching
+// the concrete files each step touches. Steps whose files can't be resolved are
+// flagged with NeedsInput instead of using placeholders.
+func (fs *FilesystemHandler) generateStepsFromDescription(ctx context.Context, description, workspace string, targetFiles []string, context map[string]interface{}) []TaskStep {
 	steps := []TaskStep{}
 	stepID := 1
 
 	// Analyze description for key operations
 	desc := strings.ToLower(description)
 
+	files, missing := fs.resolveTargetFiles(ctx, workspace, targetFiles)
+	mentioned, newFiles := fs.descriptionFiles(workspace, description)
+	if len(targetFiles) == 0 {
+		files = mentioned
+	}
+
+	addSteps := func(newSteps []TaskStep) {
+		for i := range newSteps {
+			newSteps[i].ID = stepID + i
+		}
+		steps = append(steps, newSteps...)
+		stepID += len(newSteps)
+	}
+
 	// Backup step for risky operations
 	if fs.isRiskyOperation(desc) {
-		steps = append(steps, TaskStep{
-			ID:          stepID,
+		addSteps([]TaskStep{withFiles(TaskStep{
 			Type:        "backup",
 			Description: "Create backup of files before modifications",
-			Files:       targetFiles,
 			Risk:        "low",
 			Rollback:    "Restore from backup",
-		})
-		stepID++
+		}, files, "target_files to back up")})
 	}
 
 	// Add specific steps based on keywords
 	if strings.Contains(desc, "refactor") || strings.Contains(desc, "restructure") {
-		steps = append(steps, fs.generateRefactorSteps(targetFiles, stepID)...)
-		stepID += len(steps)
+		addSteps(fs.generateRefactorSteps(files, context))
 	}
 
 	if strings.Contains(desc, "move") || strings.Contains(desc, "rename") {
-		steps = append(steps, fs.generateMoveSteps(targetFiles, stepID)...)
-		stepID += len(steps)
+		addSteps(fs.generateMoveSteps(ctx, workspace, files))
 	}
 
 	if strings.Contains(desc, "add") || strings.Contains(desc, "create") {
-		steps = append(steps, fs.generateCreateSteps(description, workspace, stepID)...)
-		stepID += len(steps)
+		addSteps(fs.generateCreateSteps(newFiles))
 	}
 
 	if strings.Contains(desc, "delete") || strings.Contains(desc, "remove") {
-		steps = append(steps, fs.generateDeleteSteps(targetFiles, stepID)...)
-		stepID += len(steps)
+		addSteps(fs.generateDeleteSteps(files))
 	}
 
-	// Validation step
+	// Validation step over the files that still exist afterwards
 	if len(steps) > 1 {
-		steps = append(steps, TaskStep{
-			ID:          stepID,
+		validate := []string{}
+		if !strings.Contains(desc, "delete") && !strings.Contains(desc, "remove") && !strings.Contains(desc, "move") {
+			validate = append(validate, files...)
+		}
+		validate = append(validate, newFiles...)
+		addSteps([]TaskStep{{
 			Type:        "validate",
 			Description: "Validate changes and run basic checks",
-			Files:       targetFiles,
+			Files:       validate,
 			Risk:        "low",
 			Rollback:    "Fix validation errors",
-		})
+		}})
 	}
 
 	// Default fallback step
 	if len(steps) == 0 {
-		steps = append(steps, TaskStep{
-			ID:          1,
+		addSteps([]TaskStep{{
 			Type:        "analyze",
 			Description: "Analyze requirements and plan detailed approach",
-			Files:       targetFiles,
+			Files:       files,
 			Risk:        "low",
 			Rollback:    "No changes made",
-		})
+		}})
+	}
+
+	if len(missing) > 0 {
+		for i := range steps {
+			note := fmt.Sprintf("target_files not found: %s", strings.Join(missing, ", "))
+			if steps[i].NeedsInput != "" {
+				note = steps[i].NeedsInput + "; " + note
+			}
+			steps[i].NeedsInput = note
+		}
 	}
 
 	return steps
 }
 
+// withFiles attaches files to a step, or flags it as needing input when there are none
+func withFiles(step TaskStep, files []string, needs string) TaskStep {
+	step.Files = append([]string{}, files...)
+	if len(files) == 0 {
+		step.NeedsInput = needs
+	}
+	return step
+}
+
+// resolveTargetFiles expands globs in target_files and keeps the paths that exist,
+// relative to the workspace when they are inside it
+func (fs *FilesystemHandler) resolveTargetFiles(ctx context.Context, workspace string, targetFiles []string) ([]string, []string) {
+	resolved := []string{}
+	missing := []string{}
+	seen := make(map[string]bool)
+
+	add := func(path string) {
+		if _, err := fs.validatePath(path); err != nil {
+			return
+		}
+		rel := planRelPath(workspace, path)
+		if !seen[rel] {
+			seen[rel] = true
+			resolved = append(resolved, rel)
+		}
+	}
+
+	for _, target := range targetFiles {
+		before := len(resolved)
+		if strings.ContainsAny(target, "*?[") {
+			pattern := filepath.ToSlash(target)
+			if filepath.IsAbs(target) {
+				pattern = planRelPath(workspace, target)
+			}
+			fs.walkContext(ctx, workspace, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					fs.logWalkError(ctx, path, err)
+					return nil
+				}
+				if info.IsDir() {
+					if path != workspace && containsString(planSkipDirs, info.Name()) {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if matchesAnyPattern(workspace, path, []string{pattern}) {
+					add(path)
+				}
+				return nil
+			})
+		} else {
+			path := target
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(workspace, path)
+			}
+			if _, err := os.Stat(path); err == nil {
+				add(path)
+			}
+		}
+		if len(resolved) == before {
+			missing = append(missing, target)
+		}
+	}
+
+	sort.Strings(resolved)
+	return resolved, missing
+}
+
+// descriptionFiles splits the file names mentioned in the description into
+// existing files and files that would have to be created
+func (fs *FilesystemHandler) descriptionFiles(workspace, description string) ([]string, []string) {
+	existing := []string{}
+	created := []string{}
+	for _, match := range descriptionFilePattern.FindAllStringSubmatch(description, -1) {
+		name := match[1]
+		path := filepath.Join(workspace, filepath.FromSlash(name))
+		if _, err := fs.validatePath(path); err != nil {
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			if !containsString(existing, name) {
+				existing = append(existing, name)
+			}
+		} else if !containsString(created, name) {
+			created = append(created, name)
+		}
+	}
+	return existing, created
+}
+
+// findReferencingFiles returns the files that mention the names of the given files
+func (fs *FilesystemHandler) findReferencingFiles(ctx context.Context, workspace string, files []string) []string {
+	const maxReferences = 50
+	refs := []string{}
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		if name == "" {
+			continue
+		}
+		matches, err := fs.performAdvancedTextSearch(ctx, workspace, regexp.QuoteMeta(name), true, true, false, 0, nil, nil)
+		if err != nil {
+			continue
+		}
+		for _, match := range matches {
+			rel := planRelPath(workspace, match.File)
+			if containsString(files, rel) || containsString(refs, rel) || inPlanSkipDir(rel) {
+				continue
+			}
+			refs = append(refs, rel)
+		}
+	}
+
+	sort.Strings(refs)
+	if len(refs) > maxReferences {
+		refs = refs[:maxReferences]
+	}
+	return refs
+}
+
+// planRelPath returns path relative to the workspace, or unchanged if it is outside
+func planRelPath(workspace, path string) string {
+	rel, err := filepath.Rel(workspace, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// inPlanSkipDir reports whether a relative path lies under one of planSkipDirs
+func inPlanSkipDir(rel string) bool {
+	for _, part := range strings.Split(rel, "/") {
+		if containsString(planSkipDirs, part) {
+			return true
+		}
+	}
+	return false
+}
+
 // Helper functions for step generation
-func (fs *FilesystemHandler) generateRefactorSteps(files []string, startID int) []TaskStep {
+func (fs *FilesystemHandler) generateRefactorSteps(files []string, context map[string]interface{}) []TaskStep {
+	analyzeFiles := files
+	if len(analyzeFiles) == 0 {
+		// Code only: manifests and docs have no dependencies to analyze
+		groups, _ := context["important_groups"].([]ImportantFileGroup)
+		for _, group := range groups {
+			if group.Category == importantEntryPoint || group.Category == importantSource {
+				analyzeFiles = append(analyzeFiles, group.Files...)
+			}
+		}
+	}
+	if len(analyzeFiles) == 0 {
+		analyzeFiles, _ = context["important_files"].([]string)
+	}
+
+	modify := withFiles(TaskStep{
+		Type:        "modify",
+		Description: "Apply refactoring changes incrementally",
+		Risk:        "medium",
+		Rollback:    "Revert file changes",
+	}, files, "target_files to modify")
+	if modify.NeedsInput == "" {
+		modify.NeedsInput = "old_text and new_text for the edit"
+	}
+
 	return []TaskStep{
-		{
-			ID:          startID,
+		withFiles(TaskStep{
 			Type:        "analyze",
 			Description: "Analyze code dependencies and relationships",
-			Files:       files,
 			Risk:        "low",
 			Rollback:    "No changes made",
-		},
-		{
-			ID:          startID + 1,
-			Type:        "modify",
-			Description: "Apply refactoring changes incrementally",
-			Files:       files,
-			Risk:        "medium",
-			Rollback:    "Revert file changes",
-		},
+		}, analyzeFiles, "files to analyze"),
+		modify,
 	}
 }
 
-func (fs *FilesystemHandler) generateMoveSteps(files []string, startID int) []TaskStep {
+func (fs *FilesystemHandler) generateMoveSteps(ctx context.Context, workspace string, files []string) []TaskStep {
+	copyStep := withFiles(TaskStep{
+		Type:        "copy",
+		Description: "Copy files to new location",
+		Risk:        "low",
+		Rollback:    "Delete copied files",
+	}, files, "target_files to move")
+	if copyStep.NeedsInput == "" {
+		copyStep.NeedsInput = "destination for the copied files"
+	}
+
+	update := TaskStep{
+		Type:        "update",
+		Description: "Update references and imports",
+		Risk:        "medium",
+		Rollback:    "Restore original references",
+	}
+	if len(files) == 0 {
+		update = withFiles(update, nil, "target_files to move")
+	} else {
+		update = withFiles(update, fs.findReferencingFiles(ctx, workspace, files), "no references found; list the files to update or drop this step")
+	}
+
 	return []TaskStep{
-		{
-			ID:          startID,
-			Type:        "copy",
-			Description: "Copy files to new location",
-			Files:       files,
-			Risk:        "low",
-			Rollback:    "Delete copied files",
-		},
-		{
-			ID:          startID + 1,
-			Type:        "update",
-			Description: "Update references and imports",
-			Files:       []string{"*"},
-			Risk:        "medium",
-			Rollback:    "Restore original references",
-		},
-		{
-			ID:          startID + 2,
+		copyStep,
+		update,
+		withFiles(TaskStep{
 			Type:        "delete",
 			Description: "Remove original files",
-			Files:       files,
-			Risk:        "high",
+			Risk:        "medium",
 			Rollback:    "Restore from backup",
-		},
+		}, files, "target_files to move"),
 	}
 }
 
-func (fs *FilesystemHandler) generateCreateSteps(description, workspace string, startID int) []TaskStep {
+func (fs *FilesystemHandler) generateCreateSteps(newFiles []string) []TaskStep {
 	return []TaskStep{
-		{
-			ID:          startID,
+		withFiles(TaskStep{
 			Type:        "create",
 			Description: "Create new files/directories",
-			Files:       []string{"new files"},
 			Risk:        "low",
 			Rollback:    "Delete created files",
-		},
+		}, newFiles, "paths of the files to create"),
 	}
 }
 
-func (fs *FilesystemHandler) generateDeleteSteps(files []string, startID int) []TaskStep {
+func (fs *FilesystemHandler) generateDeleteSteps(files []string) []TaskStep {
 	return []TaskStep{
-		{
-			ID:          startID,
+		withFiles(TaskStep{
 			Type:        "delete",
 			Description: "Remove specified files/directories",
-			Files:       files,
-			Risk:        "high",
+			Risk:        "medium",
 			Rollback:    "Restore from backup",
-		},
+		}, files, "target_files to delete"),
 	}
 }
 
@@ -438,6 +610,222 @@ func (fs *FilesystemHandler) calculateRiskLevel(steps []TaskStep) string {
 	return "low"
 }
 
+// isDestructiveStep reports whether a step removes or relocates its files
+func isDestructiveStep(step TaskStep) bool {
+	switch strings.ToLower(step.Type) {
+	case "delete", "move":
+		return true
+	}
+	return false
+}
+
+// riskRank orders risk levels so they can be compared
+func riskRank(level string) int {
+	switch level {
+	case "high":
+		return 2
+	case "medium":
+		return 1
+	}
+	return 0
+}
+
+// applyPlanRisk measures the plan, raises destructive steps to the measured level
+// and sets the plan's overall risk. Explicit step risks are never lowered.
+func (fs *FilesystemHandler) applyPlanRisk(ctx context.Context, plan *TaskPlan) {
+	plan.Risk = fs.assessPlanRisk(ctx, plan)
+	for i := range plan.Steps {
+		if isDestructiveStep(plan.Steps[i]) && riskRank(plan.Risk.Level) > riskRank(plan.Steps[i].Risk) {
+			plan.Steps[i].Risk = plan.Risk.Level
+		}
+	}
+
+	plan.RiskLevel = fs.calculateRiskLevel(plan.Steps)
+	if riskRank(plan.Risk.Level) > riskRank(plan.RiskLevel) {
+		plan.RiskLevel = plan.Risk.Level
+	}
+}
+
+// assessPlanRisk computes risk from what the destructive steps would actually touch:
+// file counts and sizes, git state, backup coverage and workspace containment.
+// If ctx ends mid-walk the metrics are marked incomplete and rated high.
+func (fs *FilesystemHandler) assessPlanRisk(ctx context.Context, plan *TaskPlan) *RiskMetrics {
+	metrics := &RiskMetrics{Level: "low"}
+
+	gitDir, workTree := findGitDir(plan.Workspace)
+	var indexTime time.Time
+	var index gitIndex
+	if gitDir != "" {
+		metrics.GitRepo = true
+		metrics.GitBranch = readGitBranch(gitDir)
+		if info, err := os.Stat(filepath.Join(gitDir, "index")); err == nil {
+			indexTime = info.ModTime()
+		}
+		index, _ = readGitIndex(filepath.Join(gitDir, "index"))
+	}
+	// Con un índice legible se compara cada objetivo con su blob; si no, se
+	// considera modificado lo que cambió después de la última actualización del índice
+	gitChanged := func(path string, info os.FileInfo) bool {
+		if index != nil {
+			rel, err := filepath.Rel(workTree, path)
+			if err != nil {
+				return true
+			}
+			rel = filepath.ToSlash(rel)
+			if _, tracked := index[rel]; !tracked {
+				return true
+			}
+			return index.fileStatus(rel, path) != ""
+		}
+		return indexTime.IsZero() || info.ModTime().After(indexTime)
+	}
+
+	backedUp := make(map[string]bool)
+	for original := range plan.Backups {
+		backedUp[original] = true
+	}
+	for _, step := range plan.Steps {
+		if strings.ToLower(step.Type) == "backup" {
+			for _, file := range step.Files {
+				backedUp[planStepPath(plan, file)] = true
+			}
+		}
+	}
+
+	destructive := []string{}
+	for _, step := range plan.Steps {
+		if !isDestructiveStep(step) || step.Status == stepDone || step.Status == stepSkipped {
+			continue
+		}
+		for _, file := range step.Files {
+			if !containsString(planPlaceholderFiles, file) {
+				destructive = append(destructive, planStepPath(plan, file))
+			}
+		}
+	}
+	if len(destructive) == 0 {
+		return metrics
+	}
+
+	metrics.BackupCovered = true
+	for _, target := range destructive {
+		if !pathWithinDir(target, plan.Workspace) {
+			metrics.OutsideWorkspace = append(metrics.OutsideWorkspace, target)
+		}
+		if !backedUp[target] {
+			if realPath, err := filepath.EvalSymlinks(target); err != nil || !backedUp[realPath] {
+				metrics.BackupCovered = false
+			}
+		}
+
+		fs.walkContext(ctx, target, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			metrics.FilesAffected++
+			metrics.BytesAffected += info.Size()
+			if gitDir != "" && gitChanged(path, info) {
+				metrics.GitDirty = true
+			}
+			return nil
+		})
+		if ctx.Err() != nil {
+			metrics.Incomplete = true
+			metrics.Level = "high"
+			metrics.Reasons = append(metrics.Reasons, fmt.Sprintf("risk assessment incomplete: %v", ctx.Err()))
+			return metrics
+		}
+	}
+
+	metrics.Level = "medium"
+	high := func(reason string) {
+		metrics.Level = "high"
+		metrics.Reasons = append(metrics.Reasons, reason)
+	}
+	if len(metrics.OutsideWorkspace) > 0 {
+		high(fmt.Sprintf("%d targets outside the workspace", len(metrics.OutsideWorkspace)))
+	}
+	if metrics.FilesAffected > riskFileThreshold {
+		high(fmt.Sprintf("%d files affected (threshold %d)", metrics.FilesAffected, riskFileThreshold))
+	}
+	if metrics.BytesAffected > riskBytesThreshold {
+		high(fmt.Sprintf("%d bytes affected (threshold %d)", metrics.BytesAffected, riskBytesThreshold))
+	}
+	if !metrics.BackupCovered {
+		switch {
+		case !metrics.GitRepo:
+			high("targets are not under git and have no backup step")
+		case metrics.GitDirty:
+			high("targets have uncommitted changes and no backup step")
+		}
+	}
+	if metrics.Level == "medium" {
+		metrics.Reasons = append(metrics.Reasons, "destructive steps are covered by git or backups")
+	}
+	return metrics
+}
+
+// findGitDir walks up from dir looking for a .git directory (or a .git file
+// pointing to one) and returns it with the work tree that contains it
+func findGitDir(dir string) (string, string) {
+	for {
+		if gitDir := gitDirAt(dir); gitDir != "" {
+			return gitDir, dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// readGitBranch returns the branch named in .git/HEAD, or the short commit when detached
+func readGitBranch(gitDir string) string {
+	data, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	head := strings.TrimSpace(string(data))
+	if ref, ok := strings.CutPrefix(head, "ref: "); ok {
+		return strings.TrimPrefix(ref, "refs/heads/")
+	}
+	if len(head) > 12 {
+		head = head[:12]
+	}
+	return head
+}
+
+// formatRiskMetrics renders the measured risk facts for the plan display
+func formatRiskMetrics(metrics *RiskMetrics) string {
+	var result strings.Builder
+	result.WriteString("**Risk factors:**\n")
+	if metrics.Incomplete {
+		result.WriteString("  • ⚠️ Assessment incomplete: the counts below are lower bounds\n")
+	}
+	result.WriteString(fmt.Sprintf("  • Destructive targets: %d files, %d bytes\n", metrics.FilesAffected, metrics.BytesAffected))
+	if metrics.GitRepo {
+		state := "clean"
+		if metrics.GitDirty {
+			state = "uncommitted changes"
+		}
+		result.WriteString(fmt.Sprintf("  • Git: branch %s, targets %s\n", metrics.GitBranch, state))
+	} else {
+		result.WriteString("  • Git: not a repository\n")
+	}
+	if metrics.FilesAffected > 0 {
+		result.WriteString(fmt.Sprintf("  • Backup covers targets: %t\n", metrics.BackupCovered))
+	}
+	for _, outside := range metrics.OutsideWorkspace {
+		result.WriteString(fmt.Sprintf("  • Outside workspace: %s\n", outside))
+	}
+	for _, reason := range metrics.Reasons {
+		result.WriteString(fmt.Sprintf("  • %s\n", reason))
+	}
+	result.WriteString("\n")
+	return result.String()
+}
+
 func (fs *FilesystemHandler) extractTaskDependencies(steps []TaskStep, context map[string]interface{}) []string {
 	deps := []string{}
 	
@@ -467,6 +855,10 @@ func (fs *FilesystemHandler) formatTaskPlan(plan *TaskPlan) string {
 	result.WriteString(fmt.Sprintf("**Complexity:** %s | **Risk:** %s | **Operations:** %d\n\n", 
 		plan.Complexity, plan.RiskLevel, plan.EstimatedOps))
 
+	if plan.Risk != nil {
+		result.WriteString(formatRiskMetrics(plan.Risk))
+	}
+
 	if len(plan.Dependencies) > 0 {
 		result.WriteString("**Dependencies:**\n")
 		for _, dep := range plan.Dependencies {
@@ -490,17 +882,230 @@ func (fs *FilesystemHandler) formatTaskPlan(plan *TaskPlan) string {
 		if len(step.Files) > 0 && step.Files[0] != "*" && step.Files[0] != "new files" {
 			result.WriteString(fmt.Sprintf("   📁 Files: %s\n", strings.Join(step.Files, ", ")))
 		}
+		if step.NeedsInput != "" {
+			result.WriteString(fmt.Sprintf("   ⚠️ Needs input: %s\n", step.NeedsInput))
+		}
 		
 		result.WriteString(fmt.Sprintf("   🔄 Rollback: %s\n", step.Rollback))
 		result.WriteString("\n")
 	}
 
-	result.WriteString("💡 **Recommendation:** Review each step before execution. Create checkpoints for high-risk operations.\n")
+	result.WriteString(fmt.Sprintf("💡 **Recommendation:** Review each step before execution. Create checkpoints with %s for high-risk operations.\n", fs.toolName("create_snapshot")))
 
 	return result.String()
 }
 
-// generateTaskID creates unique task identifier
+// generateTaskID creates unique task identifier from a timestamp and a random suffix
 func generateTaskID() string {
-	return fmt.Sprintf("task_%d", 1000+len(fmt.Sprintf("%d", 12345))) // Simple ID generation
+	return newTimestampID("task")
+}
+
+// newTimestampID returns "<prefix>_<UTC timestamp>_<8 random hex digits>"
+func newTimestampID(prefix string) string {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		// Fall back to the clock so IDs stay unique within this process
+		return fmt.Sprintf("%s_%s_%08x", prefix, time.Now().UTC().Format("20060102T150405"), uint32(time.Now().UnixNano()))
+	}
+	return fmt.Sprintf("%s_%s_%s", prefix, time.Now().UTC().Format("20060102T150405"), hex.EncodeToString(suffix))
+}
+
+// resolvePlanWorkspace validates the workspace, defaulting to the handler workspace
+func (fs *FilesystemHandler) resolvePlanWorkspace(workspace string) (string, error) {
+	if workspace == "" {
+		workspace = "."
+	}
+	return fs.validatePath(workspace)
+}
+
+// planPath returns the validated location of a persisted plan
+func (fs *FilesystemHandler) planPath(workspace, id string) (string, error) {
+	if !planIDPattern.MatchString(id) {
+		return "", fmt.Errorf("invalid plan id '%s'", id)
+	}
+	return fs.validatePath(filepath.Join(workspace, planDirName, id+".json"))
+}
+
+// savePlan writes the plan as JSON under <workspace>/.mcp-plans/<id>.json
+func (fs *FilesystemHandler) savePlan(plan *TaskPlan) (string, error) {
+	// validatePath needs the parent directory to exist
+	if err := fs.mkdirAllChecked(filepath.Join(plan.Workspace, planDirName), 0755); err != nil {
+		return "", err
+	}
+	path, err := fs.planPath(plan.Workspace, plan.ID)
+	if err != nil {
+		return "", err
+	}
+
+	plan.Updated = time.Now()
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := fs.writeFileAtomic(path, string(data)); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// loadPlan reads a persisted plan back from the workspace
+func (fs *FilesystemHandler) loadPlan(workspace, id string) (*TaskPlan, error) {
+	path, err := fs.planPath(workspace, id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := fs.readFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("plan '%s' not found", id)
+		}
+		return nil, err
+	}
+
+	var plan TaskPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("plan '%s' is corrupt: %v", id, err)
+	}
+	return &plan, nil
+}
+
+// listPlans returns every readable plan in the workspace, newest first
+func (fs *FilesystemHandler) listPlans(workspace string) ([]*TaskPlan, error) {
+	entries, err := os.ReadDir(filepath.Join(workspace, planDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var plans []*TaskPlan
+	for _, entry := range entries {
+		id := strings.TrimSuffix(entry.Name(), ".json")
+		if entry.IsDir() || id == entry.Name() || !planIDPattern.MatchString(id) {
+			continue
+		}
+		plan, err := fs.loadPlan(workspace, id)
+		if err != nil {
+			continue
+		}
+		plans = append(plans, plan)
+	}
+
+	sort.Slice(plans, func(i, j int) bool {
+		if !plans[i].Created.Equal(plans[j].Created) {
+			return plans[i].Created.After(plans[j].Created)
+		}
+		return plans[i].ID > plans[j].ID
+	})
+	return plans, nil
+}
+
+// handleGetPlan returns a plan previously created by plan_task
+func (fs *FilesystemHandler) handleGetPlan(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := getString(request.Params.Arguments, "id", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	workspace, err := getString(request.Params.Arguments, "workspace", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	output, err := getString(request.Params.Arguments, "output", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if id == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: id is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validWorkspace, err := fs.resolvePlanWorkspace(workspace)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Invalid workspace: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	plan, err := fs.loadPlan(validWorkspace, id)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: string(data)},
+			},
+		}, nil
+	}
+
+	text := fs.formatTaskPlan(plan)
+	text += fmt.Sprintf("\n🕒 Created: %s | Updated: %s\n", plan.Created.Format(time.RFC3339), plan.Updated.Format(time.RFC3339))
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: text},
+		},
+	}, nil
+}
+
+// handleListPlans lists the plans persisted in a workspace
+func (fs *FilesystemHandler) handleListPlans(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	workspace, err := getString(request.Params.Arguments, "workspace", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	validWorkspace, err := fs.resolvePlanWorkspace(workspace)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Invalid workspace: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	plans, err := fs.listPlans(validWorkspace)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("📋 Plans in %s (%d)\n", validWorkspace, len(plans)))
+	if len(plans) == 0 {
+		result.WriteString(fmt.Sprintf("No plans found. Create one with %s.\n", fs.toolName("plan_task")))
+	}
+	for _, plan := range plans {
+		result.WriteString(fmt.Sprintf("  • %s - %s [%s risk, %d steps] created %s\n",
+			plan.ID, plan.Description, plan.RiskLevel, len(plan.Steps), plan.Created.Format(time.RFC3339)))
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: result.String()},
+		},
+	}, nil
 }
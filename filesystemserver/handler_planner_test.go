@@ -0,0 +1,216 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTaskIDUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := generateTaskID()
+		assert.Regexp(t, planIDPattern, id)
+		assert.False(t, seen[id], id)
+		seen[id] = true
+	}
+}
+
+func TestPlanPersistence(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example\n"), 0644))
+	ctx := context.Background()
+
+	result, err := handler.handlePlanTask(ctx, newToolRequest("plan_task", map[string]interface{}{
+		"description": "refactor the parser",
+		"workspace":   dir,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "💾 Saved to")
+
+	id := regexp.MustCompile(`\*\*ID:\*\* (\S+)`).FindStringSubmatch(text)[1]
+	_, err = os.Stat(filepath.Join(dir, planDirName, id+".json"))
+	require.NoError(t, err)
+
+	plan, err := handler.loadPlan(dir, id)
+	require.NoError(t, err)
+	assert.Equal(t, "refactor the parser", plan.Description)
+	assert.False(t, plan.Created.IsZero())
+	assert.False(t, plan.Updated.Before(plan.Created))
+
+	result, err = handler.handleGetPlan(ctx, newToolRequest("get_plan", map[string]interface{}{
+		"id":        id,
+		"workspace": dir,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "refactor the parser")
+
+	_, err = handler.handlePlanTask(ctx, newToolRequest("plan_task", map[string]interface{}{
+		"description": "add tests",
+		"workspace":   dir,
+	}))
+	require.NoError(t, err)
+
+	result, err = handler.handleListPlans(ctx, newToolRequest("list_plans", map[string]interface{}{
+		"workspace": dir,
+	}))
+	require.NoError(t, err)
+	text = result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "(2)")
+	assert.Contains(t, text, id)
+
+	// Los IDs se validan para no salir de .mcp-plans
+	for _, bad := range []string{"../../etc/passwd", "task_1005", id + "/../x"} {
+		result, err = handler.handleGetPlan(ctx, newToolRequest("get_plan", map[string]interface{}{
+			"id":        bad,
+			"workspace": dir,
+		}))
+		require.NoError(t, err)
+		assert.True(t, result.IsError, bad)
+	}
+}
+
+func TestGenerateStepsResolvesFiles(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	files := map[string]string{
+		"go.mod":         "module example\n",
+		"pkg/parser.go":  "package pkg\n\nfunc Parse() {}\n",
+		"pkg/lexer.go":   "package pkg\n",
+		"cmd/main.go":    "package main\n\n// uses parser\nimport \"example/pkg\" // parser\n",
+		"docs/notes.txt": "see parser for details\n",
+		".git/HEAD":      "parser\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	steps := handler.generateStepsFromDescription(context.Background(), "move the parser", dir, []string{"pkg/parser.go"}, nil)
+	require.Len(t, steps, 5)
+	for i, step := range steps {
+		assert.Equal(t, i+1, step.ID)
+	}
+	assert.Equal(t, "backup", steps[0].Type)
+	assert.Equal(t, []string{"pkg/parser.go"}, steps[0].Files)
+	assert.Equal(t, "copy", steps[1].Type)
+	assert.Equal(t, "destination for the copied files", steps[1].NeedsInput)
+	assert.Equal(t, "update", steps[2].Type)
+	assert.Equal(t, []string{"cmd/main.go", "docs/notes.txt"}, steps[2].Files)
+	assert.Equal(t, []string{"pkg/parser.go"}, steps[3].Files)
+	assert.Equal(t, "validate", steps[4].Type)
+
+	// Globs se expanden; los que no encuentran nada se señalan
+	steps = handler.generateStepsFromDescription(context.Background(), "delete old code", dir, []string{"pkg/*.go", "legacy/*.go"}, nil)
+	assert.Equal(t, []string{"pkg/lexer.go", "pkg/parser.go"}, steps[1].Files)
+	assert.Contains(t, steps[1].NeedsInput, "target_files not found: legacy/*.go")
+
+	// Sin target_files ni archivos en la descripción, nada de comodines
+	steps = handler.generateStepsFromDescription(context.Background(), "create handler_extra.go and refactor stuff", dir, nil, map[string]interface{}{
+		"important_files": []string{"go.mod"},
+	})
+	for _, step := range steps {
+		assert.NotContains(t, step.Files, "*")
+		assert.NotContains(t, step.Files, "new files")
+	}
+	assert.Equal(t, "backup", steps[0].Type)
+	assert.Equal(t, "target_files to back up", steps[0].NeedsInput)
+	assert.Equal(t, []string{"go.mod"}, steps[1].Files)
+	assert.Equal(t, "target_files to modify", steps[2].NeedsInput)
+	assert.Equal(t, "create", steps[3].Type)
+	assert.Equal(t, []string{"handler_extra.go"}, steps[3].Files)
+	assert.Empty(t, steps[3].NeedsInput)
+}
+
+func TestAssessPlanRisk(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	workspace := filepath.Join(dir, "ws")
+	require.NoError(t, os.MkdirAll(filepath.Join(workspace, "old"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(workspace, "old", "a.txt"), []byte("12345"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(workspace, "old", "b.txt"), []byte("123"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "outside.txt"), []byte("x"), 0644))
+
+	plan := &TaskPlan{Workspace: workspace, Steps: []TaskStep{
+		{ID: 1, Type: "delete", Files: []string{"old"}, Risk: "medium"},
+	}}
+
+	// Sin git ni backup
+	handler.applyPlanRisk(context.Background(), plan)
+	assert.Equal(t, "high", plan.Risk.Level)
+	assert.Equal(t, 2, plan.Risk.FilesAffected)
+	assert.Equal(t, int64(8), plan.Risk.BytesAffected)
+	assert.False(t, plan.Risk.GitRepo)
+	assert.Equal(t, "high", plan.Steps[0].Risk)
+	assert.Equal(t, "high", plan.RiskLevel)
+
+	// Con un paso de backup que cubre los objetivos
+	plan.Steps = []TaskStep{
+		{ID: 1, Type: "backup", Files: []string{"old"}, Risk: "low"},
+		{ID: 2, Type: "delete", Files: []string{"old"}, Risk: "medium"},
+	}
+	handler.applyPlanRisk(context.Background(), plan)
+	assert.Equal(t, "medium", plan.Risk.Level)
+	assert.True(t, plan.Risk.BackupCovered)
+	assert.Equal(t, "medium", plan.Steps[1].Risk)
+
+	// Repositorio git limpio: índice más reciente que los objetivos
+	plan.Steps = plan.Steps[1:]
+	gitDir := filepath.Join(dir, ".git")
+	require.NoError(t, os.MkdirAll(gitDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/main\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(gitDir, "index"), []byte("DIRC"), 0644))
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(gitDir, "index"), future, future))
+	handler.applyPlanRisk(context.Background(), plan)
+	assert.True(t, plan.Risk.GitRepo)
+	assert.Equal(t, "main", plan.Risk.GitBranch)
+	assert.False(t, plan.Risk.GitDirty)
+	assert.Equal(t, "medium", plan.Risk.Level)
+
+	// Cambios sin confirmar
+	later := future.Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(workspace, "old", "a.txt"), later, later))
+	handler.applyPlanRisk(context.Background(), plan)
+	assert.True(t, plan.Risk.GitDirty)
+	assert.Equal(t, "high", plan.Risk.Level)
+
+	// Objetivos fuera del workspace
+	require.NoError(t, os.Chtimes(filepath.Join(workspace, "old", "a.txt"), time.Now(), time.Now()))
+	plan.Steps = []TaskStep{{ID: 1, Type: "delete", Files: []string{filepath.Join(dir, "outside.txt")}, Risk: "medium"}}
+	handler.applyPlanRisk(context.Background(), plan)
+	assert.Equal(t, []string{filepath.Join(dir, "outside.txt")}, plan.Risk.OutsideWorkspace)
+	assert.Equal(t, "high", plan.Risk.Level)
+	assert.Contains(t, formatRiskMetrics(plan.Risk), "Outside workspace")
+	assert.False(t, plan.Risk.Incomplete)
+
+	// Contexto cancelado: el recorrido se corta y la evaluación queda incompleta
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	plan.Steps = []TaskStep{
+		{ID: 1, Type: "backup", Files: []string{"old"}, Risk: "low"},
+		{ID: 2, Type: "delete", Files: []string{"old"}, Risk: "medium"},
+	}
+	handler.applyPlanRisk(ctx, plan)
+	assert.True(t, plan.Risk.Incomplete)
+	assert.Equal(t, 0, plan.Risk.FilesAffected)
+	assert.Equal(t, "high", plan.Risk.Level)
+	assert.Equal(t, "high", plan.Steps[1].Risk)
+	assert.Contains(t, plan.Risk.Reasons, "risk assessment incomplete: context canceled")
+	assert.Contains(t, formatRiskMetrics(plan.Risk), "Assessment incomplete")
+
+	// Sin pasos destructivos
+	plan.Steps = []TaskStep{{ID: 1, Type: "analyze", Risk: "low"}}
+	handler.applyPlanRisk(context.Background(), plan)
+	assert.Equal(t, "low", plan.Risk.Level)
+	assert.Equal(t, 0, plan.Risk.FilesAffected)
+}
@@ -0,0 +1,312 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// readOnlyMessage - Error uniforme para llamadas que escribirían en modo solo lectura
+const readOnlyMessage = "❌ Error: server is in read-only mode"
+
+// writeTools - Herramientas que siempre modifican el sistema de archivos;
+// en modo solo lectura ni siquiera se registran
+var writeTools = []string{
+	"write_file", "edit_file", "create_directory", "copy_file", "move_file", "delete_file",
+	"smart_sync", "resolve_sync_conflict", "chunked_write", "split_file", "split_cleanup",
+	"join_files", "write_file_safe", "execute_plan", "resume_plan", "rollback_plan",
+	"create_snapshot", "delete_snapshot", "create_archive",
+	"compress_file", "decompress_file", "set_frontmatter", "create_file_of_size",
+	"truncate_file", "scaffold",
+}
+
+// conditionalWriteTools - Herramientas de lectura que solo escriben con ciertos argumentos;
+// en modo solo lectura se registran, pero esas llamadas se rechazan
+var conditionalWriteTools = map[string]func(args map[string]interface{}) bool{
+	"find_duplicates": func(args map[string]interface{}) bool {
+		action, _ := args["action"].(string)
+		dryRun, _ := args["dry_run"].(bool)
+		return action != "" && action != "report" && !dryRun
+	},
+	"compare_files": func(args map[string]interface{}) bool {
+		outputPath, _ := args["output_path"].(string)
+		return outputPath != ""
+	},
+	"generate_report": func(args map[string]interface{}) bool {
+		output, _ := args["output"].(string)
+		return output != ""
+	},
+	"assist_refactor": func(args map[string]interface{}) bool {
+		options, _ := args["options"].(map[string]interface{})
+		apply, _ := options["apply"].(bool)
+		return apply
+	},
+	"cleanup": func(args map[string]interface{}) bool {
+		dryRun, ok := args["dry_run"].(bool)
+		return ok && !dryRun
+	},
+	"render_template": func(args map[string]interface{}) bool {
+		dryRun, _ := args["dry_run"].(bool)
+		return !dryRun
+	},
+	"render_tree": func(args map[string]interface{}) bool {
+		dryRun, _ := args["dry_run"].(bool)
+		return !dryRun
+	},
+	"normalize_file": func(args map[string]interface{}) bool {
+		dryRun, _ := args["dry_run"].(bool)
+		return !dryRun
+	},
+	"batch_operations": func(args map[string]interface{}) bool {
+		dryRun, _ := args["dry_run"].(bool)
+		return !dryRun
+	},
+	"fix_permissions": func(args map[string]interface{}) bool {
+		dryRun, _ := args["dry_run"].(bool)
+		return !dryRun
+	},
+	"restore_snapshot": func(args map[string]interface{}) bool {
+		force, _ := args["force"].(bool)
+		return force
+	},
+}
+
+// toolEffect - Efecto de una herramienta sobre el sistema de archivos en el peor
+// caso (con los argumentos que más modifican); se publica como anotaciones MCP
+type toolEffect int
+
+const (
+	toolReadOnly              toolEffect = iota // nunca modifica nada
+	toolCreates                                 // crea archivos o estado sin sobrescribir ni borrar
+	toolCreatesIdempotent                       // como toolCreates, y repetir la llamada no cambia nada más
+	toolDestructive                             // puede sobrescribir o borrar
+	toolDestructiveIdempotent                   // como toolDestructive, y repetir la llamada no cambia nada más
+)
+
+// annotation - Anotaciones MCP correspondientes al efecto; todas las
+// herramientas trabajan solo sobre el sistema de archivos local
+func (e toolEffect) annotation() mcp.ToolAnnotation {
+	readOnly := e == toolReadOnly
+	destructive := e == toolDestructive || e == toolDestructiveIdempotent
+	idempotent := readOnly || e == toolCreatesIdempotent || e == toolDestructiveIdempotent
+	return mcp.ToolAnnotation{
+		ReadOnlyHint:    mcp.ToBoolPtr(readOnly),
+		DestructiveHint: mcp.ToBoolPtr(destructive),
+		IdempotentHint:  mcp.ToBoolPtr(idempotent),
+		OpenWorldHint:   mcp.ToBoolPtr(false),
+	}
+}
+
+// WithReadOnly rejects every tool call that would modify the filesystem and
+// leaves the always-writing tools unregistered
+func WithReadOnly(enabled bool) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		fs.readOnly = enabled
+		return nil
+	}
+}
+
+// WithToolPolicy restricts the tools offered: when allow is non-empty only those
+// tools are available, and tools in deny are never available
+func WithToolPolicy(allow, deny []string) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		fs.allowTools = append(fs.allowTools, allow...)
+		fs.denyTools = append(fs.denyTools, deny...)
+		return nil
+	}
+}
+
+// toolRegistrable - Indica si la herramienta debe ofrecerse a los clientes
+func (fs *FilesystemHandler) toolRegistrable(name string) bool {
+	if len(fs.allowTools) > 0 && !containsString(fs.allowTools, name) {
+		return false
+	}
+	if containsString(fs.denyTools, name) {
+		return false
+	}
+	return !(fs.readOnly && containsString(writeTools, name))
+}
+
+// checkToolPolicy - Mensaje de error si la llamada no está permitida, o "" si lo está
+func (fs *FilesystemHandler) checkToolPolicy(name string, args map[string]interface{}) string {
+	if len(fs.allowTools) > 0 && !containsString(fs.allowTools, name) || containsString(fs.denyTools, name) {
+		return fmt.Sprintf("❌ Error: tool '%s' is disabled by the server policy", name)
+	}
+	if fs.readOnly && toolWrites(name, args) {
+		return readOnlyMessage
+	}
+	return ""
+}
+
+// toolWrites - Indica si la llamada puede modificar el sistema de archivos
+func toolWrites(name string, args map[string]interface{}) bool {
+	if containsString(writeTools, name) {
+		return true
+	}
+	writes, ok := conditionalWriteTools[name]
+	return ok && writes(args)
+}
+
+// guardTool - Envuelve un handler con la comprobación de política en tiempo de
+// ejecución y el límite de operaciones pesadas, registra y contabiliza cada
+// llamada y completa los resultados de error que no traen código
+func (fs *FilesystemHandler) guardTool(name string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if message := fs.checkToolPolicy(name, request.Params.Arguments); message != "" {
+			fs.log().Warn("tool call rejected by policy", "tool", name, "reason", message)
+			fs.stats.recordCall(name, 0, true)
+			return fs.withToolError(&mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: message},
+				},
+				IsError: true,
+			}, nil), nil
+		}
+
+		ctx, done, err := fs.beginCall(ctx)
+		if err != nil {
+			fs.log().Warn("tool call not started", "tool", name, "error", err)
+			fs.stats.recordCall(name, 0, true)
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+				},
+				IsError: true,
+			}, nil
+		}
+		defer done()
+
+		start := time.Now()
+		release, err := fs.acquireHeavySlot(ctx, name)
+		if err != nil {
+			fs.log().Warn("tool call not started", "tool", name, "error", err)
+			fs.stats.recordCall(name, time.Since(start), true)
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+				},
+				IsError: true,
+			}, nil
+		}
+		defer release()
+
+		timeout := fs.callTimeout(name, request.Params.Arguments)
+		callCtx, cancel := withCallTimeout(ctx, timeout)
+		defer cancel()
+		result, err := handler(fs.withProgress(withIgnoreArgs(callCtx, name, request.Params.Arguments), request), request)
+		if _, timedOut := callTimedOut(callCtx); timedOut && (err != nil || result == nil || result.IsError) {
+			// Los resultados parciales que devuelva la herramienta se conservan
+			fs.log().Warn("tool call timed out", "tool", name, "timeout", timeout)
+			result, err = timedOutResult(callCtx, timeout), nil
+		}
+		result = fs.withToolError(result, request.Params.Arguments)
+		if toolWrites(name, request.Params.Arguments) {
+			// Aunque falle puede haber escrito algo
+			fs.workspaces.invalidate()
+		}
+		duration := time.Since(start)
+		fs.stats.recordCall(name, duration, err != nil || result == nil || result.IsError)
+		fs.logToolCall(name, request.Params.Arguments, duration, result, err)
+		return result, err
+	}
+}
+
+// defaultDenyPatterns - Archivos sensibles que nunca se exponen, aunque estén
+// dentro de un directorio permitido
+var defaultDenyPatterns = []string{".env*", "*.pem", "*.key", "id_rsa*", ".aws/", ".ssh/"}
+
+// WithDenyPatterns adds globs for files and directories that stay inaccessible
+// inside the allowed directories. A pattern without "/" is matched against every
+// path component (so a denied directory hides everything below it); one with "/"
+// is matched against the path relative to the allowed directory. A trailing "/"
+// only documents that the pattern names a directory.
+func WithDenyPatterns(patterns ...string) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		for _, pattern := range patterns {
+			pattern = strings.TrimSpace(pattern)
+			if pattern == "" {
+				continue
+			}
+			if _, err := filepath.Match(strings.TrimSuffix(pattern, "/"), ""); err != nil {
+				return fmt.Errorf("invalid deny pattern %q: %w", pattern, err)
+			}
+			fs.denyPatterns = append(fs.denyPatterns, pattern)
+		}
+		return nil
+	}
+}
+
+// WithoutDefaultDenyPatterns drops defaultDenyPatterns, keeping only the
+// patterns given with WithDenyPatterns
+func WithoutDefaultDenyPatterns() HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		fs.noDefaultDeny = true
+		return nil
+	}
+}
+
+// deniedPattern - Primer patrón de denegación que coincide con path, o ""
+func (fs *FilesystemHandler) deniedPattern(path string) string {
+	if len(fs.denyPatterns) == 0 {
+		return ""
+	}
+
+	// Ruta relativa al directorio permitido más específico que la contiene
+	rel := ""
+	for _, dir := range fs.allowedDirectories() {
+		if r, ok := pathRelToDir(path, dir); ok && (rel == "" || len(r) < len(rel)) {
+			rel = r
+		}
+	}
+	if rel == "" || rel == "." {
+		return ""
+	}
+	// Donde el sistema de archivos ignora mayúsculas, .ENV abre el mismo
+	// archivo que .env y también debe coincidir con .env*
+	if caseInsensitivePaths {
+		rel = strings.ToLower(rel)
+	}
+	components := strings.Split(filepath.ToSlash(rel), "/")
+
+	for _, pattern := range fs.denyPatterns {
+		glob := strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		if caseInsensitivePaths {
+			glob = strings.ToLower(glob)
+		}
+		if strings.Contains(glob, "/") {
+			for i := range components {
+				if matched, _ := filepath.Match(glob, strings.Join(components[:i+1], "/")); matched {
+					return pattern
+				}
+			}
+			continue
+		}
+		for _, component := range components {
+			if matched, _ := filepath.Match(glob, component); matched {
+				return pattern
+			}
+		}
+	}
+	return ""
+}
+
+// isDeniedPath - Indica si path coincide con algún patrón de denegación; los
+// recorridos de directorios lo usan para saltarse esas rutas en silencio
+func (fs *FilesystemHandler) isDeniedPath(path string) bool {
+	return fs.deniedPattern(path) != ""
+}
+
+// checkDenyPatterns - Error si alguna de las rutas (la pedida y la resuelta) está denegada
+func (fs *FilesystemHandler) checkDenyPatterns(paths ...string) error {
+	for _, path := range paths {
+		if pattern := fs.deniedPattern(path); pattern != "" {
+			return fmt.Errorf("access denied by policy: matches pattern %s", pattern)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registeredTools lists the tool names a server offers through tools/list
+func registeredTools(t *testing.T, s *server.MCPServer) []string {
+	t.Helper()
+	response := s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	data, err := json.Marshal(response)
+	require.NoError(t, err)
+
+	var decoded struct {
+		Result struct {
+			Tools []struct {
+				Name string `json:"name"`
+			} `json:"tools"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	var names []string
+	for _, tool := range decoded.Result.Tools {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func TestReadOnlyRegistration(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := NewFilesystemServer([]string{dir})
+	require.NoError(t, err)
+	all := registeredTools(t, s)
+	for _, name := range writeTools {
+		assert.Contains(t, all, name)
+	}
+
+	s, err = NewFilesystemServer([]string{dir}, WithReadOnly(true))
+	require.NoError(t, err)
+	readOnly := registeredTools(t, s)
+	for _, name := range writeTools {
+		assert.NotContains(t, readOnly, name)
+	}
+	assert.Contains(t, readOnly, "read_file")
+	assert.Contains(t, readOnly, "cleanup") // solo escribe con dry_run=false
+
+	s, err = NewFilesystemServer([]string{dir}, WithToolPolicy([]string{"read_file", "tree", "delete_file"}, []string{"delete_file"}))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"read_file", "tree"}, registeredTools(t, s))
+
+	_, err = NewFilesystemServer([]string{dir}, WithToolPolicy(nil, []string{"format_disk"}))
+	assert.ErrorContains(t, err, "format_disk")
+}
+
+func TestReadOnlyRuntimeGuard(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	handler, err := NewFilesystemHandler([]string{dir}, WithReadOnly(true))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go~"), []byte("old"), 0644))
+	ctx := context.Background()
+
+	write := handler.guardTool("write_file", handler.handleWriteFile)
+	result, err := write(ctx, newToolRequest("write_file", map[string]interface{}{
+		"path": filepath.Join(dir, "new.txt"), "content": "x",
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Equal(t, "[E_ACCESS_DENIED] "+readOnlyMessage, result.Content[0].(mcp.TextContent).Text)
+	_, err = os.Stat(filepath.Join(dir, "new.txt"))
+	assert.True(t, os.IsNotExist(err))
+
+	cleanup := handler.guardTool("cleanup", handler.handleCleanup)
+	result, err = cleanup(ctx, newToolRequest("cleanup", map[string]interface{}{"path": dir}))
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+
+	result, err = cleanup(ctx, newToolRequest("cleanup", map[string]interface{}{"path": dir, "dry_run": false}))
+	require.NoError(t, err)
+	assert.Equal(t, "[E_ACCESS_DENIED] "+readOnlyMessage, result.Content[0].(mcp.TextContent).Text)
+	_, err = os.Stat(filepath.Join(dir, "main.go~"))
+	assert.NoError(t, err)
+
+	// plan_task funciona, pero no guarda el plan
+	result, err = handler.handlePlanTask(ctx, newToolRequest("plan_task", map[string]interface{}{
+		"description": "refactor", "workspace": dir,
+	}))
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Plan not saved: server is in read-only mode")
+	_, err = os.Stat(filepath.Join(dir, planDirName))
+	assert.True(t, os.IsNotExist(err))
+
+	denied, err := NewFilesystemHandler([]string{dir}, WithToolPolicy(nil, []string{"read_file"}))
+	require.NoError(t, err)
+	read := denied.guardTool("read_file", denied.handleReadFile)
+	result, err = read(ctx, newToolRequest("read_file", map[string]interface{}{"path": filepath.Join(dir, "main.go~")}))
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "disabled by the server policy")
+}
+
+func writeSecretsTree(t *testing.T, dir string) {
+	t.Helper()
+	files := map[string]string{
+		"app/config.go":       "package app // TOKEN\n",
+		"app/.env":            "TOKEN=secret\n",
+		"app/.env.local":      "TOKEN=local\n",
+		"certs/server.pem":    "TOKEN pem\n",
+		".ssh/config":         "Host TOKEN\n",
+		"home/.aws/creds.txt": "TOKEN aws\n",
+		"notes.env.example":   "TOKEN=example\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestDenyPatternsReadAndWrite(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeSecretsTree(t, dir)
+	ctx := context.Background()
+
+	result, err := handler.handleReadFile(ctx, newToolRequest("read_file", map[string]interface{}{"path": filepath.Join(dir, "app", ".env")}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "access denied by policy: matches pattern .env*")
+
+	_, err = handler.validatePath(filepath.Join(dir, "home", ".aws", "creds.txt"))
+	assert.EqualError(t, err, "access denied by policy: matches pattern .aws/")
+
+	// Escribir un archivo nuevo que coincide también se rechaza
+	result, err = handler.handleWriteFile(ctx, newToolRequest("write_file", map[string]interface{}{
+		"path": filepath.Join(dir, "certs", "new.key"), "content": "x",
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "matches pattern *.key")
+	_, err = os.Stat(filepath.Join(dir, "certs", "new.key"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Un enlace con nombre inocente hacia un archivo denegado tampoco sirve
+	require.NoError(t, os.Symlink(filepath.Join(dir, "app", ".env"), filepath.Join(dir, "app", "settings.txt")))
+	_, err = handler.validatePath(filepath.Join(dir, "app", "settings.txt"))
+	assert.ErrorContains(t, err, "access denied by policy")
+
+	_, err = handler.validatePath(filepath.Join(dir, "notes.env.example"))
+	assert.NoError(t, err)
+}
+
+func TestDenyPatternsIgnoreCase(t *testing.T) {
+	if !caseInsensitivePaths {
+		t.Skip("case-insensitive filesystems only")
+	}
+	handler, dir := newTestHandler(t)
+	writeSecretsTree(t, dir)
+	writeFixture(t, dir, map[string]string{"keys/id_rsa": "key"})
+
+	// Cada ruta abre un archivo protegido aunque sus mayúsculas no coincidan con el patrón
+	for path, pattern := range map[string]string{
+		"app/.ENV":         ".env*",
+		"CERTS/SERVER.PEM": "*.pem",
+		".SSH/config":      ".ssh/",
+		"keys/ID_RSA":      "id_rsa*",
+	} {
+		result, err := handler.handleReadFile(context.Background(), newToolRequest("read_file", map[string]interface{}{"path": filepath.Join(dir, path)}))
+		require.NoError(t, err)
+		assert.True(t, result.IsError, path)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "access denied by policy: matches pattern "+pattern, path)
+	}
+}
+
+func TestDenyPatternsSearchSkipping(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeSecretsTree(t, dir)
+
+	matches, err := handler.performAdvancedTextSearch(context.Background(), dir, "TOKEN", true, false, false, 0, nil, nil)
+	require.NoError(t, err)
+	var files []string
+	for _, match := range matches {
+		rel, _ := filepath.Rel(dir, match.File)
+		files = append(files, filepath.ToSlash(rel))
+	}
+	assert.ElementsMatch(t, []string{"app/config.go", "notes.env.example"}, files)
+
+	found, err := handler.searchFiles(dir, "e")
+	require.NoError(t, err)
+	for _, path := range found {
+		assert.False(t, handler.isDeniedPath(path), path)
+	}
+
+	result, err := handler.handleListDirectory(context.Background(), newToolRequest("list_directory", map[string]interface{}{"path": filepath.Join(dir, "app")}))
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "config.go")
+	assert.NotContains(t, text, ".env")
+}
+
+func TestDenyPatternsOptions(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	writeSecretsTree(t, dir)
+
+	handler, err := NewFilesystemHandler([]string{dir}, WithoutDefaultDenyPatterns())
+	require.NoError(t, err)
+	_, err = handler.validatePath(filepath.Join(dir, "app", ".env"))
+	assert.NoError(t, err)
+
+	handler, err = NewFilesystemHandler([]string{dir}, WithoutDefaultDenyPatterns(), WithDenyPatterns("app/*.go"))
+	require.NoError(t, err)
+	_, err = handler.validatePath(filepath.Join(dir, "app", "config.go"))
+	assert.EqualError(t, err, "access denied by policy: matches pattern app/*.go")
+	_, err = handler.validatePath(filepath.Join(dir, "certs", "server.pem"))
+	assert.NoError(t, err)
+
+	_, err = NewFilesystemHandler([]string{dir}, WithDenyPatterns("[bad"))
+	assert.ErrorContains(t, err, "invalid deny pattern")
+}
+
+func TestToolAnnotations(t *testing.T) {
+	s, err := NewFilesystemServer([]string{t.TempDir()}, WithRuntimeDirectories(true))
+	require.NoError(t, err)
+
+	response := s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	data, err := json.Marshal(response)
+	require.NoError(t, err)
+	var decoded struct {
+		Result struct {
+			Tools []mcp.Tool `json:"tools"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.NotEmpty(t, decoded.Result.Tools)
+
+	var destructive []string
+	readOnly := make(map[string]bool)
+	for _, tool := range decoded.Result.Tools {
+		a := tool.Annotations
+		// mcp.NewTool deja openWorldHint a true; addTool siempre lo fija
+		require.NotNil(t, a.OpenWorldHint, tool.Name)
+		assert.False(t, *a.OpenWorldHint, "%s has no declared annotations", tool.Name)
+		require.NotNil(t, a.ReadOnlyHint, tool.Name)
+		require.NotNil(t, a.DestructiveHint, tool.Name)
+		require.NotNil(t, a.IdempotentHint, tool.Name)
+
+		_, conditional := conditionalWriteTools[tool.Name]
+		if containsString(writeTools, tool.Name) || conditional {
+			assert.False(t, *a.ReadOnlyHint, "%s writes but is annotated read-only", tool.Name)
+		}
+		if *a.DestructiveHint {
+			destructive = append(destructive, tool.Name)
+		}
+		readOnly[tool.Name] = *a.ReadOnlyHint
+	}
+
+	// Spill oversized results to .mcp-reports, or create a probe file
+	for _, name := range []string{"tree", "smart_search", "analyze_project", "checksum", "extract_text", "find_duplicate_code", "health_check"} {
+		assert.False(t, readOnly[name], "%s writes but is annotated read-only", name)
+	}
+
+	sort.Strings(destructive)
+	assert.Equal(t, []string{
+		"assist_refactor", "batch_operations", "chunked_write", "cleanup", "compare_files", "compress_file", "copy_file", "create_archive", "create_file_of_size", "decompress_file",
+		"delete_file", "delete_snapshot", "edit_file", "execute_plan", "find_duplicates", "fix_permissions", "generate_report",
+		"join_files", "move_file", "normalize_file", "render_template", "render_tree", "resolve_sync_conflict", "restore_snapshot", "resume_plan", "rollback_plan", "scaffold", "set_frontmatter", "smart_sync",
+		"split_cleanup", "truncate_file", "write_file", "write_file_safe",
+	}, destructive)
+}
@@ -0,0 +1,477 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxQualityFindingsPerSeverity - Límite de hallazgos listados por severidad en la salida de texto
+const maxQualityFindingsPerSeverity = 50
+
+// defaultQualityThresholds - Umbrales por defecto de code_quality_check
+func defaultQualityThresholds() QualityThresholds {
+	return QualityThresholds{
+		MaxFileLines:     500,
+		MaxLineLength:    120,
+		MaxFunctionLines: 60,
+		MaxComplexity:    15,
+		MinCommentRatio:  5,
+	}
+}
+
+// handleCodeQualityCheck - Revisa archivos de código con reglas concretas
+func (fs *FilesystemHandler) handleCodeQualityCheck(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	overrides, err := getObject(request.Params.Arguments, "thresholds")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	thresholds := defaultQualityThresholds()
+	if err := thresholds.apply(overrides); err != nil {
+		return badArgError(fmt.Errorf("thresholds: %w", err)).result(), nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Path error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	report, err := fs.checkCodeQuality(ctx, validPath, thresholds)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Code quality check error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatQualityReport(path, report)},
+			mcp.EmbeddedResource{
+				Type: "resource",
+				Resource: mcp.TextResourceContents{
+					URI:      pathToResourceURI(validPath),
+					MIMEType: "application/json",
+					Text:     string(jsonData),
+				},
+			},
+		},
+	}, nil
+}
+
+// apply - Sobrescribe los umbrales presentes en el objeto thresholds; falla
+// si alguno no es un número
+func (t *QualityThresholds) apply(overrides map[string]interface{}) error {
+	limits := []struct {
+		name  string
+		value *int
+	}{
+		{"max_file_lines", &t.MaxFileLines},
+		{"max_line_length", &t.MaxLineLength},
+		{"max_function_lines", &t.MaxFunctionLines},
+		{"max_complexity", &t.MaxComplexity},
+	}
+	for _, limit := range limits {
+		v, err := getInt(overrides, limit.name, 0)
+		if err != nil {
+			return err
+		}
+		if v > 0 {
+			*limit.value = v
+		}
+	}
+	ratio, err := getNumber(overrides, "min_comment_ratio", -1)
+	if err != nil {
+		return err
+	}
+	if ratio >= 0 {
+		t.MinCommentRatio = ratio
+	}
+	return nil
+}
+
+// checkCodeQuality - Aplica las reglas a un archivo o a los archivos de código de un directorio
+func (fs *FilesystemHandler) checkCodeQuality(ctx context.Context, path string, thresholds QualityThresholds) (*QualityReport, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	if info.IsDir() {
+		err = fs.walkContext(ctx, path, func(currentPath string, info os.FileInfo, err error) error {
+			if err != nil {
+				fs.logWalkError(ctx, currentPath, err)
+				return nil
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if currentPath != path && fs.shouldIgnoreEntry(ctx, currentPath, info.IsDir()) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() || info.Size() > MAX_INLINE_SIZE {
+				return nil
+			}
+			if language, _ := languageDetector.Detect(currentPath, ""); language == unknownLanguage {
+				return nil
+			}
+			if _, err := fs.validatePath(currentPath); err != nil {
+				return nil
+			}
+			files = append(files, currentPath)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+	} else {
+		if info.Size() > MAX_INLINE_SIZE {
+			return nil, fmt.Errorf("file is too large to check (%d bytes, max %d)", info.Size(), MAX_INLINE_SIZE)
+		}
+		files = []string{path}
+	}
+
+	return fs.evaluateCodeQuality(ctx, path, files, info.IsDir(), thresholds)
+}
+
+// evaluateCodeQuality - Aplica las reglas a una lista de archivos ya recogida y
+// calcula el resumen; con relative las rutas se muestran relativas a root
+func (fs *FilesystemHandler) evaluateCodeQuality(ctx context.Context, root string, files []string, relative bool, thresholds QualityThresholds) (*QualityReport, error) {
+	report := &QualityReport{
+		Path:       root,
+		Thresholds: thresholds,
+		Summary:    map[string]int{"error": 0, "warning": 0, "info": 0},
+	}
+
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !isTextFile(detectMimeType(file)) {
+			continue
+		}
+		data, err := fs.readFile(file)
+		if err != nil {
+			continue
+		}
+
+		display := file
+		if relative {
+			if rel, err := filepath.Rel(root, file); err == nil {
+				display = filepath.ToSlash(rel)
+			}
+		}
+		language, _ := languageDetector.Detect(file, string(data))
+		report.Findings = append(report.Findings, fs.checkFileQuality(display, string(data), language.Key, thresholds)...)
+		report.FilesChecked++
+	}
+
+	penalty := 0.0
+	for _, finding := range report.Findings {
+		report.Summary[finding.Severity]++
+		switch finding.Severity {
+		case "error":
+			penalty += 10
+		case "warning":
+			penalty += 3
+		default:
+			penalty += 0.5
+		}
+	}
+	report.Score = 100
+	if report.FilesChecked > 0 {
+		report.Score = 100 - int(penalty/float64(report.FilesChecked)+0.5)
+	}
+	if report.Score < 0 {
+		report.Score = 0
+	}
+
+	return report, nil
+}
+
+// checkFileQuality - Aplica todas las reglas a un único archivo
+func (fs *FilesystemHandler) checkFileQuality(file, content, language string, t QualityThresholds) []QualityFinding {
+	var findings []QualityFinding
+	add := func(rule, severity string, line int, format string, args ...interface{}) {
+		findings = append(findings, QualityFinding{
+			Rule:     rule,
+			Severity: severity,
+			File:     file,
+			Line:     line,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	if content == "" {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if len(lines) > t.MaxFileLines {
+		add("file_length", "warning", 0, "file has %d lines (max %d)", len(lines), t.MaxFileLines)
+	}
+	if !strings.HasSuffix(content, "\n") {
+		add("missing_trailing_newline", "info", len(lines), "file does not end with a newline")
+	}
+
+	todoRe := regexp.MustCompile(`\b(TODO|FIXME|HACK)\b`)
+	tabIndented, spaceIndented := 0, 0
+	firstTab, firstSpace := 0, 0
+	for i, raw := range lines {
+		line := strings.TrimSuffix(raw, "\r")
+		lineNo := i + 1
+
+		if length := len([]rune(line)); length > t.MaxLineLength {
+			add("line_length", "info", lineNo, "line is %d characters (max %d)", length, t.MaxLineLength)
+		}
+		if trimmed := strings.TrimRight(line, " \t"); len(trimmed) != len(line) {
+			add("trailing_whitespace", "info", lineNo, "trailing whitespace")
+		}
+		if match := todoRe.FindString(line); match != "" {
+			add("todo", "info", lineNo, "%s comment", match)
+		}
+
+		// Las continuaciones de comentarios de bloque (" * ...") no cuentan
+		indent := getIndentation(line)
+		body := line[len(indent):]
+		if body == "" || indent == "" || strings.HasPrefix(body, "*") {
+			continue
+		}
+		switch {
+		case strings.Contains(indent, "\t") && strings.Contains(indent, " "):
+			add("mixed_indentation", "warning", lineNo, "indentation mixes tabs and spaces")
+		case indent[0] == '\t':
+			tabIndented++
+			if firstTab == 0 {
+				firstTab = lineNo
+			}
+		default:
+			spaceIndented++
+			if firstSpace == 0 {
+				firstSpace = lineNo
+			}
+		}
+	}
+	if tabIndented > 0 && spaceIndented > 0 {
+		line := firstSpace
+		if spaceIndented > tabIndented {
+			line = firstTab
+		}
+		add("mixed_indentation", "warning", line, "file indents %d lines with tabs and %d with spaces", tabIndented, spaceIndented)
+	}
+
+	if !hasCodeMetrics(language) {
+		return findings
+	}
+
+	for _, fn := range extractFunctionSpans(content, language) {
+		length := fn.EndLine - fn.StartLine + 1
+		if length > t.MaxFunctionLines {
+			add("function_length", "warning", fn.StartLine, "function %s has %d lines (max %d)", fn.Name, length, t.MaxFunctionLines)
+		}
+		complexity := fs.calculateComplexity(fn.Body, language)
+		if complexity > t.MaxComplexity {
+			severity := "warning"
+			if complexity > t.MaxComplexity*2 {
+				severity = "error"
+			}
+			add("complexity", severity, fn.StartLine, "function %s has cyclomatic complexity %d (max %d)", fn.Name, complexity, t.MaxComplexity)
+		}
+	}
+
+	if len(lines) >= 20 {
+		if ratio := fs.calculateCommentRatio(content, language); ratio < t.MinCommentRatio {
+			add("comment_ratio", "info", 0, "comment ratio %.1f%% is below %.1f%%", ratio, t.MinCommentRatio)
+		}
+	}
+
+	return findings
+}
+
+// functionSpan - Ubicación de una función dentro de un archivo
+type functionSpan struct {
+	Name      string
+	StartLine int
+	EndLine   int
+	Body      string
+}
+
+// functionStartPatterns - Inicio de definición de función por lenguaje; el
+// primer grupo con contenido es el nombre
+var functionStartPatterns = map[string]*regexp.Regexp{
+	"go":         regexp.MustCompile(`^func\s+(?:\([^)]*\)\s*)?(\w+)`),
+	"javascript": regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(\w+)|^\s*(?:export\s+)?(?:const|let|var)\s+(\w+)\s*=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*=>|\w+\s*=>)`),
+	"typescript": regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(\w+)|^\s*(?:export\s+)?(?:const|let|var)\s+(\w+)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b|\([^)]*\)(?:\s*:\s*[^=]+)?\s*=>|\w+\s*=>)`),
+	"rust":       regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?(?:unsafe\s+)?fn\s+(\w+)`),
+	"java":       regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|final|abstract|synchronized)\s+)+[\w<>\[\],.?\s]+?\s+(\w+)\s*\([^)]*\)\s*(?:throws\s+[\w.,\s]+)?\{?\s*$`),
+	"csharp":     regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|static|async|override|virtual|abstract|sealed)\s+)+[\w<>\[\],.?\s]+?\s+(\w+)\s*\([^)]*\)\s*\{?\s*$`),
+	"python":     regexp.MustCompile(`^\s*(?:async\s+)?def\s+(\w+)`),
+}
+
+// extractFunctionSpans - Localiza funciones: por llaves en lenguajes tipo C y
+// por indentación en Python
+func extractFunctionSpans(content, language string) []functionSpan {
+	re, ok := functionStartPatterns[language]
+	if !ok {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	var spans []functionSpan
+	for i := 0; i < len(lines); i++ {
+		match := re.FindStringSubmatch(lines[i])
+		if match == nil {
+			continue
+		}
+		name := ""
+		for _, group := range match[1:] {
+			if group != "" {
+				name = group
+				break
+			}
+		}
+
+		var end int
+		if language == "python" {
+			end = pythonBlockEnd(lines, i)
+		} else {
+			end = braceBlockEnd(lines, i)
+		}
+		if end < 0 {
+			continue
+		}
+
+		spans = append(spans, functionSpan{
+			Name:      name,
+			StartLine: i + 1,
+			EndLine:   end + 1,
+			Body:      strings.Join(lines[i:end+1], "\n"),
+		})
+	}
+	return spans
+}
+
+// braceBlockEnd - Devuelve la línea donde se cierra el bloque que empieza en
+// start, o -1 si no hay llave de apertura cerca
+func braceBlockEnd(lines []string, start int) int {
+	depth := 0
+	opened := false
+	for i := start; i < len(lines); i++ {
+		for _, ch := range lines[i] {
+			switch ch {
+			case '{':
+				depth++
+				opened = true
+			case '}':
+				depth--
+			}
+		}
+		if opened && depth <= 0 {
+			return i
+		}
+		// Declaraciones sin cuerpo (interfaces, prototipos)
+		if !opened && (i-start > 3 || strings.HasSuffix(strings.TrimSpace(lines[i]), ";")) {
+			return -1
+		}
+	}
+	return -1
+}
+
+// pythonBlockEnd - Última línea del bloque indentado bajo la definición en start
+func pythonBlockEnd(lines []string, start int) int {
+	baseIndent := len(getIndentation(lines[start]))
+	end := start
+	for i := start + 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		if len(getIndentation(lines[i])) <= baseIndent {
+			break
+		}
+		end = i
+	}
+	return end
+}
+
+// formatQualityReport - Genera el resumen de texto agrupado por severidad
+func formatQualityReport(path string, report *QualityReport) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("🧪 Code Quality Report: %s\n\n", path))
+	result.WriteString(fmt.Sprintf("📊 Score: %d/100 | Files checked: %d\n", report.Score, report.FilesChecked))
+	result.WriteString(fmt.Sprintf("❌ Errors: %d | ⚠️ Warnings: %d | ℹ️ Info: %d\n",
+		report.Summary["error"], report.Summary["warning"], report.Summary["info"]))
+
+	groups := []struct {
+		severity string
+		title    string
+	}{
+		{"error", "❌ Errors"},
+		{"warning", "⚠️ Warnings"},
+		{"info", "ℹ️ Info"},
+	}
+	for _, group := range groups {
+		count := report.Summary[group.severity]
+		if count == 0 {
+			continue
+		}
+		result.WriteString(fmt.Sprintf("\n%s:\n", group.title))
+		shown := 0
+		for _, finding := range report.Findings {
+			if finding.Severity != group.severity {
+				continue
+			}
+			if shown == maxQualityFindingsPerSeverity {
+				result.WriteString(fmt.Sprintf("   ... and %d more\n", count-shown))
+				break
+			}
+			location := finding.File
+			if finding.Line > 0 {
+				location = fmt.Sprintf("%s:%d", finding.File, finding.Line)
+			}
+			result.WriteString(fmt.Sprintf("   %s [%s] %s\n", location, finding.Rule, finding.Message))
+			shown++
+		}
+	}
+
+	if len(report.Findings) == 0 {
+		result.WriteString("\n✅ No issues found\n")
+	}
+	return result.String()
+}
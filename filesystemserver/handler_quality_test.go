@@ -0,0 +1,100 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func qualityRules(findings []QualityFinding) map[string]int {
+	rules := make(map[string]int)
+	for _, finding := range findings {
+		rules[finding.Rule]++
+	}
+	return rules
+}
+
+func TestCheckFileQualityRules(t *testing.T) {
+	handler := &FilesystemHandler{}
+	thresholds := QualityThresholds{
+		MaxFileLines:     30,
+		MaxLineLength:    40,
+		MaxFunctionLines: 10,
+		MaxComplexity:    3,
+		MinCommentRatio:  5,
+	}
+
+	var b strings.Builder
+	b.WriteString("package main\n\n")
+	b.WriteString("// TODO: split this up\n")
+	b.WriteString("func long(x int) int {\n")
+	for i := 0; i < 12; i++ {
+		b.WriteString("\tif x > 0 {\n\t\tx--\n\t}\n")
+	}
+	b.WriteString("\treturn x\n}\n\n")
+	b.WriteString("func spaced() {   \n")
+	b.WriteString("    println(\"this line is indented with spaces and is long\")\n")
+	b.WriteString(" \tprintln(\"mixed\")\n")
+	b.WriteString("}")
+	content := b.String()
+
+	findings := handler.checkFileQuality("main.go", content, "go", thresholds)
+	rules := qualityRules(findings)
+
+	for _, rule := range []string{
+		"file_length", "line_length", "function_length", "complexity", "todo",
+		"trailing_whitespace", "mixed_indentation", "missing_trailing_newline",
+	} {
+		assert.NotZero(t, rules[rule], "expected a %s finding", rule)
+	}
+
+	for _, finding := range findings {
+		switch finding.Rule {
+		case "function_length":
+			assert.Equal(t, 4, finding.Line)
+			assert.Contains(t, finding.Message, "long")
+		case "complexity":
+			assert.Equal(t, "error", finding.Severity)
+		case "todo":
+			assert.Equal(t, 3, finding.Line)
+		}
+	}
+}
+
+func TestCheckFileQualityCommentRatio(t *testing.T) {
+	handler := &FilesystemHandler{}
+	content := "def main():\n" + strings.Repeat("    print('x')\n", 25)
+
+	rules := qualityRules(handler.checkFileQuality("main.py", content, "python", defaultQualityThresholds()))
+	assert.Equal(t, 1, rules["comment_ratio"])
+	assert.Zero(t, rules["function_length"])
+}
+
+func TestCheckCodeQualityDirectory(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	files := map[string]string{
+		"clean.go":          "package main\n\n// main does nothing\nfunc main() {}\n",
+		"messy.js":          "function a() {  \n  return 1\n}",
+		"notes.txt":         "TODO: not code, not checked\n",
+		"node_modules/x.js": "function ignored() {}",
+	}
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	report, err := handler.checkCodeQuality(context.Background(), dir, defaultQualityThresholds())
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.FilesChecked)
+	assert.Equal(t, 2, report.Summary["info"])
+	for _, finding := range report.Findings {
+		assert.Equal(t, "messy.js", finding.File)
+	}
+	assert.Less(t, report.Score, 100)
+}
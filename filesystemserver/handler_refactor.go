@@ -0,0 +1,565 @@
+package filesystemserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxRefactorPreview - Ocurrencias listadas por archivo en la vista previa
+const maxRefactorPreview = 20
+
+// Regiones de un archivo fuente según classifyRegions
+const (
+	regionCode byte = iota
+	regionString
+	regionComment
+)
+
+// identifierPattern - Nombres aceptados por rename; ASCII para que \b sea fiable
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// definitionPrefix - Texto previo que indica que la ocurrencia es una definición
+var definitionPrefix = regexp.MustCompile(`(?:\b(?:func|type|var|const|let|class|struct|enum|interface|trait|def|fn|function|module|namespace|record)\s+|\bfunc\s*\([^)]*\)\s*)$`)
+
+// definitionSuffix - Declaración corta de Go (name := ...)
+var definitionSuffix = regexp.MustCompile(`^\s*:=`)
+
+// refactorSyntax - Sintaxis de comentarios y cadenas de un lenguaje
+type refactorSyntax struct {
+	lineComments    []string
+	blockStart      string
+	blockEnd        string
+	quotes          string // delimitadores de cadena de un carácter
+	multilineQuotes string // delimitadores que pueden abarcar varias líneas
+	tripleQuotes    bool   // """ y ''' de Python
+}
+
+// renameOptions - Opciones de la operación rename
+type renameOptions struct {
+	NewName         string
+	Apply           bool
+	CaseSensitive   bool
+	IncludeStrings  bool
+	IncludeComments bool
+	FilePatterns    []string
+}
+
+// parseRenameOptions - Lee el objeto options de assist_refactor
+func parseRenameOptions(options map[string]interface{}) (renameOptions, error) {
+	opts := renameOptions{}
+	var err error
+	if opts.NewName, err = getString(options, "new_name", ""); err != nil {
+		return opts, err
+	}
+	if opts.Apply, err = getBool(options, "apply", false); err != nil {
+		return opts, err
+	}
+	if opts.CaseSensitive, err = getBool(options, "case_sensitive", true); err != nil {
+		return opts, err
+	}
+	if opts.IncludeStrings, err = getBool(options, "include_strings", false); err != nil {
+		return opts, err
+	}
+	if opts.IncludeComments, err = getBool(options, "include_comments", false); err != nil {
+		return opts, err
+	}
+	opts.FilePatterns, err = getNonEmptyStrings(options, "file_patterns")
+	return opts, err
+}
+
+// refactorSyntaxFor - Sintaxis para la clave de un Language; false si no es código fuente
+func refactorSyntaxFor(language string) (refactorSyntax, bool) {
+	cLike := refactorSyntax{lineComments: []string{"//"}, blockStart: "/*", blockEnd: "*/", quotes: `"'`}
+
+	switch language {
+	case "go", "javascript", "typescript":
+		cLike.quotes += "`"
+		cLike.multilineQuotes = "`"
+		return cLike, true
+	case "java", "csharp", "cpp", "c", "kotlin", "swift", "scala", "dart":
+		return cLike, true
+	case "rust":
+		// Sin comilla simple: chocaría con los lifetimes ('a)
+		cLike.quotes = `"`
+		cLike.multilineQuotes = `"`
+		return cLike, true
+	case "php":
+		cLike.lineComments = []string{"//", "#"}
+		return cLike, true
+	case "python":
+		return refactorSyntax{lineComments: []string{"#"}, quotes: `"'`, tripleQuotes: true}, true
+	case "ruby", "shell", "bash", "perl", "r", "elixir":
+		return refactorSyntax{lineComments: []string{"#"}, quotes: `"'`}, true
+	case "lua":
+		return refactorSyntax{lineComments: []string{"--"}, quotes: `"'`}, true
+	case "sql":
+		return refactorSyntax{lineComments: []string{"--"}, blockStart: "/*", blockEnd: "*/", quotes: `'`}, true
+	}
+	return refactorSyntax{}, false
+}
+
+// handleAssistRefactor - Refactorización asistida; por ahora solo la operación rename
+func (fs *FilesystemHandler) handleAssistRefactor(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	operation, err := getString(request.Params.Arguments, "operation", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	target, err := getString(request.Params.Arguments, "target", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	options, err := getObject(request.Params.Arguments, "options")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if path == "" || operation == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path and operation are required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if operation != "rename" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: operation '%s' is not implemented yet (supported: rename)", operation)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	opts, err := parseRenameOptions(options)
+	if err != nil {
+		return badArgError(fmt.Errorf("options: %w", err)).result(), nil
+	}
+
+	if !identifierPattern.MatchString(target) || !identifierPattern.MatchString(opts.NewName) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: rename requires target and options.new_name to be identifiers ([A-Za-z_][A-Za-z0-9_]*)"},
+			},
+			IsError: true,
+		}, nil
+	}
+	if target == opts.NewName {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: new_name is the same as target"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	result, err := fs.renameSymbol(ctx, validPath, target, opts)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Rename error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatRenameResult(result, opts)},
+		},
+	}, nil
+}
+
+// collectRefactorFiles - Archivos candidatos; en directorios solo código fuente o los que casan con patterns
+func (fs *FilesystemHandler) collectRefactorFiles(ctx context.Context, root string, patterns []string) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{root}, nil
+	}
+
+	var files []string
+	err = fs.walkContext(ctx, root, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			fs.logWalkError(ctx, currentPath, err)
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if currentPath != root && fs.shouldIgnoreEntry(ctx, currentPath, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if _, err := fs.validatePath(currentPath); err != nil {
+			return nil
+		}
+
+		if len(patterns) > 0 {
+			if !matchesAnyPattern(root, currentPath, patterns) {
+				return nil
+			}
+		} else {
+			language, _ := languageDetector.Detect(currentPath, "")
+			if _, ok := refactorSyntaxFor(language.Key); !ok {
+				return nil
+			}
+		}
+		files = append(files, currentPath)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// renameSymbol - Busca las ocurrencias de target y, con Apply, las reemplaza
+func (fs *FilesystemHandler) renameSymbol(ctx context.Context, root, target string, opts renameOptions) (*RenameResult, error) {
+	files, err := fs.collectRefactorFiles(ctx, root, opts.FilePatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := ""
+	if !opts.CaseSensitive {
+		flags = "(?i)"
+	}
+	targetRe := regexp.MustCompile(flags + `\b` + regexp.QuoteMeta(target) + `\b`)
+	newNameRe := regexp.MustCompile(flags + `\b` + regexp.QuoteMeta(opts.NewName) + `\b`)
+
+	result := &RenameResult{
+		Target:  target,
+		NewName: opts.NewName,
+		Counts:  map[string]int{"definition": 0, "reference": 0, "string": 0, "comment": 0},
+	}
+	rewritten := make(map[string]string)
+
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		info, err := os.Stat(file)
+		if err != nil || info.Size() > MAX_INLINE_SIZE || !isTextFile(detectMimeType(file)) {
+			result.Skipped++
+			continue
+		}
+		data, err := fs.readFile(file)
+		if err != nil || bytes.IndexByte(data, 0) >= 0 {
+			result.Skipped++
+			continue
+		}
+		result.FilesScanned++
+
+		content := string(data)
+		display := file
+		if file != root {
+			if rel, err := filepath.Rel(root, file); err == nil {
+				display = filepath.ToSlash(rel)
+			}
+		}
+
+		if newNameRe.MatchString(content) {
+			result.Conflicts = append(result.Conflicts, display)
+		}
+
+		matches := targetRe.FindAllStringIndex(content, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		detected, _ := languageDetector.Detect(file, content)
+		language := detected.Key
+		syntax, _ := refactorSyntaxFor(language)
+		regions := classifyRegions(content, syntax)
+
+		fileResult := RefactorFileResult{File: display, Language: language}
+		var out strings.Builder
+		last := 0
+		for _, m := range matches {
+			kind := classifyOccurrence(content, m[0], m[1], regions[m[0]])
+			applied := kind == "definition" || kind == "reference" ||
+				(kind == "string" && opts.IncludeStrings) ||
+				(kind == "comment" && opts.IncludeComments)
+
+			line, column, text := occurrenceLocation(content, m[0])
+			fileResult.Occurrences = append(fileResult.Occurrences, RefactorOccurrence{
+				Line:    line,
+				Column:  column,
+				Kind:    kind,
+				Text:    text,
+				Applied: applied,
+			})
+			result.Counts[kind]++
+
+			if applied {
+				out.WriteString(content[last:m[0]])
+				out.WriteString(opts.NewName)
+				last = m[1]
+				fileResult.Replaced++
+			}
+		}
+		out.WriteString(content[last:])
+
+		if fileResult.Replaced > 0 {
+			rewritten[file] = out.String()
+		}
+		result.Files = append(result.Files, fileResult)
+	}
+
+	if !opts.Apply {
+		return result, nil
+	}
+
+	// Solo se escribe cuando todo el análisis ha terminado sin errores
+	written := 0
+	for _, file := range files {
+		content, ok := rewritten[file]
+		if !ok {
+			continue
+		}
+		if err := fs.writeFileAtomic(file, content); err != nil {
+			return nil, fmt.Errorf("writing %s (%d files already updated): %w", file, written, err)
+		}
+		written++
+	}
+	result.Applied = true
+
+	return result, nil
+}
+
+// classifyRegions - Marca cada byte como código, cadena o comentario; es textual,
+// no un parser, pero basta para no confundir texto con identificadores
+func classifyRegions(content string, syntax refactorSyntax) []byte {
+	regions := make([]byte, len(content))
+	mark := func(from, n int, region byte) {
+		for k := from; k < from+n && k < len(regions); k++ {
+			regions[k] = region
+		}
+	}
+
+	var quote string
+	inLine, inBlock := false, false
+	for i := 0; i < len(content); {
+		c := content[i]
+		rest := content[i:]
+
+		switch {
+		case inLine:
+			if c == '\n' {
+				inLine = false
+			} else {
+				regions[i] = regionComment
+			}
+			i++
+
+		case inBlock:
+			if strings.HasPrefix(rest, syntax.blockEnd) {
+				mark(i, len(syntax.blockEnd), regionComment)
+				i += len(syntax.blockEnd)
+				inBlock = false
+				continue
+			}
+			regions[i] = regionComment
+			i++
+
+		case quote != "":
+			if c == '\\' && quote != "`" {
+				mark(i, 2, regionString)
+				i += 2
+				continue
+			}
+			if strings.HasPrefix(rest, quote) {
+				mark(i, len(quote), regionString)
+				i += len(quote)
+				quote = ""
+				continue
+			}
+			if c == '\n' && len(quote) == 1 && !strings.Contains(syntax.multilineQuotes, quote) {
+				quote = ""
+				i++
+				continue
+			}
+			regions[i] = regionString
+			i++
+
+		default:
+			if syntax.blockStart != "" && strings.HasPrefix(rest, syntax.blockStart) {
+				mark(i, len(syntax.blockStart), regionComment)
+				i += len(syntax.blockStart)
+				inBlock = true
+				continue
+			}
+			if startsWithAny(rest, syntax.lineComments) {
+				inLine = true
+				continue
+			}
+			if syntax.tripleQuotes && (strings.HasPrefix(rest, `"""`) || strings.HasPrefix(rest, `'''`)) {
+				quote = rest[:3]
+				mark(i, 3, regionString)
+				i += 3
+				continue
+			}
+			if strings.IndexByte(syntax.quotes, c) >= 0 {
+				quote = string(c)
+				regions[i] = regionString
+			}
+			i++
+		}
+	}
+	return regions
+}
+
+// startsWithAny - Comprueba si s empieza por alguno de los prefijos
+func startsWithAny(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyOccurrence - definition, reference, string o comment según la región y el contexto
+func classifyOccurrence(content string, start, end int, region byte) string {
+	switch region {
+	case regionString:
+		return "string"
+	case regionComment:
+		return "comment"
+	}
+
+	lineStart := strings.LastIndexByte(content[:start], '\n') + 1
+	lineEnd := strings.IndexByte(content[end:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(content)
+	} else {
+		lineEnd += end
+	}
+
+	if definitionPrefix.MatchString(content[lineStart:start]) || definitionSuffix.MatchString(content[end:lineEnd]) {
+		return "definition"
+	}
+	return "reference"
+}
+
+// occurrenceLocation - Línea y columna (1-based, en runas) y texto de la línea
+func occurrenceLocation(content string, offset int) (int, int, string) {
+	line := strings.Count(content[:offset], "\n") + 1
+	lineStart := strings.LastIndexByte(content[:offset], '\n') + 1
+	lineEnd := strings.IndexByte(content[offset:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(content)
+	} else {
+		lineEnd += offset
+	}
+
+	column := len([]rune(content[lineStart:offset])) + 1
+	text := strings.TrimSpace(strings.TrimSuffix(content[lineStart:lineEnd], "\r"))
+	if runes := []rune(text); len(runes) > 120 {
+		text = string(runes[:117]) + "..."
+	}
+	return line, column, text
+}
+
+// formatRenameResult - Resumen legible de la vista previa o del rename aplicado
+func formatRenameResult(result *RenameResult, opts renameOptions) string {
+	var b strings.Builder
+
+	total, files := 0, 0
+	for _, file := range result.Files {
+		total += file.Replaced
+		if file.Replaced > 0 {
+			files++
+		}
+	}
+
+	if result.Applied {
+		b.WriteString(fmt.Sprintf("✅ Renamed '%s' → '%s': %d replacements in %d files\n", result.Target, result.NewName, total, files))
+	} else {
+		b.WriteString(fmt.Sprintf("🔍 Rename preview: '%s' → '%s'\n", result.Target, result.NewName))
+	}
+	b.WriteString(fmt.Sprintf("📁 Files scanned: %d", result.FilesScanned))
+	if result.Skipped > 0 {
+		b.WriteString(fmt.Sprintf(" (%d binary or oversized skipped)", result.Skipped))
+	}
+	b.WriteString("\n")
+
+	if len(result.Files) == 0 {
+		b.WriteString(fmt.Sprintf("ℹ️ No whole-word occurrences of '%s' found\n", result.Target))
+		return b.String()
+	}
+
+	included := func(enabled bool) string {
+		if enabled {
+			return "included"
+		}
+		return "excluded"
+	}
+	b.WriteString(fmt.Sprintf("📊 Occurrences: %d definitions, %d references, %d in strings (%s), %d in comments (%s)\n",
+		result.Counts["definition"], result.Counts["reference"],
+		result.Counts["string"], included(opts.IncludeStrings),
+		result.Counts["comment"], included(opts.IncludeComments)))
+	if !result.Applied {
+		b.WriteString(fmt.Sprintf("✏️ Would replace: %d occurrences in %d files\n", total, files))
+	}
+
+	for _, file := range result.Files {
+		b.WriteString(fmt.Sprintf("\n📄 %s (%s): %d of %d replaced\n", file.File, file.Language, file.Replaced, len(file.Occurrences)))
+		if result.Applied {
+			continue
+		}
+		for i, occ := range file.Occurrences {
+			if i == maxRefactorPreview {
+				b.WriteString(fmt.Sprintf("  ... and %d more\n", len(file.Occurrences)-maxRefactorPreview))
+				break
+			}
+			marker := "✓"
+			if !occ.Applied {
+				marker = "·"
+			}
+			b.WriteString(fmt.Sprintf("  %s %d:%d %-10s %s\n", marker, occ.Line, occ.Column, occ.Kind, occ.Text))
+		}
+	}
+
+	if len(result.Conflicts) > 0 {
+		b.WriteString(fmt.Sprintf("\n⚠️ '%s' already appears in: %s\n", result.NewName, strings.Join(result.Conflicts, ", ")))
+	}
+	if !result.Applied {
+		b.WriteString("\n💡 Rename is textual (whole-word, not AST-aware). Set options.apply=true to write the changes\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,149 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const refactorGoSource = `package shop
+
+// Total returns the cart Total
+func Total(items []int) int {
+	total := 0
+	for _, item := range items {
+		total += item
+	}
+	return total
+}
+
+func Summary(items []int) string {
+	return fmt.Sprintf("Total: %d", Total(items))
+}
+`
+
+func TestRenameSymbolPreviewAndApply(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	source := filepath.Join(dir, "cart.go")
+	caller := filepath.Join(dir, "cmd", "main.go")
+	require.NoError(t, os.WriteFile(source, []byte(refactorGoSource), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Dir(caller), 0755))
+	require.NoError(t, os.WriteFile(caller, []byte("package main\n\nfunc main() {\n\tprintln(shop.Total(nil), Totals)\n}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blob.go"), []byte("Total\x00\x01\x02"), 0644))
+
+	ctx := context.Background()
+	result, err := handler.renameSymbol(ctx, dir, "Total", renameOptions{NewName: "GrandTotal", CaseSensitive: true})
+	require.NoError(t, err)
+	assert.False(t, result.Applied)
+	assert.Equal(t, 2, result.FilesScanned)
+	assert.Equal(t, 1, result.Skipped)
+	assert.Equal(t, 1, result.Counts["definition"])
+	assert.Equal(t, 2, result.Counts["reference"])
+	assert.Equal(t, 1, result.Counts["string"])
+	assert.Equal(t, 2, result.Counts["comment"])
+
+	// La vista previa no modifica nada
+	data, err := os.ReadFile(source)
+	require.NoError(t, err)
+	assert.Equal(t, refactorGoSource, string(data))
+
+	result, err = handler.renameSymbol(ctx, dir, "Total", renameOptions{NewName: "GrandTotal", CaseSensitive: true, Apply: true, IncludeComments: true})
+	require.NoError(t, err)
+	assert.True(t, result.Applied)
+
+	data, err = os.ReadFile(source)
+	require.NoError(t, err)
+	content := string(data)
+	assert.Contains(t, content, "// GrandTotal returns the cart GrandTotal")
+	assert.Contains(t, content, "func GrandTotal(items []int) int {")
+	assert.Contains(t, content, `fmt.Sprintf("Total: %d", GrandTotal(items))`)
+	assert.Contains(t, content, "total := 0") // case-sensitive
+
+	data, err = os.ReadFile(caller)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "shop.GrandTotal(nil), Totals)") // whole-word
+
+	data, err = os.ReadFile(filepath.Join(dir, "blob.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "Total\x00\x01\x02", string(data))
+}
+
+func TestRenameSymbolCaseInsensitiveAndPatterns(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.py"), []byte("def load():\n    return LOAD  # load it\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.js"), []byte("load();\n"), 0644))
+
+	result, err := handler.renameSymbol(context.Background(), dir, "load", renameOptions{
+		NewName:      "fetch",
+		FilePatterns: []string{"*.py"},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Files, 1)
+	assert.Equal(t, "a.py", result.Files[0].File)
+	assert.Equal(t, 1, result.Counts["definition"])
+	assert.Equal(t, 1, result.Counts["reference"])
+	assert.Equal(t, 1, result.Counts["comment"])
+	assert.Equal(t, 2, result.Files[0].Replaced)
+}
+
+func TestClassifyRegions(t *testing.T) {
+	syntax, ok := refactorSyntaxFor("go")
+	require.True(t, ok)
+	content := "x := \"a // b\" // c\n/* d */ `e\nf` 'g'"
+	regions := classifyRegions(content, syntax)
+
+	at := func(s string) byte {
+		for i := range content {
+			if content[i:i+len(s)] == s {
+				return regions[i]
+			}
+		}
+		t.Fatalf("%q not in content", s)
+		return 0
+	}
+	assert.Equal(t, regionCode, at("x"))
+	assert.Equal(t, regionString, at("b"))
+	assert.Equal(t, regionComment, at("c"))
+	assert.Equal(t, regionComment, at("d"))
+	assert.Equal(t, regionString, at("f"))
+	assert.Equal(t, regionString, at("g"))
+}
+
+func TestAssistRefactorValidation(t *testing.T) {
+	handler, dir := newTestHandler(t)
+
+	result, err := handler.handleAssistRefactor(context.Background(), newToolRequest("assist_refactor", map[string]interface{}{
+		"path":      dir,
+		"operation": "extract",
+		"target":    "Foo",
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	result, err = handler.handleAssistRefactor(context.Background(), newToolRequest("assist_refactor", map[string]interface{}{
+		"path":      dir,
+		"operation": "rename",
+		"target":    "Foo",
+		"options":   map[string]interface{}{"new_name": "bad-name"},
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foo.go"), []byte("package foo\n\nfunc Foo() {}\n"), 0644))
+	result, err = handler.handleAssistRefactor(context.Background(), newToolRequest("assist_refactor", map[string]interface{}{
+		"path":      dir,
+		"operation": "rename",
+		"target":    "Foo",
+		"options":   map[string]interface{}{"new_name": "Bar"},
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "Rename preview")
+	assert.Contains(t, text, "3:6 definition")
+}
@@ -0,0 +1,714 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// reportSections - Secciones disponibles en generate_report, en orden de salida
+var reportSections = []string{"overview", "files", "quality", "code_stats", "dependencies", "security"}
+
+// projectFile - Archivo recogido en el recorrido compartido de un proyecto
+type projectFile struct {
+	Path     string
+	Rel      string
+	Size     int64
+	Ext      string
+	Language Language
+}
+
+// projectWalk - Resultado de recorrer un proyecto una sola vez
+type projectWalk struct {
+	Root        string
+	Files       []projectFile
+	Directories int
+}
+
+// handleGenerateReport - Genera un informe del proyecto en JSON, Markdown o HTML
+func (fs *FilesystemHandler) handleGenerateReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	format, err := getString(request.Params.Arguments, "format", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	output, err := getString(request.Params.Arguments, "output", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "markdown" && format != "html" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: unsupported format '%s' (use 'json', 'markdown' or 'html')", format)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	requested, err := getStringSlice(request.Params.Arguments, "sections")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	sections := reportSections
+	if len(requested) > 0 {
+		wanted := make(map[string]bool)
+		for _, name := range requested {
+			if !containsString(reportSections, name) {
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{
+						mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: unknown section '%s' (use %s)", name, strings.Join(reportSections, ", "))},
+					},
+					IsError: true,
+				}, nil
+			}
+			wanted[name] = true
+		}
+		sections = nil
+		for _, name := range reportSections {
+			if wanted[name] {
+				sections = append(sections, name)
+			}
+		}
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Path error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	info, err := os.Stat(validPath)
+	if err != nil || !info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: Path must be a directory"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var validOutput string
+	if output != "" {
+		validOutput, err = fs.validatePath(output)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error with output: %v", err)},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	report, err := fs.buildProjectReport(ctx, validPath, filepath.Base(validPath), sections)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Report generation error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	rendered, err := renderProjectReport(report, format)
+	if err != nil {
+		return nil, err
+	}
+
+	if validOutput == "" {
+		if spilled := fs.oversizedResult(validPath, "generate_report", len(rendered), []byte(rendered), format, func() string { return formatReportSummary(report, format) }); spilled != nil {
+			return spilled, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: rendered},
+			},
+		}, nil
+	}
+
+	if err := fs.writeFileChecked(validOutput, []byte(rendered), 0644); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error writing report: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("📄 Report written: %s (%s, %d bytes, sections: %s)\n🔗 Resource: %s",
+					validOutput, format, len(rendered), strings.Join(sections, ", "), pathToResourceURI(validOutput)),
+			},
+		},
+	}, nil
+}
+
+// buildProjectReport - Recorre el proyecto una vez y genera las secciones en paralelo
+func (fs *FilesystemHandler) buildProjectReport(ctx context.Context, root, label string, sections []string) (*ProjectReport, error) {
+	walk, err := fs.walkProject(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ProjectReport{Root: label, Sections: sections}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	run := func(fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Cada sección escribe solo su propio campo del informe
+	for _, section := range sections {
+		switch section {
+		case "overview":
+			run(func() error {
+				report.Overview = fs.reportOverview(walk)
+				return nil
+			})
+		case "files":
+			run(func() error {
+				files, err := reportFiles(ctx, walk)
+				report.Files = files
+				return err
+			})
+		case "quality":
+			run(func() error {
+				quality, err := fs.reportQuality(ctx, walk)
+				if quality != nil {
+					quality.Path = label
+				}
+				report.Quality = quality
+				return err
+			})
+		case "code_stats":
+			run(func() error {
+				stats, err := fs.codeStats(ctx, walk, "loc")
+				if stats != nil {
+					stats.Path = label
+				}
+				report.CodeStats = stats
+				return err
+			})
+		case "dependencies":
+			run(func() error {
+				deps, err := fs.reportDependencies(ctx, walk)
+				report.Dependencies = deps
+				return err
+			})
+		case "security":
+			run(func() error {
+				findings, _, err := scanSecrets(ctx, walk, loadScanAllowlist(walk.Root))
+				report.Security = findings
+				return err
+			})
+		}
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return report, nil
+}
+
+// walkProject - Recorrido compartido por todas las secciones del informe
+func (fs *FilesystemHandler) walkProject(ctx context.Context, root string) (*projectWalk, error) {
+	walk := &projectWalk{Root: root}
+
+	err := fs.walkContext(ctx, root, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			fs.logWalkError(ctx, currentPath, err)
+			return nil // Continuar con otros archivos
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if currentPath == root {
+			return nil
+		}
+		if fs.shouldIgnoreEntry(ctx, currentPath, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, err := fs.validatePath(currentPath); err != nil {
+			return nil
+		}
+
+		if info.IsDir() {
+			walk.Directories++
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, currentPath)
+		ext := strings.ToLower(filepath.Ext(currentPath))
+		language, _ := languageDetector.Detect(currentPath, "")
+		walk.Files = append(walk.Files, projectFile{
+			Path:     currentPath,
+			Rel:      filepath.ToSlash(rel),
+			Size:     info.Size(),
+			Ext:      ext,
+			Language: language,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(walk.Files, func(i, j int) bool { return walk.Files[i].Rel < walk.Files[j].Rel })
+	return walk, nil
+}
+
+// reportOverview - Totales, lenguajes y patrones de proyecto
+func (fs *FilesystemHandler) reportOverview(walk *projectWalk) *ReportOverview {
+	structure := &ProjectStructure{
+		Root:        walk.Root,
+		Languages:   make(map[string]int),
+		FileTypes:   make(map[string]int),
+		Directories: make([]string, walk.Directories),
+	}
+	for _, file := range walk.Files {
+		structure.TotalFiles++
+		structure.TotalSize += file.Size
+		ext := file.Ext
+		if ext == "" {
+			ext = "no-extension"
+		}
+		structure.FileTypes[ext]++
+		if file.Language != unknownLanguage {
+			structure.Languages[file.Language.Name]++
+		}
+	}
+
+	patterns := fs.detectProjectPatterns(structure)
+	if patterns == nil {
+		patterns = []string{}
+	}
+	return &ReportOverview{
+		TotalFiles:       structure.TotalFiles,
+		TotalDirectories: walk.Directories,
+		TotalSize:        structure.TotalSize,
+		Languages:        structure.Languages,
+		Patterns:         patterns,
+	}
+}
+
+// reportFiles - Tipos de archivo, los más grandes y duplicados
+func reportFiles(ctx context.Context, walk *projectWalk) (*ReportFiles, error) {
+	files := &ReportFiles{
+		FileTypes:  make(map[string]int),
+		Largest:    []ReportFileEntry{},
+		Duplicates: [][]string{},
+	}
+
+	bySize := make(map[int64][]DuplicateFile)
+	for _, file := range walk.Files {
+		ext := file.Ext
+		if ext == "" {
+			ext = "no-extension"
+		}
+		files.FileTypes[ext]++
+		files.Largest = append(files.Largest, ReportFileEntry{Path: file.Rel, Size: file.Size})
+		if file.Size > 0 {
+			bySize[file.Size] = append(bySize[file.Size], DuplicateFile{Path: file.Path, Size: file.Size})
+		}
+	}
+
+	sort.SliceStable(files.Largest, func(i, j int) bool { return files.Largest[i].Size > files.Largest[j].Size })
+	if len(files.Largest) > 10 {
+		files.Largest = files.Largest[:10]
+	}
+
+	duplicates, err := groupDuplicateCandidates(ctx, bySize, duplicateOptions{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range sortedDuplicateGroups(duplicates) {
+		var paths []string
+		for _, file := range group {
+			rel, _ := filepath.Rel(walk.Root, file.Path)
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		files.Duplicates = append(files.Duplicates, paths)
+		files.DuplicateWaste += group[0].Size * int64(len(group)-1)
+	}
+
+	return files, nil
+}
+
+// reportQuality - code_quality_check sobre los archivos de código del recorrido
+func (fs *FilesystemHandler) reportQuality(ctx context.Context, walk *projectWalk) (*QualityReport, error) {
+	var paths []string
+	for _, file := range walk.Files {
+		if file.Language != unknownLanguage && file.Size <= MAX_INLINE_SIZE {
+			paths = append(paths, file.Path)
+		}
+	}
+	return fs.evaluateCodeQuality(ctx, walk.Root, paths, true, defaultQualityThresholds())
+}
+
+// reportDependencies - Módulos importados por los archivos de código
+func (fs *FilesystemHandler) reportDependencies(ctx context.Context, walk *projectWalk) ([]ReportDependency, error) {
+	counts := make(map[[2]string]int)
+	for _, file := range walk.Files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		language := file.Language.Key
+		if !hasCodeMetrics(language) || file.Size > MAX_INLINE_SIZE {
+			continue
+		}
+		data, err := fs.readFile(file.Path)
+		progressFrom(ctx).read(file.Path, int64(len(data)))
+		if err != nil {
+			continue
+		}
+		for _, dep := range fs.extractDependencies(string(data), language) {
+			counts[[2]string{dep, language}]++
+		}
+	}
+
+	deps := []ReportDependency{}
+	for key, count := range counts {
+		deps = append(deps, ReportDependency{Name: key[0], Language: key[1], Files: count})
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].Files != deps[j].Files {
+			return deps[i].Files > deps[j].Files
+		}
+		if deps[i].Language != deps[j].Language {
+			return deps[i].Language < deps[j].Language
+		}
+		return deps[i].Name < deps[j].Name
+	})
+	return deps, nil
+}
+
+// containsString - Comprueba si value está en list
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// formatReportSummary - Resumen de un informe que no cabe en la respuesta: secciones y totales
+func formatReportSummary(report *ProjectReport, format string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 Project report for %s (%s)\n", report.Root, format)
+	fmt.Fprintf(&b, "📑 Sections: %s\n", strings.Join(report.Sections, ", "))
+	if o := report.Overview; o != nil {
+		fmt.Fprintf(&b, "📁 %d files in %d directories, %d bytes\n", o.TotalFiles, o.TotalDirectories, o.TotalSize)
+		if languages := keysByCount(o.Languages); len(languages) > 0 {
+			if len(languages) > 5 {
+				languages = languages[:5]
+			}
+			fmt.Fprintf(&b, "🔧 Top languages: %s\n", strings.Join(languages, ", "))
+		}
+	}
+	if f := report.Files; f != nil {
+		fmt.Fprintf(&b, "📦 %d duplicate groups, %d bytes wasted\n", len(f.Duplicates), f.DuplicateWaste)
+	}
+	if q := report.Quality; q != nil {
+		fmt.Fprintf(&b, "🧹 Quality score %d, %d findings\n", q.Score, len(q.Findings))
+	}
+	if c := report.CodeStats; c != nil {
+		fmt.Fprintf(&b, "📏 %d lines of code in %d directories, average complexity %.1f\n", c.Total.LOC, len(c.Directories), c.Total.AvgComplexity)
+	}
+	if report.Dependencies != nil {
+		fmt.Fprintf(&b, "🔗 %d dependencies\n", len(report.Dependencies))
+	}
+	if report.Security != nil {
+		fmt.Fprintf(&b, "🔒 %d possible secrets\n", len(report.Security))
+	}
+	return b.String()
+}
+
+// renderProjectReport - Serializa el informe en el formato pedido
+func renderProjectReport(report *ProjectReport, format string) (string, error) {
+	switch format {
+	case "markdown":
+		return renderReportMarkdown(report), nil
+	case "html":
+		return renderReportHTML(report), nil
+	default:
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+	}
+}
+
+// reportTable - Tabla intermedia compartida por los renderizadores Markdown y HTML
+type reportTable struct {
+	Headers []string
+	Rows    [][]string
+}
+
+// reportBlock - Bloque de una sección: tabla, párrafo o subtítulo
+type reportBlock struct {
+	Heading string
+	Text    string
+	Table   *reportTable
+}
+
+// reportSectionBlocks - Convierte cada sección del informe en bloques renderizables
+func reportSectionBlocks(report *ProjectReport) []struct {
+	Title  string
+	Blocks []reportBlock
+} {
+	type section = struct {
+		Title  string
+		Blocks []reportBlock
+	}
+	var out []section
+
+	for _, name := range report.Sections {
+		switch name {
+		case "overview":
+			if report.Overview == nil {
+				continue
+			}
+			o := report.Overview
+			blocks := []reportBlock{{Table: &reportTable{
+				Headers: []string{"Metric", "Value"},
+				Rows: [][]string{
+					{"Files", fmt.Sprint(o.TotalFiles)},
+					{"Directories", fmt.Sprint(o.TotalDirectories)},
+					{"Total size", fmt.Sprintf("%d bytes", o.TotalSize)},
+				},
+			}}}
+			if len(o.Languages) > 0 {
+				blocks = append(blocks, reportBlock{Heading: "Languages", Table: countTable("Language", "Files", o.Languages)})
+			}
+			if len(o.Patterns) > 0 {
+				blocks = append(blocks, reportBlock{Text: "Patterns: " + strings.Join(o.Patterns, ", ")})
+			}
+			out = append(out, section{"Overview", blocks})
+
+		case "files":
+			if report.Files == nil {
+				continue
+			}
+			f := report.Files
+			blocks := []reportBlock{{Heading: "File types", Table: countTable("Extension", "Files", f.FileTypes)}}
+			largest := &reportTable{Headers: []string{"File", "Size"}}
+			for _, entry := range f.Largest {
+				largest.Rows = append(largest.Rows, []string{entry.Path, fmt.Sprintf("%d bytes", entry.Size)})
+			}
+			blocks = append(blocks, reportBlock{Heading: "Largest files", Table: largest})
+			if len(f.Duplicates) == 0 {
+				blocks = append(blocks, reportBlock{Heading: "Duplicates", Text: "No duplicate files found."})
+			} else {
+				dups := &reportTable{Headers: []string{"Group", "Files"}}
+				for i, group := range f.Duplicates {
+					dups.Rows = append(dups.Rows, []string{fmt.Sprint(i + 1), strings.Join(group, ", ")})
+				}
+				blocks = append(blocks,
+					reportBlock{Heading: "Duplicates", Text: fmt.Sprintf("Duplicate groups: %d, wasted: %d bytes.", len(f.Duplicates), f.DuplicateWaste)},
+					reportBlock{Table: dups})
+			}
+			out = append(out, section{"Files", blocks})
+
+		case "quality":
+			if report.Quality == nil {
+				continue
+			}
+			q := report.Quality
+			blocks := []reportBlock{{Text: fmt.Sprintf("Score: %d/100 across %d files (%d errors, %d warnings, %d info).",
+				q.Score, q.FilesChecked, q.Summary["error"], q.Summary["warning"], q.Summary["info"])}}
+			if len(q.Findings) > 0 {
+				findings := &reportTable{Headers: []string{"Severity", "Location", "Rule", "Message"}}
+				for _, finding := range q.Findings {
+					location := finding.File
+					if finding.Line > 0 {
+						location = fmt.Sprintf("%s:%d", finding.File, finding.Line)
+					}
+					findings.Rows = append(findings.Rows, []string{finding.Severity, location, finding.Rule, finding.Message})
+				}
+				blocks = append(blocks, reportBlock{Table: findings})
+			}
+			out = append(out, section{"Quality", blocks})
+
+		case "code_stats":
+			if report.CodeStats == nil {
+				continue
+			}
+			stats := &reportTable{Headers: codeStatsHeaders}
+			for _, dir := range report.CodeStats.Directories {
+				stats.Rows = append(stats.Rows, codeStatsRow(dir))
+			}
+			stats.Rows = append(stats.Rows, codeStatsRow(report.CodeStats.Total))
+			out = append(out, section{"Code stats", []reportBlock{{Table: stats}}})
+
+		case "dependencies":
+			var blocks []reportBlock
+			if len(report.Dependencies) == 0 {
+				blocks = append(blocks, reportBlock{Text: "No dependencies detected."})
+			} else {
+				deps := &reportTable{Headers: []string{"Dependency", "Language", "Files"}}
+				for _, dep := range report.Dependencies {
+					deps.Rows = append(deps.Rows, []string{dep.Name, dep.Language, fmt.Sprint(dep.Files)})
+				}
+				blocks = append(blocks, reportBlock{Table: deps})
+			}
+			out = append(out, section{"Dependencies", blocks})
+
+		case "security":
+			var blocks []reportBlock
+			if len(report.Security) == 0 {
+				blocks = append(blocks, reportBlock{Text: "No potential secrets found."})
+			} else {
+				secrets := &reportTable{Headers: []string{"Location", "Rule", "Match"}}
+				for _, finding := range report.Security {
+					secrets.Rows = append(secrets.Rows, []string{fmt.Sprintf("%s:%d", finding.File, finding.Line), finding.Rule, finding.Match})
+				}
+				blocks = append(blocks, reportBlock{Table: secrets})
+			}
+			out = append(out, section{"Security", blocks})
+		}
+	}
+
+	return out
+}
+
+// countTable - Tabla de conteos ordenada por valor descendente y nombre
+func countTable(keyHeader, valueHeader string, counts map[string]int) *reportTable {
+	table := &reportTable{Headers: []string{keyHeader, valueHeader}}
+	for _, key := range keysByCount(counts) {
+		table.Rows = append(table.Rows, []string{key, fmt.Sprint(counts[key])})
+	}
+	return table
+}
+
+// renderReportMarkdown - Informe en Markdown con encabezados y tablas
+func renderReportMarkdown(report *ProjectReport) string {
+	cell := func(s string) string {
+		return strings.ReplaceAll(s, "|", "\\|")
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# Project Report: %s\n", report.Root))
+	for _, section := range reportSectionBlocks(report) {
+		b.WriteString(fmt.Sprintf("\n## %s\n", section.Title))
+		for _, block := range section.Blocks {
+			if block.Heading != "" {
+				b.WriteString(fmt.Sprintf("\n### %s\n", block.Heading))
+			}
+			if block.Text != "" {
+				b.WriteString(fmt.Sprintf("\n%s\n", block.Text))
+			}
+			if block.Table != nil {
+				b.WriteString("\n| " + strings.Join(block.Table.Headers, " | ") + " |\n")
+				b.WriteString("|" + strings.Repeat(" --- |", len(block.Table.Headers)) + "\n")
+				for _, row := range block.Table.Rows {
+					cells := make([]string, len(row))
+					for i, value := range row {
+						cells[i] = cell(value)
+					}
+					b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+				}
+			}
+		}
+	}
+	return b.String()
+}
+
+// reportCSS - Estilos embebidos para el informe HTML autónomo
+const reportCSS = `body{font-family:-apple-system,Segoe UI,Helvetica,Arial,sans-serif;margin:2em auto;max-width:960px;color:#222;padding:0 1em}
+h1{border-bottom:2px solid #ddd;padding-bottom:.3em}
+h2{margin-top:1.6em;border-bottom:1px solid #eee;padding-bottom:.2em}
+table{border-collapse:collapse;margin:.6em 0;width:100%}
+th,td{border:1px solid #ddd;padding:4px 8px;text-align:left;font-size:14px;vertical-align:top}
+th{background:#f5f5f5}
+tr:nth-child(even) td{background:#fafafa}`
+
+// renderReportHTML - Informe HTML autónomo, sin recursos externos
+func renderReportHTML(report *ProjectReport) string {
+	esc := html.EscapeString
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString(fmt.Sprintf("<title>Project Report: %s</title>\n", esc(report.Root)))
+	b.WriteString("<style>\n" + reportCSS + "\n</style>\n</head>\n<body>\n")
+	b.WriteString(fmt.Sprintf("<h1>Project Report: %s</h1>\n", esc(report.Root)))
+	for _, section := range reportSectionBlocks(report) {
+		b.WriteString(fmt.Sprintf("<h2>%s</h2>\n", esc(section.Title)))
+		for _, block := range section.Blocks {
+			if block.Heading != "" {
+				b.WriteString(fmt.Sprintf("<h3>%s</h3>\n", esc(block.Heading)))
+			}
+			if block.Text != "" {
+				b.WriteString(fmt.Sprintf("<p>%s</p>\n", esc(block.Text)))
+			}
+			if block.Table != nil {
+				b.WriteString("<table>\n<tr>")
+				for _, header := range block.Table.Headers {
+					b.WriteString("<th>" + esc(header) + "</th>")
+				}
+				b.WriteString("</tr>\n")
+				for _, row := range block.Table.Rows {
+					b.WriteString("<tr>")
+					for _, value := range row {
+						b.WriteString("<td>" + esc(value) + "</td>")
+					}
+					b.WriteString("</tr>\n")
+				}
+				b.WriteString("</table>\n")
+			}
+		}
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
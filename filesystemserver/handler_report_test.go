@@ -0,0 +1,114 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// copyFixture - Copia testdata/<name> a un directorio permitido del handler
+func copyFixture(t *testing.T, name, dest string) {
+	t.Helper()
+	src := filepath.Join("testdata", name)
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dest, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+	require.NoError(t, err)
+}
+
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	golden := filepath.Join("testdata", name)
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(golden, []byte(got), 0644))
+	}
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), got)
+}
+
+func TestGenerateReportGolden(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	root := filepath.Join(dir, "fixture")
+	copyFixture(t, "report_project", root)
+
+	report, err := handler.buildProjectReport(context.Background(), root, "fixture", reportSections)
+	require.NoError(t, err)
+
+	jsonOut, err := renderProjectReport(report, "json")
+	require.NoError(t, err)
+	assertGolden(t, "report.golden.json", jsonOut)
+
+	markdownOut, err := renderProjectReport(report, "markdown")
+	require.NoError(t, err)
+	assertGolden(t, "report.golden.md", markdownOut)
+
+	// La contraseña de la fixture nunca aparece completa
+	assert.NotContains(t, jsonOut, "q8Zr3LmX7vKp2TnW")
+	assert.NotContains(t, markdownOut, "q8Zr3LmX7vKp2TnW")
+}
+
+func TestGenerateReportSectionsAndOutput(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	root := filepath.Join(dir, "fixture")
+	copyFixture(t, "report_project", root)
+	output := filepath.Join(dir, "report.html")
+
+	result, err := handler.handleGenerateReport(context.Background(), newToolRequest("generate_report", map[string]interface{}{
+		"path":     root,
+		"format":   "html",
+		"output":   output,
+		"sections": []interface{}{"security", "overview"},
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Resource: file://")
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	page := string(data)
+	assert.True(t, strings.HasPrefix(page, "<!DOCTYPE html>"))
+	assert.Contains(t, page, "<style>")
+	assert.Contains(t, page, "<h2>Overview</h2>")
+	assert.Contains(t, page, "<h2>Security</h2>")
+	assert.NotContains(t, page, "<h2>Quality</h2>")
+	assert.Less(t, strings.Index(page, "Overview"), strings.Index(page, "Security"))
+
+	result, err = handler.handleGenerateReport(context.Background(), newToolRequest("generate_report", map[string]interface{}{
+		"path":   root,
+		"format": "json",
+	}))
+	require.NoError(t, err)
+	var report ProjectReport
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &report))
+	assert.Equal(t, reportSections, report.Sections)
+
+	result, err = handler.handleGenerateReport(context.Background(), newToolRequest("generate_report", map[string]interface{}{
+		"path":     root,
+		"sections": []interface{}{"bogus"},
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
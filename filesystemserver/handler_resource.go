@@ -0,0 +1,129 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// directoryResourceParams - Parámetros de consulta que aceptan los recursos de directorio
+var directoryResourceParams = []string{"depth", "glob", "format"}
+
+// resourceURIQuery - Parámetros de consulta de un URI file://; vacío si no tiene o
+// si el URI no se puede analizar (forma antigua sin codificar)
+func resourceURIQuery(uri string) url.Values {
+	u, err := url.Parse(uri)
+	if err != nil || u.RawQuery == "" {
+		return nil
+	}
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil
+	}
+	return query
+}
+
+// readDirectoryResource - Listado de un directorio con depth, glob y format,
+// construido con el mismo árbol que la herramienta tree
+func (fs *FilesystemHandler) readDirectoryResource(uri, dir string, query url.Values) ([]mcp.ResourceContents, error) {
+	depth := 1
+	if value := query.Get("depth"); value != "" {
+		d, err := strconv.Atoi(value)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid depth %q: must be a non-negative integer", value)
+		}
+		depth = d
+	}
+	globs := query["glob"]
+	for _, glob := range globs {
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %v", glob, err)
+		}
+	}
+	format := query.Get("format")
+	if format != "" && format != "text" && format != "json" {
+		return nil, fmt.Errorf("invalid format %q: use text or json", format)
+	}
+
+	var ignored []string
+	for key := range query {
+		if !containsString(directoryResourceParams, key) {
+			ignored = append(ignored, key)
+		}
+	}
+	sort.Strings(ignored)
+
+	tree, err := fs.buildTree(context.Background(), dir, depth, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(globs) > 0 {
+		filterTreeByGlob(tree, dir, globs)
+	}
+
+	var contents []mcp.ResourceContents
+	if format == "json" {
+		addImageInfo(tree)
+		data, err := json.MarshalIndent(tree, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, mcp.TextResourceContents{URI: uri, MIMEType: "application/json", Text: string(data)})
+	} else {
+		var b strings.Builder
+		fmt.Fprintf(&b, "Directory listing for: %s (depth %d", dir, depth)
+		if len(globs) > 0 {
+			fmt.Fprintf(&b, ", glob %s", strings.Join(globs, " "))
+		}
+		b.WriteString(")\n\n")
+		writeTreeListing(&b, tree.Children, "")
+		contents = append(contents, mcp.TextResourceContents{URI: uri, MIMEType: "text/plain", Text: b.String()})
+	}
+
+	if len(ignored) > 0 {
+		note := fmt.Sprintf("Note: ignored unknown parameter(s): %s (supported: %s)", strings.Join(ignored, ", "), strings.Join(directoryResourceParams, ", "))
+		if format == "json" {
+			contents = append(contents, mcp.TextResourceContents{URI: uri, MIMEType: "text/plain", Text: note})
+		} else {
+			text := contents[0].(mcp.TextResourceContents)
+			text.Text += "\n" + note + "\n"
+			contents[0] = text
+		}
+	}
+	return contents, nil
+}
+
+// filterTreeByGlob - Deja los archivos que coinciden con algún glob (nombre o ruta
+// relativa) y los directorios que aún los contienen; devuelve si queda algo
+func filterTreeByGlob(node *FileNode, root string, globs []string) bool {
+	if node.Type != "directory" {
+		return matchesAnyPattern(root, node.Path, globs)
+	}
+	kept := node.Children[:0]
+	for _, child := range node.Children {
+		if filterTreeByGlob(child, root, globs) {
+			kept = append(kept, child)
+		}
+	}
+	node.Children = kept
+	return len(kept) > 0
+}
+
+// writeTreeListing - Listado sangrado con el formato de los recursos de directorio
+func writeTreeListing(b *strings.Builder, nodes []*FileNode, indent string) {
+	for _, node := range nodes {
+		if node.Type == "directory" {
+			fmt.Fprintf(b, "%s[DIR]  %s (%s)\n", indent, safeDisplayName(node.Name), dirResourceURI(node.Path))
+			writeTreeListing(b, node.Children, indent+"  ")
+		} else {
+			fmt.Fprintf(b, "%s[FILE] %s (%s) - %d bytes\n", indent, safeDisplayName(node.Name), pathToResourceURI(node.Path), node.Size)
+		}
+	}
+}
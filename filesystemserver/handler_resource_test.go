@@ -0,0 +1,93 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readResourceText(t *testing.T, handler *FilesystemHandler, uri string) []mcp.ResourceContents {
+	t.Helper()
+	request := mcp.ReadResourceRequest{}
+	request.Params.URI = uri
+	contents, err := handler.handleReadResource(context.Background(), request)
+	require.NoError(t, err, uri)
+	return contents
+}
+
+func TestReadDirectoryResourceQuery(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	for name, content := range map[string]string{
+		"main.go":           "package main\n",
+		"README.md":         "# readme\n",
+		"pkg/util.go":       "package pkg\n",
+		"pkg/deep/inner.go": "package deep\n",
+		"docs/guide.md":     "# guide\n",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	base := dirResourceURI(dir)
+
+	// Sin parámetros, el listado plano de siempre
+	flat := readResourceText(t, handler, base)[0].(mcp.TextResourceContents).Text
+	assert.Contains(t, flat, "[DIR]  pkg (")
+	assert.NotContains(t, flat, "util.go")
+
+	text := readResourceText(t, handler, base+"?depth=2")[0].(mcp.TextResourceContents).Text
+	assert.Contains(t, text, "(depth 2)")
+	assert.Contains(t, text, "[DIR]  pkg (")
+	assert.Contains(t, text, "  [FILE] util.go (")
+	assert.Contains(t, text, "  [DIR]  deep (")
+	assert.NotContains(t, text, "inner.go")
+
+	text = readResourceText(t, handler, base+"?glob=*.md")[0].(mcp.TextResourceContents).Text
+	assert.Contains(t, text, "[FILE] README.md")
+	assert.NotContains(t, text, "guide.md", "depth defaults to 1")
+
+	text = readResourceText(t, handler, base+"?depth=5&glob=*.go")[0].(mcp.TextResourceContents).Text
+	assert.Contains(t, text, "[FILE] main.go")
+	assert.Contains(t, text, "    [FILE] inner.go")
+	assert.NotContains(t, text, "README.md")
+	assert.NotContains(t, text, "docs", "directories without matches are dropped")
+
+	contents := readResourceText(t, handler, base+"?glob=pkg/*.go&format=json&depth=3&sort=name")
+	require.Len(t, contents, 2)
+	data := contents[0].(mcp.TextResourceContents)
+	assert.Equal(t, "application/json", data.MIMEType)
+	var tree FileNode
+	require.NoError(t, json.Unmarshal([]byte(data.Text), &tree))
+	require.Len(t, tree.Children, 1)
+	assert.Equal(t, "pkg", tree.Children[0].Name)
+	require.Len(t, tree.Children[0].Children, 1)
+	assert.Equal(t, "util.go", tree.Children[0].Children[0].Name)
+	assert.Contains(t, contents[1].(mcp.TextResourceContents).Text, "ignored unknown parameter(s): sort")
+
+	// Un ? codificado forma parte del nombre, no de la consulta
+	if runtime.GOOS != "windows" {
+		oddDir := filepath.Join(dir, "odd?name")
+		require.NoError(t, os.Mkdir(oddDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(oddDir, "nested.txt"), []byte("x"), 0644))
+		odd := dirResourceURI(oddDir)
+		require.True(t, strings.Contains(odd, "%3F"), odd)
+		text = readResourceText(t, handler, odd+"?depth=1&foo=1")[0].(mcp.TextResourceContents).Text
+		assert.Contains(t, text, "[FILE] nested.txt")
+		assert.Contains(t, text, "ignored unknown parameter(s): foo")
+	}
+
+	for _, bad := range []string{"?depth=-1", "?depth=two", "?format=xml", "?glob=[a"} {
+		request := mcp.ReadResourceRequest{}
+		request.Params.URI = base + bad
+		_, err := handler.handleReadResource(context.Background(), request)
+		assert.Error(t, err, bad)
+	}
+}
@@ -0,0 +1,193 @@
+package filesystemserver
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// rootLabelPattern - Etiquetas válidas; al menos dos caracteres para no
+// confundirse nunca con una letra de unidad de Windows (C:)
+var rootLabelPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{2,}$`)
+
+// WithRootLabels names allowed directories (directory -> label). Directories
+// without a name are labelled root1, root2... by position. Labels appear in
+// tool output as label:relative/path, and that form is accepted back in any
+// path argument.
+func WithRootLabels(labels map[string]string) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		if fs.rootLabels == nil {
+			fs.rootLabels = make(map[string]string)
+		}
+		for dir, label := range labels {
+			if !rootLabelPattern.MatchString(label) {
+				return fmt.Errorf("invalid root label %q: use at least two letters, digits, '_' or '-'", label)
+			}
+			normalized, err := normalizeAllowedDir(dir)
+			if err != nil {
+				return err
+			}
+			fs.rootLabels[normalized] = label
+		}
+		return nil
+	}
+}
+
+// assignRootLabels - Comprueba las etiquetas configuradas y asigna rootN a los
+// directorios sin etiqueta; se llama una vez, al crear el handler
+func (fs *FilesystemHandler) assignRootLabels() error {
+	if fs.rootLabels == nil {
+		fs.rootLabels = make(map[string]string)
+	}
+	used := make(map[string]bool)
+	for dir, label := range fs.rootLabels {
+		if !containsString(fs.allowedDirs, dir) {
+			return fmt.Errorf("root label %q names %s, which is not an allowed directory", label, dir)
+		}
+		if used[label] {
+			return fmt.Errorf("root label %q is used more than once", label)
+		}
+		used[label] = true
+	}
+	for _, dir := range fs.allowedDirs {
+		if _, ok := fs.rootLabels[dir]; !ok {
+			fs.rootLabels[dir] = fs.nextRootLabel()
+		}
+	}
+	return nil
+}
+
+// nextRootLabel - Siguiente rootN libre; los números no se reutilizan, así una
+// etiqueta nunca pasa a señalar otro directorio. Requiere fs.mu o exclusividad.
+func (fs *FilesystemHandler) nextRootLabel() string {
+	for {
+		fs.rootCounter++
+		label := fmt.Sprintf("root%d", fs.rootCounter)
+		taken := false
+		for _, existing := range fs.rootLabels {
+			if existing == label {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return label
+		}
+	}
+}
+
+// rootLabel - Etiqueta de un directorio permitido (con separador final)
+func (fs *FilesystemHandler) rootLabel(dir string) string {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.rootLabels[dir]
+}
+
+// labeledRoot - Directorio permitido con la etiqueta dada
+func (fs *FilesystemHandler) labeledRoot(label string) (string, bool) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	for _, dir := range fs.allowedDirs {
+		if fs.rootLabels[dir] == label {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// resolveLabeledPath - Convierte label:ruta/relativa en una ruta absoluta
+func (fs *FilesystemHandler) resolveLabeledPath(requestedPath string) (string, bool) {
+	label, rest, ok := strings.Cut(requestedPath, ":")
+	if !ok || !rootLabelPattern.MatchString(label) {
+		return "", false
+	}
+	root, ok := fs.labeledRoot(label)
+	if !ok {
+		return "", false
+	}
+	return filepath.Join(root, filepath.FromSlash(rest)), true
+}
+
+// labeledPath - label:ruta/relativa si path está bajo exactamente un
+// directorio permitido; en otro caso la ruta absoluta
+func (fs *FilesystemHandler) labeledPath(path string) string {
+	match, rel := "", ""
+	for _, dir := range fs.allowedDirectories() {
+		if r, ok := pathRelToDir(path, dir); ok {
+			if match != "" {
+				return path
+			}
+			match, rel = dir, r
+		}
+	}
+	label := fs.rootLabel(match)
+	if match == "" || label == "" {
+		return path
+	}
+	return label + ":" + filepath.ToSlash(rel)
+}
+
+// pathDisplay - Función que da formato a las rutas de la salida según relative_to:
+// "" o "root" usa label:ruta, "absolute" deja las rutas absolutas y cualquier
+// otro valor es un directorio respecto al que se muestran
+func (fs *FilesystemHandler) pathDisplay(relativeTo string) (func(string) string, error) {
+	switch relativeTo {
+	case "", "root":
+		return fs.labeledPath, nil
+	case "absolute":
+		return func(path string) string { return path }, nil
+	}
+
+	base, err := fs.validatePath(relativeTo)
+	if err != nil {
+		return nil, fmt.Errorf("relative_to: %w", err)
+	}
+	return func(path string) string {
+		if rel, ok := pathRelToDir(path, base); ok {
+			return filepath.ToSlash(rel)
+		}
+		return fs.labeledPath(path)
+	}, nil
+}
+
+// displayTreePaths - Aplica display a las rutas de un árbol de tree
+func displayTreePaths(node *FileNode, display func(string) string) {
+	if node == nil {
+		return
+	}
+	node.Path = display(node.Path)
+	for _, child := range node.Children {
+		displayTreePaths(child, display)
+	}
+}
+
+// dirResourceURI - URI de un directorio, siempre con barra final
+func dirResourceURI(dir string) string {
+	return pathToResourceURI(strings.TrimSuffix(dir, string(filepath.Separator)) + string(filepath.Separator))
+}
+
+// rootResources - Un recurso por directorio permitido para resources/list
+func (fs *FilesystemHandler) rootResources() []mcp.Resource {
+	dirs := fs.allowedDirectories()
+	resources := make([]mcp.Resource, 0, len(dirs))
+	for _, dir := range dirs {
+		display := strings.TrimSuffix(dir, string(filepath.Separator))
+		resources = append(resources, mcp.NewResource(
+			dirResourceURI(dir),
+			fmt.Sprintf("%s (%s)", fs.rootLabel(dir), filepath.Base(display)),
+			mcp.WithResourceDescription(fmt.Sprintf("Allowed directory %s", display)),
+			mcp.WithMIMEType("text/plain"),
+		))
+	}
+	return resources
+}
+
+// notifyRootsChanged - Avisa al servidor de que cambiaron los directorios permitidos
+func (fs *FilesystemHandler) notifyRootsChanged() {
+	if fs.rootsChanged != nil {
+		fs.rootsChanged()
+	}
+}
@@ -0,0 +1,186 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRootLabelsDisplayAndResolve(t *testing.T) {
+	app, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	docs, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(app, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(app, "src", "main.go"), []byte("package main\n"), 0644))
+	ctx := context.Background()
+
+	handler, err := NewFilesystemHandler([]string{app, docs}, WithRootLabels(map[string]string{docs: "docs"}))
+	require.NoError(t, err)
+
+	result, err := handler.handleListAllowedDirectories(ctx, newToolRequest("list_allowed_directories", nil))
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "root1: "+app)
+	assert.Contains(t, text, "docs: "+docs)
+
+	result, err = handler.handleSearchFiles(ctx, newToolRequest("search_files", map[string]interface{}{
+		"path":    app,
+		"pattern": "main",
+	}))
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "[FILE] root1:src/main.go")
+
+	result, err = handler.handleSearchFiles(ctx, newToolRequest("search_files", map[string]interface{}{
+		"path":        app,
+		"pattern":     "main",
+		"relative_to": "absolute",
+	}))
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "[FILE] "+filepath.Join(app, "src", "main.go"))
+
+	result, err = handler.handleListDirectory(ctx, newToolRequest("list_directory", map[string]interface{}{
+		"path":        "root1:src",
+		"relative_to": filepath.Join(app, "src"),
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Directory listing for: .")
+
+	// La forma con etiqueta vuelve a cualquier argumento de ruta
+	result, err = handler.handleReadFile(ctx, newToolRequest("read_file", map[string]interface{}{"path": "root1:src/main.go"}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	assert.Equal(t, "package main\n", result.Content[0].(mcp.TextContent).Text)
+
+	result, err = handler.handleWriteFile(ctx, newToolRequest("write_file", map[string]interface{}{"path": "docs:notes.md", "content": "# Notes\n"}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	_, err = os.Stat(filepath.Join(docs, "notes.md"))
+	assert.NoError(t, err)
+
+	result, err = handler.handleListDirectory(ctx, newToolRequest("list_directory", map[string]interface{}{"path": "docs:.."}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	result, err = handler.handleTree(ctx, newToolRequest("tree", map[string]interface{}{"path": "root1:"}))
+	require.NoError(t, err)
+	text = result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "Directory tree for root1:.")
+	assert.Contains(t, text, `"path": "root1:src/main.go"`)
+}
+
+func TestRootLabelOptions(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	other, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = NewFilesystemHandler([]string{dir}, WithRootLabels(map[string]string{dir: "C"}))
+	assert.Error(t, err)
+	_, err = NewFilesystemHandler([]string{dir}, WithRootLabels(map[string]string{other: "other"}))
+	assert.Error(t, err)
+	_, err = NewFilesystemHandler([]string{dir, other}, WithRootLabels(map[string]string{dir: "same", other: "same"}))
+	assert.Error(t, err)
+
+	// Un directorio añadido en tiempo de ejecución recibe la siguiente etiqueta libre
+	handler, err := NewFilesystemHandler([]string{dir}, WithRuntimeDirectories(true))
+	require.NoError(t, err)
+	added, err := handler.addAllowedDir(other)
+	require.NoError(t, err)
+	assert.Equal(t, "root2", handler.rootLabel(added))
+	assert.Equal(t, "root2:.", handler.labeledPath(other))
+	_, err = handler.removeAllowedDir(other)
+	require.NoError(t, err)
+	_, ok := handler.labeledRoot("root2")
+	assert.False(t, ok)
+}
+
+// callServer sends one JSON-RPC request and decodes its result into out
+func callServer(t *testing.T, s *server.MCPServer, method string, params interface{}, out interface{}) {
+	t.Helper()
+	message, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	require.NoError(t, err)
+	data, err := json.Marshal(s.HandleMessage(context.Background(), message))
+	require.NoError(t, err)
+
+	var decoded struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Nil(t, decoded.Error, string(data))
+	require.NoError(t, json.Unmarshal(decoded.Result, out))
+}
+
+func TestRootResources(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	extra, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub dir"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub dir", "ñandú.txt"), []byte("hola"), 0644))
+
+	s, err := NewFilesystemServer([]string{dir}, WithRootLabels(map[string]string{dir: "app"}), WithRuntimeDirectories(true))
+	require.NoError(t, err)
+
+	type resourceList struct {
+		Resources []struct {
+			URI  string `json:"uri"`
+			Name string `json:"name"`
+		} `json:"resources"`
+	}
+	var list resourceList
+	callServer(t, s, "resources/list", map[string]interface{}{}, &list)
+	require.Len(t, list.Resources, 1)
+	assert.Equal(t, pathToResourceURI(dir+string(filepath.Separator)), list.Resources[0].URI)
+	assert.Equal(t, "app ("+filepath.Base(dir)+")", list.Resources[0].Name)
+
+	var templates struct {
+		ResourceTemplates []struct {
+			URITemplate string `json:"uriTemplate"`
+		} `json:"resourceTemplates"`
+	}
+	callServer(t, s, "resources/templates/list", map[string]interface{}{}, &templates)
+	require.Len(t, templates.ResourceTemplates, 1)
+	assert.Equal(t, "file://{+path}", templates.ResourceTemplates[0].URITemplate)
+
+	// El listado del directorio raíz enlaza a URIs codificadas que se pueden leer
+	var read struct {
+		Contents []struct {
+			Text string `json:"text"`
+		} `json:"contents"`
+	}
+	callServer(t, s, "resources/read", map[string]interface{}{"uri": list.Resources[0].URI}, &read)
+	require.Len(t, read.Contents, 1)
+	subURI := pathToResourceURI(filepath.Join(dir, "sub dir") + string(filepath.Separator))
+	assert.Contains(t, read.Contents[0].Text, "[DIR]  sub dir ("+subURI+")")
+	assert.Contains(t, subURI, "sub%20dir/")
+
+	callServer(t, s, "resources/read", map[string]interface{}{"uri": pathToResourceURI(filepath.Join(dir, "sub dir", "ñandú.txt"))}, &read)
+	require.Len(t, read.Contents, 1)
+	assert.Equal(t, "hola", read.Contents[0].Text)
+
+	// Los directorios añadidos y retirados en tiempo de ejecución aparecen y desaparecen
+	var result struct {
+		IsError bool `json:"isError"`
+	}
+	callServer(t, s, "tools/call", map[string]interface{}{"name": "add_allowed_directory", "arguments": map[string]interface{}{"path": extra}}, &result)
+	require.False(t, result.IsError)
+	callServer(t, s, "resources/list", map[string]interface{}{}, &list)
+	assert.Len(t, list.Resources, 2)
+
+	callServer(t, s, "tools/call", map[string]interface{}{"name": "remove_allowed_directory", "arguments": map[string]interface{}{"path": extra}}, &result)
+	list = resourceList{}
+	callServer(t, s, "resources/list", map[string]interface{}{}, &list)
+	assert.Len(t, list.Resources, 1)
+}
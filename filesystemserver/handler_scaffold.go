@@ -0,0 +1,309 @@
+package filesystemserver
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	iofs "io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// builtinScaffolds - Plantillas de scaffold incluidas en el binario, una
+// carpeta por tipo bajo scaffolds/; all: hace falta para los .gitignore
+//
+//go:embed all:scaffolds
+var builtinScaffolds embed.FS
+
+// scaffoldEcosystems - Tipo de proyecto que detecta LanguageDetector para cada
+// tipo incluido; un workspace de otro tipo recibe un aviso
+var scaffoldEcosystems = map[string]string{
+	"go-cli":     "go",
+	"go-lib":     "go",
+	"node-ts":    "node",
+	"python-pkg": "python",
+}
+
+// WithScaffoldDir adds the template sets in dir to the scaffold tool: each
+// subdirectory is a kind, rendered like render_tree (.tmpl dropped from names,
+// {{...}} allowed in them). A subdirectory named like a built-in kind
+// replaces it.
+func WithScaffoldDir(dir string) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		normalized, err := normalizeAllowedDir(dir)
+		if err != nil {
+			return err
+		}
+		fs.scaffoldDir = strings.TrimSuffix(normalized, string(filepath.Separator))
+		return nil
+	}
+}
+
+// scaffoldKinds - Tipos disponibles, incluidos y del directorio del usuario, ordenados
+func (fs *FilesystemHandler) scaffoldKinds() []string {
+	var kinds []string
+	builtin, _ := builtinScaffolds.ReadDir("scaffolds")
+	for _, entry := range builtin {
+		kinds = append(kinds, entry.Name())
+	}
+	if fs.scaffoldDir != "" {
+		entries, _ := os.ReadDir(fs.scaffoldDir)
+		for _, entry := range entries {
+			if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") && !containsString(kinds, entry.Name()) {
+				kinds = append(kinds, entry.Name())
+			}
+		}
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// scaffoldTemplates - Plantillas de kind y su origen: el directorio del
+// usuario si tiene ese tipo, si no las incluidas
+func (fs *FilesystemHandler) scaffoldTemplates(kind string) (iofs.FS, string, error) {
+	if !containsString(fs.scaffoldKinds(), kind) {
+		return nil, "", fmt.Errorf("unknown kind %q (available: %s)", kind, strings.Join(fs.scaffoldKinds(), ", "))
+	}
+	if fs.scaffoldDir != "" {
+		dir := filepath.Join(fs.scaffoldDir, kind)
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return os.DirFS(dir), dir, nil
+		}
+	}
+	templates, err := iofs.Sub(builtinScaffolds, path.Join("scaffolds", kind))
+	return templates, "built-in", err
+}
+
+// scaffoldPackageName - Identificador válido en Go y Python a partir de un
+// nombre de proyecto: minúsculas y "_" en lugar de cualquier otro carácter
+func scaffoldPackageName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	pkg := b.String()
+	if pkg == "" || unicode.IsDigit(rune(pkg[0])) {
+		pkg = "_" + pkg
+	}
+	return pkg
+}
+
+// renderScaffold - Renderiza en memoria las plantillas de templates con
+// destino en outputDir; nada se escribe si falla cualquiera de ellas
+func (fs *FilesystemHandler) renderScaffold(ctx context.Context, templates iofs.FS, outputDir string, variables map[string]interface{}) ([]renderedFile, error) {
+	var files []renderedFile
+	err := iofs.WalkDir(templates, ".", func(name string, entry iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > maxTemplateSize {
+			return fmt.Errorf("template %s is %d bytes, the limit is %d", name, info.Size(), maxTemplateSize)
+		}
+		data, err := iofs.ReadFile(templates, name)
+		if err != nil {
+			return err
+		}
+
+		target, err := renderTemplatePath(name, variables)
+		if err != nil {
+			return err
+		}
+		content, err := renderTemplateText(name, string(data), variables)
+		if err != nil {
+			return err
+		}
+		validTarget, err := fs.validateNewPath(filepath.Join(outputDir, target))
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		files = append(files, renderedFile{path: validTarget, content: content, perm: 0644})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("the template set has no files")
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	for i := 1; i < len(files); i++ {
+		if files[i].path == files[i-1].path {
+			return nil, fmt.Errorf("several templates render to %s", files[i].path)
+		}
+	}
+	return files, nil
+}
+
+// handleScaffold - Crea el esqueleto de un proyecto del tipo indicado en un
+// directorio, sin sobrescribir archivos existentes salvo con force
+func (fs *FilesystemHandler) handleScaffold(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	dir, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	kind, err := getString(request.Params.Arguments, "kind", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	name, err := getString(request.Params.Arguments, "name", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	module, err := getString(request.Params.Arguments, "module", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	variables, err := getObject(request.Params.Arguments, "variables")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	force, err := getBool(request.Params.Arguments, "force", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	dryRun, err := getBool(request.Params.Arguments, "dry_run", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if dir == "" || kind == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: path and kind are required (kinds: %s)", strings.Join(fs.scaffoldKinds(), ", "))},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validDir, err := fs.validateNewPath(dir)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	if info, err := os.Stat(validDir); err == nil && !info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %s is not a directory", dir)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	templates, source, err := fs.scaffoldTemplates(kind)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// name sale del módulo o del directorio; module, de name. Los argumentos
+	// ganan a variables, que gana a los valores por defecto.
+	vars := map[string]interface{}{}
+	for key, value := range variables {
+		vars[key] = value
+	}
+	if name != "" {
+		vars["name"] = name
+	}
+	if module != "" {
+		vars["module"] = module
+	}
+	if _, ok := vars["name"]; !ok {
+		if module, ok := vars["module"].(string); ok && module != "" {
+			vars["name"] = path.Base(module)
+		} else {
+			vars["name"] = filepath.Base(validDir)
+		}
+	}
+	if _, ok := vars["module"]; !ok {
+		vars["module"] = vars["name"]
+	}
+	if _, ok := vars["package"]; !ok {
+		vars["package"] = scaffoldPackageName(fmt.Sprint(vars["name"]))
+	}
+
+	// El tipo se detecta antes de escribir, que los archivos nuevos lo cambiarían
+	projectType := languageDetector.Project(validDir)
+
+	files, err := fs.renderScaffold(ctx, templates, validDir, vars)
+	rendered := &RenderResult{Output: validDir, DryRun: dryRun}
+	if err == nil {
+		err = fs.writeRendered(ctx, rendered, files, force, "force")
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	result := &ScaffoldResult{
+		Kind:        kind,
+		Path:        validDir,
+		Templates:   source,
+		ProjectType: projectType,
+		DryRun:      dryRun,
+		Variables:   vars,
+		Files:       rendered.Files,
+	}
+	var written []string
+	for i := range result.Files {
+		if !dryRun {
+			result.Files[i].Resource = pathToResourceURI(result.Files[i].Path)
+			written = append(written, result.Files[i].Path)
+		}
+	}
+
+	var text strings.Builder
+	if dryRun {
+		text.WriteString(fmt.Sprintf("🔍 Dry run: scaffold %s would write %d file(s) in %s\n", kind, len(result.Files), validDir))
+	} else {
+		text.WriteString(fmt.Sprintf("🏗️ Scaffolded %s in %s (%d file(s), templates: %s)\n", kind, validDir, len(result.Files), source))
+	}
+	if ecosystem := scaffoldEcosystems[kind]; ecosystem != "" && projectType != "unknown" && projectType != ecosystem {
+		text.WriteString(fmt.Sprintf("⚠️ %s was already a %s project\n", validDir, projectType))
+	}
+	for _, file := range result.Files {
+		rel, _ := filepath.Rel(validDir, file.Path)
+		note := ""
+		if file.Overwritten {
+			note = " (overwrites existing file)"
+		}
+		text.WriteString(fmt.Sprintf("  📄 %s (%d bytes)%s\n", filepath.ToSlash(rel), file.Size, note))
+	}
+
+	return withStructuredContent(withFileResources(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: text.String()},
+		},
+	}, written), dirResourceURI(validDir), result)
+}
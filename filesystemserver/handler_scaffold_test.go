@@ -0,0 +1,138 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runScaffold calls scaffold and decodes its result
+func runScaffold(t *testing.T, handler *FilesystemHandler, args map[string]interface{}) ScaffoldResult {
+	t.Helper()
+	result, err := handler.handleScaffold(context.Background(), newToolRequest("scaffold", args))
+	require.NoError(t, err)
+	var scaffold ScaffoldResult
+	decodeStructured(t, result, &scaffold)
+	if scaffold.DryRun {
+		assert.Empty(t, fileResourceURIs(result))
+	} else {
+		assert.Len(t, fileResourceURIs(result), len(scaffold.Files))
+	}
+	return scaffold
+}
+
+func TestScaffoldGoCLI(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	project := filepath.Join(dir, "tool")
+
+	result := runScaffold(t, handler, map[string]interface{}{
+		"path": project, "kind": "go-cli", "module": "example.com/acme/tool",
+	})
+	assert.Equal(t, "built-in", result.Templates)
+	assert.Equal(t, "unknown", result.ProjectType)
+	assert.Equal(t, "tool", result.Variables["name"])
+	var names []string
+	for _, file := range result.Files {
+		rel, err := filepath.Rel(project, file.Path)
+		require.NoError(t, err)
+		names = append(names, filepath.ToSlash(rel))
+		assert.Equal(t, pathToResourceURI(file.Path), file.Resource)
+	}
+	assert.Equal(t, []string{".gitignore", "README.md", "cmd/main.go", "go.mod"}, names)
+	assertFileContent(t, filepath.Join(project, "go.mod"), "module example.com/acme/tool\n\ngo 1.22\n")
+	data, err := os.ReadFile(filepath.Join(project, "cmd", "main.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `fmt.Fprintln(os.Stderr, "tool:", err)`)
+	assert.Equal(t, "go", languageDetector.Project(project))
+
+	// Sin force no se toca nada
+	require.NoError(t, os.WriteFile(filepath.Join(project, "go.mod"), []byte("module mine\n"), 0644))
+	text, isError := callText(t, handler.handleScaffold, "scaffold", map[string]interface{}{"path": project, "kind": "go-cli"})
+	assert.True(t, isError)
+	assert.Contains(t, text, "already exist, pass force=true")
+	assertFileContent(t, filepath.Join(project, "go.mod"), "module mine\n")
+
+	result = runScaffold(t, handler, map[string]interface{}{"path": project, "kind": "go-cli", "force": true})
+	assert.Equal(t, "go", result.ProjectType)
+	assertFileContent(t, filepath.Join(project, "go.mod"), "module tool\n\ngo 1.22\n")
+}
+
+func TestScaffoldKinds(t *testing.T) {
+	handler, dir := newTestHandler(t)
+
+	result := runScaffold(t, handler, map[string]interface{}{"path": filepath.Join(dir, "lib"), "kind": "go-lib", "module": "example.com/my-lib"})
+	assert.Equal(t, "my_lib", result.Variables["package"])
+	assertFileContent(t, filepath.Join(dir, "lib", "my_lib.go"), "// Package my_lib implements my-lib.\npackage my_lib\n")
+
+	runScaffold(t, handler, map[string]interface{}{"path": filepath.Join(dir, "py"), "kind": "python-pkg", "name": "data-tools"})
+	assert.FileExists(t, filepath.Join(dir, "py", "src", "data_tools", "__init__.py"))
+	assert.FileExists(t, filepath.Join(dir, "py", "pyproject.toml"))
+
+	result = runScaffold(t, handler, map[string]interface{}{"path": filepath.Join(dir, "web"), "kind": "node-ts", "dry_run": true})
+	assert.True(t, result.DryRun)
+	assert.Len(t, result.Files, 4)
+	assert.Contains(t, result.Files[1].Content, `"name": "web"`)
+	assert.NoDirExists(t, filepath.Join(dir, "web"))
+}
+
+func TestScaffoldWarnsAboutOtherProjectType(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{"app/go.mod": "module app\n"})
+
+	text, isError := callText(t, handler.handleScaffold, "scaffold", map[string]interface{}{"path": filepath.Join(dir, "app"), "kind": "node-ts"})
+	require.False(t, isError, text)
+	assert.Contains(t, text, "was already a go project")
+}
+
+func TestScaffoldUserTemplates(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	templates := t.TempDir()
+	writeFixture(t, templates, map[string]string{
+		"go-cli/go.mod.tmpl":       "module {{.module}} // {{.owner}}\n",
+		"rust-bin/Cargo.toml.tmpl": "[package]\nname = \"{{.name}}\"\n",
+		"rust-bin/src/main.rs":     "fn main() {}\n",
+		".hidden/ignored.txt":      "x",
+	})
+	require.NoError(t, WithScaffoldDir(templates)(handler))
+	assert.Equal(t, []string{"go-cli", "go-lib", "node-ts", "python-pkg", "rust-bin"}, handler.scaffoldKinds())
+
+	result := runScaffold(t, handler, map[string]interface{}{"path": filepath.Join(dir, "rs"), "kind": "rust-bin"})
+	assert.Equal(t, filepath.Join(templates, "rust-bin"), result.Templates)
+	assertFileContent(t, filepath.Join(dir, "rs", "Cargo.toml"), "[package]\nname = \"rs\"\n")
+	assertFileContent(t, filepath.Join(dir, "rs", "src", "main.rs"), "fn main() {}\n")
+
+	// Un tipo del usuario sustituye al incluido del mismo nombre
+	runScaffold(t, handler, map[string]interface{}{
+		"path": filepath.Join(dir, "cli"), "kind": "go-cli", "variables": map[string]interface{}{"owner": "acme"},
+	})
+	assertFileContent(t, filepath.Join(dir, "cli", "go.mod"), "module cli // acme\n")
+	assert.NoFileExists(t, filepath.Join(dir, "cli", "cmd", "main.go"))
+}
+
+func TestScaffoldRejects(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{"file.txt": "x"})
+
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want string
+	}{
+		{"missing kind", map[string]interface{}{"path": dir}, "path and kind are required (kinds: go-cli, go-lib, node-ts, python-pkg)"},
+		{"unknown kind", map[string]interface{}{"path": dir, "kind": "cobol"}, `unknown kind "cobol"`},
+		{"escaping kind", map[string]interface{}{"path": dir, "kind": "../go-cli"}, "unknown kind"},
+		{"file path", map[string]interface{}{"path": filepath.Join(dir, "file.txt"), "kind": "go-cli"}, "is not a directory"},
+		{"outside", map[string]interface{}{"path": t.TempDir(), "kind": "go-cli"}, "access denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, isError := callText(t, handler.handleScaffold, "scaffold", tt.args)
+			assert.True(t, isError)
+			assert.Contains(t, text, tt.want)
+		})
+	}
+}
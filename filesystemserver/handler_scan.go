@@ -0,0 +1,478 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// scanners - Escáneres disponibles en la herramienta scan, en orden de salida
+var scanners = []string{"todos", "license", "secrets"}
+
+// scanIgnoreFile - Allowlist de falsos positivos en la raíz del proyecto
+const scanIgnoreFile = ".mcpscanignore"
+
+// maxSecretScanSize - Los archivos mayores no se revisan en busca de secretos
+const maxSecretScanSize = 1024 * 1024
+
+// secretPatterns - Reglas de detección de posibles secretos; con grupo de
+// captura solo se enmascara y se evalúa el valor
+var secretPatterns = []struct {
+	rule       string
+	re         *regexp.Regexp
+	minEntropy float64
+}{
+	{"aws_access_key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), 0},
+	{"private_key", regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY-----`), 0},
+	{"github_token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`), 0},
+	{"slack_token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`), 0},
+	{"generic_secret", regexp.MustCompile(`(?i)\b[a-z0-9_.-]*(?:password|passwd|secret|token|api[_-]?key)[a-z0-9_]*\b['"]?\s*[:=]\s*['"]([^'"\s]{16,})['"]`), 3.5},
+}
+
+// todoPattern - Marcador, autor opcional (TODO(ana) o @ana) y texto
+var todoPattern = regexp.MustCompile(`\b(TODO|FIXME|HACK|XXX)\b(?:\(([^)]*)\))?:?\s*(?:@([\w.-]+)\s*)?(.*)`)
+
+// licenseFingerprints - Frases características de cada licencia, en minúsculas y
+// con los espacios normalizados; la primera coincidencia gana
+var licenseFingerprints = []struct {
+	spdx    string
+	phrases []string
+}{
+	{"AGPL-3.0", []string{"gnu affero general public license", "version 3"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license", "version 2.1"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2"}},
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	{"MIT", []string{"permission is hereby granted, free of charge"}},
+	{"ISC", []string{"permission to use, copy, modify, and/or distribute this software for any purpose with or without fee"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+	{"CC0-1.0", []string{"cc0 1.0 universal"}},
+}
+
+// licenseFileNames - Archivos de licencia reconocidos en la raíz (sin distinguir mayúsculas)
+var licenseFileNames = []string{"license", "license.md", "license.txt", "licence", "licence.md", "licence.txt", "copying", "copying.md", "copying.txt", "unlicense"}
+
+// scanAllowlist - Reglas de .mcpscanignore: "glob" ignora archivos en todos los
+// escáneres, "escáner:glob" solo en uno y "glob:línea" un hallazgo concreto
+type scanAllowlist struct {
+	root    string
+	global  []string
+	scanner map[string][]string
+	lines   map[string][]string // "glob" -> líneas como texto
+}
+
+// loadScanAllowlist - Lee .mcpscanignore de root; si no existe no se ignora nada
+func loadScanAllowlist(root string) *scanAllowlist {
+	allow := &scanAllowlist{root: root, scanner: make(map[string][]string), lines: make(map[string][]string)}
+
+	data, err := os.ReadFile(filepath.Join(root, scanIgnoreFile))
+	if err != nil {
+		return allow
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if idx := strings.LastIndex(line, ":"); idx > 0 {
+			if _, err := strconv.Atoi(line[idx+1:]); err == nil {
+				allow.lines[line[:idx]] = append(allow.lines[line[:idx]], line[idx+1:])
+				continue
+			}
+		}
+		if name, glob, ok := strings.Cut(line, ":"); ok && containsString(scanners, name) {
+			allow.scanner[name] = append(allow.scanner[name], glob)
+			continue
+		}
+		allow.global = append(allow.global, line)
+	}
+	return allow
+}
+
+// ignoresFile - Si el archivo queda fuera de un escáner
+func (a *scanAllowlist) ignoresFile(scanner, path string) bool {
+	if a == nil {
+		return false
+	}
+	if filepath.Base(path) == scanIgnoreFile {
+		return true
+	}
+	return matchesAnyPattern(a.root, path, a.global) || matchesAnyPattern(a.root, path, a.scanner[scanner])
+}
+
+// ignoresLine - Si un hallazgo concreto está en la allowlist
+func (a *scanAllowlist) ignoresLine(path string, line int) bool {
+	if a == nil {
+		return false
+	}
+	want := strconv.Itoa(line)
+	for glob, lines := range a.lines {
+		if containsString(lines, want) && matchesAnyPattern(a.root, path, []string{glob}) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleScan - Busca TODOs, detecta la licencia y señala posibles secretos
+func (fs *FilesystemHandler) handleScan(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	output, err := getString(request.Params.Arguments, "output", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	requested, err := getStringSlice(request.Params.Arguments, "scanners")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	selected := scanners
+	if len(requested) > 0 {
+		wanted := make(map[string]bool)
+		for _, name := range requested {
+			if !containsString(scanners, name) {
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{
+						mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: unknown scanner '%s' (use %s)", name, strings.Join(scanners, ", "))},
+					},
+					IsError: true,
+				}, nil
+			}
+			wanted[name] = true
+		}
+		selected = nil
+		for _, name := range scanners {
+			if wanted[name] {
+				selected = append(selected, name)
+			}
+		}
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Path error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	info, err := os.Stat(validPath)
+	if err != nil || !info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: Path must be a directory"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	result, err := fs.scanProject(ctx, validPath, selected)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Scan error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: string(data)},
+			},
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatScanResult(result)},
+		},
+	}, nil
+}
+
+// scanProject - Ejecuta los escáneres seleccionados sobre un único recorrido
+func (fs *FilesystemHandler) scanProject(ctx context.Context, root string, selected []string) (*ScanResult, error) {
+	walk, err := fs.walkProject(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	allow := loadScanAllowlist(root)
+
+	result := &ScanResult{Root: root, Scanners: selected, Summary: make(map[string]int)}
+	for _, name := range selected {
+		var ignored int
+		switch name {
+		case "todos":
+			result.Todos, ignored, err = fs.scanTodos(ctx, walk, allow)
+			result.Summary["todos"] = len(result.Todos)
+		case "license":
+			result.Licenses = detectLicenses(walk)
+			result.Summary["license"] = len(result.Licenses)
+		case "secrets":
+			result.Secrets, ignored, err = scanSecrets(ctx, walk, allow)
+			result.Summary["secrets"] = len(result.Secrets)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Ignored += ignored
+	}
+	return result, nil
+}
+
+// scanTodos - Marcadores TODO/FIXME/HACK/XXX dentro de comentarios de código
+func (fs *FilesystemHandler) scanTodos(ctx context.Context, walk *projectWalk, allow *scanAllowlist) ([]TodoFinding, int, error) {
+	findings := []TodoFinding{}
+	ignored := 0
+
+	for _, file := range walk.Files {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		if file.Size == 0 || file.Size > MAX_INLINE_SIZE || allow.ignoresFile("todos", file.Path) {
+			continue
+		}
+		syntax, ok := refactorSyntaxFor(file.Language.Key)
+		if !ok || !isTextFile(detectMimeType(file.Path)) {
+			continue
+		}
+		data, err := fs.readFile(file.Path)
+		if err != nil {
+			continue
+		}
+
+		content := string(data)
+		regions := classifyRegions(content, syntax)
+		lineStart := 0
+		for i, line := range strings.Split(content, "\n") {
+			loc := todoPattern.FindStringSubmatchIndex(line)
+			if loc != nil && regions[lineStart+loc[0]] == regionComment {
+				if allow.ignoresLine(file.Path, i+1) {
+					ignored++
+				} else {
+					match := todoPattern.FindStringSubmatch(line)
+					author := strings.TrimSpace(match[2])
+					if author == "" {
+						author = match[3]
+					}
+					text := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(match[4]), "*/"))
+					if runes := []rune(text); len(runes) > 120 {
+						text = string(runes[:117]) + "..."
+					}
+					findings = append(findings, TodoFinding{
+						File:   file.Rel,
+						Line:   i + 1,
+						Marker: match[1],
+						Author: author,
+						Text:   text,
+					})
+				}
+			}
+			lineStart += len(line) + 1
+		}
+	}
+	return findings, ignored, nil
+}
+
+// detectLicenses - Identifica la licencia de los archivos LICENSE/COPYING de la raíz
+func detectLicenses(walk *projectWalk) []LicenseFinding {
+	findings := []LicenseFinding{}
+	for _, file := range walk.Files {
+		if strings.Contains(file.Rel, "/") || !containsString(licenseFileNames, strings.ToLower(file.Rel)) {
+			continue
+		}
+		if file.Size > MAX_INLINE_SIZE {
+			continue
+		}
+		data, err := os.ReadFile(file.Path)
+		if err != nil {
+			continue
+		}
+		findings = append(findings, LicenseFinding{File: file.Rel, License: matchLicense(string(data))})
+	}
+	return findings
+}
+
+// matchLicense - Compara el texto normalizado con las huellas conocidas
+func matchLicense(text string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(text), " "))
+	for _, fingerprint := range licenseFingerprints {
+		matched := true
+		for _, phrase := range fingerprint.phrases {
+			if !strings.Contains(normalized, phrase) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return fingerprint.spdx
+		}
+	}
+	return "unknown"
+}
+
+// scanSecrets - Busca posibles credenciales en los archivos de texto del recorrido
+func scanSecrets(ctx context.Context, walk *projectWalk, allow *scanAllowlist) ([]SecretFinding, int, error) {
+	findings := []SecretFinding{}
+	ignored := 0
+
+	for _, file := range walk.Files {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		if file.Size == 0 || file.Size > maxSecretScanSize || allow.ignoresFile("secrets", file.Path) {
+			continue
+		}
+		if !isTextFile(detectMimeType(file.Path)) {
+			continue
+		}
+		data, err := os.ReadFile(file.Path)
+		if err != nil {
+			continue
+		}
+		for i, line := range strings.Split(string(data), "\n") {
+			for _, pattern := range secretPatterns {
+				match := pattern.re.FindStringSubmatch(line)
+				if match == nil {
+					continue
+				}
+				value := match[0]
+				if len(match) > 1 && match[1] != "" {
+					value = match[1]
+				}
+				if pattern.minEntropy > 0 && shannonEntropy(value) < pattern.minEntropy {
+					continue
+				}
+				if allow.ignoresLine(file.Path, i+1) {
+					ignored++
+					continue
+				}
+				findings = append(findings, SecretFinding{
+					File:  file.Rel,
+					Line:  i + 1,
+					Rule:  pattern.rule,
+					Match: strings.Replace(match[0], value, maskSecret(value), 1),
+				})
+			}
+		}
+	}
+	return findings, ignored, nil
+}
+
+// shannonEntropy - Entropía en bits por carácter; los valores aleatorios superan ~3.5
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	if total == 0 {
+		return 0
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// maskSecret - Conserva solo el principio del valor para poder localizarlo
+func maskSecret(s string) string {
+	runes := []rune(s)
+	if len(runes) <= 8 {
+		return "****"
+	}
+	return string(runes[:4]) + "****"
+}
+
+// formatScanResult - Resumen legible con una sección por escáner
+func formatScanResult(result *ScanResult) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🔎 Scan: %s\n", result.Root))
+
+	var summary []string
+	for _, name := range result.Scanners {
+		switch name {
+		case "todos":
+			summary = append(summary, fmt.Sprintf("%d TODO/FIXME", result.Summary["todos"]))
+		case "license":
+			summary = append(summary, fmt.Sprintf("%d license files", result.Summary["license"]))
+		case "secrets":
+			summary = append(summary, fmt.Sprintf("%d potential secrets", result.Summary["secrets"]))
+		}
+	}
+	b.WriteString("📊 Summary: " + strings.Join(summary, ", "))
+	if result.Ignored > 0 {
+		b.WriteString(fmt.Sprintf(" (%d ignored via %s)", result.Ignored, scanIgnoreFile))
+	}
+	b.WriteString("\n")
+
+	for _, name := range result.Scanners {
+		switch name {
+		case "todos":
+			b.WriteString(fmt.Sprintf("\n📝 TODO/FIXME (%d):\n", len(result.Todos)))
+			if len(result.Todos) == 0 {
+				b.WriteString("  None found\n")
+			}
+			for _, todo := range result.Todos {
+				author := ""
+				if todo.Author != "" {
+					author = fmt.Sprintf(" [%s]", todo.Author)
+				}
+				b.WriteString(fmt.Sprintf("  • %s:%d %s%s %s\n", todo.File, todo.Line, todo.Marker, author, todo.Text))
+			}
+		case "license":
+			b.WriteString("\n⚖️ License:\n")
+			if len(result.Licenses) == 0 {
+				b.WriteString("  No LICENSE or COPYING file found\n")
+			}
+			for _, license := range result.Licenses {
+				b.WriteString(fmt.Sprintf("  • %s: %s\n", license.File, license.License))
+			}
+		case "secrets":
+			b.WriteString(fmt.Sprintf("\n🔐 Potential secrets (%d):\n", len(result.Secrets)))
+			if len(result.Secrets) == 0 {
+				b.WriteString("  None found\n")
+			}
+			for _, secret := range result.Secrets {
+				b.WriteString(fmt.Sprintf("  • %s:%d %s %s\n", secret.File, secret.Line, secret.Rule, secret.Match))
+			}
+		}
+	}
+
+	return b.String()
+}
@@ -3,23 +3,60 @@ package filesystemserver
 import (
 	"context"
 	"crypto/md5"
+	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // handleSmartSearch - Búsqueda inteligente con regex y filtros
 func (fs *FilesystemHandler) handleSmartSearch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	path, _ := request.Params.Arguments["path"].(string)
-	pattern, _ := request.Params.Arguments["pattern"].(string)
-	includeContent, _ := request.Params.Arguments["include_content"].(bool)
-	fileTypesParam, _ := request.Params.Arguments["file_types"].([]interface{})
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	pattern, err := getString(request.Params.Arguments, "pattern", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	includeContent, err := getBool(request.Params.Arguments, "include_content", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	excludeGenerated, err := getBool(request.Params.Arguments, "exclude_generated", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	extractDocuments, err := getBool(request.Params.Arguments, "extract_documents", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	fileTypes, err := getStringSlice(request.Params.Arguments, "file_types")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	contextLines, err := getInt(request.Params.Arguments, "context_lines", 3)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	contentOpts, err := parseSearchContentOptions(request.Params.Arguments, contextLines)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	// Incrustar contenido o extraer documentos requiere buscar dentro de los archivos
+	includeContent = includeContent || contentOpts.enabled || extractDocuments
 
 	if path == "" || pattern == "" {
 		return &mcp.CallToolResult{
@@ -46,15 +83,50 @@ func (fs *FilesystemHandler) handleSmartSearch(ctx context.Context, request mcp.
 		}, nil
 	}
 
-	// Convertir tipos de archivo
-	fileTypes := []string{}
-	for _, ft := range fileTypesParam {
-		if str, ok := ft.(string); ok {
-			fileTypes = append(fileTypes, str)
-		}
+	relativeTo, err := getString(request.Params.Arguments, "relative_to", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	display, err := fs.pathDisplay(relativeTo)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
 	}
 
-	results, err := fs.performSmartSearch(validPath, pattern, includeContent, fileTypes)
+	literal, err := literalArg(request.Params.Arguments)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	regexPattern, fallback, err := searchPattern(pattern, literal, nil)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	literalMode := ""
+	if literal != nil {
+		literalMode = fmt.Sprint(*literal)
+	}
+
+	hash := paramsHash("smart_search", validPath, pattern, fmt.Sprint(includeContent), strings.Join(fileTypes, ","), fmt.Sprint(excludeGenerated), fmt.Sprint(extractDocuments), literalMode)
+	page, err := newResultPage(validPath, request.Params.Arguments, hash)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	results, spill, err := fs.performSmartSearch(ctx, validPath, pattern, regexPattern, includeContent, fileTypes, excludeGenerated, newDocumentSearch(ctx, extractDocuments), display, page, contentOpts)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -67,26 +139,58 @@ func (fs *FilesystemHandler) handleSmartSearch(ctx context.Context, request mcp.
 		}, nil
 	}
 
+	// El patrón no era regex válido: se buscó literalmente y se dice
+	if fallback != "" {
+		results = fmt.Sprintf("⚠️ pattern was not valid regex (%s); searched literally. Pass literal: true to search literally on purpose, or literal: false to get an error instead\n\n", fallback) + results
+	}
+
 	return &mcp.CallToolResult{
-		Content: []mcp.Content{
+		Content: append([]mcp.Content{
 			mcp.TextContent{
 				Type: "text",
 				Text: results,
 			},
-		},
+		}, spill...),
 	}, nil
 }
 
 // handleAdvancedTextSearch - Búsqueda avanzada de texto con contexto
 func (fs *FilesystemHandler) handleAdvancedTextSearch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	path, _ := request.Params.Arguments["path"].(string)
-	pattern, _ := request.Params.Arguments["pattern"].(string)
-	caseSensitive, _ := request.Params.Arguments["case_sensitive"].(bool)
-	wholeWord, _ := request.Params.Arguments["whole_word"].(bool)
-	includeContext, _ := request.Params.Arguments["include_context"].(bool)
-	contextLines := 3
-	if cl, ok := request.Params.Arguments["context_lines"].(float64); ok {
-		contextLines = int(cl)
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	pattern, err := getString(request.Params.Arguments, "pattern", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	caseSensitive, err := getBool(request.Params.Arguments, "case_sensitive", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	wholeWord, err := getBool(request.Params.Arguments, "whole_word", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	includeContext, err := getBool(request.Params.Arguments, "include_context", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	extractDocuments, err := getBool(request.Params.Arguments, "extract_documents", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	contextLines, err := getInt(request.Params.Arguments, "context_lines", 3)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	contentOpts, err := parseSearchContentOptions(request.Params.Arguments, contextLines)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	literal, err := literalArg(request.Params.Arguments)
+	if err != nil {
+		return badArgError(err).result(), nil
 	}
 
 	if path == "" || pattern == "" {
@@ -108,7 +212,9 @@ func (fs *FilesystemHandler) handleAdvancedTextSearch(ctx context.Context, reque
 		}, nil
 	}
 
-	matches, err := fs.performAdvancedTextSearch(validPath, pattern, caseSensitive, wholeWord, includeContext, contextLines)
+	ctx, warnings := withWalkWarnings(ctx)
+	docs := newDocumentSearch(ctx, extractDocuments)
+	matches, err := fs.performAdvancedTextSearch(ctx, validPath, pattern, caseSensitive, wholeWord, includeContext, contextLines, literal, docs)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -119,57 +225,85 @@ func (fs *FilesystemHandler) handleAdvancedTextSearch(ctx context.Context, reque
 	}
 
 	if len(matches) == 0 {
+		var result strings.Builder
+		result.WriteString(fmt.Sprintf("🔍 No matches found for pattern '%s' in %s\n", pattern, path))
+		docs.writeSkipped(&result, func(p string) string { return p })
+		result.WriteString(warnings.note(nil))
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				mcp.TextContent{Type: "text", Text: fmt.Sprintf("🔍 No matches found for pattern '%s' in %s", pattern, path)},
+				mcp.TextContent{Type: "text", Text: strings.TrimSuffix(result.String(), "\n")},
 			},
 		}, nil
 	}
 
-	var result strings.Builder
+	result := fs.newResultBuilder()
 	result.WriteString(fmt.Sprintf("🔍 Found %d matches for pattern '%s':\n\n", len(matches), pattern))
 
 	for _, match := range matches {
-		result.WriteString(fmt.Sprintf("📁 %s:%d\n", match.File, match.LineNumber))
-		result.WriteString(fmt.Sprintf("   %s\n", match.Line))
+		var entry strings.Builder
+		entry.WriteString(fmt.Sprintf("📁 %s:%d\n", match.File, match.LineNumber))
+		entry.WriteString(fmt.Sprintf("   %s\n", match.Line))
 
 		if includeContext && len(match.Context) > 0 {
-			result.WriteString("   Context:\n")
+			entry.WriteString("   Context:\n")
 			for _, contextLine := range match.Context {
-				result.WriteString(fmt.Sprintf("   │ %s\n", contextLine))
+				entry.WriteString(fmt.Sprintf("   │ %s\n", contextLine))
 			}
 		}
-		result.WriteString("\n")
+		entry.WriteString("\n")
+		result.item(entry.String())
 	}
+	if contentOpts.enabled {
+		fs.writeMatchedContent(result, matches, contentOpts, docs, func(p string) string { return p })
+	}
+	docs.writeSkipped(result, func(p string) string { return p })
+	result.tail(warnings.note(nil))
+	text, spill := result.done(validPath, "advanced_text_search")
 
 	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{Type: "text", Text: result.String()},
-		},
+		Content: append([]mcp.Content{
+			mcp.TextContent{Type: "text", Text: text},
+		}, spill...),
 	}, nil
 }
 
 // performSmartSearch - Implementación de búsqueda inteligente
-func (fs *FilesystemHandler) performSmartSearch(path, pattern string, includeContent bool, fileTypes []string) (string, error) {
-	var results []string
+func (fs *FilesystemHandler) performSmartSearch(ctx context.Context, path, pattern string, regexPattern *regexp.Regexp, includeContent bool, fileTypes []string, excludeGenerated bool, docs *documentSearch, display func(string) string, page *resultPage, contentOpts searchContentOptions) (string, []mcp.Content, error) {
+	var nameMatches []string
 	var contentMatches []SearchMatch
 
-	// Compilar regex del patrón
-	regexPattern, err := regexp.Compile(pattern)
-	if err != nil {
-		// Si no es regex válido, usar búsqueda literal
-		regexPattern = regexp.MustCompile(regexp.QuoteMeta(pattern))
-	}
-
-	err = filepath.Walk(path, func(currentPath string, info os.FileInfo, err error) error {
+	ctx, warnings := withWalkWarnings(ctx)
+	err := fs.walkContext(ctx, path, func(currentPath string, info os.FileInfo, err error) error {
 		if err != nil {
+			fs.logWalkError(ctx, currentPath, err)
 			return nil // Continuar con otros archivos
 		}
+		if currentPath != path && fs.isDeniedPath(currentPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() && currentPath != path && isServerDataDir(info.Name()) {
+			return filepath.SkipDir
+		}
+		if currentPath != path && fs.mcpIgnored(ctx, currentPath, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 
 		// Validar path
 		if _, err := fs.validatePath(currentPath); err != nil {
 			return nil
 		}
+		if skip, skipDir := page.resumeSkip(currentPath, info.IsDir()); skip {
+			if skipDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 
 		// Filtrar por tipos de archivo si se especifican
 		if len(fileTypes) > 0 {
@@ -185,84 +319,138 @@ func (fs *FilesystemHandler) performSmartSearch(path, pattern string, includeCon
 				return nil
 			}
 		}
-
-		// Buscar en nombre de archivo
-		if regexPattern.MatchString(info.Name()) {
-			results = append(results, fmt.Sprintf("📄 %s (%s)", currentPath, pathToResourceURI(currentPath)))
+		if excludeGenerated && !info.IsDir() && fs.generatedReason(currentPath) != "" {
+			return nil
 		}
 
-		// Buscar en contenido si es archivo de texto y se solicita
-		if includeContent && !info.IsDir() && info.Size() < MAX_INLINE_SIZE {
-			mimeType := detectMimeType(currentPath)
-			if isTextFile(mimeType) {
-				content, err := os.ReadFile(currentPath)
+		// Buscar en nombre de archivo
+		nameMatch := regexPattern.MatchString(info.Name())
+		var fileMatches []SearchMatch
+
+		// Buscar en contenido si es archivo de texto (o documento extraído) y se solicita
+		var lines []string
+		if includeContent && !info.IsDir() {
+			if text, ok := docs.text(currentPath); ok {
+				lines = strings.Split(text, "\n")
+			} else if info.Size() < MAX_INLINE_SIZE && isTextFile(detectMimeType(currentPath)) {
+				content, err := fs.readFile(currentPath)
+				progressFrom(ctx).read(currentPath, int64(len(content)))
 				if err == nil {
-					lines := strings.Split(string(content), "\n")
-					for lineNum, line := range lines {
-						if regexPattern.MatchString(line) {
-							match := SearchMatch{
-								File:       currentPath,
-								LineNumber: lineNum + 1,
-								Line:       strings.TrimSpace(line),
-							}
-							contentMatches = append(contentMatches, match)
-						}
-					}
+					lines = strings.Split(stripBOM(string(content)), "\n")
 				}
 			}
 		}
+		for lineNum, line := range lines {
+			if regexPattern.MatchString(line) {
+				match := SearchMatch{
+					File:       currentPath,
+					LineNumber: lineNum + 1,
+					Line:       strings.TrimSpace(line),
+				}
+				fileMatches = append(fileMatches, match)
+			}
+		}
 
+		// La página cuenta archivos con coincidencias, nunca los parte
+		if nameMatch || len(fileMatches) > 0 {
+			if !page.add(currentPath) {
+				return errPageFull
+			}
+			if nameMatch {
+				nameMatches = append(nameMatches, currentPath)
+			}
+			contentMatches = append(contentMatches, fileMatches...)
+		}
 		return nil
 	})
 
-	if err != nil {
-		return "", err
+	// Si se agotó el timeout se devuelve lo encontrado hasta entonces
+	timeout, timedOut := callTimedOut(ctx)
+	partial := timedOut && (len(nameMatches) > 0 || len(contentMatches) > 0)
+	if err != nil && !errors.Is(err, errPageFull) && !partial {
+		return "", nil, err
 	}
 
-	var resultBuilder strings.Builder
+	// Orden del recorrido; dentro de un archivo, las líneas en orden
+	sortWalkOrder(path, nameMatches)
+	order := &resultPage{root: path}
+	sort.SliceStable(contentMatches, func(i, j int) bool {
+		return walkBefore(order.rel(contentMatches[i].File), order.rel(contentMatches[j].File))
+	})
+
+	// Las dos secciones no se pueden reanudar con el cursor: si no caben, el
+	// resultado completo va a un informe
+	out := fs.newResultBuilder()
+	if partial {
+		out.WriteString(timedOutNote(timeout))
+	}
 
-	if len(results) > 0 {
-		resultBuilder.WriteString(fmt.Sprintf("🔍 File name matches (%d):\n", len(results)))
-		for _, result := range results {
-			resultBuilder.WriteString(fmt.Sprintf("  %s\n", result))
+	if len(nameMatches) > 0 {
+		out.WriteString(fmt.Sprintf("🔍 File name matches (%d):\n", len(nameMatches)))
+		for _, match := range nameMatches {
+			out.item(fmt.Sprintf("  📄 %s (%s)\n", safeDisplayName(display(match)), pathToResourceURI(match)))
 		}
-		resultBuilder.WriteString("\n")
+		out.WriteString("\n")
 	}
 
 	if len(contentMatches) > 0 {
-		resultBuilder.WriteString(fmt.Sprintf("📝 Content matches (%d):\n", len(contentMatches)))
+		out.WriteString(fmt.Sprintf("📝 Content matches (%d):\n", len(contentMatches)))
 		for _, match := range contentMatches {
-			resultBuilder.WriteString(fmt.Sprintf("  📁 %s:%d - %s\n", match.File, match.LineNumber, match.Line))
+			out.item(fmt.Sprintf("  📁 %s:%d - %s\n", safeDisplayName(display(match.File)), match.LineNumber, match.Line))
+		}
+		if contentOpts.enabled {
+			fs.writeMatchedContent(out, contentMatches, contentOpts, docs, display)
 		}
 	}
 
-	if len(results) == 0 && len(contentMatches) == 0 {
-		return fmt.Sprintf("🔍 No matches found for pattern '%s' in %s", pattern, path), nil
+	if len(nameMatches) == 0 && len(contentMatches) == 0 {
+		out.WriteString(fmt.Sprintf("🔍 No matches found for pattern '%s' in %s\n", pattern, path))
+		docs.writeSkipped(out, display)
+		out.tail(warnings.note(display))
+		text, spill := out.done(path, "smart_search")
+		return strings.TrimSuffix(text, "\n"), spill, nil
 	}
 
-	return resultBuilder.String(), nil
+	docs.writeSkipped(out, display)
+	out.tail(warnings.note(display))
+	out.tail(page.footer())
+	text, spill := out.done(path, "smart_search")
+	return text, spill, nil
 }
 
 // performAdvancedTextSearch - Implementación de búsqueda avanzada de texto
-func (fs *FilesystemHandler) performAdvancedTextSearch(path, pattern string, caseSensitive, wholeWord, includeContext bool, contextLines int) ([]SearchMatch, error) {
+func (fs *FilesystemHandler) performAdvancedTextSearch(ctx context.Context, path, pattern string, caseSensitive, wholeWord, includeContext bool, contextLines int, literal *bool, docs *documentSearch) ([]SearchMatch, error) {
 	var matches []SearchMatch
 
-	// Preparar el patrón
-	searchPattern := pattern
-	if !caseSensitive {
-		searchPattern = "(?i)" + searchPattern
-	}
-	if wholeWord {
-		searchPattern = `\b` + searchPattern + `\b`
+	// Preparar el patrón; sin literal un regex inválido es un error
+	if literal == nil {
+		literal = new(bool)
 	}
-
-	regexPattern, err := regexp.Compile(searchPattern)
+	regexPattern, _, err := searchPattern(pattern, literal, func(expr string) string {
+		if !caseSensitive {
+			expr = "(?i)" + expr
+		}
+		if wholeWord {
+			expr = `\b` + expr + `\b`
+		}
+		return expr
+	})
 	if err != nil {
-		return nil, fmt.Errorf("invalid regex pattern: %v", err)
+		return nil, err
 	}
 
-	err = filepath.Walk(path, func(currentPath string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() {
+	err = fs.walkContext(ctx, path, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			fs.logWalkError(ctx, currentPath, err)
+			return nil
+		}
+		if info.IsDir() {
+			if currentPath != path && (isServerDataDir(info.Name()) || fs.isDeniedPath(currentPath) || fs.mcpIgnored(ctx, currentPath, true)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if fs.mcpIgnored(ctx, currentPath, false) {
 			return nil
 		}
 
@@ -271,18 +459,22 @@ func (fs *FilesystemHandler) performAdvancedTextSearch(path, pattern string, cas
 			return nil
 		}
 
-		// Solo buscar en archivos de texto
-		mimeType := detectMimeType(currentPath)
-		if !isTextFile(mimeType) || info.Size() > MAX_INLINE_SIZE {
-			return nil
-		}
+		// Solo buscar en archivos de texto y documentos extraídos
+		text, isDocument := docs.text(currentPath)
+		if !isDocument {
+			mimeType := detectMimeType(currentPath)
+			if !isTextFile(mimeType) || info.Size() > MAX_INLINE_SIZE {
+				return nil
+			}
 
-		content, err := os.ReadFile(currentPath)
-		if err != nil {
-			return nil
+			content, err := fs.readFile(currentPath)
+			if err != nil {
+				return nil
+			}
+			text = stripBOM(string(content))
 		}
 
-		lines := strings.Split(string(content), "\n")
+		lines := strings.Split(text, "\n")
 		for lineNum, line := range lines {
 			if regexPattern.MatchString(line) {
 				match := SearchMatch{
@@ -332,7 +524,22 @@ func minInt(a, b int) int {
 
 // handleFindDuplicates - Encuentra archivos duplicados por hash
 func (fs *FilesystemHandler) handleFindDuplicates(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	path, _ := request.Params.Arguments["path"].(string)
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	action, err := getString(request.Params.Arguments, "action", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	dryRun, err := getBool(request.Params.Arguments, "dry_run", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	output, err := getString(request.Params.Arguments, "output", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
 	if path == "" {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -345,6 +552,23 @@ func (fs *FilesystemHandler) handleFindDuplicates(ctx context.Context, request m
 		}, nil
 	}
 
+	if action == "" {
+		action = "report"
+	}
+	switch action {
+	case "report", "delete_newest", "delete_oldest", "hardlink":
+	default:
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("❌ Error: unsupported action '%s' (use 'report', 'delete_newest', 'delete_oldest' or 'hardlink')", action),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
 	validPath, err := fs.validatePath(path)
 	if err != nil {
 		return &mcp.CallToolResult{
@@ -358,7 +582,31 @@ func (fs *FilesystemHandler) handleFindDuplicates(ctx context.Context, request m
 		}, nil
 	}
 
-	duplicates, err := fs.findDuplicateFiles(validPath)
+	opts := duplicateOptions{
+		MinSize: 1,
+		MaxSize: 100 * 1024 * 1024,
+	}
+	minSize, err := getNumber(request.Params.Arguments, "min_size", -1)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	maxSize, err := getNumber(request.Params.Arguments, "max_size", -1)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if minSize >= 0 {
+		opts.MinSize = int64(minSize)
+	}
+	if maxSize >= 0 {
+		opts.MaxSize = int64(maxSize)
+	}
+	if opts.ExcludePatterns, err = getNonEmptyStrings(request.Params.Arguments, "exclude_patterns"); err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	var stats duplicateStats
+	ctx, warnings := withWalkWarnings(ctx)
+	duplicates, err := fs.findDuplicateFiles(ctx, validPath, opts, &stats)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -371,48 +619,318 @@ func (fs *FilesystemHandler) handleFindDuplicates(ctx context.Context, request m
 		}, nil
 	}
 
-	if len(duplicates) == 0 {
+	groups := sortedDuplicateGroups(duplicates)
+	var actions []DuplicateAction
+	var reclaimed int64
+	if action != "report" {
+		actions, reclaimed = fs.applyDuplicateAction(groups, action, dryRun)
+	}
+
+	report := DuplicateReport{Groups: groups, Action: action, DryRun: dryRun, Actions: actions, Reclaimed: reclaimed, Warnings: warnings.entries()}
+	summary := func() string { return formatDuplicateSummary(&report, stats) + warnings.note(nil) }
+	if output == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if spilled := fs.oversizedResult(validPath, "find_duplicates", len(data), data, "json", summary); spilled != nil {
+			return spilled, nil
+		}
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				mcp.TextContent{Type: "text", Text: "✅ No duplicate files found"},
+				mcp.TextContent{Type: "text", Text: string(data)},
 			},
 		}, nil
 	}
 
-	var result strings.Builder
-	result.WriteString(fmt.Sprintf("🔍 Found %d groups of duplicate files:\n\n", len(duplicates)))
+	if len(groups) == 0 {
+		return withStructuredContent(&mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "✅ No duplicate files found" + strings.TrimSuffix(warnings.note(nil), "\n")},
+			},
+		}, pathToResourceURI(validPath), report)
+	}
+
+	// Cada grupo y cada acción es un elemento; los totales se muestran siempre
+	result := fs.newResultBuilder()
+	result.WriteString(fmt.Sprintf("🔍 Found %d groups of duplicate files:\n\n", len(groups)))
 
 	totalWastedSpace := int64(0)
-	for hash, files := range duplicates {
-		if len(files) > 1 {
-			result.WriteString(fmt.Sprintf("📋 Hash: %s\n", hash[:16]+"..."))
-			result.WriteString(fmt.Sprintf("   Size: %d bytes each\n", files[0].Size))
-			result.WriteString(fmt.Sprintf("   Wasted space: %d bytes\n", files[0].Size*int64(len(files)-1)))
-			totalWastedSpace += files[0].Size * int64(len(files)-1)
-			
-			for _, file := range files {
-				result.WriteString(fmt.Sprintf("   📄 %s\n", file.Path))
-			}
-			result.WriteString("\n")
+	for _, files := range groups {
+		var group strings.Builder
+		hash := files[0].Hash
+		group.WriteString(fmt.Sprintf("📋 Hash: %s\n", hash[:16]+"..."))
+		group.WriteString(fmt.Sprintf("   Size: %d bytes each\n", files[0].Size))
+		group.WriteString(fmt.Sprintf("   Wasted space: %d bytes\n", files[0].Size*int64(len(files)-1)))
+		totalWastedSpace += files[0].Size * int64(len(files)-1)
+
+		for _, file := range files {
+			group.WriteString(fmt.Sprintf("   📄 %s\n", file.Path))
 		}
+		group.WriteString("\n")
+		result.item(group.String())
 	}
 
-	result.WriteString(fmt.Sprintf("💾 Total wasted space: %d bytes (%.2f MB)\n", 
+	result.tail(fmt.Sprintf("💾 Total wasted space: %d bytes (%.2f MB)\n",
 		totalWastedSpace, float64(totalWastedSpace)/(1024*1024)))
+	result.tail(fmt.Sprintf("📊 Scanned %d files (%d partial hashes, %d full hashes)\n",
+		stats.Scanned, stats.PartialHashes, stats.FullHashes))
+
+	if action != "report" {
+		if dryRun {
+			result.WriteString(fmt.Sprintf("\n🧪 Dry run: %s (no files changed)\n", action))
+		} else {
+			result.WriteString(fmt.Sprintf("\n🛠️ Action: %s\n", action))
+		}
+		for _, a := range actions {
+			switch a.Action {
+			case "delete":
+				result.item(fmt.Sprintf("   🗑️ %s (kept %s)\n", a.Path, a.Kept))
+			case "hardlink":
+				result.item(fmt.Sprintf("   🔗 %s → %s\n", a.Path, a.Kept))
+			default:
+				result.item(fmt.Sprintf("   ⏭️ %s: %s\n", a.Path, a.Note))
+			}
+		}
+		result.tail(fmt.Sprintf("♻️ Reclaimed: %d bytes (%.2f MB)\n", reclaimed, float64(reclaimed)/(1024*1024)))
+	}
+	result.tail(warnings.note(nil))
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{Type: "text", Text: result.String()},
-		},
-	}, nil
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if spilled := fs.oversizedResult(validPath, "find_duplicates", result.size()+len(data), data, "json", summary); spilled != nil {
+		return spilled, nil
+	}
+	text, spill := result.done(validPath, "find_duplicates")
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: append([]mcp.Content{
+			mcp.TextContent{Type: "text", Text: text},
+		}, spill...),
+	}, pathToResourceURI(validPath), report)
+}
+
+// formatDuplicateSummary - Resumen de find_duplicates cuando el resultado completo va a un informe:
+// totales y los 10 grupos que más espacio desperdician
+func formatDuplicateSummary(report *DuplicateReport, stats duplicateStats) string {
+	wasted := func(group []DuplicateFile) int64 { return group[0].Size * int64(len(group)-1) }
+	groups := append([][]DuplicateFile(nil), report.Groups...)
+	var total int64
+	for _, group := range groups {
+		total += wasted(group)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return wasted(groups[i]) > wasted(groups[j]) })
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "🔍 Found %d groups of duplicate files\n", len(groups))
+	fmt.Fprintf(&b, "💾 Total wasted space: %d bytes (%.2f MB)\n", total, float64(total)/(1024*1024))
+	fmt.Fprintf(&b, "📊 Scanned %d files (%d partial hashes, %d full hashes)\n", stats.Scanned, stats.PartialHashes, stats.FullHashes)
+	if len(groups) > 10 {
+		fmt.Fprintf(&b, "\n🏆 Top 10 groups by wasted space:\n")
+		groups = groups[:10]
+	} else {
+		fmt.Fprintf(&b, "\n🏆 Groups by wasted space:\n")
+	}
+	for _, group := range groups {
+		fmt.Fprintf(&b, "  • %d × %d bytes, %d wasted: %s (+%d more)\n", len(group), group[0].Size, wasted(group), group[0].Path, len(group)-1)
+	}
+	if report.Action != "report" {
+		mode := "Action"
+		if report.DryRun {
+			mode = "Dry run"
+		}
+		fmt.Fprintf(&b, "\n🛠️ %s: %s, %d files, %d bytes reclaimed\n", mode, report.Action, len(report.Actions), report.Reclaimed)
+	}
+	return b.String()
+}
+
+// sortedDuplicateGroups - Devuelve los grupos de duplicados en orden estable
+func sortedDuplicateGroups(duplicates map[string][]DuplicateFile) [][]DuplicateFile {
+	groups := make([][]DuplicateFile, 0, len(duplicates))
+	for _, files := range duplicates {
+		groups = append(groups, files)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0].Path < groups[j][0].Path })
+	return groups
 }
 
-// findDuplicateFiles - Busca archivos duplicados por contenido (hash MD5)
-func (fs *FilesystemHandler) findDuplicateFiles(path string) (map[string][]DuplicateFile, error) {
-	hashMap := make(map[string][]DuplicateFile)
+// applyDuplicateAction - Conserva una copia por grupo y elimina o enlaza el
+// resto. delete_newest y hardlink conservan la copia más antigua;
+// delete_oldest conserva la más reciente. Un grupo cuya copia conservada
+// cambió desde el escaneo se omite entero. Devuelve los bytes recuperados.
+func (fs *FilesystemHandler) applyDuplicateAction(groups [][]DuplicateFile, action string, dryRun bool) ([]DuplicateAction, int64) {
+	var actions []DuplicateAction
+	var reclaimed int64
+
+	for _, group := range groups {
+		files := append([]DuplicateFile(nil), group...)
+		sort.SliceStable(files, func(i, j int) bool {
+			if action == "delete_oldest" {
+				return files[i].ModTime.After(files[j].ModTime)
+			}
+			return files[i].ModTime.Before(files[j].ModTime)
+		})
+		kept := files[0]
+		keptNote := ""
+
+		for _, file := range files[1:] {
+			entry := DuplicateAction{Path: file.Path, Kept: kept.Path, Bytes: file.Size}
+			// Si la copia conservada cambió o ya no está, las demás pueden ser
+			// las últimas con ese contenido: el resto del grupo no se toca
+			if keptNote == "" {
+				if note := fs.revalidateDuplicate(kept); note != "" {
+					keptNote = "kept copy: " + note
+				}
+			}
+			if keptNote != "" {
+				entry.Action, entry.Note, entry.Bytes = "skip", keptNote, 0
+				actions = append(actions, entry)
+				continue
+			}
+			if note := fs.revalidateDuplicate(file); note != "" {
+				entry.Action, entry.Note, entry.Bytes = "skip", note, 0
+				actions = append(actions, entry)
+				continue
+			}
+
+			switch action {
+			case "hardlink":
+				entry.Action = "hardlink"
+				if note := checkHardlink(kept.Path, file.Path); note != "" {
+					entry.Action, entry.Note, entry.Bytes = "skip", note, 0
+				} else if !dryRun {
+					if err := fs.replaceWithHardlink(kept.Path, file.Path); err != nil {
+						entry.Action, entry.Note, entry.Bytes = "skip", err.Error(), 0
+					}
+				}
+			default:
+				entry.Action = "delete"
+				if !dryRun {
+					if err := fs.removeChecked(file.Path, false); err != nil {
+						entry.Action, entry.Note, entry.Bytes = "skip", err.Error(), 0
+					}
+				}
+			}
 
-	err := filepath.Walk(path, func(currentPath string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() {
+			reclaimed += entry.Bytes
+			actions = append(actions, entry)
+		}
+	}
+
+	return actions, reclaimed
+}
+
+// revalidateDuplicate - Comprueba justo antes de modificar que el archivo sigue
+// permitido y sin cambios desde el escaneo; devuelve el motivo si no es así
+func (fs *FilesystemHandler) revalidateDuplicate(file DuplicateFile) string {
+	if _, err := fs.validatePath(file.Path); err != nil {
+		return err.Error()
+	}
+	info, err := os.Lstat(file.Path)
+	if err != nil {
+		return err.Error()
+	}
+	if !info.Mode().IsRegular() || info.Size() != file.Size || !info.ModTime().Equal(file.ModTime) {
+		return "file changed since scan"
+	}
+	return ""
+}
+
+// checkHardlink - Verifica que dup pueda sustituirse por un enlace a kept
+func checkHardlink(kept, dup string) string {
+	keptInfo, err := os.Stat(kept)
+	if err != nil {
+		return err.Error()
+	}
+	dupInfo, err := os.Stat(dup)
+	if err != nil {
+		return err.Error()
+	}
+	if os.SameFile(keptInfo, dupInfo) {
+		return "already hard linked"
+	}
+	keptDev, ok1 := fileDevice(keptInfo)
+	dupDev, ok2 := fileDevice(dupInfo)
+	if !ok1 || !ok2 {
+		return "hard links not supported on this platform"
+	}
+	if keptDev != dupDev {
+		return "files are on different devices"
+	}
+	return ""
+}
+
+// replaceWithHardlink - Sustituye dup por un enlace duro a kept de forma atómica,
+// comprobando de nuevo ambas rutas antes de enlazar y de renombrar
+func (fs *FilesystemHandler) replaceWithHardlink(kept, dup string) error {
+	if err := fs.recheckPath(kept); err != nil {
+		return err
+	}
+	tempPath := fmt.Sprintf("%s.link-%d", dup, os.Getpid())
+	if err := fs.recheckPath(tempPath); err != nil {
+		return err
+	}
+	if err := os.Link(kept, tempPath); err != nil {
+		return err
+	}
+	if err := fs.renameChecked(tempPath, dup); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
+// partialHashSize - Bytes iniciales usados para descartar candidatos a duplicado
+const partialHashSize = 64 * 1024
+
+// duplicateOptions - Filtros y límites para la búsqueda de duplicados
+type duplicateOptions struct {
+	MinSize         int64
+	MaxSize         int64
+	ExcludePatterns []string
+	Workers         int
+}
+
+// duplicateStats - Contadores de trabajo realizado durante la búsqueda
+type duplicateStats struct {
+	PartialHashes int64 // actualizados de forma atómica por los workers
+	FullHashes    int64
+	Scanned       int
+}
+
+// findDuplicateFiles - Busca archivos duplicados agrupando por tamaño, hash
+// parcial (primeros 64KB) y finalmente hash SHA256 completo
+func (fs *FilesystemHandler) findDuplicateFiles(ctx context.Context, path string, opts duplicateOptions, stats *duplicateStats) (map[string][]DuplicateFile, error) {
+	if stats == nil {
+		stats = &duplicateStats{}
+	}
+	bySize := make(map[int64][]DuplicateFile)
+
+	err := fs.walkContext(ctx, path, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			fs.logWalkError(ctx, currentPath, err)
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if currentPath != path && info.IsDir() && isServerDataDir(info.Name()) {
+			return filepath.SkipDir
+		}
+		if currentPath != path && fs.isDeniedPath(currentPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if currentPath != path && (fs.mcpIgnored(ctx, currentPath, info.IsDir()) || matchesAnyPattern(path, currentPath, opts.ExcludePatterns)) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
 			return nil
 		}
 
@@ -421,34 +939,105 @@ func (fs *FilesystemHandler) findDuplicateFiles(path string) (map[string][]Dupli
 			return nil
 		}
 
-		// Solo archivos menores a 100MB para eficiencia
-		if info.Size() > 100*1024*1024 {
+		if info.Size() < opts.MinSize || (opts.MaxSize > 0 && info.Size() > opts.MaxSize) {
 			return nil
 		}
 
-		hash, err := calculateFileMD5(currentPath)
-		if err != nil {
-			return nil // Continuar con otros archivos
+		stats.Scanned++
+		bySize[info.Size()] = append(bySize[info.Size()], DuplicateFile{
+			Path:    currentPath,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return groupDuplicateCandidates(ctx, bySize, opts, stats)
+}
+
+// groupDuplicateCandidates - Confirma duplicados entre archivos agrupados por
+// tamaño: hash parcial y luego hash completo solo de los que siguen coincidiendo
+func groupDuplicateCandidates(ctx context.Context, bySize map[int64][]DuplicateFile, opts duplicateOptions, stats *duplicateStats) (map[string][]DuplicateFile, error) {
+	if stats == nil {
+		stats = &duplicateStats{}
+	}
+
+	// Hash parcial solo para archivos que comparten tamaño
+	progress := progressFrom(ctx)
+	var candidates []string
+	sizes := make(map[string]int64)
+	for size, files := range bySize {
+		if len(files) > 1 {
+			for _, file := range files {
+				candidates = append(candidates, file.Path)
+				sizes[file.Path] = size
+			}
 		}
+	}
+	partial, err := hashFilesParallel(ctx, candidates, opts.Workers, func(p string) (string, error) {
+		atomic.AddInt64(&stats.PartialHashes, 1)
+		progress.read(p, min(sizes[p], partialHashSize))
+		return calculatePartialSHA256(p, partialHashSize)
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		duplicate := DuplicateFile{
-			Path: currentPath,
-			Hash: hash,
-			Size: info.Size(),
+	// Hash completo solo para los que siguen coincidiendo; si el archivo cabe
+	// entero en el hash parcial, ese hash ya es el definitivo
+	groups := make(map[string][]DuplicateFile)
+	var survivors []DuplicateFile
+	for size, files := range bySize {
+		byPartial := make(map[string][]DuplicateFile)
+		for _, file := range files {
+			if h, ok := partial[file.Path]; ok {
+				byPartial[h] = append(byPartial[h], file)
+			}
+		}
+		for h, same := range byPartial {
+			if len(same) < 2 {
+				continue
+			}
+			if size <= partialHashSize {
+				for _, file := range same {
+					file.Hash = h
+					groups[h] = append(groups[h], file)
+				}
+				continue
+			}
+			survivors = append(survivors, same...)
 		}
+	}
 
-		hashMap[hash] = append(hashMap[hash], duplicate)
-		return nil
+	survivorPaths := make([]string, len(survivors))
+	for i, file := range survivors {
+		survivorPaths[i] = file.Path
+	}
+	full, err := hashFilesParallel(ctx, survivorPaths, opts.Workers, func(p string) (string, error) {
+		atomic.AddInt64(&stats.FullHashes, 1)
+		progress.read(p, sizes[p])
+		return calculateFileSHA256(p)
 	})
-
 	if err != nil {
 		return nil, err
 	}
+	for _, file := range survivors {
+		h, ok := full[file.Path]
+		if !ok {
+			continue
+		}
+		file.Hash = h
+		groups[h] = append(groups[h], file)
+	}
 
 	// Filtrar solo los que tienen duplicados
 	duplicates := make(map[string][]DuplicateFile)
-	for hash, files := range hashMap {
+	for hash, files := range groups {
 		if len(files) > 1 {
+			sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
 			duplicates[hash] = files
 		}
 	}
@@ -456,6 +1045,98 @@ func (fs *FilesystemHandler) findDuplicateFiles(path string) (map[string][]Dupli
 	return duplicates, nil
 }
 
+// hashFilesParallel - Calcula hashes con un pool acotado de workers; los
+// archivos que no se pueden leer se omiten del resultado
+func hashFilesParallel(ctx context.Context, paths []string, workers int, hashFn func(string) (string, error)) (map[string]string, error) {
+	results := make(map[string]string, len(paths))
+	if len(paths) == 0 {
+		return results, nil
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+
+	jobs := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				h, err := hashFn(p)
+				if err != nil {
+					walkWarningsFrom(ctx).add(p, err)
+					continue // Continuar con otros archivos
+				}
+				mu.Lock()
+				results[p] = h
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var err error
+feed:
+	for _, p := range paths {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		case jobs <- p:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// calculatePartialSHA256 - Calcula SHA256 de los primeros limit bytes de un archivo
+func calculatePartialSHA256(filePath string, limit int64) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.LimitReader(file, limit)); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// matchesAnyPattern - Comprueba un path contra patrones glob, tanto por nombre
+// como por ruta relativa a root
+func matchesAnyPattern(root, path string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	name := filepath.Base(path)
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(filepath.ToSlash(pattern), rel); matched {
+			return true
+		}
+	}
+	return false
+}
+
 // calculateFileMD5 - Calcula hash MD5 de un archivo
 func calculateFileMD5(filePath string) (string, error) {
 	file, err := os.Open(filePath)
@@ -471,3 +1152,36 @@ func calculateFileMD5(filePath string) (string, error) {
 
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
+
+// calculateFileHashes - Calcula SHA256 y MD5 de un archivo en una sola lectura
+func calculateFileHashes(filePath string) (string, string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	sha := sha256.New()
+	sum := md5.New()
+	if _, err := io.Copy(io.MultiWriter(sha, sum), file); err != nil {
+		return "", "", err
+	}
+
+	return hex.EncodeToString(sha.Sum(nil)), hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// calculateFileSHA256 - Calcula hash SHA256 de un archivo
+func calculateFileSHA256(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,159 @@
+package filesystemserver
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	// defaultContentLimitPerFile, maxContentLimitPerFile - Bytes de contenido por archivo
+	defaultContentLimitPerFile = 4000
+	maxContentLimitPerFile     = 20000
+	// defaultMaxFilesWithContent, maxMaxFilesWithContent - Archivos con contenido incrustado
+	defaultMaxFilesWithContent = 5
+	maxMaxFilesWithContent     = 20
+	// maxEmbeddedContentTotal - Presupuesto total de contenido incrustado por respuesta
+	maxEmbeddedContentTotal = 60000
+)
+
+// searchContentOptions - Opciones de include_file_content en las búsquedas
+type searchContentOptions struct {
+	enabled      bool
+	contextLines int
+	perFile      int
+	maxFiles     int
+}
+
+// lineWindow - Rango de líneas [start, end) de un archivo, base 0
+type lineWindow struct {
+	start, end int
+}
+
+// parseSearchContentOptions - Lee include_file_content, content_limit_per_file y max_files_with_content
+func parseSearchContentOptions(args map[string]interface{}, contextLines int) (searchContentOptions, error) {
+	opts := searchContentOptions{
+		contextLines: max(contextLines, 0),
+		perFile:      defaultContentLimitPerFile,
+		maxFiles:     defaultMaxFilesWithContent,
+	}
+	var err error
+	if opts.enabled, err = getBool(args, "include_file_content", false); err != nil {
+		return opts, err
+	}
+	perFile, err := getInt(args, "content_limit_per_file", 0)
+	if err != nil {
+		return opts, err
+	}
+	maxFiles, err := getInt(args, "max_files_with_content", 0)
+	if err != nil {
+		return opts, err
+	}
+	if perFile > 0 {
+		opts.perFile = min(perFile, maxContentLimitPerFile)
+	}
+	if maxFiles > 0 {
+		opts.maxFiles = min(maxFiles, maxMaxFilesWithContent)
+	}
+	return opts, nil
+}
+
+// mergeLineWindows - Amplía cada línea coincidente (base 1) con contexto y une
+// las ventanas que se solapan o se tocan
+func mergeLineWindows(matchLines []int, contextLines, totalLines int) []lineWindow {
+	sorted := append([]int(nil), matchLines...)
+	sort.Ints(sorted)
+
+	var windows []lineWindow
+	for _, line := range sorted {
+		w := lineWindow{start: max(line-1-contextLines, 0), end: min(line+contextLines, totalLines)}
+		if w.start >= w.end {
+			continue
+		}
+		if n := len(windows); n > 0 && w.start <= windows[n-1].end {
+			windows[n-1].end = max(windows[n-1].end, w.end)
+			continue
+		}
+		windows = append(windows, w)
+	}
+	return windows
+}
+
+// renderLineWindows - Ventanas numeradas, marcando las líneas coincidentes, hasta
+// limit bytes; indica si hubo que cortar
+func renderLineWindows(lines []string, windows []lineWindow, matched map[int]bool, limit int) (string, bool) {
+	var b strings.Builder
+	for i, w := range windows {
+		if i > 0 {
+			if b.Len()+len("      ┆\n") > limit {
+				return b.String(), true
+			}
+			b.WriteString("      ┆\n")
+		}
+		for n := w.start; n < w.end; n++ {
+			marker := " "
+			if matched[n+1] {
+				marker = "▶"
+			}
+			line := fmt.Sprintf("%s%5d│ %s\n", marker, n+1, strings.TrimSuffix(lines[n], "\r"))
+			if b.Len()+len(line) > limit {
+				return b.String(), true
+			}
+			b.WriteString(line)
+		}
+	}
+	return b.String(), false
+}
+
+// writeMatchedContent - Añade las regiones coincidentes de los archivos con más
+// coincidencias, respetando los presupuestos por archivo y total; los documentos
+// se vuelven a extraer con docs
+func (fs *FilesystemHandler) writeMatchedContent(b io.Writer, matches []SearchMatch, opts searchContentOptions, docs *documentSearch, display func(string) string) {
+	var files []string
+	lineNums := make(map[string][]int)
+	for _, match := range matches {
+		if _, ok := lineNums[match.File]; !ok {
+			files = append(files, match.File)
+		}
+		lineNums[match.File] = append(lineNums[match.File], match.LineNumber)
+	}
+	if len(files) == 0 {
+		return
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return len(lineNums[files[i]]) > len(lineNums[files[j]])
+	})
+
+	shown := min(len(files), opts.maxFiles)
+	fmt.Fprintf(b, "\n📖 Matched regions (%d of %d file(s)):\n", shown, len(files))
+	budget := maxEmbeddedContentTotal
+	for i, file := range files[:shown] {
+		if budget <= 0 {
+			fmt.Fprintf(b, "\n⚠️ Content budget of %d bytes reached; %d file(s) not shown\n", maxEmbeddedContentTotal, shown-i)
+			break
+		}
+		fmt.Fprintf(b, "\n── %s (%d match(es)) ──\n", safeDisplayName(display(file)), len(lineNums[file]))
+		text, isDocument := docs.text(file)
+		if !isDocument {
+			content, err := fs.readFile(file)
+			if err != nil {
+				fmt.Fprintf(b, "  ❌ %v\n", err)
+				continue
+			}
+			text = stripBOM(string(content))
+		}
+		lines := strings.Split(text, "\n")
+		matched := make(map[int]bool, len(lineNums[file]))
+		for _, n := range lineNums[file] {
+			matched[n] = true
+		}
+		limit := min(opts.perFile, budget)
+		text, truncated := renderLineWindows(lines, mergeLineWindows(lineNums[file], opts.contextLines, len(lines)), matched, limit)
+		io.WriteString(b, text)
+		budget -= len(text)
+		if truncated {
+			fmt.Fprintf(b, "  ✂️ Truncated at %d bytes\n", limit)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDuplicatesUniqueSizesSkipHashing(t *testing.T) {
+	handler, dir := newTestHandler(t)
+
+	for i := 1; i <= 10000; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("f%05d.bin", i))
+		f, err := os.Create(path)
+		require.NoError(t, err)
+		require.NoError(t, f.Truncate(int64(i)))
+		require.NoError(t, f.Close())
+	}
+
+	var stats duplicateStats
+	duplicates, err := handler.findDuplicateFiles(context.Background(), dir, duplicateOptions{MinSize: 1}, &stats)
+	require.NoError(t, err)
+	assert.Empty(t, duplicates)
+	assert.Equal(t, 10000, stats.Scanned)
+	assert.Zero(t, stats.PartialHashes)
+	assert.Zero(t, stats.FullHashes)
+}
+
+func TestFindDuplicatesPartialHashPrefilter(t *testing.T) {
+	handler, dir := newTestHandler(t)
+
+	big := strings.Repeat("a", partialHashSize*2)
+	files := map[string]string{
+		"small1.txt":      "hello",
+		"small2.txt":      "hello",
+		"small3.txt":      "world",
+		"big1.dat":        big,
+		"big2.dat":        big,
+		"other.dat":       strings.Repeat("b", partialHashSize*2),
+		"tail.dat":        big[:len(big)-1] + "z",
+		"empty1.txt":      "",
+		"empty2.txt":      "",
+		"skip/small4.txt": "hello",
+	}
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	var stats duplicateStats
+	duplicates, err := handler.findDuplicateFiles(context.Background(), dir, duplicateOptions{
+		MinSize:         1,
+		ExcludePatterns: []string{"skip"},
+		Workers:         2,
+	}, &stats)
+	require.NoError(t, err)
+	require.Len(t, duplicates, 2)
+
+	var groups [][]string
+	for _, group := range duplicates {
+		var names []string
+		for _, file := range group {
+			names = append(names, filepath.Base(file.Path))
+		}
+		groups = append(groups, names)
+	}
+	assert.ElementsMatch(t, [][]string{{"small1.txt", "small2.txt"}, {"big1.dat", "big2.dat"}}, groups)
+
+	// other.dat se descarta con el hash parcial; tail.dat necesita el completo
+	assert.Equal(t, int64(7), stats.PartialHashes)
+	assert.Equal(t, int64(3), stats.FullHashes)
+}
+
+func TestFindDuplicatesHonorsContext(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("x"), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := handler.findDuplicateFiles(ctx, dir, duplicateOptions{MinSize: 1}, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestFindDuplicatesActions(t *testing.T) {
+	setup := func(t *testing.T) (*FilesystemHandler, string, []string) {
+		handler, dir := newTestHandler(t)
+		base := time.Now().Add(-time.Hour)
+		var paths []string
+		for i, name := range []string{"old.txt", "mid.txt", "new.txt"} {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.WriteFile(path, []byte("duplicate content"), 0644))
+			mtime := base.Add(time.Duration(i) * time.Minute)
+			require.NoError(t, os.Chtimes(path, mtime, mtime))
+			paths = append(paths, path)
+		}
+		return handler, dir, paths
+	}
+	run := func(t *testing.T, handler *FilesystemHandler, dir string, args map[string]interface{}) string {
+		args["path"] = dir
+		result, err := handler.handleFindDuplicates(context.Background(), newToolRequest("find_duplicates", args))
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		handler, dir, paths := setup(t)
+		text := run(t, handler, dir, map[string]interface{}{"action": "delete_newest", "dry_run": true})
+		assert.Contains(t, text, "Reclaimed: 34 bytes")
+		for _, path := range paths {
+			assert.FileExists(t, path)
+		}
+	})
+
+	t.Run("delete newest keeps oldest", func(t *testing.T) {
+		handler, dir, paths := setup(t)
+		run(t, handler, dir, map[string]interface{}{"action": "delete_newest"})
+		assert.FileExists(t, paths[0])
+		assert.NoFileExists(t, paths[1])
+		assert.NoFileExists(t, paths[2])
+	})
+
+	t.Run("delete oldest keeps newest", func(t *testing.T) {
+		handler, dir, paths := setup(t)
+		text := run(t, handler, dir, map[string]interface{}{"action": "delete_oldest", "output": "json"})
+		assert.NoFileExists(t, paths[0])
+		assert.NoFileExists(t, paths[1])
+		assert.FileExists(t, paths[2])
+
+		var payload struct {
+			Groups    [][]DuplicateFile `json:"groups"`
+			Actions   []DuplicateAction `json:"actions"`
+			Reclaimed int64             `json:"reclaimed_bytes"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(text), &payload))
+		assert.Len(t, payload.Groups, 1)
+		assert.Len(t, payload.Actions, 2)
+		assert.Equal(t, int64(34), payload.Reclaimed)
+	})
+
+	t.Run("changed kept copy skips its group", func(t *testing.T) {
+		handler, _, paths := setup(t)
+		var group []DuplicateFile
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			group = append(group, DuplicateFile{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+		}
+
+		// La copia más antigua cambia después del escaneo
+		require.NoError(t, os.WriteFile(paths[0], []byte("edited after the scan"), 0644))
+		actions, reclaimed := handler.applyDuplicateAction([][]DuplicateFile{group}, "delete_newest", false)
+		assert.Zero(t, reclaimed)
+		require.Len(t, actions, 2)
+		for _, action := range actions {
+			assert.Equal(t, "skip", action.Action)
+			assert.Equal(t, "kept copy: file changed since scan", action.Note)
+		}
+		for _, path := range paths {
+			assert.FileExists(t, path)
+		}
+
+		// Y si desaparece, tampoco se borran las demás
+		require.NoError(t, os.Remove(paths[0]))
+		actions, _ = handler.applyDuplicateAction([][]DuplicateFile{group}, "delete_newest", false)
+		assert.Equal(t, "skip", actions[0].Action)
+		assert.Contains(t, actions[0].Note, "kept copy: ")
+		assert.FileExists(t, paths[1])
+		assert.FileExists(t, paths[2])
+	})
+
+	t.Run("hardlink", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("hard links are only attempted on unix")
+		}
+		handler, dir, paths := setup(t)
+		run(t, handler, dir, map[string]interface{}{"action": "hardlink"})
+		kept, err := os.Stat(paths[0])
+		require.NoError(t, err)
+		for _, path := range paths[1:] {
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.True(t, os.SameFile(kept, info))
+		}
+
+		text := run(t, handler, dir, map[string]interface{}{"action": "hardlink"})
+		assert.Contains(t, text, "already hard linked")
+	})
+}
+
+func TestTextSearchWithBOM(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("\ufeffHello world\nsecond line\n"), 0644))
+
+	matches, err := handler.performAdvancedTextSearch(context.Background(), dir, "^Hello", true, false, false, 0, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, 1, matches[0].LineNumber)
+	assert.Equal(t, "Hello world", matches[0].Line)
+
+	result, err := handler.handleSmartSearch(context.Background(), newToolRequest("smart_search", map[string]interface{}{
+		"path":            dir,
+		"pattern":         "^Hello",
+		"include_content": true,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "Hello world")
+	assert.NotContains(t, text, "\ufeff")
+}
+
+func TestMergeLineWindows(t *testing.T) {
+	cases := []struct {
+		name    string
+		lines   []int
+		context int
+		total   int
+		want    []lineWindow
+	}{
+		{"separate", []int{2, 20}, 1, 30, []lineWindow{{0, 3}, {18, 21}}},
+		{"overlapping", []int{5, 7}, 2, 30, []lineWindow{{2, 9}}},
+		{"touching", []int{3, 6}, 1, 30, []lineWindow{{1, 7}}},
+		{"unsorted and clamped", []int{30, 1}, 3, 30, []lineWindow{{0, 4}, {26, 30}}},
+		{"no context", []int{4, 4, 5}, 0, 10, []lineWindow{{3, 5}}},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, mergeLineWindows(tc.lines, tc.context, tc.total), tc.name)
+	}
+}
+
+func TestSmartSearchIncludeFileContent(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	var many strings.Builder
+	for i := 1; i <= 30; i++ {
+		if i == 5 || i == 7 || i == 25 {
+			fmt.Fprintf(&many, "// TODO item %d\n", i)
+		} else {
+			fmt.Fprintf(&many, "line %d\n", i)
+		}
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "many.go"), []byte(many.String()), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "one.go"), []byte("// TODO once\nfunc f() {}\n"), 0644))
+
+	search := func(args map[string]interface{}) string {
+		args["path"] = dir
+		args["pattern"] = "TODO"
+		args["include_file_content"] = true
+		result, err := handler.handleSmartSearch(context.Background(), newToolRequest("smart_search", args))
+		require.NoError(t, err)
+		text := result.Content[0].(mcp.TextContent).Text
+		require.False(t, result.IsError, text)
+		return text
+	}
+
+	text := search(map[string]interface{}{"context_lines": float64(1), "max_files_with_content": float64(1)})
+	assert.Contains(t, text, "📝 Content matches (4)", "include_file_content implies content search")
+	assert.Contains(t, text, "📖 Matched regions (1 of 2 file(s))")
+	assert.Contains(t, text, "many.go (3 match(es))")
+	assert.NotContains(t, text, "one.go (1 match(es))")
+	regions := text[strings.Index(text, "📖"):]
+	assert.Contains(t, regions, "     4│ line 4\n▶    5│ // TODO item 5\n     6│ line 6\n▶    7│ // TODO item 7\n     8│ line 8\n      ┆\n    24│ line 24\n")
+	assert.NotContains(t, regions, "line 9\n")
+
+	text = search(map[string]interface{}{"context_lines": float64(1), "content_limit_per_file": float64(60)})
+	assert.Contains(t, text, "📖 Matched regions (2 of 2 file(s))")
+	assert.Contains(t, text, "✂️ Truncated at 60 bytes")
+	assert.Contains(t, text, "▶    1│ // TODO once\n     2│ func f() {}\n")
+	assert.NotContains(t, text, "line 8\n")
+}
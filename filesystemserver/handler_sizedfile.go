@@ -0,0 +1,223 @@
+package filesystemserver
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultMaxCreateSize - Tamaño máximo por defecto de create_file_of_size (10GB)
+const defaultMaxCreateSize = 10 << 30
+
+// sizedFileModes - Contenido de los archivos que crea create_file_of_size
+var sizedFileModes = []string{"sparse", "zero", "random"}
+
+// errPreallocateUnsupported - La plataforma o el sistema de archivos no reserva espacio por adelantado
+var errPreallocateUnsupported = errors.New("preallocation is not supported here")
+
+// WithMaxCreateSize caps the size of the files create_file_of_size makes.
+// 0 or less keeps the 10GB default.
+func WithMaxCreateSize(limit int64) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		fs.maxCreateSize = limit
+		return nil
+	}
+}
+
+// maxCreateFileSize - Límite efectivo de create_file_of_size
+func (fs *FilesystemHandler) maxCreateFileSize() int64 {
+	if fs.maxCreateSize > 0 {
+		return fs.maxCreateSize
+	}
+	return defaultMaxCreateSize
+}
+
+// sizedFileResult - Lo que hizo createSizedFile
+type sizedFileResult struct {
+	written     int64 // bytes escritos de verdad; 0 en un archivo disperso
+	preallocate string
+	elapsed     time.Duration
+}
+
+// handleCreateFileOfSize - Crea un archivo de un tamaño dado sin enviar su contenido:
+// disperso, lleno de ceros o aleatorio
+func (fs *FilesystemHandler) handleCreateFileOfSize(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	sizeArg, err := getNumber(request.Params.Arguments, "size", -1)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	hasSize := request.Params.Arguments["size"] != nil
+	mode, err := getString(request.Params.Arguments, "mode", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	preallocate, err := getBool(request.Params.Arguments, "preallocate", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	overwrite, err := getBool(request.Params.Arguments, "overwrite", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if mode == "" {
+		mode = "sparse"
+	}
+	var problem string
+	switch {
+	case path == "":
+		problem = "path is required"
+	case !hasSize || sizeArg < 0 || sizeArg != float64(int64(sizeArg)):
+		problem = "size must be a whole number of bytes, 0 or more"
+	case !containsString(sizedFileModes, mode):
+		problem = fmt.Sprintf("unknown mode %q (use sparse, zero or random)", mode)
+	case int64(sizeArg) > fs.maxCreateFileSize():
+		problem = fmt.Sprintf("size %d exceeds the server limit of %d bytes", int64(sizeArg), fs.maxCreateFileSize())
+	}
+	if problem != "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: " + problem},
+			},
+			IsError: true,
+		}, nil
+	}
+	size := int64(sizeArg)
+
+	validPath, err := fs.validateNewPath(path)
+	if err == nil {
+		if info, statErr := os.Lstat(validPath); statErr == nil {
+			switch {
+			case !info.Mode().IsRegular():
+				err = fmt.Errorf("%s exists and is not a regular file", path)
+			case !overwrite:
+				err = fmt.Errorf("%s already exists (set overwrite=true to replace it)", path)
+			}
+		}
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Un archivo disperso sin reservar apenas ocupa; el resto necesita el tamaño entero
+	if mode != "sparse" || preallocate {
+		if available, ok := availableDiskSpace(filepath.Dir(validPath)); ok && available < size {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: not enough free space to create %s (need %d bytes, %d available)", path, size, available)},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	unlock := fs.lockPath(validPath)
+	result, err := fs.createSizedFile(ctx, validPath, size, mode, preallocate)
+	unlock()
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: could not create %s: %v", path, err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	text := fmt.Sprintf("✅ Created %s (%d bytes, %s)\n", validPath, size, mode)
+	if result.preallocate != "" {
+		text += fmt.Sprintf("💽 Preallocation: %s\n", result.preallocate)
+	}
+	seconds := result.elapsed.Seconds()
+	text += fmt.Sprintf("⏱️ %s", result.elapsed.Round(time.Millisecond))
+	if result.written > 0 && seconds > 0 {
+		text += fmt.Sprintf(", %d bytes written at %.1f MB/s", result.written, float64(result.written)/seconds/(1<<20))
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: text + "\n"},
+		},
+	}, nil
+}
+
+// createSizedFile - Escribe el archivo en un temporal junto a validPath, en fragmentos
+// de MAX_CHUNK_SIZE comprobando ctx entre ellos, y lo renombra al terminar
+func (fs *FilesystemHandler) createSizedFile(ctx context.Context, validPath string, size int64, mode string, preallocate bool) (*sizedFileResult, error) {
+	start := time.Now()
+	result := &sizedFileResult{}
+
+	tempPath := validPath + ".tmp"
+	defer fs.trackTemp(tempPath)()
+	out, err := fs.openFileChecked(tempPath, os.O_WRONLY|os.O_CREATE, 0644)
+	if err == nil {
+		if err = out.Truncate(0); err != nil {
+			out.Close()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = func() error {
+		if preallocate {
+			switch err := preallocateFile(out, size); {
+			case errors.Is(err, errPreallocateUnsupported):
+				result.preallocate = "not supported here, skipped"
+			case err != nil:
+				return fmt.Errorf("preallocation failed: %w", err)
+			default:
+				result.preallocate = fmt.Sprintf("%d bytes reserved", size)
+			}
+		}
+		if mode == "sparse" {
+			return out.Truncate(size)
+		}
+
+		buf := make([]byte, min(size, MAX_CHUNK_SIZE))
+		for result.written < size {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			chunk := buf[:min(size-result.written, int64(len(buf)))]
+			if mode == "random" {
+				if _, err := rand.Read(chunk); err != nil {
+					return err
+				}
+			}
+			n, err := out.Write(chunk)
+			result.written += int64(n)
+			if err != nil {
+				return err
+			}
+		}
+		return out.Sync()
+	}()
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = fs.renameChecked(tempPath, validPath)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return nil, err
+	}
+	fs.stats.addWritten(int(result.written))
+	result.elapsed = time.Since(start)
+	return result, nil
+}
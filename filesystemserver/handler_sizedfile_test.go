@@ -0,0 +1,95 @@
+package filesystemserver
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateFileOfSizeModes(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	size := int64(MAX_CHUNK_SIZE) + MAX_CHUNK_SIZE/2
+
+	for _, mode := range []string{"sparse", "zero", "random"} {
+		t.Run(mode, func(t *testing.T) {
+			path := filepath.Join(dir, mode+".bin")
+			text, isError := callText(t, handler.handleCreateFileOfSize, "create_file_of_size", map[string]interface{}{
+				"path": path, "size": float64(size), "mode": mode, "preallocate": true,
+			})
+			require.False(t, isError, text)
+			assert.Contains(t, text, "✅ Created")
+			assert.Contains(t, text, "💽 Preallocation:")
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.Len(t, data, int(size))
+			zeros := bytes.Count(data, []byte{0}) == len(data)
+			assert.Equal(t, mode != "random", zeros)
+			if mode == "sparse" {
+				assert.NotContains(t, text, "MB/s")
+			} else {
+				assert.Contains(t, text, "MB/s")
+			}
+		})
+	}
+	assert.Empty(t, tempFilesIn(t, dir))
+}
+
+func TestCreateFileOfSizeRejects(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	require.NoError(t, WithMaxCreateSize(4096)(handler))
+	existing := filepath.Join(dir, "existing.bin")
+	require.NoError(t, os.WriteFile(existing, []byte("keep"), 0644))
+
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want string
+	}{
+		{"over limit", map[string]interface{}{"path": filepath.Join(dir, "big.bin"), "size": float64(4097)}, "exceeds the server limit of 4096 bytes"},
+		{"negative", map[string]interface{}{"path": filepath.Join(dir, "a.bin"), "size": float64(-1)}, "size must be"},
+		{"fractional", map[string]interface{}{"path": filepath.Join(dir, "a.bin"), "size": 1.5}, "size must be"},
+		{"missing size", map[string]interface{}{"path": filepath.Join(dir, "a.bin")}, "size must be"},
+		{"bad mode", map[string]interface{}{"path": filepath.Join(dir, "a.bin"), "size": float64(1), "mode": "ones"}, "unknown mode"},
+		{"exists", map[string]interface{}{"path": existing, "size": float64(10)}, "overwrite=true"},
+		{"directory", map[string]interface{}{"path": dir, "size": float64(10), "overwrite": true}, "not a regular file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, isError := callText(t, handler.handleCreateFileOfSize, "create_file_of_size", tt.args)
+			assert.True(t, isError)
+			assert.Contains(t, text, tt.want)
+		})
+	}
+
+	text, isError := callText(t, handler.handleCreateFileOfSize, "create_file_of_size", map[string]interface{}{
+		"path": existing, "size": float64(4096), "mode": "zero", "overwrite": true,
+	})
+	require.False(t, isError, text)
+	info, err := os.Stat(existing)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4096), info.Size())
+	assert.NoFileExists(t, filepath.Join(dir, "a.bin"))
+}
+
+func TestCreateFileOfSizeCanceled(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	path := filepath.Join(dir, "fixture.bin")
+	result, err := handler.handleCreateFileOfSize(ctx, newToolRequest("create_file_of_size", map[string]interface{}{
+		"path": path, "size": float64(MAX_CHUNK_SIZE), "mode": "zero",
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "context canceled")
+	assert.NoFileExists(t, path)
+	assert.Empty(t, tempFilesIn(t, dir))
+}
@@ -0,0 +1,555 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// snapshotDirName - Directorio, dentro de cada directorio permitido, donde se guardan los snapshots
+const snapshotDirName = ".mcp-snapshots"
+
+// snapshotIDPattern - IDs generados por create_snapshot
+var snapshotIDPattern = regexp.MustCompile(`^snap_[0-9]{8}T[0-9]{6}_[0-9a-f]{8}$`)
+
+// maxRestoreDiffLines - Líneas de diff por archivo en la vista previa de restore
+const maxRestoreDiffLines = 40
+
+// handleCreateSnapshot - Copia un archivo o árbol a .mcp-snapshots/<id> con manifiesto de hashes
+func (fs *FilesystemHandler) handleCreateSnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	include, err := getNonEmptyStrings(request.Params.Arguments, "include")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	exclude, err := getNonEmptyStrings(request.Params.Arguments, "exclude")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	snapshot, dir, err := fs.createSnapshot(ctx, validPath, include, exclude)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Snapshot failed: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: fmt.Sprintf("📸 Snapshot %s created\n📁 Source: %s\n📊 %d files, %d bytes\n💾 Stored in %s\n",
+				snapshot.ID, snapshot.Source, len(snapshot.Files), snapshot.TotalSize, dir)},
+		},
+	}, nil
+}
+
+// handleRestoreSnapshot - Muestra lo que se sobrescribiría y, con force, restaura
+func (fs *FilesystemHandler) handleRestoreSnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := getString(request.Params.Arguments, "id", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	target, err := getString(request.Params.Arguments, "target", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	force, err := getBool(request.Params.Arguments, "force", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if id == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: id is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	dir, snapshot, err := fs.findSnapshot(id)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if target == "" {
+		target = snapshot.Source
+	}
+	validTarget, err := fs.validateNewPath(target)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Invalid target: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	entries, err := fs.planSnapshotRestore(ctx, dir, snapshot, validTarget)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if !force {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: formatSnapshotRestore(snapshot, validTarget, entries, true)},
+			},
+		}, nil
+	}
+
+	if err := fs.applySnapshotRestore(dir, snapshot, validTarget, entries); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: Restore failed: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatSnapshotRestore(snapshot, validTarget, entries, false)},
+		},
+	}, nil
+}
+
+// handleListSnapshots - Lista los snapshots de todos los directorios permitidos
+func (fs *FilesystemHandler) handleListSnapshots(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	snapshots := fs.listSnapshots()
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📸 Snapshots (%d)\n", len(snapshots)))
+	if len(snapshots) == 0 {
+		b.WriteString(fmt.Sprintf("No snapshots found. Create one with %s.\n", fs.toolName("create_snapshot")))
+	}
+	for _, snapshot := range snapshots {
+		b.WriteString(fmt.Sprintf("  • %s - %s (%d files, %d bytes) created %s\n",
+			snapshot.ID, snapshot.Source, len(snapshot.Files), snapshot.TotalSize, snapshot.Created.Format(time.RFC3339)))
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: b.String()},
+		},
+	}, nil
+}
+
+// handleDeleteSnapshot - Elimina un snapshot y sus archivos copiados
+func (fs *FilesystemHandler) handleDeleteSnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := getString(request.Params.Arguments, "id", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if id == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: id is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	dir, snapshot, err := fs.findSnapshot(id)
+	if err == nil {
+		err = os.RemoveAll(dir)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: fmt.Sprintf("🗑️ Snapshot %s deleted (%d files, %d bytes)", snapshot.ID, len(snapshot.Files), snapshot.TotalSize)},
+		},
+	}, nil
+}
+
+// allowedDirFor - Directorio permitido más específico que contiene path
+func (fs *FilesystemHandler) allowedDirFor(path string) string {
+	best := ""
+	for _, dir := range fs.allowedDirectories() {
+		if pathWithinDir(path, dir) && len(dir) > len(best) {
+			best = dir
+		}
+	}
+	return strings.TrimSuffix(best, string(filepath.Separator))
+}
+
+// createSnapshot - Copia los archivos seleccionados y escribe manifest.json
+func (fs *FilesystemHandler) createSnapshot(ctx context.Context, source string, include, exclude []string) (*Snapshot, string, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, "", err
+	}
+	root := fs.allowedDirFor(source)
+	if root == "" {
+		return nil, "", fmt.Errorf("no allowed directory contains %s", source)
+	}
+
+	snapshot := &Snapshot{
+		ID:        newTimestampID("snap"),
+		Source:    source,
+		SourceDir: info.IsDir(),
+		Created:   time.Now(),
+		Include:   include,
+		Exclude:   exclude,
+		Files:     []SnapshotFile{},
+	}
+
+	sources := []string{source}
+	if info.IsDir() {
+		if sources, err = fs.snapshotSources(ctx, source, include, exclude); err != nil {
+			return nil, "", err
+		}
+	}
+
+	dir := filepath.Join(root, snapshotDirName, snapshot.ID)
+	filesDir := filepath.Join(dir, "files")
+	if err := fs.mkdirAllChecked(filesDir, 0755); err != nil {
+		return nil, "", err
+	}
+
+	for _, path := range sources {
+		rel := filepath.Base(path)
+		if snapshot.SourceDir {
+			rel, _ = filepath.Rel(source, path)
+		}
+		stored := filepath.Join(filesDir, rel)
+		if err := fs.mkdirAllChecked(filepath.Dir(stored), 0755); err != nil {
+			os.RemoveAll(dir)
+			return nil, "", err
+		}
+		sourceInfo, err := os.Stat(path)
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, "", err
+		}
+		if err := fs.copyFileChecked(path, stored); err != nil {
+			os.RemoveAll(dir)
+			return nil, "", fmt.Errorf("copying %s: %v", rel, err)
+		}
+
+		// El hash describe la copia guardada, no el original que pudo cambiar entretanto
+		hashes, err := calculateChecksums(stored, []string{"sha256"})
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, "", err
+		}
+		storedInfo, err := os.Stat(stored)
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, "", err
+		}
+		snapshot.Files = append(snapshot.Files, SnapshotFile{
+			Path:    filepath.ToSlash(rel),
+			Size:    storedInfo.Size(),
+			Mode:    storedInfo.Mode().Perm(),
+			SHA256:  hashes["sha256"],
+			ModTime: sourceInfo.ModTime(),
+		})
+		snapshot.TotalSize += storedInfo.Size()
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err == nil {
+		err = fs.writeFileChecked(filepath.Join(dir, "manifest.json"), data, 0644)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, "", err
+	}
+	return snapshot, dir, nil
+}
+
+// snapshotSources - Archivos regulares bajo source que entran en un snapshot:
+// sin directorios de VCS ni de datos del servidor, rutas denegadas ni excluidas
+func (fs *FilesystemHandler) snapshotSources(ctx context.Context, source string, include, exclude []string) ([]string, error) {
+	var sources []string
+	err := fs.walkContext(ctx, source, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			fs.logWalkError(ctx, currentPath, err)
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if currentPath == source {
+			return nil
+		}
+		if info.IsDir() {
+			if containsString(cleanupVCSDirs, info.Name()) || isServerDataDir(info.Name()) || fs.isDeniedPath(currentPath) || matchesAnyPattern(source, currentPath, exclude) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if fs.isDeniedPath(currentPath) {
+			return nil
+		}
+		if !info.Mode().IsRegular() || matchesAnyPattern(source, currentPath, exclude) {
+			return nil
+		}
+		if len(include) > 0 && !matchesAnyPattern(source, currentPath, include) {
+			return nil
+		}
+		if _, err := fs.validatePath(currentPath); err != nil {
+			return nil
+		}
+		sources = append(sources, currentPath)
+		return nil
+	})
+	return sources, err
+}
+
+// findSnapshot - Busca el snapshot por ID en los directorios permitidos
+func (fs *FilesystemHandler) findSnapshot(id string) (string, *Snapshot, error) {
+	if !snapshotIDPattern.MatchString(id) {
+		return "", nil, fmt.Errorf("invalid snapshot id '%s'", id)
+	}
+	for _, allowed := range fs.allowedDirectories() {
+		dir := filepath.Join(allowed, snapshotDirName, id)
+		snapshot, err := loadSnapshotManifest(dir)
+		if err != nil {
+			continue
+		}
+		validDir, err := fs.validatePath(dir)
+		if err != nil {
+			return "", nil, err
+		}
+		return validDir, snapshot, nil
+	}
+	return "", nil, fmt.Errorf("snapshot '%s' not found", id)
+}
+
+// loadSnapshotManifest - Lee manifest.json de un directorio de snapshot
+func loadSnapshotManifest(dir string) (*Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		return nil, err
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// listSnapshots - Todos los snapshots legibles, los más recientes primero
+func (fs *FilesystemHandler) listSnapshots() []*Snapshot {
+	var snapshots []*Snapshot
+	for _, allowed := range fs.allowedDirectories() {
+		base := filepath.Join(allowed, snapshotDirName)
+		entries, err := os.ReadDir(base)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() || !snapshotIDPattern.MatchString(entry.Name()) {
+				continue
+			}
+			if snapshot, err := loadSnapshotManifest(filepath.Join(base, entry.Name())); err == nil {
+				snapshots = append(snapshots, snapshot)
+			}
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.After(snapshots[j].Created)
+	})
+	return snapshots
+}
+
+// validateNewPath - Como validatePath, pero admite rutas cuyos directorios padre aún no existen
+func (fs *FilesystemHandler) validateNewPath(path string) (string, error) {
+	abs, err := fs.resolvePath(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Lstat(abs); err == nil {
+		return fs.validatePath(abs)
+	}
+
+	ancestor := filepath.Dir(abs)
+	for {
+		if _, err := os.Stat(ancestor); err == nil {
+			break
+		}
+		parent := filepath.Dir(ancestor)
+		if parent == ancestor {
+			break
+		}
+		ancestor = parent
+	}
+	if _, err := fs.validatePath(ancestor); err != nil {
+		return "", err
+	}
+	if !fs.isPathInAllowedDirs(abs) {
+		return "", fmt.Errorf("access denied - path outside allowed directories: %s", abs)
+	}
+	if err := fs.checkDenyPatterns(abs); err != nil {
+		return "", err
+	}
+	return abs, nil
+}
+
+// snapshotTargetPath - Destino de un archivo del snapshot al restaurar en target
+func snapshotTargetPath(snapshot *Snapshot, target, rel string) string {
+	if !snapshot.SourceDir {
+		return target
+	}
+	return filepath.Join(target, filepath.FromSlash(rel))
+}
+
+// planSnapshotRestore - Compara cada archivo del snapshot con el destino actual
+func (fs *FilesystemHandler) planSnapshotRestore(ctx context.Context, dir string, snapshot *Snapshot, target string) ([]SnapshotRestoreEntry, error) {
+	entries := make([]SnapshotRestoreEntry, 0, len(snapshot.Files))
+	for _, file := range snapshot.Files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		dest, err := fs.validateNewPath(snapshotTargetPath(snapshot, target, file.Path))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file.Path, err)
+		}
+
+		entry := SnapshotRestoreEntry{Path: file.Path, Action: "create"}
+		if info, err := os.Stat(dest); err == nil {
+			if info.IsDir() {
+				return nil, fmt.Errorf("%s: a directory exists at the restore location", file.Path)
+			}
+			entry.Action = "overwrite"
+			if hashes, err := calculateChecksums(dest, []string{"sha256"}); err == nil && hashes["sha256"] == file.SHA256 {
+				entry.Action = "unchanged"
+			} else {
+				stored := filepath.Join(dir, "files", filepath.FromSlash(file.Path))
+				if diff, err := fs.compareFiles(dest, stored, diffOptions{ContextLines: 3}); err == nil {
+					if diff.HashOnly != "" {
+						entry.Diff = diff.HashOnly
+					} else {
+						hunks, _ := truncateHunks(diff.Hunks, maxRestoreDiffLines)
+						entry.Diff = renderUnifiedDiff(file.Path+" (current)", file.Path+" (snapshot)", hunks)
+					}
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// applySnapshotRestore - Copia de vuelta los archivos que cambiaron, verificando la integridad del snapshot
+func (fs *FilesystemHandler) applySnapshotRestore(dir string, snapshot *Snapshot, target string, entries []SnapshotRestoreEntry) error {
+	files := make(map[string]SnapshotFile, len(snapshot.Files))
+	for _, file := range snapshot.Files {
+		files[file.Path] = file
+	}
+
+	for _, entry := range entries {
+		if entry.Action == "unchanged" {
+			continue
+		}
+		file := files[entry.Path]
+		stored := filepath.Join(dir, "files", filepath.FromSlash(file.Path))
+		hashes, err := calculateChecksums(stored, []string{"sha256"})
+		if err != nil {
+			return fmt.Errorf("%s: %v", file.Path, err)
+		}
+		if hashes["sha256"] != file.SHA256 {
+			return fmt.Errorf("%s: snapshot copy is corrupt (hash mismatch)", file.Path)
+		}
+
+		dest := snapshotTargetPath(snapshot, target, file.Path)
+		if err := fs.mkdirAllChecked(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("%s: %v", file.Path, err)
+		}
+		if err := fs.copyFileChecked(stored, dest); err != nil {
+			return fmt.Errorf("%s: %v", file.Path, err)
+		}
+		fs.chmodChecked(dest, file.Mode)
+	}
+	return nil
+}
+
+// formatSnapshotRestore - Resumen de la restauración o de su vista previa
+func formatSnapshotRestore(snapshot *Snapshot, target string, entries []SnapshotRestoreEntry, dryRun bool) string {
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		counts[entry.Action]++
+	}
+
+	var b strings.Builder
+	if dryRun {
+		b.WriteString(fmt.Sprintf("🔍 Restore preview: %s → %s\n", snapshot.ID, target))
+	} else {
+		b.WriteString(fmt.Sprintf("♻️ Restored %s → %s\n", snapshot.ID, target))
+	}
+	b.WriteString(fmt.Sprintf("📊 %d to create, %d to overwrite, %d unchanged\n", counts["create"], counts["overwrite"], counts["unchanged"]))
+
+	for _, entry := range entries {
+		switch entry.Action {
+		case "create":
+			b.WriteString(fmt.Sprintf("  ➕ %s\n", entry.Path))
+		case "overwrite":
+			b.WriteString(fmt.Sprintf("  ✏️ %s\n", entry.Path))
+			if dryRun && entry.Diff != "" {
+				b.WriteString(entry.Diff)
+				if !strings.HasSuffix(entry.Diff, "\n") {
+					b.WriteString("\n")
+				}
+			}
+		}
+	}
+
+	if dryRun && counts["create"]+counts["overwrite"] > 0 {
+		b.WriteString("\n💡 Run with force=true to restore these files\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotCreateRestoreDelete(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	project := filepath.Join(dir, "project")
+	files := map[string]string{
+		"main.go":         "package main\n\nfunc main() {}\n",
+		"lib/util.go":     "package lib\n",
+		"build/out.bin":   "binary",
+		".git/HEAD":       "ref: refs/heads/main\n",
+		"notes/draft.txt": "draft\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(project, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	ctx := context.Background()
+
+	result, err := handler.handleCreateSnapshot(ctx, newToolRequest("create_snapshot", map[string]interface{}{
+		"path":    project,
+		"exclude": []interface{}{"build"},
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	text := result.Content[0].(mcp.TextContent).Text
+	id := regexp.MustCompile(`snap_\S+`).FindString(text)
+	assert.Contains(t, text, "3 files")
+
+	snapDir, snapshot, err := handler.findSnapshot(id)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, snapshotDirName, id), snapDir)
+	paths := []string{}
+	for _, file := range snapshot.Files {
+		paths = append(paths, file.Path)
+		assert.Len(t, file.SHA256, 64)
+	}
+	assert.ElementsMatch(t, []string{"main.go", "lib/util.go", "notes/draft.txt"}, paths)
+
+	// Los snapshots no aparecen en búsquedas ni en duplicados
+	matches, err := handler.performAdvancedTextSearch(context.Background(), dir, "package lib", true, false, false, 0, nil, nil)
+	require.NoError(t, err)
+	assert.Len(t, matches, 1)
+
+	require.NoError(t, os.WriteFile(filepath.Join(project, "main.go"), []byte("package main\n\nfunc main() { panic(1) }\n"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(project, "notes", "draft.txt")))
+
+	// Sin force solo se muestra el diff
+	result, err = handler.handleRestoreSnapshot(ctx, newToolRequest("restore_snapshot", map[string]interface{}{"id": id}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	text = result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "1 to create, 1 to overwrite, 1 unchanged")
+	assert.Contains(t, text, "-func main() { panic(1) }")
+	assert.Contains(t, text, "+func main() {}")
+	_, err = os.Stat(filepath.Join(project, "notes", "draft.txt"))
+	assert.True(t, os.IsNotExist(err))
+
+	result, err = handler.handleRestoreSnapshot(ctx, newToolRequest("restore_snapshot", map[string]interface{}{"id": id, "force": true}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	data, err := os.ReadFile(filepath.Join(project, "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, files["main.go"], string(data))
+	data, err = os.ReadFile(filepath.Join(project, "notes", "draft.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "draft\n", string(data))
+
+	// Una copia corrupta no se restaura
+	require.NoError(t, os.WriteFile(filepath.Join(snapDir, "files", "lib", "util.go"), []byte("tampered"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(project, "lib", "util.go")))
+	result, err = handler.handleRestoreSnapshot(ctx, newToolRequest("restore_snapshot", map[string]interface{}{"id": id, "force": true}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	result, err = handler.handleListSnapshots(ctx, newToolRequest("list_snapshots", nil))
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, id)
+
+	result, err = handler.handleDeleteSnapshot(ctx, newToolRequest("delete_snapshot", map[string]interface{}{"id": id}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	_, err = os.Stat(snapDir)
+	assert.True(t, os.IsNotExist(err))
+
+	result, err = handler.handleDeleteSnapshot(ctx, newToolRequest("delete_snapshot", map[string]interface{}{"id": "../project"}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestSnapshotSingleFileToNewTarget(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	source := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(source, []byte(`{"a": 1}`), 0600))
+
+	snapshot, _, err := handler.createSnapshot(context.Background(), source, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, snapshot.Files, 1)
+	assert.Equal(t, "config.json", snapshot.Files[0].Path)
+
+	target := filepath.Join(dir, "restored", "deep", "config.json")
+	result, err := handler.handleRestoreSnapshot(context.Background(), newToolRequest("restore_snapshot", map[string]interface{}{
+		"id": snapshot.ID, "target": target, "force": true,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	info, err := os.Stat(target)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
@@ -0,0 +1,205 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// serverStats - Contadores de actividad en memoria; todos se actualizan con operaciones atómicas
+type serverStats struct {
+	started      time.Time
+	tools        sync.Map // nombre -> *toolCounters
+	bytesRead    atomic.Int64
+	bytesWritten atomic.Int64
+	walkEntries  atomic.Int64
+}
+
+// toolCounters - Contadores de una herramienta
+type toolCounters struct {
+	calls  atomic.Int64
+	errors atomic.Int64
+	nanos  atomic.Int64
+}
+
+// newServerStats - Contadores a cero con el reloj de uptime en marcha
+func newServerStats() *serverStats {
+	return &serverStats{started: time.Now()}
+}
+
+// recordCall - Suma una llamada a la herramienta name
+func (s *serverStats) recordCall(name string, duration time.Duration, failed bool) {
+	if s == nil {
+		return
+	}
+	value, _ := s.tools.LoadOrStore(name, &toolCounters{})
+	counters := value.(*toolCounters)
+	counters.calls.Add(1)
+	counters.nanos.Add(int64(duration))
+	if failed {
+		counters.errors.Add(1)
+	}
+}
+
+// addRead - Suma bytes leídos
+func (s *serverStats) addRead(n int) {
+	if s != nil {
+		s.bytesRead.Add(int64(n))
+	}
+}
+
+// addWritten - Suma bytes escritos
+func (s *serverStats) addWritten(n int) {
+	if s != nil {
+		s.bytesWritten.Add(int64(n))
+	}
+}
+
+// snapshot - Copia consistente por campo de los contadores actuales
+func (s *serverStats) snapshot() ServerStats {
+	stats := ServerStats{Tools: []ToolStats{}}
+	if s == nil {
+		return stats
+	}
+	stats.Uptime = time.Since(s.started).Round(time.Second).String()
+	stats.BytesRead = s.bytesRead.Load()
+	stats.BytesWritten = s.bytesWritten.Load()
+	stats.WalkEntries = s.walkEntries.Load()
+
+	s.tools.Range(func(key, value interface{}) bool {
+		counters := value.(*toolCounters)
+		tool := ToolStats{
+			Name:   key.(string),
+			Calls:  counters.calls.Load(),
+			Errors: counters.errors.Load(),
+		}
+		if tool.Calls > 0 {
+			tool.AvgMillis = float64(counters.nanos.Load()) / float64(tool.Calls) / float64(time.Millisecond)
+		}
+		stats.TotalCalls += tool.Calls
+		stats.TotalErrors += tool.Errors
+		stats.Tools = append(stats.Tools, tool)
+		return true
+	})
+	sort.Slice(stats.Tools, func(i, j int) bool {
+		if stats.Tools[i].Calls != stats.Tools[j].Calls {
+			return stats.Tools[i].Calls > stats.Tools[j].Calls
+		}
+		return stats.Tools[i].Name < stats.Tools[j].Name
+	})
+	return stats
+}
+
+// readFile - os.ReadFile contabilizando los bytes leídos
+func (fs *FilesystemHandler) readFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	fs.stats.addRead(len(data))
+	return data, err
+}
+
+// walk - filepath.Walk contabilizando cada entrada visitada
+func (fs *FilesystemHandler) walk(root string, fn filepath.WalkFunc) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if fs.stats != nil {
+			fs.stats.walkEntries.Add(1)
+		}
+		return fn(path, info, err)
+	})
+}
+
+// walkContext - walk que además informa del progreso de la llamada en curso y
+// se detiene con ctx.Err() en cuanto ctx se cancela o agota su timeout
+func (fs *FilesystemHandler) walkContext(ctx context.Context, root string, fn filepath.WalkFunc) error {
+	progress := progressFrom(ctx)
+	activity := activityFrom(ctx)
+	return fs.walk(root, func(path string, info os.FileInfo, err error) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		progress.entry(path)
+		if activity != nil {
+			activity.entries.Add(1)
+		}
+		return fn(path, info, err)
+	})
+}
+
+// handleServerStats - Informa de los contadores de actividad, el uptime y los límites configurados
+func (fs *FilesystemHandler) handleServerStats(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	output, err := getString(request.Params.Arguments, "output", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	stats := fs.stats.snapshot()
+	stats.Limits = map[string]int64{
+		"max_inline_size":       MAX_INLINE_SIZE,
+		"max_base64_size":       MAX_BASE64_SIZE,
+		"max_chunk_size":        MAX_CHUNK_SIZE,
+		"max_decompressed_size": fs.maxDecompressedSize(),
+		"max_create_size":       fs.maxCreateFileSize(),
+		"inline_result_limit":   int64(fs.inlineResultLimit()),
+		"response_budget":       int64(fs.responseBudget()),
+	}
+	stats.AllowedDirectories = len(fs.allowedDirectories())
+	stats.ReadOnly = fs.readOnly
+
+	if output == "json" {
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: string(data)},
+			},
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatServerStats(stats)},
+		},
+	}, nil
+}
+
+// formatServerStats - Resumen legible de ServerStats
+func formatServerStats(stats ServerStats) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📊 Server stats (uptime %s)\n", stats.Uptime))
+	b.WriteString(fmt.Sprintf("🔧 %d tool calls, %d errors\n", stats.TotalCalls, stats.TotalErrors))
+	b.WriteString(fmt.Sprintf("📖 %d bytes read, ✍️ %d bytes written\n", stats.BytesRead, stats.BytesWritten))
+	b.WriteString(fmt.Sprintf("🚶 %d directory entries walked\n", stats.WalkEntries))
+	b.WriteString(fmt.Sprintf("📁 %d allowed directories", stats.AllowedDirectories))
+	if stats.ReadOnly {
+		b.WriteString(" (read-only)")
+	}
+	b.WriteString("\n")
+
+	limits := make([]string, 0, len(stats.Limits))
+	for name := range stats.Limits {
+		limits = append(limits, name)
+	}
+	sort.Strings(limits)
+	b.WriteString("\n⚙️ Limits:\n")
+	for _, name := range limits {
+		b.WriteString(fmt.Sprintf("  %s: %d bytes\n", name, stats.Limits[name]))
+	}
+
+	if len(stats.Tools) > 0 {
+		b.WriteString("\n🔧 Per tool:\n")
+		for _, tool := range stats.Tools {
+			b.WriteString(fmt.Sprintf("  • %s: %d calls, %d errors, avg %.1f ms\n", tool.Name, tool.Calls, tool.Errors, tool.AvgMillis))
+		}
+	}
+	return b.String()
+}
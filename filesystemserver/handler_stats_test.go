@@ -0,0 +1,77 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerStatsCounters(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "a.txt"), []byte("hello"), 0644))
+	ctx := context.Background()
+
+	read := handler.guardTool("read_file", handler.handleReadFile)
+	write := handler.guardTool("write_file", handler.handleWriteFile)
+	search := handler.guardTool("search_files", handler.handleSearchFiles)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			read(ctx, newToolRequest("read_file", map[string]interface{}{"path": filepath.Join(dir, "sub", "a.txt")}))
+		}()
+	}
+	wg.Wait()
+	read(ctx, newToolRequest("read_file", map[string]interface{}{"path": filepath.Join(dir, "missing.txt")}))
+	write(ctx, newToolRequest("write_file", map[string]interface{}{"path": filepath.Join(dir, "b.txt"), "content": "123"}))
+	search(ctx, newToolRequest("search_files", map[string]interface{}{"path": dir, "pattern": "a"}))
+
+	stats := handler.stats.snapshot()
+	assert.Equal(t, int64(13), stats.TotalCalls)
+	assert.Equal(t, int64(1), stats.TotalErrors)
+	assert.Equal(t, int64(50), stats.BytesRead)
+	assert.Equal(t, int64(3), stats.BytesWritten)
+	assert.Equal(t, int64(4), stats.WalkEntries) // dir, b.txt, sub, sub/a.txt
+	require.NotEmpty(t, stats.Tools)
+	assert.Equal(t, "read_file", stats.Tools[0].Name)
+	assert.Equal(t, int64(11), stats.Tools[0].Calls)
+	assert.Equal(t, int64(1), stats.Tools[0].Errors)
+}
+
+func TestHandleServerStats(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	list := handler.guardTool("list_directory", handler.handleListDirectory)
+	list(context.Background(), newToolRequest("list_directory", map[string]interface{}{"path": dir}))
+
+	result, err := handler.handleServerStats(context.Background(), newToolRequest("server_stats", map[string]interface{}{}))
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "📊 Server stats (uptime")
+	assert.Contains(t, text, "🔧 1 tool calls, 0 errors")
+	assert.Contains(t, text, "max_inline_size: 5242880 bytes")
+	assert.Contains(t, text, "• list_directory: 1 calls, 0 errors")
+
+	result, err = handler.handleServerStats(context.Background(), newToolRequest("server_stats", map[string]interface{}{"output": "json"}))
+	require.NoError(t, err)
+	var stats ServerStats
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &stats))
+	assert.Equal(t, int64(1), stats.TotalCalls)
+	assert.Equal(t, 1, stats.AllowedDirectories)
+	assert.Equal(t, int64(MAX_CHUNK_SIZE), stats.Limits["max_chunk_size"])
+
+	// Los handlers construidos sin NewFilesystemHandler no tienen contadores
+	empty := &FilesystemHandler{}
+	result, err = empty.handleServerStats(context.Background(), newToolRequest("server_stats", map[string]interface{}{}))
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+}
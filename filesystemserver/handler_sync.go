@@ -0,0 +1,371 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// syncModes - Modos de smart_sync
+var syncModes = []string{"preview", "merge", "overwrite"}
+
+// handleSmartSync - Sincroniza un directorio destino con un origen: copia lo
+// nuevo, actualiza lo cambiado y renombra en el destino lo que solo se movió
+func (fs *FilesystemHandler) handleSmartSync(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	source, err := getString(request.Params.Arguments, "source", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	target, err := getString(request.Params.Arguments, "target", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	mode, err := getString(request.Params.Arguments, "mode", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	detectRenames, err := getBool(request.Params.Arguments, "detect_renames", true)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	exclude, err := getNonEmptyStrings(request.Params.Arguments, "exclude_patterns")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	dirOpts := dirCompareOptions{DetectRenames: detectRenames, Exclude: exclude}
+
+	if mode == "" {
+		mode = "preview"
+	}
+	if source == "" || target == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: source and target are required"},
+			},
+			IsError: true,
+		}, nil
+	}
+	if !containsString(syncModes, mode) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: unknown mode %q (use preview, merge or overwrite)", mode)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validSource, err := fs.validatePath(source)
+	var validTarget string
+	if err == nil {
+		validTarget, err = fs.validatePath(target)
+	}
+	if err == nil {
+		err = checkSyncPaths(validSource, validTarget)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if mode != "preview" {
+		unlock := fs.lockPath(validTarget)
+		defer unlock()
+	}
+	ctx, warnings := withWalkWarnings(ctx)
+	report, err := fs.smartSync(ctx, validSource, validTarget, mode, dirOpts)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Sync error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	report.Warnings = warnings.entries()
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: fs.formatSyncReport(report) + warnings.note(nil)},
+		},
+		IsError: len(report.Errors) > 0,
+	}, pathToResourceURI(validTarget), report)
+}
+
+// checkSyncPaths - El origen debe ser un directorio, el destino un directorio o
+// nada, y ninguno puede contener al otro
+func checkSyncPaths(source, target string) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("source is not a directory: %s", source)
+	}
+	if info, err := os.Stat(target); err == nil && !info.IsDir() {
+		return fmt.Errorf("target is not a directory: %s", target)
+	}
+	if pathWithinDir(target, source) || pathWithinDir(source, target) {
+		return fmt.Errorf("source and target must not contain each other")
+	}
+	return nil
+}
+
+// smartSync - Compara destino (A) con origen (B), de modo que los renombrados
+// van de la ruta del destino a la del origen, clasifica cada diferencia según
+// el modo y, salvo en preview, la aplica. Los fallos de una entrada se anotan
+// en Errors y no detienen el resto.
+func (fs *FilesystemHandler) smartSync(ctx context.Context, source, target, mode string, dirOpts dirCompareOptions) (*SyncReport, error) {
+	dirDiff, err := fs.compareDirectories(ctx, target, source, dirOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SyncReport{
+		Source:    source,
+		Target:    target,
+		Mode:      mode,
+		Copied:    dirDiff.OnlyInB,
+		Updated:   []string{},
+		Renamed:   dirDiff.Renamed,
+		Deleted:   []string{},
+		Kept:      []string{},
+		Conflicts: []SyncConflict{},
+		Unchanged: dirDiff.Identical,
+	}
+	if mode == "overwrite" {
+		report.Deleted = dirDiff.OnlyInA
+	} else {
+		report.Kept = dirDiff.OnlyInA
+	}
+
+	// En merge gana el origen salvo que el destino sea más reciente
+	for _, entry := range dirDiff.Different {
+		switch {
+		case mode == "overwrite":
+			report.Updated = append(report.Updated, entry.Path)
+		case entry.Reason == "type":
+			report.Conflicts = append(report.Conflicts, fs.describeSyncConflict(source, target, entry.Path, "file in one tree, directory in the other"))
+		case syncTargetNewer(filepath.Join(source, entry.Path), filepath.Join(target, entry.Path)):
+			report.Conflicts = append(report.Conflicts, fs.describeSyncConflict(source, target, entry.Path, "target was modified after the source"))
+		default:
+			report.Updated = append(report.Updated, entry.Path)
+		}
+	}
+
+	if mode == "preview" {
+		return report, nil
+	}
+	report.Applied = true
+	if err := fs.mkdirAllChecked(target, 0755); err != nil {
+		return nil, err
+	}
+	fail := func(path string, err error) {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", path, err))
+	}
+
+	// Primero los renombrados, que vacían directorios que luego se borran o podan
+	for _, rename := range report.Renamed {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := fs.applySyncRename(source, target, rename); err != nil {
+			fail(rename.From, err)
+		}
+	}
+	for _, list := range [][]string{report.Copied, report.Updated} {
+		for _, entry := range list {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			rel := strings.TrimSuffix(entry, string(filepath.Separator))
+			if err := fs.syncCopy(ctx, source, target, rel, dirOpts.Exclude); err != nil {
+				fail(rel, err)
+			}
+		}
+	}
+	for _, entry := range report.Deleted {
+		rel := strings.TrimSuffix(entry, string(filepath.Separator))
+		if err := fs.removeChecked(filepath.Join(target, rel), true); err != nil {
+			fail(rel, err)
+		}
+	}
+	for _, rename := range report.Renamed {
+		fs.pruneVacatedDirs(source, target, rename.From)
+	}
+	return report, nil
+}
+
+// syncTargetNewer - Indica si el destino se modificó después que el origen
+func syncTargetNewer(source, target string) bool {
+	sourceInfo, errSource := os.Stat(source)
+	targetInfo, errTarget := os.Stat(target)
+	return errSource == nil && errTarget == nil && targetInfo.ModTime().After(sourceInfo.ModTime())
+}
+
+// applySyncRename - Mueve en el destino un archivo a la ruta que tiene en el
+// origen y le pone su fecha, para que la siguiente comparación lo vea idéntico
+func (fs *FilesystemHandler) applySyncRename(source, target string, rename DirectoryRename) error {
+	from := filepath.Join(target, rename.From)
+	to := filepath.Join(target, rename.To)
+	if err := fs.mkdirAllChecked(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	if err := fs.renameChecked(from, to); err != nil {
+		return err
+	}
+	if info, err := os.Stat(filepath.Join(source, rename.To)); err == nil {
+		os.Chtimes(to, info.ModTime(), info.ModTime())
+	}
+	return nil
+}
+
+// syncCopy - Copia rel del origen al destino, un archivo o un subárbol entero,
+// conservando las fechas de modificación. Cada archivo se escribe en un
+// temporal y se renombra, así que un fallo no deja archivos a medias.
+func (fs *FilesystemHandler) syncCopy(ctx context.Context, source, target, rel string, exclude []string) error {
+	src := filepath.Join(source, rel)
+	dst := filepath.Join(target, rel)
+
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		// Un directorio en el destino que ahora es archivo en el origen (modo overwrite)
+		if targetInfo, err := os.Lstat(dst); err == nil && targetInfo.IsDir() {
+			if err := fs.removeChecked(dst, true); err != nil {
+				return err
+			}
+		}
+		return fs.syncCopyFile(src, dst, info)
+	}
+
+	if targetInfo, err := os.Lstat(dst); err == nil && !targetInfo.IsDir() {
+		if err := fs.removeChecked(dst, false); err != nil {
+			return err
+		}
+	}
+	return fs.walkContext(ctx, src, func(path string, entry os.FileInfo, err error) error {
+		if err != nil {
+			fs.logWalkError(ctx, path, err)
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if path != src && (fs.isDeniedPath(path) || fs.shouldIgnoreEntry(ctx, path, entry.IsDir()) || matchesAnyPattern(source, path, exclude)) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		sub, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return fs.mkdirAllChecked(filepath.Join(target, sub), entry.Mode().Perm()|0700)
+		}
+		if !entry.Mode().IsRegular() {
+			return nil
+		}
+		return fs.syncCopyFile(path, filepath.Join(target, sub), entry)
+	})
+}
+
+// syncCopyFile - Copia un archivo regular a través de un temporal y le pone la
+// fecha de modificación del origen
+func (fs *FilesystemHandler) syncCopyFile(src, dst string, info os.FileInfo) error {
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("not a regular file")
+	}
+	if err := fs.mkdirAllChecked(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := fs.recheckPath(dst); err != nil {
+		return err
+	}
+
+	tempPath := dst + ".tmp"
+	defer fs.trackTemp(tempPath)()
+	n, err := copyFileHash(src, tempPath, nil)
+	if err == nil {
+		err = os.Chtimes(tempPath, info.ModTime(), info.ModTime())
+	}
+	if err == nil {
+		err = fs.renameChecked(tempPath, dst)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	fs.stats.addWritten(int(n))
+	return nil
+}
+
+// pruneVacatedDirs - Borra los directorios del destino que un renombrado dejó
+// vacíos y que no existen en el origen
+func (fs *FilesystemHandler) pruneVacatedDirs(source, target, rel string) {
+	for dir := filepath.Dir(rel); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		if _, err := os.Lstat(filepath.Join(source, dir)); err == nil {
+			return
+		}
+		// Remove no borra directorios con contenido
+		if err := fs.removeChecked(filepath.Join(target, dir), false); err != nil {
+			return
+		}
+	}
+}
+
+// formatSyncReport - Resumen legible de un SyncReport
+func (fs *FilesystemHandler) formatSyncReport(report *SyncReport) string {
+	var result strings.Builder
+	if report.Applied {
+		result.WriteString(fmt.Sprintf("🔄 Smart sync (%s): %s → %s\n", report.Mode, report.Source, report.Target))
+	} else {
+		result.WriteString(fmt.Sprintf("🔄 Smart sync preview: %s → %s\n", report.Source, report.Target))
+	}
+	result.WriteString(fmt.Sprintf("✅ Unchanged: %d | ➕ Copy: %d | ✏️ Update: %d | 🔀 Rename: %d | 🗑️ Delete: %d | 📌 Kept: %d | ⚠️ Conflicts: %d\n\n",
+		report.Unchanged, len(report.Copied), len(report.Updated), len(report.Renamed), len(report.Deleted), len(report.Kept), len(report.Conflicts)))
+
+	writeSection := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		result.WriteString(fmt.Sprintf("%s (%d):\n", title, len(lines)))
+		for _, line := range lines {
+			result.WriteString(fmt.Sprintf("  %s\n", line))
+		}
+		result.WriteString("\n")
+	}
+
+	renames := make([]string, 0, len(report.Renamed))
+	for _, rename := range report.Renamed {
+		renames = append(renames, fmt.Sprintf("%s → %s", rename.From, rename.To))
+	}
+	conflicts := make([]string, 0, len(report.Conflicts))
+	for _, conflict := range report.Conflicts {
+		conflicts = append(conflicts, fmt.Sprintf("%s (%s)", conflict.Path, conflict.Reason))
+	}
+	writeSection("🔀 Rename on target", renames)
+	writeSection("➕ Copy to target", report.Copied)
+	writeSection("✏️ Update on target", report.Updated)
+	writeSection("🗑️ Delete from target", report.Deleted)
+	writeSection("📌 Only in target, kept", report.Kept)
+	writeSection("⚠️ Conflicts, left untouched", conflicts)
+	writeSection("❌ Errors", report.Errors)
+	result.WriteString(fs.formatSyncConflicts(report.Conflicts))
+
+	if !report.Applied {
+		result.WriteString("ℹ️ Preview only, nothing was changed. Use mode 'merge' (keeps target-only files and newer target files) or 'overwrite' (mirrors the source) to apply.\n")
+	}
+	return result.String()
+}
@@ -0,0 +1,296 @@
+package filesystemserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Límites del diff que smart_sync adjunta a cada conflicto
+const (
+	syncConflictDiffMaxSize = 256 * 1024 // ambos lados deben ser de texto y no pasar de aquí
+	syncConflictDiffHunks   = 5          // hunks que se muestran; DiffHunks dice cuántos hay
+)
+
+// syncResolutions - Resoluciones de resolve_sync_conflict
+var syncResolutions = []string{"keep_source", "keep_target", "merged"}
+
+// syncConflictToken - Contenido del id de un conflicto. Como con los cursores,
+// el servidor no guarda nada: el id basta para resolverlo tras reconectar y es
+// el mismo en cada preview mientras ningún lado cambie.
+type syncConflictToken struct {
+	Source string `json:"s"`
+	Target string `json:"t"`
+	Path   string `json:"p"` // relativa a ambos directorios
+	State  string `json:"h"` // huella de los dos lados
+}
+
+// describeSyncConflict - Conflicto de rel con el estado de cada lado, su id y,
+// si ambos son archivos de texto pequeños, los primeros hunks del diff del
+// destino al origen
+func (fs *FilesystemHandler) describeSyncConflict(source, target, rel, reason string) SyncConflict {
+	name := strings.TrimSuffix(rel, string(filepath.Separator))
+	src, dst := filepath.Join(source, name), filepath.Join(target, name)
+	conflict := SyncConflict{
+		Path:   rel,
+		Reason: reason,
+		Source: syncConflictSide(src),
+		Target: syncConflictSide(dst),
+	}
+	conflict.ID = newSyncConflictID(source, target, name, conflict.Source, conflict.Target)
+	conflict.Diff, conflict.DiffHunks = syncConflictDiff(src, dst, name, conflict.Source, conflict.Target)
+	return conflict
+}
+
+// syncConflictSide - Tipo, tamaño, fecha y hash de un lado; nil si no se puede leer
+func syncConflictSide(path string) *SyncConflictSide {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil
+	}
+	side := &SyncConflictSide{Type: "file", Modified: info.ModTime()}
+	if info.IsDir() {
+		side.Type = "directory"
+		return side
+	}
+	side.Size = info.Size()
+	if hash, err := sha256File(path); err == nil {
+		side.SHA256 = hash
+	}
+	return side
+}
+
+// syncConflictState - Huella de ambos lados: tipo y contenido, no la fecha
+func syncConflictState(source, target *SyncConflictSide) string {
+	describe := func(side *SyncConflictSide) string {
+		if side == nil {
+			return "missing"
+		}
+		return side.Type + ":" + side.SHA256
+	}
+	sum := sha256.Sum256([]byte(describe(source) + "\x00" + describe(target)))
+	return hex.EncodeToString(sum[:12])
+}
+
+// newSyncConflictID - Id estable del conflicto de name entre source y target
+func newSyncConflictID(source, target, name string, sourceSide, targetSide *SyncConflictSide) string {
+	data, _ := json.Marshal(syncConflictToken{
+		Source: source,
+		Target: target,
+		Path:   filepath.ToSlash(name),
+		State:  syncConflictState(sourceSide, targetSide),
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// parseSyncConflictID - Decodifica un id de conflicto
+func parseSyncConflictID(id string) (*syncConflictToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid conflict id")
+	}
+	var token syncConflictToken
+	if err := json.Unmarshal(data, &token); err != nil || token.Source == "" || token.Target == "" || !filepath.IsLocal(filepath.FromSlash(token.Path)) {
+		return nil, fmt.Errorf("invalid conflict id")
+	}
+	return &token, nil
+}
+
+// syncConflictDiff - Diff unificado del destino al origen, limitado a los
+// primeros hunks, y el total de hunks; vacío si algún lado no es un archivo
+// de texto pequeño
+func syncConflictDiff(src, dst, name string, sourceSide, targetSide *SyncConflictSide) (string, int) {
+	for _, side := range []*SyncConflictSide{sourceSide, targetSide} {
+		if side == nil || side.Type != "file" || side.Size > syncConflictDiffMaxSize {
+			return "", 0
+		}
+	}
+	if !isTextFile(detectMimeType(src)) || !isTextFile(detectMimeType(dst)) {
+		return "", 0
+	}
+	targetLines, err := readFileLines(dst)
+	if err != nil {
+		return "", 0
+	}
+	sourceLines, err := readFileLines(src)
+	if err != nil {
+		return "", 0
+	}
+
+	hunks := diffLines(targetLines, sourceLines, diffOptions{ContextLines: 3}).Hunks
+	shown := hunks[:min(len(hunks), syncConflictDiffHunks)]
+	label := filepath.ToSlash(name)
+	return renderUnifiedDiff("target/"+label, "source/"+label, shown), len(hunks)
+}
+
+// formatSyncConflicts - Detalle de los conflictos: id, estado de cada lado y diff
+func (fs *FilesystemHandler) formatSyncConflicts(conflicts []SyncConflict) string {
+	if len(conflicts) == 0 {
+		return ""
+	}
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("🧩 Conflict details (resolve with %s):\n", fs.toolName("resolve_sync_conflict")))
+	for _, conflict := range conflicts {
+		result.WriteString(fmt.Sprintf("\n── %s ──\n", conflict.Path))
+		result.WriteString(fmt.Sprintf("  🆔 %s\n", conflict.ID))
+		for _, side := range []struct {
+			label string
+			info  *SyncConflictSide
+		}{{"source", conflict.Source}, {"target", conflict.Target}} {
+			switch {
+			case side.info == nil:
+				result.WriteString(fmt.Sprintf("  %s: unreadable\n", side.label))
+			case side.info.Type == "directory":
+				result.WriteString(fmt.Sprintf("  %s: directory, modified %s\n", side.label, side.info.Modified.Format("2006-01-02 15:04:05")))
+			default:
+				result.WriteString(fmt.Sprintf("  %s: %d bytes, modified %s, sha256 %.12s\n", side.label, side.info.Size, side.info.Modified.Format("2006-01-02 15:04:05"), side.info.SHA256))
+			}
+		}
+		if conflict.Diff != "" {
+			result.WriteString(conflict.Diff)
+			if conflict.DiffHunks > syncConflictDiffHunks {
+				result.WriteString(fmt.Sprintf("  … %d more hunk(s)\n", conflict.DiffHunks-syncConflictDiffHunks))
+			}
+		}
+	}
+	result.WriteString("\n")
+	return result.String()
+}
+
+// handleResolveSyncConflict - Resuelve un conflicto de smart_sync por su id:
+// copia el origen sobre el destino, deja el destino o escribe en él el
+// contenido fusionado, siempre con un temporal y un rename
+func (fs *FilesystemHandler) handleResolveSyncConflict(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := getString(request.Params.Arguments, "id", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	resolution, err := getString(request.Params.Arguments, "resolution", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	merged, err := getString(request.Params.Arguments, "merged_content", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	hasMerged := request.Params.Arguments["merged_content"] != nil
+	// "merged_content" como resolución equivale a "merged"; basta con pasar el contenido
+	if resolution == "merged_content" || (resolution == "" && hasMerged) {
+		resolution = "merged"
+	}
+
+	fail := func(format string, args ...interface{}) (*mcp.CallToolResult, error) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: " + fmt.Sprintf(format, args...)},
+			},
+			IsError: true,
+		}, nil
+	}
+	if id == "" {
+		return fail("id is required")
+	}
+	if !containsString(syncResolutions, resolution) {
+		return fail("unknown resolution %q (use keep_source, keep_target or merged with merged_content)", resolution)
+	}
+	if resolution == "merged" && !hasMerged {
+		return fail("merged_content is required for the merged resolution")
+	}
+	if resolution != "merged" && hasMerged {
+		return fail("merged_content only goes with the merged resolution")
+	}
+
+	token, err := parseSyncConflictID(id)
+	if err != nil {
+		return fail("%v", err)
+	}
+	name := filepath.FromSlash(token.Path)
+	src, err := fs.validatePath(filepath.Join(token.Source, name))
+	if err != nil {
+		return fail("%v", err)
+	}
+	dst, err := fs.validatePath(filepath.Join(token.Target, name))
+	if err != nil {
+		return fail("%v", err)
+	}
+
+	unlock := fs.lockPath(dst)
+	defer unlock()
+
+	// El id lleva la huella de ambos lados: si alguno cambió, la decisión se
+	// tomó sobre otro contenido
+	sourceSide, targetSide := syncConflictSide(src), syncConflictSide(dst)
+	if syncConflictState(sourceSide, targetSide) != token.State {
+		return fail("%s changed since the conflict was reported; run %s in preview mode again for a fresh id", token.Path, fs.toolName("smart_sync"))
+	}
+
+	switch resolution {
+	case "keep_source":
+		if sourceSide == nil {
+			return fail("source %s can no longer be read", token.Path)
+		}
+		// syncCopy ya sustituye un archivo por un directorio y al revés
+		if err := fs.syncCopy(ctx, token.Source, token.Target, name, nil); err != nil {
+			return fail("%v", err)
+		}
+	case "merged":
+		if targetSide != nil && targetSide.Type != "file" {
+			return fail("merged_content needs a file on the target, %s is a directory", token.Path)
+		}
+		if err := fs.writeSyncMerged(dst, merged); err != nil {
+			return fail("%v", err)
+		}
+	}
+
+	result := SyncResolution{ID: id, Path: token.Path, Resolution: resolution, Target: dst}
+	if side := syncConflictSide(dst); side != nil {
+		result.SHA256 = side.SHA256
+	}
+
+	var text string
+	switch resolution {
+	case "keep_source":
+		text = fmt.Sprintf("✅ Conflict resolved: %s replaced with the source version", dst)
+	case "keep_target":
+		text = fmt.Sprintf("✅ Conflict resolved: %s kept as it is; merge syncs keep reporting it while it is newer than the source and differs from it", dst)
+	case "merged":
+		text = fmt.Sprintf("✅ Conflict resolved: merged content (%d bytes) written to %s; merge syncs keep reporting it until the source has the same content", len(merged), dst)
+	}
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: text},
+		},
+	}, pathToResourceURI(dst), result)
+}
+
+// writeSyncMerged - Escribe el contenido fusionado en un temporal junto a dst
+// y lo renombra encima, conservando los permisos de dst
+func (fs *FilesystemHandler) writeSyncMerged(dst, content string) error {
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(dst); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := fs.mkdirAllChecked(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	tempPath := dst + ".tmp"
+	defer fs.trackTemp(tempPath)()
+	err := fs.writeFileChecked(tempPath, []byte(content), perm)
+	if err == nil {
+		err = fs.renameChecked(tempPath, dst)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
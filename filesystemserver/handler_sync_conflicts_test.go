@@ -0,0 +1,178 @@
+package filesystemserver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSyncConflict creates a source and a target whose edited.txt was
+// changed on both sides, the target last, and returns the preview args
+func writeSyncConflict(t *testing.T, dir, sourceContent, targetContent string) map[string]interface{} {
+	t.Helper()
+	writeFixture(t, dir, map[string]string{
+		"source/edited.txt": sourceContent,
+		"target/edited.txt": targetContent,
+	})
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "source", "edited.txt"), past, past))
+	return map[string]interface{}{
+		"source": filepath.Join(dir, "source"), "target": filepath.Join(dir, "target"), "mode": "merge",
+	}
+}
+
+// resolveConflict calls resolve_sync_conflict
+func resolveConflict(t *testing.T, handler *FilesystemHandler, args map[string]interface{}) (string, bool) {
+	t.Helper()
+	return callText(t, handler.handleResolveSyncConflict, "resolve_sync_conflict", args)
+}
+
+func TestSmartSyncConflictDetails(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	var source, target []string
+	for i := 0; i < 80; i++ {
+		source = append(source, fmt.Sprintf("line %d", i))
+		target = append(target, fmt.Sprintf("line %d", i))
+	}
+	for i := 5; i < 80; i += 10 {
+		target[i] = "changed on target"
+	}
+	args := writeSyncConflict(t, dir, strings.Join(source, "\n")+"\n", strings.Join(target, "\n")+"\n")
+
+	report := runSync(t, handler, args)
+	require.Len(t, report.Conflicts, 1)
+	conflict := report.Conflicts[0]
+	assert.NotEmpty(t, conflict.ID)
+	require.NotNil(t, conflict.Source)
+	require.NotNil(t, conflict.Target)
+	assert.Equal(t, "file", conflict.Target.Type)
+	assert.Equal(t, int64(len(strings.Join(target, "\n"))+1), conflict.Target.Size)
+	hash, err := sha256File(filepath.Join(dir, "target", "edited.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, hash, conflict.Target.SHA256)
+	assert.True(t, conflict.Target.Modified.After(conflict.Source.Modified))
+
+	// Ocho cambios separados dan ocho hunks; solo se muestran los primeros
+	assert.Equal(t, 8, conflict.DiffHunks)
+	assert.Equal(t, syncConflictDiffHunks, strings.Count(conflict.Diff, "@@ -"))
+	assert.Contains(t, conflict.Diff, "--- target/edited.txt")
+	assert.Contains(t, conflict.Diff, "+++ source/edited.txt")
+	assert.Contains(t, conflict.Diff, "-changed on target\n+line 5\n")
+
+	// Un segundo preview da el mismo id
+	args["mode"] = "preview"
+	again := runSync(t, handler, args)
+	require.Len(t, again.Conflicts, 1)
+	assert.Equal(t, conflict.ID, again.Conflicts[0].ID)
+
+	text, isError := callText(t, handler.handleSmartSync, "smart_sync", args)
+	require.False(t, isError, text)
+	assert.Contains(t, text, "🆔 "+conflict.ID)
+	assert.Contains(t, text, "… 3 more hunk(s)")
+}
+
+func TestResolveSyncConflict(t *testing.T) {
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want string
+	}{
+		{"keep source", map[string]interface{}{"resolution": "keep_source"}, "source version\n"},
+		{"keep target", map[string]interface{}{"resolution": "keep_target"}, "edited on target\n"},
+		{"merged", map[string]interface{}{"resolution": "merged", "merged_content": "both\n"}, "both\n"},
+		{"merged content alone", map[string]interface{}{"merged_content": "both\n"}, "both\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, dir := newTestHandler(t)
+			args := writeSyncConflict(t, dir, "source version\n", "edited on target\n")
+			require.NoError(t, os.Chmod(filepath.Join(dir, "target", "edited.txt"), 0600))
+			report := runSync(t, handler, args)
+			require.Len(t, report.Conflicts, 1)
+
+			tt.args["id"] = report.Conflicts[0].ID
+			text, isError := resolveConflict(t, handler, tt.args)
+			require.False(t, isError, text)
+			assert.Contains(t, text, "✅ Conflict resolved")
+			targetFile := filepath.Join(dir, "target", "edited.txt")
+			assertFileContent(t, targetFile, tt.want)
+			assert.Empty(t, tempFilesIn(t, filepath.Join(dir, "target")))
+			if _, merged := tt.args["merged_content"]; merged {
+				info, err := os.Stat(targetFile)
+				require.NoError(t, err)
+				assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "merged content keeps the target's permissions")
+			}
+
+			report = runSync(t, handler, args)
+			// Sync es en un sentido: solo keep_source deja el destino igual que el origen
+			if tt.want == "source version\n" {
+				assert.Empty(t, report.Conflicts)
+			} else {
+				require.Len(t, report.Conflicts, 1)
+				assert.Equal(t, tt.want == "edited on target\n", tt.args["id"] == report.Conflicts[0].ID, "the id changes only with the content")
+			}
+		})
+	}
+}
+
+func TestResolveSyncConflictTypeMismatch(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{
+		"source/item/a.txt": "a",
+		"target/item":       "a file on the target",
+	})
+	report := runSync(t, handler, map[string]interface{}{
+		"source": filepath.Join(dir, "source"), "target": filepath.Join(dir, "target"),
+	})
+	require.Len(t, report.Conflicts, 1)
+	assert.Empty(t, report.Conflicts[0].Diff)
+	assert.Equal(t, "directory", report.Conflicts[0].Source.Type)
+
+	text, isError := resolveConflict(t, handler, map[string]interface{}{"id": report.Conflicts[0].ID, "resolution": "keep_source"})
+	require.False(t, isError, text)
+	assertFileContent(t, filepath.Join(dir, "target", "item", "a.txt"), "a")
+}
+
+func TestResolveSyncConflictRejects(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	args := writeSyncConflict(t, dir, "source version\n", "edited on target\n")
+	report := runSync(t, handler, args)
+	require.Len(t, report.Conflicts, 1)
+	id := report.Conflicts[0].ID
+
+	outside := newSyncConflictID(t.TempDir(), filepath.Join(dir, "target"), "edited.txt", nil, nil)
+	escape := newSyncConflictID(filepath.Join(dir, "source"), filepath.Join(dir, "target"), "../edited.txt", nil, nil)
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want string
+	}{
+		{"missing id", map[string]interface{}{"resolution": "keep_source"}, "id is required"},
+		{"bad resolution", map[string]interface{}{"id": id, "resolution": "keep_both"}, "unknown resolution"},
+		{"merged without content", map[string]interface{}{"id": id, "resolution": "merged"}, "merged_content is required"},
+		{"content with keep", map[string]interface{}{"id": id, "resolution": "keep_source", "merged_content": "x"}, "only goes with the merged resolution"},
+		{"garbage id", map[string]interface{}{"id": "not-an-id!", "resolution": "keep_source"}, "invalid conflict id"},
+		{"escaping path", map[string]interface{}{"id": escape, "resolution": "keep_source"}, "invalid conflict id"},
+		{"outside allowed dirs", map[string]interface{}{"id": outside, "resolution": "keep_source"}, "access denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, isError := resolveConflict(t, handler, tt.args)
+			assert.True(t, isError)
+			assert.Contains(t, text, tt.want)
+		})
+	}
+
+	// Si el destino cambia después del preview, el id ya no vale
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "target", "edited.txt"), []byte("edited again\n"), 0644))
+	text, isError := resolveConflict(t, handler, map[string]interface{}{"id": id, "resolution": "keep_source"})
+	assert.True(t, isError)
+	assert.Contains(t, text, "changed since the conflict was reported")
+	assertFileContent(t, filepath.Join(dir, "target", "edited.txt"), "edited again\n")
+}
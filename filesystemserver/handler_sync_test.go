@@ -0,0 +1,150 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runSync calls smart_sync and decodes its report
+func runSync(t *testing.T, handler *FilesystemHandler, args map[string]interface{}) SyncReport {
+	t.Helper()
+	result, err := handler.handleSmartSync(context.Background(), newToolRequest("smart_sync", args))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	var report SyncReport
+	decodeStructured(t, result, &report)
+	return report
+}
+
+// assertFileContent checks the whole content of a file
+func assertFileContent(t *testing.T, path, want string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, want, string(data))
+}
+
+func TestSmartSyncRenamesOnTarget(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	source := filepath.Join(dir, "source")
+	target := filepath.Join(dir, "target")
+	writeFixture(t, dir, map[string]string{
+		"source/docs/new.txt": "renamed content",
+		"source/fresh.txt":    "another content",
+		"target/old/a.txt":    "renamed content",
+		"target/extra.txt":    "target only",
+	})
+	before, err := os.Stat(filepath.Join(target, "old", "a.txt"))
+	require.NoError(t, err)
+	args := map[string]interface{}{"source": source, "target": target}
+
+	report := runSync(t, handler, args)
+	assert.False(t, report.Applied)
+	assert.Equal(t, []DirectoryRename{{From: filepath.Join("old", "a.txt"), To: filepath.Join("docs", "new.txt"), Size: 15}}, report.Renamed)
+	assert.Equal(t, []string{"fresh.txt"}, report.Copied)
+	assert.Equal(t, []string{"extra.txt"}, report.Kept)
+	assert.FileExists(t, filepath.Join(target, "old", "a.txt"), "preview changes nothing")
+
+	args["mode"] = "merge"
+	report = runSync(t, handler, args)
+	assert.True(t, report.Applied)
+	assert.Empty(t, report.Errors)
+	after, err := os.Stat(filepath.Join(target, "docs", "new.txt"))
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(before, after), "the file was renamed, not copied")
+	assert.NoDirExists(t, filepath.Join(target, "old"))
+	assert.FileExists(t, filepath.Join(target, "extra.txt"))
+	assertFileContent(t, filepath.Join(target, "fresh.txt"), "another content")
+	assert.Empty(t, tempFilesIn(t, target))
+
+	delete(args, "mode")
+	report = runSync(t, handler, args)
+	assert.Empty(t, report.Renamed)
+	assert.Empty(t, report.Copied)
+	assert.Empty(t, report.Updated)
+	assert.Equal(t, 2, report.Unchanged)
+}
+
+func TestSmartSyncDetectRenamesDisabled(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{
+		"source/new.txt": "renamed content",
+		"target/old.txt": "renamed content",
+	})
+
+	report := runSync(t, handler, map[string]interface{}{
+		"source": filepath.Join(dir, "source"), "target": filepath.Join(dir, "target"),
+		"mode": "overwrite", "detect_renames": false,
+	})
+	assert.Empty(t, report.Renamed)
+	assert.Equal(t, []string{"new.txt"}, report.Copied)
+	assert.Equal(t, []string{"old.txt"}, report.Deleted)
+	assert.FileExists(t, filepath.Join(dir, "target", "new.txt"))
+	assert.NoFileExists(t, filepath.Join(dir, "target", "old.txt"))
+}
+
+func TestSmartSyncConflicts(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	source := filepath.Join(dir, "source")
+	target := filepath.Join(dir, "target")
+	writeFixture(t, dir, map[string]string{
+		"source/stale.txt":  "source v2",
+		"target/stale.txt":  "source v1",
+		"source/edited.txt": "source version",
+		"target/edited.txt": "edited on target",
+		"source/skip.log":   "excluded",
+	})
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(target, "stale.txt"), past, past))
+	require.NoError(t, os.Chtimes(filepath.Join(source, "edited.txt"), past, past))
+	args := map[string]interface{}{
+		"source": source, "target": target, "mode": "merge",
+		"exclude_patterns": []interface{}{"*.log"},
+	}
+
+	report := runSync(t, handler, args)
+	assert.Equal(t, []string{"stale.txt"}, report.Updated)
+	require.Len(t, report.Conflicts, 1)
+	assert.Equal(t, "edited.txt", report.Conflicts[0].Path)
+	assert.Equal(t, "target was modified after the source", report.Conflicts[0].Reason)
+	assert.Empty(t, report.Copied)
+	assertFileContent(t, filepath.Join(target, "stale.txt"), "source v2")
+	assertFileContent(t, filepath.Join(target, "edited.txt"), "edited on target")
+	assert.NoFileExists(t, filepath.Join(target, "skip.log"))
+
+	args["mode"] = "overwrite"
+	report = runSync(t, handler, args)
+	assert.Equal(t, []string{"edited.txt"}, report.Updated)
+	assert.Empty(t, report.Conflicts)
+	assertFileContent(t, filepath.Join(target, "edited.txt"), "source version")
+}
+
+func TestSmartSyncRejects(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{"source/a.txt": "a", "file.txt": "f"})
+	source := filepath.Join(dir, "source")
+
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want string
+	}{
+		{"missing target", map[string]interface{}{"source": source}, "source and target are required"},
+		{"bad mode", map[string]interface{}{"source": source, "target": filepath.Join(dir, "t"), "mode": "push"}, "unknown mode"},
+		{"nested", map[string]interface{}{"source": source, "target": filepath.Join(source, "copy")}, "must not contain each other"},
+		{"file target", map[string]interface{}{"source": source, "target": filepath.Join(dir, "file.txt")}, "target is not a directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, isError := callText(t, handler.handleSmartSync, "smart_sync", tt.args)
+			assert.True(t, isError)
+			assert.Contains(t, text, tt.want)
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package filesystemserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultFollowDuration, defaultMaxFollowDuration - Espera de tail_follow si no se
+	// indica duration_ms, y el máximo permitido si la configuración no fija otro
+	defaultFollowDuration    = 10 * time.Second
+	defaultMaxFollowDuration = 60 * time.Second
+	// defaultFollowLines, maxFollowLines - Líneas nuevas que devuelve tail_follow
+	defaultFollowLines = 200
+	maxFollowLines     = 5000
+	// maxFollowLineLength - Una línea más larga se corta, para no acumular sin límite
+	maxFollowLineLength = 64 << 10
+)
+
+// followPollInterval - Cada cuánto tail_follow comprueba si el archivo creció; los tests lo acortan
+var followPollInterval = 250 * time.Millisecond
+
+// WithMaxFollowDuration caps how long tail_follow waits for new lines
+// (default 60s); the tool timeout still applies on top of it
+func WithMaxFollowDuration(d time.Duration) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		if d < 0 {
+			return fmt.Errorf("max follow duration must not be negative")
+		}
+		fs.maxFollowDuration = d
+		return nil
+	}
+}
+
+// maxFollow - Duración máxima de tail_follow configurada o por defecto
+func (fs *FilesystemHandler) maxFollow() time.Duration {
+	if fs.maxFollowDuration > 0 {
+		return fs.maxFollowDuration
+	}
+	return defaultMaxFollowDuration
+}
+
+// handleTailFollow - Espera líneas nuevas al final de un archivo durante un
+// tiempo acotado y devuelve las añadidas
+func (fs *FilesystemHandler) handleTailFollow(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	pattern, err := getString(request.Params.Arguments, "pattern", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	ignoreCase, err := getBool(request.Params.Arguments, "ignore_case", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	durationMs, err := getNumber(request.Params.Arguments, "duration_ms", 0)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	duration := defaultFollowDuration
+	if durationMs > 0 {
+		duration = time.Duration(durationMs) * time.Millisecond
+	}
+	duration = min(duration, fs.maxFollow())
+	maxLines, err := getInt(request.Params.Arguments, "max_lines", 0)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	if maxLines <= 0 {
+		maxLines = defaultFollowLines
+	}
+	maxLines = min(maxLines, maxFollowLines)
+
+	if path == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: path is required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var filter *regexp.Regexp
+	if pattern != "" {
+		if ignoreCase {
+			pattern = "(?i)" + pattern
+		}
+		var err error
+		if filter, err = compileUserPattern(pattern); err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: invalid pattern: %v", err)},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	if info, err := os.Stat(validPath); err != nil || !info.Mode().IsRegular() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: Path must be a regular file"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	result, err := fs.followFile(ctx, validPath, duration, filter, maxLines)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatTailFollow(result)},
+		},
+	}, pathToResourceURI(validPath), result)
+}
+
+// followState - Posición leída del archivo seguido y la línea incompleta pendiente
+type followState struct {
+	path     string
+	info     os.FileInfo // archivo que se está siguiendo, para detectar rotaciones
+	offset   int64
+	pending  []byte
+	overlong bool // la línea pendiente ya se cortó; el resto hasta el salto se descarta
+}
+
+// followFile - Parte del tamaño actual de path y, consultándolo cada
+// followPollInterval, acumula las líneas añadidas hasta agotar duration, llegar
+// a maxLines o cancelarse ctx. Si el archivo encoge o lo sustituye otro
+// (rotación) se vuelve a leer desde el principio.
+func (fs *FilesystemHandler) followFile(ctx context.Context, path string, duration time.Duration, filter *regexp.Regexp, maxLines int) (*TailFollowResult, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	state := &followState{path: path, info: info, offset: info.Size()}
+	result := &TailFollowResult{Path: path, Lines: []string{}}
+	if filter != nil {
+		result.Pattern = filter.String()
+	}
+
+	start := time.Now()
+	deadline := time.NewTimer(duration)
+	defer deadline.Stop()
+	ticker := time.NewTicker(followPollInterval)
+	defer ticker.Stop()
+
+	// add - Cuenta y filtra una línea completa; false al llegar a maxLines
+	add := func(line string) bool {
+		result.LinesRead++
+		if filter != nil && !filter.MatchString(line) {
+			return true
+		}
+		result.Lines = append(result.Lines, line)
+		return len(result.Lines) < maxLines
+	}
+
+	for result.StoppedBy == "" {
+		select {
+		case <-ctx.Done():
+			result.StoppedBy = "cancelled"
+		case <-deadline.C:
+			result.StoppedBy = "duration"
+		case <-ticker.C:
+		}
+		// Lo añadido hasta el final también cuenta, también al terminar
+		if !fs.readAppended(ctx, state, result, add) {
+			result.StoppedBy = "max_lines"
+		}
+	}
+
+	// La última línea sin salto de línea se devuelve tal cual
+	if len(state.pending) > 0 && len(result.Lines) < maxLines {
+		add(string(state.pending))
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// readAppended - Lee lo añadido desde la última consulta, por bloques, pasando
+// cada línea completa a add; false si add pidió parar
+func (fs *FilesystemHandler) readAppended(ctx context.Context, state *followState, result *TailFollowResult, add func(string) bool) bool {
+	info, err := os.Stat(state.path)
+	if err != nil {
+		// Rotado y aún sin recrear: se sigue esperando
+		return true
+	}
+	if !os.SameFile(state.info, info) || info.Size() < state.offset {
+		result.Rotated = true
+		result.Rotations++
+		state.offset, state.pending, state.overlong = 0, nil, false
+	}
+	state.info = info
+	if info.Size() == state.offset {
+		return true
+	}
+
+	file, err := os.Open(state.path)
+	if err != nil {
+		return true
+	}
+	defer file.Close()
+	reader := io.NewSectionReader(file, state.offset, info.Size()-state.offset)
+	buf := make([]byte, 64<<10)
+	for {
+		n, err := reader.Read(buf)
+		state.offset += int64(n)
+		progressFrom(ctx).read(state.path, int64(n))
+		chunk := buf[:n]
+		for len(chunk) > 0 {
+			i := bytes.IndexByte(chunk, '\n')
+			if i < 0 {
+				state.appendPending(chunk)
+				break
+			}
+			state.appendPending(chunk[:i])
+			chunk = chunk[i+1:]
+			line := strings.TrimSuffix(string(state.pending), "\r")
+			if state.overlong {
+				line += "…"
+			}
+			state.pending, state.overlong = state.pending[:0], false
+			if !add(line) {
+				return false
+			}
+		}
+		if err != nil {
+			return true
+		}
+	}
+}
+
+// appendPending - Añade data a la línea pendiente, cortándola en maxFollowLineLength bytes
+func (s *followState) appendPending(data []byte) {
+	if room := maxFollowLineLength - len(s.pending); len(data) > room {
+		data, s.overlong = data[:max(room, 0)], true
+	}
+	s.pending = append(s.pending, data...)
+}
+
+// formatTailFollow - Resumen y líneas nuevas de tail_follow
+func formatTailFollow(result *TailFollowResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "👀 Followed %s for %s: %d new lines", safeDisplayName(result.Path), (time.Duration(result.DurationMs) * time.Millisecond).String(), result.LinesRead)
+	if result.Pattern != "" {
+		fmt.Fprintf(&b, ", %d matching %s", len(result.Lines), result.Pattern)
+	}
+	b.WriteString("\n")
+	switch result.StoppedBy {
+	case "max_lines":
+		fmt.Fprintf(&b, "✂️ Stopped at max_lines (%d)\n", len(result.Lines))
+	case "cancelled":
+		b.WriteString("⏹️ Stopped early: the call was cancelled\n")
+	}
+	if result.Rotated {
+		fmt.Fprintf(&b, "🔄 File was truncated or rotated (%d) and reopened from the start\n", result.Rotations)
+	}
+	if len(result.Lines) == 0 {
+		b.WriteString("\n(no new lines)\n")
+		return b.String()
+	}
+	b.WriteString("\n")
+	for _, line := range result.Lines {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,107 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// appendLog appends text to path after delay, from another goroutine
+func appendLog(t *testing.T, path string, delay time.Duration, text string) {
+	t.Helper()
+	go func() {
+		time.Sleep(delay)
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return
+		}
+		f.WriteString(text)
+		f.Close()
+	}()
+}
+
+func TestTailFollow(t *testing.T) {
+	followPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { followPollInterval = 250 * time.Millisecond })
+	handler, dir := newTestHandler(t)
+	log := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(log, []byte("old ERROR line\n"), 0644))
+
+	appendLog(t, log, 30*time.Millisecond, "INFO start\nERROR boom\nINFO ok\nERROR again\nERROR third\n")
+	result, err := handler.followFile(context.Background(), log, 5*time.Second, compileTestPattern(t, "ERROR"), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ERROR boom", "ERROR again"}, result.Lines, "old lines are not returned")
+	assert.Equal(t, "max_lines", result.StoppedBy)
+	assert.Equal(t, 4, result.LinesRead)
+	assert.False(t, result.Rotated)
+
+	// Una línea sin salto al final se devuelve al terminar
+	appendLog(t, log, 20*time.Millisecond, "partial")
+	text, isError := callText(t, handler.handleTailFollow, "tail_follow", map[string]interface{}{"path": log, "duration_ms": float64(150)})
+	require.False(t, isError, text)
+	assert.Contains(t, text, ": 1 new lines\n\npartial\n")
+}
+
+func compileTestPattern(t *testing.T, pattern string) *regexp.Regexp {
+	t.Helper()
+	re, err := compileUserPattern(pattern)
+	require.NoError(t, err)
+	return re
+}
+
+func TestTailFollowRotation(t *testing.T) {
+	followPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { followPollInterval = 250 * time.Millisecond })
+	handler, dir := newTestHandler(t)
+	log := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(log, []byte("a long line written before following\n"), 0644))
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		os.Rename(log, log+".1")
+		os.WriteFile(log, []byte("fresh 1\nfresh 2\n"), 0644)
+	}()
+	text, isError := callText(t, handler.handleTailFollow, "tail_follow", map[string]interface{}{"path": log, "duration_ms": float64(200)})
+	require.False(t, isError, text)
+	assert.Contains(t, text, "🔄 File was truncated or rotated (1) and reopened from the start\n\nfresh 1\nfresh 2\n")
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		os.WriteFile(log, []byte("x\n"), 0644)
+	}()
+	result, err := handler.followFile(context.Background(), log, 200*time.Millisecond, nil, 10)
+	require.NoError(t, err)
+	assert.True(t, result.Rotated, "truncated in place")
+	assert.Equal(t, []string{"x"}, result.Lines)
+}
+
+func TestTailFollowStopsEarly(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	log := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(log, nil, 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	result, err := handler.followFile(ctx, log, time.Minute, nil, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", result.StoppedBy)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	require.NoError(t, WithMaxFollowDuration(100*time.Millisecond)(handler))
+	start = time.Now()
+	text, isError := callText(t, handler.handleTailFollow, "tail_follow", map[string]interface{}{"path": log, "duration_ms": float64(60000)})
+	require.False(t, isError, text)
+	assert.Contains(t, text, "(no new lines)")
+	assert.Less(t, time.Since(start), 5*time.Second, "duration is capped by the server")
+
+	text, isError = callText(t, handler.handleTailFollow, "tail_follow", map[string]interface{}{"path": dir})
+	assert.True(t, isError)
+	assert.Contains(t, text, "must be a regular file")
+}
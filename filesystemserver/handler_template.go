@@ -0,0 +1,420 @@
+package filesystemserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxTemplateSize - Tamaño máximo de cada plantilla leída de disco
+const maxTemplateSize = 1024 * 1024
+
+// templateSuffix - Extensión que se quita del nombre al renderizar un árbol
+const templateSuffix = ".tmpl"
+
+// renderedFile - Archivo renderizado pendiente de escribir
+type renderedFile struct {
+	path    string
+	content string
+	perm    os.FileMode
+}
+
+// handleRenderTemplate - Renderiza una plantilla (en línea o de archivo) y escribe el resultado
+func (fs *FilesystemHandler) handleRenderTemplate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	text, err := getString(request.Params.Arguments, "template", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	hasText := request.Params.Arguments["template"] != nil
+	templatePath, err := getString(request.Params.Arguments, "template_path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	outputPath, err := getString(request.Params.Arguments, "output_path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	variables, err := getObject(request.Params.Arguments, "variables")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	dryRun, err := getBool(request.Params.Arguments, "dry_run", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	overwrite, err := getBool(request.Params.Arguments, "overwrite", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if hasText == (templatePath != "") {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: provide exactly one of template or template_path"},
+			},
+			IsError: true,
+		}, nil
+	}
+	if outputPath == "" && !dryRun {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: output_path is required unless dry_run is set"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	name, source := "template", ""
+	perm := os.FileMode(0644)
+	if templatePath != "" {
+		validTemplate, err := fs.validatePath(templatePath)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+				},
+				IsError: true,
+			}, nil
+		}
+		if info, err := os.Stat(validTemplate); err == nil && info.IsDir() {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: template_path is a directory; use %s to render a whole directory", fs.toolName("render_tree"))},
+				},
+				IsError: true,
+			}, nil
+		}
+		if text, perm, err = readTemplateFile(validTemplate); err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+				},
+				IsError: true,
+			}, nil
+		}
+		name, source = filepath.Base(validTemplate), validTemplate
+	}
+
+	content, err := renderTemplateText(name, text, variables)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	result := &RenderResult{Output: outputPath, Template: source, DryRun: dryRun}
+	files := []renderedFile{{path: outputPath, content: content, perm: perm}}
+	if outputPath != "" {
+		validOutput, err := fs.validateNewPath(outputPath)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+				},
+				IsError: true,
+			}, nil
+		}
+		result.Output = validOutput
+		files[0].path = validOutput
+	}
+
+	if err := fs.writeRendered(ctx, result, files, overwrite, "overwrite"); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatRenderResult(result)},
+		},
+	}, pathToResourceURI(result.Output), result)
+}
+
+// handleRenderTree - Renderiza cada archivo de un directorio de plantillas, nombres incluidos
+func (fs *FilesystemHandler) handleRenderTree(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	templatePath, err := getString(request.Params.Arguments, "template_path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	outputPath, err := getString(request.Params.Arguments, "output_path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	variables, err := getObject(request.Params.Arguments, "variables")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	dryRun, err := getBool(request.Params.Arguments, "dry_run", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	overwrite, err := getBool(request.Params.Arguments, "overwrite", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	if templatePath == "" || outputPath == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: template_path and output_path are required"},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validTemplate, err := fs.validatePath(templatePath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	validOutput, err := fs.validateNewPath(outputPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	if info, err := os.Stat(validTemplate); err != nil || !info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: template_path must be an existing directory; use %s for a single file", fs.toolName("render_template"))},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	files, err := fs.renderTree(ctx, validTemplate, validOutput, variables)
+	result := &RenderResult{Output: validOutput, Template: validTemplate, DryRun: dryRun}
+	if err == nil {
+		err = fs.writeRendered(ctx, result, files, overwrite, "overwrite")
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatRenderResult(result)},
+		},
+	}, pathToResourceURI(validOutput), result)
+}
+
+// renderTree - Renderiza en memoria todos los archivos de templateDir con destino
+// en outputDir; nada se escribe si falla cualquiera de ellos
+func (fs *FilesystemHandler) renderTree(ctx context.Context, templateDir, outputDir string, variables map[string]interface{}) ([]renderedFile, error) {
+	if outputDir == templateDir || strings.HasPrefix(outputDir, templateDir+string(filepath.Separator)) {
+		return nil, fmt.Errorf("output_path must be outside the template directory")
+	}
+
+	var files []renderedFile
+	err := fs.walkContext(ctx, templateDir, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if currentPath == templateDir {
+			return nil
+		}
+		if info.IsDir() {
+			if isServerDataDir(info.Name()) || fs.isDeniedPath(currentPath) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() || fs.isDeniedPath(currentPath) {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(templateDir, currentPath)
+		target, err := renderTemplatePath(rel, variables)
+		if err != nil {
+			return err
+		}
+
+		text, perm, err := readTemplateFile(currentPath)
+		if err != nil {
+			return err
+		}
+		content := text
+		// Los binarios (con bytes nulos) se copian tal cual
+		if !strings.Contains(text, "\x00") {
+			if content, err = renderTemplateText(filepath.ToSlash(rel), text, variables); err != nil {
+				return err
+			}
+		}
+
+		validTarget, err := fs.validateNewPath(filepath.Join(outputDir, target))
+		if err != nil {
+			return fmt.Errorf("%s: %v", rel, err)
+		}
+		files = append(files, renderedFile{path: validTarget, content: content, perm: perm})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no template files found in %s", templateDir)
+	}
+
+	// Dos plantillas no pueden acabar en el mismo destino
+	seen := make(map[string]bool, len(files))
+	for _, file := range files {
+		if seen[file.path] {
+			return nil, fmt.Errorf("several templates render to %s", file.path)
+		}
+		seen[file.path] = true
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	return files, nil
+}
+
+// writeRendered - Comprueba conflictos y escribe los archivos de forma atómica,
+// o solo los describe en modo dry_run; flag es el argumento que permite sobrescribir
+func (fs *FilesystemHandler) writeRendered(ctx context.Context, result *RenderResult, files []renderedFile, overwrite bool, flag string) error {
+	var conflicts []string
+	for _, file := range files {
+		entry := RenderedFile{Path: file.path, Size: len(file.content)}
+		if info, err := os.Lstat(file.path); err == nil {
+			if info.IsDir() {
+				return fmt.Errorf("%s is a directory", file.path)
+			}
+			entry.Overwritten = true
+			if !overwrite {
+				conflicts = append(conflicts, file.path)
+			}
+		}
+		if result.DryRun {
+			entry.Content = file.content
+		}
+		result.Files = append(result.Files, entry)
+	}
+	if len(conflicts) > 0 && !result.DryRun {
+		return fmt.Errorf("%d output file(s) already exist, pass %s=true to replace them: %s", len(conflicts), flag, strings.Join(conflicts, ", "))
+	}
+	if result.DryRun {
+		return nil
+	}
+
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fs.replaceFileChecked(file.path, file.content, file.perm); err != nil {
+			return fmt.Errorf("writing %s: %v", file.path, err)
+		}
+	}
+	return nil
+}
+
+// readTemplateFile - Lee una plantilla de disco respetando maxTemplateSize
+func readTemplateFile(path string) (string, os.FileMode, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0, err
+	}
+	if info.Size() > maxTemplateSize {
+		return "", 0, fmt.Errorf("template %s is %d bytes, the limit is %d", path, info.Size(), maxTemplateSize)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, err
+	}
+	return string(data), info.Mode().Perm(), nil
+}
+
+// renderTemplateText - Ejecuta text/template con missingkey=error, de modo que
+// una variable mal escrita falla en lugar de producir "<no value>"
+func renderTemplateText(name, text string, variables map[string]interface{}) (string, error) {
+	if variables == nil {
+		variables = map[string]interface{}{}
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, variables); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// renderTemplatePath - Renderiza cada componente de una ruta relativa y quita
+// la extensión .tmpl; un componente no puede quedar vacío ni salir del destino
+func renderTemplatePath(rel string, variables map[string]interface{}) (string, error) {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i, part := range parts {
+		if !strings.Contains(part, "{{") {
+			continue
+		}
+		rendered, err := renderTemplateText(rel, part, variables)
+		if err != nil {
+			return "", err
+		}
+		if rendered == "" || rendered == "." || rendered == ".." || strings.ContainsAny(rendered, `/\`) {
+			return "", fmt.Errorf("%s: file name renders to invalid %q", rel, rendered)
+		}
+		parts[i] = rendered
+	}
+	last := len(parts) - 1
+	if trimmed := strings.TrimSuffix(parts[last], templateSuffix); trimmed != "" {
+		parts[last] = trimmed
+	}
+	return filepath.FromSlash(strings.Join(parts, "/")), nil
+}
+
+// formatRenderResult - Archivos escritos o, en dry_run, su contenido
+func formatRenderResult(result *RenderResult) string {
+	var b strings.Builder
+	if result.DryRun {
+		b.WriteString(fmt.Sprintf("🔍 Dry run: %d file(s) would be written\n", len(result.Files)))
+	} else {
+		b.WriteString(fmt.Sprintf("✅ Rendered %d file(s)\n", len(result.Files)))
+	}
+	for _, file := range result.Files {
+		note := ""
+		if file.Overwritten {
+			note = " (overwrites existing file)"
+		}
+		if file.Path == "" {
+			b.WriteString(fmt.Sprintf("\n── %d bytes%s ──\n", file.Size, note))
+		} else {
+			b.WriteString(fmt.Sprintf("\n── %s (%d bytes)%s ──\n", file.Path, file.Size, note))
+		}
+		if result.DryRun {
+			b.WriteString(file.Content)
+			if !strings.HasSuffix(file.Content, "\n") {
+				b.WriteString("\n")
+			}
+		}
+	}
+	return b.String()
+}
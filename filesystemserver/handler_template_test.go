@@ -0,0 +1,186 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	output := filepath.Join(dir, "cmd", "main.go")
+
+	// dry_run devuelve el contenido sin escribir nada
+	result, err := handler.handleRenderTemplate(context.Background(), newToolRequest("render_template", map[string]interface{}{
+		"template":    "package {{.Package}}\n\n// {{.Name}} v{{.Version}}\n",
+		"variables":   map[string]interface{}{"Package": "main", "Name": "tool", "Version": float64(2)},
+		"output_path": output,
+		"dry_run":     true,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "package main\n\n// tool v2\n")
+	_, err = os.Stat(output)
+	assert.True(t, os.IsNotExist(err))
+
+	var decoded RenderResult
+	decodeStructured(t, result, &decoded)
+	assert.True(t, decoded.DryRun)
+	assert.Equal(t, "package main\n\n// tool v2\n", decoded.Files[0].Content)
+
+	result, err = handler.handleRenderTemplate(context.Background(), newToolRequest("render_template", map[string]interface{}{
+		"template":    "package {{.Package}}\n",
+		"variables":   map[string]interface{}{"Package": "main"},
+		"output_path": output,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(data))
+	assert.Empty(t, tempFilesIn(t, filepath.Dir(output)))
+
+	// Un archivo existente solo se sustituye con overwrite
+	result, err = handler.handleRenderTemplate(context.Background(), newToolRequest("render_template", map[string]interface{}{
+		"template":    "package other\n",
+		"output_path": output,
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "overwrite=true")
+
+	// Plantilla desde archivo
+	templateFile := filepath.Join(dir, "readme.md.tmpl")
+	require.NoError(t, os.WriteFile(templateFile, []byte("# {{.Title}}\n"), 0644))
+	result, err = handler.handleRenderTemplate(context.Background(), newToolRequest("render_template", map[string]interface{}{
+		"template_path": templateFile,
+		"variables":     map[string]interface{}{"Title": "Demo"},
+		"output_path":   filepath.Join(dir, "README.md"),
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	data, err = os.ReadFile(filepath.Join(dir, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Demo\n", string(data))
+}
+
+func TestRenderTemplateMissingVariable(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	output := filepath.Join(dir, "out.txt")
+
+	result, err := handler.handleRenderTemplate(context.Background(), newToolRequest("render_template", map[string]interface{}{
+		"template":    "Hello {{.Nmae}}\n",
+		"variables":   map[string]interface{}{"Name": "world"},
+		"output_path": output,
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, `map has no entry for key "Nmae"`)
+	_, err = os.Stat(output)
+	assert.True(t, os.IsNotExist(err))
+
+	for _, args := range []map[string]interface{}{
+		{"output_path": output},
+		{"template": "x", "template_path": output, "output_path": output},
+		{"template": "x"},
+		{"template": "{{.Broken", "dry_run": true},
+		{"template_path": dir, "output_path": output},
+	} {
+		result, err = handler.handleRenderTemplate(context.Background(), newToolRequest("render_template", args))
+		require.NoError(t, err)
+		assert.True(t, result.IsError, args)
+	}
+}
+
+func TestRenderTree(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	templates := filepath.Join(dir, "templates")
+	files := map[string]string{
+		"go.mod.tmpl":                     "module {{.Module}}\n",
+		"cmd/{{.Name}}/main.go.tmpl":      "package main // {{.Name}}\n",
+		"internal/{{.Name}}/{{.Name}}.go": "package {{.Name}}\n",
+		"assets/logo.bin":                 "\x00{{.Name}}",
+	}
+	for name, content := range files {
+		path := filepath.Join(templates, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	require.NoError(t, os.Chmod(filepath.Join(templates, "go.mod.tmpl"), 0600))
+	variables := map[string]interface{}{"Module": "example.com/demo", "Name": "demo"}
+	output := filepath.Join(dir, "demo")
+
+	result, err := handler.handleRenderTree(context.Background(), newToolRequest("render_tree", map[string]interface{}{
+		"template_path": templates,
+		"output_path":   output,
+		"variables":     variables,
+		"dry_run":       true,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	_, err = os.Stat(output)
+	assert.True(t, os.IsNotExist(err))
+
+	result, err = handler.handleRenderTree(context.Background(), newToolRequest("render_tree", map[string]interface{}{
+		"template_path": templates,
+		"output_path":   output,
+		"variables":     variables,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Rendered 4 file(s)")
+
+	for name, want := range map[string]string{
+		"go.mod":                "module example.com/demo\n",
+		"cmd/demo/main.go":      "package main // demo\n",
+		"internal/demo/demo.go": "package demo\n",
+		"assets/logo.bin":       "\x00{{.Name}}",
+	} {
+		data, err := os.ReadFile(filepath.Join(output, filepath.FromSlash(name)))
+		require.NoError(t, err, name)
+		assert.Equal(t, want, string(data), name)
+	}
+	info, err := os.Stat(filepath.Join(output, "go.mod"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Una variable que falta en un nombre de archivo aborta antes de escribir
+	result, err = handler.handleRenderTree(context.Background(), newToolRequest("render_tree", map[string]interface{}{
+		"template_path": templates,
+		"output_path":   filepath.Join(dir, "other"),
+		"variables":     map[string]interface{}{"Module": "x"},
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, `"Name"`)
+	_, err = os.Stat(filepath.Join(dir, "other"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Los archivos existentes se rechazan sin overwrite
+	result, err = handler.handleRenderTree(context.Background(), newToolRequest("render_tree", map[string]interface{}{
+		"template_path": templates,
+		"output_path":   output,
+		"variables":     variables,
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "4 output file(s) already exist")
+}
+
+func TestRenderTemplatePath(t *testing.T) {
+	variables := map[string]interface{}{"Name": "api", "Empty": "", "Escape": "../x"}
+
+	path, err := renderTemplatePath(filepath.FromSlash("{{.Name}}/handler_{{.Name}}.go.tmpl"), variables)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.FromSlash("api/handler_api.go"), path)
+
+	_, err = renderTemplatePath("{{.Empty}}", variables)
+	assert.ErrorContains(t, err, "invalid")
+	_, err = renderTemplatePath("{{.Escape}}.txt", variables)
+	assert.ErrorContains(t, err, "invalid")
+}
@@ -0,0 +1,307 @@
+package filesystemserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// truncateResult - Lo que hizo truncate_file
+type truncateResult struct {
+	before, after int64
+	skipped       int64 // bytes de la línea incompleta que keep_last_bytes descartó al alinear
+	zeroed        int64
+}
+
+// handleTruncateFile - Acorta un archivo sin pasar su contenido por el cliente:
+// a size bytes, a sus últimos keep_last_bytes bytes o poniendo a cero un rango
+func (fs *FilesystemHandler) handleTruncateFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	path, err := getString(args, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	alignLines, err := getBool(args, "align_to_line", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	size, err := getNumber(args, "size", 0)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	keep, err := getNumber(args, "keep_last_bytes", 0)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	zeroOffset, err := getNumber(args, "zero_offset", 0)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	zeroLength, err := getNumber(args, "zero_length", 0)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	hasSize, hasKeep := args["size"] != nil, args["keep_last_bytes"] != nil
+	hasZero, hasZeroLength := args["zero_offset"] != nil, args["zero_length"] != nil
+
+	modes := 0
+	for _, set := range []bool{hasSize, hasKeep, hasZero} {
+		if set {
+			modes++
+		}
+	}
+	wholeBytes := func(v float64) bool { return v >= 0 && v == float64(int64(v)) }
+	var problem string
+	switch {
+	case path == "":
+		problem = "path is required"
+	case modes != 1:
+		problem = "provide exactly one of size, keep_last_bytes or zero_offset"
+	case hasSize && !wholeBytes(size):
+		problem = "size must be a whole number of bytes, 0 or more"
+	case hasKeep && !wholeBytes(keep):
+		problem = "keep_last_bytes must be a whole number of bytes, 0 or more"
+	case hasZero && (!hasZeroLength || !wholeBytes(zeroOffset) || !wholeBytes(zeroLength)):
+		problem = "zero_offset and zero_length must both be whole numbers of bytes, 0 or more"
+	case alignLines && !hasKeep:
+		problem = "align_to_line only applies to keep_last_bytes"
+	}
+	if problem != "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: "❌ Error: " + problem},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err == nil {
+		var info os.FileInfo
+		if info, err = os.Lstat(validPath); err == nil && !info.Mode().IsRegular() {
+			err = fmt.Errorf("%s is not a regular file", path)
+		}
+	}
+	if err == nil && fs.hasChunkSession(validPath) {
+		err = fmt.Errorf("%s has a chunked write in progress; finish it before truncating", path)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	unlock := fs.lockPath(validPath)
+	var result *truncateResult
+	switch {
+	case hasSize:
+		result, err = fs.truncateTo(validPath, int64(size))
+	case hasKeep:
+		result, err = fs.keepLastBytes(ctx, validPath, int64(keep), alignLines)
+	default:
+		result, err = fs.zeroRange(ctx, validPath, int64(zeroOffset), int64(zeroLength))
+	}
+	unlock()
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: could not truncate %s: %v", path, err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var text string
+	switch {
+	case hasZero:
+		text = fmt.Sprintf("✅ Zeroed %d bytes of %s at offset %d (%d bytes)\n", result.zeroed, validPath, int64(zeroOffset), result.after)
+	case result.before == result.after:
+		text = fmt.Sprintf("✅ %s unchanged: %d bytes\n", validPath, result.after)
+	default:
+		text = fmt.Sprintf("✅ Truncated %s: %d → %d bytes\n", validPath, result.before, result.after)
+	}
+	if result.skipped > 0 {
+		text += fmt.Sprintf("↩️ Skipped %d bytes to start at a line boundary\n", result.skipped)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: text},
+		},
+	}, nil
+}
+
+// truncateTo - Corta validPath a size bytes en su sitio; no lo alarga
+func (fs *FilesystemHandler) truncateTo(validPath string, size int64) (*truncateResult, error) {
+	file, err := fs.openFileChecked(validPath, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if size > info.Size() {
+		return nil, fmt.Errorf("size %d is larger than the file (%d bytes); %s only shortens files", size, info.Size(), fs.toolName("truncate_file"))
+	}
+	if err := file.Truncate(size); err != nil {
+		return nil, err
+	}
+	return &truncateResult{before: info.Size(), after: size}, nil
+}
+
+// keepLastBytes - Copia los últimos keep bytes de validPath (desde el siguiente
+// salto de línea si alignLines) a un temporal junto a él y lo renombra encima,
+// conservando sus permisos
+func (fs *FilesystemHandler) keepLastBytes(ctx context.Context, validPath string, keep int64, alignLines bool) (*truncateResult, error) {
+	in, err := fs.openFileChecked(validPath, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return nil, err
+	}
+	result := &truncateResult{before: info.Size(), after: info.Size()}
+	if keep >= info.Size() {
+		return result, nil
+	}
+
+	start := info.Size() - keep
+	if alignLines {
+		aligned, err := nextLineStart(ctx, in, start, info.Size())
+		if err != nil {
+			return nil, err
+		}
+		result.skipped, start = aligned-start, aligned
+	}
+
+	tempPath := validPath + ".tmp"
+	defer fs.trackTemp(tempPath)()
+	out, err := fs.openFileChecked(tempPath, os.O_WRONLY|os.O_CREATE, info.Mode().Perm())
+	if err == nil {
+		if err = out.Truncate(0); err != nil {
+			out.Close()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	written, err := copyContext(ctx, out, io.NewSectionReader(in, start, info.Size()-start))
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tempPath, info.Mode().Perm())
+	}
+	if err == nil {
+		err = fs.renameChecked(tempPath, validPath)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return nil, err
+	}
+	progressFrom(ctx).read(validPath, written)
+	fs.stats.addRead(int(written))
+	fs.stats.addWritten(int(written))
+	result.after = written
+	return result, nil
+}
+
+// nextLineStart - Primera posición desde start que empieza una línea: start si
+// el byte anterior es un salto de línea, si no el byte tras el siguiente, o end
+// si no queda ninguno
+func nextLineStart(ctx context.Context, file *os.File, start, end int64) (int64, error) {
+	if start == 0 {
+		return 0, nil
+	}
+	buf := make([]byte, 64<<10)
+	for pos := start - 1; pos < end; {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		n, err := file.ReadAt(buf[:min(int64(len(buf)), end-pos)], pos)
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			return pos + int64(i) + 1, nil
+		}
+		pos += int64(n)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if n == 0 {
+			break
+		}
+	}
+	return end, nil
+}
+
+// zeroRange - Escribe ceros en length bytes de validPath desde offset, sin
+// cambiar su tamaño; el rango debe caer dentro del archivo
+func (fs *FilesystemHandler) zeroRange(ctx context.Context, validPath string, offset, length int64) (*truncateResult, error) {
+	file, err := fs.openFileChecked(validPath, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if offset+length > info.Size() {
+		return nil, fmt.Errorf("range %d+%d goes past the end of the file (%d bytes)", offset, length, info.Size())
+	}
+
+	result := &truncateResult{before: info.Size(), after: info.Size()}
+	buf := make([]byte, min(length, MAX_CHUNK_SIZE))
+	for result.zeroed < length {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, err := file.WriteAt(buf[:min(length-result.zeroed, int64(len(buf)))], offset+result.zeroed)
+		result.zeroed += int64(n)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := file.Sync(); err != nil {
+		return nil, err
+	}
+	fs.stats.addWritten(int(result.zeroed))
+	return result, nil
+}
+
+// copyContext - io.Copy por bloques de MAX_CHUNK_SIZE que para si se cancela ctx
+func copyContext(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
+	var written int64
+	buf := make([]byte, MAX_CHUNK_SIZE)
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+		n, err := src.Read(buf)
+		if n > 0 {
+			w, werr := dst.Write(buf[:n])
+			written += int64(w)
+			if werr != nil {
+				return written, werr
+			}
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
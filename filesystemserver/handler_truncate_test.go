@@ -0,0 +1,105 @@
+package filesystemserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncateFileModes(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "app.log")
+	reset := func() {
+		require.NoError(t, os.WriteFile(path, []byte("first line\nsecond line\nthird line\n"), 0640))
+	}
+	truncate := func(args map[string]interface{}) string {
+		args["path"] = path
+		text, isError := callText(t, handler.handleTruncateFile, "truncate_file", args)
+		require.False(t, isError, text)
+		return text
+	}
+	content := func() string {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	reset()
+	text := truncate(map[string]interface{}{"size": float64(0)})
+	assert.Contains(t, text, "34 → 0 bytes")
+	assert.Empty(t, content())
+
+	reset()
+	truncate(map[string]interface{}{"size": float64(10)})
+	assert.Equal(t, "first line", content())
+
+	reset()
+	truncate(map[string]interface{}{"keep_last_bytes": float64(15)})
+	assert.Equal(t, "ine\nthird line\n", content())
+
+	reset()
+	text = truncate(map[string]interface{}{"keep_last_bytes": float64(15), "align_to_line": true})
+	assert.Equal(t, "third line\n", content())
+	assert.Contains(t, text, "Skipped 4 bytes")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm(), "permissions are kept")
+
+	// Ya empieza en una línea: no se descarta nada
+	reset()
+	truncate(map[string]interface{}{"keep_last_bytes": float64(23), "align_to_line": true})
+	assert.Equal(t, "second line\nthird line\n", content())
+
+	reset()
+	text = truncate(map[string]interface{}{"keep_last_bytes": float64(100)})
+	assert.Contains(t, text, "unchanged: 34 bytes")
+
+	reset()
+	truncate(map[string]interface{}{"zero_offset": float64(0), "zero_length": float64(5)})
+	assert.Equal(t, "\x00\x00\x00\x00\x00 line\nsecond line\nthird line\n", content())
+	assert.Empty(t, tempFilesIn(t, dir))
+}
+
+func TestTruncateFileRejects(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "data.txt")
+	require.NoError(t, os.WriteFile(path, []byte("0123456789"), 0644))
+
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want string
+	}{
+		{"no mode", map[string]interface{}{"path": path}, "exactly one of"},
+		{"two modes", map[string]interface{}{"path": path, "size": float64(1), "keep_last_bytes": float64(1)}, "exactly one of"},
+		{"fractional", map[string]interface{}{"path": path, "size": 1.5}, "size must be"},
+		{"grow", map[string]interface{}{"path": path, "size": float64(11)}, "only shortens files"},
+		{"zero without length", map[string]interface{}{"path": path, "zero_offset": float64(1)}, "zero_length"},
+		{"zero past end", map[string]interface{}{"path": path, "zero_offset": float64(5), "zero_length": float64(6)}, "past the end"},
+		{"align without keep", map[string]interface{}{"path": path, "size": float64(1), "align_to_line": true}, "align_to_line"},
+		{"directory", map[string]interface{}{"path": dir, "size": float64(0)}, "not a regular file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, isError := callText(t, handler.handleTruncateFile, "truncate_file", tt.args)
+			assert.True(t, isError)
+			assert.Contains(t, text, tt.want)
+		})
+	}
+
+	// Un chunked_write a medias del mismo archivo lo bloquea
+	text, isError := callText(t, handler.handleChunkedWrite, "chunked_write", map[string]interface{}{
+		"path": path, "content": "new", "chunk_index": float64(0), "total_chunks": float64(2),
+	})
+	require.False(t, isError, text)
+	text, isError = callText(t, handler.handleTruncateFile, "truncate_file", map[string]interface{}{"path": path, "size": float64(0)})
+	assert.True(t, isError)
+	assert.Contains(t, text, "chunked write in progress")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+}
@@ -5,11 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"mime"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"slices"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/gabriel-vasile/mimetype"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -75,29 +79,114 @@ func isTextFile(mimeType string) bool {
 	return false
 }
 
-// isImageFile determines if a file is an image based on MIME type
-func isImageFile(mimeType string) bool {
+// isImageFile determines if a file is an image based on MIME type; an SVG
+// detected as plain XML or text still counts by its extension
+func isImageFile(path, mimeType string) bool {
 	return strings.HasPrefix(mimeType, "image/") ||
-		(mimeType == "application/xml" && strings.HasSuffix(strings.ToLower(mimeType), ".svg"))
+		(strings.EqualFold(filepath.Ext(path), ".svg") && isTextFile(mimeType))
 }
 
+// windowsPaths reports whether paths use drive letters and backslashes
+const windowsPaths = runtime.GOOS == "windows"
+
+// driveLetterPattern matches a Windows drive prefix such as C:
+var driveLetterPattern = regexp.MustCompile(`^[A-Za-z]:`)
+
 // pathToResourceURI converts a file path to a resource URI
 func pathToResourceURI(path string) string {
-	return "file://" + path
+	return fileURIFromPath(path, windowsPaths)
 }
 
-// detectLanguage detects programming language from content
-func (fs *FilesystemHandler) detectLanguage(content string) string {
-	if strings.Contains(content, "package ") && strings.Contains(content, "import (") {
-		return "go"
+// fileURIFromPath builds a percent-encoded file:// URI. Windows paths get
+// forward slashes and a leading slash before the drive letter
+// (file:///C:/dir/file); UNC paths put the server in the host part.
+func fileURIFromPath(path string, windows bool) string {
+	u := url.URL{Scheme: "file", Path: path}
+	if windows {
+		p := strings.ReplaceAll(path, `\`, "/")
+		p = strings.TrimPrefix(p, "//?/")
+		if rest, ok := strings.CutPrefix(p, "UNC/"); ok {
+			p = "//" + rest
+		}
+		switch {
+		case strings.HasPrefix(p, "//"):
+			host, rest, _ := strings.Cut(p[2:], "/")
+			u.Host, u.Path = host, "/"+rest
+		case driveLetterPattern.MatchString(p):
+			u.Path = "/" + p
+		default:
+			u.Path = p
+		}
 	}
-	if strings.Contains(content, "function ") || strings.Contains(content, "const ") {
-		return "javascript"
+	return u.String()
+}
+
+// safeDisplayName makes a file name or path safe to interpolate into text
+// output. Names made only of printable characters come back unchanged; any
+// other name is Go-quoted, so newlines, escape sequences and other control
+// characters show up as escapes instead of being rendered, and names with
+// invalid UTF-8 (shown as \xNN) are also marked as such. Structured output
+// keeps the raw name.
+func safeDisplayName(name string) string {
+	printable, valid := true, true
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		if r == utf8.RuneError && size == 1 {
+			valid = false
+		} else if !strconv.IsPrint(r) {
+			printable = false
+		}
+		i += size
 	}
-	if strings.Contains(content, "def ") || strings.Contains(content, "import ") {
-		return "python"
+	switch {
+	case !valid:
+		return strconv.Quote(name) + " (invalid UTF-8)"
+	case !printable:
+		return strconv.Quote(name)
 	}
-	return "unknown"
+	return name
+}
+
+// resourceURIPaths returns the file paths a file:// URI may refer to: the
+// percent-decoded path first and, for URIs produced before paths were
+// encoded, the raw text after file:// when it differs
+func resourceURIPaths(uri string, windows bool) ([]string, error) {
+	raw, ok := strings.CutPrefix(uri, "file://")
+	if !ok {
+		return nil, fmt.Errorf("unsupported URI scheme: %s", uri)
+	}
+	if windows {
+		raw = strings.ReplaceAll(raw, "/", `\`)
+		if strings.HasPrefix(raw, `\`) && driveLetterPattern.MatchString(raw[1:]) {
+			raw = raw[1:]
+		}
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		// file://C:\dir\file or an unencoded %: only the legacy form applies
+		return []string{raw}, nil
+	}
+
+	path := u.Path
+	if windows {
+		switch {
+		case driveLetterPattern.MatchString(u.Host) && len(u.Host) == 2:
+			path = u.Host + path
+		case u.Host != "" && u.Host != "localhost":
+			path = "//" + u.Host + path
+		case driveLetterPattern.MatchString(strings.TrimPrefix(path, "/")):
+			path = strings.TrimPrefix(path, "/")
+		}
+		path = strings.ReplaceAll(path, "/", `\`)
+	} else if u.Host != "" && u.Host != "localhost" {
+		return nil, fmt.Errorf("unsupported file URI host %q: %s", u.Host, uri)
+	}
+
+	if path == raw {
+		return []string{path}, nil
+	}
+	return []string{path, raw}, nil
 }
 
 // convertToString converts interface{} to string
@@ -123,7 +212,7 @@ func (fs *FilesystemHandler) validateEditableFile(path string) error {
 // createBackup creates a backup of a file
 func (fs *FilesystemHandler) createBackup(path string) (string, error) {
 	backupPath := path + ".backup"
-	content, err := os.ReadFile(path)
+	content, err := fs.readFile(path)
 	if err != nil {
 		return "", err
 	}
@@ -136,8 +225,19 @@ func (fs *FilesystemHandler) analyzeContent(content, oldText string) interface{}
 	return nil // Basic implementation
 }
 
-// performIntelligentEdit performs intelligent text replacement
+// performIntelligentEdit performs intelligent text replacement. A UTF-8 BOM
+// is not part of the text: it is ignored while matching and kept in the result.
 func (fs *FilesystemHandler) performIntelligentEdit(content, oldText, newText string, analysis interface{}) (*EditResult, error) {
+	bom, text := splitBOM(content)
+	result, err := replaceEditText(text, stripBOM(oldText), stripBOM(newText))
+	if result != nil {
+		result.ModifiedContent = bom + result.ModifiedContent
+	}
+	return result, err
+}
+
+// replaceEditText - Reemplazo exacto, por líneas, multilínea o flexible, en ese orden
+func replaceEditText(content, oldText, newText string) (*EditResult, error) {
 	if oldText == "" {
 		return nil, fmt.Errorf("old_text cannot be empty")
 	}
@@ -210,7 +310,7 @@ func (fs *FilesystemHandler) performIntelligentEdit(content, oldText, newText st
 		// Permitir espacios flexibles y saltos de línea opcionales
 		flexiblePattern := makeFlexiblePattern(escapedOld)
 
-		re, err := regexp.Compile(flexiblePattern)
+		re, err := compileBoundedPattern(flexiblePattern)
 		if err == nil {
 			matches := re.FindAllString(content, -1)
 			if len(matches) > 0 {
@@ -349,6 +449,46 @@ func (fs *FilesystemHandler) calculateCodeComplexity(content, language string) C
 		for _, pattern := range patterns {
 			complexity.CyclomaticComplexity += len(regexp.MustCompile(pattern).FindAllString(content, -1))
 		}
+
+	case "typescript":
+		complexity.FunctionCount = len(regexp.MustCompile(`function\s+\w+|=>\s*{|\w+\s*:\s*function`).FindAllString(content, -1))
+		complexity.ClassCount = len(regexp.MustCompile(`\b(?:class|interface)\s+\w+`).FindAllString(content, -1))
+		complexity.ImportCount = len(regexp.MustCompile(`import\s+.*from|require\s*\(`).FindAllString(content, -1))
+
+		patterns := []string{`\bif\b`, `\bfor\b`, `\bwhile\b`, `\bswitch\b`, `\bcase\b`, `\btry\b`, `\bcatch\b`}
+		for _, pattern := range patterns {
+			complexity.CyclomaticComplexity += len(regexp.MustCompile(pattern).FindAllString(content, -1))
+		}
+
+	case "rust":
+		complexity.FunctionCount = len(regexp.MustCompile(`\bfn\s+\w+`).FindAllString(content, -1))
+		complexity.ClassCount = len(regexp.MustCompile(`\b(?:struct|enum|trait)\s+\w+`).FindAllString(content, -1))
+		complexity.ImportCount = len(regexp.MustCompile(`(?m)^\s*(?:pub\s+)?use\s+`).FindAllString(content, -1))
+
+		patterns := []string{`\bif\b`, `\bfor\b`, `\bwhile\b`, `\bloop\b`, `\bmatch\b`}
+		for _, pattern := range patterns {
+			complexity.CyclomaticComplexity += len(regexp.MustCompile(pattern).FindAllString(content, -1))
+		}
+
+	case "java":
+		complexity.FunctionCount = len(regexp.MustCompile(`(?m)^\s*(?:(?:public|private|protected|static|final|abstract|synchronized)\s+)+[\w<>\[\],.?\s]+?\s+\w+\s*\([^)]*\)\s*(?:throws\s+[\w.,\s]+)?\{`).FindAllString(content, -1))
+		complexity.ClassCount = len(regexp.MustCompile(`\b(?:class|interface|enum|record)\s+\w+`).FindAllString(content, -1))
+		complexity.ImportCount = len(regexp.MustCompile(`(?m)^\s*import\s+`).FindAllString(content, -1))
+
+		patterns := []string{`\bif\b`, `\bfor\b`, `\bwhile\b`, `\bswitch\b`, `\bcase\b`, `\bcatch\b`}
+		for _, pattern := range patterns {
+			complexity.CyclomaticComplexity += len(regexp.MustCompile(pattern).FindAllString(content, -1))
+		}
+
+	case "csharp":
+		complexity.FunctionCount = len(regexp.MustCompile(`(?m)^\s*(?:(?:public|private|protected|internal|static|async|override|virtual|abstract|sealed)\s+)+[\w<>\[\],.?\s]+?\s+\w+\s*\([^)]*\)\s*\{`).FindAllString(content, -1))
+		complexity.ClassCount = len(regexp.MustCompile(`\b(?:class|interface|struct|enum|record)\s+\w+`).FindAllString(content, -1))
+		complexity.ImportCount = len(regexp.MustCompile(`(?m)^\s*using\s+[\w.]+\s*;`).FindAllString(content, -1))
+
+		patterns := []string{`\bif\b`, `\bfor\b`, `\bforeach\b`, `\bwhile\b`, `\bswitch\b`, `\bcase\b`, `\bcatch\b`}
+		for _, pattern := range patterns {
+			complexity.CyclomaticComplexity += len(regexp.MustCompile(pattern).FindAllString(content, -1))
+		}
 	}
 
 	return complexity
@@ -370,7 +510,16 @@ func (fs *FilesystemHandler) extractDependencies(content, language string) []str
 			}
 		}
 
-	case "javascript":
+		// Bloques import ( ... )
+		blockRe := regexp.MustCompile(`(?s)import\s*\((.*?)\)`)
+		specRe := regexp.MustCompile(`(?m)^\s*(?:[\w.]+\s+)?"([^"]+)"`)
+		for _, block := range blockRe.FindAllStringSubmatch(content, -1) {
+			for _, spec := range specRe.FindAllStringSubmatch(block[1], -1) {
+				dependencies = append(dependencies, spec[1])
+			}
+		}
+
+	case "javascript", "typescript":
 		importRe := regexp.MustCompile(`import.*from\s+['"]([^'"]+)['"]|require\s*\(\s*['"]([^'"]+)['"]\s*\)`)
 		matches := importRe.FindAllStringSubmatch(content, -1)
 		for _, match := range matches {
@@ -396,6 +545,28 @@ func (fs *FilesystemHandler) extractDependencies(content, language string) []str
 				}
 			}
 		}
+
+	case "rust":
+		re := regexp.MustCompile(`(?m)^\s*(?:pub\s+)?use\s+([\w:]+)|^\s*extern\s+crate\s+(\w+)`)
+		for _, match := range re.FindAllStringSubmatch(content, -1) {
+			if match[1] != "" {
+				dependencies = append(dependencies, strings.TrimSuffix(match[1], "::"))
+			} else if match[2] != "" {
+				dependencies = append(dependencies, match[2])
+			}
+		}
+
+	case "java":
+		re := regexp.MustCompile(`(?m)^\s*import\s+(?:static\s+)?([\w.]+(?:\.\*)?)\s*;`)
+		for _, match := range re.FindAllStringSubmatch(content, -1) {
+			dependencies = append(dependencies, match[1])
+		}
+
+	case "csharp":
+		re := regexp.MustCompile(`(?m)^\s*using\s+(?:static\s+)?([\w.]+)\s*;`)
+		for _, match := range re.FindAllStringSubmatch(content, -1) {
+			dependencies = append(dependencies, match[1])
+		}
 	}
 
 	// Remove duplicates
@@ -422,50 +593,65 @@ func (fs *FilesystemHandler) calculateCommentRatio(content, language string) flo
 	}
 
 	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		switch language {
-		case "go", "javascript", "java":
-			if strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*") {
-				commentLines++
-			}
-		case "python":
-			if strings.HasPrefix(trimmed, "#") {
-				commentLines++
-			}
+		if isCommentLine(strings.TrimSpace(line), language) {
+			commentLines++
 		}
 	}
 
 	return float64(commentLines) / float64(len(lines)) * 100
 }
 
-// calculateAvgLineLength calculates average line length
-func (fs *FilesystemHandler) calculateAvgLineLength(content string) float64 {
-	lines := strings.Split(content, "\n")
-	totalLength := 0
+// isCommentLine reports whether a trimmed line starts a comment in language
+func isCommentLine(trimmed, language string) bool {
+	switch language {
+	case "go", "javascript", "typescript", "java", "csharp", "rust":
+		return strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*")
+	case "python":
+		return strings.HasPrefix(trimmed, "#")
+	}
+	return false
+}
 
-	for _, line := range lines {
-		totalLength += len(line)
+// lineLengthStats accumulates line lengths in bytes one line at a time, so
+// streaming readers share the definitions of the calculate* helpers
+type lineLengthStats struct {
+	lines int
+	total int
+	max   int
+}
+
+func (s *lineLengthStats) add(length int) {
+	s.lines++
+	s.total += length
+	if length > s.max {
+		s.max = length
 	}
+}
 
-	if len(lines) == 0 {
+func (s lineLengthStats) avg() float64 {
+	if s.lines == 0 {
 		return 0
 	}
+	return float64(s.total) / float64(s.lines)
+}
+
+// contentLineStats measures every line of content
+func contentLineStats(content string) lineLengthStats {
+	var stats lineLengthStats
+	for _, line := range strings.Split(content, "\n") {
+		stats.add(len(line))
+	}
+	return stats
+}
 
-	return float64(totalLength) / float64(len(lines))
+// calculateAvgLineLength calculates average line length
+func (fs *FilesystemHandler) calculateAvgLineLength(content string) float64 {
+	return contentLineStats(content).avg()
 }
 
 // calculateMaxLineLength calculates maximum line length
 func (fs *FilesystemHandler) calculateMaxLineLength(content string) int {
-	lines := strings.Split(content, "\n")
-	maxLength := 0
-
-	for _, line := range lines {
-		if len(line) > maxLength {
-			maxLength = len(line)
-		}
-	}
-
-	return maxLength
+	return contentLineStats(content).max
 }
 
 // calculateComplexity calculates cyclomatic complexity
@@ -473,7 +659,7 @@ func (fs *FilesystemHandler) calculateComplexity(content, language string) int {
 	complexity := 1
 
 	switch language {
-	case "go", "javascript", "java":
+	case "go", "javascript", "typescript", "java", "csharp", "rust":
 		patterns := []string{
 			`\bif\b`, `\belse\b`, `\bfor\b`, `\bwhile\b`,
 			`\bswitch\b`, `\bcase\b`, `\btry\b`, `\bcatch\b`,
@@ -500,13 +686,13 @@ func (fs *FilesystemHandler) calculateComplexity(content, language string) int {
 
 // handleCopyFile handles file copy operations
 func (fs *FilesystemHandler) handleCopyFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	source, ok := request.Params.Arguments["source"].(string)
-	if !ok {
-		return nil, fmt.Errorf("source must be a string")
+	source, err := requireString(request.Params.Arguments, "source")
+	if err != nil {
+		return badArgError(err).result(), nil
 	}
-	destination, ok := request.Params.Arguments["destination"].(string)
-	if !ok {
-		return nil, fmt.Errorf("destination must be a string")
+	destination, err := requireString(request.Params.Arguments, "destination")
+	if err != nil {
+		return badArgError(err).result(), nil
 	}
 
 	validSource, err := fs.validatePath(source)
@@ -529,7 +715,11 @@ func (fs *FilesystemHandler) handleCopyFile(ctx context.Context, request mcp.Cal
 		}, nil
 	}
 
-	err = copyFile(validSource, validDest)
+	verify, err := getBool(request.Params.Arguments, "verify", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	copied, err := fs.copyTreeChecked(ctx, validSource, validDest, verify)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -539,31 +729,23 @@ func (fs *FilesystemHandler) handleCopyFile(ctx context.Context, request mcp.Cal
 		}, nil
 	}
 
-	resourceURI := pathToResourceURI(validDest)
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
-			mcp.TextContent{Type: "text", Text: fmt.Sprintf("Successfully copied %s to %s", source, destination)},
-			mcp.EmbeddedResource{
-				Type: "resource",
-				Resource: mcp.TextResourceContents{
-					URI:      resourceURI,
-					MIMEType: "text/plain",
-					Text:     fmt.Sprintf("Copied file: %s", validDest),
-				},
-			},
+			mcp.TextContent{Type: "text", Text: strings.TrimSuffix(fmt.Sprintf("Successfully copied %s to %s\n%s", source, destination, copied.summary()), "\n")},
+			newFileResource(validDest),
 		},
 	}, nil
 }
 
 // handleMoveFile handles file move operations
 func (fs *FilesystemHandler) handleMoveFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	source, ok := request.Params.Arguments["source"].(string)
-	if !ok {
-		return nil, fmt.Errorf("source must be a string")
+	source, err := requireString(request.Params.Arguments, "source")
+	if err != nil {
+		return badArgError(err).result(), nil
 	}
-	destination, ok := request.Params.Arguments["destination"].(string)
-	if !ok {
-		return nil, fmt.Errorf("destination must be a string")
+	destination, err := requireString(request.Params.Arguments, "destination")
+	if err != nil {
+		return badArgError(err).result(), nil
 	}
 
 	validSource, err := fs.validatePath(source)
@@ -587,7 +769,7 @@ func (fs *FilesystemHandler) handleMoveFile(ctx context.Context, request mcp.Cal
 	}
 
 	parentDir := filepath.Dir(validDest)
-	if err := os.MkdirAll(parentDir, 0755); err != nil {
+	if err := fs.mkdirAllChecked(parentDir, 0755); err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error creating destination directory: %v", err)},
@@ -596,7 +778,11 @@ func (fs *FilesystemHandler) handleMoveFile(ctx context.Context, request mcp.Cal
 		}, nil
 	}
 
-	err = os.Rename(validSource, validDest)
+	verify, err := getBool(request.Params.Arguments, "verify", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	moved, err := fs.moveChecked(ctx, validSource, validDest, verify)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -606,18 +792,10 @@ func (fs *FilesystemHandler) handleMoveFile(ctx context.Context, request mcp.Cal
 		}, nil
 	}
 
-	resourceURI := pathToResourceURI(validDest)
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
-			mcp.TextContent{Type: "text", Text: fmt.Sprintf("Successfully moved %s to %s", source, destination)},
-			mcp.EmbeddedResource{
-				Type: "resource",
-				Resource: mcp.TextResourceContents{
-					URI:      resourceURI,
-					MIMEType: "text/plain",
-					Text:     fmt.Sprintf("Moved file: %s", validDest),
-				},
-			},
+			mcp.TextContent{Type: "text", Text: strings.TrimSuffix(fmt.Sprintf("Successfully moved %s to %s\n%s", source, destination, moved.summary()), "\n")},
+			newFileResource(validDest),
 		},
 	}, nil
 }
@@ -0,0 +1,276 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var languageFixtures = []struct {
+	name     string
+	path     string
+	content  string
+	language string
+	deps     []string
+}{
+	{
+		name: "go",
+		path: "main.go",
+		content: `package main
+
+import (
+	"fmt"
+	str "strings"
+)
+
+const greeting = "hi"
+
+func main() {
+	fmt.Println(str.ToUpper(greeting))
+}
+`,
+		language: "go",
+		deps:     []string{"fmt", "strings"},
+	},
+	{
+		name: "typescript",
+		path: "service.ts",
+		content: `import { Injectable } from "@angular/core";
+
+interface User {
+  name: string;
+}
+
+const format = (u: User) => { return u.name; };
+
+export function greet(user: User): string {
+  return "hi " + format(user);
+}
+`,
+		language: "typescript",
+		deps:     []string{"@angular/core"},
+	},
+	{
+		name: "rust",
+		path: "lib.rs",
+		content: `use std::collections::HashMap;
+
+pub struct Cache {
+    items: HashMap<String, u32>,
+}
+
+pub fn lookup(cache: &Cache, key: &str) -> Option<u32> {
+    if let Some(v) = cache.items.get(key) {
+        return Some(*v);
+    }
+    None
+}
+`,
+		language: "rust",
+		deps:     []string{"std::collections::HashMap"},
+	},
+	{
+		name: "java",
+		path: "App.java",
+		content: `package com.example;
+
+import java.util.List;
+
+public class App {
+    public static void main(String[] args) {
+        for (String arg : args) {
+            System.out.println(arg);
+        }
+    }
+
+    private int size(List<String> items) {
+        return items.size();
+    }
+}
+`,
+		language: "java",
+		deps:     []string{"java.util.List"},
+	},
+	{
+		name: "csharp",
+		path: "Program.cs",
+		content: `using System;
+using System.Linq;
+
+namespace Demo
+{
+    public class Program
+    {
+        public static void Main(string[] args)
+        {
+            foreach (var arg in args.Where(a => a.Length > 0))
+            {
+                Console.WriteLine(arg);
+            }
+        }
+    }
+}
+`,
+		language: "csharp",
+		deps:     []string{"System", "System.Linq"},
+	},
+	{
+		name: "python shebang",
+		path: "deploy",
+		content: `#!/usr/bin/env python3
+import os
+
+def main():
+    print(os.getcwd())
+`,
+		language: "python",
+		deps:     []string{"os"},
+	},
+}
+
+func TestDetectLanguageAndMetrics(t *testing.T) {
+	handler := &FilesystemHandler{}
+
+	for _, tc := range languageFixtures {
+		t.Run(tc.name, func(t *testing.T) {
+			detected, _ := languageDetector.Detect(tc.path, tc.content)
+			language := detected.Key
+			assert.Equal(t, tc.language, language)
+
+			complexity := handler.calculateCodeComplexity(tc.content, language)
+			assert.NotZero(t, complexity.FunctionCount, "function count")
+			assert.Equal(t, tc.deps, handler.extractDependencies(tc.content, language))
+		})
+	}
+}
+
+func TestDetectLanguageFromContent(t *testing.T) {
+	detectKey := func(path, content string) string {
+		language, _ := languageDetector.Detect(path, content)
+		return language.Key
+	}
+
+	// Sin extensión, "const " ya no implica JavaScript
+	goSource := "package main\n\nconst x = 1\n\nfunc main() {}\n"
+	assert.Equal(t, "go", detectKey("", goSource))
+	assert.Equal(t, "javascript", detectKey("", "#!/usr/bin/node\nconsole.log(1)\n"))
+	assert.Equal(t, "shell", detectKey("run", "#!/bin/bash\necho hi\n"))
+	assert.Equal(t, "unknown", detectKey("", "just some notes\n"))
+}
+
+func TestFileURIConversion(t *testing.T) {
+	cases := []struct {
+		path    string
+		windows bool
+		uri     string
+	}{
+		{"/home/me/notes.txt", false, "file:///home/me/notes.txt"},
+		{"/home/me/my notes.txt", false, "file:///home/me/my%20notes.txt"},
+		{"/home/me/résumé #1.txt", false, "file:///home/me/r%C3%A9sum%C3%A9%20%231.txt"},
+		{`C:\Users\me\my notes.txt`, true, "file:///C:/Users/me/my%20notes.txt"},
+		{`C:\`, true, "file:///C:/"},
+		{`\\server\share\año.txt`, true, "file://server/share/a%C3%B1o.txt"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.uri, fileURIFromPath(c.path, c.windows), c.path)
+		paths, err := resourceURIPaths(c.uri, c.windows)
+		require.NoError(t, err)
+		assert.Equal(t, c.path, paths[0], c.uri)
+	}
+
+	// URIs sin codificar de versiones anteriores
+	legacy := []struct {
+		uri     string
+		windows bool
+		paths   []string
+	}{
+		{"file:///tmp/my notes.txt", false, []string{"/tmp/my notes.txt"}},
+		{"file:///tmp/100%done", false, []string{"/tmp/100%done"}},
+		{"file:///tmp/a%20b", false, []string{"/tmp/a b", "/tmp/a%20b"}},
+		{`file://C:\dir\file.txt`, true, []string{`C:\dir\file.txt`}},
+		{"file://C:/dir/file.txt", true, []string{`C:\dir\file.txt`}},
+	}
+	for _, c := range legacy {
+		paths, err := resourceURIPaths(c.uri, c.windows)
+		require.NoError(t, err)
+		assert.Equal(t, c.paths, paths, c.uri)
+	}
+
+	_, err := resourceURIPaths("http://example.com/x", false)
+	assert.Error(t, err)
+	_, err = resourceURIPaths("file://otherhost/etc/passwd", false)
+	assert.Error(t, err)
+}
+
+func TestReadResourceEncodedURI(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "my notés.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0644))
+
+	for _, uri := range []string{pathToResourceURI(path), "file://" + path} {
+		request := mcp.ReadResourceRequest{}
+		request.Params.URI = uri
+		contents, err := handler.handleReadResource(context.Background(), request)
+		require.NoError(t, err, uri)
+		require.Len(t, contents, 1)
+		assert.Equal(t, "hello", contents[0].(mcp.TextResourceContents).Text)
+	}
+}
+
+func TestEditFileWithBOM(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("\ufeffTitle: draft\r\nbody\r\n"), 0644))
+
+	// El BOM se ignora al buscar la primera línea y se conserva al escribir
+	result, err := handler.handleEditFile(context.Background(), newToolRequest("edit_file", map[string]interface{}{
+		"path":     path,
+		"old_text": "Title: draft",
+		"new_text": "Title: final",
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "\ufeffTitle: final\nbody\n", string(data))
+
+	// Un old_text copiado con el BOM también coincide
+	edit, err := handler.performIntelligentEdit(string(data), "\ufeffTitle: final", "Title: done", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "\ufeffTitle: done\nbody\n", edit.ModifiedContent)
+
+	result, err = handler.handleEditFile(context.Background(), newToolRequest("edit_file", map[string]interface{}{
+		"path":      path,
+		"old_text":  "body",
+		"new_text":  "text",
+		"strip_bom": true,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Title: final\ntext\n", string(data))
+}
+
+func TestSafeDisplayName(t *testing.T) {
+	tests := map[string]string{
+		"plain.txt":       "plain.txt",
+		"with space.txt":  "with space.txt",
+		"ñandú/日本語.md":    "ñandú/日本語.md",
+		"line\nbreak.txt": `"line\nbreak.txt"`,
+		"bell\a.txt":      `"bell\a.txt"`,
+		"\x1b[31mred":     `"\x1b[31mred"`,
+		"tab\there":       `"tab\there"`,
+		"bad\xffbyte":     `"bad\xffbyte" (invalid UTF-8)`,
+		"quote\"\x00":     `"quote\"\x00"`,
+		"sep\u2028line":   `"sep\u2028line"`,
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, safeDisplayName(name), "%q", name)
+	}
+}
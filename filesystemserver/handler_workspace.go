@@ -0,0 +1,205 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// workspaceCache - Resultados de workspace_context por directorio raíz. Cualquier
+// llamada que escribe lo vacía entero: no se sabe qué raíces toca.
+type workspaceCache struct {
+	mu         sync.Mutex
+	entries    map[string]*WorkspaceContext
+	generation uint64 // sube con cada invalidación; un cálculo anterior ya no se guarda
+}
+
+// get - Copia del resultado guardado para root, que el llamador puede modificar
+func (c *workspaceCache) get(root string) (*WorkspaceContext, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[root]
+	if !ok {
+		return nil, c.generation, false
+	}
+	cached := *entry
+	cloneWorkspaceContext(&cached)
+	return &cached, c.generation, true
+}
+
+// put - Guarda el resultado si nada escribió desde que empezó a calcularse
+func (c *workspaceCache) put(root string, generation uint64, result *WorkspaceContext) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]*WorkspaceContext)
+	}
+	stored := *result
+	cloneWorkspaceContext(&stored)
+	c.entries[root] = &stored
+}
+
+// cloneWorkspaceContext - Deja w sin slices ni mapas compartidos con el original
+func cloneWorkspaceContext(w *WorkspaceContext) {
+	w.ImportantFiles = slices.Clone(w.ImportantFiles)
+	w.Extensions = maps.Clone(w.Extensions)
+	groups := make([]ImportantFileGroup, len(w.ImportantGroups))
+	for i, group := range w.ImportantGroups {
+		groups[i] = ImportantFileGroup{Category: group.Category, Files: slices.Clone(group.Files)}
+	}
+	w.ImportantGroups = groups
+}
+
+// invalidate - Descarta todos los resultados
+func (c *workspaceCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.entries = nil
+}
+
+// handleWorkspaceContext - Tipo de proyecto, archivos importantes y resumen de estructura de un directorio
+func (fs *FilesystemHandler) handleWorkspaceContext(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := getString(request.Params.Arguments, "path", "")
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+	refresh, err := getBool(request.Params.Arguments, "refresh", false)
+	if err != nil {
+		return badArgError(err).result(), nil
+	}
+
+	// Sin path, el workspace del servidor, como en plan_task
+	validPath, err := fs.resolvePlanWorkspace(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+	info, err := os.Stat(validPath)
+	if err == nil && !info.IsDir() {
+		err = fmt.Errorf("%s is not a directory", path)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	result, err := fs.workspaceContext(ctx, validPath, refresh)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("❌ Error: %v", err)},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return withStructuredContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: formatWorkspaceContext(result)},
+		},
+	}, dirResourceURI(validPath), result)
+}
+
+// workspaceContext - Contexto de root desde la caché o calculado de nuevo
+func (fs *FilesystemHandler) workspaceContext(ctx context.Context, root string, refresh bool) (*WorkspaceContext, error) {
+	cached, generation, ok := fs.workspaces.get(root)
+	if ok && !refresh {
+		cached.Cached = true
+		return cached, nil
+	}
+
+	overview, err := fs.getDirectoryOverview(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	groups := fs.rankImportantFiles(ctx, root)
+	// Un recorrido cortado no se guarda en la caché
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	result := &WorkspaceContext{
+		Path:            root,
+		ProjectType:     languageDetector.Project(root),
+		ImportantFiles:  flattenImportantFiles(groups),
+		ImportantGroups: groups,
+		Files:           overview["files"],
+		Directories:     overview["directories"],
+		Extensions:      make(map[string]int),
+		Generated:       time.Now(),
+	}
+	if result.ImportantGroups == nil {
+		result.ImportantGroups = []ImportantFileGroup{}
+	}
+	for key, count := range overview {
+		if strings.HasPrefix(key, ".") {
+			result.Extensions[key] = count
+		}
+	}
+	fs.workspaces.put(root, generation, result)
+	return result, nil
+}
+
+// overview - El mapa de getDirectoryOverview que usa el planificador
+func (w *WorkspaceContext) overview() map[string]int {
+	overview := map[string]int{"files": w.Files, "directories": w.Directories}
+	for ext, count := range w.Extensions {
+		overview[ext] = count
+	}
+	return overview
+}
+
+// formatWorkspaceContext - Resumen breve de workspace_context
+func formatWorkspaceContext(w *WorkspaceContext) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🧭 Workspace: %s\n", safeDisplayName(w.Path))
+	fmt.Fprintf(&b, "📦 Project type: %s\n", w.ProjectType)
+	fmt.Fprintf(&b, "📊 %d files in %d directories\n", w.Files, w.Directories)
+
+	if len(w.Extensions) > 0 {
+		exts := keysByCount(w.Extensions)
+		if len(exts) > 8 {
+			exts = exts[:8]
+		}
+		parts := make([]string, len(exts))
+		for i, ext := range exts {
+			parts[i] = fmt.Sprintf("%s (%d)", ext, w.Extensions[ext])
+		}
+		fmt.Fprintf(&b, "🗂️ Top extensions: %s\n", strings.Join(parts, ", "))
+	}
+
+	labels := map[string]string{
+		importantBuild:      "🔧 Build files",
+		importantEntryPoint: "🚀 Entry points",
+		importantDocs:       "📚 Docs",
+		importantSource:     "🕒 Recently modified source",
+	}
+	for _, group := range w.ImportantGroups {
+		fmt.Fprintf(&b, "\n%s (%d):\n", labels[group.Category], len(group.Files))
+		for _, file := range group.Files {
+			fmt.Fprintf(&b, "  - %s\n", safeDisplayName(file))
+		}
+	}
+	if w.Cached {
+		fmt.Fprintf(&b, "\n♻️ Cached from %s; changes made outside this server need refresh=true\n", w.Generated.Format(time.RFC3339))
+	}
+	return b.String()
+}
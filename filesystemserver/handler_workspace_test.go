@@ -0,0 +1,149 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFixture(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestWorkspaceContextProjectTypes(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		want      string
+		important []string
+	}{
+		{
+			name: "go",
+			files: map[string]string{
+				"go.mod":          "module example\n",
+				"main.go":         "package main\n",
+				"src/lib.go":      "package src\n",
+				"README.md":       "# example\n",
+				"vendor/x/dep.go": "package x\n",
+			},
+			want:      "go",
+			important: []string{"go.mod", "main.go", "README.md", filepath.Join("src", "lib.go")},
+		},
+		{
+			name: "node",
+			files: map[string]string{
+				"package.json":               `{"name": "app"}`,
+				"index.js":                   "module.exports = {}\n",
+				"public/index.html":          "<html></html>\n",
+				"node_modules/left/index.js": "module.exports = {}\n",
+			},
+			want:      "node",
+			important: []string{"package.json", "index.js"},
+		},
+		{
+			name: "python",
+			files: map[string]string{
+				"pyproject.toml": "[project]\nname = \"app\"\n",
+				"app/main.py":    "print('hi')\n",
+				"Dockerfile":     "FROM python\n",
+			},
+			want:      "python",
+			important: []string{"Dockerfile", "pyproject.toml", filepath.Join("app", "main.py")},
+		},
+		{
+			name:      "unknown",
+			files:     map[string]string{"notes.txt": "nothing to see\n"},
+			want:      "unknown",
+			important: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, dir := newTestHandler(t)
+			writeFixture(t, dir, tt.files)
+
+			result, err := handler.handleWorkspaceContext(context.Background(), newToolRequest("workspace_context", map[string]interface{}{"path": dir}))
+			require.NoError(t, err)
+			require.False(t, result.IsError)
+			assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "📦 Project type: "+tt.want)
+
+			var workspace WorkspaceContext
+			decodeStructured(t, result, &workspace)
+			assert.Equal(t, tt.want, workspace.ProjectType)
+			assert.Equal(t, tt.important, workspace.ImportantFiles, "vendor and node_modules are skipped")
+			assert.False(t, workspace.Cached)
+		})
+	}
+}
+
+func TestWorkspaceContextCache(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{"go.mod": "module example\n", "main.go": "package main\n"})
+
+	workspaceContext := func(args map[string]interface{}) WorkspaceContext {
+		result, err := handler.guardTool("workspace_context", handler.handleWorkspaceContext)(context.Background(), newToolRequest("workspace_context", args))
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		var workspace WorkspaceContext
+		decodeStructured(t, result, &workspace)
+		return workspace
+	}
+
+	first := workspaceContext(map[string]interface{}{"path": dir})
+	assert.False(t, first.Cached)
+	assert.Equal(t, 2, first.Files)
+	assert.Equal(t, map[string]int{".go": 1, ".mod": 1}, first.Extensions)
+
+	// Un cambio externo no se ve hasta refresh
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "util.go"), []byte("package main\n"), 0644))
+	second := workspaceContext(map[string]interface{}{"path": dir})
+	assert.True(t, second.Cached)
+	assert.Equal(t, 2, second.Files)
+	assert.Equal(t, first.Generated.UnixNano(), second.Generated.UnixNano())
+
+	refreshed := workspaceContext(map[string]interface{}{"path": dir, "refresh": true})
+	assert.False(t, refreshed.Cached)
+	assert.Equal(t, 3, refreshed.Files)
+	assert.True(t, workspaceContext(map[string]interface{}{"path": dir}).Cached)
+
+	// Una herramienta que escribe vacía la caché
+	result, err := handler.guardTool("write_file", handler.handleWriteFile)(context.Background(), newToolRequest("write_file", map[string]interface{}{
+		"path": filepath.Join(dir, "README.md"), "content": "# example\n",
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	afterWrite := workspaceContext(map[string]interface{}{"path": dir})
+	assert.False(t, afterWrite.Cached)
+	assert.Equal(t, 4, afterWrite.Files)
+	assert.Contains(t, afterWrite.ImportantFiles, "README.md")
+
+	// Las llamadas de solo lectura la conservan
+	_, err = handler.guardTool("read_file", handler.handleReadFile)(context.Background(), newToolRequest("read_file", map[string]interface{}{
+		"path": filepath.Join(dir, "README.md"),
+	}))
+	require.NoError(t, err)
+	assert.True(t, workspaceContext(map[string]interface{}{"path": dir}).Cached)
+
+	// plan_task reutiliza el mismo resultado
+	planContext, err := handler.analyzeWorkspaceContext(context.Background(), dir)
+	require.NoError(t, err)
+	assert.Equal(t, "go", planContext["project_type"])
+	assert.Equal(t, 4, planContext["structure"].(map[string]int)["files"])
+
+	result, err = handler.handleWorkspaceContext(context.Background(), newToolRequest("workspace_context", map[string]interface{}{
+		"path": filepath.Join(dir, "main.go"),
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
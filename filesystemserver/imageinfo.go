@@ -0,0 +1,296 @@
+package filesystemserver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"  // registers the GIF header decoder
+	_ "image/jpeg" // registers the JPEG header decoder
+	_ "image/png"  // registers the PNG header decoder
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// undecodableImage is reported instead of an error for corrupt images
+const undecodableImage = "undecodable image header"
+
+// headerImageTypes are the image types whose size is read from the header,
+// by extension for listings that don't sniff content
+var headerImageTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
+// exifOrientations describes the EXIF orientation values
+var exifOrientations = map[int]string{
+	1: "normal",
+	2: "mirrored horizontally",
+	3: "rotated 180°",
+	4: "mirrored vertically",
+	5: "mirrored horizontally and rotated 270° CW",
+	6: "rotated 90° CW",
+	7: "mirrored horizontally and rotated 90° CW",
+	8: "rotated 270° CW",
+}
+
+// hasImageHeader reports whether imageInfo can read images of mimeType
+func hasImageHeader(mimeType string) bool {
+	for _, known := range headerImageTypes {
+		if mimeType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// imageInfo reads the dimensions and color model of a PNG, JPEG, GIF or WebP
+// image from its header, plus the EXIF orientation and date of a JPEG, without
+// decoding the pixels. A corrupt header gives Error instead of a failure; nil
+// means the file could not be opened.
+func imageInfo(path string) *ImageInfo {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	header := make([]byte, 30)
+	n, _ := io.ReadFull(file, header)
+	if bytes.HasPrefix(header[:n], []byte("RIFF")) && n >= 12 && string(header[8:12]) == "WEBP" {
+		return webpInfo(header[:n])
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil
+	}
+	config, format, err := image.DecodeConfig(bufio.NewReader(file))
+	if err != nil || config.Width <= 0 || config.Height <= 0 {
+		return &ImageInfo{Error: undecodableImage}
+	}
+	info := &ImageInfo{Format: format, Width: config.Width, Height: config.Height, ColorModel: colorModelName(config.ColorModel)}
+	if format == "jpeg" {
+		if _, err := file.Seek(0, io.SeekStart); err == nil {
+			jpegExif(bufio.NewReader(file), info)
+		}
+	}
+	return info
+}
+
+// webpInfo reads the canvas size from the first chunk of a WebP file: VP8
+// (lossy), VP8L (lossless) or VP8X (extended, whose color model depends on
+// the chunks that follow)
+func webpInfo(header []byte) *ImageInfo {
+	info := &ImageInfo{Format: "webp"}
+	if len(header) < 30 {
+		info.Error = undecodableImage
+		return info
+	}
+	data := header[20:]
+	switch string(header[12:16]) {
+	case "VP8 ":
+		if !bytes.Equal(data[3:6], []byte{0x9d, 0x01, 0x2a}) {
+			info.Error = undecodableImage
+			return info
+		}
+		info.Width = int(binary.LittleEndian.Uint16(data[6:8]) & 0x3fff)
+		info.Height = int(binary.LittleEndian.Uint16(data[8:10]) & 0x3fff)
+		info.ColorModel = "YCbCr"
+	case "VP8L":
+		if data[0] != 0x2f {
+			info.Error = undecodableImage
+			return info
+		}
+		bits := binary.LittleEndian.Uint32(data[1:5])
+		info.Width = int(bits&0x3fff) + 1
+		info.Height = int(bits>>14&0x3fff) + 1
+		info.ColorModel = "NRGBA"
+	case "VP8X":
+		info.Width = int(uint32(data[4])|uint32(data[5])<<8|uint32(data[6])<<16) + 1
+		info.Height = int(uint32(data[7])|uint32(data[8])<<8|uint32(data[9])<<16) + 1
+	default:
+		info.Error = undecodableImage
+	}
+	return info
+}
+
+// colorModelName names the standard color models; palettes include their size
+func colorModelName(model color.Model) string {
+	if palette, ok := model.(color.Palette); ok {
+		return fmt.Sprintf("paletted (%d colors)", len(palette))
+	}
+	switch model {
+	case color.RGBAModel:
+		return "RGBA"
+	case color.RGBA64Model:
+		return "RGBA64"
+	case color.NRGBAModel:
+		return "NRGBA"
+	case color.NRGBA64Model:
+		return "NRGBA64"
+	case color.AlphaModel:
+		return "Alpha"
+	case color.Alpha16Model:
+		return "Alpha16"
+	case color.GrayModel:
+		return "Gray"
+	case color.Gray16Model:
+		return "Gray16"
+	case color.YCbCrModel:
+		return "YCbCr"
+	case color.NYCbCrAModel:
+		return "NYCbCrA"
+	case color.CMYKModel:
+		return "CMYK"
+	}
+	return ""
+}
+
+// jpegExif fills the orientation and date from the EXIF segment of a JPEG,
+// reading segment headers only up to the start of the compressed data
+func jpegExif(r *bufio.Reader, info *ImageInfo) {
+	var marker [2]byte
+	if _, err := io.ReadFull(r, marker[:]); err != nil || marker != [2]byte{0xFF, 0xD8} {
+		return
+	}
+	for {
+		if _, err := io.ReadFull(r, marker[:]); err != nil || marker[0] != 0xFF {
+			return
+		}
+		switch {
+		case marker[1] == 0xDA || marker[1] == 0xD9: // start of scan, end of image
+			return
+		case marker[1] == 0x01 || marker[1] >= 0xD0 && marker[1] <= 0xD7: // no length
+			continue
+		}
+		var size [2]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return
+		}
+		length := int(binary.BigEndian.Uint16(size[:])) - 2
+		if length < 0 {
+			return
+		}
+		if marker[1] != 0xE1 {
+			if _, err := r.Discard(length); err != nil {
+				return
+			}
+			continue
+		}
+		segment := make([]byte, length)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return
+		}
+		if exif, ok := bytes.CutPrefix(segment, []byte("Exif\x00\x00")); ok {
+			parseExif(exif, info)
+			return
+		}
+	}
+}
+
+// parseExif reads Orientation and DateTime from IFD0 and DateTimeOriginal,
+// which wins, from the EXIF sub-IFD of a TIFF structure
+func parseExif(tiff []byte, info *ImageInfo) {
+	if len(tiff) < 8 {
+		return
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return
+	}
+
+	// Each entry: tag, type, count and a 4-byte value or offset
+	readIFD := func(offset uint32, visit func(tag, kind uint16, count uint32, value []byte)) {
+		if uint64(offset)+2 > uint64(len(tiff)) {
+			return
+		}
+		entries := int(order.Uint16(tiff[offset:]))
+		for i := 0; i < entries; i++ {
+			entry := int(offset) + 2 + i*12
+			if entry+12 > len(tiff) {
+				return
+			}
+			visit(order.Uint16(tiff[entry:]), order.Uint16(tiff[entry+2:]), order.Uint32(tiff[entry+4:]), tiff[entry+8:entry+12])
+		}
+	}
+	ascii := func(count uint32, value []byte) string {
+		var data []byte
+		if count > 4 {
+			offset := order.Uint32(value)
+			if uint64(offset)+uint64(count) > uint64(len(tiff)) {
+				return ""
+			}
+			data = tiff[offset : offset+count]
+		} else {
+			data = value[:count]
+		}
+		return strings.TrimRight(string(data), "\x00 ")
+	}
+
+	const asciiType, shortType = 2, 3
+	var exifIFD uint32
+	readIFD(order.Uint32(tiff[4:8]), func(tag, kind uint16, count uint32, value []byte) {
+		switch {
+		case tag == 0x0112 && kind == shortType:
+			if orientation := int(order.Uint16(value)); exifOrientations[orientation] != "" {
+				info.Orientation = orientation
+			}
+		case tag == 0x0132 && kind == asciiType:
+			info.DateTime = ascii(count, value)
+		case tag == 0x8769:
+			exifIFD = order.Uint32(value)
+		}
+	})
+	if exifIFD != 0 {
+		readIFD(exifIFD, func(tag, kind uint16, count uint32, value []byte) {
+			if tag == 0x9003 && kind == asciiType {
+				if taken := ascii(count, value); taken != "" {
+					info.DateTime = taken
+				}
+			}
+		})
+	}
+}
+
+// addImageInfo fills Image for the files of a tree with an image extension
+func addImageInfo(node *FileNode) {
+	if node.Type == "file" {
+		if _, ok := headerImageTypes[strings.ToLower(filepath.Ext(node.Name))]; ok {
+			node.Image = imageInfo(node.Path)
+		}
+		return
+	}
+	for _, child := range node.Children {
+		addImageInfo(child)
+	}
+}
+
+// formatImageInfo describes an image in one line
+func formatImageInfo(info *ImageInfo) string {
+	if info.Error != "" {
+		return info.Error
+	}
+	parts := []string{fmt.Sprintf("%dx%d %s", info.Width, info.Height, info.Format)}
+	if info.ColorModel != "" {
+		parts = append(parts, info.ColorModel)
+	}
+	if info.Orientation != 0 {
+		parts = append(parts, fmt.Sprintf("orientation %d (%s)", info.Orientation, exifOrientations[info.Orientation]))
+	}
+	if info.DateTime != "" {
+		parts = append(parts, "taken "+info.DateTime)
+	}
+	return strings.Join(parts, ", ")
+}
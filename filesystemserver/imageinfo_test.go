@@ -0,0 +1,131 @@
+package filesystemserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exifSegment builds a little-endian APP1 segment with an orientation, a
+// DateTime in IFD0 and a DateTimeOriginal in the EXIF sub-IFD
+func exifSegment(orientation uint16, dateTime, original string) []byte {
+	le := binary.LittleEndian
+	tiff := []byte("II\x2a\x00\x08\x00\x00\x00")
+	entry := func(tag, kind uint16, count, value uint32) {
+		tiff = le.AppendUint16(tiff, tag)
+		tiff = le.AppendUint16(tiff, kind)
+		tiff = le.AppendUint32(tiff, count)
+		tiff = le.AppendUint32(tiff, value)
+	}
+	// IFD0 en 8 (42 bytes), DateTime en 50, sub-IFD en 70, DateTimeOriginal en 88
+	tiff = le.AppendUint16(tiff, 3)
+	entry(0x0112, 3, 1, uint32(orientation))
+	entry(0x0132, 2, 20, 50)
+	entry(0x8769, 4, 1, 70)
+	tiff = le.AppendUint32(tiff, 0)
+	tiff = append(tiff, dateTime+"\x00"...)
+	tiff = le.AppendUint16(tiff, 1)
+	entry(0x9003, 2, 20, 88)
+	tiff = le.AppendUint32(tiff, 0)
+	tiff = append(tiff, original+"\x00"...)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// writeImages creates one image of each supported format plus a corrupt PNG
+func writeImages(t *testing.T, dir string) {
+	t.Helper()
+	rgba := image.NewNRGBA(image.Rect(0, 0, 40, 30))
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, rgba))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "photo.png"), buf.Bytes(), 0644))
+
+	buf.Reset()
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 16, 8)), nil))
+	withExif := append(append([]byte{0xFF, 0xD8}, exifSegment(6, "2021:05:01 10:00:00", "2020:01:02 03:04:05")...), buf.Bytes()[2:]...)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "photo.jpg"), withExif, 0644))
+
+	buf.Reset()
+	paletted := image.NewPaletted(image.Rect(0, 0, 5, 7), color.Palette{color.Black, color.White})
+	require.NoError(t, gif.Encode(&buf, paletted, nil))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "anim.gif"), buf.Bytes(), 0644))
+
+	// WebP sin pérdida: firma 0x2f y ancho-1 / alto-1 en 14 bits cada uno
+	webp := append([]byte("RIFF\x00\x00\x00\x00WEBPVP8L\x00\x00\x00\x00\x2f"), binary.LittleEndian.AppendUint32(nil, (300-1)|(200-1)<<14)...)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pic.webp"), append(webp, make([]byte, 8)...), 0644))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.png"), []byte("\x89PNG\r\n\x1a\ntruncated"), 0644))
+}
+
+func TestImageInfo(t *testing.T) {
+	dir := t.TempDir()
+	writeImages(t, dir)
+
+	tests := map[string]*ImageInfo{
+		"photo.png":  {Format: "png", Width: 40, Height: 30, ColorModel: "NRGBA"},
+		"photo.jpg":  {Format: "jpeg", Width: 16, Height: 8, ColorModel: "YCbCr", Orientation: 6, DateTime: "2020:01:02 03:04:05"},
+		"anim.gif":   {Format: "gif", Width: 5, Height: 7, ColorModel: "paletted (2 colors)"},
+		"pic.webp":   {Format: "webp", Width: 300, Height: 200, ColorModel: "NRGBA"},
+		"broken.png": {Error: undecodableImage},
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, imageInfo(filepath.Join(dir, name)), name)
+	}
+	assert.Nil(t, imageInfo(filepath.Join(dir, "missing.png")))
+	assert.Equal(t, "16x8 jpeg, YCbCr, orientation 6 (rotated 90° CW), taken 2020:01:02 03:04:05", formatImageInfo(tests["photo.jpg"]))
+
+	assert.True(t, isImageFile("logo.svg", "image/svg+xml"))
+	assert.True(t, isImageFile("logo.SVG", "text/xml; charset=utf-8"))
+	assert.False(t, isImageFile("feed.xml", "text/xml; charset=utf-8"))
+}
+
+func TestImageInfoInTools(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeImages(t, dir)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("text"), 0644))
+
+	text, isError := callText(t, handler.handleGetFileInfo, "get_file_info", map[string]interface{}{"path": filepath.Join(dir, "photo.jpg")})
+	require.False(t, isError)
+	assert.Contains(t, text, "MIME Type: image/jpeg\nImage: 16x8 jpeg, YCbCr, orientation 6")
+
+	result, err := handler.handleGetFileInfo(context.Background(), newToolRequest("get_file_info", map[string]interface{}{"path": filepath.Join(dir, "broken.png")}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, "a corrupt image is not an error")
+	var info FileInfo
+	decodeStructured(t, result, &info)
+	assert.Equal(t, &ImageInfo{Error: undecodableImage}, info.Image)
+
+	text, isError = callText(t, handler.handleAnalyzeFile, "analyze_file", map[string]interface{}{"path": filepath.Join(dir, "anim.gif")})
+	require.False(t, isError)
+	assert.Contains(t, text, "🖼️ Image: 5x7 gif, paletted (2 colors)\n")
+	analysis, err := handler.analyzeFile(filepath.Join(dir, "notes.txt"))
+	require.NoError(t, err)
+	assert.Nil(t, analysis.Image)
+
+	contents := readResourceText(t, handler, dirResourceURI(dir)+"?format=json")
+	var tree FileNode
+	require.NoError(t, json.Unmarshal([]byte(contents[0].(mcp.TextResourceContents).Text), &tree))
+	images := map[string]*ImageInfo{}
+	for _, child := range tree.Children {
+		images[child.Name] = child.Image
+	}
+	assert.Equal(t, 300, images["pic.webp"].Width)
+	assert.Equal(t, "png", images["photo.png"].Format)
+	assert.Nil(t, images["notes.txt"])
+}
@@ -0,0 +1,183 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Important file categories, in the order they are ranked and presented
+const (
+	importantBuild      = "build"       // manifests, build and tooling config
+	importantEntryPoint = "entry_point" // where execution starts
+	importantDocs       = "docs"        // README, LICENSE, CHANGELOG...
+	importantSource     = "source"      // the most recently modified source files
+)
+
+var importantCategories = []string{importantBuild, importantEntryPoint, importantDocs, importantSource}
+
+// importantCategoryCaps bounds each category, so a deep tree of sources
+// can't crowd out the root manifest
+var importantCategoryCaps = map[string]int{
+	importantBuild:      10,
+	importantEntryPoint: 8,
+	importantDocs:       5,
+	importantSource:     10,
+}
+
+// defaultImportantPatterns are matched case-insensitively against file names;
+// a pattern with "/" is matched against the path relative to the workspace
+var defaultImportantPatterns = map[string][]string{
+	importantBuild: {
+		"go.mod", "go.work", "package.json", "tsconfig.json", "Cargo.toml",
+		"pyproject.toml", "setup.py", "setup.cfg", "requirements.txt", "Pipfile",
+		"pom.xml", "build.gradle", "build.gradle.kts", "*.csproj", "*.sln",
+		"Makefile", "CMakeLists.txt", "Dockerfile", "docker-compose.yml",
+		"docker-compose.yaml", ".gitignore",
+	},
+	importantEntryPoint: {
+		"main.go", "main.py", "__main__.py", "app.py", "manage.py",
+		"index.js", "index.ts", "main.js", "main.ts", "server.js", "server.ts",
+		"main.rs", "lib.rs", "Program.cs", "Main.java",
+	},
+	importantDocs: {
+		"README*", "LICENSE*", "CHANGELOG*", "CONTRIBUTING*",
+	},
+	importantSource: {
+		"*.go", "*.js", "*.ts", "*.tsx", "*.jsx", "*.py", "*.rs", "*.java", "*.cs",
+	},
+}
+
+// WithImportantFiles adds patterns to the important file categories used by
+// workspace_context and plan_task. Each pattern is "category:glob", where the
+// category is build, entry_point, docs or source; globs follow the defaults
+// (file name, or relative path when they contain "/").
+func WithImportantFiles(patterns ...string) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		for _, pattern := range patterns {
+			pattern = strings.TrimSpace(pattern)
+			if pattern == "" {
+				continue
+			}
+			category, glob, ok := strings.Cut(pattern, ":")
+			if !ok || glob == "" || !containsString(importantCategories, category) {
+				return fmt.Errorf("invalid important file pattern %q: want category:glob with category one of %s", pattern, strings.Join(importantCategories, ", "))
+			}
+			if _, err := filepath.Match(glob, ""); err != nil {
+				return fmt.Errorf("invalid important file pattern %q: %w", pattern, err)
+			}
+			if fs.importantPatterns == nil {
+				fs.importantPatterns = make(map[string][]string)
+			}
+			fs.importantPatterns[category] = append(fs.importantPatterns[category], glob)
+		}
+		return nil
+	}
+}
+
+// importantCandidate is a file that matched a category while walking
+type importantCandidate struct {
+	rel     string
+	depth   int
+	modTime time.Time
+}
+
+// rankImportantFiles walks the workspace and returns the non-empty categories
+// in presentation order. Build files, entry points and docs are ranked by depth
+// then path, so the root manifest always comes first; sources by modification
+// time, newest first. Paths are relative to the workspace.
+func (fs *FilesystemHandler) rankImportantFiles(ctx context.Context, workspace string) []ImportantFileGroup {
+	patterns := make(map[string][]string, len(importantCategories))
+	for _, category := range importantCategories {
+		patterns[category] = append(append([]string{}, defaultImportantPatterns[category]...), fs.importantPatterns[category]...)
+	}
+
+	candidates := make(map[string][]importantCandidate)
+	fs.walkContext(ctx, workspace, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != workspace && containsString(planSkipDirs, info.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, err := fs.validatePath(path); err != nil {
+			return nil
+		}
+
+		rel, err := filepath.Rel(workspace, path)
+		if err != nil {
+			return nil
+		}
+		for _, category := range importantCategories {
+			if matchesImportantPattern(patterns[category], info.Name(), filepath.ToSlash(rel)) {
+				candidates[category] = append(candidates[category], importantCandidate{
+					rel:     rel,
+					depth:   strings.Count(filepath.ToSlash(rel), "/"),
+					modTime: info.ModTime(),
+				})
+				break
+			}
+		}
+		return nil
+	})
+
+	var groups []ImportantFileGroup
+	for _, category := range importantCategories {
+		files := candidates[category]
+		if len(files) == 0 {
+			continue
+		}
+		sort.Slice(files, func(i, j int) bool {
+			a, b := files[i], files[j]
+			if category == importantSource && !a.modTime.Equal(b.modTime) {
+				return a.modTime.After(b.modTime)
+			}
+			if a.depth != b.depth {
+				return a.depth < b.depth
+			}
+			return a.rel < b.rel
+		})
+		if limit := importantCategoryCaps[category]; len(files) > limit {
+			files = files[:limit]
+		}
+		group := ImportantFileGroup{Category: category, Files: make([]string, len(files))}
+		for i, file := range files {
+			group.Files[i] = file.rel
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// matchesImportantPattern matches name case-insensitively, or rel for
+// patterns with a "/"
+func matchesImportantPattern(patterns []string, name, rel string) bool {
+	for _, pattern := range patterns {
+		if strings.Contains(pattern, "/") {
+			if matched, _ := filepath.Match(pattern, rel); matched {
+				return true
+			}
+			continue
+		}
+		if matched, _ := filepath.Match(strings.ToLower(pattern), strings.ToLower(name)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenImportantFiles lists the files of every group in ranking order
+func flattenImportantFiles(groups []ImportantFileGroup) []string {
+	files := []string{}
+	for _, group := range groups {
+		files = append(files, group.Files...)
+	}
+	return files
+}
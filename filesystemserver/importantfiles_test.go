@@ -0,0 +1,91 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankImportantFiles(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	handler, err := NewFilesystemHandler([]string{dir}, WithImportantFiles("build:Taskfile.yml", "docs:docs/*.adoc"))
+	require.NoError(t, err)
+
+	files := map[string]string{
+		// "a/" se recorre antes que la raíz: el go.mod de la raíz debe ganar igualmente
+		"a/b/c/go.mod":       "module deep\n",
+		"go.mod":             "module root\n",
+		"Taskfile.yml":       "version: 3\n",
+		"cmd/tool/main.go":   "package main\n",
+		"main.go":            "package main\n",
+		"readme.md":          "# lower-case readme\n",
+		"docs/guide.adoc":    "= Guide\n",
+		"docs/other.txt":     "not important\n",
+		"node_modules/x.js":  "skipped\n",
+		"internal/old.go":    "package internal\n",
+		"internal/recent.go": "package internal\n",
+	}
+	for i := 0; i < 15; i++ {
+		files[fmt.Sprintf("pkg/file%02d.go", i)] = "package pkg\n"
+	}
+	writeFixture(t, dir, files)
+
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 15; i++ {
+		at := base.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "pkg", fmt.Sprintf("file%02d.go", i)), at, at))
+	}
+	old := base.Add(-time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "internal", "old.go"), old, old))
+	recent := base.Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "internal", "recent.go"), recent, recent))
+
+	want := []ImportantFileGroup{
+		{Category: "build", Files: []string{"Taskfile.yml", "go.mod", filepath.Join("a", "b", "c", "go.mod")}},
+		{Category: "entry_point", Files: []string{"main.go", filepath.Join("cmd", "tool", "main.go")}},
+		{Category: "docs", Files: []string{"readme.md", filepath.Join("docs", "guide.adoc")}},
+		{Category: "source", Files: []string{
+			filepath.Join("internal", "recent.go"),
+			filepath.Join("pkg", "file14.go"), filepath.Join("pkg", "file13.go"), filepath.Join("pkg", "file12.go"),
+			filepath.Join("pkg", "file11.go"), filepath.Join("pkg", "file10.go"), filepath.Join("pkg", "file09.go"),
+			filepath.Join("pkg", "file08.go"), filepath.Join("pkg", "file07.go"), filepath.Join("pkg", "file06.go"),
+		}},
+	}
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, want, handler.rankImportantFiles(context.Background(), dir), "ranking must not depend on walk order")
+	}
+	assert.Equal(t, flattenImportantFiles(want), handler.findImportantFiles(context.Background(), dir))
+
+	result, err := handler.handleWorkspaceContext(context.Background(), newToolRequest("workspace_context", map[string]interface{}{"path": dir}))
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "🔧 Build files (3):\n  - Taskfile.yml\n  - go.mod\n")
+	assert.Contains(t, text, "🚀 Entry points (2):")
+	assert.Contains(t, text, "📚 Docs (2):")
+	assert.Contains(t, text, "🕒 Recently modified source (10):")
+	var workspace WorkspaceContext
+	decodeStructured(t, result, &workspace)
+	assert.Equal(t, want, workspace.ImportantGroups)
+
+	// El planificador analiza el código, no los manifiestos
+	steps := handler.generateRefactorSteps(nil, map[string]interface{}{
+		"important_files":  flattenImportantFiles(want),
+		"important_groups": want,
+	})
+	assert.Equal(t, append(want[1].Files, want[3].Files...), steps[0].Files)
+}
+
+func TestWithImportantFilesRejectsBadPatterns(t *testing.T) {
+	for _, pattern := range []string{"Taskfile.yml", "tests:*.go", "build:", "build:[a"} {
+		_, err := NewFilesystemHandler([]string{t.TempDir()}, WithImportantFiles(pattern))
+		assert.Error(t, err, pattern)
+	}
+}
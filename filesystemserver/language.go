@@ -0,0 +1,316 @@
+package filesystemserver
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Language is a detected language. Name is the display name used in project
+// analysis and reports ("Go", "React TSX"); Key is the lowercase key the code
+// metrics, outlines and refactor syntaxes are keyed by ("go", "typescript").
+type Language struct {
+	Name string
+	Key  string
+}
+
+// unknownLanguage is what Detect returns when no signal matches.
+var unknownLanguage = Language{Name: "unknown", Key: "unknown"}
+
+// newLanguage builds a Language from its display name.
+func newLanguage(name string) Language {
+	return Language{Name: name, Key: analysisLanguage(name)}
+}
+
+// analysisLanguage maps a display name to its metrics key
+func analysisLanguage(language string) string {
+	switch language {
+	case "JavaScript", "React JSX", "Node.js":
+		return "javascript"
+	case "TypeScript", "React TSX":
+		return "typescript"
+	case "C#":
+		return "csharp"
+	case "C++":
+		return "cpp"
+	}
+	return strings.ToLower(language)
+}
+
+// Confidence Detect reports for each kind of evidence. Content signals score
+// up to maxContentConfidence depending on how many matched and how clearly one
+// language won.
+const (
+	extensionConfidence  = 0.9
+	filenameConfidence   = 0.9
+	shebangConfidence    = 0.85
+	maxContentConfidence = 0.7
+	ambiguousConfidence  = 0.4 // ambiguous extension, no content signal to settle it
+)
+
+// minContentScore is the signal weight a language needs before content alone
+// names it; a single weak hint is not enough.
+const minContentScore = 2
+
+// maxLanguageSample is how much of the content Detect looks at.
+const maxLanguageSample = 16 * 1024
+
+// languageSignal is a content pattern that hints at language with weight.
+type languageSignal struct {
+	language string
+	weight   float64
+	pattern  *regexp.Regexp
+}
+
+// projectMarker names a project type and the files or globs that reveal it.
+type projectMarker struct {
+	projectType string
+	files       []string
+}
+
+// LanguageDetector names the language of a file from, in order, its
+// extension, its file name, its shebang line and weighted content signals.
+// Ambiguous extensions (.h, .m) let the content choose among their candidates.
+// It also names the project type of a directory from its marker files.
+type LanguageDetector struct {
+	extensions   map[string]string
+	ambiguous    map[string][]string // candidates, the first one is the fallback
+	filenames    map[string]string   // lower-case base name
+	interpreters map[string]string   // shebang interpreter without version
+	signals      []languageSignal
+	projects     []projectMarker // checked in order
+}
+
+// languageDetector is the detector every tool uses.
+var languageDetector = newLanguageDetector()
+
+// newLanguageDetector builds the detector with the built-in tables.
+func newLanguageDetector() *LanguageDetector {
+	signal := func(language string, weight float64, pattern string) languageSignal {
+		return languageSignal{language: language, weight: weight, pattern: regexp.MustCompile(pattern)}
+	}
+	return &LanguageDetector{
+		extensions: map[string]string{
+			".go": "Go", ".py": "Python", ".js": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript",
+			".ts": "TypeScript", ".jsx": "React JSX", ".tsx": "React TSX",
+			".java": "Java", ".kt": "Kotlin", ".rs": "Rust",
+			".cpp": "C++", ".cc": "C++", ".cxx": "C++", ".hpp": "C++", ".hh": "C++",
+			".c": "C", ".cs": "C#", ".mm": "Objective-C",
+			".php": "PHP", ".rb": "Ruby", ".swift": "Swift", ".dart": "Dart", ".scala": "Scala",
+			".html": "HTML", ".css": "CSS", ".scss": "SASS", ".less": "LESS", ".vue": "Vue",
+			".sql": "SQL", ".sh": "Shell", ".bash": "Shell", ".zsh": "Shell",
+			".ps1": "PowerShell", ".bat": "Batch", ".dockerfile": "Docker",
+			".yaml": "YAML", ".yml": "YAML", ".json": "JSON", ".xml": "XML", ".toml": "TOML", ".ini": "INI",
+			".md": "Markdown", ".tex": "LaTeX", ".r": "R", ".jl": "Julia", ".elm": "Elm",
+			".ex": "Elixir", ".exs": "Elixir", ".erl": "Erlang", ".hrl": "Erlang", ".clj": "Clojure",
+			".fs": "F#", ".ml": "OCaml", ".hs": "Haskell", ".lua": "Lua", ".pl": "Perl", ".vim": "Vimscript",
+		},
+		ambiguous: map[string][]string{
+			".h": {"C", "C++", "Objective-C"},
+			".m": {"MATLAB", "Objective-C"},
+		},
+		filenames: map[string]string{
+			"dockerfile": "Docker", "makefile": "Makefile", "rakefile": "Ruby", "gemfile": "Ruby",
+			"package.json": "Node.js", "composer.json": "PHP", "pom.xml": "Java", "cargo.toml": "Rust",
+			"go.mod": "Go", "requirements.txt": "Python", "pipfile": "Python",
+		},
+		interpreters: map[string]string{
+			"python": "Python", "pypy": "Python",
+			"node": "JavaScript", "nodejs": "JavaScript",
+			"deno": "TypeScript", "ts-node": "TypeScript", "bun": "TypeScript",
+			"sh": "Shell", "bash": "Shell", "zsh": "Shell", "dash": "Shell", "ksh": "Shell",
+			"ruby": "Ruby", "perl": "Perl", "php": "PHP", "lua": "Lua",
+		},
+		signals: []languageSignal{
+			signal("Go", 1.5, `(?m)^package\s+\w+\s*$`),
+			signal("Go", 1.5, `(?m)^func\s`),
+			signal("Go", 1, `(?m)^type\s+\w+\s+(?:struct|interface)\s*\{`),
+			signal("Go", 1, `(?m)^import\s+(?:\(|"[\w./-]+"\s*$)`),
+
+			signal("Rust", 1.5, `(?m)^\s*(?:pub\s+)?fn\s+\w+`),
+			signal("Rust", 1, `\blet\s+mut\s+\w+`),
+			signal("Rust", 1.5, `(?m)^\s*use\s+\w+(?:::\w+)+`),
+			signal("Rust", 1, `\b(?:println|vec|format)!\s*[(\[]`),
+
+			signal("C#", 3, `(?m)^\s*using\s+System[\w.]*;`),
+			signal("C#", 1, `(?m)^\s*namespace\s+[\w.]+\s*(?:;|$)`),
+			signal("C#", 1.5, `\bConsole\.Write(?:Line)?\s*\(`),
+
+			signal("Java", 3, `(?m)^\s*package\s+[\w.]+;`),
+			signal("Java", 3, `(?m)^\s*import\s+java\.`),
+			signal("Java", 3, `public\s+static\s+void\s+main\s*\(`),
+			signal("Java", 1.5, `\bSystem\.out\.print`),
+
+			signal("Python", 2, `(?m)^\s*def\s+\w+\s*\(.*\)\s*(?:->\s*[^:]+)?:`),
+			signal("Python", 2, `(?m)^\s*from\s+[\w.]+\s+import\s`),
+			signal("Python", 3, `(?m)^if\s+__name__\s*==\s*['"]__main__['"]`),
+			signal("Python", 0.5, `(?m)^import\s+[\w.]+(?:\s+as\s+\w+)?\s*$`),
+			signal("Python", 1, `(?m)^\s*elif\s`),
+
+			signal("TypeScript", 1, `(?m)^\s*(?:export\s+)?(?:interface|type)\s+\w+`),
+			signal("TypeScript", 1.5, `:\s*(?:string|number|boolean)\b`),
+
+			signal("JavaScript", 1.5, `\bfunction\s+\w+\s*\(`),
+			signal("JavaScript", 1, `=>\s*[{(]`),
+			signal("JavaScript", 2, `\brequire\s*\(\s*['"]`),
+			signal("JavaScript", 2, `\bmodule\.exports\b`),
+			signal("JavaScript", 1, `\bconsole\.log\s*\(`),
+
+			signal("Shell", 2, `(?m)^\s*(?:fi|esac|done)\s*$`),
+			signal("Shell", 1.5, `(?m)^\s*export\s+\w+=`),
+			signal("Shell", 1, `(?m)^\s*echo\s`),
+
+			signal("C", 2, `(?m)^\s*#include\s*<\w+\.h>`),
+			signal("C", 1.5, `(?m)^\s*typedef\s+struct\b`),
+			signal("C", 1, `\b(?:printf|malloc|free)\s*\(`),
+
+			signal("C++", 2.5, `(?m)^\s*#include\s*<[a-z_]+>`),
+			signal("C++", 2, `\bstd::`),
+			signal("C++", 2, `\btemplate\s*<`),
+			signal("C++", 2, `(?m)^\s*(?:public|private|protected):`),
+			signal("C++", 1.5, `(?m)^\s*namespace\s+\w+\s*\{`),
+			signal("C++", 1, `\bvirtual\s`),
+
+			signal("Objective-C", 3, `(?m)^\s*@(?:interface|implementation|protocol)\b`),
+			signal("Objective-C", 2, `(?m)^\s*#import\s*[<"]`),
+			signal("Objective-C", 2, `(?m)^\s*@property\b`),
+			signal("Objective-C", 1.5, `\bNS(?:String|Object|Array|Dictionary)\b`),
+
+			signal("MATLAB", 3, `(?m)^\s*function\s+(?:\[[^\]]*\]|\w+)\s*=\s*\w+\s*\(`),
+			signal("MATLAB", 1, `(?m)^\s*%`),
+			signal("MATLAB", 1.5, `\b(?:disp|zeros|ones|fprintf)\s*\(`),
+			signal("MATLAB", 1, `\.\^`),
+
+			signal("PHP", 3, `<\?php`),
+		},
+		projects: []projectMarker{
+			// Language manifests before the generic markers (src, public,
+			// Dockerfile) that many projects share
+			{"go", []string{"go.mod", "go.sum", "main.go"}},
+			{"rust", []string{"Cargo.toml", "Cargo.lock"}},
+			{"node", []string{"package.json", "node_modules"}},
+			{"python", []string{"requirements.txt", "setup.py", "pyproject.toml"}},
+			{"java", []string{"pom.xml", "build.gradle", "src/main/java"}},
+			{"dotnet", []string{"*.csproj", "*.sln", "Program.cs"}},
+			{"docker", []string{"Dockerfile", "docker-compose.yml"}},
+			{"web", []string{"index.html", "src", "public"}},
+		},
+	}
+}
+
+// Detect names the language of path, using sample (the start of its content,
+// or "" when it wasn't read) for files the name doesn't settle. The confidence
+// is between 0 and 1, and 0 for unknownLanguage.
+func (d *LanguageDetector) Detect(path, sample string) (Language, float64) {
+	if len(sample) > maxLanguageSample {
+		sample = sample[:maxLanguageSample]
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if name, ok := d.extensions[ext]; ok {
+		return newLanguage(name), extensionConfidence
+	}
+	if candidates, ok := d.ambiguous[ext]; ok {
+		if name, confidence := d.fromContent(sample, candidates); name != "" {
+			return newLanguage(name), confidence
+		}
+		return newLanguage(candidates[0]), ambiguousConfidence
+	}
+	if path != "" {
+		if name, ok := d.filenames[strings.ToLower(filepath.Base(path))]; ok {
+			return newLanguage(name), filenameConfidence
+		}
+	}
+	if name := d.fromShebang(sample); name != "" {
+		return newLanguage(name), shebangConfidence
+	}
+	if name, confidence := d.fromContent(sample, nil); name != "" {
+		return newLanguage(name), confidence
+	}
+	return unknownLanguage, 0
+}
+
+// fromShebang parses "#!/usr/bin/python3" or "#!/usr/bin/env -S node"
+func (d *LanguageDetector) fromShebang(content string) string {
+	if !strings.HasPrefix(content, "#!") {
+		return ""
+	}
+	firstLine := strings.SplitN(content, "\n", 2)[0]
+	fields := strings.Fields(strings.TrimPrefix(firstLine, "#!"))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = filepath.Base(field)
+				break
+			}
+		}
+	}
+	// python3.11 -> python
+	return d.interpreters[strings.TrimRight(interpreter, "0123456789.")]
+}
+
+// fromContent adds up the weights of the signals that match content, only
+// for candidates when given, and names the best language if it reaches
+// minContentScore. Ties go to the language listed first in the signals.
+func (d *LanguageDetector) fromContent(content string, candidates []string) (string, float64) {
+	if content == "" {
+		return "", 0
+	}
+	scores := make(map[string]float64)
+	var order []string
+	total := 0.0
+	for _, s := range d.signals {
+		if candidates != nil && !containsString(candidates, s.language) {
+			continue
+		}
+		if !s.pattern.MatchString(content) {
+			continue
+		}
+		if _, seen := scores[s.language]; !seen {
+			order = append(order, s.language)
+		}
+		scores[s.language] += s.weight
+		total += s.weight
+	}
+
+	best := ""
+	for _, language := range order {
+		if best == "" || scores[language] > scores[best] {
+			best = language
+		}
+	}
+	if best == "" || scores[best] < minContentScore {
+		return "", 0
+	}
+	// More evidence and less competition from other languages raise it
+	confidence := maxContentConfidence * scores[best] / total * min(1, scores[best]/4)
+	return best, confidence
+}
+
+// Project names the project type of dir from its marker files: "go", "node",
+// "python"... or "unknown".
+func (d *LanguageDetector) Project(dir string) string {
+	for _, marker := range d.projects {
+		for _, file := range marker.files {
+			if filepath.Ext(file) == "" {
+				// Directory or exact file
+				if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+					return marker.projectType
+				}
+			} else {
+				// Pattern matching
+				matches, _ := filepath.Glob(filepath.Join(dir, file))
+				if len(matches) > 0 {
+					return marker.projectType
+				}
+			}
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,80 @@
+package filesystemserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguageDetectorCorpus(t *testing.T) {
+	tests := []struct {
+		file          string
+		language      string
+		key           string
+		minConfidence float64
+		maxConfidence float64
+	}{
+		{"main.go", "Go", "go", extensionConfidence, extensionConfidence},
+		{"Makefile", "Makefile", "makefile", filenameConfidence, filenameConfidence},
+		{"deploy", "Python", "python", shebangConfidence, shebangConfidence},
+		{"build", "Shell", "shell", shebangConfidence, shebangConfidence},
+
+		// Extensiones ambiguas: decide el contenido
+		{"point.h", "C", "c", 0.5, maxContentConfidence},
+		{"shape.h", "C++", "cpp", 0.5, maxContentConfidence},
+		{"Greeter.h", "Objective-C", "objective-c", 0.5, maxContentConfidence},
+		{"square.m", "MATLAB", "matlab", 0.5, maxContentConfidence},
+		{"Greeter.m", "Objective-C", "objective-c", 0.5, maxContentConfidence},
+
+		// Sin extensión ni shebang: solo señales de contenido
+		{"manage", "Python", "python", 0.5, maxContentConfidence},
+		{"server", "JavaScript", "javascript", 0.5, maxContentConfidence},
+		{"notes", "unknown", "unknown", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "languages", tt.file))
+			require.NoError(t, err)
+			language, confidence := languageDetector.Detect(tt.file, string(data))
+			assert.Equal(t, Language{Name: tt.language, Key: tt.key}, language)
+			assert.GreaterOrEqual(t, confidence, tt.minConfidence)
+			assert.LessOrEqual(t, confidence, tt.maxConfidence)
+		})
+	}
+}
+
+func TestLanguageDetectorFallbacks(t *testing.T) {
+	// Sin contenido, una extensión ambigua se queda con su primer candidato
+	language, confidence := languageDetector.Detect("api.h", "")
+	assert.Equal(t, "C", language.Name)
+	assert.Equal(t, ambiguousConfidence, confidence)
+
+	// La extensión manda sobre el contenido y el nombre especial
+	language, _ = languageDetector.Detect("package.json", `{"name": "app"}`)
+	assert.Equal(t, "JSON", language.Name)
+	language, _ = languageDetector.Detect("notes.py", "#!/bin/bash\necho hi\n")
+	assert.Equal(t, "Python", language.Name)
+
+	// Una sola pista débil no basta
+	language, _ = languageDetector.Detect("", "import os\n")
+	assert.Equal(t, unknownLanguage, language)
+
+	// Más señales, más confianza
+	_, weak := languageDetector.Detect("", "package main\n\nfunc main() {}\n")
+	_, strong := languageDetector.Detect("", "package main\n\nimport (\n\t\"fmt\"\n)\n\ntype T struct {\n}\n\nfunc main() {}\n")
+	assert.Less(t, weak, strong)
+}
+
+func TestLanguageDetectorProject(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, "unknown", languageDetector.Project(dir))
+	writeFixture(t, dir, map[string]string{"public/index.html": "<html></html>\n"})
+	assert.Equal(t, "web", languageDetector.Project(dir))
+	writeFixture(t, dir, map[string]string{"App.csproj": "<Project/>\n"})
+	assert.Equal(t, "dotnet", languageDetector.Project(dir))
+	writeFixture(t, dir, map[string]string{"Cargo.toml": "[package]\n"})
+	assert.Equal(t, "rust", languageDetector.Project(dir), "manifests beat generic markers")
+}
@@ -0,0 +1,135 @@
+package filesystemserver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Some tools (find_duplicates, checksum, analyze_project, compare_files on
+// directories, generate_report) can produce hundreds of KB for a big tree.
+// Past the inline limit the full output is written to a report file inside the
+// allowed directory holding the analyzed path, and the call returns a summary
+// plus an embedded resource pointing at the file, as read_file does for large
+// files. In read-only mode nothing is written and the result stays inline.
+
+// reportDirName is the directory, inside each allowed directory, holding oversized results
+const reportDirName = ".mcp-reports"
+
+// defaultInlineResultLimit is the largest result, text and JSON together, returned inline
+const defaultInlineResultLimit = 128 * 1024
+
+// maxKeptReports bounds the report files kept per allowed directory; older ones are removed
+const maxKeptReports = 20
+
+// oversizedResultNote ends the description of the tools that use oversizedResult
+const oversizedResultNote = " Results over the inline limit (128KB by default) are saved under .mcp-reports in the allowed directory and returned as a summary plus a resource for the full file."
+
+// reportFormats maps a report format to its file extension and MIME type
+var reportFormats = map[string]struct{ ext, mimeType string }{
+	"json":     {".json", "application/json"},
+	"markdown": {".md", "text/markdown"},
+	"html":     {".html", "text/html"},
+	"sfv":      {".sfv", "text/plain"},
+	"text":     {".txt", "text/plain"},
+}
+
+// WithInlineResultLimit sets the size in bytes above which large results are
+// written to a report file under .mcp-reports instead of being returned
+// inline. 0 or less keeps the 128KB default.
+func WithInlineResultLimit(limit int) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		fs.inlineLimit = limit
+		return nil
+	}
+}
+
+// inlineResultLimit returns the effective inline limit
+func (fs *FilesystemHandler) inlineResultLimit() int {
+	if fs.inlineLimit > 0 {
+		return fs.inlineLimit
+	}
+	return defaultInlineResultLimit
+}
+
+// oversizedResult returns nil when a result of inlineSize bytes fits inline,
+// or when the report cannot be written; the caller then answers as usual.
+// Otherwise report is saved in the given format next to validPath's allowed
+// directory and the result is summary, the report location and a resource
+// for it.
+func (fs *FilesystemHandler) oversizedResult(validPath, tool string, inlineSize int, report []byte, format string, summary func() string) *mcp.CallToolResult {
+	limit := fs.inlineResultLimit()
+	if inlineSize <= limit || fs.readOnly {
+		return nil
+	}
+	reportPath, err := fs.writeReport(validPath, tool, format, report)
+	if err != nil {
+		fs.log().Warn("could not write oversized result to a report file", "tool", tool, "error", err)
+		return nil
+	}
+
+	text := strings.TrimRight(summary(), "\n")
+	text += fmt.Sprintf("\n\n📎 Full result (%d bytes, over the %d byte inline limit) saved to %s\n", len(report), limit, reportPath)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: text},
+			newResourceRef(pathToResourceURI(reportPath), reportFormats[format].mimeType),
+		},
+	}
+}
+
+// writeReport saves data under <allowed dir>/.mcp-reports and prunes the
+// oldest reports beyond maxKeptReports
+func (fs *FilesystemHandler) writeReport(validPath, tool, format string, data []byte) (string, error) {
+	root := fs.allowedDirFor(validPath)
+	if root == "" {
+		return "", fmt.Errorf("no allowed directory contains %s", validPath)
+	}
+	dir := filepath.Join(root, reportDirName)
+	if err := fs.mkdirAllChecked(dir, 0755); err != nil {
+		return "", err
+	}
+	reportPath := filepath.Join(dir, newTimestampID(tool)+reportFormats[format].ext)
+	if err := fs.writeFileChecked(reportPath, data, 0644); err != nil {
+		os.Remove(reportPath)
+		return "", err
+	}
+	pruneReports(dir, maxKeptReports)
+	return reportPath, nil
+}
+
+// pruneReports removes all but the keep most recent files in dir
+func pruneReports(dir string, keep int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	type report struct {
+		path    string
+		modTime int64
+	}
+	var reports []report
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		reports = append(reports, report{filepath.Join(dir, entry.Name()), info.ModTime().UnixNano()})
+	}
+	if len(reports) <= keep {
+		return
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].modTime != reports[j].modTime {
+			return reports[i].modTime > reports[j].modTime
+		}
+		return reports[i].path > reports[j].path
+	})
+	for _, r := range reports[keep:] {
+		os.Remove(r.path)
+	}
+}
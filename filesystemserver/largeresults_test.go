@@ -0,0 +1,176 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// spilledReport checks that result is a summary plus a resource for a report
+// under .mcp-reports and returns the summary text and the report path
+func spilledReport(t *testing.T, result *mcp.CallToolResult, dir, ext string) (string, string) {
+	t.Helper()
+	require.False(t, result.IsError)
+	require.Len(t, result.Content, 2)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "📎 Full result")
+
+	embedded, ok := result.Content[1].(mcp.EmbeddedResource)
+	require.True(t, ok)
+	resource := embedded.Resource.(mcp.TextResourceContents)
+	reports, err := filepath.Glob(filepath.Join(dir, reportDirName, "*"+ext))
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, pathToResourceURI(reports[0]), resource.URI)
+	assert.Contains(t, text, reports[0])
+	return text, reports[0]
+}
+
+// writeDuplicateGroups creates n groups of two identical files
+func writeDuplicateGroups(t *testing.T, dir string, n int) {
+	t.Helper()
+	files := make(map[string]string)
+	for i := 0; i < n; i++ {
+		content := strings.Repeat(fmt.Sprintf("group %d ", i), i+1)
+		files[fmt.Sprintf("a/file%02d.txt", i)] = content
+		files[fmt.Sprintf("b/file%02d.txt", i)] = content
+	}
+	writeFixture(t, dir, files)
+}
+
+func TestFindDuplicatesOversizedResult(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeDuplicateGroups(t, dir, 15)
+
+	args := map[string]interface{}{"path": dir}
+	result, err := handler.handleFindDuplicates(context.Background(), newToolRequest("find_duplicates", args))
+	require.NoError(t, err)
+	var inline DuplicateReport
+	decodeStructured(t, result, &inline)
+	assert.Len(t, inline.Groups, 15, "small results stay inline")
+
+	require.NoError(t, WithInlineResultLimit(1024)(handler))
+	result, err = handler.handleFindDuplicates(context.Background(), newToolRequest("find_duplicates", args))
+	require.NoError(t, err)
+	text, reportPath := spilledReport(t, result, dir, ".json")
+	assert.Contains(t, text, "🔍 Found 15 groups of duplicate files")
+	assert.Contains(t, text, "🏆 Top 10 groups by wasted space:")
+	assert.Equal(t, 10, strings.Count(text, "  • 2 × "))
+	assert.Contains(t, text, filepath.Join(dir, "a", "file14.txt"), "the largest group comes first")
+	assert.NotContains(t, text, filepath.Join(dir, "a", "file00.txt"))
+
+	data, err := os.ReadFile(reportPath)
+	require.NoError(t, err)
+	var full DuplicateReport
+	require.NoError(t, json.Unmarshal(data, &full))
+	assert.Equal(t, inline.Groups, full.Groups)
+
+	// Los informes no cuentan como duplicados en la siguiente búsqueda
+	result, err = handler.handleFindDuplicates(context.Background(), newToolRequest("find_duplicates", map[string]interface{}{"path": dir, "output": "json"}))
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "🔍 Found 15 groups")
+}
+
+func TestOversizedResultReadOnlyStaysInline(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeDuplicateGroups(t, dir, 5)
+	require.NoError(t, WithInlineResultLimit(256)(handler))
+	require.NoError(t, WithReadOnly(true)(handler))
+
+	result, err := handler.handleFindDuplicates(context.Background(), newToolRequest("find_duplicates", map[string]interface{}{"path": dir}))
+	require.NoError(t, err)
+	var report DuplicateReport
+	decodeStructured(t, result, &report)
+	assert.Len(t, report.Groups, 5)
+	assert.NoDirExists(t, filepath.Join(dir, reportDirName))
+}
+
+func TestOversizedResultFormats(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	require.NoError(t, WithInlineResultLimit(512)(handler))
+	files := make(map[string]string)
+	for i := 0; i < 30; i++ {
+		files[fmt.Sprintf("src/file%02d.go", i)] = fmt.Sprintf("package src\n\nvar X%d = %d\n", i, i)
+	}
+	writeFixture(t, filepath.Join(dir, "project"), files)
+	project := filepath.Join(dir, "project")
+
+	tests := []struct {
+		name    string
+		handle  func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
+		args    map[string]interface{}
+		ext     string
+		summary string
+	}{
+		{"checksum", handler.handleChecksum, map[string]interface{}{"path": project, "recursive": true}, ".json", "🔐 Checksums for"},
+		{"checksum sfv", handler.handleChecksum, map[string]interface{}{"path": project, "recursive": true, "output": "sfv"}, ".sfv", "📋 Manifest SHA256"},
+		{"analyze_project", handler.handleAnalyzeProject, map[string]interface{}{"path": project}, ".json", "🏗️ **Project Structure Analysis**"},
+		{"generate_report", handler.handleGenerateReport, map[string]interface{}{"path": project, "format": "markdown"}, ".md", "📊 Project report for"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, os.RemoveAll(filepath.Join(dir, reportDirName)))
+			result, err := tt.handle(context.Background(), newToolRequest(tt.name, tt.args))
+			require.NoError(t, err)
+			text, reportPath := spilledReport(t, result, dir, tt.ext)
+			assert.Contains(t, text, tt.summary)
+			info, err := os.Stat(reportPath)
+			require.NoError(t, err)
+			assert.Greater(t, info.Size(), int64(512))
+		})
+	}
+}
+
+func TestCompareDirectoriesOversizedResult(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	require.NoError(t, WithInlineResultLimit(512)(handler))
+	left := make(map[string]string)
+	for i := 0; i < 40; i++ {
+		left[fmt.Sprintf("only-left-%02d.txt", i)] = "x"
+	}
+	writeFixture(t, filepath.Join(dir, "left"), left)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "right"), 0755))
+
+	result, err := handler.handleCompareFiles(context.Background(), newToolRequest("compare_files", map[string]interface{}{
+		"file1": filepath.Join(dir, "left"), "file2": filepath.Join(dir, "right"),
+	}))
+	require.NoError(t, err)
+	text, reportPath := spilledReport(t, result, dir, ".json")
+	assert.Contains(t, text, "⬅️ Only in A: 40")
+	assert.NotContains(t, text, "only-left-00.txt")
+
+	data, err := os.ReadFile(reportPath)
+	require.NoError(t, err)
+	var diff DirectoryDiff
+	require.NoError(t, json.Unmarshal(data, &diff))
+	assert.Len(t, diff.OnlyInA, 40)
+}
+
+func TestPruneReports(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("report%d.json", i))
+		require.NoError(t, os.WriteFile(path, []byte("{}"), 0644))
+		modTime := now.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	pruneReports(dir, 2)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{"report3.json", "report4.json"}, names)
+}
@@ -0,0 +1,139 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxLoggedArgLength is the longest string argument logged verbatim; longer
+// ones (file contents, edits) are logged by size only
+const maxLoggedArgLength = 80
+
+// WithLogger sets the logger used for tool invocations (debug), path rejections
+// and swallowed walker errors (warn). It must not write to stdout, which
+// carries the MCP stdio transport.
+func WithLogger(logger *slog.Logger) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		if logger == nil {
+			return fmt.Errorf("logger must not be nil")
+		}
+		fs.logger = logger
+		return nil
+	}
+}
+
+// newStderrLogger returns the default text logger writing to stderr
+func newStderrLogger(level slog.Level) *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+}
+
+// parseLogLevel parses debug, info, warn or error (case-insensitive)
+func parseLogLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q (use debug, info, warn or error)", value)
+	}
+	return level, nil
+}
+
+// fallbackLogger serves handlers built without NewFilesystemHandler
+var fallbackLogger = newStderrLogger(slog.LevelInfo)
+
+// log returns the handler logger
+func (fs *FilesystemHandler) log() *slog.Logger {
+	if fs.logger != nil {
+		return fs.logger
+	}
+	return fallbackLogger
+}
+
+// logWalkError records an entry a directory walk skipped because it could not
+// be read, in the log and in the call's walk warnings when ctx collects them
+func (fs *FilesystemHandler) logWalkError(ctx context.Context, path string, err error) {
+	fs.log().Warn("walk skipped entry", "path", path, "error", err)
+	walkWarningsFrom(ctx).add(path, err)
+}
+
+// logToolCall records one tool invocation at debug level
+func (fs *FilesystemHandler) logToolCall(name string, args map[string]interface{}, duration time.Duration, result *mcp.CallToolResult, err error) {
+	attrs := []any{
+		"tool", name,
+		summarizeArgs(args),
+		"duration", duration,
+		"result_bytes", resultSize(result),
+	}
+	switch {
+	case err != nil:
+		attrs = append(attrs, "error", err.Error())
+	case result != nil && result.IsError:
+		attrs = append(attrs, "error", truncateForLog(resultText(result)))
+	}
+	fs.log().Debug("tool call", attrs...)
+}
+
+// summarizeArgs groups the call arguments, replacing long strings and
+// collections by their size so file contents never reach the log
+func summarizeArgs(args map[string]interface{}) slog.Attr {
+	keys := make([]string, 0, len(args))
+	for key := range args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]any, 0, len(keys))
+	for _, key := range keys {
+		switch value := args[key].(type) {
+		case string:
+			if len(value) > maxLoggedArgLength {
+				attrs = append(attrs, slog.String(key, fmt.Sprintf("<%d bytes>", len(value))))
+			} else {
+				attrs = append(attrs, slog.String(key, value))
+			}
+		case []interface{}:
+			attrs = append(attrs, slog.String(key, fmt.Sprintf("<%d items>", len(value))))
+		case map[string]interface{}:
+			attrs = append(attrs, slog.String(key, fmt.Sprintf("<%d keys>", len(value))))
+		default:
+			attrs = append(attrs, slog.Any(key, value))
+		}
+	}
+	return slog.Group("args", attrs...)
+}
+
+// resultSize is the total length of the text content of a result
+func resultSize(result *mcp.CallToolResult) int {
+	if result == nil {
+		return 0
+	}
+	size := 0
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			size += len(text.Text)
+		}
+	}
+	return size
+}
+
+// resultText is the first text content of a result
+func resultText(result *mcp.CallToolResult) string {
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			return text.Text
+		}
+	}
+	return ""
+}
+
+// truncateForLog shortens long error texts
+func truncateForLog(s string) string {
+	if len(s) > 200 {
+		s = s[:200] + "..."
+	}
+	return s
+}
@@ -0,0 +1,98 @@
+package filesystemserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logRecords decodes the JSON lines written by a slog.JSONHandler
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestToolCallLogging(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0644))
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	handler, err := NewFilesystemHandler([]string{dir}, WithLogger(logger))
+	require.NoError(t, err)
+	read := handler.guardTool("read_file", handler.handleReadFile)
+
+	result, err := read(context.Background(), newToolRequest("read_file", map[string]interface{}{"path": filepath.Join(dir, "a.txt")}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	records := logRecords(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "DEBUG", records[0]["level"])
+	assert.Equal(t, "tool call", records[0]["msg"])
+	assert.Equal(t, "read_file", records[0]["tool"])
+	assert.Equal(t, map[string]interface{}{"path": filepath.Join(dir, "a.txt")}, records[0]["args"])
+	assert.Equal(t, float64(5), records[0]["result_bytes"])
+	assert.Contains(t, records[0], "duration")
+	assert.NotContains(t, records[0], "error")
+
+	buf.Reset()
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	result, err = read(context.Background(), newToolRequest("read_file", map[string]interface{}{"path": outside}))
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+
+	records = logRecords(t, &buf)
+	require.Len(t, records, 2)
+	assert.Equal(t, "WARN", records[0]["level"])
+	assert.Equal(t, "path rejected", records[0]["msg"])
+	assert.Equal(t, outside, records[0]["path"])
+	assert.Equal(t, "tool call", records[1]["msg"])
+	assert.Contains(t, records[1]["error"], "outside allowed directories")
+}
+
+func TestSummarizeArgs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger.Info("x", summarizeArgs(map[string]interface{}{
+		"path":      "/a.txt",
+		"content":   strings.Repeat("secret ", 20),
+		"paths":     []interface{}{"a", "b"},
+		"options":   map[string]interface{}{"apply": true},
+		"recursive": true,
+	}))
+
+	records := logRecords(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, map[string]interface{}{
+		"path":      "/a.txt",
+		"content":   "<140 bytes>",
+		"paths":     "<2 items>",
+		"options":   "<1 keys>",
+		"recursive": true,
+	}, records[0]["args"])
+
+	_, err := parseLogLevel("verbose")
+	assert.Error(t, err)
+	level, err := parseLogLevel("debug")
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, level)
+}
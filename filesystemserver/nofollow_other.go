@@ -0,0 +1,7 @@
+//go:build !linux && !darwin && !freebsd
+
+package filesystemserver
+
+// openNoFollow is not available on this platform; the Lstat comparison in
+// openFileChecked still rejects files reached through a symlink.
+const openNoFollow = 0
@@ -0,0 +1,9 @@
+//go:build linux || darwin || freebsd
+
+package filesystemserver
+
+import "syscall"
+
+// openNoFollow makes os.OpenFile fail instead of following a symlink in the
+// final path component.
+const openNoFollow = syscall.O_NOFOLLOW
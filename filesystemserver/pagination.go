@@ -0,0 +1,184 @@
+package filesystemserver
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// errPageFull stops a walk once a page is complete and one more result exists
+var errPageFull = errors.New("page full")
+
+// pageCursor is the resume state carried by a cursor. The server keeps
+// nothing, so a cursor stays valid across reconnects.
+type pageCursor struct {
+	After  string `json:"a"` // last result returned, relative to the search root
+	Params string `json:"h"` // hash of the parameters the cursor belongs to
+}
+
+// resultPage tracks one page of a paginated listing or walk. A nil page
+// returns everything, as the tools did before pagination.
+type resultPage struct {
+	root   string
+	after  string // resume after this path (slash separated, relative to root)
+	limit  int    // 0 = no limit
+	params string
+	count  int
+	last   string // last path added to the page
+	more   bool   // at least one result did not fit
+}
+
+// paramsHash identifies the parameters that determine a tool's result set
+func paramsHash(tool string, params ...string) string {
+	sum := sha256.Sum256([]byte(tool + "\x00" + strings.Join(params, "\x00")))
+	return hex.EncodeToString(sum[:12])
+}
+
+// newResultPage reads max_results and cursor from args. hash comes from
+// paramsHash; a cursor issued for other parameters is rejected.
+func newResultPage(root string, args map[string]interface{}, hash string) (*resultPage, error) {
+	page := &resultPage{root: root, params: hash}
+	limit, err := getInt(args, "max_results", 0)
+	if err != nil {
+		return nil, err
+	}
+	page.limit = max(limit, 0)
+
+	cursor, err := getString(args, "cursor", "")
+	if err != nil {
+		return nil, err
+	}
+	if cursor == "" {
+		return page, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var decoded pageCursor
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.After == "" {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	if decoded.Params != hash {
+		return nil, fmt.Errorf("cursor belongs to a call with different parameters; repeat the call without cursor to start over")
+	}
+	page.after = decoded.After
+	return page, nil
+}
+
+// rel returns path relative to the page root in slash form
+func (p *resultPage) rel(path string) string {
+	rel, err := filepath.Rel(p.root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// resumeSkip reports whether a walk resuming from the cursor should ignore
+// path, and whether the whole directory can be skipped because it lies
+// entirely before the cursor position
+func (p *resultPage) resumeSkip(path string, isDir bool) (skip, skipDir bool) {
+	if p == nil || p.after == "" {
+		return false, false
+	}
+	rel := p.rel(path)
+	if rel == p.after {
+		return true, false
+	}
+	if !walkBefore(rel, p.after) {
+		return false, false
+	}
+	// Ancestors of the cursor position still have to be descended
+	if isDir && rel != "." && !strings.HasPrefix(p.after, rel+"/") {
+		return true, true
+	}
+	return true, false
+}
+
+// add records one result. It returns false, with more set, when the page is
+// already full; the caller then stops (a walk returns errPageFull).
+func (p *resultPage) add(path string) bool {
+	if p == nil {
+		return true
+	}
+	if p.limit > 0 && p.count >= p.limit {
+		p.more = true
+		return false
+	}
+	p.count++
+	p.last = p.rel(path)
+	return true
+}
+
+// nextCursor returns the cursor for the next page, or "" on the last page
+func (p *resultPage) nextCursor() string {
+	if p == nil || !p.more {
+		return ""
+	}
+	data, _ := json.Marshal(pageCursor{After: p.last, Params: p.params})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// footer is the line appended to a page with more results after it
+func (p *resultPage) footer() string {
+	cursor := p.nextCursor()
+	if cursor == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n➡️ More results available. Call again with cursor: %s\n", cursor)
+}
+
+// sortWalkOrder sorts paths under root in the order filepath.Walk visits
+// them, which is the order cursors resume from, so results never depend on
+// how they were collected
+func sortWalkOrder(root string, paths []string) {
+	page := &resultPage{root: root}
+	sort.SliceStable(paths, func(i, j int) bool {
+		return walkBefore(page.rel(paths[i]), page.rel(paths[j]))
+	})
+}
+
+// walkBefore reports whether slash-separated relative path a is visited
+// before b by filepath.Walk: components compare in sorted order and a
+// directory comes before its contents
+func walkBefore(a, b string) bool {
+	if a == "." {
+		return b != "."
+	}
+	if b == "." {
+		return false
+	}
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return as[i] < bs[i]
+		}
+	}
+	return len(as) < len(bs)
+}
+
+// cutAfter ends the page after path, the last result actually shown, when
+// the response budget runs out before the page limit, so the next cursor
+// resumes right after it. An empty path means nothing on this page was shown
+// and the next call starts where this one did.
+func (p *resultPage) cutAfter(path string) {
+	if p == nil {
+		return
+	}
+	switch {
+	case path != "":
+		p.last = p.rel(path)
+	case p.after != "":
+		p.last = p.after
+	default:
+		return
+	}
+	p.more = true
+}
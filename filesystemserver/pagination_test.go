@@ -0,0 +1,153 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var cursorPattern = regexp.MustCompile(`cursor: (\S+)`)
+
+// collectPages calls a paginated tool until it stops returning a cursor,
+// returning the text of each page
+func collectPages(t *testing.T, handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), name string, args map[string]interface{}) []string {
+	t.Helper()
+	var pages []string
+	cursor := ""
+	for i := 0; i < 50; i++ {
+		call := map[string]interface{}{}
+		for k, v := range args {
+			call[k] = v
+		}
+		if cursor != "" {
+			call["cursor"] = cursor
+		}
+		result, err := handle(context.Background(), newToolRequest(name, call))
+		require.NoError(t, err)
+		text := result.Content[0].(mcp.TextContent).Text
+		require.False(t, result.IsError, text)
+		pages = append(pages, text)
+
+		match := cursorPattern.FindStringSubmatch(text)
+		if match == nil {
+			return pages
+		}
+		cursor = match[1]
+	}
+	t.Fatal("pagination did not terminate")
+	return nil
+}
+
+func writePagedTree(t *testing.T, dir string) {
+	t.Helper()
+	for _, name := range []string{"a/match1.txt", "a/b/match2.txt", "a/b/other.txt", "c/match3.txt", "match4.txt", "d/e/f/match5.txt", "z-match6.txt"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("needle\n"), 0644))
+	}
+}
+
+func TestWalkBefore(t *testing.T) {
+	assert.True(t, walkBefore(".", "a"))
+	assert.True(t, walkBefore("a", "a/b"))
+	assert.True(t, walkBefore("a/z", "b"))
+	assert.True(t, walkBefore("a/b/c", "a/c"))
+	assert.False(t, walkBefore("b", "a/z"))
+	assert.False(t, walkBefore("a/b", "a"))
+	assert.False(t, walkBefore("a", "a"))
+}
+
+func TestSortWalkOrder(t *testing.T) {
+	root := filepath.Join("srv", "data")
+	paths := []string{"b.txt", "a-b/x", "a/z/deep", "a", "a/y"}
+	for i, p := range paths {
+		paths[i] = filepath.Join(root, filepath.FromSlash(p))
+	}
+	sortWalkOrder(root, paths)
+
+	want := []string{"a", "a/y", "a/z/deep", "a-b/x", "b.txt"}
+	for i, p := range want {
+		want[i] = filepath.Join(root, filepath.FromSlash(p))
+	}
+	assert.Equal(t, want, paths, "a directory's contents come before a sibling that sorts after it by full path")
+}
+
+func TestSearchFilesPagination(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writePagedTree(t, dir)
+
+	all, err := handler.searchFiles(dir, "match")
+	require.NoError(t, err)
+	require.Len(t, all, 6)
+
+	pages := collectPages(t, handler.handleSearchFiles, "search_files", map[string]interface{}{
+		"path": dir, "pattern": "match", "max_results": float64(4), "relative_to": "absolute",
+	})
+	require.Len(t, pages, 2)
+	assert.Contains(t, pages[0], "Found 4 results")
+	assert.Contains(t, pages[1], "Found 2 results")
+
+	joined := strings.Join(pages, "\n")
+	for _, path := range all {
+		assert.Equal(t, 1, strings.Count(joined, path+" ("), path)
+	}
+
+	// Exactamente una página llena no deja un cursor hacia una página vacía
+	pages = collectPages(t, handler.handleSearchFiles, "search_files", map[string]interface{}{
+		"path": dir, "pattern": "match", "max_results": float64(6),
+	})
+	assert.Len(t, pages, 1)
+}
+
+func TestSmartSearchAndListDirectoryPagination(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writePagedTree(t, dir)
+
+	pages := collectPages(t, handler.handleSmartSearch, "smart_search", map[string]interface{}{
+		"path": dir, "pattern": "needle", "include_content": true, "max_results": float64(3),
+	})
+	require.Len(t, pages, 3)
+	assert.Equal(t, 7, strings.Count(strings.Join(pages, "\n"), "- needle"))
+
+	pages = collectPages(t, handler.handleListDirectory, "list_directory", map[string]interface{}{
+		"path": dir, "max_results": float64(2),
+	})
+	require.Len(t, pages, 3)
+	assert.Contains(t, pages[0], "[DIR]  a ")
+	assert.Contains(t, pages[0], "[DIR]  c ")
+	assert.Contains(t, pages[1], "[DIR]  d ")
+	assert.Contains(t, pages[1], "[FILE] match4.txt ")
+	assert.Contains(t, pages[2], "[FILE] z-match6.txt ")
+}
+
+func TestCursorRejectedForOtherParameters(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writePagedTree(t, dir)
+
+	result, err := handler.handleSearchFiles(context.Background(), newToolRequest("search_files", map[string]interface{}{
+		"path": dir, "pattern": "match", "max_results": float64(2),
+	}))
+	require.NoError(t, err)
+	cursor := cursorPattern.FindStringSubmatch(result.Content[0].(mcp.TextContent).Text)[1]
+
+	result, err = handler.handleSearchFiles(context.Background(), newToolRequest("search_files", map[string]interface{}{
+		"path": dir, "pattern": "other", "cursor": cursor,
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "different parameters")
+
+	result, err = handler.handleListDirectory(context.Background(), newToolRequest("list_directory", map[string]interface{}{
+		"path": dir, "cursor": "not a cursor",
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "invalid cursor")
+}
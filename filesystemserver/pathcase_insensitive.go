@@ -0,0 +1,7 @@
+//go:build windows || darwin
+
+package filesystemserver
+
+// caseInsensitivePaths is true where the default filesystems ignore case, so
+// path containment checks compare case-folded paths.
+const caseInsensitivePaths = true
@@ -0,0 +1,6 @@
+//go:build !windows && !darwin
+
+package filesystemserver
+
+// caseInsensitivePaths is false where paths are compared byte for byte.
+const caseInsensitivePaths = false
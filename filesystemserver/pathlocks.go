@@ -0,0 +1,76 @@
+package filesystemserver
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// Concurrency design
+//
+// mcp-go may run several tool calls at once, so every piece of state shared
+// between calls has its own guard:
+//
+//   - fs.mu (RWMutex) guards the configuration that changes at runtime:
+//     allowedDirs, runtimeDirs and rootLabels. It is only held for short
+//     reads and swaps, never across filesystem I/O.
+//   - fs.writeLocks hands out one mutex per absolute path. Tools that
+//     read-modify-write or replace a file (edit_file, write_file,
+//     write_file_safe) hold it from before they read the file until the new
+//     content is in place, so concurrent calls on the same file run one after
+//     another instead of interleaving or sharing a temp file.
+//   - fs.stats uses atomics and a sync.Map; fs.life has its own mutex.
+//
+// Lock order: a path lock is always taken before fs.mu, never while holding
+// it. Validation and the *Checked helpers take fs.mu internally, so they may
+// be called with a path lock held. A call that needs several path locks must
+// take them in sorted path order.
+
+// pathLocks is a set of mutexes keyed by path. Entries exist only while a
+// lock is held or awaited, so the map does not grow with every file touched.
+type pathLocks struct {
+	mu    sync.Mutex
+	locks map[string]*pathLock
+}
+
+type pathLock struct {
+	sync.Mutex
+	refs int // holders plus waiters
+}
+
+// lock blocks until path is free and returns the function that releases it
+func (p *pathLocks) lock(path string) func() {
+	p.mu.Lock()
+	if p.locks == nil {
+		p.locks = make(map[string]*pathLock)
+	}
+	l := p.locks[path]
+	if l == nil {
+		l = &pathLock{}
+		p.locks[path] = l
+	}
+	l.refs++
+	p.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		p.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(p.locks, path)
+		}
+		p.mu.Unlock()
+	}
+}
+
+// held reports how many paths are locked or awaited, for tests
+func (p *pathLocks) held() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.locks)
+}
+
+// lockPath serializes writers of validPath, which must already be validated
+// (absolute, symlinks resolved). Call the returned function to release it.
+func (fs *FilesystemHandler) lockPath(validPath string) func() {
+	return fs.writeLocks.lock(filepath.Clean(validPath))
+}
@@ -0,0 +1,110 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathLocksExclusive(t *testing.T) {
+	var locks pathLocks
+	var wg sync.WaitGroup
+	inside := map[string]int{}
+	var mu sync.Mutex
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			path := fmt.Sprintf("/data/file%d", i%3)
+			unlock := locks.lock(path)
+			mu.Lock()
+			inside[path]++
+			assert.Equal(t, 1, inside[path], "two holders of %s", path)
+			mu.Unlock()
+			mu.Lock()
+			inside[path]--
+			mu.Unlock()
+			unlock()
+		}(i)
+	}
+	wg.Wait()
+	assert.Zero(t, locks.held(), "released locks are forgotten")
+}
+
+func TestConcurrentEditsOnSameFile(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "shared.txt")
+	const slots = 20
+	var initial strings.Builder
+	for i := 0; i < slots; i++ {
+		fmt.Fprintf(&initial, "slot %02d: old\n", i)
+	}
+	require.NoError(t, os.WriteFile(path, []byte(initial.String()), 0644))
+
+	// Cada edición reescribe el archivo entero: sin bloqueo se perderían cambios
+	var wg sync.WaitGroup
+	for i := 0; i < slots; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result, err := handler.handleEditFile(context.Background(), newToolRequest("edit_file", map[string]interface{}{
+				"path":     path,
+				"old_text": fmt.Sprintf("slot %02d: old", i),
+				"new_text": fmt.Sprintf("slot %02d: new", i),
+			}))
+			if assert.NoError(t, err) {
+				assert.False(t, result.IsError)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, strings.ReplaceAll(initial.String(), ": old", ": new"), string(data))
+	assert.Zero(t, handler.writeLocks.held())
+}
+
+func TestConcurrentWritesOnSameFile(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	path := filepath.Join(dir, "shared.txt")
+	require.NoError(t, os.WriteFile(path, []byte("start\n"), 0644))
+
+	contents := map[string]bool{}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		content := strings.Repeat(fmt.Sprintf("writer %02d\n", i), 500+i*50)
+		contents[content] = true
+		tool, handle := "write_file", handler.handleWriteFile
+		if i%2 == 1 {
+			tool, handle = "write_file_safe", handler.handleWriteFileSafe
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := handle(context.Background(), newToolRequest(tool, map[string]interface{}{
+				"path":          path,
+				"content":       content,
+				"create_backup": true,
+			}))
+			if assert.NoError(t, err) {
+				assert.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, contents[string(data)], "the file holds exactly one writer's content")
+	assert.Empty(t, tempFilesIn(t, dir))
+	assert.Zero(t, handler.writeLocks.held())
+}
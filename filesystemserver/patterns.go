@@ -0,0 +1,95 @@
+package filesystemserver
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+)
+
+const (
+	maxPatternLength  = 1000  // bytes in a user-supplied pattern
+	maxPatternProgram = 10000 // instructions in its compiled program
+)
+
+// errPatternLimit is wrapped by the errors for patterns that are too long or
+// compile to a program too large to run over big files
+var errPatternLimit = errors.New("pattern limit exceeded")
+
+// compileUserPattern compiles a regular expression supplied by a client. Go's
+// RE2 engine never backtracks, so the cost to guard against is the size of the
+// compiled program: a counted repetition such as (abcdef|ghijkl){1000} expands
+// into thousands of instructions that are then run against every byte searched.
+func compileUserPattern(pattern string) (*regexp.Regexp, error) {
+	if err := checkPatternLength(pattern); err != nil {
+		return nil, err
+	}
+	return compileBoundedPattern(pattern)
+}
+
+// checkPatternLength rejects patterns over maxPatternLength
+func checkPatternLength(pattern string) error {
+	if len(pattern) > maxPatternLength {
+		return fmt.Errorf("%w: pattern is %d bytes, the maximum is %d", errPatternLimit, len(pattern), maxPatternLength)
+	}
+	return nil
+}
+
+// compileBoundedPattern checks only the compiled program size, for patterns the
+// server builds from longer input such as the flexible match in edit_file
+func compileBoundedPattern(pattern string) (*regexp.Regexp, error) {
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	prog, err := syntax.Compile(parsed.Simplify())
+	if err != nil {
+		return nil, err
+	}
+	if len(prog.Inst) > maxPatternProgram {
+		return nil, fmt.Errorf("%w: pattern compiles to %d instructions, the maximum is %d; reduce counted repetitions like {1000}", errPatternLimit, len(prog.Inst), maxPatternProgram)
+	}
+	return regexp.Compile(pattern)
+}
+
+// searchPattern compiles the pattern of a search tool, passing the expression
+// through decorate (flags, word boundaries) when it is not nil. literal is the
+// tool's literal argument: true searches the text as-is, false requires valid
+// regex, and when it is omitted an invalid regex is searched literally and
+// fallback holds the compile error so the response can say so.
+func searchPattern(pattern string, literal *bool, decorate func(string) string) (re *regexp.Regexp, fallback string, err error) {
+	if decorate == nil {
+		decorate = func(expr string) string { return expr }
+	}
+	if err := checkPatternLength(pattern); err != nil {
+		return nil, "", err
+	}
+	if literal != nil && *literal {
+		re, err = compileBoundedPattern(decorate(regexp.QuoteMeta(pattern)))
+		return re, "", err
+	}
+	re, err = compileBoundedPattern(decorate(pattern))
+	if err == nil || errors.Is(err, errPatternLimit) {
+		return re, "", err
+	}
+	if literal != nil {
+		return nil, "", fmt.Errorf("pattern is not valid regex: %v (pass literal: true to search for the text as-is)", err)
+	}
+	re, litErr := compileBoundedPattern(decorate(regexp.QuoteMeta(pattern)))
+	if litErr != nil {
+		return nil, "", litErr
+	}
+	return re, err.Error(), nil
+}
+
+// literalArg reads an optional boolean argument, nil when it was not passed
+func literalArg(args map[string]interface{}) (*bool, error) {
+	if args["literal"] == nil {
+		return nil, nil
+	}
+	v, err := getBool(args, "literal", false)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
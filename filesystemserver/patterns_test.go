@@ -0,0 +1,90 @@
+package filesystemserver
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileUserPattern(t *testing.T) {
+	re, err := compileUserPattern(`func \w+\(`)
+	require.NoError(t, err)
+	assert.True(t, re.MatchString("func main("))
+
+	_, err = compileUserPattern(strings.Repeat("a", maxPatternLength+1))
+	assert.ErrorIs(t, err, errPatternLimit)
+	assert.ErrorContains(t, err, "the maximum is 1000")
+
+	_, err = compileUserPattern(`(abcdef|ghijkl){1000}`)
+	assert.ErrorIs(t, err, errPatternLimit)
+	assert.ErrorContains(t, err, "instructions")
+
+	_, err = compileUserPattern(`a(b`)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, errPatternLimit)
+}
+
+func TestSearchPattern(t *testing.T) {
+	yes, no := true, false
+
+	re, fallback, err := searchPattern("a+b", nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, fallback)
+	assert.True(t, re.MatchString("aab"))
+
+	re, _, err = searchPattern("a+b", &yes, nil)
+	require.NoError(t, err)
+	assert.False(t, re.MatchString("aab"))
+	assert.True(t, re.MatchString("xa+b"))
+
+	re, fallback, err = searchPattern("foo(", nil, nil)
+	require.NoError(t, err)
+	assert.Contains(t, fallback, "missing closing )")
+	assert.True(t, re.MatchString("call foo(x)"))
+
+	_, _, err = searchPattern("foo(", &no, nil)
+	assert.ErrorContains(t, err, "pattern is not valid regex")
+	assert.ErrorContains(t, err, "literal: true")
+
+	// Los límites nunca se saltan buscando literalmente
+	_, _, err = searchPattern(`(abcdef|ghijkl){1000}`, nil, nil)
+	assert.ErrorIs(t, err, errPatternLimit)
+
+	re, _, err = searchPattern("A.B", &yes, func(expr string) string { return "(?i)" + expr })
+	require.NoError(t, err)
+	assert.True(t, re.MatchString("a.b"))
+	assert.False(t, re.MatchString("axb"))
+}
+
+func TestSmartSearchLiteral(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "calc.go"), []byte("x := sum(a, b)\ny := a+b\n"), 0644))
+	args := map[string]interface{}{"path": dir, "pattern": "sum(a", "include_content": true}
+
+	text, isError := callText(t, handler.handleSmartSearch, "smart_search", args)
+	require.False(t, isError, text)
+	assert.True(t, strings.HasPrefix(text, "⚠️ pattern was not valid regex (error parsing regexp: missing closing ): `sum(a`); searched literally."), text)
+	assert.Contains(t, text, "calc.go:1 - x := sum(a, b)")
+
+	args["literal"] = false
+	text, isError = callText(t, handler.handleSmartSearch, "smart_search", args)
+	assert.True(t, isError)
+	assert.Contains(t, text, "❌ Error: pattern is not valid regex")
+
+	args["literal"] = true
+	args["pattern"] = "a+b"
+	text, isError = callText(t, handler.handleSmartSearch, "smart_search", args)
+	require.False(t, isError, text)
+	assert.NotContains(t, text, "searched literally")
+	assert.Contains(t, text, "Content matches (1)")
+	assert.Contains(t, text, "calc.go:2 - y := a+b")
+
+	args["pattern"] = strings.Repeat("x", maxPatternLength+1)
+	text, isError = callText(t, handler.handleSmartSearch, "smart_search", args)
+	assert.True(t, isError)
+	assert.Contains(t, text, "pattern is 1001 bytes, the maximum is 1000")
+}
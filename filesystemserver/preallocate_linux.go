@@ -0,0 +1,23 @@
+//go:build linux
+
+package filesystemserver
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// preallocateFile reserves size bytes of disk for f with fallocate(2), so the
+// writes that follow cannot run out of space halfway. Filesystems without
+// fallocate support report errPreallocateUnsupported.
+func preallocateFile(f *os.File, size int64) error {
+	if size == 0 {
+		return nil
+	}
+	err := syscall.Fallocate(int(f.Fd()), 0, 0, size)
+	if errors.Is(err, syscall.EOPNOTSUPP) || errors.Is(err, syscall.ENOSYS) {
+		return errPreallocateUnsupported
+	}
+	return err
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package filesystemserver
+
+import "os"
+
+// preallocateFile is not implemented on this platform; callers write the file
+// without reserving space first.
+func preallocateFile(f *os.File, size int64) error {
+	return errPreallocateUnsupported
+}
@@ -0,0 +1,144 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// progressInterval is the minimum time between two progress notifications
+const progressInterval = 500 * time.Millisecond
+
+// progressNotifyFunc delivers one notifications/progress payload
+type progressNotifyFunc func(ctx context.Context, params map[string]any) error
+
+// progressReporter turns walker activity into throttled progress
+// notifications for one tool call. A nil reporter (no progress token in the
+// request) ignores every update, so tools behave the same without one.
+type progressReporter struct {
+	ctx    context.Context
+	token  mcp.ProgressToken
+	notify progressNotifyFunc
+	now    func() time.Time
+
+	mu      sync.Mutex
+	steps   int64 // entries visited plus files read, always increasing
+	entries int64
+	bytes   int64
+	last    time.Time
+
+	// Tools made of several operations, like batch_operations, name the one
+	// running and count the files it has processed
+	task      string
+	taskFiles int64
+}
+
+type progressKey struct{}
+
+// withProgress attaches a reporter to ctx when the request carries a progress token
+func (fs *FilesystemHandler) withProgress(ctx context.Context, request mcp.CallToolRequest) context.Context {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return ctx
+	}
+	notify := fs.progressNotify
+	if notify == nil {
+		notify = sendProgressNotification
+	}
+	reporter := &progressReporter{
+		ctx:    ctx,
+		token:  request.Params.Meta.ProgressToken,
+		notify: notify,
+		now:    time.Now,
+	}
+	// The first notification comes one interval in, so quick calls send none
+	reporter.last = reporter.now()
+	return context.WithValue(ctx, progressKey{}, reporter)
+}
+
+// progressFrom returns the reporter attached to ctx, or nil
+func progressFrom(ctx context.Context) *progressReporter {
+	reporter, _ := ctx.Value(progressKey{}).(*progressReporter)
+	return reporter
+}
+
+// entry records one visited directory entry
+func (p *progressReporter) entry(path string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.steps++
+	p.entries++
+	p.report(path)
+}
+
+// read records n bytes read or hashed from path
+func (p *progressReporter) read(path string, n int64) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.steps++
+	p.bytes += n
+	p.report(path)
+}
+
+// begin starts task, which later notifications name along with the files it
+// has processed
+func (p *progressReporter) begin(task string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.task, p.taskFiles = task, 0
+	p.steps++
+	p.report("")
+}
+
+// fileDone records one file copied or removed by the current task
+func (p *progressReporter) fileDone(path string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.steps++
+	p.taskFiles++
+	p.report(path)
+}
+
+// report sends a notification if the interval has passed; it is called with
+// p.mu held and releases it before notifying
+func (p *progressReporter) report(path string) {
+	now := p.now()
+	if now.Sub(p.last) < progressInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.last = now
+	message := fmt.Sprintf("%d entries scanned, %d bytes read, at %s", p.entries, p.bytes, path)
+	if p.task != "" {
+		message = fmt.Sprintf("%s (%d files done)", p.task, p.taskFiles)
+	}
+	params := map[string]any{
+		"progressToken": p.token,
+		"progress":      float64(p.steps),
+		"message":       message,
+	}
+	p.mu.Unlock()
+
+	// Progress is best effort: a client that went away must not fail the tool
+	_ = p.notify(p.ctx, params)
+}
+
+// sendProgressNotification sends params to the client that made the request
+func sendProgressNotification(ctx context.Context, params map[string]any) error {
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return nil
+	}
+	return srv.SendNotificationToClient(ctx, "notifications/progress", params)
+}
@@ -0,0 +1,94 @@
+package filesystemserver
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNotifier records the progress notifications a reporter sends
+type fakeNotifier struct {
+	mu   sync.Mutex
+	sent []map[string]any
+}
+
+func (n *fakeNotifier) notify(ctx context.Context, params map[string]any) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, params)
+	return nil
+}
+
+func progressRequest(token mcp.ProgressToken) mcp.CallToolRequest {
+	request := newToolRequest("smart_search", nil)
+	request.Params.Meta = &struct {
+		ProgressToken mcp.ProgressToken `json:"progressToken,omitempty"`
+	}{ProgressToken: token}
+	return request
+}
+
+func TestProgressReporterThrottles(t *testing.T) {
+	handler, _ := newTestHandler(t)
+	notifier := &fakeNotifier{}
+	handler.progressNotify = notifier.notify
+
+	ctx := handler.withProgress(context.Background(), progressRequest("tok-1"))
+	reporter := progressFrom(ctx)
+	require.NotNil(t, reporter)
+
+	clock := time.Now()
+	reporter.now = func() time.Time { return clock }
+	reporter.last = clock
+
+	// 30 entradas cada 100ms durante 3s: una notificación cada 500ms
+	for i := 0; i < 30; i++ {
+		clock = clock.Add(100 * time.Millisecond)
+		reporter.entry("/tmp/file")
+	}
+	reporter.read("/tmp/big.bin", 4096)
+
+	require.Len(t, notifier.sent, 6)
+	first := notifier.sent[0]
+	assert.Equal(t, "tok-1", first["progressToken"])
+	assert.Equal(t, float64(5), first["progress"])
+	assert.Equal(t, "5 entries scanned, 0 bytes read, at /tmp/file", first["message"])
+	assert.Equal(t, float64(30), notifier.sent[5]["progress"])
+
+	clock = clock.Add(time.Second)
+	reporter.read("/tmp/big.bin", 4096)
+	require.Len(t, notifier.sent, 7)
+	assert.Equal(t, "30 entries scanned, 8192 bytes read, at /tmp/big.bin", notifier.sent[6]["message"])
+}
+
+func TestProgressWithoutToken(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	notifier := &fakeNotifier{}
+	handler.progressNotify = notifier.notify
+
+	var seen []*progressReporter
+	tool := handler.guardTool("smart_search", func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		reporter := progressFrom(ctx)
+		seen = append(seen, reporter)
+		// Sin token el reporter es nil y las llamadas no hacen nada
+		reporter.entry(dir)
+		reporter.read(dir, 10)
+		return &mcp.CallToolResult{Content: []mcp.Content{mcp.TextContent{Type: "text", Text: "ok"}}}, nil
+	})
+
+	_, err := tool(context.Background(), newToolRequest("smart_search", nil))
+	require.NoError(t, err)
+	_, err = tool(context.Background(), progressRequest(7))
+	require.NoError(t, err)
+
+	require.Len(t, seen, 2)
+	assert.Nil(t, seen[0])
+	require.NotNil(t, seen[1])
+	assert.Equal(t, 7, seen[1].token)
+	// Una llamada rápida no llega a notificar
+	assert.Empty(t, notifier.sent)
+}
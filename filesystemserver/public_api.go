@@ -0,0 +1,74 @@
+package filesystemserver
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Exported entry points for programs that embed the handler instead of serving
+// it over MCP. Each one runs the same handler the server registers for the
+// tool, behind the same guard: the runtime policy, read-only mode, call limits
+// and timeouts apply, and problems with the request come back as an IsError
+// result with an error code, never as a Go error. The request's
+// Params.Arguments are the tool's arguments as documented in the README.
+
+// HandleReadFile runs the read_file tool
+func (fs *FilesystemHandler) HandleReadFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return fs.guardTool("read_file", fs.handleReadFile)(ctx, request)
+}
+
+// HandleReadMultipleFiles runs the read_multiple_files tool
+func (fs *FilesystemHandler) HandleReadMultipleFiles(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return fs.guardTool("read_multiple_files", fs.handleReadMultipleFiles)(ctx, request)
+}
+
+// HandleWriteFile runs the write_file tool
+func (fs *FilesystemHandler) HandleWriteFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return fs.guardTool("write_file", fs.handleWriteFile)(ctx, request)
+}
+
+// HandleEditFile runs the edit_file tool
+func (fs *FilesystemHandler) HandleEditFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return fs.guardTool("edit_file", fs.handleEditFile)(ctx, request)
+}
+
+// HandleListDirectory runs the list_directory tool
+func (fs *FilesystemHandler) HandleListDirectory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return fs.guardTool("list_directory", fs.handleListDirectory)(ctx, request)
+}
+
+// HandleTree runs the tree tool
+func (fs *FilesystemHandler) HandleTree(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return fs.guardTool("tree", fs.handleTree)(ctx, request)
+}
+
+// HandleCreateDirectory runs the create_directory tool
+func (fs *FilesystemHandler) HandleCreateDirectory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return fs.guardTool("create_directory", fs.handleCreateDirectory)(ctx, request)
+}
+
+// HandleCopyFile runs the copy_file tool
+func (fs *FilesystemHandler) HandleCopyFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return fs.guardTool("copy_file", fs.handleCopyFile)(ctx, request)
+}
+
+// HandleMoveFile runs the move_file tool
+func (fs *FilesystemHandler) HandleMoveFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return fs.guardTool("move_file", fs.handleMoveFile)(ctx, request)
+}
+
+// HandleDeleteFile runs the delete_file tool
+func (fs *FilesystemHandler) HandleDeleteFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return fs.guardTool("delete_file", fs.handleDeleteFile)(ctx, request)
+}
+
+// HandleSearchFiles runs the search_files tool
+func (fs *FilesystemHandler) HandleSearchFiles(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return fs.guardTool("search_files", fs.handleSearchFiles)(ctx, request)
+}
+
+// HandleGetFileInfo runs the get_file_info tool
+func (fs *FilesystemHandler) HandleGetFileInfo(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return fs.guardTool("get_file_info", fs.handleGetFileInfo)(ctx, request)
+}
@@ -0,0 +1,57 @@
+package filesystemserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublicAPI(t *testing.T) {
+	dir := t.TempDir()
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+	path := filepath.Join(dir, "notes.txt")
+
+	_, isError := callText(t, handler.HandleWriteFile, "write_file", map[string]interface{}{"path": path, "content": "one\ntwo\n"})
+	require.False(t, isError)
+	_, isError = callText(t, handler.HandleEditFile, "edit_file", map[string]interface{}{"path": path, "old_text": "two", "new_text": "three"})
+	require.False(t, isError)
+	text, isError := callText(t, handler.HandleReadFile, "read_file", map[string]interface{}{"path": path})
+	require.False(t, isError)
+	assert.Equal(t, "one\nthree\n", text)
+
+	_, isError = callText(t, handler.HandleCopyFile, "copy_file", map[string]interface{}{"source": path, "destination": filepath.Join(dir, "copy.txt")})
+	require.False(t, isError)
+	text, isError = callText(t, handler.HandleListDirectory, "list_directory", map[string]interface{}{"path": dir})
+	require.False(t, isError)
+	assert.Contains(t, text, "copy.txt")
+	_, isError = callText(t, handler.HandleDeleteFile, "delete_file", map[string]interface{}{"path": filepath.Join(dir, "copy.txt")})
+	require.False(t, isError)
+	assert.NoFileExists(t, filepath.Join(dir, "copy.txt"))
+
+	// Los errores de entrada llegan como resultado con código, igual que por MCP
+	text, isError = callText(t, handler.HandleReadFile, "read_file", map[string]interface{}{"path": filepath.Join(dir, "missing.txt")})
+	assert.True(t, isError)
+	assert.Contains(t, text, "[E_NOT_FOUND] ")
+	text, isError = callText(t, handler.HandleEditFile, "edit_file", map[string]interface{}{"path": path})
+	assert.True(t, isError)
+	assert.Contains(t, text, "[E_BAD_ARG] ")
+}
+
+func TestPublicAPIAppliesPolicy(t *testing.T) {
+	dir := t.TempDir()
+	handler, err := NewFilesystemHandler([]string{dir}, WithReadOnly(true))
+	require.NoError(t, err)
+	path := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("keep"), 0644))
+
+	text, isError := callText(t, handler.HandleWriteFile, "write_file", map[string]interface{}{"path": path, "content": "changed"})
+	assert.True(t, isError)
+	assert.Contains(t, text, "[E_ACCESS_DENIED] ")
+	text, isError = callText(t, handler.HandleReadFile, "read_file", map[string]interface{}{"path": path})
+	assert.False(t, isError)
+	assert.Equal(t, "keep", text)
+}
@@ -0,0 +1,217 @@
+package filesystemserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// The listing, search and analysis tools (tree, list_directory, search_files,
+// smart_search, advanced_text_search, find_duplicates, compare_files,
+// analyze_project) build their text through a resultBuilder, which stops
+// adding items once the response budget is spent. The result then ends with a
+// truncation footer saying how much was shown; paginated tools point at the
+// next cursor, the others save the full output as a report file under
+// .mcp-reports and attach it as a resource, as oversizedResult does.
+
+// defaultResponseBudget is the largest text a budgeted tool returns
+const defaultResponseBudget = 100 * 1024
+
+// minResponseBudget is the smallest budget accepted; lower values are raised to it
+const minResponseBudget = 4 * 1024
+
+// responseFooterReserve is the part of the budget kept for the truncation
+// footer and the closing lines written after the items
+const responseFooterReserve = 2048
+
+// WithResponseBudget sets the size in bytes of the text the listing, search
+// and analysis tools return before truncating it. 0 or less keeps the 100KB
+// default; values under 4KB are raised to 4KB.
+func WithResponseBudget(limit int) HandlerOption {
+	return func(fs *FilesystemHandler) error {
+		fs.responseLimit = limit
+		return nil
+	}
+}
+
+// responseBudget returns the effective response budget
+func (fs *FilesystemHandler) responseBudget() int {
+	if fs.responseLimit <= 0 {
+		return defaultResponseBudget
+	}
+	return max(fs.responseLimit, minResponseBudget)
+}
+
+// resultBuilder accumulates a tool's text within the response budget. Items
+// that don't fit are dropped from the text but kept in the full output, so it
+// can be saved as a report; everything written after the first dropped item
+// is dropped too, to keep the text a clean prefix.
+type resultBuilder struct {
+	fs     *FilesystemHandler
+	budget int
+	limit  int // budget minus the footer reserve
+	text   strings.Builder
+	full   strings.Builder
+	shown  int
+	total  int
+	cut    bool
+	cutAt  int  // length of text when the first item was dropped; the footer goes there
+	paged  bool // a page cursor resumes after the last item shown, so no report is written
+}
+
+// newResultBuilder returns a builder for the handler's response budget
+func (fs *FilesystemHandler) newResultBuilder() *resultBuilder {
+	budget := fs.responseBudget()
+	return &resultBuilder{fs: fs, budget: budget, limit: budget - responseFooterReserve}
+}
+
+// WriteString adds s to the text if it still fits, and always to the full
+// output. It never fails; the signature lets the builder stand in for a
+// strings.Builder.
+func (b *resultBuilder) WriteString(s string) (int, error) {
+	b.full.WriteString(s)
+	if !b.cut && b.text.Len()+len(s) > b.limit {
+		b.cut = true
+		b.cutAt = b.text.Len()
+	}
+	if !b.cut {
+		b.text.WriteString(s)
+	}
+	return len(s), nil
+}
+
+// Write is WriteString for fmt.Fprintf
+func (b *resultBuilder) Write(p []byte) (int, error) {
+	return b.WriteString(string(p))
+}
+
+// item writes one counted item, typically a line or a block of lines, and
+// reports whether it made it into the text
+func (b *resultBuilder) item(s string) bool {
+	b.total++
+	b.WriteString(s)
+	if b.cut {
+		return false
+	}
+	b.shown++
+	return true
+}
+
+// lines writes text as one item per line
+func (b *resultBuilder) lines(text string) {
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line != "" {
+			b.item(line)
+		}
+	}
+}
+
+// tail writes closing lines, such as totals or the page footer, that belong
+// in the text even when items were dropped. They come out of the footer
+// reserve, so they must stay short.
+func (b *resultBuilder) tail(s string) {
+	b.full.WriteString(s)
+	b.text.WriteString(s)
+}
+
+// stopPage marks the text as cut after lastShown, the last item of page that
+// was shown, so the next cursor resumes from there
+func (b *resultBuilder) stopPage(page *resultPage, lastShown string) {
+	b.paged = true
+	page.cutAfter(lastShown)
+}
+
+// truncate records content that the caller fitted to the budget itself:
+// shown of total items made it into the text and full is the complete output
+func (b *resultBuilder) truncate(shown, total int, full string) {
+	b.cut = true
+	b.cutAt = b.text.Len()
+	b.shown, b.total = shown, total
+	b.full.Reset()
+	b.full.WriteString(full)
+}
+
+// size is the length of the full output, dropped items included
+func (b *resultBuilder) size() int {
+	return b.full.Len()
+}
+
+// truncated reports whether any item was dropped
+func (b *resultBuilder) truncated() bool {
+	return b.cut
+}
+
+// done returns the text, with the truncation footer where items were dropped,
+// and the resource for the full output when it was saved to a report file
+// under the allowed directory holding validPath
+func (b *resultBuilder) done(validPath, tool string) (string, []mcp.Content) {
+	text := b.text.String()
+	if !b.cut {
+		return text, nil
+	}
+
+	var footer string
+	var spill []mcp.Content
+	if b.paged {
+		footer = fmt.Sprintf("\n✂️ Output truncated: %d items shown, more available (response budget %d bytes); refine filters or use pagination cursor\n", b.shown, b.budget)
+	} else {
+		footer = fmt.Sprintf("\n✂️ Output truncated: %d of %d items shown (response budget %d bytes); refine filters or narrow the path\n", b.shown, b.total, b.budget)
+		if !b.fs.readOnly {
+			reportPath, err := b.fs.writeReport(validPath, tool, "text", []byte(b.full.String()))
+			if err != nil {
+				b.fs.log().Warn("could not write truncated output to a report file", "tool", tool, "error", err)
+			} else {
+				footer += fmt.Sprintf("📎 Full output (%d bytes) saved to %s\n", b.full.Len(), reportPath)
+				spill = append(spill, newResourceRef(pathToResourceURI(reportPath), reportFormats["text"].mimeType))
+			}
+		}
+	}
+	return text[:b.cutAt] + footer + text[b.cutAt:], spill
+}
+
+// fitTree returns the indented JSON of the largest breadth-first part of tree
+// that fits in room bytes, with Omitted set on the directories whose children
+// were cut, and how many of the tree's nodes it kept out of how many. The
+// root is always kept.
+func fitTree(tree *FileNode, room int) ([]byte, int, int) {
+	nodes := []*FileNode{tree}
+	parents := []int{-1}
+	for i := 0; i < len(nodes); i++ {
+		for _, child := range nodes[i].Children {
+			nodes = append(nodes, child)
+			parents = append(parents, i)
+		}
+	}
+
+	build := func(n int) []byte {
+		kept := make([]*FileNode, n)
+		for i := range kept {
+			node := *nodes[i]
+			node.Children = nil
+			kept[i] = &node
+			if parents[i] >= 0 {
+				parent := kept[parents[i]]
+				parent.Children = append(parent.Children, kept[i])
+			}
+		}
+		for i, node := range kept {
+			node.Omitted = len(nodes[i].Children) - len(node.Children)
+		}
+		data, _ := json.MarshalIndent(kept[0], "", "  ")
+		return data
+	}
+
+	// The JSON only grows with n, so search for the largest n that fits
+	lo, hi := 1, len(nodes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if len(build(mid)) <= room {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return build(lo), lo, len(nodes)
+}
@@ -0,0 +1,166 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBudgetHandler returns a test handler with the smallest response budget
+func newBudgetHandler(t *testing.T) (*FilesystemHandler, string) {
+	t.Helper()
+	handler, dir := newTestHandler(t)
+	require.NoError(t, WithResponseBudget(1)(handler))
+	require.Equal(t, minResponseBudget, handler.responseBudget())
+	return handler, dir
+}
+
+// writeManyFiles creates n files with long names directly under dir
+func writeManyFiles(t *testing.T, dir string, n int) []string {
+	t.Helper()
+	var names []string
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("file-with-a-fairly-long-name-to-fill-the-budget-%03d.txt", i)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644))
+		names = append(names, name)
+	}
+	return names
+}
+
+// budgetReport checks that result holds a resource for a text report under
+// .mcp-reports, mentioned in the text, and returns its content
+func budgetReport(t *testing.T, result *mcp.CallToolResult, dir string) string {
+	t.Helper()
+	reports, err := filepath.Glob(filepath.Join(dir, reportDirName, "*.txt"))
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "📎 Full output")
+
+	found := false
+	for _, content := range result.Content[1:] {
+		if embedded, ok := content.(mcp.EmbeddedResource); ok {
+			if embedded.Resource.(mcp.TextResourceContents).URI == pathToResourceURI(reports[0]) {
+				found = true
+			}
+		}
+	}
+	assert.True(t, found, "the full output is attached as a resource")
+	data, err := os.ReadFile(reports[0])
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestResponseBudgetListDirectoryPages(t *testing.T) {
+	handler, dir := newBudgetHandler(t)
+	names := writeManyFiles(t, dir, 120)
+
+	pages := collectPages(t, handler.handleListDirectory, "list_directory", map[string]interface{}{"path": dir})
+	require.Greater(t, len(pages), 1)
+	seen := make(map[string]int)
+	for i, page := range pages {
+		assert.LessOrEqual(t, len(page), minResponseBudget)
+		if i < len(pages)-1 {
+			assert.Contains(t, page, "✂️ Output truncated")
+			assert.Contains(t, page, "use pagination cursor")
+		}
+		for _, name := range names {
+			if strings.Contains(page, "[FILE] "+name) {
+				seen[name]++
+			}
+		}
+	}
+	assert.NotContains(t, pages[len(pages)-1], "✂️")
+	require.Len(t, seen, len(names), "every entry is shown on some page")
+	for name, count := range seen {
+		assert.Equal(t, 1, count, name)
+	}
+	assert.NoDirExists(t, filepath.Join(dir, reportDirName), "paginated tools write no report")
+}
+
+func TestResponseBudgetTree(t *testing.T) {
+	handler, dir := newBudgetHandler(t)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	names := writeManyFiles(t, filepath.Join(dir, "sub"), 80)
+
+	result, err := handler.handleTree(context.Background(), newToolRequest("tree", map[string]interface{}{"path": dir}))
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.LessOrEqual(t, len(text), minResponseBudget)
+	assert.Contains(t, text, "✂️ Output truncated")
+	assert.Contains(t, text, "of 82 items shown")
+
+	// El recurso JSON es el árbol recortado y dice cuántos hijos faltan
+	embedded := result.Content[len(result.Content)-1].(mcp.EmbeddedResource)
+	var tree FileNode
+	require.NoError(t, json.Unmarshal([]byte(embedded.Resource.(mcp.TextResourceContents).Text), &tree))
+	require.Len(t, tree.Children, 1)
+	sub := tree.Children[0]
+	assert.Positive(t, sub.Omitted)
+	assert.Equal(t, len(names), len(sub.Children)+sub.Omitted)
+
+	full := budgetReport(t, result, dir)
+	for _, name := range names {
+		assert.Contains(t, full, name)
+	}
+}
+
+func TestResponseBudgetFindDuplicates(t *testing.T) {
+	handler, dir := newBudgetHandler(t)
+	writeDuplicateGroups(t, dir, 60)
+
+	result, err := handler.handleFindDuplicates(context.Background(), newToolRequest("find_duplicates", map[string]interface{}{"path": dir}))
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.LessOrEqual(t, len(text), minResponseBudget)
+	assert.Contains(t, text, "of 60 items shown")
+	assert.Contains(t, text, "💾 Total wasted space", "totals survive truncation")
+	assert.Contains(t, text, "📊 Scanned 120 files")
+
+	full := budgetReport(t, result, dir)
+	assert.Contains(t, full, filepath.Join(dir, "a", "file59.txt"))
+
+	// El JSON estructurado sigue completo
+	var report DuplicateReport
+	decodeStructured(t, result, &report)
+	assert.Len(t, report.Groups, 60)
+}
+
+func TestResponseBudgetReadOnlyWritesNoReport(t *testing.T) {
+	handler, dir := newBudgetHandler(t)
+	writeDuplicateGroups(t, dir, 60)
+	require.NoError(t, WithReadOnly(true)(handler))
+
+	text, isError := callText(t, handler.handleFindDuplicates, "find_duplicates", map[string]interface{}{"path": dir})
+	require.False(t, isError, text)
+	assert.Contains(t, text, "✂️ Output truncated")
+	assert.NotContains(t, text, "📎")
+	assert.NoDirExists(t, filepath.Join(dir, reportDirName))
+}
+
+func TestResultBuilder(t *testing.T) {
+	handler, _ := newBudgetHandler(t)
+	b := handler.newResultBuilder()
+	b.WriteString("header\n")
+	line := strings.Repeat("x", 99) + "\n"
+	for i := 0; i < 100; i++ {
+		b.item(line)
+	}
+	b.tail("totals\n")
+	assert.True(t, b.truncated())
+	assert.Equal(t, (b.limit-len("header\n"))/len(line), b.shown)
+	assert.Equal(t, 100, b.total)
+	assert.Equal(t, len("header\n")+100*len(line)+len("totals\n"), b.size())
+
+	b.fs.readOnly = true
+	text, spill := b.done("", "test")
+	assert.Empty(t, spill)
+	assert.True(t, strings.HasSuffix(text, "items shown (response budget 4096 bytes); refine filters or narrow the path\ntotals\n"), text)
+}
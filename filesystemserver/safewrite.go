@@ -0,0 +1,214 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Mutating operations validate their paths up front, but a directory on the way
+// can be replaced by a symlink before the write happens. The helpers below
+// re-resolve the path immediately before acting and, for files they open,
+// check afterwards that the opened file is the one inside the allowed
+// directories.
+
+// recheckPath verifies, right before a mutation, that the parent of validPath
+// still resolves inside the allowed directories and that validPath itself has
+// not become a symlink.
+func (fs *FilesystemHandler) recheckPath(validPath string) error {
+	realParent, err := filepath.EvalSymlinks(filepath.Dir(validPath))
+	if err != nil {
+		return fmt.Errorf("path changed after validation: %w", err)
+	}
+	if !fs.isPathInAllowedDirs(realParent) {
+		return fmt.Errorf("access denied - path changed after validation: %s now resolves outside allowed directories", filepath.Dir(validPath))
+	}
+	if info, err := os.Lstat(validPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("access denied - path changed after validation: %s became a symlink", validPath)
+	}
+	return nil
+}
+
+// openFileChecked opens validPath without following a final symlink and then
+// confirms that the open file is the one currently at validPath inside the
+// allowed directories. Callers must not pass os.O_TRUNC: truncation happens
+// only after the check, in writeFileChecked.
+func (fs *FilesystemHandler) openFileChecked(validPath string, flag int, perm os.FileMode) (*os.File, error) {
+	if err := fs.recheckPath(validPath); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(validPath, flag|openNoFollow, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	opened, err := file.Stat()
+	if err == nil {
+		err = fs.recheckPath(validPath)
+	}
+	if err == nil {
+		var current os.FileInfo
+		current, err = os.Lstat(validPath)
+		if err == nil && !os.SameFile(opened, current) {
+			err = fmt.Errorf("access denied - path changed after validation: %s was replaced while opening", validPath)
+		}
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// writeFileChecked is os.WriteFile with the checks of openFileChecked
+func (fs *FilesystemHandler) writeFileChecked(validPath string, data []byte, perm os.FileMode) error {
+	file, err := fs.openFileChecked(validPath, os.O_WRONLY|os.O_CREATE, perm)
+	if err != nil {
+		return err
+	}
+	if err := file.Truncate(0); err != nil {
+		file.Close()
+		return err
+	}
+	n, err := file.Write(data)
+	fs.stats.addWritten(n)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// mkdirAllChecked is os.MkdirAll that refuses to create directories through a
+// symlink leading outside the allowed directories
+func (fs *FilesystemHandler) mkdirAllChecked(dir string, perm os.FileMode) error {
+	existing := dir
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		existing = parent
+	}
+	if real, err := filepath.EvalSymlinks(existing); err != nil || !fs.isPathInAllowedDirs(real) {
+		return fmt.Errorf("access denied - path changed after validation: %s resolves outside allowed directories", existing)
+	}
+	if err := os.MkdirAll(dir, perm); err != nil {
+		return err
+	}
+	if real, err := filepath.EvalSymlinks(dir); err != nil || !fs.isPathInAllowedDirs(real) {
+		return fmt.Errorf("access denied - path changed after validation: %s resolves outside allowed directories", dir)
+	}
+	return nil
+}
+
+// removeChecked removes validPath (recursively if asked) after recheckPath
+func (fs *FilesystemHandler) removeChecked(validPath string, recursive bool) error {
+	if err := fs.recheckPath(validPath); err != nil {
+		return err
+	}
+	if recursive {
+		return os.RemoveAll(validPath)
+	}
+	return os.Remove(validPath)
+}
+
+// removeTreeChecked removes the directory validPath and everything in it
+// after recheckPath, one file at a time so that cancelling ctx stops it
+// between files; what was already removed stays removed
+func (fs *FilesystemHandler) removeTreeChecked(ctx context.Context, validPath string) error {
+	if err := fs.recheckPath(validPath); err != nil {
+		return err
+	}
+	var dirs []string
+	err := filepath.WalkDir(validPath, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			dirs = append(dirs, path)
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		progressFrom(ctx).fileDone(path)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// The directories are empty now; remove them innermost first
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Remove(dirs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chmodChecked changes the mode of validPath after rechecking it, so a path
+// swapped for a symlink is never followed out of the allowed directories
+func (fs *FilesystemHandler) chmodChecked(validPath string, mode os.FileMode) error {
+	if err := fs.recheckPath(validPath); err != nil {
+		return err
+	}
+	return os.Chmod(validPath, mode)
+}
+
+// renameChecked renames from to to after rechecking both paths
+func (fs *FilesystemHandler) renameChecked(from, to string) error {
+	if err := fs.recheckPath(from); err != nil {
+		return err
+	}
+	if err := fs.recheckPath(to); err != nil {
+		return err
+	}
+	return os.Rename(from, to)
+}
+
+// copyFileChecked copies the file src to dst after rechecking dst; the copy
+// never follows a symlink at dst
+func (fs *FilesystemHandler) copyFileChecked(src, dst string) error {
+	if err := fs.recheckPath(dst); err != nil {
+		return err
+	}
+	n, err := copyFileHash(src, dst, nil)
+	fs.stats.addWritten(int(n))
+	return err
+}
+
+// replaceFileChecked writes content to a temp file next to validPath and
+// renames it over validPath, creating missing parent directories. The temp
+// file is removed on failure and on shutdown.
+func (fs *FilesystemHandler) replaceFileChecked(validPath, content string, perm os.FileMode) error {
+	if err := fs.mkdirAllChecked(filepath.Dir(validPath), 0755); err != nil {
+		return err
+	}
+	tempPath := validPath + ".tmp"
+	if err := fs.recheckPath(tempPath); err != nil {
+		return err
+	}
+	defer fs.trackTemp(tempPath)()
+	if _, err := writeTempFileWithHash(tempPath, content); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err := os.Chmod(tempPath, perm); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err := fs.renameChecked(tempPath, validPath); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	fs.stats.addWritten(len(content))
+	return nil
+}
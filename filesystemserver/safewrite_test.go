@@ -0,0 +1,197 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRaceDirs returns a handler allowed in one directory, with a "sub"
+// subdirectory, and a directory outside it for symlinks to point at
+func newRaceDirs(t *testing.T) (*FilesystemHandler, string, string) {
+	t.Helper()
+	allowed, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	outside, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(allowed, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "victim.txt"), []byte("original"), 0644))
+
+	handler, err := NewFilesystemHandler([]string{allowed})
+	require.NoError(t, err)
+	return handler, allowed, outside
+}
+
+// swapForSymlink replaces path with a symlink to target, as an attacker would
+// between validation and the write
+func swapForSymlink(t *testing.T, path, target string) {
+	t.Helper()
+	require.NoError(t, os.RemoveAll(path))
+	if err := os.Symlink(target, path); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+}
+
+func TestWriteRejectsParentSwappedAfterValidation(t *testing.T) {
+	handler, allowed, outside := newRaceDirs(t)
+
+	validPath, err := handler.validatePath(filepath.Join(allowed, "sub", "victim.txt"))
+	require.NoError(t, err)
+	swapForSymlink(t, filepath.Join(allowed, "sub"), outside)
+
+	err = handler.writeFileChecked(validPath, []byte("pwned"), 0644)
+	assert.ErrorContains(t, err, "path changed after validation")
+	err = handler.removeChecked(validPath, false)
+	assert.ErrorContains(t, err, "path changed after validation")
+	err = handler.mkdirAllChecked(filepath.Join(allowed, "sub", "nested"), 0755)
+	assert.ErrorContains(t, err, "outside allowed directories")
+
+	data, err := os.ReadFile(filepath.Join(outside, "victim.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(data))
+	_, err = os.Stat(filepath.Join(outside, "nested"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestWriteRejectsFileSwappedAfterValidation(t *testing.T) {
+	handler, allowed, outside := newRaceDirs(t)
+	target := filepath.Join(allowed, "sub", "notes.txt")
+	require.NoError(t, os.WriteFile(target, []byte("notes"), 0644))
+
+	validPath, err := handler.validatePath(target)
+	require.NoError(t, err)
+	swapForSymlink(t, target, filepath.Join(outside, "victim.txt"))
+
+	err = handler.writeFileChecked(validPath, []byte("pwned"), 0644)
+	assert.ErrorContains(t, err, "became a symlink")
+	err = handler.renameChecked(validPath, filepath.Join(allowed, "moved.txt"))
+	assert.ErrorContains(t, err, "became a symlink")
+
+	data, err := os.ReadFile(filepath.Join(outside, "victim.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(data))
+}
+
+func TestSplitFileRefusesSymlinkedChunk(t *testing.T) {
+	handler, allowed, outside := newRaceDirs(t)
+	source := filepath.Join(allowed, "data.bin")
+	require.NoError(t, os.WriteFile(source, make([]byte, 4096), 0644))
+	swapForSymlink(t, source+".part001", filepath.Join(outside, "victim.txt"))
+
+	result, err := handler.handleSplitFile(context.Background(), newToolRequest("split_file", map[string]interface{}{
+		"path":       source,
+		"chunk_size": float64(1024),
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	data, err := os.ReadFile(filepath.Join(outside, "victim.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(data))
+	assert.NoFileExists(t, source+".part000")
+}
+
+func TestWriteFileChecked(t *testing.T) {
+	handler, allowed, _ := newRaceDirs(t)
+	path := filepath.Join(allowed, "sub", "file.txt")
+
+	require.NoError(t, handler.writeFileChecked(path, []byte("a longer first version"), 0644))
+	require.NoError(t, handler.writeFileChecked(path, []byte("short"), 0644))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "short", string(data))
+
+	require.NoError(t, handler.mkdirAllChecked(filepath.Join(allowed, "a", "b"), 0755))
+	require.NoError(t, handler.removeChecked(filepath.Join(allowed, "a"), true))
+	_, err = os.Stat(filepath.Join(allowed, "a"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestExecutePlanRejectsSwappedPaths(t *testing.T) {
+	handler, allowed, outside := newRaceDirs(t)
+	target := filepath.Join(allowed, "sub", "main.go")
+	require.NoError(t, os.WriteFile(target, []byte("package main"), 0644))
+
+	// A modify step writes the file it validated
+	validPath, err := handler.validatePath(target)
+	require.NoError(t, err)
+	swapForSymlink(t, filepath.Join(allowed, "sub"), outside)
+	err = handler.writeFileAtomic(validPath, "pwned")
+	assert.ErrorContains(t, err, "path changed after validation")
+	assert.NoFileExists(t, filepath.Join(outside, "main.go"))
+	assert.NoFileExists(t, filepath.Join(outside, "main.go.tmp"))
+
+	// The backup directory of the plan is swapped before the backup is taken
+	require.NoError(t, os.WriteFile(filepath.Join(allowed, "notes.txt"), []byte("notes"), 0644))
+	plan := &TaskPlan{ID: "plan_test", Workspace: allowed}
+	swapForSymlink(t, filepath.Join(allowed, planDirName), outside)
+	_, err = handler.ensurePlanBackup(context.Background(), plan, filepath.Join(allowed, "notes.txt"))
+	assert.ErrorContains(t, err, "outside allowed directories")
+	assert.NoDirExists(t, filepath.Join(outside, plan.ID))
+}
+
+func TestRollbackRejectsSwappedPaths(t *testing.T) {
+	handler, allowed, outside := newRaceDirs(t)
+	backup := filepath.Join(allowed, "backup.txt")
+	require.NoError(t, os.WriteFile(backup, []byte("backup"), 0644))
+
+	validPath, err := handler.validatePath(filepath.Join(allowed, "sub", "victim.txt"))
+	require.NoError(t, err)
+	swapForSymlink(t, filepath.Join(allowed, "sub"), outside)
+
+	for _, action := range []string{"created", "moved", "modified"} {
+		err := handler.revertPlanChange(action, validPath, backup)
+		assert.ErrorContains(t, err, "path changed after validation", action)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outside, "victim.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(data))
+	assert.FileExists(t, backup)
+}
+
+func TestRestoreSnapshotRejectsSwappedTarget(t *testing.T) {
+	handler, allowed, outside := newRaceDirs(t)
+	source := filepath.Join(allowed, "sub")
+	require.NoError(t, os.WriteFile(filepath.Join(source, "victim.txt"), []byte("snapshot"), 0644))
+
+	snapshot, dir, err := handler.createSnapshot(context.Background(), source, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(source, "victim.txt"), []byte("changed"), 0644))
+	entries, err := handler.planSnapshotRestore(context.Background(), dir, snapshot, source)
+	require.NoError(t, err)
+
+	swapForSymlink(t, source, outside)
+	err = handler.applySnapshotRestore(dir, snapshot, source, entries)
+	assert.ErrorContains(t, err, "path changed after validation")
+
+	data, err := os.ReadFile(filepath.Join(outside, "victim.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(data))
+}
+
+func TestHardlinkDedupRejectsSwappedDuplicate(t *testing.T) {
+	handler, allowed, outside := newRaceDirs(t)
+	kept := filepath.Join(allowed, "kept.txt")
+	require.NoError(t, os.WriteFile(kept, []byte("original"), 0644))
+
+	dup, err := handler.validatePath(filepath.Join(allowed, "sub", "victim.txt"))
+	require.NoError(t, err)
+	swapForSymlink(t, filepath.Join(allowed, "sub"), outside)
+
+	err = handler.replaceWithHardlink(kept, dup)
+	assert.ErrorContains(t, err, "path changed after validation")
+
+	entries, err := os.ReadDir(outside)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	info, err := os.Stat(filepath.Join(outside, "victim.txt"))
+	require.NoError(t, err)
+	other, err := os.Stat(kept)
+	require.NoError(t, err)
+	assert.False(t, os.SameFile(info, other))
+}
@@ -0,0 +1,188 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// Defaults for sampled walks: entries looked at per directory, entries looked
+// at in total, and directory levels below the root that are always listed in
+// full so the structure they show is exact.
+const (
+	defaultSamplePerDir      = 200
+	defaultSampleTotal       = 20000
+	defaultSampleExactLevels = 2
+)
+
+// sampleOptions bounds a sampledWalk.
+type sampleOptions struct {
+	PerDir      int // entries sampled from each directory below ExactLevels
+	Total       int // entry budget for the whole walk
+	ExactLevels int // directory levels listed in full, whatever the budget
+}
+
+// withDefaults fills zero fields with the package defaults.
+func (o sampleOptions) withDefaults() sampleOptions {
+	if o.PerDir <= 0 {
+		o.PerDir = defaultSamplePerDir
+	}
+	if o.Total <= 0 {
+		o.Total = defaultSampleTotal
+	}
+	if o.ExactLevels <= 0 {
+		o.ExactLevels = defaultSampleExactLevels
+	}
+	return o
+}
+
+// sampledWalkFunc is called once per visited entry. depth is 1 for the root's
+// children; weight is how many real entries this one stands for (1 when
+// nothing above it was sampled), so callers extrapolate by summing weights.
+type sampledWalkFunc func(path string, info os.FileInfo, depth int, weight float64) error
+
+// sampleStats describes how much of the tree a sampledWalk actually looked at.
+type sampleStats struct {
+	Visited            int     // entries passed to fn
+	Listed             int     // directories read
+	SampledDirectories int     // directories where only part of the entries were visited
+	Unexplored         float64 // estimated directories counted but never read, their contents missing from the estimate
+}
+
+// exact reports whether the walk saw every entry, so weights are all 1.
+func (s sampleStats) exact() bool {
+	return s.SampledDirectories == 0 && s.Unexplored == 0
+}
+
+// sampledWalk visits a bounded part of the tree under root. The first
+// ExactLevels levels are listed in full; deeper directories contribute at most
+// PerDir entries, picked evenly across their sorted listing, and the Total
+// budget is shared out among the subdirectories still to visit. When a
+// directory can't afford to descend into all its subdirectories it descends
+// into an evenly spaced subset and weights them for the rest. Ignored and
+// denied entries are skipped before sampling, as in the full walkers.
+func (fs *FilesystemHandler) sampledWalk(ctx context.Context, root string, opts sampleOptions, fn sampledWalkFunc) (sampleStats, error) {
+	opts = opts.withDefaults()
+	w := &sampledWalker{fs: fs, ctx: ctx, opts: opts, fn: fn, progress: progressFrom(ctx), activity: activityFrom(ctx)}
+	_, err := w.dir(root, 0, 1, opts.Total)
+	return w.stats, err
+}
+
+// sampledWalker holds the state of one sampledWalk.
+type sampledWalker struct {
+	fs       *FilesystemHandler
+	ctx      context.Context
+	opts     sampleOptions
+	fn       sampledWalkFunc
+	progress *progressReporter
+	activity *callActivity
+	stats    sampleStats
+}
+
+// dir visits the entries of dir, which sits at depth and stands for weight
+// directories, spending at most budget entries unless it is within the exact
+// levels. It returns the entries it spent.
+func (w *sampledWalker) dir(dir string, depth int, weight float64, budget int) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		w.fs.logWalkError(w.ctx, dir, err)
+		return 0, nil
+	}
+	w.stats.Listed++
+
+	kept := entries[:0]
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !w.fs.shouldIgnoreEntry(w.ctx, path, entry.IsDir()) {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) == 0 {
+		return 0, nil
+	}
+
+	exact := depth < w.opts.ExactLevels
+	sample := kept
+	if !exact {
+		sample = evenlySpaced(kept, min(w.opts.PerDir, max(budget, 1)))
+	}
+	if len(sample) < len(kept) {
+		w.stats.SampledDirectories++
+	}
+	childWeight := weight * float64(len(kept)) / float64(len(sample))
+
+	used := 0
+	var subdirs []string
+	for _, entry := range sample {
+		if err := w.ctx.Err(); err != nil {
+			return used, err
+		}
+		path := filepath.Join(dir, entry.Name())
+		if w.fs.stats != nil {
+			w.fs.stats.walkEntries.Add(1)
+		}
+		w.progress.entry(path)
+		if w.activity != nil {
+			w.activity.entries.Add(1)
+		}
+		used++
+		if _, err := w.fs.validatePath(path); err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			w.fs.logWalkError(w.ctx, path, err)
+			continue
+		}
+		w.stats.Visited++
+		if err := w.fn(path, info, depth+1, childWeight); err != nil {
+			if err == filepath.SkipDir {
+				continue
+			}
+			return used, err
+		}
+		if info.IsDir() {
+			subdirs = append(subdirs, path)
+		}
+	}
+
+	if len(subdirs) == 0 {
+		return used, nil
+	}
+	remaining := budget - used
+	descend := subdirs
+	if !exact && remaining < len(subdirs) {
+		descend = evenlySpaced(subdirs, max(remaining, 0))
+		if len(descend) == 0 {
+			w.stats.Unexplored += childWeight * float64(len(subdirs))
+			return used, nil
+		}
+	}
+	subWeight := childWeight * float64(len(subdirs)) / float64(len(descend))
+	for i, sub := range descend {
+		share := max(remaining, 0) / (len(descend) - i)
+		spent, err := w.dir(sub, depth+1, subWeight, share)
+		used += spent
+		remaining -= spent
+		if err != nil {
+			return used, err
+		}
+	}
+	return used, nil
+}
+
+// evenlySpaced picks n items spread across items, keeping their order, so a
+// sample of a sorted listing isn't biased towards the start of the alphabet.
+func evenlySpaced[T any](items []T, n int) []T {
+	if n >= len(items) {
+		return items
+	}
+	picked := make([]T, n)
+	for i := range picked {
+		picked[i] = items[i*len(items)/n]
+	}
+	return picked
+}
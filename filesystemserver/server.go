@@ -1,43 +1,121 @@
 package filesystemserver
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
 var Version = "0.4.1"
 
-func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
+// NewFilesystemServer creates the MCP server for allowedDirs.
+//
+// Deprecated: use NewServer with WithAllowedDirectories and WithHandlerOptions.
+func NewFilesystemServer(allowedDirs []string, opts ...HandlerOption) (*server.MCPServer, error) {
+	s, _, err := NewServer(WithAllowedDirectories(allowedDirs...), WithHandlerOptions(opts...))
+	return s, err
+}
 
-	h, err := NewFilesystemHandler(allowedDirs)
-	if err != nil {
-		return nil, err
+// NewFilesystemServerWithHandler is NewFilesystemServer that also returns the
+// handler behind the tools.
+//
+// Deprecated: use NewServer, which returns the handler too.
+func NewFilesystemServerWithHandler(allowedDirs []string, opts ...HandlerOption) (*server.MCPServer, *FilesystemHandler, error) {
+	return NewServer(WithAllowedDirectories(allowedDirs...), WithHandlerOptions(opts...))
+}
+
+// NewServer creates the MCP server, or registers its tools and resources on
+// the server passed with WithExistingServer, and returns the handler behind
+// the tools so the caller can Shutdown it once the transport closes.
+// Runtime directory changes can be enabled with options or with the MCP_ALLOW_RUNTIME_DIRS=1 and
+// MCP_GRANTABLE_ROOTS (path-list separated) environment variables;
+// MCP_READ_ONLY=1 is equivalent to WithReadOnly(true). MCP_DENY_PATTERNS
+// (comma separated) adds deny globs and MCP_NO_DEFAULT_DENY=1 drops the defaults.
+// MCP_WORKSPACE sets the directory relative paths are resolved against,
+// MCP_IMPORTANT_FILES (comma separated category:glob) adds important file
+// patterns, and MCP_FS_LOG_LEVEL (debug, info, warn, error) the level of the stderr log.
+// MCP_MAX_HEAVY_OPS caps concurrent directory-walking tools (0 = unlimited),
+// MCP_HEAVY_QUEUE=0 rejects excess calls instead of queueing them and
+// MCP_HEAVY_QUEUE_TIMEOUT (e.g. 10s) bounds the wait. MCP_MAX_DECOMPRESSED_SIZE
+// (bytes) caps what decompress_file writes and MCP_MAX_CREATE_SIZE (bytes) the
+// files create_file_of_size makes. Results larger than MCP_INLINE_RESULT_LIMIT
+// (bytes) are written to .mcp-reports instead of being returned inline,
+// MCP_RESPONSE_BUDGET (bytes) caps the text of listing, search and analysis
+// results before they are truncated, and
+// MCP_HEALTH_CHECK=1 logs a warning at startup for unhealthy allowed directories.
+// MCP_GENERATED_MARKERS (comma separated) replaces the generated file markers
+// and MCP_SCAFFOLD_DIR adds template sets to the scaffold tool.
+// The same settings can come from a configuration file: pass the result of
+// LoadConfig with WithOptions; the environment variables override it.
+func NewServer(options ...Option) (*server.MCPServer, *FilesystemHandler, error) {
+	cfg := &serverConfig{}
+	for _, option := range options {
+		if err := option(cfg); err != nil {
+			return nil, nil, err
+		}
 	}
+	opts := append([]HandlerOption{}, cfg.handlerOpts...)
 
-	s := server.NewMCPServer(
-		"secure-filesystem-server",
-		Version,
-		server.WithResourceCapabilities(true, true),
-	)
+	env := &HandlerOptions{}
+	if err := env.FromEnvironment(); err != nil {
+		return nil, nil, err
+	}
+	if env.LogLevel != "" {
+		level, _ := parseLogLevel(env.LogLevel)
+		// Before the caller options, so an explicit WithLogger wins
+		opts = append([]HandlerOption{WithLogger(newStderrLogger(level))}, opts...)
+		env.LogLevel = ""
+	}
+	opts = append(opts, WithOptions(env))
 
-	// Register resource handlers
-	s.AddResource(mcp.NewResource(
-		"file://",
-		"File System",
-		mcp.WithResourceDescription("Access to files and directories on the local file system"),
-	), h.handleReadResource)
+	h, err := NewFilesystemHandler(cfg.allowedDirs, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	h.toolPrefix = cfg.toolPrefix
+
+	// addTool adds a tool to register unless the policy hides it or WithTools
+	// leaves it out, wrapping its handler with the runtime policy check, timeout and
+	// argument type check. Every tool must state its effect on the filesystem,
+	// which becomes its MCP annotations; tools that may run long also accept
+	// timeout_ms, and those that apply .mcpignore no_ignore. The policy, limits
+	// and logs always use the unprefixed name.
+	var toolNames []string
+	var tools []server.ServerTool
+	addTool := func(tool mcp.Tool, effect toolEffect, handler server.ToolHandlerFunc) {
+		tool = describeOutput(tool)
+		tool.Annotations = effect.annotation()
+		if acceptsTimeoutArg(tool.Name) {
+			mcp.WithNumber("timeout_ms",
+				mcp.Description("Stop after this many milliseconds, returning partial results where available; can only shorten the server's timeout for this tool"),
+			)(&tool)
+		}
+		if containsString(ignoreAwareTools, tool.Name) {
+			mcp.WithBoolean("no_ignore",
+				mcp.Description("Bypass the ignore rules (.mcpignore and the built-in defaults) for this call; denied paths stay hidden"),
+			)(&tool)
+		}
+		toolNames = append(toolNames, tool.Name)
+		if h.toolRegistrable(tool.Name) && cfg.selects(tool.Name) {
+			guarded := h.guardTool(tool.Name, withArgumentTypes(tool, handler))
+			tool.Name = cfg.toolPrefix + tool.Name
+			tools = append(tools, server.ServerTool{Tool: tool, Handler: guarded})
+		}
+	}
 
 	// Register tool handlers
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"read_file",
 		mcp.WithDescription("Read the complete contents of a file from the file system."),
 		mcp.WithString("path",
 			mcp.Description("Path to the file to read"),
 			mcp.Required(),
 		),
-	), h.handleReadFile)
+	), toolReadOnly, h.handleReadFile)
 
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"write_file",
 		mcp.WithDescription("Create a new file or overwrite an existing file with new content."),
 		mcp.WithString("path",
@@ -48,29 +126,41 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 			mcp.Description("Content to write to the file"),
 			mcp.Required(),
 		),
-	), h.handleWriteFile)
+		mcp.WithBoolean("allow_generated",
+			mcp.Description("Overwrite an existing file that looks generated (\"Code generated\", \"DO NOT EDIT\", \"@generated\" near the top, or a trailing sourceMappingURL); refused by default because regenerating it loses the change"),
+		),
+	), toolDestructiveIdempotent, h.handleWriteFile)
 
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"list_directory",
 		mcp.WithDescription("Get a detailed listing of all files and directories in a specified path."),
 		mcp.WithString("path",
 			mcp.Description("Path of the directory to list"),
 			mcp.Required(),
 		),
-	), h.handleListDirectory)
+		mcp.WithString("relative_to",
+			mcp.Description("How to show paths: 'root' (default, label:relative/path when under one allowed directory), 'absolute', or a directory to show paths relative to"),
+		),
+		mcp.WithNumber("max_results",
+			mcp.Description("Maximum results per page; when more exist the output ends with a cursor (default: no limit)"),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("Cursor from a previous page to continue where it stopped (same other parameters required)"),
+		),
+	), toolReadOnly, h.handleListDirectory)
 
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"create_directory",
 		mcp.WithDescription("Create a new directory or ensure a directory exists."),
 		mcp.WithString("path",
 			mcp.Description("Path of the directory to create"),
 			mcp.Required(),
 		),
-	), h.handleCreateDirectory)
+	), toolCreatesIdempotent, h.handleCreateDirectory)
 
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"copy_file",
-		mcp.WithDescription("Copy files and directories."),
+		mcp.WithDescription("Copy files and directories. A directory is copied recursively into a new destination (regular files only)."),
 		mcp.WithString("source",
 			mcp.Description("Source path of the file or directory"),
 			mcp.Required(),
@@ -79,11 +169,14 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 			mcp.Description("Destination path"),
 			mcp.Required(),
 		),
-	), h.handleCopyFile)
+		mcp.WithBoolean("verify",
+			mcp.Description("Hash the source while copying and the destination afterwards; a mismatch fails and removes the copy. Reports the SHA-256 of every file (default: false)"),
+		),
+	), toolDestructiveIdempotent, h.handleCopyFile)
 
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"move_file",
-		mcp.WithDescription("Move or rename files and directories."),
+		mcp.WithDescription("Move or rename files and directories. Across devices the move copies and deletes the source only once the copy succeeded."),
 		mcp.WithString("source",
 			mcp.Description("Source path of the file or directory"),
 			mcp.Required(),
@@ -92,9 +185,12 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 			mcp.Description("Destination path"),
 			mcp.Required(),
 		),
-	), h.handleMoveFile)
+		mcp.WithBoolean("verify",
+			mcp.Description("Report the SHA-256 of every moved file; a cross-device move also verifies the copy before deleting the source (default: false)"),
+		),
+	), toolDestructive, h.handleMoveFile)
 
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"search_files",
 		mcp.WithDescription("Recursively search for files and directories matching a pattern."),
 		mcp.WithString("path",
@@ -105,32 +201,102 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 			mcp.Description("Search pattern to match against file names"),
 			mcp.Required(),
 		),
-	), h.handleSearchFiles)
+		mcp.WithString("relative_to",
+			mcp.Description("How to show paths: 'root' (default, label:relative/path when under one allowed directory), 'absolute', or a directory to show paths relative to"),
+		),
+		mcp.WithNumber("max_results",
+			mcp.Description("Maximum results per page; when more exist the output ends with a cursor (default: no limit)"),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("Cursor from a previous page to continue where it stopped (same other parameters required)"),
+		),
+	), toolReadOnly, h.handleSearchFiles)
 
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"get_file_info",
-		mcp.WithDescription("Retrieve detailed metadata about a file or directory."),
+		mcp.WithDescription("Retrieve detailed metadata about a file or directory. Created and accessed times come from the platform (statx on Linux, birth time on macOS/FreeBSD, file attributes on Windows); where one is unavailable the modification time is shown and listed in estimatedTimes. Also reports whether the path is a symlink and its target, owner/group with uid/gid and hard link count on unix, and file attributes (readonly, hidden, system...) on Windows. Images (PNG, JPEG, GIF, WebP) add width, height and color model read from the header, and JPEGs their EXIF orientation and date."),
 		mcp.WithString("path",
 			mcp.Description("Path to the file or directory"),
 			mcp.Required(),
 		),
-	), h.handleGetFileInfo)
+		mcp.WithBoolean("include_xattrs",
+			mcp.Description("Also list extended attributes as name/value pairs (Linux, macOS, FreeBSD; values over 1KB are truncated, binary values base64 encoded)"),
+		),
+	), toolReadOnly, h.handleGetFileInfo)
 
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
+		"get_multiple_file_info",
+		mcp.WithDescription("Retrieve get_file_info metadata for up to 50 paths in one call, with per-path errors inline and a summary of file and directory counts, total file size and the most recently modified path. Paths may be globs (*, ? and [...] within one directory level, no **), expanded as in read_multiple_files; the limit counts the expanded paths."),
+		mcp.WithArray("paths",
+			mcp.Description("Paths or globs to describe"),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("include_xattrs",
+			mcp.Description("Also list extended attributes of each path, as in get_file_info"),
+		),
+	), toolReadOnly, h.handleGetMultipleFileInfo)
+
+	addTool(mcp.NewTool(
 		"list_allowed_directories",
-		mcp.WithDescription("Returns the list of directories that this server is allowed to access."),
-	), h.handleListAllowedDirectories)
+		mcp.WithDescription("Returns the list of directories that this server is allowed to access, with the label each one can be referred to by (label:relative/path)."),
+	), toolReadOnly, h.handleListAllowedDirectories)
+
+	addTool(mcp.NewTool(
+		"server_stats",
+		mcp.WithDescription("Report server activity since startup: per-tool call and error counts with average duration, bytes read and written, directory entries walked, uptime and the configured size limits."),
+		mcp.WithString("output",
+			mcp.Description("Output format: 'text' (default) or 'json'"),
+		),
+	), toolReadOnly, h.handleServerStats)
+
+	addTool(mcp.NewTool(
+		"health_check",
+		mcp.WithDescription("Check every allowed directory: existence, readability, writability (a probe file is created and deleted, except in read-only mode), symlink resolution, free space and case sensitivity. Reports a per-directory table and an overall status: ok, degraded or failed."),
+	), toolCreatesIdempotent, h.handleHealthCheck)
+
+	addTool(mcp.NewTool(
+		"get_config",
+		mcp.WithDescription("Report the effective server configuration: config file, environment variables and command line merged, with the defaults filled in. Paths outside the allowed directories are redacted."),
+	), toolReadOnly, h.handleGetConfig)
+
+	addTool(mcp.NewTool(
+		"get_ignore_rules",
+		mcp.WithDescription("Show the ignore rules the directory walkers apply: the built-in defaults and the .mcpignore (gitignore syntax) at the root of each allowed directory, reloaded as soon as it changes. Per-call exclude_patterns come on top, and no_ignore: true bypasses the rules for one call."),
+		mcp.WithString("path",
+			mcp.Description("Also tell whether this path is ignored and by which rule"),
+		),
+	), toolReadOnly, h.handleGetIgnoreRules)
+
+	if h.runtimeDirsEnabled() {
+		addTool(mcp.NewTool(
+			"add_allowed_directory",
+			mcp.WithDescription("Grant access to an additional directory for the rest of the session."),
+			mcp.WithString("path",
+				mcp.Description("Directory to allow"),
+				mcp.Required(),
+			),
+		), toolCreatesIdempotent, h.handleAddAllowedDirectory)
+
+		addTool(mcp.NewTool(
+			"remove_allowed_directory",
+			mcp.WithDescription("Revoke a directory previously added with add_allowed_directory."),
+			mcp.WithString("path",
+				mcp.Description("Directory to revoke"),
+				mcp.Required(),
+			),
+		), toolCreatesIdempotent, h.handleRemoveAllowedDirectory)
+	}
 
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"read_multiple_files",
-		mcp.WithDescription("Read the contents of multiple files in a single operation."),
+		mcp.WithDescription("Read the contents of up to 50 files in a single operation. Paths may be globs (*, ? and [...] within one directory level, no **); the limit counts the expanded paths."),
 		mcp.WithArray("paths",
-			mcp.Description("List of file paths to read"),
+			mcp.Description("List of file paths or globs to read"),
 			mcp.Required(),
 		),
-	), h.handleReadMultipleFiles)
+	), toolReadOnly, h.handleReadMultipleFiles)
 
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"tree",
 		mcp.WithDescription("Returns a hierarchical JSON representation of a directory structure."),
 		mcp.WithString("path",
@@ -143,9 +309,12 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 		mcp.WithBoolean("follow_symlinks",
 			mcp.Description("Whether to follow symbolic links (default: false)"),
 		),
-	), h.handleTree)
+		mcp.WithString("relative_to",
+			mcp.Description("How to show paths: 'root' (default, label:relative/path when under one allowed directory), 'absolute', or a directory to show paths relative to"),
+		),
+	), toolCreates, h.handleTree)
 
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"delete_file",
 		mcp.WithDescription("Delete a file or directory from the file system."),
 		mcp.WithString("path",
@@ -155,9 +324,9 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 		mcp.WithBoolean("recursive",
 			mcp.Description("Whether to recursively delete directories (default: false)"),
 		),
-	), h.handleDeleteFile)
+	), toolDestructiveIdempotent, h.handleDeleteFile)
 
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"edit_file",
 		mcp.WithDescription("Modify file content by replacing specific text without rewriting the entire file."),
 		mcp.WithString("path",
@@ -172,20 +341,89 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 			mcp.Description("New text to replace with"),
 			mcp.Required(),
 		),
-	), h.handleEditFile)
+		mcp.WithBoolean("strip_bom",
+			mcp.Description("Remove a leading UTF-8 BOM from the file; by default it is ignored while matching and kept on write"),
+		),
+		mcp.WithBoolean("allow_generated",
+			mcp.Description("Edit a file that looks generated (\"Code generated\", \"DO NOT EDIT\", \"@generated\" near the top, or a trailing sourceMappingURL); refused by default because regenerating it loses the change"),
+		),
+	), toolDestructive, h.handleEditFile)
+
+	addTool(mcp.NewTool(
+		"normalize_file",
+		mcp.WithDescription("Normalize line endings, trailing whitespace, the final newline and leading indentation of a text file or, recursively, of every text file under a directory. Binary files are skipped, files are streamed line by line and rewritten atomically only when something changes, and the result lists the changes per file."),
+		mcp.WithString("path",
+			mcp.Description("File or directory to normalize"),
+			mcp.Required(),
+		),
+		mcp.WithString("line_endings",
+			mcp.Description("Convert every line ending to lf or crlf (default: keep them)"),
+			mcp.Enum("lf", "crlf"),
+		),
+		mcp.WithBoolean("trim_trailing_whitespace",
+			mcp.Description("Remove spaces and tabs at the end of lines"),
+		),
+		mcp.WithBoolean("ensure_final_newline",
+			mcp.Description("End the file with a line ending if it does not"),
+		),
+		mcp.WithBoolean("tabs_to_spaces",
+			mcp.Description("Convert leading indentation to spaces"),
+		),
+		mcp.WithBoolean("spaces_to_tabs",
+			mcp.Description("Convert leading indentation to tabs; spaces that do not fill a tab stop are kept"),
+		),
+		mcp.WithNumber("tab_width",
+			mcp.Description("Columns per tab stop for indentation conversion (default: 4, or tab_width/indent_size from .editorconfig)"),
+		),
+		mcp.WithBoolean("use_editorconfig",
+			mcp.Description("Take end_of_line, trim_trailing_whitespace, insert_final_newline, indent_style and tab_width/indent_size from .editorconfig files; explicit options win (default: false)"),
+		),
+		mcp.WithArray("include",
+			mcp.Description("For directories, only normalize files matching these globs (name or relative path)"),
+		),
+		mcp.WithArray("exclude",
+			mcp.Description("For directories, skip files and directories matching these globs"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Report what would change without writing (default: false)"),
+		),
+	), toolDestructiveIdempotent, h.handleNormalizeFile)
 
 	// Herramienta de análisis profundo de archivos
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"analyze_file",
 		mcp.WithDescription("Perform deep analysis of a file including complexity metrics, dependencies, and metadata optimized for Claude Desktop."),
 		mcp.WithString("path",
 			mcp.Description("Path to the file to analyze"),
 			mcp.Required(),
 		),
-	), h.handleAnalyzeFile)
+	), toolReadOnly, h.handleAnalyzeFile)
+
+	// Outline de símbolos sin leer el archivo completo
+	addTool(mcp.NewTool(
+		"extract_outline",
+		mcp.WithDescription("List top-level symbols (functions, methods, types/classes, consts) with line ranges and signatures for Go, JavaScript, TypeScript and Python files. Use it to see a file's shape without reading it whole; directories are outlined per file."),
+		mcp.WithString("path",
+			mcp.Description("Source file or directory"),
+			mcp.Required(),
+		),
+		mcp.WithString("output",
+			mcp.Description("Output format: 'text' (default, indented) or 'json'"),
+		),
+	), toolReadOnly, h.handleExtractOutline)
+
+	// Texto de documentos PDF y .docx
+	addTool(mcp.NewTool(
+		"extract_text",
+		mcp.WithDescription("Extract the text of a PDF (text layer only, no OCR) or .docx document. Documents over 20MB are refused, extraction stops after 5 seconds and the text is cut at 2MB."+oversizedResultNote),
+		mcp.WithString("path",
+			mcp.Description("PDF or .docx file"),
+			mcp.Required(),
+		),
+	), toolCreates, h.handleExtractText)
 
 	// Búsqueda inteligente optimizada para Claude
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"smart_search",
 		mcp.WithDescription("Intelligent search with regex support, content matching, and file type filtering - perfect for Claude's code analysis."),
 		mcp.WithString("path",
@@ -193,66 +431,400 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 			mcp.Required(),
 		),
 		mcp.WithString("pattern",
-			mcp.Description("Search pattern (supports regex)"),
+			mcp.Description("Search pattern (supports regex, up to 1000 bytes)"),
 			mcp.Required(),
 		),
+		mcp.WithBoolean("literal",
+			mcp.Description("true searches for the pattern text as-is, false requires valid regex and reports an error otherwise. When omitted, a pattern that is not valid regex is searched literally and the response says so"),
+		),
 		mcp.WithBoolean("include_content",
 			mcp.Description("Search within file contents (default: false)"),
 		),
 		mcp.WithArray("file_types",
 			mcp.Description("Filter by file extensions (e.g., ['.js', '.py', '.go'])"),
 		),
-	), h.handleSmartSearch)
+		mcp.WithBoolean("exclude_generated",
+			mcp.Description("Skip files that look generated, as edit_file would refuse them (default: false)"),
+		),
+		mcp.WithBoolean("extract_documents",
+			mcp.Description("Also search the text of PDF (text layer only) and .docx files, at most 20MB and 5 seconds each; documents that fail are listed as skipped (implies include_content; default: false)"),
+		),
+		mcp.WithString("relative_to",
+			mcp.Description("How to show paths: 'root' (default, label:relative/path when under one allowed directory), 'absolute', or a directory to show paths relative to"),
+		),
+		mcp.WithNumber("max_results",
+			mcp.Description("Maximum results per page; when more exist the output ends with a cursor (default: no limit)"),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("Cursor from a previous page to continue where it stopped (same other parameters required)"),
+		),
+		mcp.WithBoolean("include_file_content",
+			mcp.Description("Also return the matched regions of the files with most content matches: matched lines with context, overlapping windows merged (implies include_content; default: false)"),
+		),
+		mcp.WithNumber("context_lines",
+			mcp.Description("Lines of context around each match in the returned regions (default: 3)"),
+		),
+		mcp.WithNumber("content_limit_per_file",
+			mcp.Description("Maximum bytes of content returned per file (default: 4000, max: 20000); the whole response is capped at 60000"),
+		),
+		mcp.WithNumber("max_files_with_content",
+			mcp.Description("Number of files, most matches first, whose regions are returned (default: 5, max: 20)"),
+		),
+	), toolCreates, h.handleSmartSearch)
 
 	// Detección de archivos duplicados
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"find_duplicates",
-		mcp.WithDescription("Find duplicate files by content hash - useful for cleanup and optimization tasks Claude might suggest."),
+		mcp.WithDescription("Find duplicate files by content hash - useful for cleanup and optimization tasks Claude might suggest. Can optionally delete or hard link the extra copies."+oversizedResultNote),
 		mcp.WithString("path",
 			mcp.Description("Directory to scan for duplicates"),
 			mcp.Required(),
 		),
-	), h.handleFindDuplicates)
+		mcp.WithNumber("min_size",
+			mcp.Description("Skip files smaller than this many bytes (default: 1, so empty files are ignored)"),
+		),
+		mcp.WithNumber("max_size",
+			mcp.Description("Skip files larger than this many bytes; 0 for no limit (default: 104857600)"),
+		),
+		mcp.WithArray("exclude_patterns",
+			mcp.Description("Glob patterns (file name or relative path) to exclude from the scan"),
+		),
+		mcp.WithString("action",
+			mcp.Description("What to do with duplicates: 'report', 'delete_newest', 'delete_oldest', 'hardlink' (default: report)"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Show what the action would do without changing any files (default: false)"),
+		),
+		mcp.WithString("output",
+			mcp.Description("Output format: 'text' or 'json' (default: text)"),
+		),
+	), toolDestructive, h.handleFindDuplicates)
+
+	// Detección de código duplicado
+	addTool(mcp.NewTool(
+		"find_duplicate_code",
+		mcp.WithDescription("Find copy-pasted code blocks across the source files of a directory (or repeated within one). Lines are normalized (trimmed, whitespace collapsed; blank and brace-only lines dropped), sliding windows of lines are hashed and matching windows are merged into maximal blocks. Reports the largest blocks with every location (file, start and end line) and a snippet."),
+		mcp.WithString("path",
+			mcp.Description("Directory to scan"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("window",
+			mcp.Description("Significant lines per hashed window, the shortest block reported (default: 8, min: 3)"),
+		),
+		mcp.WithNumber("skip_prefix_lines",
+			mcp.Description("Lines skipped at the top of every file, e.g. the length of a license header (default: the leading comment block of each file)"),
+		),
+		mcp.WithNumber("max_results",
+			mcp.Description("Number of blocks reported, largest first (default: 10, max: 100)"),
+		),
+		mcp.WithArray("languages",
+			mcp.Description("Only scan files of these languages, e.g. ['go', 'python'] (default: every detected language)"),
+		),
+		mcp.WithArray("exclude_patterns",
+			mcp.Description("Glob patterns (file name or relative path) to exclude from the scan"),
+		),
+		mcp.WithBoolean("include_generated",
+			mcp.Description("Also scan files that look generated (generated-code markers, bundles with a source map); default: false"),
+		),
+		mcp.WithString("output",
+			mcp.Description("Output format: 'text' or 'json' (default: text)"),
+		),
+	), toolCreates, h.handleFindDuplicateCode)
+
+	// Checksums de archivos
+	addTool(mcp.NewTool(
+		"checksum",
+		mcp.WithDescription("Compute md5, sha1, sha256, sha512 or xxhash checksums for a file or every file in a directory, with an aggregate manifest hash."+oversizedResultNote),
+		mcp.WithString("path",
+			mcp.Description("File or directory to hash"),
+			mcp.Required(),
+		),
+		mcp.WithArray("algorithms",
+			mcp.Description("Algorithms to compute: md5, sha1, sha256, sha512, xxhash (default: [sha256])"),
+		),
+		mcp.WithBoolean("recursive",
+			mcp.Description("Include files in subdirectories (default: false)"),
+		),
+		mcp.WithString("output",
+			mcp.Description("Output format: 'text', 'json' or 'sfv' (<hash>  <relpath>, usable with sha256sum -c) (default: text)"),
+		),
+		mcp.WithArray("exclude_patterns",
+			mcp.Description("Glob patterns (file name or relative path) to exclude"),
+		),
+	), toolCreates, h.handleChecksum)
+
+	addTool(mcp.NewTool(
+		"verify_checksums",
+		mcp.WithDescription("Verify files against a checksum manifest (<hash>  <relpath> per line) and report OK, FAILED or MISSING per entry."),
+		mcp.WithString("manifest",
+			mcp.Description("Path to the manifest file"),
+			mcp.Required(),
+		),
+		mcp.WithString("base_dir",
+			mcp.Description("Directory that relative manifest paths are resolved against (default: the manifest's directory)"),
+		),
+		mcp.WithString("algorithm",
+			mcp.Description("Hash algorithm used in the manifest (default: inferred from hash length)"),
+		),
+	), toolReadOnly, h.handleVerifyChecksums)
 
 	// Análisis de estructura de proyecto
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"analyze_project",
-		mcp.WithDescription("Comprehensive project structure analysis with language detection and metrics - gives Claude full project context."),
+		mcp.WithDescription("Comprehensive project structure analysis with language detection and metrics - gives Claude full project context."+oversizedResultNote),
 		mcp.WithString("path",
 			mcp.Description("Project root directory"),
 			mcp.Required(),
 		),
-	), h.handleAnalyzeProject)
+		mcp.WithBoolean("include_loc",
+			mcp.Description("Count code, comment and blank lines per language (default: true; disable for speed on huge repos)"),
+		),
+		mcp.WithNumber("top",
+			mcp.Description("Number of largest files and directories to list (default: 10)"),
+		),
+		mcp.WithBoolean("quick",
+			mcp.Description("Bounded walk for huge repos: list the top 2 levels in full, sample deeper directories and report estimated counts and sizes with the sample sizes and caveats; lines of code are skipped (default: false)"),
+		),
+		mcp.WithNumber("sample_per_dir",
+			mcp.Description("Quick mode: entries sampled per directory below the top 2 levels (default: 200)"),
+		),
+		mcp.WithNumber("sample_total",
+			mcp.Description("Quick mode: entries looked at in total (default: 20000)"),
+		),
+		mcp.WithString("output",
+			mcp.Description("Output format: 'text' (default) or 'json' for the full project structure"),
+		),
+	), toolCreates, h.handleAnalyzeProject)
+
+	addTool(mcp.NewTool(
+		"csv_query",
+		mcp.WithDescription("Preview and query a CSV/TSV file (also .csv.gz) without loading it: select columns, filter rows with a simple where, page with offset/limit. Returns a Markdown table plus JSON rows; malformed rows are reported with their line numbers."),
+		mcp.WithString("path",
+			mcp.Description("CSV or TSV file; the first row is the header"),
+			mcp.Required(),
+		),
+		mcp.WithString("delimiter",
+			mcp.Description("Field separator: 'auto' (default: comma, tab or semicolon from the header line), 'tab' or any single character"),
+		),
+		mcp.WithArray("columns",
+			mcp.Description("Columns to return, by header name or 0-based index (default: all)"),
+		),
+		mcp.WithString("where",
+			mcp.Description("Filter 'column op value' with op =, !=, >, >=, <, <= (numeric when both sides are numbers) or contains (case-insensitive), e.g. \"status = failed\""),
+		),
+		mcp.WithNumber("offset",
+			mcp.Description("Matching rows to skip (default: 0)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Rows to return (default: 50, max: 1000)"),
+		),
+		mcp.WithBoolean("count_rows",
+			mcp.Description("Read the whole file to count total and matching rows (default: true; false stops after limit rows)"),
+		),
+	), toolReadOnly, h.handleCSVQuery)
+
+	addTool(mcp.NewTool(
+		"log_query",
+		mcp.WithDescription("Stream a log file (also .gz) without loading it: keep lines between since and until and matching a regex, then return the lines or a summary grouping them by normalized message. Reports the detected timestamp format (iso8601, go-log, syslog)."),
+		mcp.WithString("path",
+			mcp.Description("Log file; gzip is detected from its magic bytes"),
+			mcp.Required(),
+		),
+		mcp.WithString("since",
+			mcp.Description("Keep lines at or after this time: RFC3339, 'YYYY-MM-DD HH:MM[:SS]', 'HH:MM[:SS]' on the day of the first timestamped line, or a duration back from now such as 15m. Lines without a timestamp inherit the previous one"),
+		),
+		mcp.WithString("until",
+			mcp.Description("Keep lines at or before this time, same formats as since"),
+		),
+		mcp.WithString("pattern",
+			mcp.Description("Regular expression the whole line must match, e.g. ERROR|WARN"),
+		),
+		mcp.WithBoolean("ignore_case",
+			mcp.Description("Case-insensitive pattern (default: false)"),
+		),
+		mcp.WithString("mode",
+			mcp.Description("lines (default) returns matching lines; summary counts them per normalized message (numbers, ids and quoted strings replaced)"),
+			mcp.Enum("lines", "summary"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Lines to return in lines mode (default: 100, max: 1000)"),
+		),
+		mcp.WithBoolean("tail",
+			mcp.Description("Return the last limit matching lines instead of the first (default: false)"),
+		),
+		mcp.WithNumber("top",
+			mcp.Description("Groups to return in summary mode, most frequent first (default: 20, max: 200)"),
+		),
+	), toolReadOnly, h.handleLogQuery)
+
+	addTool(mcp.NewTool(
+		"tail_follow",
+		mcp.WithDescription("Follow a file like tail -f for a bounded time: start at its current end, poll for appended lines and return them when duration_ms elapses, max_lines is reached or the call is cancelled. A file that shrinks or is replaced (log rotation) is read again from the start, and the result says so."),
+		mcp.WithString("path",
+			mcp.Description("File to follow"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("duration_ms",
+			mcp.Description("How long to wait for new lines (default: 10000; capped by the server, 60000 unless configured)"),
+		),
+		mcp.WithString("pattern",
+			mcp.Description("Only return new lines matching this regular expression"),
+		),
+		mcp.WithBoolean("ignore_case",
+			mcp.Description("Case-insensitive pattern (default: false)"),
+		),
+		mcp.WithNumber("max_lines",
+			mcp.Description("Stop after this many returned lines (default: 200, max: 5000)"),
+		),
+	), toolReadOnly, h.handleTailFollow)
+
+	addTool(mcp.NewTool(
+		"git_info",
+		mcp.WithDescription("Read-only git status without running git: current branch and commit, plus modified, deleted and untracked files under path, computed from .git/index (best-effort)."),
+		mcp.WithString("path",
+			mcp.Description("Directory inside the repository; only changes below it are listed (default: the workspace)"),
+		),
+		mcp.WithNumber("max_files",
+			mcp.Description("Maximum paths per list (default: 200)"),
+		),
+	), toolReadOnly, h.handleGitInfo)
+
+	addTool(mcp.NewTool(
+		"classify_files",
+		mcp.WithDescription("Walk a path and group files by content family (text, code, image, audio, video, archive, binary) with counts and sizes. Files with a known extension only get a header signature check; extensionless or unknown ones are detected by content. Flags files whose content contradicts the extension, such as a .txt that is an ELF or PE executable."),
+		mcp.WithString("path",
+			mcp.Description("File or directory to classify"),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("mismatches_only",
+			mcp.Description("Only report extension/content mismatches, without the per-family totals (default: false)"),
+		),
+		mcp.WithBoolean("full_detection",
+			mcp.Description("Detect the MIME type of every file by content, not just extensionless or unknown ones; slower (default: false)"),
+		),
+	), toolReadOnly, h.handleClassifyFiles)
+
+	addTool(mcp.NewTool(
+		"find_name_collisions",
+		mcp.WithDescription("Walk a directory and report names that differ only in case within the same parent (README.md and Readme.md), which clash on macOS and Windows. Each set suggests renames that collide with nothing. Output is grouped by directory."),
+		mcp.WithString("path",
+			mcp.Description("Directory to check"),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("check_windows_compat",
+			mcp.Description("Also report names Windows rejects: reserved device names (CON, PRN, AUX, NUL, COM1-9, LPT1-9, with any extension), characters <>:\"/\\|?* or control characters, and trailing dots or spaces (default: false)"),
+		),
+	), toolReadOnly, h.handleFindNameCollisions)
 
 	// Operaciones en lote
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"batch_operations",
-		mcp.WithDescription("Execute multiple file operations in a single call - efficient for Claude's bulk suggestions."),
+		mcp.WithDescription("Execute multiple file operations in a single call - efficient for Claude's bulk suggestions. Cancelling the call stops between files of a recursive copy, move or delete; the remaining operations are reported as not run. With a progress token, progress names the running operation and the files it has processed."),
 		mcp.WithArray("operations",
-			mcp.Description("Array of operations to execute: [{type: 'rename|delete|copy', from: 'path', to: 'path'}]"),
+			mcp.Description("Array of operations: {type: 'rename'|'move'|'copy', from, to, verify}, {type: 'delete', path, recursive}, {type: 'create_dir'|'mkdir', path} or {type: 'write', path, content}. Unknown fields are rejected and the whole batch is validated before anything runs"),
 			mcp.Required(),
 		),
-	), h.handleBatchEdit)
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Validate the operations and their paths and report what would happen without changing anything (default: false)"),
+		),
+		mcp.WithBoolean("transactional",
+			mcp.Description("All or nothing: stop at the first failure or cancellation and undo the operations already done, in reverse order (default: false, later operations still run after a failure)"),
+		),
+	), toolDestructive, h.handleBatchEdit)
 
 	// Comparación de archivos avanzada
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"compare_files",
-		mcp.WithDescription("Advanced file comparison with diff generation and similarity analysis for Claude's code review tasks."),
+		mcp.WithDescription("Advanced file comparison with diff generation and similarity analysis for Claude's code review tasks. When both paths are directories, compares the two trees."+oversizedResultNote),
 		mcp.WithString("file1",
-			mcp.Description("First file to compare"),
+			mcp.Description("First file (or directory) to compare"),
 			mcp.Required(),
 		),
 		mcp.WithString("file2",
-			mcp.Description("Second file to compare"),
-			mcp.Required(),
+			mcp.Description("Second file (or directory) to compare"),
+		),
+		mcp.WithString("file2_content",
+			mcp.Description("Inline content to compare against file1 instead of file2"),
 		),
 		mcp.WithString("format",
 			mcp.Description("Output format: 'unified', 'context', 'side-by-side' (default: unified)"),
 		),
-	), h.handleCompareFiles)
+		mcp.WithNumber("context_lines",
+			mcp.Description("Number of unchanged context lines around each change (default: 3)"),
+		),
+		mcp.WithNumber("width",
+			mcp.Description("Column width for side-by-side output (default: 60)"),
+		),
+		mcp.WithBoolean("ignore_whitespace",
+			mcp.Description("Ignore whitespace differences when comparing lines (default: false)"),
+		),
+		mcp.WithBoolean("ignore_case",
+			mcp.Description("Ignore case differences when comparing lines (default: false)"),
+		),
+		mcp.WithNumber("max_lines",
+			mcp.Description("Maximum diff lines to return; remaining hunks are omitted (default: 2000)"),
+		),
+		mcp.WithBoolean("hash",
+			mcp.Description("Directory mode: compare file contents by SHA256 instead of size and modification time (default: false)"),
+		),
+		mcp.WithBoolean("recursive_diff",
+			mcp.Description("Directory mode: include unified diffs for small differing text files (default: false)"),
+		),
+		mcp.WithBoolean("detect_renames",
+			mcp.Description("Directory mode: report files only in A whose content is at a new path only in B as renames, hashing same-size candidates (default: true)"),
+		),
+		mcp.WithString("output_path",
+			mcp.Description("Write the full unified diff to this patch file instead of returning it inline"),
+		),
+		mcp.WithBoolean("inline",
+			mcp.Description("With output_path: also return the diff inline (default: false)"),
+		),
+	), toolDestructive, h.handleCompareFiles)
+
+	// Revisión de calidad de código
+	addTool(mcp.NewTool(
+		"code_quality_check",
+		mcp.WithDescription("Check code files for long files, lines and functions, high complexity, TODO/FIXME markers, whitespace problems and low comment ratio. Returns findings by severity with a score."),
+		mcp.WithString("path",
+			mcp.Description("File or directory to check"),
+			mcp.Required(),
+		),
+		mcp.WithObject("thresholds",
+			mcp.Description("Override defaults: {max_file_lines: 500, max_line_length: 120, max_function_lines: 60, max_complexity: 15, min_comment_ratio: 5}"),
+		),
+	), toolReadOnly, h.handleCodeQualityCheck)
+
+	// Análisis de líneas de un archivo de texto
+	addTool(mcp.NewTool(
+		"analyze_lines",
+		mcp.WithDescription("Stream a text file and report duplicate lines with counts and line numbers, runs of consecutive blank lines longer than a threshold, the longest lines, and line count and length statistics."),
+		mcp.WithString("path",
+			mcp.Description("Text file to analyze"),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("trim_whitespace",
+			mcp.Description("Ignore leading and trailing whitespace when comparing lines (default: true)"),
+		),
+		mcp.WithBoolean("ignore_case",
+			mcp.Description("Compare lines case-insensitively (default: false)"),
+		),
+		mcp.WithNumber("max_blank_lines",
+			mcp.Description("Report runs of more consecutive blank lines than this (default: 1)"),
+		),
+		mcp.WithNumber("top",
+			mcp.Description("Number of longest lines to report (default: 5, max: 100)"),
+		),
+		mcp.WithNumber("max_duplicates",
+			mcp.Description("Maximum duplicate groups to return, most repeated first (default: 50, max: 500)"),
+		),
+		mcp.WithString("output",
+			mcp.Description("text (default) or json"),
+			mcp.Enum("text", "json"),
+		),
+	), toolReadOnly, h.handleAnalyzeLines)
 
 	// Análisis de rendimiento de archivos
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"performance_analysis",
 		mcp.WithDescription("Analyze file system performance metrics and identify bottlenecks."),
 		mcp.WithString("path",
@@ -262,12 +834,12 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 		mcp.WithString("operation",
 			mcp.Description("Operation to benchmark: 'read', 'write', 'list' (default: all)"),
 		),
-	), h.handlePerformanceAnalysis)
+	), toolReadOnly, h.handlePerformanceAnalysis)
 
 	// Generador de reportes
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"generate_report",
-		mcp.WithDescription("Generate comprehensive reports in various formats (JSON, HTML, Markdown) for Claude's analysis."),
+		mcp.WithDescription("Generate comprehensive reports in various formats (JSON, HTML, Markdown) for Claude's analysis."+oversizedResultNote),
 		mcp.WithString("path",
 			mcp.Description("Path to analyze for report"),
 			mcp.Required(),
@@ -279,14 +851,43 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 			mcp.Description("Output file path (optional)"),
 		),
 		mcp.WithArray("sections",
-			mcp.Description("Report sections to include: ['overview', 'files', 'quality', 'dependencies', 'security']"),
+			mcp.Description("Report sections to include: ['overview', 'files', 'quality', 'code_stats', 'dependencies', 'security']; all of them share a single walk of the tree"),
 		),
-	), h.handleGenerateReport)
+	), toolDestructive, h.handleGenerateReport)
+
+	// Métricas de código por directorio
+	addTool(mcp.NewTool(
+		"code_stats",
+		mcp.WithDescription("Code metrics per top-level directory of a project: files, lines of code, comment ratio, average and maximum cyclomatic complexity and the dominant language, as a table with a total row. Shows where the code and its complexity are concentrated, which analyze_project's global totals don't."),
+		mcp.WithString("path",
+			mcp.Description("Project directory"),
+			mcp.Required(),
+		),
+		mcp.WithString("sort_by",
+			mcp.Description("Column to sort by, largest first: 'loc', 'files', 'code_files', 'comment_ratio', 'avg_complexity', 'max_complexity', or 'directory' for name order (default: loc)"),
+		),
+	), toolReadOnly, h.handleCodeStats)
+
+	// Escáner de TODOs, licencia y secretos
+	addTool(mcp.NewTool(
+		"scan",
+		mcp.WithDescription("Scan a project for TODO/FIXME/HACK/XXX comments, its license and potential secrets (masked). False positives can be allowlisted in .mcpscanignore at the project root."),
+		mcp.WithString("path",
+			mcp.Description("Project root directory"),
+			mcp.Required(),
+		),
+		mcp.WithArray("scanners",
+			mcp.Description("Scanners to run: ['todos', 'license', 'secrets'] (default: all)"),
+		),
+		mcp.WithString("output",
+			mcp.Description("Output format: 'text' (default) or 'json'"),
+		),
+	), toolReadOnly, h.handleScan)
 
 	// Sincronización inteligente
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"smart_sync",
-		mcp.WithDescription("Intelligent file synchronization with conflict detection and resolution suggestions."),
+		mcp.WithDescription("One-way sync of a target directory from a source: copies new files, updates changed ones and renames moved files on the target instead of deleting and copying them again. 'preview' (default) only reports; 'merge' keeps target-only files and leaves files newer on the target as conflicts; 'overwrite' mirrors the source, deleting target-only files."),
 		mcp.WithString("source",
 			mcp.Description("Source directory"),
 			mcp.Required(),
@@ -301,10 +902,28 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 		mcp.WithArray("exclude_patterns",
 			mcp.Description("Patterns to exclude from sync"),
 		),
-	), h.handleSmartSync)
+		mcp.WithBoolean("detect_renames",
+			mcp.Description("Match files only in the source with files only in the target by size and hash and rename them on the target (default: true)"),
+		),
+	), toolDestructive, h.handleSmartSync)
+
+	addTool(mcp.NewTool(
+		"resolve_sync_conflict",
+		mcp.WithDescription("Resolve a conflict reported by smart_sync, by the id from its conflicts list: 'keep_source' copies the source version over the target, 'keep_target' leaves the target alone, 'merged' writes merged_content to the target. The change is atomic, and ids are refused once either side changed since they were reported."),
+		mcp.WithString("id",
+			mcp.Description("Conflict id from the smart_sync report"),
+			mcp.Required(),
+		),
+		mcp.WithString("resolution",
+			mcp.Description("'keep_source', 'keep_target' or 'merged' (default: merged when merged_content is given)"),
+		),
+		mcp.WithString("merged_content",
+			mcp.Description("Content to write to the target for the 'merged' resolution"),
+		),
+	), toolDestructive, h.handleResolveSyncConflict)
 
 	// Herramienta de refactoring asistido
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"assist_refactor",
 		mcp.WithDescription("Assist with code refactoring by analyzing dependencies and suggesting safe changes."),
 		mcp.WithString("path",
@@ -316,15 +935,15 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 			mcp.Required(),
 		),
 		mcp.WithString("target",
-			mcp.Description("Target for refactoring (new name, extracted function name, etc.)"),
+			mcp.Description("Symbol to refactor (for 'rename', the current name)"),
 		),
 		mcp.WithObject("options",
-			mcp.Description("Refactoring options"),
+			mcp.Description("Refactoring options. rename: new_name (required), apply (default: false, preview only), case_sensitive (default: true), include_strings (default: false), include_comments (default: false), file_patterns (globs limiting the files scanned)"),
 		),
-	), h.handleAssistRefactor)
+	), toolDestructive, h.handleAssistRefactor)
 
 	// Planificador de tareas
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"plan_task",
 		mcp.WithDescription("Create step-by-step execution plan for complex file operations."),
 		mcp.WithString("description",
@@ -337,10 +956,94 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 		mcp.WithString("workspace",
 			mcp.Description("Workspace path"),
 		),
-	), h.handlePlanTask)
+	), toolCreates, h.handlePlanTask)
+
+	addTool(mcp.NewTool(
+		"workspace_context",
+		mcp.WithDescription("Orient yourself in a project: detected project type, important files (manifests, entry points, README...) and file/directory counts per extension, as plan_task sees them. Results are cached per directory and dropped whenever a tool writes; changes made outside this server need refresh=true."),
+		mcp.WithString("path",
+			mcp.Description("Project directory (default: the server workspace)"),
+		),
+		mcp.WithBoolean("refresh",
+			mcp.Description("Ignore the cached result and analyze again (default: false)"),
+		),
+	), toolReadOnly, h.handleWorkspaceContext)
+
+	addTool(mcp.NewTool(
+		"get_plan",
+		mcp.WithDescription("Retrieve a plan created by plan_task from <workspace>/.mcp-plans."),
+		mcp.WithString("id",
+			mcp.Description("Plan ID returned by plan_task"),
+			mcp.Required(),
+		),
+		mcp.WithString("workspace",
+			mcp.Description("Workspace path the plan was created in"),
+		),
+		mcp.WithString("output",
+			mcp.Description("Output format: 'text' (default) or 'json'"),
+		),
+	), toolReadOnly, h.handleGetPlan)
+
+	addTool(mcp.NewTool(
+		"list_plans",
+		mcp.WithDescription("List plans persisted in <workspace>/.mcp-plans, newest first."),
+		mcp.WithString("workspace",
+			mcp.Description("Workspace path"),
+		),
+	), toolReadOnly, h.handleListPlans)
+
+	addTool(mcp.NewTool(
+		"execute_plan",
+		mcp.WithDescription("Execute a plan step by step (backup, copy, move, delete, create, modify, validate), recording each step's status in the plan file. Stops at the first failure, before unacknowledged high-risk steps or after pause_after_step."),
+		mcp.WithString("id",
+			mcp.Description("ID of a plan saved by plan_task"),
+		),
+		mcp.WithObject("plan",
+			mcp.Description("Inline plan with steps (type, files, destination, content, old_text, new_text, risk); saved before running"),
+		),
+		mcp.WithString("workspace",
+			mcp.Description("Workspace path; relative step paths are resolved against it"),
+		),
+		mcp.WithNumber("pause_after_step",
+			mcp.Description("Stop once the step with this ID (or higher) has finished"),
+		),
+		mcp.WithBoolean("acknowledge_risk",
+			mcp.Description("Allow high-risk steps to run (default: false)"),
+		),
+	), toolDestructive, h.handleExecutePlan)
+
+	addTool(mcp.NewTool(
+		"resume_plan",
+		mcp.WithDescription("Continue a paused or failed plan from the last completed step."),
+		mcp.WithString("id",
+			mcp.Description("Plan ID"),
+			mcp.Required(),
+		),
+		mcp.WithString("workspace",
+			mcp.Description("Workspace path the plan was created in"),
+		),
+		mcp.WithNumber("pause_after_step",
+			mcp.Description("Stop once the step with this ID (or higher) has finished"),
+		),
+		mcp.WithBoolean("acknowledge_risk",
+			mcp.Description("Allow high-risk steps to run (default: false)"),
+		),
+	), toolDestructive, h.handleResumePlan)
+
+	addTool(mcp.NewTool(
+		"rollback_plan",
+		mcp.WithDescription("Undo the executed steps of a plan in reverse order, restoring recorded backups."),
+		mcp.WithString("id",
+			mcp.Description("Plan ID"),
+			mcp.Required(),
+		),
+		mcp.WithString("workspace",
+			mcp.Description("Workspace path the plan was created in"),
+		),
+	), toolDestructive, h.handleRollbackPlan)
 
 	// ARCHIVOS FRAGMENTADOS - Chunked Operations
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"chunked_write",
 		mcp.WithDescription("Write large files in chunks to avoid memory limits."),
 		mcp.WithString("path",
@@ -359,9 +1062,9 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 			mcp.Description("Total number of chunks"),
 			mcp.Required(),
 		),
-	), h.handleChunkedWrite)
+	), toolDestructive, h.handleChunkedWrite)
 
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"split_file",
 		mcp.WithDescription("Split large file into smaller chunks."),
 		mcp.WithString("path",
@@ -371,22 +1074,288 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 		mcp.WithNumber("chunk_size",
 			mcp.Description("Size of each chunk in bytes (default: 1MB)"),
 		),
-	), h.handleSplitFile)
+	), toolCreates, h.handleSplitFile)
+
+	addTool(mcp.NewTool(
+		"split_cleanup",
+		mcp.WithDescription("Remove all .partNNN chunk files created by split_file for a source file."),
+		mcp.WithString("path",
+			mcp.Description("Path of the original (split) file"),
+			mcp.Required(),
+		),
+	), toolDestructive, h.handleSplitCleanup)
+
+	addTool(mcp.NewTool(
+		"cleanup",
+		mcp.WithDescription("Remove common junk under a directory: editor backups (*~, *.swp), left-over .tmp files and .backup/.partNNN files whose original still exists, __pycache__, .DS_Store, empty directories and extra globs. Dry run by default."),
+		mcp.WithString("path",
+			mcp.Description("Directory to clean"),
+			mcp.Required(),
+		),
+		mcp.WithArray("categories",
+			mcp.Description("Categories to clean: ['editor_backups', 'server_artifacts', 'pycache', 'ds_store', 'empty_dirs'] (default: all)"),
+		),
+		mcp.WithArray("extra_patterns",
+			mcp.Description("Additional glob patterns (file name or relative path) to remove"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Only report what would be removed (default: true)"),
+		),
+		mcp.WithString("output",
+			mcp.Description("Output format: 'text' (default) or 'json'"),
+		),
+	), toolDestructive, h.handleCleanup)
+
+	addTool(mcp.NewTool(
+		"fix_permissions",
+		mcp.WithDescription("Fix common permission problems after extracting archives or copying from Windows mounts: set directories to dir_mode and files to file_mode, keeping execute bits on files matching executable_globs. Only entries whose mode differs are changed; the report counts them by kind and old/new mode. Symlinks, special files and denied paths are skipped. On an allowed root directory a dry run must be reviewed first and its confirm token passed back. Not available on Windows."),
+		mcp.WithString("path",
+			mcp.Description("File or directory to fix"),
+			mcp.Required(),
+		),
+		mcp.WithString("dir_mode",
+			mcp.Description("Octal mode for directories (default: 755)"),
+		),
+		mcp.WithString("file_mode",
+			mcp.Description("Octal mode for files (default: 644)"),
+		),
+		mcp.WithArray("executable_globs",
+			mcp.Description("Globs (file name or relative path, e.g. '*.sh', 'bin/*') of files that get file_mode plus execute wherever it grants read, 755 for the default 644"),
+		),
+		mcp.WithBoolean("recursive",
+			mcp.Description("Walk the whole tree; otherwise only the path and its direct entries are fixed (default: false)"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Only report what would change (default: false)"),
+		),
+		mcp.WithString("confirm",
+			mcp.Description("Token from a dry run with the same parameters, required to fix an allowed root directory"),
+		),
+	), toolDestructiveIdempotent, h.handleFixPermissions)
+
+	// SNAPSHOTS - Workspace Checkpoints
+	addTool(mcp.NewTool(
+		"create_snapshot",
+		mcp.WithDescription("Copy a file or directory tree into <allowedDir>/.mcp-snapshots/<id>/ with a SHA256 manifest, as a checkpoint before risky changes."),
+		mcp.WithString("path",
+			mcp.Description("File or directory to snapshot"),
+			mcp.Required(),
+		),
+		mcp.WithArray("include",
+			mcp.Description("Only include files matching these globs (name or relative path)"),
+		),
+		mcp.WithArray("exclude",
+			mcp.Description("Skip files and directories matching these globs"),
+		),
+	), toolCreates, h.handleCreateSnapshot)
+
+	addTool(mcp.NewTool(
+		"create_archive",
+		mcp.WithDescription("Package a file or directory into a zip or tar.gz archive, streaming entries and keeping file modes, mtimes and symlinks (stored as links, not followed). Reports entry count, sizes and the archive SHA256."),
+		mcp.WithString("source_path",
+			mcp.Description("File or directory to archive"),
+			mcp.Required(),
+		),
+		mcp.WithString("output_path",
+			mcp.Description("Archive file to write; must be outside source_path. An existing file is replaced"),
+			mcp.Required(),
+		),
+		mcp.WithString("format",
+			mcp.Description("zip or tar.gz (default: from the output extension, zip if unknown)"),
+			mcp.Enum("zip", "tar.gz"),
+		),
+		mcp.WithArray("include",
+			mcp.Description("Only include files matching these globs (name or relative path)"),
+		),
+		mcp.WithArray("exclude",
+			mcp.Description("Skip files and directories matching these globs"),
+		),
+		mcp.WithBoolean("respect_ignore_dirs",
+			mcp.Description("Skip VCS, dependency, build and hidden directories such as .git, node_modules and dist (default: true)"),
+		),
+	), toolDestructive, h.handleCreateArchive)
+
+	// COMPRESSION - Single Files
+	addTool(mcp.NewTool(
+		"compress_file",
+		mcp.WithDescription("Compress a single file with gzip, streaming to <path>.gz or output_path. Reports sizes before and after."),
+		mcp.WithString("path",
+			mcp.Description("File to compress"),
+			mcp.Required(),
+		),
+		mcp.WithString("output_path",
+			mcp.Description("Compressed file to write (default: path + .gz)"),
+		),
+		mcp.WithString("format",
+			mcp.Description("Compression format; only gzip is supported (default: gzip)"),
+			mcp.Enum("gzip"),
+		),
+		mcp.WithNumber("level",
+			mcp.Description("Compression level 1 (fastest) to 9 (smallest); default 6"),
+		),
+		mcp.WithBoolean("keep_original",
+			mcp.Description("Keep the source file (default: true)"),
+		),
+		mcp.WithBoolean("overwrite",
+			mcp.Description("Replace output_path if it exists (default: false)"),
+		),
+	), toolDestructive, h.handleCompressFile)
 
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
+		"decompress_file",
+		mcp.WithDescription("Decompress a gzip file, detecting the format from its magic bytes. Output is capped to guard against decompression bombs."),
+		mcp.WithString("path",
+			mcp.Description("Compressed file"),
+			mcp.Required(),
+		),
+		mcp.WithString("output_path",
+			mcp.Description("File to write (default: path without .gz; required for other names)"),
+		),
+		mcp.WithNumber("max_output_size",
+			mcp.Description("Fail if the output would exceed this many bytes (cannot raise the server limit, 1GB by default)"),
+		),
+		mcp.WithBoolean("keep_original",
+			mcp.Description("Keep the compressed file (default: true)"),
+		),
+		mcp.WithBoolean("overwrite",
+			mcp.Description("Replace output_path if it exists (default: false)"),
+		),
+	), toolDestructive, h.handleDecompressFile)
+
+	addTool(mcp.NewTool(
+		"restore_snapshot",
+		mcp.WithDescription("Restore a snapshot. Without force, shows which files would be created or overwritten with a diff."),
+		mcp.WithString("id",
+			mcp.Description("Snapshot ID"),
+			mcp.Required(),
+		),
+		mcp.WithString("target",
+			mcp.Description("Restore location (default: the original path)"),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("Actually write the files (default: false, preview only)"),
+		),
+	), toolDestructive, h.handleRestoreSnapshot)
+
+	addTool(mcp.NewTool(
+		"list_snapshots",
+		mcp.WithDescription("List snapshots in all allowed directories, newest first."),
+	), toolReadOnly, h.handleListSnapshots)
+
+	// Detección de cambios desde una fecha o un snapshot
+	addTool(mcp.NewTool(
+		"detect_changes",
+		mcp.WithDescription("Report files created, modified and (against a snapshot) deleted under a path since a time or a snapshot from create_snapshot, with counts and total changed bytes. Skips VCS and server data directories and denied paths."),
+		mcp.WithString("path",
+			mcp.Description("File or directory to check"),
+			mcp.Required(),
+		),
+		mcp.WithString("since",
+			mcp.Description("Report changes after this time: RFC3339, 'YYYY-MM-DD HH:MM[:SS]', 'HH:MM[:SS]' today or a duration back like 15m or 2h"),
+		),
+		mcp.WithString("baseline_snapshot",
+			mcp.Description("Snapshot ID to compare with instead of since; also reports deleted files. path must be inside the snapshot source"),
+		),
+		mcp.WithBoolean("hash_verify",
+			mcp.Description("With baseline_snapshot: compare SHA-256 of same-size files instead of trusting modification times (default: false)"),
+		),
+		mcp.WithArray("include",
+			mcp.Description("Only check files matching these globs (name or relative path); defaults to the snapshot's filters"),
+		),
+		mcp.WithArray("exclude",
+			mcp.Description("Skip files and directories matching these globs; defaults to the snapshot's filters"),
+		),
+	), toolReadOnly, h.handleDetectChanges)
+
+	addTool(mcp.NewTool(
+		"recent_activity",
+		mcp.WithDescription("What happened here while I was away: files changed under a directory since a time or a snapshot, grouped by directory, with the total bytes of churn, the 10 most changed files (by size change against a snapshot, else the most recently modified) and new or deleted directories. One walk, modification times only unless hash_verify is set."),
+		mcp.WithString("path",
+			mcp.Description("Directory to summarize (default: the workspace)"),
+		),
+		mcp.WithString("since",
+			mcp.Description("Report changes after this time: RFC3339, 'YYYY-MM-DD HH:MM[:SS]', 'HH:MM[:SS]' today or a duration back like 15m or 2h (default: 24h)"),
+		),
+		mcp.WithString("baseline_snapshot",
+			mcp.Description("Snapshot ID to compare with instead of since; adds size changes, deleted files and deleted directories. path must be inside the snapshot source"),
+		),
+		mcp.WithBoolean("hash_verify",
+			mcp.Description("With baseline_snapshot: compare SHA-256 of same-size files instead of trusting modification times (default: false)"),
+		),
+	), toolReadOnly, h.handleRecentActivity)
+
+	addTool(mcp.NewTool(
+		"delete_snapshot",
+		mcp.WithDescription("Delete a snapshot and its stored files."),
+		mcp.WithString("id",
+			mcp.Description("Snapshot ID"),
+			mcp.Required(),
+		),
+	), toolDestructive, h.handleDeleteSnapshot)
+
+	addTool(mcp.NewTool(
 		"join_files",
-		mcp.WithDescription("Join multiple file chunks into single file."),
+		mcp.WithDescription("Join multiple file chunks into single file, either listed in order or matched by a glob."),
 		mcp.WithString("target_path",
 			mcp.Description("Path for the joined file"),
 			mcp.Required(),
 		),
 		mcp.WithArray("source_files",
-			mcp.Description("List of chunk files to join"),
+			mcp.Description("List of chunk files to join, in order"),
+		),
+		mcp.WithString("source_glob",
+			mcp.Description("Glob matching the chunk files instead of source_files (e.g. 'backup.tar.part*'). Matches are sorted naturally (part2 before part10) and must have consecutive numeric suffixes"),
+		),
+	), toolDestructive, h.handleJoinFiles)
+
+	addTool(mcp.NewTool(
+		"create_file_of_size",
+		mcp.WithDescription("Create a file of a given size without sending its content (test fixtures, disk images, write benchmarks). Reports elapsed time and throughput."),
+		mcp.WithString("path",
+			mcp.Description("File to create"),
 			mcp.Required(),
 		),
-	), h.handleJoinFiles)
+		mcp.WithNumber("size",
+			mcp.Description("Size in bytes (capped by the server, 10GB by default)"),
+			mcp.Required(),
+		),
+		mcp.WithString("mode",
+			mcp.Description("Content: 'sparse' (default, a hole that takes no disk space where supported), 'zero' (zeros actually written) or 'random' (cryptographically random bytes)"),
+			mcp.Enum("sparse", "zero", "random"),
+		),
+		mcp.WithBoolean("preallocate",
+			mcp.Description("Reserve the disk space before writing (fallocate; Linux only, skipped elsewhere)"),
+		),
+		mcp.WithBoolean("overwrite",
+			mcp.Description("Replace path if it exists (default: false)"),
+		),
+	), toolDestructive, h.handleCreateFileOfSize)
+
+	addTool(mcp.NewTool(
+		"truncate_file",
+		mcp.WithDescription("Shorten a file in place without sending its content (log rotation, fixtures): cut it to size bytes, keep only its last keep_last_bytes bytes, or zero a byte range. Refuses directories and files with a chunked_write in progress. Reports the size before and after."),
+		mcp.WithString("path",
+			mcp.Description("File to truncate"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("size",
+			mcp.Description("Cut the file to this many bytes (0 empties it); it is never extended"),
+		),
+		mcp.WithNumber("keep_last_bytes",
+			mcp.Description("Keep only the last N bytes; they are copied to a temporary file that atomically replaces path"),
+		),
+		mcp.WithBoolean("align_to_line",
+			mcp.Description("With keep_last_bytes: drop the partial first line so the kept part starts at a line boundary (default: false)"),
+		),
+		mcp.WithNumber("zero_offset",
+			mcp.Description("Overwrite zero_length bytes with zeros starting at this offset, keeping the file size"),
+		),
+		mcp.WithNumber("zero_length",
+			mcp.Description("Bytes to zero from zero_offset; the range must be inside the file"),
+		),
+	), toolDestructiveIdempotent, h.handleTruncateFile)
 
-	s.AddTool(mcp.NewTool(
+	addTool(mcp.NewTool(
 		"write_file_safe",
 		mcp.WithDescription("Safe file write with atomic operation and optional backup."),
 		mcp.WithString("path",
@@ -400,7 +1369,163 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 		mcp.WithBoolean("create_backup",
 			mcp.Description("Create backup before writing (default: false)"),
 		),
-	), h.handleWriteFileSafe)
+		mcp.WithString("expected_sha256",
+			mcp.Description("Optional SHA256 (hex) the written content must match before the file is replaced"),
+		),
+	), toolDestructive, h.handleWriteFileSafe)
+
+	addTool(mcp.NewTool(
+		"render_template",
+		mcp.WithDescription("Render a Go text/template (inline or from a file) with the given variables and write the result atomically. Missing variables are errors. Use dry_run to see the rendered content without writing."),
+		mcp.WithString("template",
+			mcp.Description("Inline template text, e.g. \"package {{.Package}}\" (use this or template_path)"),
+		),
+		mcp.WithString("template_path",
+			mcp.Description("Template file to render (use this or template; for directories use render_tree)"),
+		),
+		mcp.WithObject("variables",
+			mcp.Description("Values available to the template as {{.Name}}"),
+		),
+		mcp.WithString("output_path",
+			mcp.Description("File to write; optional with dry_run"),
+		),
+		mcp.WithBoolean("overwrite",
+			mcp.Description("Replace output_path if it exists (default: false)"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Return the rendered content without writing (default: false)"),
+		),
+	), toolDestructive, h.handleRenderTemplate)
+
+	addTool(mcp.NewTool(
+		"render_tree",
+		mcp.WithDescription("Scaffold a directory from a template directory: every file is rendered with Go text/template, {{.Var}} placeholders in file and directory names are rendered too, and a trailing .tmpl extension is dropped. Everything is rendered before anything is written; existing files are refused unless overwrite is set."),
+		mcp.WithString("template_path",
+			mcp.Description("Directory of templates"),
+			mcp.Required(),
+		),
+		mcp.WithObject("variables",
+			mcp.Description("Values available to the templates and file names as {{.Name}}"),
+		),
+		mcp.WithString("output_path",
+			mcp.Description("Directory to create the rendered files in (outside template_path)"),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("overwrite",
+			mcp.Description("Replace files that already exist (default: false)"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Return the rendered files and their content without writing (default: false)"),
+		),
+	), toolDestructive, h.handleRenderTree)
+
+	addTool(mcp.NewTool(
+		"scaffold",
+		mcp.WithDescription("Create the conventional file skeleton of a new project in a directory: 'go-cli' (go.mod, cmd/main.go, .gitignore, README.md), 'go-lib' (go.mod, <package>.go, .gitignore, README.md), 'node-ts' (package.json, tsconfig.json, src/index.ts, .gitignore) or 'python-pkg' (pyproject.toml, src/<package>/__init__.py, .gitignore), plus any template sets the server adds. Existing files are refused unless force is set. Returns the created files as resources."),
+		mcp.WithString("path",
+			mcp.Description("Directory to create the project in; created if missing"),
+			mcp.Required(),
+		),
+		mcp.WithString("kind",
+			mcp.Description("Project kind: 'go-cli', 'go-lib', 'node-ts', 'python-pkg' or a server-provided kind"),
+			mcp.Required(),
+		),
+		mcp.WithString("name",
+			mcp.Description("Project name (default: last element of module, else the directory name)"),
+		),
+		mcp.WithString("module",
+			mcp.Description("Go module path for go.mod (default: the name)"),
+		),
+		mcp.WithObject("variables",
+			mcp.Description("Extra values for the templates; name, module and package (the name as an identifier) are always set"),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("Overwrite files that already exist (default: false)"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Return the files and their content without writing (default: false)"),
+		),
+	), toolDestructive, h.handleScaffold)
 
-	return s, nil
+	addTool(mcp.NewTool(
+		"get_frontmatter",
+		mcp.WithDescription("Read the YAML front-matter of a Markdown file (the leading --- block) as key/value pairs in file order, plus the first H1 and the heading outline with line numbers. Reports cleanly when there is no front-matter."),
+		mcp.WithString("path",
+			mcp.Description("Markdown file"),
+			mcp.Required(),
+		),
+	), toolReadOnly, h.handleGetFrontmatter)
+
+	addTool(mcp.NewTool(
+		"set_frontmatter",
+		mcp.WithDescription("Set or delete top-level keys in the YAML front-matter of a Markdown file. Existing keys keep their order, new keys are appended, and the body is left byte-for-byte unchanged (CRLF and a missing final newline included). Creates the block if missing and removes it when no keys are left. Written atomically."),
+		mcp.WithString("path",
+			mcp.Description("Markdown file"),
+			mcp.Required(),
+		),
+		mcp.WithObject("set",
+			mcp.Description("Keys to add or replace, e.g. {\"draft\": false, \"tags\": [\"go\"]}"),
+		),
+		mcp.WithArray("delete",
+			mcp.Description("Keys to remove"),
+		),
+	), toolDestructiveIdempotent, h.handleSetFrontmatter)
+
+	for _, name := range append(append([]string{}, h.allowTools...), h.denyTools...) {
+		if !containsString(toolNames, name) {
+			return nil, nil, fmt.Errorf("unknown tool in policy: %s", name)
+		}
+	}
+	for _, name := range cfg.tools {
+		if !containsString(toolNames, name) {
+			return nil, nil, fmt.Errorf("unknown tool in WithTools: %s", name)
+		}
+	}
+
+	// Nothing is registered until the options are known to be valid, so an
+	// error leaves a server passed with WithExistingServer untouched
+	s := cfg.server
+	if s == nil {
+		s = server.NewMCPServer(
+			"secure-filesystem-server",
+			Version,
+			server.WithResourceCapabilities(true, true),
+		)
+	}
+
+	// Register resource handlers: one resource per allowed directory, kept in
+	// sync with runtime changes, plus a template for any file under them
+	var rootsMu sync.Mutex
+	rootURIs := make(map[string]bool)
+	syncRootResources := func() {
+		rootsMu.Lock()
+		defer rootsMu.Unlock()
+		current := make(map[string]bool)
+		for _, resource := range h.rootResources() {
+			current[resource.URI] = true
+			if !rootURIs[resource.URI] {
+				s.AddResource(resource, h.handleReadResource)
+			}
+		}
+		for uri := range rootURIs {
+			if !current[uri] {
+				s.RemoveResource(uri)
+			}
+		}
+		rootURIs = current
+	}
+	syncRootResources()
+	h.rootsChanged = syncRootResources
+
+	s.AddResourceTemplate(mcp.NewResourceTemplate(
+		"file://{+path}",
+		"File System",
+		mcp.WithTemplateDescription("Files and directories under the allowed directories, as percent-encoded file:// URIs. Directories accept ?depth=N&glob=*.go&format=json"),
+	), h.handleReadResource)
+
+	if cfg.toolPrefix != "" {
+		prefixToolReferences(tools, toolNames, cfg.toolPrefix)
+	}
+	s.AddTools(tools...)
+	return s, h, nil
 }
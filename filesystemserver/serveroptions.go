@@ -0,0 +1,123 @@
+package filesystemserver
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Option configures NewServer. Options that shape the handler itself (limits,
+// read-only mode, deny patterns...) are HandlerOptions, passed through
+// WithHandlerOptions.
+type Option func(*serverConfig) error
+
+// serverConfig collects the Options given to NewServer
+type serverConfig struct {
+	allowedDirs []string
+	handlerOpts []HandlerOption
+	tools       []string
+	toolPrefix  string
+	server      *server.MCPServer
+}
+
+// WithAllowedDirectories adds directories the tools may access
+func WithAllowedDirectories(dirs ...string) Option {
+	return func(c *serverConfig) error {
+		c.allowedDirs = append(c.allowedDirs, dirs...)
+		return nil
+	}
+}
+
+// WithHandlerOptions configures the handler behind the tools
+func WithHandlerOptions(opts ...HandlerOption) Option {
+	return func(c *serverConfig) error {
+		c.handlerOpts = append(c.handlerOpts, opts...)
+		return nil
+	}
+}
+
+// WithTools registers only the named tools. The tool policy still applies, so
+// a tool named here but hidden by read-only mode or the deny list stays
+// hidden; unknown names make NewServer fail.
+func WithTools(names ...string) Option {
+	return func(c *serverConfig) error {
+		c.tools = append(c.tools, names...)
+		return nil
+	}
+}
+
+// WithToolPrefix registers every tool as prefix+name, e.g. "fs_read_file", so
+// the tools don't clash with others on a shared server. Descriptions, hints
+// and results that point at another tool name it with the prefix too;
+// WithTools, the tool policy and the logs use the plain names.
+func WithToolPrefix(prefix string) Option {
+	return func(c *serverConfig) error {
+		if strings.ContainsAny(prefix, " \t\r\n") {
+			return errors.New("tool prefix cannot contain whitespace")
+		}
+		c.toolPrefix = prefix
+		return nil
+	}
+}
+
+// WithExistingServer registers the tools and the file:// resources on s
+// instead of creating a new server. s should have resource capabilities
+// enabled for the resources to be listed.
+func WithExistingServer(s *server.MCPServer) Option {
+	return func(c *serverConfig) error {
+		if s == nil {
+			return errors.New("existing server cannot be nil")
+		}
+		c.server = s
+		return nil
+	}
+}
+
+// selects reports whether WithTools leaves name in
+func (c *serverConfig) selects(name string) bool {
+	return len(c.tools) == 0 || containsString(c.tools, name)
+}
+
+// toolName is name as clients call it, for messages that point at another tool
+func (fs *FilesystemHandler) toolName(name string) string {
+	return fs.toolPrefix + name
+}
+
+// prefixToolReferences adds prefix to the tool names mentioned in the
+// descriptions of tools. Only names with an underscore are rewritten, as
+// "tree" or "scan" are also plain words, and single-quoted names are argument
+// values ('code_stats' is a report section) rather than references.
+func prefixToolReferences(tools []server.ServerTool, names []string, prefix string) {
+	var referable []string
+	for _, name := range names {
+		if strings.Contains(name, "_") {
+			referable = append(referable, regexp.QuoteMeta(name))
+		}
+	}
+	// Longest first, so read_file_lines isn't taken for read_file
+	sort.Slice(referable, func(i, j int) bool { return len(referable[i]) > len(referable[j]) })
+	pattern := regexp.MustCompile(`'?\b(` + strings.Join(referable, "|") + `)\b`)
+	rewrite := func(text string) string {
+		return pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if strings.HasPrefix(match, "'") {
+				return match
+			}
+			return prefix + match
+		})
+	}
+
+	for i := range tools {
+		tool := &tools[i].Tool
+		tool.Description = rewrite(tool.Description)
+		for _, raw := range tool.InputSchema.Properties {
+			if property, ok := raw.(map[string]interface{}); ok {
+				if description, ok := property["description"].(string); ok {
+					property["description"] = rewrite(description)
+				}
+			}
+		}
+	}
+}
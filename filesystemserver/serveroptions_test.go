@@ -0,0 +1,124 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHostServer - Servidor de otra aplicación con una herramienta propia
+func newHostServer() *server.MCPServer {
+	host := server.NewMCPServer("host", "1.0.0", server.WithResourceCapabilities(true, true))
+	host.AddTool(mcp.NewTool("hello"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("hi"), nil
+	})
+	return host
+}
+
+func TestNewServerOnExistingServerWithPrefix(t *testing.T) {
+	dir := t.TempDir()
+	host := newHostServer()
+
+	s, handler, err := NewServer(
+		WithAllowedDirectories(dir),
+		WithExistingServer(host),
+		WithTools("read_file", "write_file", "list_directory", "split_cleanup"),
+		WithToolPrefix("fs_"),
+	)
+	require.NoError(t, err)
+	require.NotNil(t, handler)
+	assert.Same(t, host, s)
+	assert.ElementsMatch(t, []string{"hello", "fs_read_file", "fs_write_file", "fs_list_directory", "fs_split_cleanup"}, registeredTools(t, host))
+
+	// Las descripciones nombran las demás herramientas con el prefijo
+	var listed struct {
+		Tools []mcp.Tool `json:"tools"`
+	}
+	callServer(t, host, "tools/list", map[string]interface{}{}, &listed)
+	descriptions := make(map[string]string)
+	for _, tool := range listed.Tools {
+		descriptions[tool.Name] = tool.Description
+	}
+	assert.Contains(t, descriptions["fs_split_cleanup"], "created by fs_split_file")
+
+	path := filepath.Join(dir, "notes.txt")
+	var result struct {
+		IsError bool `json:"isError"`
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	callServer(t, host, "tools/call", map[string]interface{}{
+		"name": "fs_write_file", "arguments": map[string]interface{}{"path": path, "content": "embedded"},
+	}, &result)
+	require.False(t, result.IsError, result.Content)
+	callServer(t, host, "tools/call", map[string]interface{}{
+		"name": "fs_read_file", "arguments": map[string]interface{}{"path": path},
+	}, &result)
+	require.False(t, result.IsError, result.Content)
+	assert.Equal(t, "embedded", result.Content[0].Text)
+
+	// La política y los errores usan el nombre sin prefijo
+	callServer(t, host, "tools/call", map[string]interface{}{
+		"name": "fs_read_file", "arguments": map[string]interface{}{"path": filepath.Join(dir, "missing.txt")},
+	}, &result)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "[E_NOT_FOUND] ")
+	assert.Contains(t, result.Content[0].Text, "fs_list_directory shows what it contains")
+
+	// Sin prefijo la herramienta no existe
+	data, err := json.Marshal(host.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"read_file","arguments":{}}}`)))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"error"`)
+
+	// Los recursos file:// también se registran en el servidor existente
+	var resources struct {
+		Resources []mcp.Resource `json:"resources"`
+	}
+	callServer(t, host, "resources/list", map[string]interface{}{}, &resources)
+	assert.NotEmpty(t, resources.Resources)
+}
+
+func TestPrefixToolReferences(t *testing.T) {
+	tools := []server.ServerTool{{Tool: mcp.NewTool("generate_report",
+		mcp.WithDescription("Uses read_file_lines and read_file, not the tree or a scan."),
+		mcp.WithArray("sections", mcp.Description("Sections: ['overview', 'code_stats']; see code_stats")),
+	)}}
+	prefixToolReferences(tools, []string{"read_file", "read_file_lines", "code_stats", "tree", "scan"}, "fs_")
+
+	assert.Equal(t, "Uses fs_read_file_lines and fs_read_file, not the tree or a scan.", tools[0].Tool.Description)
+	property := tools[0].Tool.InputSchema.Properties["sections"].(map[string]interface{})
+	assert.Equal(t, "Sections: ['overview', 'code_stats']; see fs_code_stats", property["description"])
+}
+
+func TestWithToolsKeepsPolicy(t *testing.T) {
+	dir := t.TempDir()
+	s, _, err := NewServer(
+		WithAllowedDirectories(dir),
+		WithHandlerOptions(WithReadOnly(true)),
+		WithTools("read_file", "write_file"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"read_file"}, registeredTools(t, s))
+}
+
+func TestNewServerOptionErrors(t *testing.T) {
+	dir := t.TempDir()
+	host := newHostServer()
+
+	_, _, err := NewServer(WithAllowedDirectories(dir), WithExistingServer(host), WithTools("read_file", "nope"))
+	assert.EqualError(t, err, "unknown tool in WithTools: nope")
+	// Un error no deja nada a medias en el servidor existente
+	assert.Equal(t, []string{"hello"}, registeredTools(t, host))
+
+	_, _, err = NewServer(WithAllowedDirectories(dir), WithExistingServer(nil))
+	assert.EqualError(t, err, "existing server cannot be nil")
+	_, _, err = NewServer(WithAllowedDirectories(dir), WithToolPrefix("fs "))
+	assert.EqualError(t, err, "tool prefix cannot contain whitespace")
+}
@@ -0,0 +1,177 @@
+package filesystemserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultShutdownTimeout is how long Shutdown is usually given to drain
+// in-flight tool calls before their temporary files are removed anyway.
+const DefaultShutdownTimeout = 5 * time.Second
+
+// errShuttingDown rejects tool calls that arrive once Shutdown has started
+var errShuttingDown = errors.New("server is shutting down")
+
+// lifecycle tracks what Shutdown has to wait for and clean up. Its zero value
+// is ready to use, so handlers built without NewFilesystemHandler work too.
+type lifecycle struct {
+	mu       sync.Mutex
+	ctx      context.Context // cancelled by Shutdown; created on first use
+	cancel   context.CancelFunc
+	closing  bool
+	active   int
+	inflight sync.WaitGroup
+	temps    map[string]int    // temporary file -> number of registrations
+	sessions map[string]func() // chunked_write target -> release of its temp file
+}
+
+// lifetime returns the handler-level context that Shutdown cancels. The caller
+// must hold life.mu.
+func (l *lifecycle) lifetime() context.Context {
+	if l.ctx == nil {
+		l.ctx, l.cancel = context.WithCancel(context.Background())
+	}
+	return l.ctx
+}
+
+// beginCall counts a tool call as in flight until the returned function runs.
+// The returned context is ctx, also cancelled when the handler shuts down.
+func (fs *FilesystemHandler) beginCall(ctx context.Context) (context.Context, func(), error) {
+	fs.life.mu.Lock()
+	if fs.life.closing {
+		fs.life.mu.Unlock()
+		return nil, nil, errShuttingDown
+	}
+	lifetime := fs.life.lifetime()
+	fs.life.active++
+	fs.life.inflight.Add(1)
+	fs.life.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(lifetime, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+		fs.life.mu.Lock()
+		fs.life.active--
+		fs.life.mu.Unlock()
+		fs.life.inflight.Done()
+	}, nil
+}
+
+// trackTemp registers a temporary file that Shutdown removes if it still
+// exists. The returned function drops the registration once the file has been
+// renamed into place or removed by its owner.
+func (fs *FilesystemHandler) trackTemp(path string) func() {
+	fs.life.mu.Lock()
+	defer fs.life.mu.Unlock()
+	if fs.life.temps == nil {
+		fs.life.temps = make(map[string]int)
+	}
+	fs.life.temps[path]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			fs.life.mu.Lock()
+			defer fs.life.mu.Unlock()
+			if fs.life.temps == nil {
+				return // already cleaned up by Shutdown
+			}
+			if fs.life.temps[path]--; fs.life.temps[path] <= 0 {
+				delete(fs.life.temps, path)
+			}
+		})
+	}
+}
+
+// startChunkSession registers the temporary file of a chunked write to target,
+// replacing any session left behind by an earlier write to the same target.
+func (fs *FilesystemHandler) startChunkSession(target, tempPath string) {
+	release := fs.trackTemp(tempPath)
+	fs.life.mu.Lock()
+	if fs.life.sessions == nil {
+		fs.life.sessions = make(map[string]func())
+	}
+	previous := fs.life.sessions[target]
+	fs.life.sessions[target] = release
+	fs.life.mu.Unlock()
+	if previous != nil {
+		previous()
+	}
+}
+
+// endChunkSession forgets the chunked write to target once its temporary file
+// has been renamed into place.
+func (fs *FilesystemHandler) endChunkSession(target string) {
+	fs.life.mu.Lock()
+	release := fs.life.sessions[target]
+	delete(fs.life.sessions, target)
+	fs.life.mu.Unlock()
+	if release != nil {
+		release()
+	}
+}
+
+// hasChunkSession reports whether a chunked write to target is in progress
+func (fs *FilesystemHandler) hasChunkSession(target string) bool {
+	fs.life.mu.Lock()
+	defer fs.life.mu.Unlock()
+	_, ok := fs.life.sessions[target]
+	return ok
+}
+
+// Shutdown stops accepting tool calls, cancels the context of the ones in
+// flight and waits up to timeout for them to return. Temporary files still
+// registered afterwards (unfinished chunked writes, safe-write temps of calls
+// that did not stop in time) are removed. It returns an error if some calls
+// were still running or a temporary file could not be removed.
+func (fs *FilesystemHandler) Shutdown(timeout time.Duration) error {
+	fs.life.mu.Lock()
+	fs.life.closing = true
+	fs.life.lifetime()
+	fs.life.cancel()
+	fs.life.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		fs.life.inflight.Wait()
+		close(drained)
+	}()
+
+	var errs []error
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-drained:
+	case <-timer.C:
+		fs.life.mu.Lock()
+		active := fs.life.active
+		fs.life.mu.Unlock()
+		errs = append(errs, fmt.Errorf("timed out after %s waiting for %d in-flight operations", timeout, active))
+	}
+
+	fs.life.mu.Lock()
+	temps := make([]string, 0, len(fs.life.temps))
+	for path := range fs.life.temps {
+		temps = append(temps, path)
+	}
+	fs.life.temps = nil
+	fs.life.sessions = nil
+	fs.life.mu.Unlock()
+
+	for _, path := range temps {
+		err := os.Remove(path)
+		switch {
+		case err == nil:
+			fs.log().Info("removed temporary file on shutdown", "path", path)
+		case !os.IsNotExist(err):
+			errs = append(errs, fmt.Errorf("removing %s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,154 @@
+package filesystemserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tempFilesIn(t *testing.T, dir string) []string {
+	t.Helper()
+	var found []string
+	require.NoError(t, filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && strings.HasSuffix(path, ".tmp") {
+			found = append(found, path)
+		}
+		return err
+	}))
+	return found
+}
+
+func TestChunkedWriteCompletes(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	target := filepath.Join(dir, "big.txt")
+
+	for i, chunk := range []string{"one ", "two ", "three"} {
+		result, err := handler.handleChunkedWrite(context.Background(), newToolRequest("chunked_write", map[string]interface{}{
+			"path":         target,
+			"content":      chunk,
+			"chunk_index":  float64(i),
+			"total_chunks": float64(3),
+		}))
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		if i < 2 {
+			// El destino no aparece hasta el último fragmento
+			_, err := os.Stat(target)
+			assert.True(t, os.IsNotExist(err))
+		}
+	}
+
+	data, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "one two three", string(data))
+	assert.Empty(t, tempFilesIn(t, dir))
+
+	result, err := handler.handleChunkedWrite(context.Background(), newToolRequest("chunked_write", map[string]interface{}{
+		"path":         filepath.Join(dir, "never-started.txt"),
+		"content":      "x",
+		"chunk_index":  float64(1),
+		"total_chunks": float64(2),
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "start again with chunk_index 0")
+}
+
+func TestShutdownMidChunkedWriteRemovesTempFiles(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	target := filepath.Join(dir, "big.txt")
+	write := handler.guardTool("chunked_write", handler.handleChunkedWrite)
+
+	result, err := write(context.Background(), newToolRequest("chunked_write", map[string]interface{}{
+		"path":         target,
+		"content":      "first chunk",
+		"chunk_index":  float64(0),
+		"total_chunks": float64(3),
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.Len(t, tempFilesIn(t, dir), 1)
+
+	require.NoError(t, handler.Shutdown(time.Second))
+	assert.Empty(t, tempFilesIn(t, dir))
+	_, err = os.Stat(target)
+	assert.True(t, os.IsNotExist(err))
+
+	// Después del apagado no se aceptan más llamadas
+	result, err = write(context.Background(), newToolRequest("chunked_write", map[string]interface{}{
+		"path":         target,
+		"content":      "second chunk",
+		"chunk_index":  float64(1),
+		"total_chunks": float64(3),
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "shutting down")
+	assert.Empty(t, tempFilesIn(t, dir))
+}
+
+func TestShutdownDrainsInFlightCalls(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	temp := filepath.Join(dir, "work.tmp")
+
+	started := make(chan struct{})
+	finished := make(chan struct{})
+	slow := handler.guardTool("write_file", func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		defer handler.trackTemp(temp)()
+		require.NoError(t, os.WriteFile(temp, []byte("partial"), 0644))
+		close(started)
+		<-ctx.Done()
+		os.Remove(temp)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{mcp.TextContent{Type: "text", Text: "❌ Error: cancelled"}},
+			IsError: true,
+		}, nil
+	})
+
+	go func() {
+		slow(context.Background(), newToolRequest("write_file", map[string]interface{}{"path": temp}))
+		close(finished)
+	}()
+	<-started
+
+	require.NoError(t, handler.Shutdown(time.Second))
+	<-finished
+	assert.Empty(t, tempFilesIn(t, dir))
+}
+
+func TestShutdownTimesOutAndRemovesTemps(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	temp := filepath.Join(dir, "stuck.tmp")
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	stuck := handler.guardTool("write_file", func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		defer handler.trackTemp(temp)()
+		require.NoError(t, os.WriteFile(temp, []byte("partial"), 0644))
+		close(started)
+		<-unblock // ignores cancellation
+		return &mcp.CallToolResult{}, nil
+	})
+
+	finished := make(chan struct{})
+	go func() {
+		stuck(context.Background(), newToolRequest("write_file", map[string]interface{}{"path": temp}))
+		close(finished)
+	}()
+	<-started
+
+	err := handler.Shutdown(50 * time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 in-flight operations")
+	assert.Empty(t, tempFilesIn(t, dir))
+
+	close(unblock)
+	<-finished
+}
@@ -0,0 +1,126 @@
+package filesystemserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// toolOutputTypes names the Go type (see types.go) each tool returns as
+// structured content. The mcp-go version in use has no outputSchema or
+// structuredContent fields, so the payload travels as the last content item:
+// an embedded application/json resource after the human-readable text.
+var toolOutputTypes = map[string]string{
+	"tree":                   "FileNode",
+	"get_file_info":          "FileInfo",
+	"get_multiple_file_info": "MultipleFileInfo",
+	"find_duplicates":        "DuplicateReport",
+	"find_duplicate_code":    "DuplicateCodeReport",
+	"analyze_project":        "ProjectStructure",
+	"code_stats":             "CodeStatsReport",
+	"compare_files":          "FileDiff (DirectoryDiff for directories)",
+	"batch_operations":       "BatchResult",
+	"create_archive":         "ArchiveResult",
+	"git_info":               "GitInfo",
+	"csv_query":              "CSVQueryResult",
+	"log_query":              "LogQueryResult",
+	"tail_follow":            "TailFollowResult",
+	"render_template":        "RenderResult",
+	"render_tree":            "RenderResult",
+	"scaffold":               "ScaffoldResult",
+	"get_frontmatter":        "FrontmatterInfo",
+	"classify_files":         "FileClassification",
+	"normalize_file":         "NormalizeResult",
+	"analyze_lines":          "LineAnalysis",
+	"detect_changes":         "ChangeReport",
+	"recent_activity":        "RecentActivity",
+	"workspace_context":      "WorkspaceContext",
+	"smart_sync":             "SyncReport",
+	"resolve_sync_conflict":  "SyncResolution",
+	"get_config":             "HandlerOptions",
+	"get_ignore_rules":       "IgnoreRules",
+	"health_check":           "HealthReport",
+	"find_name_collisions":   "NameCollisionReport",
+	"fix_permissions":        "PermissionFixReport",
+}
+
+// describeOutput appends the structured output note to a tool description
+func describeOutput(tool mcp.Tool) mcp.Tool {
+	if outputType, ok := toolOutputTypes[tool.Name]; ok {
+		tool.Description += fmt.Sprintf(" Structured output: %s as JSON in the last content item (application/json resource).", outputType)
+	}
+	return tool
+}
+
+// withStructuredContent appends value as an application/json resource,
+// keeping the text first so clients that only read it are unaffected
+func withStructuredContent(result *mcp.CallToolResult, uri string, value interface{}) (*mcp.CallToolResult, error) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	result.Content = append(result.Content, mcp.EmbeddedResource{
+		Type: "resource",
+		Resource: mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: "application/json",
+			Text:     string(data),
+		},
+	})
+	return result, nil
+}
+
+// directoryMimeType is the MIME type embedded directory resources carry, the
+// freedesktop.org convention for directories.
+const directoryMimeType = "inode/directory"
+
+// newFileResource references the file or directory at path so clients can
+// fetch it with resources/read. Files carry their detected MIME type and
+// directories inode/directory with a trailing-slash URI, matching the roots in
+// resources/list. The text is left empty: the resource points at the content
+// rather than summarizing it.
+func newFileResource(path string) mcp.EmbeddedResource {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return newResourceRef(dirResourceURI(path), directoryMimeType)
+	}
+	return newResourceRef(pathToResourceURI(path), detectMimeType(path))
+}
+
+// maxFileResources is how many file resources withFileResources embeds in one
+// result; the rest are only counted in the text.
+const maxFileResources = 10
+
+// withFileResources appends a newFileResource for each of paths, the files an
+// operation created or modified, up to maxFileResources. When there are more,
+// the first text item says how many were left out. Call it before
+// withStructuredContent so the JSON payload stays the last item.
+func withFileResources(result *mcp.CallToolResult, paths []string) *mcp.CallToolResult {
+	for i, path := range paths {
+		if i == maxFileResources {
+			break
+		}
+		result.Content = append(result.Content, newFileResource(path))
+	}
+	if extra := len(paths) - maxFileResources; extra > 0 {
+		for i, content := range result.Content {
+			if text, ok := content.(mcp.TextContent); ok {
+				text.Text += fmt.Sprintf("\n📎 ... and %d more file(s) not attached as resources", extra)
+				result.Content[i] = text
+				break
+			}
+		}
+	}
+	return result
+}
+
+// fileResourceURI is the URI newFileResource gives path
+func fileResourceURI(path string) string {
+	return newFileResource(path).Resource.(mcp.TextResourceContents).URI
+}
+
+// newResourceRef is an embedded resource that only names uri and its MIME type.
+func newResourceRef(uri, mimeType string) mcp.EmbeddedResource {
+	return mcp.NewEmbeddedResource(mcp.TextResourceContents{URI: uri, MIMEType: mimeType})
+}
@@ -0,0 +1,199 @@
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeStructured unmarshals the structured payload (last content item) into out
+func decodeStructured(t *testing.T, result *mcp.CallToolResult, out interface{}) {
+	t.Helper()
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	require.GreaterOrEqual(t, len(result.Content), 2)
+	_, isText := result.Content[0].(mcp.TextContent)
+	assert.True(t, isText, "text must stay the first content item")
+
+	embedded, ok := result.Content[len(result.Content)-1].(mcp.EmbeddedResource)
+	require.True(t, ok)
+	resource, ok := embedded.Resource.(mcp.TextResourceContents)
+	require.True(t, ok)
+	require.Equal(t, "application/json", resource.MIMEType)
+	require.NoError(t, json.Unmarshal([]byte(resource.Text), out))
+}
+
+// fileResourceURIs returns the URIs of the embedded resources in result that
+// are not a JSON payload, in order
+func fileResourceURIs(result *mcp.CallToolResult) []string {
+	var uris []string
+	for _, content := range result.Content {
+		if embedded, ok := content.(mcp.EmbeddedResource); ok {
+			if resource := embedded.Resource.(mcp.TextResourceContents); resource.MIMEType != "application/json" {
+				uris = append(uris, resource.URI)
+			}
+		}
+	}
+	return uris
+}
+
+func TestStructuredContent(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	ctx := context.Background()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("same content\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("same content\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.txt"), []byte("other content\n"), 0644))
+
+	call := func(handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), name string, args map[string]interface{}) *mcp.CallToolResult {
+		result, err := handle(ctx, newToolRequest(name, args))
+		require.NoError(t, err)
+		return result
+	}
+
+	var tree FileNode
+	decodeStructured(t, call(handler.handleTree, "tree", map[string]interface{}{"path": dir, "relative_to": "absolute"}), &tree)
+	assert.Equal(t, dir, tree.Path)
+	assert.Equal(t, "directory", tree.Type)
+
+	var info FileInfo
+	decodeStructured(t, call(handler.handleGetFileInfo, "get_file_info", map[string]interface{}{"path": filepath.Join(dir, "a.txt")}), &info)
+	assert.Equal(t, int64(13), info.Size)
+	assert.True(t, info.IsFile)
+
+	var duplicates DuplicateReport
+	decodeStructured(t, call(handler.handleFindDuplicates, "find_duplicates", map[string]interface{}{"path": dir}), &duplicates)
+	require.Len(t, duplicates.Groups, 1)
+	assert.Len(t, duplicates.Groups[0], 2)
+	assert.Equal(t, "report", duplicates.Action)
+
+	var project ProjectStructure
+	decodeStructured(t, call(handler.handleAnalyzeProject, "analyze_project", map[string]interface{}{"path": dir}), &project)
+	assert.Equal(t, 4, project.TotalFiles)
+
+	var diff FileDiff
+	decodeStructured(t, call(handler.handleCompareFiles, "compare_files", map[string]interface{}{
+		"file1": filepath.Join(dir, "a.txt"),
+		"file2": filepath.Join(dir, "c.txt"),
+	}), &diff)
+	assert.Equal(t, []string{"other content"}, diff.Added)
+
+	var batch BatchResult
+	decodeStructured(t, call(handler.handleBatchEdit, "batch_operations", map[string]interface{}{
+		"operations": []interface{}{
+			map[string]interface{}{"type": "mkdir", "path": filepath.Join(dir, "out")},
+			map[string]interface{}{"type": "copy", "from": filepath.Join(dir, "missing.txt"), "to": filepath.Join(dir, "out", "copy.txt")},
+		},
+	}), &batch)
+	assert.Equal(t, 1, batch.Successful)
+	assert.Equal(t, 1, batch.Failed)
+	require.Len(t, batch.Operations, 2)
+	assert.True(t, batch.Operations[0].Success)
+	assert.Equal(t, "mkdir", batch.Operations[0].Type)
+	assert.Equal(t, "copy", batch.Operations[1].Type)
+	assert.Contains(t, batch.Operations[1].Error, "copy failed")
+}
+
+func TestStructuredOutputDescriptions(t *testing.T) {
+	s, err := NewFilesystemServer([]string{t.TempDir()})
+	require.NoError(t, err)
+
+	response := s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	data, err := json.Marshal(response)
+	require.NoError(t, err)
+	var decoded struct {
+		Result struct {
+			Tools []mcp.Tool `json:"tools"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	described := 0
+	for _, tool := range decoded.Result.Tools {
+		if outputType, ok := toolOutputTypes[tool.Name]; ok {
+			assert.True(t, strings.HasSuffix(tool.Description, "Structured output: "+outputType+" as JSON in the last content item (application/json resource)."), tool.Name)
+			described++
+		}
+	}
+	assert.Equal(t, len(toolOutputTypes), described)
+}
+
+func TestEmbeddedFileResources(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	writeFixture(t, dir, map[string]string{"pkg/index.html": "<!DOCTYPE html><html><body>hi</body></html>\n"})
+	file := filepath.Join(dir, "pkg", "index.html")
+	pkg := filepath.Join(dir, "pkg")
+
+	// resourceOf returns the first embedded resource that is not a JSON payload
+	resourceOf := func(name string, call func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]interface{}) mcp.TextResourceContents {
+		t.Helper()
+		result, err := call(context.Background(), newToolRequest(name, args))
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		for _, content := range result.Content {
+			if embedded, ok := content.(mcp.EmbeddedResource); ok {
+				resource := embedded.Resource.(mcp.TextResourceContents)
+				if resource.MIMEType != "application/json" {
+					return resource
+				}
+			}
+		}
+		t.Fatalf("%s returned no file resource", name)
+		return mcp.TextResourceContents{}
+	}
+
+	for _, tt := range []struct {
+		name     string
+		resource mcp.TextResourceContents
+	}{
+		{"write_file", resourceOf("write_file", handler.handleWriteFile, map[string]interface{}{"path": file, "content": "<!DOCTYPE html><html><body>hi</body></html>\n"})},
+		{"get_file_info", resourceOf("get_file_info", handler.handleGetFileInfo, map[string]interface{}{"path": file})},
+		{"copy_file", resourceOf("copy_file", handler.handleCopyFile, map[string]interface{}{"source": file, "destination": filepath.Join(dir, "copy.html")})},
+	} {
+		assert.True(t, strings.HasPrefix(tt.resource.MIMEType, "text/html"), "%s: %s", tt.name, tt.resource.MIMEType)
+		assert.Empty(t, tt.resource.Text, tt.name)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		resource mcp.TextResourceContents
+	}{
+		{"read_file", resourceOf("read_file", handler.handleReadFile, map[string]interface{}{"path": pkg})},
+		{"list_directory", resourceOf("list_directory", handler.handleListDirectory, map[string]interface{}{"path": pkg})},
+		{"create_directory", resourceOf("create_directory", handler.handleCreateDirectory, map[string]interface{}{"path": pkg})},
+	} {
+		assert.Equal(t, directoryMimeType, tt.resource.MIMEType, tt.name)
+		assert.Equal(t, dirResourceURI(pkg), tt.resource.URI, tt.name)
+		assert.Empty(t, tt.resource.Text, tt.name)
+	}
+}
+
+func TestWithFileResourcesCap(t *testing.T) {
+	_, dir := newTestHandler(t)
+	var paths []string
+	for i := 0; i < maxFileResources+3; i++ {
+		paths = append(paths, filepath.Join(dir, fmt.Sprintf("f%02d.txt", i)))
+	}
+
+	result := withFileResources(&mcp.CallToolResult{
+		Content: []mcp.Content{mcp.TextContent{Type: "text", Text: "done"}},
+	}, paths)
+	uris := fileResourceURIs(result)
+	require.Len(t, uris, maxFileResources)
+	assert.Equal(t, pathToResourceURI(paths[0]), uris[0])
+	assert.Equal(t, "done\n📎 ... and 3 more file(s) not attached as resources", result.Content[0].(mcp.TextContent).Text)
+
+	result = withFileResources(&mcp.CallToolResult{
+		Content: []mcp.Content{mcp.TextContent{Type: "text", Text: "done"}},
+	}, paths[:2])
+	assert.Len(t, fileResourceURIs(result), 2)
+	assert.Equal(t, "done", result.Content[0].(mcp.TextContent).Text)
+}
@@ -0,0 +1,6 @@
+#import <Foundation/Foundation.h>
+
+@interface Greeter : NSObject
+@property (nonatomic, copy) NSString *name;
+- (void)greet;
+@end
@@ -0,0 +1,7 @@
+#import "Greeter.h"
+
+@implementation Greeter
+- (void)greet {
+    NSLog(@"Hello, %@", self.name);
+}
+@end
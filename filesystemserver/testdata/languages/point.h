@@ -0,0 +1,8 @@
+#include <stdio.h>
+
+typedef struct point {
+    int x;
+    int y;
+} point;
+
+void point_print(const point *p);
@@ -0,0 +1,11 @@
+#include <vector>
+
+namespace geo {
+class Shape {
+public:
+    virtual ~Shape() = default;
+    virtual double area() const = 0;
+};
+
+std::vector<Shape*> shapes();
+}
@@ -0,0 +1,5 @@
+function y = square(x)
+% SQUARE returns the element-wise square of x
+  y = x.^2;
+  disp(y);
+end
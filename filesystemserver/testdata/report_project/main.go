@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"example.com/fixture/util"
+)
+
+// main prints a greeting for each argument
+func main() {
+	for _, name := range os.Args[1:] {
+		if name == "" {
+			continue
+		}
+		fmt.Println(util.Greet(name))
+	}
+}
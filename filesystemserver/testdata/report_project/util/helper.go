@@ -0,0 +1,9 @@
+package util
+
+import "strings"
+
+// Greet builds a greeting for name
+func Greet(name string) string {
+	// TODO: localize the greeting
+	return "Hello, " + strings.TrimSpace(name)
+}
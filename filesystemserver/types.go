@@ -1,6 +1,11 @@
 package filesystemserver
 
-import "time"
+import (
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
 
 const (
 	// Maximum size for inline content (5MB)
@@ -20,32 +25,275 @@ type FileInfo struct {
 	IsDirectory bool      `json:"isDirectory"`
 	IsFile      bool      `json:"isFile"`
 	Permissions string    `json:"permissions"`
+	// EstimatedTimes lists "created" and/or "accessed" when the platform does
+	// not report them and the modification time was used instead
+	EstimatedTimes []string `json:"estimatedTimes,omitempty"`
+	// UID, GID, Owner, Group and HardLinks are only reported on unix;
+	// Attributes holds the Windows file attributes (readonly, hidden...)
+	UID           *uint32  `json:"uid,omitempty"`
+	GID           *uint32  `json:"gid,omitempty"`
+	Owner         string   `json:"owner,omitempty"`
+	Group         string   `json:"group,omitempty"`
+	HardLinks     uint64   `json:"hardLinks,omitempty"`
+	IsSymlink     bool     `json:"isSymlink"`
+	SymlinkTarget string   `json:"symlinkTarget,omitempty"`
+	Attributes    []string `json:"attributes,omitempty"`
+	// Xattrs is only filled when requested with include_xattrs; XattrsError
+	// explains why they could not be read
+	Xattrs      []FileXattr `json:"xattrs,omitempty"`
+	XattrsError string      `json:"xattrsError,omitempty"`
+	// Image is set for PNG, JPEG, GIF and WebP files
+	Image *ImageInfo `json:"image,omitempty"`
+}
+
+// MultipleFileInfo is the result of get_multiple_file_info: one entry per
+// requested path, with globs expanded in place, in request order
+type MultipleFileInfo struct {
+	Files   []PathFileInfo          `json:"files"`
+	Summary MultipleFileInfoSummary `json:"summary"`
+}
+
+// PathFileInfo is the FileInfo of one path, or the Error that prevented it.
+// Path is as requested, or the match for a glob; Glob is the pattern it came from.
+type PathFileInfo struct {
+	Path  string `json:"path"`
+	Glob  string `json:"glob,omitempty"`
+	Error string `json:"error,omitempty"`
+	*FileInfo
+}
+
+// MultipleFileInfoSummary aggregates the paths that could be read. TotalSize
+// only counts files.
+type MultipleFileInfoSummary struct {
+	Files       int        `json:"files"`
+	Directories int        `json:"directories"`
+	Errors      int        `json:"errors"`
+	TotalSize   int64      `json:"totalSize"`
+	Newest      string     `json:"newest,omitempty"` // path with the latest modification time
+	NewestMTime *time.Time `json:"newestModified,omitempty"`
+}
+
+// ImageInfo describes an image from its header, without decoding the pixels.
+// A corrupt header only sets Error.
+type ImageInfo struct {
+	Format      string `json:"format,omitempty"` // png, jpeg, gif or webp
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+	ColorModel  string `json:"colorModel,omitempty"`  // e.g. NRGBA, YCbCr, paletted (16 colors)
+	Orientation int    `json:"orientation,omitempty"` // EXIF orientation of a JPEG, 1-8
+	DateTime    string `json:"dateTime,omitempty"`    // EXIF DateTimeOriginal (or DateTime), as written
+	Error       string `json:"error,omitempty"`
+}
+
+// FileXattr is an extended attribute of a file. Values that are not
+// printable UTF-8 are base64 encoded, and long values are truncated.
+type FileXattr struct {
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	Encoding  string `json:"encoding"` // "text" or "base64"
+	Size      int    `json:"size"`
+	Truncated bool   `json:"truncated,omitempty"`
 }
 
 // FileNode represents a node in the file tree
 type FileNode struct {
-	Name     string      `json:"name"`
-	Path     string      `json:"path"`
-	Type     string      `json:"type"` // "file" or "directory"
-	Size     int64       `json:"size,omitempty"`
-	Modified time.Time   `json:"modified,omitempty"`
-	Children []*FileNode `json:"children,omitempty"`
+	Name       string      `json:"name"`
+	NameBase64 string      `json:"name_base64,omitempty"` // raw bytes of Name when not valid UTF-8 (JSON turns them into U+FFFD)
+	Path       string      `json:"path"`
+	Type       string      `json:"type"` // "file" or "directory"
+	Size       int64       `json:"size,omitempty"`
+	Modified   time.Time   `json:"modified,omitempty"`
+	Children   []*FileNode `json:"children,omitempty"`
+	Omitted    int         `json:"omitted,omitempty"` // children left out to fit the response budget
+	Image      *ImageInfo  `json:"image,omitempty"`   // only in the format=json directory resource
 }
 
 // FilesystemHandler manages file system operations
 type FilesystemHandler struct {
-	allowedDirs []string
+	mu           sync.RWMutex // guards allowedDirs, runtimeDirs and rootLabels; see pathlocks.go for the lock order
+	allowedDirs  []string     // replaced, never mutated in place, so readers can keep a copy
+	runtimeDirs  map[string]bool
+	rootLabels   map[string]string // allowed dir -> label shown as label:relative/path
+	rootCounter  int               // last N handed out as a rootN label
+	rootsChanged func()            // set by the server to refresh root resources
+	toolPrefix   string            // set by the server from WithToolPrefix
+
+	allowRuntimeDirs bool     // add_allowed_directory accepts any existing directory
+	grantableRoots   []string // add_allowed_directory accepts directories under these
+
+	readOnly   bool     // reject every tool call that would modify the filesystem
+	allowTools []string // when set, only these tools are registered and callable
+	denyTools  []string // tools that are never registered or callable
+
+	denyPatterns  []string // globs for files that stay inaccessible inside allowed dirs
+	noDefaultDeny bool     // don't prepend defaultDenyPatterns to denyPatterns
+
+	workspace string // relative paths resolve here; defaults to the first allowed dir
+
+	importantPatterns map[string][]string // extra important file globs per category
+
+	logger *slog.Logger // tool calls, rejections and walker errors; never stdout
+	stats  *serverStats // activity counters reported by server_stats
+
+	progressNotify progressNotifyFunc // nil sends MCP progress notifications; tests replace it
+
+	heavySlots   chan struct{} // semaphore for heavyTools; nil means unlimited
+	heavyQueue   bool          // wait for a slot instead of failing fast
+	heavyTimeout time.Duration // longest wait for a slot when queueing
+
+	toolTimeout      time.Duration            // longest single-path tool call; 0 means the 10s default, negative none
+	heavyToolTimeout time.Duration            // same for heavyTools and streamingTools; 0 means the 120s default
+	toolTimeouts     map[string]time.Duration // per-tool timeouts replacing the two above
+
+	maxDecompressed int64 // largest output decompress_file writes; 0 means the 1GB default
+	maxCreateSize   int64 // largest file create_file_of_size makes; 0 means the 10GB default
+	inlineLimit     int   // results over this many bytes go to a report file; 0 means the 128KB default
+	responseLimit   int   // text budget of listing and analysis results; 0 means the 100KB default
+
+	startupHealthCheck bool // log a warning for unhealthy allowed dirs when the handler is created
+
+	generatedMarkers []string // markers of generated files; nil means defaultGeneratedMarkers
+
+	scaffoldDir string // user template sets for scaffold, one subdirectory per kind
+
+	maxFollowDuration time.Duration // longest tail_follow wait; 0 means the 60s default
+
+	life lifecycle // in-flight calls and temporary files drained by Shutdown
+
+	writeLocks pathLocks // per-file locks held by tools that rewrite a file
+
+	workspaces workspaceCache // workspace_context results, dropped by any writing call
+
+	ignores ignoreCache // .mcpignore rules per allowed directory, reloaded when the file changes
+}
+
+// HealthReport represents the result of health_check over the allowed directories
+type HealthReport struct {
+	Status       string       `json:"status"` // "ok", "degraded" or "failed": the worst root
+	ReadOnlyMode bool         `json:"read_only_mode"`
+	Roots        []RootHealth `json:"roots"`
+}
+
+// RootHealth represents the checks run on one allowed directory
+type RootHealth struct {
+	Path          string   `json:"path"`
+	Label         string   `json:"label"`
+	Status        string   `json:"status"`
+	Exists        bool     `json:"exists"`
+	Readable      bool     `json:"readable"`
+	Writable      bool     `json:"writable"`
+	WriteChecked  bool     `json:"write_checked"`           // false in read-only mode, where nothing is written
+	ResolvedPath  string   `json:"resolved_path,omitempty"` // set when the path goes through a symlink
+	FreeBytes     *int64   `json:"free_bytes,omitempty"`
+	CaseSensitive *bool    `json:"case_sensitive,omitempty"`
+	Problems      []string `json:"problems"`
 }
 
 // FileDiff represents the result of file comparison
 type FileDiff struct {
-	File1     string   `json:"file1"`
-	File2     string   `json:"file2"`
-	Similar   float64  `json:"similarity"`
-	Added     []string `json:"added"`
-	Removed   []string `json:"removed"`
-	Modified  []string `json:"modified"`
-	Unchanged int      `json:"unchanged"`
+	File1     string     `json:"file1"`
+	File2     string     `json:"file2"`
+	Similar   float64    `json:"similarity"`
+	Added     []string   `json:"added"`
+	Removed   []string   `json:"removed"`
+	Modified  []string   `json:"modified"`
+	Unchanged int        `json:"unchanged"`
+	Hunks     []DiffHunk `json:"hunks,omitempty"`
+	SHA256    []string   `json:"sha256,omitempty"`    // solo en comparación por hash
+	MD5       []string   `json:"md5,omitempty"`       // compatibilidad con salidas anteriores
+	HashOnly  string     `json:"hash_only,omitempty"` // motivo por el que no hay diff de líneas
+}
+
+// DiffHunk represents a contiguous block of changes with surrounding context
+type DiffHunk struct {
+	OldStart int        `json:"old_start"`
+	OldLines int        `json:"old_lines"`
+	NewStart int        `json:"new_start"`
+	NewLines int        `json:"new_lines"`
+	Lines    []DiffLine `json:"lines"`
+}
+
+// DiffLine represents a single line inside a diff hunk
+type DiffLine struct {
+	Type    string `json:"type"` // "equal", "delete" or "insert"
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+	Text    string `json:"text"`
+	NewText string `json:"new_text,omitempty"` // right-hand text when equal only after normalization
+}
+
+// DirectoryDiff represents the result of comparing two directory trees
+type DirectoryDiff struct {
+	DirA      string               `json:"dir_a"`
+	DirB      string               `json:"dir_b"`
+	OnlyInA   []string             `json:"only_in_a"`
+	OnlyInB   []string             `json:"only_in_b"`
+	Renamed   []DirectoryRename    `json:"renamed"`
+	Different []DirectoryDiffEntry `json:"different"`
+	Identical int                  `json:"identical"`
+	HashUsed  bool                 `json:"hash_used"`
+	Diffs     map[string]string    `json:"diffs,omitempty"`
+}
+
+// DirectoryRename represents a file only in A whose content is at another path only in B
+type DirectoryRename struct {
+	From string `json:"from"` // path in A
+	To   string `json:"to"`   // path in B
+	Size int64  `json:"size"`
+}
+
+// DirectoryDiffEntry represents a path present in both trees whose content differs
+type DirectoryDiffEntry struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"` // "size", "mtime", "content" or "type"
+	SizeA  int64  `json:"size_a"`
+	SizeB  int64  `json:"size_b"`
+}
+
+// SyncReport represents what smart_sync did, or would do in preview mode, to make
+// the target match the source
+type SyncReport struct {
+	Source    string            `json:"source"`
+	Target    string            `json:"target"`
+	Mode      string            `json:"mode"`
+	Applied   bool              `json:"applied"`
+	Copied    []string          `json:"copied"`  // only in the source
+	Updated   []string          `json:"updated"` // in both, replaced with the source version
+	Renamed   []DirectoryRename `json:"renamed"` // moved on the target: from is the target path, to the source path
+	Deleted   []string          `json:"deleted"` // only in the target, removed in overwrite mode
+	Kept      []string          `json:"kept"`    // only in the target, left alone in merge mode
+	Conflicts []SyncConflict    `json:"conflicts"`
+	Unchanged int               `json:"unchanged"`
+	Errors    []string          `json:"errors,omitempty"`
+	Warnings  []WalkWarning     `json:"warnings,omitempty"` // entries skipped because they could not be read
+}
+
+// SyncConflict represents a path smart_sync left alone because the target side also changed
+type SyncConflict struct {
+	ID        string            `json:"id"` // pass to resolve_sync_conflict; stable while neither side changes
+	Path      string            `json:"path"`
+	Reason    string            `json:"reason"`
+	Source    *SyncConflictSide `json:"source,omitempty"` // nil when the side could not be read
+	Target    *SyncConflictSide `json:"target,omitempty"`
+	Diff      string            `json:"diff,omitempty"`       // unified diff from target to source, first hunks only, for small text files
+	DiffHunks int               `json:"diff_hunks,omitempty"` // total hunks in the diff
+}
+
+// SyncConflictSide describes one side of a smart_sync conflict
+type SyncConflictSide struct {
+	Type     string    `json:"type"` // "file" or "directory"
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	SHA256   string    `json:"sha256,omitempty"`
+}
+
+// SyncResolution represents the outcome of resolve_sync_conflict
+type SyncResolution struct {
+	ID         string `json:"id"`
+	Path       string `json:"path"`       // relative to both directories
+	Resolution string `json:"resolution"` // keep_source, keep_target or merged
+	Target     string `json:"target"`
+	SHA256     string `json:"sha256,omitempty"` // of the target file after resolving
 }
 
 // FileWatchEvent represents a file system event
@@ -75,29 +323,63 @@ type DependencyAnalysis struct {
 
 // CleanupResult represents cleanup operation results
 type CleanupResult struct {
-	TotalFiles   int      `json:"totalFiles"`
-	TotalSize    int64    `json:"totalSize"`
-	FilesRemoved []string `json:"filesRemoved"`
-	SizeFreed    int64    `json:"sizeFreed"`
-	DryRun       bool     `json:"dryRun"`
+	TotalFiles   int            `json:"totalFiles"`
+	TotalSize    int64          `json:"totalSize"`
+	FilesRemoved []string       `json:"filesRemoved"`
+	SizeFreed    int64          `json:"sizeFreed"`
+	DryRun       bool           `json:"dryRun"`
+	Categories   map[string]int `json:"categories"`
+	Errors       []string       `json:"errors,omitempty"`
+}
+
+// PermissionFixReport is the result of fix_permissions. Changes groups the
+// entries whose mode was (or, in a dry run, would be) changed by kind and
+// old/new mode; ConfirmToken is set by a dry run on an allowed root.
+type PermissionFixReport struct {
+	Root         string             `json:"root"`
+	DryRun       bool               `json:"dryRun"`
+	Scanned      int                `json:"scanned"`
+	Changed      int                `json:"changed"`
+	Changes      []PermissionChange `json:"changes"`
+	Skipped      int                `json:"skipped"` // symlinks, special files and denied or outside paths
+	Errors       []string           `json:"errors,omitempty"`
+	ConfirmToken string             `json:"confirmToken,omitempty"`
+}
+
+// PermissionChange counts the entries of one kind (directory, file or
+// executable) that went from one mode to another, as octal strings
+type PermissionChange struct {
+	Kind  string `json:"kind"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int    `json:"count"`
 }
 
 // FileAnalysis represents comprehensive file analysis
 type FileAnalysis struct {
-	Path         string          `json:"path"`
-	Size         int64           `json:"size"`
-	Lines        int             `json:"lines"`
-	Words        int             `json:"words"`
-	Characters   int             `json:"characters"`
-	MimeType     string          `json:"mimeType"`
-	Encoding     string          `json:"encoding"`
-	LineEndings  string          `json:"lineEndings"`
-	LastModified time.Time       `json:"lastModified"`
-	Permissions  string          `json:"permissions"`
-	Hash         FileHashes      `json:"hashes"`
-	Language     string          `json:"language,omitempty"`
-	Complexity   *CodeComplexity `json:"complexity,omitempty"`
-	Dependencies []string        `json:"dependencies,omitempty"`
+	Path         string     `json:"path"`
+	Size         int64      `json:"size"`
+	Lines        int        `json:"lines"`
+	Words        int        `json:"words"`
+	Characters   int        `json:"characters"`
+	MimeType     string     `json:"mimeType"`
+	Encoding     string     `json:"encoding"`
+	LineEndings  string     `json:"lineEndings"`
+	LastModified time.Time  `json:"lastModified"`
+	Created      *time.Time `json:"created,omitempty"`  // only when the platform reports it
+	Accessed     *time.Time `json:"accessed,omitempty"` // only when the platform reports it
+	Permissions  string     `json:"permissions"`
+	Hash         FileHashes `json:"hashes"`
+	Language     string     `json:"language,omitempty"`
+	// How sure the detector is of Language, from 0 to 1: the extension or file
+	// name scores high, a guess from content signals lower
+	LanguageConfidence float64         `json:"languageConfidence,omitempty"`
+	Complexity         *CodeComplexity `json:"complexity,omitempty"`
+	Dependencies       []string        `json:"dependencies,omitempty"`
+	CommentRatio       float64         `json:"commentRatio,omitempty"`
+	Skipped            string          `json:"skipped,omitempty"`   // motivo si se omitió el análisis de contenido
+	Generated          string          `json:"generated,omitempty"` // why the file looks generated (see generatedReason)
+	Image              *ImageInfo      `json:"image,omitempty"`
 }
 
 // FileHashes contains file hash information
@@ -106,6 +388,30 @@ type FileHashes struct {
 	SHA256 string `json:"sha256"`
 }
 
+// FileChecksum contains the hashes computed for one file
+type FileChecksum struct {
+	Path   string            `json:"path"`
+	Size   int64             `json:"size"`
+	Hashes map[string]string `json:"hashes"`
+}
+
+// ChecksumManifest represents checksums for a file or directory tree
+type ChecksumManifest struct {
+	Root         string         `json:"root"`
+	Algorithms   []string       `json:"algorithms"`
+	Files        []FileChecksum `json:"files"`
+	ManifestHash string         `json:"manifest_sha256,omitempty"`
+}
+
+// ChecksumVerification represents the result of checking one manifest entry
+type ChecksumVerification struct {
+	Path     string `json:"path"`
+	Status   string `json:"status"` // OK | FAILED | MISSING
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Note     string `json:"note,omitempty"`
+}
+
 // CodeComplexity represents code complexity metrics
 type CodeComplexity struct {
 	CyclomaticComplexity int `json:"cyclomaticComplexity"`
@@ -114,11 +420,264 @@ type CodeComplexity struct {
 	ImportCount          int `json:"importCount"`
 }
 
+// QualityThresholds configures the code_quality_check rules
+type QualityThresholds struct {
+	MaxFileLines     int     `json:"max_file_lines"`
+	MaxLineLength    int     `json:"max_line_length"`
+	MaxFunctionLines int     `json:"max_function_lines"`
+	MaxComplexity    int     `json:"max_complexity"`
+	MinCommentRatio  float64 `json:"min_comment_ratio"`
+}
+
+// QualityFinding represents a single code quality issue
+type QualityFinding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"` // error | warning | info
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+}
+
+// QualityReport represents the results of a code quality check
+type QualityReport struct {
+	Path         string            `json:"path"`
+	FilesChecked int               `json:"files_checked"`
+	Score        int               `json:"score"`
+	Summary      map[string]int    `json:"summary"`
+	Thresholds   QualityThresholds `json:"thresholds"`
+	Findings     []QualityFinding  `json:"findings"`
+}
+
 // DuplicateFile represents a duplicate file entry
 type DuplicateFile struct {
-	Path string `json:"path"`
-	Hash string `json:"hash"`
-	Size int64  `json:"size"`
+	Path    string    `json:"path"`
+	Hash    string    `json:"hash"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// DuplicateAction represents an action taken (or planned) on a duplicate file
+type DuplicateAction struct {
+	Action string `json:"action"` // delete | hardlink | skip
+	Path   string `json:"path"`
+	Kept   string `json:"kept"`
+	Bytes  int64  `json:"bytes"`
+	Note   string `json:"note,omitempty"`
+}
+
+// DuplicateReport represents the result of find_duplicates
+type DuplicateReport struct {
+	Groups    [][]DuplicateFile `json:"groups"`
+	Action    string            `json:"action"`
+	DryRun    bool              `json:"dry_run"`
+	Actions   []DuplicateAction `json:"actions,omitempty"`
+	Reclaimed int64             `json:"reclaimed_bytes"`
+	Warnings  []WalkWarning     `json:"warnings,omitempty"` // entries skipped because they could not be read
+}
+
+// DuplicateCodeReport represents the result of find_duplicate_code. Blocks
+// holds the largest ones only; TotalBlocks counts them all.
+type DuplicateCodeReport struct {
+	Path         string               `json:"path"`
+	Window       int                  `json:"window"` // lines per hashed window, the shortest block reported
+	FilesScanned int                  `json:"files_scanned"`
+	LinesScanned int                  `json:"lines_scanned"` // significant lines, after skipping headers, blank and brace-only lines
+	TotalBlocks  int                  `json:"total_blocks"`
+	Truncated    bool                 `json:"truncated,omitempty"` // the candidate window cap was reached, some duplicates may be missing
+	Blocks       []DuplicateCodeBlock `json:"blocks"`
+	Warnings     []WalkWarning        `json:"warnings,omitempty"` // entries skipped because they could not be read
+}
+
+// DuplicateCodeBlock is a run of significant lines repeated at every location
+type DuplicateCodeBlock struct {
+	Lines     int                     `json:"lines"` // significant lines in the block
+	Locations []DuplicateCodeLocation `json:"locations"`
+	Snippet   string                  `json:"snippet"` // first lines of the first location
+}
+
+// DuplicateCodeLocation is one copy of a duplicate block; lines are 1-based
+// and inclusive, Length counting the skipped lines in between
+type DuplicateCodeLocation struct {
+	File      string `json:"file"` // relative to Path
+	Language  string `json:"language"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Length    int    `json:"length"`
+}
+
+// BatchResult represents the outcome of batch_operations
+type BatchResult struct {
+	DryRun        bool                   `json:"dry_run,omitempty"`       // nothing was executed
+	Transactional bool                   `json:"transactional,omitempty"` // all operations or none
+	Cancelled     bool                   `json:"cancelled,omitempty"`     // the call was cancelled before the batch finished
+	Successful    int                    `json:"successful"`
+	Failed        int                    `json:"failed"`
+	NotRun        int                    `json:"not_run,omitempty"`
+	RolledBack    int                    `json:"rolled_back,omitempty"`
+	Operations    []BatchOperationResult `json:"operations"`
+}
+
+// BatchOperationResult represents one operation of a batch, in request order
+type BatchOperationResult struct {
+	Index int    `json:"index"` // 1-based, as in the text output
+	Type  string `json:"type,omitempty"`
+	// Status is done, failed, cancelled (interrupted by cancellation), not run
+	// or rolled back; checked in a dry run
+	Status  string `json:"status"`
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// Resource is the URI of the file or directory the operation created or
+	// modified; empty for deletes and dry runs
+	Resource string `json:"resource,omitempty"`
+}
+
+// ArchiveResult represents the outcome of create_archive
+type ArchiveResult struct {
+	Path             string `json:"path"`
+	Source           string `json:"source"`
+	Format           string `json:"format"` // "zip" or "tar.gz"
+	Entries          int    `json:"entries"`
+	Files            int    `json:"files"`
+	Directories      int    `json:"directories"`
+	Symlinks         int    `json:"symlinks"`
+	UncompressedSize int64  `json:"uncompressed_size"`
+	CompressedSize   int64  `json:"compressed_size"` // size of the archive file
+	SHA256           string `json:"sha256"`
+}
+
+// GitInfo represents the outcome of git_info. Paths are relative to WorkTree.
+type GitInfo struct {
+	WorkTree  string   `json:"work_tree"`
+	Scope     string   `json:"scope"`            // only changes under this path are listed
+	Branch    string   `json:"branch,omitempty"` // empty when HEAD is detached
+	Commit    string   `json:"commit,omitempty"` // empty on a branch without commits
+	Modified  []string `json:"modified"`
+	Deleted   []string `json:"deleted"`
+	Untracked []string `json:"untracked"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// CSVQueryResult represents the outcome of csv_query. Row counts are omitted
+// when Partial is set (count_rows: false stopped reading early).
+type CSVQueryResult struct {
+	Path          string              `json:"path"`
+	Delimiter     string              `json:"delimiter"` // "tab" for tab-separated files
+	Header        []string            `json:"header"`
+	Columns       []string            `json:"columns"` // selected columns, keys of each row
+	Offset        int                 `json:"offset"`
+	Rows          []map[string]string `json:"rows"`
+	Lines         []int               `json:"lines"` // line where each row starts
+	TotalRows     int                 `json:"total_rows,omitempty"`
+	MatchedRows   int                 `json:"matched_rows,omitempty"`
+	Partial       bool                `json:"partial,omitempty"`
+	MalformedRows int                 `json:"malformed_rows,omitempty"`
+	Malformed     []CSVMalformedRow   `json:"malformed,omitempty"` // first 20 only
+}
+
+// CSVMalformedRow represents a row csv_query skipped
+type CSVMalformedRow struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// TailFollowResult represents the outcome of tail_follow: the lines appended
+// while following (only those matching Pattern when set). StoppedBy is
+// duration, max_lines or cancelled.
+type TailFollowResult struct {
+	Path       string   `json:"path"`
+	Pattern    string   `json:"pattern,omitempty"`
+	DurationMs int64    `json:"duration_ms"`
+	LinesRead  int      `json:"lines_read"`
+	Lines      []string `json:"lines"`
+	StoppedBy  string   `json:"stopped_by"`
+	Rotated    bool     `json:"rotated"`             // the file shrank or was replaced and was read again from the start
+	Rotations  int      `json:"rotations,omitempty"` // times that happened
+}
+
+// LogQueryResult represents the outcome of log_query. Lines is filled in
+// "lines" mode and Groups in "summary" mode.
+type LogQueryResult struct {
+	Path             string     `json:"path"`
+	Compression      string     `json:"compression,omitempty"` // "gzip" when decompressed on the fly
+	Mode             string     `json:"mode"`
+	TimestampFormat  string     `json:"timestamp_format"` // iso8601, go-log, syslog or none
+	Since            string     `json:"since,omitempty"`  // resolved bounds, RFC3339
+	Until            string     `json:"until,omitempty"`
+	Pattern          string     `json:"pattern,omitempty"`
+	LinesRead        int        `json:"lines_read"`
+	MatchedLines     int        `json:"matched_lines"`
+	Lines            []LogLine  `json:"lines,omitempty"`
+	Truncated        bool       `json:"truncated,omitempty"` // more matches than limit
+	Groups           []LogGroup `json:"groups,omitempty"`
+	DistinctMessages int        `json:"distinct_messages,omitempty"`
+	OtherLines       int        `json:"other_lines,omitempty"` // matches past the distinct message cap
+	Note             string     `json:"note,omitempty"`
+}
+
+// LogLine represents one matching log line
+type LogLine struct {
+	Line      int    `json:"line"`
+	Timestamp string `json:"timestamp,omitempty"` // inherited by lines without their own
+	Text      string `json:"text"`
+}
+
+// LogGroup represents the lines that share a normalized message
+type LogGroup struct {
+	Message   string `json:"message"`
+	Count     int    `json:"count"`
+	FirstLine int    `json:"first_line"`
+	LastLine  int    `json:"last_line"`
+	First     string `json:"first,omitempty"`
+	Last      string `json:"last,omitempty"`
+	Example   string `json:"example"`
+}
+
+// RenderResult represents the outcome of render_template and render_tree.
+// Content is only filled in for dry runs.
+type RenderResult struct {
+	Output   string         `json:"output,omitempty"`
+	Template string         `json:"template,omitempty"` // empty for inline templates
+	DryRun   bool           `json:"dry_run,omitempty"`
+	Files    []RenderedFile `json:"files"`
+}
+
+// RenderedFile represents one file produced from a template
+type RenderedFile struct {
+	Path        string `json:"path,omitempty"`
+	Size        int    `json:"size"`
+	Overwritten bool   `json:"overwritten,omitempty"` // the file existed before
+	Content     string `json:"content,omitempty"`
+	Resource    string `json:"resource,omitempty"` // URI of the written file, in scaffold results
+}
+
+// ScaffoldResult represents the outcome of scaffold
+type ScaffoldResult struct {
+	Kind        string                 `json:"kind"`
+	Path        string                 `json:"path"`
+	Templates   string                 `json:"templates"`    // "built-in" or the template directory used
+	ProjectType string                 `json:"project_type"` // detected in path before scaffolding, "unknown" if none
+	DryRun      bool                   `json:"dry_run,omitempty"`
+	Variables   map[string]interface{} `json:"variables"` // as passed to the templates, defaults included
+	Files       []RenderedFile         `json:"files"`
+}
+
+// FrontmatterInfo represents the YAML front-matter and outline of a Markdown
+// file. Keys keeps the order of the block, which Frontmatter loses.
+type FrontmatterInfo struct {
+	Path        string                 `json:"path"`
+	Present     bool                   `json:"present"`
+	Keys        []string               `json:"keys"`
+	Frontmatter map[string]interface{} `json:"frontmatter,omitempty"`
+	Title       string                 `json:"title,omitempty"` // first H1
+	Headings    []MarkdownHeading      `json:"headings"`
+}
+
+// MarkdownHeading represents one ATX heading
+type MarkdownHeading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	Line  int    `json:"line"`
 }
 
 // ProjectStructure represents project analysis results
@@ -130,6 +689,208 @@ type ProjectStructure struct {
 	TotalSize   int64               `json:"totalSize"`
 	Directories []string            `json:"directories"`
 	Structure   map[string][]string `json:"structure"`
+
+	// Only filled when lines of code are counted
+	LinesOfCode map[string]*LanguageLOC `json:"linesOfCode,omitempty"`
+
+	LargestFiles       []ReportFileEntry `json:"largestFiles"`
+	LargestDirectories []ReportFileEntry `json:"largestDirectories"`
+
+	Manifests []DependencyManifest `json:"manifests,omitempty"`
+
+	// Only set in quick mode: counts, sizes, languages and file types are
+	// then extrapolated from a sample, while Directories and Structure cover
+	// the fully listed top levels exactly
+	Estimate *ProjectEstimate `json:"estimate,omitempty"`
+
+	// Entries skipped because they could not be read
+	Warnings []WalkWarning `json:"warnings,omitempty"`
+}
+
+// ProjectEstimate describes the sample behind a quick analyze_project
+type ProjectEstimate struct {
+	Exact              bool     `json:"exact"` // the sample turned out to be every entry
+	SampledEntries     int      `json:"sampledEntries"`
+	ListedDirectories  int      `json:"listedDirectories"`
+	SampledDirectories int      `json:"sampledDirectories"`    // directories where only part of the entries were read
+	Unexplored         int      `json:"unexploredDirectories"` // estimated directories never read
+	PerDirectoryLimit  int      `json:"perDirectoryLimit"`
+	TotalLimit         int      `json:"totalLimit"`
+	ExactLevels        int      `json:"exactLevels"`
+	Caveats            []string `json:"caveats,omitempty"`
+}
+
+// DependencyManifest represents a parsed go.mod, package.json, requirements.txt,
+// pyproject.toml or Cargo.toml from the project root
+type DependencyManifest struct {
+	File            string   `json:"file"`
+	Ecosystem       string   `json:"ecosystem"` // go | npm | python | cargo
+	Name            string   `json:"name,omitempty"`
+	Version         string   `json:"version,omitempty"` // Go version for go.mod, package version otherwise
+	Scripts         []string `json:"scripts,omitempty"`
+	Dependencies    []string `json:"dependencies"`
+	DevDependencies []string `json:"devDependencies,omitempty"`
+	Error           string   `json:"error,omitempty"`
+}
+
+// LanguageLOC represents line counts for one language
+type LanguageLOC struct {
+	Files   int `json:"files"`
+	Code    int `json:"code"`
+	Comment int `json:"comment"`
+	Blank   int `json:"blank"`
+}
+
+// ProjectReport represents a generated project report; only the requested
+// sections are populated
+type ProjectReport struct {
+	Root         string             `json:"root"`
+	Sections     []string           `json:"sections"`
+	Overview     *ReportOverview    `json:"overview,omitempty"`
+	Files        *ReportFiles       `json:"files,omitempty"`
+	Quality      *QualityReport     `json:"quality,omitempty"`
+	CodeStats    *CodeStatsReport   `json:"code_stats,omitempty"`
+	Dependencies []ReportDependency `json:"dependencies,omitempty"`
+	Security     []SecretFinding    `json:"security,omitempty"`
+}
+
+// CodeStatsReport is the result of code_stats: code metrics per top-level
+// directory of Path, files directly under it being grouped as "."
+type CodeStatsReport struct {
+	Path        string               `json:"path"`
+	SortBy      string               `json:"sort_by"`
+	Directories []DirectoryCodeStats `json:"directories"`
+	Total       DirectoryCodeStats   `json:"total"` // every directory together, Directory "total"
+}
+
+// DirectoryCodeStats holds the code metrics of one directory. Only files of
+// languages with code metrics (Go, JavaScript, TypeScript, Python, Rust, Java,
+// C#) count towards LOC, comments and complexity.
+type DirectoryCodeStats struct {
+	Directory         string  `json:"directory"`
+	Files             int     `json:"files"` // every file, code or not
+	CodeFiles         int     `json:"code_files"`
+	LOC               int     `json:"loc"`
+	CommentRatio      float64 `json:"comment_ratio"`  // percentage of comment lines
+	AvgComplexity     float64 `json:"avg_complexity"` // cyclomatic complexity per code file
+	MaxComplexity     int     `json:"max_complexity"`
+	MaxComplexityFile string  `json:"max_complexity_file,omitempty"` // relative to the report path
+	Language          string  `json:"language,omitempty"`            // dominant by LOC
+}
+
+// ReportOverview summarizes a project
+type ReportOverview struct {
+	TotalFiles       int            `json:"total_files"`
+	TotalDirectories int            `json:"total_directories"`
+	TotalSize        int64          `json:"total_size"`
+	Languages        map[string]int `json:"languages"`
+	Patterns         []string       `json:"patterns"`
+}
+
+// ReportFiles describes file types, the largest files and duplicates
+type ReportFiles struct {
+	FileTypes      map[string]int    `json:"file_types"`
+	Largest        []ReportFileEntry `json:"largest"`
+	Duplicates     [][]string        `json:"duplicates"`
+	DuplicateWaste int64             `json:"duplicate_waste"`
+}
+
+// ReportFileEntry is a file or directory path with its size
+type ReportFileEntry struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// ReportDependency is an imported module and how many files use it
+type ReportDependency struct {
+	Name     string `json:"name"`
+	Language string `json:"language"`
+	Files    int    `json:"files"`
+}
+
+// SecretFinding represents a potential secret found in a file
+type SecretFinding struct {
+	File  string `json:"file"`
+	Line  int    `json:"line"`
+	Rule  string `json:"rule"`
+	Match string `json:"match"` // masked
+}
+
+// TodoFinding represents a TODO/FIXME/HACK/XXX comment
+type TodoFinding struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Marker string `json:"marker"`
+	Author string `json:"author,omitempty"`
+	Text   string `json:"text"`
+}
+
+// LicenseFinding represents a license file and the license it matches
+type LicenseFinding struct {
+	File    string `json:"file"`
+	License string `json:"license"` // SPDX identifier or "unknown"
+}
+
+// ScanResult represents the output of the scan tool; only the selected
+// scanners' sections are populated
+type ScanResult struct {
+	Root     string           `json:"root"`
+	Scanners []string         `json:"scanners"`
+	Summary  map[string]int   `json:"summary"`
+	Todos    []TodoFinding    `json:"todos,omitempty"`
+	Licenses []LicenseFinding `json:"licenses,omitempty"`
+	Secrets  []SecretFinding  `json:"secrets,omitempty"`
+	Ignored  int              `json:"ignored"` // findings suppressed by .mcpscanignore
+}
+
+// OutlineSymbol represents a top-level symbol (or a class member) in a source file
+type OutlineSymbol struct {
+	Name      string          `json:"name"`
+	Kind      string          `json:"kind"` // function | method | type | struct | interface | class | enum | const | var
+	Receiver  string          `json:"receiver,omitempty"`
+	Signature string          `json:"signature"`
+	StartLine int             `json:"start_line"`
+	EndLine   int             `json:"end_line"`
+	Lines     int             `json:"lines"`
+	Children  []OutlineSymbol `json:"children,omitempty"`
+}
+
+// FileOutline represents the outline of one source file
+type FileOutline struct {
+	File     string          `json:"file"`
+	Language string          `json:"language"`
+	Lines    int             `json:"lines"`
+	Symbols  []OutlineSymbol `json:"symbols"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// RefactorOccurrence represents one whole-word match of a refactor target
+type RefactorOccurrence struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Kind    string `json:"kind"` // definition | reference | string | comment
+	Text    string `json:"text"`
+	Applied bool   `json:"applied"`
+}
+
+// RefactorFileResult represents the occurrences found in one file
+type RefactorFileResult struct {
+	File        string               `json:"file"`
+	Language    string               `json:"language"`
+	Occurrences []RefactorOccurrence `json:"occurrences"`
+	Replaced    int                  `json:"replaced"`
+}
+
+// RenameResult represents a rename preview or applied rename
+type RenameResult struct {
+	Target       string               `json:"target"`
+	NewName      string               `json:"new_name"`
+	Applied      bool                 `json:"applied"`
+	FilesScanned int                  `json:"files_scanned"`
+	Skipped      int                  `json:"skipped"` // binary or oversized
+	Counts       map[string]int       `json:"counts"`
+	Files        []RefactorFileResult `json:"files"`
+	Conflicts    []string             `json:"conflicts,omitempty"`
 }
 
 // ChunkWriteResult represents chunked file write results
@@ -188,3 +949,302 @@ type JoinResult struct {
 	SourceFiles []string `json:"source_files"`
 	TotalSize   int64    `json:"total_size"`
 }
+
+// SnapshotFile is one file recorded in a snapshot manifest
+type SnapshotFile struct {
+	Path   string      `json:"path"` // relative to the snapshot source, slash-separated
+	Size   int64       `json:"size"`
+	Mode   os.FileMode `json:"mode"`
+	SHA256 string      `json:"sha256"`
+	// ModTime is the source file's modification time; zero in manifests
+	// written before it was recorded
+	ModTime time.Time `json:"mod_time,omitempty"`
+}
+
+// Snapshot is the manifest stored alongside a snapshot's copied files
+type Snapshot struct {
+	ID        string         `json:"id"`
+	Source    string         `json:"source"`
+	SourceDir bool           `json:"source_dir"`
+	Created   time.Time      `json:"created"`
+	Include   []string       `json:"include,omitempty"`
+	Exclude   []string       `json:"exclude,omitempty"`
+	Files     []SnapshotFile `json:"files"`
+	TotalSize int64          `json:"total_size"`
+}
+
+// SnapshotRestoreEntry describes what restoring one snapshot file would do
+type SnapshotRestoreEntry struct {
+	Path   string `json:"path"`
+	Action string `json:"action"` // "create", "overwrite" or "unchanged"
+	Diff   string `json:"diff,omitempty"`
+}
+
+// ServerStats is the result of the server_stats tool
+type ServerStats struct {
+	Uptime             string           `json:"uptime"`
+	TotalCalls         int64            `json:"total_calls"`
+	TotalErrors        int64            `json:"total_errors"`
+	BytesRead          int64            `json:"bytes_read"`
+	BytesWritten       int64            `json:"bytes_written"`
+	WalkEntries        int64            `json:"walk_entries"`
+	AllowedDirectories int              `json:"allowed_directories"`
+	ReadOnly           bool             `json:"read_only"`
+	Limits             map[string]int64 `json:"limits"`
+	Tools              []ToolStats      `json:"tools"`
+}
+
+// ToolStats holds the counters of one tool
+type ToolStats struct {
+	Name      string  `json:"name"`
+	Calls     int64   `json:"calls"`
+	Errors    int64   `json:"errors"`
+	AvgMillis float64 `json:"avg_ms"`
+}
+
+// FileClassification is the result of classify_files
+type FileClassification struct {
+	Root       string `json:"root"`
+	TotalFiles int    `json:"totalFiles"`
+	TotalSize  int64  `json:"totalSize"`
+	// Detected counts the files classified by content sniffing instead of
+	// by extension plus a header signature check
+	Detected        int                `json:"detected"`
+	Buckets         []FileBucket       `json:"buckets,omitempty"`
+	Mismatches      []FileTypeMismatch `json:"mismatches"`
+	TotalMismatches int                `json:"totalMismatches"`
+	MismatchesOnly  bool               `json:"mismatchesOnly,omitempty"`
+}
+
+// FileBucket counts the files of one family (text, code, image, audio,
+// video, archive or binary)
+type FileBucket struct {
+	Family string `json:"family"`
+	Files  int    `json:"files"`
+	Size   int64  `json:"size"`
+}
+
+// FileTypeMismatch is a file whose content does not match its extension
+type FileTypeMismatch struct {
+	Path       string `json:"path"`
+	Extension  string `json:"extension"`
+	Expected   string `json:"expected"`
+	Detected   string `json:"detected"`
+	MimeType   string `json:"mimeType"`
+	Executable bool   `json:"executable,omitempty"`
+}
+
+// NameCollisionReport is the result of find_name_collisions. Directories are
+// relative to Root, "." being Root itself.
+type NameCollisionReport struct {
+	Root           string          `json:"root"`
+	Scanned        int             `json:"scanned"` // files and directories checked
+	WindowsChecked bool            `json:"windowsChecked"`
+	Collisions     []NameCollision `json:"collisions"`
+	InvalidNames   []InvalidName   `json:"invalidNames"` // only with check_windows_compat
+}
+
+// NameCollision is a set of names in one directory that differ only in case.
+// Names are sorted; the first is kept and the others get a suggested rename.
+type NameCollision struct {
+	Directory string            `json:"directory"`
+	Names     []string          `json:"names"`
+	Renames   []SuggestedRename `json:"renames"`
+}
+
+// SuggestedRename is a new name that collides with nothing in its directory
+type SuggestedRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// InvalidName is a name Windows does not accept
+type InvalidName struct {
+	Directory string   `json:"directory"`
+	Name      string   `json:"name"`
+	Problems  []string `json:"problems"`
+	Suggested string   `json:"suggested"`
+}
+
+// NormalizeResult is the result of normalize_file
+type NormalizeResult struct {
+	Path          string `json:"path"`
+	DryRun        bool   `json:"dryRun"`
+	FilesScanned  int    `json:"filesScanned"`
+	FilesChanged  int    `json:"filesChanged"`
+	SkippedBinary int    `json:"skippedBinary"`
+	// EditorConfig is true when use_editorconfig found at least one file
+	EditorConfig bool             `json:"editorConfig,omitempty"`
+	Files        []NormalizedFile `json:"files"`
+	Errors       []string         `json:"errors,omitempty"`
+}
+
+// NormalizedFile counts the lines normalize_file changed, or would change
+// in a dry run, in one file
+type NormalizedFile struct {
+	Path               string `json:"path"`
+	LineEndings        int    `json:"lineEndings"`
+	TrailingWhitespace int    `json:"trailingWhitespace"`
+	Indentation        int    `json:"indentation"`
+	FinalNewline       bool   `json:"finalNewline"`
+}
+
+// LineAnalysis is the result of analyze_lines
+type LineAnalysis struct {
+	Path          string  `json:"path"`
+	Lines         int     `json:"lines"`
+	BlankLines    int     `json:"blankLines"`
+	UniqueLines   int     `json:"uniqueLines"`
+	MaxLineLength int     `json:"maxLineLength"`
+	AvgLineLength float64 `json:"avgLineLength"`
+	// DuplicateGroups and TotalBlankRuns count everything found, even when
+	// Duplicates and BlankRuns are capped
+	DuplicateGroups int             `json:"duplicateGroups"`
+	Duplicates      []DuplicateLine `json:"duplicates"`
+	TotalBlankRuns  int             `json:"totalBlankRuns"`
+	BlankRuns       []BlankRun      `json:"blankRuns"`
+	LongestLines    []LongLine      `json:"longestLines"`
+	// Truncated is set when there were too many distinct lines to track
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// DuplicateLine is a line that appears more than once, with the first line
+// numbers where it appears
+type DuplicateLine struct {
+	Text  string `json:"text"`
+	Count int    `json:"count"`
+	Lines []int  `json:"lines"`
+}
+
+// BlankRun is a run of consecutive blank lines
+type BlankRun struct {
+	StartLine int `json:"startLine"`
+	Length    int `json:"length"`
+}
+
+// LongLine is one of the longest lines of a file, lengths in bytes
+type LongLine struct {
+	Line    int    `json:"line"`
+	Length  int    `json:"length"`
+	Preview string `json:"preview"`
+}
+
+// ChangeReport is the result of detect_changes
+type ChangeReport struct {
+	Path         string    `json:"path"`
+	Baseline     string    `json:"baseline"` // "since" or the snapshot ID
+	Since        time.Time `json:"since"`
+	HashVerified bool      `json:"hashVerified,omitempty"`
+	Created      int       `json:"created"`
+	Modified     int       `json:"modified"`
+	Deleted      int       `json:"deleted"`
+	// ChangedBytes adds the current size of created and modified files and the
+	// snapshot size of deleted ones
+	ChangedBytes int64         `json:"changedBytes"`
+	Files        []ChangedFile `json:"files"`
+	Truncated    bool          `json:"truncated,omitempty"`
+	Notes        []string      `json:"notes,omitempty"`
+}
+
+// ChangedFile is one file detect_changes found changed
+type ChangedFile struct {
+	Path    string    `json:"path"`   // relative to the checked path
+	Change  string    `json:"change"` // "created", "modified" or "deleted"
+	Reason  string    `json:"reason"` // what showed the change: size, hash, mtime...
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// RecentActivity is the result of recent_activity: what changed under a
+// directory since a time or a snapshot, grouped for a quick overview
+type RecentActivity struct {
+	Path     string    `json:"path"`
+	Baseline string    `json:"baseline"` // "since" or the snapshot ID
+	Since    time.Time `json:"since"`
+	Created  int       `json:"created"`
+	Modified int       `json:"modified"`
+	Deleted  int       `json:"deleted"`
+	// ChurnBytes adds the absolute size change of each file against the
+	// snapshot, or the current size of each changed file without one
+	ChurnBytes  int64               `json:"churnBytes"`
+	Directories []ActivityDirectory `json:"directories"`
+	// TopFiles holds the most changed files: by absolute size change against
+	// a snapshot, by most recent modification without one
+	TopFiles           []ActivityFile `json:"topFiles"`
+	NewDirectories     []string       `json:"newDirectories"`
+	DeletedDirectories []string       `json:"deletedDirectories"`
+	Truncated          bool           `json:"truncated,omitempty"` // Directories lists only the first files
+	Notes              []string       `json:"notes,omitempty"`
+}
+
+// ActivityDirectory groups the changed files directly inside one directory
+type ActivityDirectory struct {
+	Path     string   `json:"path"` // relative to the checked path, "." for itself
+	Created  int      `json:"created"`
+	Modified int      `json:"modified"`
+	Deleted  int      `json:"deleted"`
+	Bytes    int64    `json:"bytes"` // same measure as RecentActivity.ChurnBytes
+	Files    []string `json:"files"` // file names
+}
+
+// ActivityFile is a changed file with its size change against the snapshot
+type ActivityFile struct {
+	ChangedFile
+	SizeDelta *int64 `json:"sizeDelta,omitempty"` // only with a baseline snapshot
+}
+
+// IgnoreRules is the result of get_ignore_rules
+type IgnoreRules struct {
+	Defaults []string          `json:"defaults"` // built-in names skipped by the analysis walkers
+	Roots    []IgnoreRootRules `json:"roots"`
+	Check    *IgnoreCheck      `json:"check,omitempty"` // only when a path was given
+}
+
+// IgnoreRootRules is the .mcpignore of one allowed directory
+type IgnoreRootRules struct {
+	Root     string     `json:"root"`
+	File     string     `json:"file"`
+	Exists   bool       `json:"exists"`
+	Modified *time.Time `json:"modified,omitempty"`
+	Rules    []string   `json:"rules"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// IgnoreCheck tells whether one path is skipped by the walkers
+type IgnoreCheck struct {
+	Path    string `json:"path"`
+	Ignored bool   `json:"ignored"`
+	By      string `json:"by,omitempty"`   // the path itself or the ignored directory containing it
+	Rule    string `json:"rule,omitempty"` // .mcpignore:line: pattern, or the built-in default
+}
+
+// WorkspaceContext is the result of workspace_context: what the planner knows
+// about a project before it plans anything
+type WorkspaceContext struct {
+	Path           string   `json:"path"`
+	ProjectType    string   `json:"project_type"`    // "go", "node", "python"... or "unknown"
+	ImportantFiles []string `json:"important_files"` // every group, in ranking order
+	// ImportantGroups splits ImportantFiles into build, entry_point, docs and
+	// source, in that order; empty categories are left out
+	ImportantGroups []ImportantFileGroup `json:"important_groups"`
+	Files           int                  `json:"files"`
+	Directories     int                  `json:"directories"`
+	Extensions      map[string]int       `json:"extensions"` // file count per lower-case extension
+	Generated       time.Time            `json:"generated"`
+	Cached          bool                 `json:"cached"` // served from the cache, unchanged since Generated
+}
+
+// ImportantFileGroup is one category of important files, best ranked first
+type ImportantFileGroup struct {
+	Category string   `json:"category"`
+	Files    []string `json:"files"`
+}
+
+// ToolError is the payload of an error result caused by the caller's input,
+// sent as JSON in the last content item after the human-readable text
+type ToolError struct {
+	Code    string `json:"code"` // E_BAD_ARG, E_ACCESS_DENIED, E_NOT_FOUND or E_TOO_LARGE
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+	Hint    string `json:"hint,omitempty"` // what to try instead
+}
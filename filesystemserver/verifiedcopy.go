@@ -0,0 +1,356 @@
+package filesystemserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// copy_file, move_file and batch copies accept verify: the source is hashed
+// while it is copied (one read), the destination is hashed again afterwards,
+// and a mismatch fails the call and removes what was written. A move that has
+// to copy because it crosses devices only deletes the source once the copy is
+// complete and, with verify, has passed verification.
+
+// osRename is os.Rename; tests replace it to simulate a cross-device move
+var osRename = os.Rename
+
+// fileDigest is the SHA-256 of one copied or moved file
+type fileDigest struct {
+	rel  string // relative to the copied directory, or the file name
+	sum  string
+	size int64
+}
+
+// copyResult describes a copy or move done by copyTreeChecked or moveChecked
+type copyResult struct {
+	dir         bool
+	files       int
+	bytes       int64
+	skipped     int          // symlinks, special files and denied paths not copied
+	digests     []fileDigest // only with verify
+	verified    bool         // digests were checked against the copied data
+	crossDevice bool         // a move that had to copy and delete
+}
+
+// maxListedDigests bounds the per-file hash lines in the tool output
+const maxListedDigests = 1000
+
+// copyFileHash copies src to dst, feeding what it reads into h when not nil
+func copyFileHash(src, dst string, h hash.Hash) (int64, error) {
+	sourceFile, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer sourceFile.Close()
+
+	// Never write through a symlink at the destination
+	destFile, err := os.OpenFile(dst, os.O_RDWR|os.O_CREATE|os.O_TRUNC|openNoFollow, 0666)
+	if err != nil {
+		return 0, err
+	}
+	defer destFile.Close()
+
+	var reader io.Reader = sourceFile
+	if h != nil {
+		reader = io.TeeReader(sourceFile, h)
+	}
+	n, err := io.Copy(destFile, reader)
+	if err != nil {
+		return n, err
+	}
+	if err := destFile.Close(); err != nil {
+		return n, err
+	}
+
+	sourceInfo, err := os.Stat(src)
+	if err != nil {
+		return n, err
+	}
+	return n, os.Chmod(dst, sourceInfo.Mode())
+}
+
+// copyFileVerified copies src to dst and checks that dst hashes like the data
+// read from src; on any failure dst is removed
+func copyFileVerified(src, dst string) (fileDigest, error) {
+	h := sha256.New()
+	n, err := copyFileHash(src, dst, h)
+	if err != nil {
+		os.Remove(dst)
+		return fileDigest{}, err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if err := verifyCopiedFile(dst, sum); err != nil {
+		return fileDigest{}, err
+	}
+	return fileDigest{rel: filepath.Base(dst), sum: sum, size: n}, nil
+}
+
+// verifyCopiedFile re-reads dst and removes it unless its SHA-256 is want
+func verifyCopiedFile(dst, want string) error {
+	sum, err := sha256File(dst)
+	if err == nil && sum != want {
+		err = fmt.Errorf("verification failed for %s: source sha256 %s, destination sha256 %s", dst, want, sum)
+	}
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return nil
+}
+
+// sha256File is the hex SHA-256 of a file's content
+func sha256File(path string) (string, error) {
+	hashes, err := calculateChecksums(path, []string{"sha256"})
+	if err != nil {
+		return "", err
+	}
+	return hashes["sha256"], nil
+}
+
+// copyTreeChecked copies a file, or a directory recursively, to dst. A
+// directory is copied into a new dst, which is removed again if the copy
+// fails. Inside a directory only regular files are copied: symlinks, special
+// files and denied paths are skipped and counted.
+func (fs *FilesystemHandler) copyTreeChecked(ctx context.Context, src, dst string, verify bool) (*copyResult, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	result := &copyResult{dir: info.IsDir(), verified: verify}
+
+	if !info.IsDir() {
+		if err := fs.recheckPath(dst); err != nil {
+			return nil, err
+		}
+		if verify {
+			digest, err := copyFileVerified(src, dst)
+			if err != nil {
+				return nil, err
+			}
+			result.digests = append(result.digests, digest)
+			result.files, result.bytes = 1, digest.size
+		} else {
+			n, err := copyFileHash(src, dst, nil)
+			if err != nil {
+				return nil, err
+			}
+			result.files, result.bytes = 1, n
+		}
+		progressFrom(ctx).fileDone(src)
+		fs.stats.addWritten(int(result.bytes))
+		return result, nil
+	}
+
+	if pathWithinDir(dst, src) {
+		return nil, fmt.Errorf("cannot copy %s into itself", src)
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		return nil, fmt.Errorf("destination already exists: %s", dst)
+	}
+	if err := fs.mkdirAllChecked(dst, info.Mode().Perm()|0700); err != nil {
+		return nil, err
+	}
+
+	err = fs.walkContext(ctx, src, func(path string, entry os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if path == src {
+			return nil
+		}
+		if fs.isDeniedPath(path) {
+			result.skipped++
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if entry.IsDir() {
+			return os.Mkdir(target, entry.Mode().Perm()|0700)
+		}
+		if !entry.Mode().IsRegular() {
+			result.skipped++
+			return nil
+		}
+
+		if verify {
+			digest, err := copyFileVerified(path, target)
+			if err != nil {
+				return err
+			}
+			digest.rel = filepath.ToSlash(rel)
+			result.digests = append(result.digests, digest)
+			result.bytes += digest.size
+		} else {
+			n, err := copyFileHash(path, target, nil)
+			if err != nil {
+				return err
+			}
+			result.bytes += n
+		}
+		result.files++
+		progressFrom(ctx).fileDone(path)
+		return nil
+	})
+	if err != nil {
+		os.RemoveAll(dst)
+		return nil, err
+	}
+	fs.stats.addWritten(int(result.bytes))
+	return result, nil
+}
+
+// moveChecked renames src to dst. When they are on different devices it
+// copies instead and deletes src only after the copy, verified if asked,
+// succeeded. With verify a same-device move reports the hashes of dst, whose
+// content the rename left untouched.
+func (fs *FilesystemHandler) moveChecked(ctx context.Context, src, dst string, verify bool) (*copyResult, error) {
+	if err := fs.recheckPath(src); err != nil {
+		return nil, err
+	}
+	if err := fs.recheckPath(dst); err != nil {
+		return nil, err
+	}
+
+	err := osRename(src, dst)
+	if err == nil {
+		if !verify {
+			return &copyResult{}, nil
+		}
+		return hashTree(dst)
+	}
+	if !isCrossDeviceError(err) {
+		return nil, err
+	}
+
+	result, err := fs.copyTreeChecked(ctx, src, dst, verify)
+	if err != nil {
+		return nil, fmt.Errorf("cross-device move: copy failed, source left in place: %w", err)
+	}
+	result.crossDevice = true
+	if result.skipped > 0 {
+		return nil, fmt.Errorf("cross-device move: %d entries could not be copied; the copy is at %s and the source was left in place", result.skipped, dst)
+	}
+	if err := fs.removeChecked(src, true); err != nil {
+		return nil, fmt.Errorf("cross-device move: copied to %s but the source could not be removed: %w", dst, err)
+	}
+	return result, nil
+}
+
+// hashTree hashes a file, or every regular file under a directory
+func hashTree(root string) (*copyResult, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	result := &copyResult{dir: info.IsDir()}
+	err = filepath.Walk(root, func(path string, entry os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Mode().IsRegular() {
+			return nil
+		}
+		sum, err := sha256File(path)
+		if err != nil {
+			return err
+		}
+		rel := filepath.Base(path)
+		if info.IsDir() {
+			rel, _ = filepath.Rel(root, path)
+			rel = filepath.ToSlash(rel)
+		}
+		result.digests = append(result.digests, fileDigest{rel: rel, sum: sum, size: entry.Size()})
+		result.files++
+		result.bytes += entry.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// isCrossDeviceError reports whether a rename failed because source and
+// destination are on different devices or volumes
+func isCrossDeviceError(err error) bool {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	// ERROR_NOT_SAME_DEVICE on Windows
+	return errno == syscall.EXDEV || windowsPaths && errno == 17
+}
+
+// summary describes the copy or move for the tool output: counts and, with
+// verify, one "sha256  path" line per file
+func (r *copyResult) summary() string {
+	var b strings.Builder
+	if r.crossDevice {
+		b.WriteString("🚚 Different devices: copied, then removed the source\n")
+	}
+	if r.dir {
+		fmt.Fprintf(&b, "📦 %d files, %d bytes\n", r.files, r.bytes)
+	}
+	if r.skipped > 0 {
+		fmt.Fprintf(&b, "⚠️ Skipped %d symlinks, special files or denied paths\n", r.skipped)
+	}
+	if len(r.digests) == 0 {
+		return b.String()
+	}
+	label := "SHA-256 verified"
+	if !r.verified {
+		label = "SHA-256 (renamed in place, content untouched)"
+	}
+	if !r.dir {
+		fmt.Fprintf(&b, "🔐 %s: %s (%d bytes)\n", label, r.digests[0].sum, r.digests[0].size)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "🔐 %s for %d files:\n", label, len(r.digests))
+	for i, digest := range r.digests {
+		if i == maxListedDigests {
+			fmt.Fprintf(&b, "... and %d more\n", len(r.digests)-i)
+			break
+		}
+		fmt.Fprintf(&b, "%s  %s\n", digest.sum, safeDisplayName(digest.rel))
+	}
+	return b.String()
+}
+
+// batchNote is the one-line version of summary for batch_operations results
+func (r *copyResult) batchNote() string {
+	var notes []string
+	if r.crossDevice {
+		notes = append(notes, "across devices")
+	}
+	switch {
+	case len(r.digests) == 1 && !r.dir:
+		notes = append(notes, "sha256 "+r.digests[0].sum)
+	case len(r.digests) > 0:
+		notes = append(notes, fmt.Sprintf("sha256 of %d files checked", len(r.digests)))
+	}
+	if r.skipped > 0 {
+		notes = append(notes, fmt.Sprintf("%d skipped", r.skipped))
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(notes, ", ") + ")"
+}
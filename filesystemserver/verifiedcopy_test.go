@@ -0,0 +1,185 @@
+package filesystemserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// simulateCrossDevice makes every rename fail as if source and destination
+// were on different devices
+func simulateCrossDevice(t *testing.T) {
+	t.Helper()
+	original := osRename
+	osRename = func(from, to string) error {
+		return &os.LinkError{Op: "rename", Old: from, New: to, Err: syscall.EXDEV}
+	}
+	t.Cleanup(func() { osRename = original })
+}
+
+func callText(t *testing.T, handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), name string, args map[string]interface{}) (string, bool) {
+	t.Helper()
+	result, err := handle(context.Background(), newToolRequest(name, args))
+	require.NoError(t, err)
+	return result.Content[0].(mcp.TextContent).Text, result.IsError
+}
+
+func TestCopyFileVerify(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	source := filepath.Join(dir, "data.bin")
+	require.NoError(t, os.WriteFile(source, []byte("payload"), 0644))
+
+	text, isError := callText(t, handler.handleCopyFile, "copy_file", map[string]interface{}{
+		"source": source, "destination": filepath.Join(dir, "copy.bin"), "verify": true,
+	})
+	require.False(t, isError, text)
+	assert.Contains(t, text, "🔐 SHA-256 verified: "+sha256Hex("payload")+" (7 bytes)")
+
+	text, isError = callText(t, handler.handleCopyFile, "copy_file", map[string]interface{}{
+		"source": source, "destination": filepath.Join(dir, "plain.bin"),
+	})
+	require.False(t, isError, text)
+	assert.NotContains(t, text, "SHA-256")
+}
+
+func TestCopyDirectoryVerify(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	tree := filepath.Join(dir, "tree")
+	writeFixture(t, tree, map[string]string{
+		"a.txt":       "alpha",
+		"sub/b.txt":   "beta",
+		"sub/.env":    "SECRET=1",
+		"empty/.keep": "",
+	})
+	if runtime.GOOS != "windows" {
+		require.NoError(t, os.Symlink(filepath.Join(tree, "a.txt"), filepath.Join(tree, "link.txt")))
+	}
+
+	dest := filepath.Join(dir, "tree-copy")
+	text, isError := callText(t, handler.handleCopyFile, "copy_file", map[string]interface{}{
+		"source": tree, "destination": dest, "verify": true,
+	})
+	require.False(t, isError, text)
+	assert.Contains(t, text, "📦 3 files, 9 bytes")
+	assert.Contains(t, text, "🔐 SHA-256 verified for 3 files:")
+	assert.Contains(t, text, "\n"+sha256Hex("alpha")+"  a.txt")
+	assert.Contains(t, text, "\n"+sha256Hex("beta")+"  sub/b.txt")
+	assert.Contains(t, text, "\n"+sha256Hex("")+"  empty/.keep")
+	assert.Contains(t, text, "⚠️ Skipped")
+
+	data, err := os.ReadFile(filepath.Join(dest, "sub", "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "beta", string(data))
+	assert.NoFileExists(t, filepath.Join(dest, "sub", ".env"), "denied files are not copied")
+	assert.NoFileExists(t, filepath.Join(dest, "link.txt"))
+
+	for _, destination := range []string{dest, filepath.Join(tree, "sub", "nested")} {
+		text, isError = callText(t, handler.handleCopyFile, "copy_file", map[string]interface{}{
+			"source": tree, "destination": destination,
+		})
+		assert.True(t, isError, text)
+	}
+	assert.NoDirExists(t, filepath.Join(tree, "sub", "nested"))
+}
+
+func TestVerifyCopiedFileRemovesMismatch(t *testing.T) {
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "copy.txt")
+	require.NoError(t, os.WriteFile(dst, []byte("corrupted"), 0644))
+
+	err := verifyCopiedFile(dst, sha256Hex("original"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "verification failed")
+	assert.Contains(t, err.Error(), sha256Hex("corrupted"))
+	assert.NoFileExists(t, dst)
+
+	require.NoError(t, os.WriteFile(dst, []byte("original"), 0644))
+	require.NoError(t, verifyCopiedFile(dst, sha256Hex("original")))
+	assert.FileExists(t, dst)
+}
+
+func TestMoveFileVerify(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	source := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(source, []byte("alpha"), 0644))
+
+	text, isError := callText(t, handler.handleMoveFile, "move_file", map[string]interface{}{
+		"source": source, "destination": filepath.Join(dir, "b.txt"), "verify": true,
+	})
+	require.False(t, isError, text)
+	assert.Contains(t, text, "🔐 SHA-256 (renamed in place, content untouched): "+sha256Hex("alpha"))
+	assert.NotContains(t, text, "Different devices")
+	assert.NoFileExists(t, source)
+}
+
+func TestMoveAcrossDevices(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	simulateCrossDevice(t)
+
+	source := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(source, []byte("alpha"), 0644))
+	text, isError := callText(t, handler.handleMoveFile, "move_file", map[string]interface{}{
+		"source": source, "destination": filepath.Join(dir, "moved.txt"), "verify": true,
+	})
+	require.False(t, isError, text)
+	assert.Contains(t, text, "🚚 Different devices")
+	assert.Contains(t, text, "🔐 SHA-256 verified: "+sha256Hex("alpha"))
+	assert.NoFileExists(t, source)
+	assert.FileExists(t, filepath.Join(dir, "moved.txt"))
+
+	tree := filepath.Join(dir, "tree")
+	writeFixture(t, tree, map[string]string{"one.txt": "1", "sub/two.txt": "2"})
+	text, isError = callText(t, handler.handleMoveFile, "move_file", map[string]interface{}{
+		"source": tree, "destination": filepath.Join(dir, "tree2"),
+	})
+	require.False(t, isError, text)
+	assert.Contains(t, text, "📦 2 files, 2 bytes")
+	assert.NoDirExists(t, tree)
+	assert.FileExists(t, filepath.Join(dir, "tree2", "sub", "two.txt"))
+
+	// Lo que no se pudo copiar deja el origen intacto
+	withSecret := filepath.Join(dir, "secret")
+	writeFixture(t, withSecret, map[string]string{"keep.txt": "k", ".env": "TOKEN=1"})
+	text, isError = callText(t, handler.handleMoveFile, "move_file", map[string]interface{}{
+		"source": withSecret, "destination": filepath.Join(dir, "secret2"), "verify": true,
+	})
+	assert.True(t, isError)
+	assert.Contains(t, text, "source was left in place")
+	assert.FileExists(t, filepath.Join(withSecret, ".env"))
+	assert.FileExists(t, filepath.Join(withSecret, "keep.txt"))
+}
+
+func TestBatchCopyVerify(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	source := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(source, []byte("alpha"), 0644))
+
+	result, err := handler.handleBatchEdit(context.Background(), newToolRequest("batch_operations", map[string]interface{}{
+		"operations": []interface{}{
+			map[string]interface{}{"type": "copy", "from": source, "to": filepath.Join(dir, "b.txt"), "verify": true},
+			map[string]interface{}{"type": "move", "from": filepath.Join(dir, "b.txt"), "to": filepath.Join(dir, "c.txt")},
+		},
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	var batch BatchResult
+	decodeStructured(t, result, &batch)
+	require.Equal(t, 2, batch.Successful)
+	assert.Contains(t, batch.Operations[0].Message, "(sha256 "+sha256Hex("alpha")+")")
+	assert.NotContains(t, batch.Operations[1].Message, "sha256")
+	assert.FileExists(t, filepath.Join(dir, "c.txt"))
+}
@@ -0,0 +1,113 @@
+package filesystemserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Walkers skip the entries they cannot read (permission denied, removed
+// between listing and stat, broken mounts) and carry on. So that a search of
+// a half-readable tree doesn't pass for a clean "no matches", the tools that
+// report on a whole tree collect those skips for the call and end their text
+// with a warnings section; their JSON output carries the full list.
+
+// maxWalkWarnings bounds the skipped entries kept per call; the count goes on
+const maxWalkWarnings = 1000
+
+// walkWarningExamples is how many skipped entries the warnings section lists
+const walkWarningExamples = 5
+
+// WalkWarning is an entry a walk skipped because it could not be read
+type WalkWarning struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// walkWarnings collects the entries skipped during one call
+type walkWarnings struct {
+	mu    sync.Mutex
+	count int
+	seen  map[string]bool
+	list  []WalkWarning
+}
+
+type walkWarningsKey struct{}
+
+// withWalkWarnings returns ctx collecting walk errors for the call, reusing
+// the collector ctx already carries
+func withWalkWarnings(ctx context.Context) (context.Context, *walkWarnings) {
+	if warnings := walkWarningsFrom(ctx); warnings != nil {
+		return ctx, warnings
+	}
+	warnings := &walkWarnings{seen: make(map[string]bool)}
+	return context.WithValue(ctx, walkWarningsKey{}, warnings), warnings
+}
+
+// walkWarningsFrom returns the collector of ctx, or nil
+func walkWarningsFrom(ctx context.Context) *walkWarnings {
+	warnings, _ := ctx.Value(walkWarningsKey{}).(*walkWarnings)
+	return warnings
+}
+
+// add records one skipped path; a path reported twice counts once
+func (w *walkWarnings) add(path string, err error) {
+	if w == nil {
+		return
+	}
+	// The path is already known: keep only the reason
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.seen[path] {
+		return
+	}
+	w.seen[path] = true
+	w.count++
+	if len(w.list) < maxWalkWarnings {
+		w.list = append(w.list, WalkWarning{Path: path, Error: err.Error()})
+	}
+}
+
+// entries returns the recorded warnings, nil when nothing was skipped
+func (w *walkWarnings) entries() []WalkWarning {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]WalkWarning(nil), w.list...)
+}
+
+// note returns the warnings section of a text result, or "" when nothing was
+// skipped. display formats the paths; nil shows them as they are.
+func (w *walkWarnings) note(display func(string) string) string {
+	if w == nil {
+		return ""
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.count == 0 {
+		return ""
+	}
+	if display == nil {
+		display = func(path string) string { return path }
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n⚠️ Warnings: %d paths could not be read; results may be incomplete\n", w.count)
+	for _, warning := range w.list[:min(len(w.list), walkWarningExamples)] {
+		fmt.Fprintf(&b, "  🚫 %s: %s\n", safeDisplayName(display(warning.Path)), warning.Error)
+	}
+	if w.count > walkWarningExamples {
+		fmt.Fprintf(&b, "  … and %d more\n", w.count-walkWarningExamples)
+	}
+	return b.String()
+}
@@ -0,0 +1,45 @@
+package filesystemserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalkWarnings(t *testing.T) {
+	handler, dir := newTestHandler(t)
+
+	// Sin colector, logWalkError solo registra en el log
+	handler.logWalkError(context.Background(), dir, os.ErrPermission)
+	var none *walkWarnings
+	assert.Empty(t, none.note(nil))
+	assert.Nil(t, none.entries())
+
+	ctx, warnings := withWalkWarnings(context.Background())
+	same, reused := withWalkWarnings(ctx)
+	assert.Equal(t, ctx, same)
+	assert.Same(t, warnings, reused, "nested calls share the collector")
+	assert.Empty(t, warnings.note(nil))
+
+	for i := 0; i < walkWarningExamples+3; i++ {
+		path := fmt.Sprintf("%s/locked%d", dir, i)
+		handler.logWalkError(ctx, path, &os.PathError{Op: "open", Path: path, Err: os.ErrPermission})
+	}
+	handler.logWalkError(ctx, dir+"/locked0", os.ErrPermission)
+
+	entries := warnings.entries()
+	require.Len(t, entries, walkWarningExamples+3, "a path reported twice counts once")
+	assert.Equal(t, WalkWarning{Path: dir + "/locked0", Error: "permission denied"}, entries[0])
+
+	note := warnings.note(nil)
+	assert.Contains(t, note, "⚠️ Warnings: 8 paths could not be read")
+	assert.Contains(t, note, "🚫 "+dir+"/locked4: permission denied")
+	assert.NotContains(t, note, "locked5")
+	assert.Contains(t, note, "… and 3 more")
+	assert.Equal(t, walkWarningExamples+3, strings.Count(note, "\n"))
+}
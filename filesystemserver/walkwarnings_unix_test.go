@@ -0,0 +1,77 @@
+//go:build linux || darwin || freebsd
+
+package filesystemserver
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lockedTree creates matching files in src/open and src/locked and makes
+// src/locked unreadable, skipping the test when permissions are not enforced
+func lockedTree(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	writeFixture(t, dir, map[string]string{
+		"src/open/needle.txt":   "needle here\n",
+		"src/open/copy.txt":     "needle here\n",
+		"src/locked/needle.txt": "needle here\n",
+	})
+	src := filepath.Join(dir, "src")
+	locked := filepath.Join(src, "locked")
+	require.NoError(t, os.Chmod(locked, 0000))
+	t.Cleanup(func() { os.Chmod(locked, 0755) })
+	if _, err := os.ReadDir(locked); err == nil {
+		t.Skip("permissions are not enforced for this user")
+	}
+	return src, locked
+}
+
+func TestWalkWarningsUnreadableDirectory(t *testing.T) {
+	handler, dir := newTestHandler(t)
+	src, locked := lockedTree(t, dir)
+	warning := "⚠️ Warnings: 1 paths could not be read"
+
+	tools := []struct {
+		name   string
+		handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
+		args   map[string]interface{}
+		found  string
+	}{
+		{"smart_search", handler.handleSmartSearch, map[string]interface{}{"pattern": "needle", "include_content": true}, "open/needle.txt"},
+		{"search_files", handler.handleSearchFiles, map[string]interface{}{"pattern": "needle"}, "open/needle.txt"},
+		{"advanced_text_search", handler.handleAdvancedTextSearch, map[string]interface{}{"pattern": "needle"}, "open/needle.txt"},
+		{"tree", handler.handleTree, map[string]interface{}{}, "needle.txt"},
+		{"find_duplicates", handler.handleFindDuplicates, map[string]interface{}{}, "open/copy.txt"},
+		{"analyze_project", handler.handleAnalyzeProject, map[string]interface{}{}, "Total Files:** 2"},
+	}
+	for _, tool := range tools {
+		t.Run(tool.name, func(t *testing.T) {
+			tool.args["path"] = src
+			text, isError := callText(t, tool.handle, tool.name, tool.args)
+			require.False(t, isError, text)
+			assert.Contains(t, text, tool.found, "matches elsewhere are still found")
+			assert.Contains(t, text, warning)
+			assert.Contains(t, text, "locked: permission denied")
+		})
+	}
+
+	// La salida JSON lleva la lista completa
+	text, isError := callText(t, handler.handleFindDuplicates, "find_duplicates", map[string]interface{}{"path": src, "output": "json"})
+	require.False(t, isError, text)
+	var report DuplicateReport
+	require.NoError(t, json.Unmarshal([]byte(text), &report))
+	assert.Equal(t, []WalkWarning{{Path: locked, Error: "permission denied"}}, report.Warnings)
+
+	// smart_sync copia lo legible y avisa de lo demás
+	target := filepath.Join(dir, "dst")
+	text, _ = callText(t, handler.handleSmartSync, "smart_sync", map[string]interface{}{"source": src, "target": target, "mode": "merge"})
+	assert.Contains(t, text, warning)
+	assert.FileExists(t, filepath.Join(target, "open", "needle.txt"))
+}
@@ -4,8 +4,10 @@ go 1.23.2
 
 require (
 	github.com/gabriel-vasile/mimetype v1.4.3
-	github.com/mark3labs/mcp-go v0.26.0
+	github.com/mark3labs/mcp-go v0.27.0
 	github.com/stretchr/testify v1.10.0
+	golang.org/x/sys v0.30.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -15,5 +17,4 @@ require (
 	github.com/spf13/cast v1.7.1 // indirect
 	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
 	golang.org/x/net v0.21.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -4,30 +4,84 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/scopweb/mcp-filesystem-server/filesystemserver"
 	"github.com/mark3labs/mcp-go/server"
 )
 
 func main() {
-	// Parse command line arguments
-	if len(os.Args) < 2 {
+	// Parse command line arguments: flags first, then allowed directories
+	var dirs []string
+	var opts []filesystemserver.HandlerOption
+	var allowTools, denyTools []string
+	configDirs := 0
+	labels := make(map[string]string)
+	for _, arg := range os.Args[1:] {
+		switch {
+		case strings.HasPrefix(arg, "--config="):
+			config, err := filesystemserver.LoadConfig(strings.TrimPrefix(arg, "--config="))
+			if err != nil {
+				log.Fatalf("Failed to load config: %v", err)
+			}
+			configDirs = len(config.AllowedDirs)
+			// Before the flags, so the command line overrides the file
+			opts = append([]filesystemserver.HandlerOption{filesystemserver.WithOptions(config)}, opts...)
+		case arg == "--read-only":
+			opts = append(opts, filesystemserver.WithReadOnly(true))
+		case strings.HasPrefix(arg, "--allow-tools="):
+			allowTools = append(allowTools, strings.Split(strings.TrimPrefix(arg, "--allow-tools="), ",")...)
+		case strings.HasPrefix(arg, "--deny-tools="):
+			denyTools = append(denyTools, strings.Split(strings.TrimPrefix(arg, "--deny-tools="), ",")...)
+		case strings.HasPrefix(arg, "--deny-patterns="):
+			opts = append(opts, filesystemserver.WithDenyPatterns(strings.Split(strings.TrimPrefix(arg, "--deny-patterns="), ",")...))
+		case arg == "--no-default-deny":
+			opts = append(opts, filesystemserver.WithoutDefaultDenyPatterns())
+		case strings.HasPrefix(arg, "--workspace="):
+			opts = append(opts, filesystemserver.WithWorkspace(strings.TrimPrefix(arg, "--workspace=")))
+		case strings.HasPrefix(arg, "--important-files="):
+			opts = append(opts, filesystemserver.WithImportantFiles(strings.Split(strings.TrimPrefix(arg, "--important-files="), ",")...))
+		default:
+			// name=dir labels an allowed directory (name:relative/path)
+			if label, dir, ok := strings.Cut(arg, "="); ok && label != "" && !strings.ContainsAny(label, `/\`) {
+				labels[dir] = label
+				arg = dir
+			}
+			dirs = append(dirs, arg)
+		}
+	}
+	if len(labels) > 0 {
+		opts = append(opts, filesystemserver.WithRootLabels(labels))
+	}
+	if len(allowTools) > 0 || len(denyTools) > 0 {
+		opts = append(opts, filesystemserver.WithToolPolicy(allowTools, denyTools))
+	}
+
+	if len(dirs) == 0 && configDirs == 0 {
 		fmt.Fprintf(
 			os.Stderr,
-			"Usage: %s <allowed-directory> [additional-directories...]\n",
+			"Usage: %s [--config=file.yaml] [--read-only] [--allow-tools=a,b] [--deny-tools=a,b] [--deny-patterns=g1,g2] [--no-default-deny] [--workspace=dir] [--important-files=category:glob,...] [name=]<allowed-directory> [[name=]additional-directories...]\n",
 			os.Args[0],
 		)
 		os.Exit(1)
 	}
 
 	// Create and start the server
-	fss, err := filesystemserver.NewFilesystemServer(os.Args[1:])
+	fss, handler, err := filesystemserver.NewServer(
+		filesystemserver.WithAllowedDirectories(dirs...),
+		filesystemserver.WithHandlerOptions(opts...),
+	)
 	if err != nil {
 		log.Fatalf("Failed to create server: %v", err)
 	}
 
-	// Serve requests
-	if err := server.ServeStdio(fss); err != nil {
-		log.Fatalf("Server error: %v", err)
+	// Serve requests until stdin closes or a signal arrives, then let running
+	// tools finish and remove the temporary files they leave behind
+	serveErr := server.ServeStdio(fss)
+	if err := handler.Shutdown(filesystemserver.DefaultShutdownTimeout); err != nil {
+		log.Printf("Shutdown: %v", err)
+	}
+	if serveErr != nil {
+		log.Fatalf("Server error: %v", serveErr)
 	}
 }
\ No newline at end of file